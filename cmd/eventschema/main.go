@@ -0,0 +1,29 @@
+// Command eventschema writes the JSON Schema of the realtime event payloads, for the app teams' code generation.
+//
+//	go run ./cmd/eventschema -o docs/events.schema.json
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+
+	"instant-hdr-backend/internal/events"
+)
+
+func main() {
+	output := flag.String("o", "", "File to write (default: standard output)")
+	flag.Parse()
+
+	schema, err := events.JSONSchema()
+	if err != nil {
+		log.Fatalf("Failed to generate event schema: %v", err)
+	}
+	if *output == "" {
+		os.Stdout.Write(schema)
+		return
+	}
+	if err := os.WriteFile(*output, schema, 0644); err != nil {
+		log.Fatalf("Failed to write event schema: %v", err)
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/google/uuid"
+	"instant-hdr-backend/internal/services"
+)
+
+// runConsistencyCommand implements `server consistency [-repair] [-user <uuid>] [-min-age 1h] [-json]`.
+// It returns the process exit code: 0 when storage and database agree (or everything was repaired), 1 otherwise.
+func runConsistencyCommand(args []string, checker *services.ConsistencyChecker) int {
+	flags := flag.NewFlagSet("consistency", flag.ExitOnError)
+	repair := flags.Bool("repair", false, "repair the issues found (default is a dry run)")
+	user := flags.String("user", "", "limit the check to one user ID")
+	minAge := flags.Duration("min-age", services.DefaultMinObjectAge, "ignore objects modified more recently than this")
+	asJSON := flags.Bool("json", false, "print the full report as JSON")
+	flags.Parse(args)
+
+	opts := services.ConsistencyOptions{
+		Repair:       *repair,
+		MinObjectAge: *minAge,
+	}
+	if *user != "" {
+		userID, err := uuid.Parse(*user)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "invalid -user: %v\n", err)
+			return 2
+		}
+		opts.UserID = uuid.NullUUID{UUID: userID, Valid: true}
+	}
+
+	report := checker.Check(opts)
+
+	if *asJSON {
+		out, _ := json.MarshalIndent(report, "", "  ")
+		fmt.Println(string(out))
+	} else {
+		for _, issue := range report.Issues {
+			status := "would " + issue.Action
+			if issue.Action == services.ActionNone {
+				status = "needs attention"
+			} else if issue.Repaired {
+				status = "repaired (" + issue.Action + ")"
+			} else if !report.DryRun {
+				status = "not repaired"
+			}
+			subject := issue.StoragePath
+			if subject == "" {
+				subject = "bracket " + issue.BracketID
+			}
+			fmt.Printf("%-16s %s [%s] %s\n", issue.Kind, subject, status, issue.Detail)
+		}
+		for _, e := range report.Errors {
+			fmt.Fprintf(os.Stderr, "error: %s\n", e)
+		}
+		mode := "dry run"
+		if !report.DryRun {
+			mode = "repair"
+		}
+		fmt.Printf("Checked %d orders, %d rows, %d objects (%s): %d issues, %d repaired, %d errors\n",
+			report.OrdersChecked, report.RowsChecked, report.ObjectsChecked, mode,
+			len(report.Issues), report.Repaired, len(report.Errors))
+	}
+
+	if len(report.Errors) > 0 || len(report.Issues) > report.Repaired {
+		return 1
+	}
+	return 0
+}
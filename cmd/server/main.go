@@ -14,22 +14,30 @@
 // @securityDefinitions.apikey Bearer
 // @in header
 // @name Authorization
-// @description Type "Bearer" followed by a space and JWT token.
+// @description Type "Bearer" followed by a space and a JWT token or an API key (ihdr_...).
 
 package main
 
 import (
 	"log"
 	"net/http"
+	"os"
 
 	"instant-hdr-backend/docs"
 	"instant-hdr-backend/internal/autoenhance"
 	"instant-hdr-backend/internal/config"
+	"instant-hdr-backend/internal/credits"
 	"instant-hdr-backend/internal/database"
+	"instant-hdr-backend/internal/events"
 	"instant-hdr-backend/internal/handlers"
 	_ "instant-hdr-backend/internal/imagen" // Kept for reference, not used
 	"instant-hdr-backend/internal/middleware"
+	"instant-hdr-backend/internal/models"
+	"instant-hdr-backend/internal/quota"
+	"instant-hdr-backend/internal/ratelimit"
+	"instant-hdr-backend/internal/repository"
 	"instant-hdr-backend/internal/services"
+	"instant-hdr-backend/internal/storage"
 	"instant-hdr-backend/internal/supabase"
 	"net/url"
 
@@ -67,8 +75,23 @@ func main() {
 
 	// Database connection string
 	dbURL := cfg.DatabaseURL
+
+	// `server migrate ...` manages the schema and exits without starting the server
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if dbURL == "" {
+			log.Fatalf("migrate requires DATABASE_URL")
+		}
+		migrator, err := database.NewMigrator(dbURL)
+		if err != nil {
+			log.Fatalf("Failed to initialize migrator: %v", err)
+		}
+		migrator.LockTimeout = cfg.MigrationLockTimeout
+		code := runMigrateCommand(os.Args[2:], migrator)
+		migrator.Close()
+		os.Exit(code)
+	}
 	if dbURL == "" {
-		log.Println("Warning: DATABASE_URL not set. Migrations will be skipped.")
+		log.Println("Warning: DATABASE_URL not set. Using the in-memory repository - all data is lost on restart.")
 		log.Println("Please set DATABASE_URL environment variable with your Supabase PostgreSQL connection string")
 	}
 
@@ -88,19 +111,57 @@ func main() {
 		log.Fatalf("Failed to initialize Supabase client: %v", err)
 	}
 
-	// Storage client: Choose between RLS (publishable key) or service role key based on config
-	var storageKey string
-	if cfg.SupabaseUseRLS {
-		log.Println("Using Supabase Storage with RLS (publishable key) - More secure")
-		storageKey = cfg.SupabasePublishableKey
-	} else {
-		log.Println("Using Supabase Storage with Service Role Key - Bypasses RLS")
-		storageKey = cfg.SupabaseServiceRoleKey
+	// Storage backend: Supabase (default), local filesystem or S3-compatible
+	var storageClient storage.Storage
+	var localStorage *storage.LocalStorage
+	switch cfg.StorageBackend {
+	case storage.BackendLocal:
+		log.Printf("Using local filesystem storage at %s", cfg.LocalStoragePath)
+		localStorage, err = storage.NewLocalStorage(cfg.LocalStoragePath, cfg.LocalStorageURL)
+		if err != nil {
+			log.Fatalf("Failed to initialize local storage: %v", err)
+		}
+		storageClient = localStorage
+	case storage.BackendS3:
+		log.Printf("Using S3-compatible storage: endpoint=%s, bucket=%s", cfg.S3Endpoint, cfg.S3Bucket)
+		s3Storage, err := storage.NewS3Storage(storage.S3Config{
+			Endpoint:        cfg.S3Endpoint,
+			Region:          cfg.S3Region,
+			Bucket:          cfg.S3Bucket,
+			AccessKeyID:     cfg.S3AccessKeyID,
+			SecretAccessKey: cfg.S3SecretAccessKey,
+			ForcePathStyle:  cfg.S3ForcePathStyle,
+			PublicURL:       cfg.S3PublicURL,
+		})
+		if err != nil {
+			log.Fatalf("Failed to initialize S3 storage: %v", err)
+		}
+		storageClient = s3Storage
+	default:
+		// Storage client: Choose between RLS (publishable key) or service role key based on config
+		var storageKey string
+		if cfg.SupabaseUseRLS {
+			log.Println("Using Supabase Storage with RLS (publishable key) - More secure")
+			storageKey = cfg.SupabasePublishableKey
+		} else {
+			log.Println("Using Supabase Storage with Service Role Key - Bypasses RLS")
+			storageKey = cfg.SupabaseServiceRoleKey
+		}
+		supabaseStorage, err := supabase.NewStorageClient(cfg.SupabaseURL, storageKey, cfg.SupabaseStorageBucket)
+		if err != nil {
+			log.Fatalf("Failed to initialize storage client: %v", err)
+		}
+		storageClient = supabaseStorage
 	}
-	storageClient, err := supabase.NewStorageClient(cfg.SupabaseURL, storageKey, cfg.SupabaseStorageBucket)
-	if err != nil {
-		log.Fatalf("Failed to initialize storage client: %v", err)
+
+	// Private bucket mode: persist storage paths only and hand out signed URLs
+	if cfg.StoragePrivate {
+		log.Printf("Storage is private - serving signed URLs (TTL %s)", cfg.SignedURLTTL)
+		if localStorage != nil {
+			localStorage.RequireSignedURLs([]byte(cfg.StorageSigningKey))
+		}
 	}
+	urlResolver := storage.NewURLResolver(storageClient, cfg.StoragePrivate, cfg.SignedURLTTL)
 
 	// Use service role key for Realtime (server-side publishing)
 	// Service role key bypasses RLS and is required for server-side broadcast
@@ -109,24 +170,40 @@ func main() {
 	}
 	realtimeClient := supabase.NewRealtimeClient(supabaseClient.Supabase, cfg.SupabaseURL, cfg.SupabaseServiceRoleKey)
 
-	// Create database client for direct queries
-	var dbClient *supabase.DatabaseClient
-	if dbURL != "" {
-		var err error
-		dbClient, err = supabase.NewDatabaseClient(dbURL)
+	// SSE subscribers, connected to the other replicas below; the event dispatcher also broadcasts through Supabase
+	eventBus := events.NewBus(cfg.EventsHistory)
+
+	// Repository: Postgres when DATABASE_URL is set, in memory otherwise (local development and tests).
+	// If Postgres is configured but unreachable, dbClient stays nil and handlers report the database as unavailable.
+	var dbClient repository.Repository
+	if dbURL == "" {
+		dbClient = repository.NewMemory()
+	} else {
+		postgres, err := repository.NewPostgres(dbURL)
 		if err != nil {
+			if cfg.MigrationFailureFatal {
+				log.Fatalf("Failed to initialize database client, migrations cannot run: %v", err)
+			}
 			log.Printf("Warning: Failed to initialize database client: %v", err)
 			log.Println("Database operations will be limited. Please configure DATABASE_URL properly.")
 		} else {
+			dbClient = postgres
 			defer dbClient.Close()
 
-			// Run migrations
+			// Run migrations (an advisory lock keeps concurrent replicas from racing)
 			migrator, err := database.NewMigrator(dbURL)
 			if err != nil {
+				if cfg.MigrationFailureFatal {
+					log.Fatalf("Failed to initialize migrator: %v", err)
+				}
 				log.Printf("Warning: Failed to initialize migrator: %v", err)
 			} else {
 				defer migrator.Close()
+				migrator.LockTimeout = cfg.MigrationLockTimeout
 				if err := migrator.Run(); err != nil {
+					if cfg.MigrationFailureFatal {
+						log.Fatalf("Migration failed: %v", err)
+					}
 					log.Printf("Warning: Migration failed: %v", err)
 				} else {
 					log.Println("Migrations completed successfully")
@@ -135,19 +212,110 @@ func main() {
 		}
 	}
 
+	// Maintenance subcommands share the storage and database setup above and exit before the server starts
+	if len(os.Args) > 1 && os.Args[1] == "consistency" {
+		if dbURL == "" || dbClient == nil {
+			log.Fatalf("consistency check requires a reachable DATABASE_URL")
+		}
+		os.Exit(runConsistencyCommand(os.Args[2:], services.NewConsistencyChecker(dbClient, storageClient)))
+	}
+
+	// Quotas (usage is reported even when limits are not enforced)
+	var quotaService *quota.Service
+	if dbClient != nil {
+		quotaService = quota.NewService(dbClient, cfg.QuotaEnabled, cfg.QuotaDefaultPlan)
+		if cfg.QuotaEnabled {
+			log.Printf("Quotas enforced (default plan: %s)", cfg.QuotaDefaultPlan)
+		}
+	}
+
+	// Credits for unwatermarked downloads (spends are recorded even when balances are not enforced)
+	var creditService *credits.Service
+	if dbClient != nil {
+		creditService = credits.NewService(dbClient, cfg.CreditsEnabled, cfg.CreditsMonthlyBudget)
+		if cfg.CreditsEnabled {
+			log.Printf("Credits enforced (default monthly budget: %d)", cfg.CreditsMonthlyBudget)
+		}
+	}
+
+	// Events reach the SSE subscribers of this process, or of every replica with EVENTS_TRANSPORT=database
+	var eventTransport events.Transport = events.NewMemoryTransport()
+	if cfg.EventsTransport == "database" {
+		if dbClient != nil {
+			notify, err := supabase.NewNotifyTransport(dbURL, dbClient)
+			if err != nil {
+				log.Printf("Warning: Failed to initialize the events transport, events reach this process only: %v", err)
+			} else {
+				eventTransport = notify
+			}
+		} else {
+			log.Println("Warning: Database not available, events reach this process only")
+		}
+	}
+	if err := eventBus.Connect(eventTransport); err != nil {
+		log.Printf("Warning: Failed to listen for events, events reach this process only: %v", err)
+		eventBus.Connect(events.NewMemoryTransport())
+	}
+
+	// Order events are stored in the outbox with the change they announce and dispatched from there
+	var eventDispatcher *services.EventDispatcher
+	if dbClient != nil {
+		eventDispatcher = services.NewEventDispatcher(dbClient, eventBus, realtimeClient, cfg.EventsDispatchInterval)
+		eventDispatcher.Start(make(chan struct{}))
+	}
+
 	// Initialize storage service (only if dbClient is available)
 	var storageService *services.StorageService
+	var imageService *services.ImageService
+	if dbClient != nil {
+		storageService = services.NewStorageService(autoenhanceClient, dbClient, storageClient, urlResolver, eventDispatcher)
+		imageService = services.NewImageService(autoenhanceClient, dbClient, storageClient, urlResolver, quotaService, creditService)
+	}
+
+	// Permanent order deletion (DELETE ?permanent=true and the janitor's trash purge)
+	var orderPurger *services.OrderPurger
 	if dbClient != nil {
-		storageService = services.NewStorageService(autoenhanceClient, dbClient, storageClient, realtimeClient)
+		orderPurger = services.NewOrderPurger(autoenhanceClient, dbClient, storageClient)
+	}
+
+	// Storage retention janitor (the dry-run report works even when the background janitor is disabled)
+	var janitor *services.Janitor
+	if dbClient != nil {
+		janitor = services.NewJanitor(dbClient, storageClient, orderPurger, services.RetentionPolicy{
+			PreviewDays:      cfg.RetentionPreviewDays,
+			DeletedOrderDays: cfg.RetentionDeletedOrderDays,
+		}, cfg.JanitorInterval)
+		if cfg.RetentionEnabled {
+			log.Printf("Storage janitor enabled: previews expire after %d days, trashed orders purged after %d days, every %s",
+				cfg.RetentionPreviewDays, cfg.RetentionDeletedOrderDays, cfg.JanitorInterval)
+			janitor.Start(make(chan struct{}))
+		}
 	}
 
 	// Initialize handlers (dbClient might be nil, handlers should handle this)
-	ordersHandler := handlers.NewOrdersHandler(autoenhanceClient, dbClient, storageClient)
-	uploadHandler := handlers.NewUploadHandler(autoenhanceClient, dbClient, realtimeClient)
-	processHandler := handlers.NewProcessHandler(autoenhanceClient, dbClient, realtimeClient)
+	ordersHandler := handlers.NewOrdersHandler(autoenhanceClient, dbClient, storageClient, quotaService, orderPurger)
+	uploadHandler := handlers.NewUploadHandler(autoenhanceClient, dbClient, eventDispatcher, quotaService)
+	processHandler := handlers.NewProcessHandler(autoenhanceClient, dbClient, eventDispatcher)
 	statusHandler := handlers.NewStatusHandler(dbClient, autoenhanceClient)
-	filesHandler := handlers.NewFilesHandler(dbClient, autoenhanceClient)
-	imagesHandler := handlers.NewImagesHandler(autoenhanceClient, dbClient, storageClient)
+	filesHandler := handlers.NewFilesHandler(dbClient, autoenhanceClient, urlResolver)
+	imagesHandler := handlers.NewImagesHandler(autoenhanceClient, dbClient, storageClient, urlResolver, imageService)
+	propertiesHandler := handlers.NewPropertiesHandler(dbClient)
+	organizationsHandler := handlers.NewOrganizationsHandler(dbClient)
+	tagsHandler := handlers.NewTagsHandler(autoenhanceClient, dbClient)
+	savedFiltersHandler := handlers.NewSavedFiltersHandler(dbClient)
+	apiKeysHandler := handlers.NewAPIKeysHandler(dbClient, cfg)
+	exportHandler := handlers.NewExportHandler(autoenhanceClient, dbClient, storageClient, imageService)
+	retentionHandler := handlers.NewRetentionHandler(janitor)
+	usageHandler := handlers.NewUsageHandler(quotaService)
+	creditsHandler := handlers.NewCreditsHandler(creditService)
+	auditHandler := handlers.NewAuditHandler(dbClient)
+	eventsHandler := handlers.NewEventsHandler(dbClient, eventBus, cfg.EventsHeartbeat)
+
+	var consistencyChecker *services.ConsistencyChecker
+	if dbClient != nil {
+		consistencyChecker = services.NewConsistencyChecker(dbClient, storageClient)
+	}
+	adminHandler := handlers.NewAdminHandler(consistencyChecker, autoenhanceClient, dbClient, storageService, eventDispatcher, quotaService, creditService)
 
 	// Webhook handler requires storage service
 	if storageService == nil {
@@ -156,50 +324,173 @@ func main() {
 	}
 	webhookHandler := handlers.NewWebhookHandler(cfg, storageService)
 
+	// Signing keys for asymmetric JWTs, refreshed in the background
+	var jwks *middleware.JWKS
+	if cfg.JWTJWKSURL != "" {
+		log.Printf("Verifying JWTs with keys from %s (refresh every %s, HS256 accepted: %t)", cfg.JWTJWKSURL, cfg.JWTJWKSRefresh, cfg.AcceptsHS256())
+		jwks = middleware.NewJWKS(cfg.JWTJWKSURL, cfg.JWTJWKSRefresh)
+		jwks.Start(make(chan struct{}))
+	}
+
+	// Rate limiting (counted in this process unless RATE_LIMIT_STORE=database)
+	var limiter *ratelimit.Limiter
+	if cfg.RateLimitEnabled {
+		var store ratelimit.Store = ratelimit.NewMemory()
+		if cfg.RateLimitStore == "database" {
+			if dbClient != nil {
+				store = dbClient
+			} else {
+				log.Println("Warning: Database not available, counting rate limits in memory")
+			}
+		}
+		limiter = ratelimit.NewLimiter(store)
+		limiter.Start(make(chan struct{}))
+		log.Printf("Rate limits enabled (%s): api %s, status %s, download %s, public %s, webhook %s", cfg.RateLimitStore,
+			cfg.RateLimitAPI, cfg.RateLimitStatus, cfg.RateLimitDownload, cfg.RateLimitPublic, cfg.RateLimitWebhook)
+	}
+	publicLimit := middleware.RateLimit(limiter, "public", cfg.RateLimitPublic)
+	statusLimit := middleware.RateLimit(limiter, "status", cfg.RateLimitStatus)
+	downloadLimit := middleware.RateLimit(limiter, "download", cfg.RateLimitDownload)
+
 	// Setup router
 	router := gin.Default()
+	// Without TRUSTED_PROXIES no proxy is trusted: the client IP is the remote address, so X-Forwarded-For can't
+	// dodge the per-IP limits or forge the audit log IP
+	if err := router.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
+	}
 
 	// Middleware
 	router.Use(gin.Logger())
 	router.Use(gin.Recovery())
+	router.Use(middleware.RequestID())
 
 	// Swagger documentation
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
 	// Health check (no auth) - available at root level
-	router.GET("/health", handlers.HealthHandler)
+	router.GET("/health", publicLimit, handlers.HealthHandler)
+
+	// Built-in file serving for the local storage backend
+	if localStorage != nil {
+		router.GET("/files/*filepath", gin.WrapH(http.StripPrefix("/files", localStorage.Handler())))
+	}
 
 	// API routes - public endpoints (no auth)
 	apiPublic := router.Group("/api/v1")
-	apiPublic.GET("/health", handlers.HealthHandler)
+	apiPublic.GET("/health", publicLimit, handlers.HealthHandler)
 	// Webhook endpoint (uses AutoEnhance webhook token, not JWT)
-	apiPublic.POST("/webhooks/autoenhance", webhookHandler.HandleWebhook)
+	apiPublic.POST("/webhooks/autoenhance", middleware.RateLimit(limiter, "webhook", cfg.RateLimitWebhook), webhookHandler.HandleWebhook)
 
-	// API routes - protected endpoints (with auth)
+	// API keys for server-to-server integrations (needs the database)
+	var apiKeys *middleware.APIKeys
+	if dbClient != nil {
+		apiKeys = middleware.NewAPIKeys(dbClient)
+	}
+
+	// API routes - protected endpoints (JWT or API key)
 	api := router.Group("/api/v1")
-	api.Use(middleware.AuthMiddleware(cfg))
+	api.Use(middleware.AuthMiddleware(cfg, jwks, apiKeys), middleware.RateLimit(limiter, "api", cfg.RateLimitAPI))
+
+	// Scopes an API key needs for each route; JWTs have them all. Routes in the account group don't accept API keys.
+	read := apiKeys.RequireScope(models.ScopeOrdersRead)
+	write := apiKeys.RequireScope(models.ScopeOrdersWrite)
+	download := apiKeys.RequireScope(models.ScopeImagesDownload)
+	account := api.Group("", middleware.RejectAPIKeys())
 
 	// Order routes
-	api.POST("/orders", ordersHandler.CreateOrder)
-	api.GET("/orders", ordersHandler.ListOrders)
-	api.GET("/orders/:order_id", ordersHandler.GetOrder)
-	api.GET("/orders/:order_id/verify", ordersHandler.VerifyOrderUploads) // Verify uploads with AutoEnhance
-	api.DELETE("/orders/:order_id", ordersHandler.DeleteOrder)
+	api.POST("/orders", write, ordersHandler.CreateOrder)
+	api.GET("/orders", read, ordersHandler.ListOrders)
+	api.GET("/orders/trash", read, ordersHandler.ListTrash) // Deleted orders that can still be restored
+	api.GET("/orders/:order_id", read, ordersHandler.GetOrder)
+	api.GET("/orders/:order_id/verify", read, statusLimit, ordersHandler.VerifyOrderUploads) // Verify uploads with AutoEnhance
+	api.DELETE("/orders/:order_id", write, ordersHandler.DeleteOrder)                        // Moves to the trash unless ?permanent=true
+	api.POST("/orders/:order_id/restore", write, ordersHandler.RestoreOrder)
+	api.PUT("/orders/:order_id/property", write, ordersHandler.SetOrderProperty)      // Link to (or unlink from) a property
+	account.PUT("/orders/:order_id/organization", ordersHandler.SetOrderOrganization) // Share with (or take back from) an organization
+	api.GET("/orders/:order_id/audit-log", read, auditHandler.ListOrderAuditLog)      // Who did what to the order, including downloads
+
+	// Tags - filter GET /orders and GET /orders/:order_id/images with ?tag=
+	api.POST("/orders/tags", write, tagsHandler.BulkTagOrders) // Add and remove tags on many orders
+	api.POST("/orders/:order_id/tags", write, tagsHandler.AddOrderTags)
+	api.DELETE("/orders/:order_id/tags/:tag", write, tagsHandler.RemoveOrderTag)
+	api.POST("/orders/:order_id/images/:image_id/tags", write, tagsHandler.AddImageTags)
+	api.DELETE("/orders/:order_id/images/:image_id/tags/:tag", write, tagsHandler.RemoveImageTag)
+
+	// Saved filters - apply with GET /orders?saved_filter=
+	account.POST("/saved-filters", savedFiltersHandler.CreateSavedFilter)
+	account.GET("/saved-filters", savedFiltersHandler.ListSavedFilters)
+	account.DELETE("/saved-filters/:filter_id", savedFiltersHandler.DeleteSavedFilter)
 
 	// Upload and processing
-	api.POST("/orders/:order_id/upload", uploadHandler.Upload)
-	api.POST("/orders/:order_id/process", processHandler.Process)
+	api.POST("/orders/:order_id/upload", write, uploadHandler.Upload)
+	api.POST("/orders/:order_id/process", write, processHandler.Process)
 
 	// Status and files
-	api.GET("/orders/:order_id/status", statusHandler.GetStatus)
-	api.GET("/orders/:order_id/files", filesHandler.GetFiles)                        // Processed files only
-	api.GET("/orders/:order_id/brackets", filesHandler.GetBrackets)                  // Uploaded brackets (raw images)
-	api.DELETE("/orders/:order_id/brackets/:bracket_id", filesHandler.DeleteBracket) // Delete bracket
-
-	// Images - list, download, and delete processed images
-	api.GET("/orders/:order_id/images", imagesHandler.ListImages)
-	api.POST("/orders/:order_id/images/:image_id/download", imagesHandler.DownloadImage)
-	api.DELETE("/orders/:order_id/images/:image_id", imagesHandler.DeleteImage)
+	api.GET("/orders/:order_id/status", read, statusLimit, statusHandler.GetStatus)
+	api.GET("/orders/:order_id/events", read, eventsHandler.StreamOrderEvents)              // Server-Sent Events, instead of polling /status
+	api.GET("/orders/:order_id/files", read, filesHandler.GetFiles)                         // Processed files only
+	api.POST("/orders/:order_id/files/:file_id/url", download, filesHandler.RefreshFileURL) // Re-sign a file URL
+	api.GET("/orders/:order_id/brackets", read, filesHandler.GetBrackets)                   // Uploaded brackets (raw images)
+	api.DELETE("/orders/:order_id/brackets/:bracket_id", write, filesHandler.DeleteBracket) // Delete bracket
+
+	// Images - list, download, stream, and delete processed images
+	api.GET("/orders/:order_id/images", read, imagesHandler.ListImages)
+	api.POST("/orders/:order_id/images/:image_id/download", download, downloadLimit, imagesHandler.DownloadImage)
+	api.GET("/orders/:order_id/images/:image_id/content", download, downloadLimit, imagesHandler.GetImageContent) // Streamed with Range/ETag support
+	api.DELETE("/orders/:order_id/images/:image_id", write, imagesHandler.DeleteImage)
+	api.GET("/orders/:order_id/export.zip", download, downloadLimit, exportHandler.ExportOrder) // Streamed ZIP of all images + manifest.json
+
+	// Properties - listings that group several orders
+	account.POST("/properties", propertiesHandler.CreateProperty)
+	account.GET("/properties", propertiesHandler.ListProperties)
+	account.GET("/properties/:property_id", propertiesHandler.GetProperty) // Orders and delivery summary
+	account.PATCH("/properties/:property_id", propertiesHandler.UpdateProperty)
+	account.DELETE("/properties/:property_id", propertiesHandler.DeleteProperty) // Orders are kept and unlinked
+
+	// Organizations - members share orders according to their role
+	account.POST("/organizations", organizationsHandler.CreateOrganization)
+	account.GET("/organizations", organizationsHandler.ListOrganizations)
+	account.GET("/organizations/:organization_id", organizationsHandler.GetOrganization)
+	account.PATCH("/organizations/:organization_id", organizationsHandler.UpdateOrganization)
+	account.DELETE("/organizations/:organization_id", organizationsHandler.DeleteOrganization) // Orders go back to their creators
+	account.GET("/organizations/:organization_id/members", organizationsHandler.ListMembers)
+	account.PUT("/organizations/:organization_id/members/:user_id", organizationsHandler.SetMember) // Add or change role
+	account.DELETE("/organizations/:organization_id/members/:user_id", organizationsHandler.RemoveMember)
+
+	// API keys - created and revoked with a user token only
+	account.POST("/api-keys", apiKeysHandler.CreateAPIKey)
+	account.GET("/api-keys", apiKeysHandler.ListAPIKeys)
+	account.DELETE("/api-keys/:key_id", apiKeysHandler.RevokeAPIKey)
+
+	// Storage retention
+	account.GET("/retention/report", retentionHandler.GetReport) // Dry run of the janitor for the current user
+
+	// Quota usage and credits
+	account.GET("/me/usage", usageHandler.GetUsage)
+	account.GET("/me/credits", creditsHandler.GetCredits)
+
+	// Realtime events of the current user (Server-Sent Events)
+	account.GET("/me/events", eventsHandler.StreamUserEvents)
+
+	// Audit log - actions by or concerning the current user
+	account.GET("/me/audit-log", auditHandler.ListUserAuditLog)
+
+	// Admin routes (ADMIN_USER_IDS, ADMIN_ROLE in the JWT or an API key with the admin scope); every call is audit-logged
+	admin := api.Group("/admin")
+	admin.Use(middleware.AdminMiddleware(cfg))
+	admin.GET("/consistency", adminHandler.CheckConsistency)             // Dry-run report
+	admin.POST("/consistency/repair", adminHandler.RepairConsistency)    // Repair (dry_run=true to preview)
+	admin.GET("/orders", adminHandler.SearchOrders)                      // Every user's orders
+	admin.GET("/orders/:order_id", adminHandler.GetOrder)                // Order, AutoEnhance state and brackets
+	admin.POST("/orders/:order_id/resync", adminHandler.ResyncOrder)     // Refresh cached AutoEnhance fields
+	admin.POST("/orders/:order_id/complete", adminHandler.CompleteOrder) // Re-run the processing-completed webhook
+	admin.POST("/orders/:order_id/reset-error", adminHandler.ResetOrderError)
+	admin.GET("/users/:user_id/usage", adminHandler.GetUserUsage)
+	admin.GET("/users/:user_id/credits", adminHandler.GetUserCredits)
+	admin.POST("/users/:user_id/credits", adminHandler.GrantCredits)          // Add or take back credits
+	admin.PUT("/users/:user_id/credits/budget", adminHandler.SetCreditBudget) // Monthly budget
+	admin.GET("/audit-log", adminHandler.ListAuditLog)                        // Admin actions, newest first
 
 	// Start server
 	port := cfg.Port
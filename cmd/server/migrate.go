@@ -0,0 +1,105 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+
+	"instant-hdr-backend/internal/database"
+)
+
+const migrateUsage = "usage: server migrate up | down [N] | status | verify"
+
+// runMigrateCommand implements `server migrate up|down [N]|status|verify`.
+// It returns the process exit code: 0 on success, 1 when migrating fails or verify finds a problem, 2 on bad usage.
+func runMigrateCommand(args []string, migrator *database.Migrator) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		return 2
+	}
+
+	switch args[0] {
+	case "up":
+		if err := migrator.Run(); err != nil {
+			fmt.Fprintf(os.Stderr, "migrate up failed: %v\n", err)
+			return 1
+		}
+		fmt.Println("Database is up to date")
+		return 0
+
+	case "down":
+		n := 1
+		if len(args) > 1 {
+			var err error
+			if n, err = strconv.Atoi(args[1]); err != nil || n < 1 {
+				fmt.Fprintf(os.Stderr, "invalid number of migrations %q\n%s\n", args[1], migrateUsage)
+				return 2
+			}
+		}
+		rolledBack, err := migrator.Down(n)
+		for _, name := range rolledBack {
+			fmt.Printf("Rolled back %s\n", name)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "migrate down failed: %v\n", err)
+			return 1
+		}
+		return 0
+
+	case "status", "verify":
+		statuses, err := migrator.Verify()
+		var drift *database.DriftError
+		if err != nil && !errors.As(err, &drift) {
+			fmt.Fprintf(os.Stderr, "migrate %s failed: %v\n", args[0], err)
+			return 1
+		}
+
+		pending, unknown := 0, 0
+		for _, status := range statuses {
+			state := "pending"
+			switch {
+			case status.Unknown:
+				state = "unknown"
+				unknown++
+			case status.Drifted:
+				state = "DRIFTED"
+			case status.Applied:
+				state = "applied"
+			default:
+				pending++
+			}
+			appliedAt := ""
+			if status.Applied {
+				appliedAt = status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			rollback := ""
+			if !status.Reversible && !status.Unknown {
+				rollback = "no down migration"
+			}
+			fmt.Printf("%-45s %-8s %-19s %s\n", status.Name, state, appliedAt, rollback)
+		}
+
+		if args[0] == "status" {
+			return 0
+		}
+		if drift != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", drift)
+		}
+		if pending > 0 {
+			fmt.Fprintf(os.Stderr, "%d migrations pending\n", pending)
+		}
+		if unknown > 0 {
+			fmt.Fprintf(os.Stderr, "%d applied migrations are not known to this build\n", unknown)
+		}
+		if drift != nil || pending > 0 || unknown > 0 {
+			return 1
+		}
+		fmt.Println("All migrations applied and unchanged")
+		return 0
+
+	default:
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		return 2
+	}
+}
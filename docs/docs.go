@@ -22,37 +22,14 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/health": {
-            "get": {
-                "description": "Returns the health status of the API",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "health"
-                ],
-                "summary": "Health check",
-                "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/models.HealthResponse"
-                        }
-                    }
-                }
-            }
-        },
-        "/orders": {
+        "/admin/audit-log": {
             "get": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "Returns a list of all orders for the authenticated user",
+                "description": "Returns audit log entries, newest first. Filter by who acted (actor_id), the order or the user concerned, and time.",
                 "consumes": [
                     "application/json"
                 ],
@@ -60,14 +37,59 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "orders"
+                    "admin"
+                ],
+                "summary": "Read the audit log",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Only actions by this user (UUID)",
+                        "name": "actor_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only actions on this order (UUID)",
+                        "name": "order_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only actions concerning this user (UUID)",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "At or after (RFC 3339 or YYYY-MM-DD)",
+                        "name": "since",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Before (RFC 3339 or YYYY-MM-DD)",
+                        "name": "until",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 100,
+                        "description": "Maximum number of entries (1-1000)",
+                        "name": "limit",
+                        "in": "query"
+                    }
                 ],
-                "summary": "List all orders",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.OrderListResponse"
+                            "$ref": "#/definitions/models.AuditLogResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
                     "401": {
@@ -76,6 +98,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -83,14 +111,16 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "post": {
+            }
+        },
+        "/admin/consistency": {
+            "get": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "Creates a new AutoEnhance AI order for a listing (real estate shoot). You can optionally provide a custom name for the order.",
+                "description": "Compares storage objects under users/{user_id}/orders/{order_id}/ with the order_files and brackets tables and reports\norphaned objects, dangling rows, rows with a missing storage_path/user_id, and rows or brackets whose order is gone. Nothing is changed.\nRequires an admin (see GET /admin/orders).",
                 "consumes": [
                     "application/json"
                 ],
@@ -98,24 +128,29 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "orders"
+                    "admin"
                 ],
-                "summary": "Create a new order",
+                "summary": "Storage/database consistency report",
                 "parameters": [
                     {
-                        "description": "Order name (optional, defaults to 'Order')",
-                        "name": "request",
-                        "in": "body",
-                        "schema": {
-                            "$ref": "#/definitions/models.CreateOrderRequest"
-                        }
+                        "type": "string",
+                        "description": "Limit the check to one user (UUID)",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "1h",
+                        "description": "Ignore objects modified more recently than this (Go duration)",
+                        "name": "min_age",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.OrderResponse"
+                            "$ref": "#/definitions/models.ConsistencyReport"
                         }
                     },
                     "400": {
@@ -130,6 +165,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -139,14 +180,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/orders/{order_id}": {
-            "get": {
+        "/admin/consistency/repair": {
+            "post": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "Returns detailed information about a specific order",
+                "description": "Runs the consistency check and repairs what it finds: orphaned objects are deleted, dangling rows are deleted,\nrows with a missing path are relinked to the object at the expected path (or deleted if there is none).\nPass dry_run=true to see what would be repaired without changing anything.\nRequires an admin (see GET /admin/orders).",
                 "consumes": [
                     "application/json"
                 ],
@@ -154,23 +195,36 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "orders"
+                    "admin"
                 ],
-                "summary": "Get order details",
+                "summary": "Repair storage/database inconsistencies",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Order ID (UUID)",
-                        "name": "order_id",
-                        "in": "path",
-                        "required": true
+                        "description": "Limit the repair to one user (UUID)",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "1h",
+                        "description": "Ignore objects modified more recently than this (Go duration)",
+                        "name": "min_age",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "default": false,
+                        "description": "Report only",
+                        "name": "dry_run",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.OrderResponse"
+                            "$ref": "#/definitions/models.ConsistencyReport"
                         }
                     },
                     "400": {
@@ -185,21 +239,29 @@ const docTemplate = `{
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
                     }
                 }
-            },
-            "delete": {
+            }
+        },
+        "/admin/orders": {
+            "get": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "Deletes an order, including associated AutoEnhance AI order and files from Supabase Storage",
+                "description": "Lists the orders of every user, with the filters, sorting and paging of GET /orders, optionally limited to one user.\nAdmin endpoints require a user listed in ADMIN_USER_IDS, a JWT with ADMIN_ROLE in app_metadata.role(s),\nor an API key with the admin scope. Every call is recorded in the audit log.",
                 "consumes": [
                     "application/json"
                 ],
@@ -207,26 +269,113 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "orders"
+                    "admin"
                 ],
-                "summary": "Delete an order",
+                "summary": "Search all orders",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Order ID (UUID)",
-                        "name": "order_id",
-                        "in": "path",
-                        "required": true
+                        "description": "Only orders created by this user (UUID)",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "default": false,
+                        "description": "Search the trash instead of active orders",
+                        "name": "trashed",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated statuses",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Filter by AutoEnhance processing state",
+                        "name": "is_processing",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Case-insensitive substring of the order name",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated tags; orders must carry all of them",
+                        "name": "tag",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only orders of this property",
+                        "name": "property_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only orders of this organization",
+                        "name": "organization_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Created at or after (RFC 3339 or YYYY-MM-DD)",
+                        "name": "created_after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Created before (RFC 3339 or YYYY-MM-DD)",
+                        "name": "created_before",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "created_at",
+                            "updated_at",
+                            "name",
+                            "deleted_at"
+                        ],
+                        "type": "string",
+                        "default": "created_at",
+                        "description": "Sort key",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "asc",
+                            "desc"
+                        ],
+                        "type": "string",
+                        "description": "Sort direction (default desc for dates, asc for name)",
+                        "name": "direction",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 50,
+                        "description": "Page size (1-200)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor from the previous page",
+                        "name": "cursor",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "message",
+                        "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/models.OrderListResponse"
                         }
                     },
                     "400": {
@@ -241,8 +390,8 @@ const docTemplate = `{
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
@@ -256,14 +405,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/orders/{order_id}/brackets": {
+        "/admin/orders/{order_id}": {
             "get": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "Returns a list of all uploaded brackets (raw images) for an order",
+                "description": "Returns an order of any user with its error message and metadata, the order as AutoEnhance reports it now,\nour bracket rows and the brackets AutoEnhance still has. AutoEnhance failures are reported in autoenhance_error.",
                 "consumes": [
                     "application/json"
                 ],
@@ -271,9 +420,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "brackets"
+                    "admin"
                 ],
-                "summary": "Get uploaded brackets",
+                "summary": "Inspect any order",
                 "parameters": [
                     {
                         "type": "string",
@@ -287,7 +436,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.BracketsResponse"
+                            "$ref": "#/definitions/models.AdminOrderResponse"
                         }
                     },
                     "400": {
@@ -302,6 +451,18 @@ const docTemplate = `{
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -311,14 +472,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/orders/{order_id}/brackets/{bracket_id}": {
-            "delete": {
+        "/admin/orders/{order_id}/complete": {
+            "post": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "Deletes a bracket (uploaded raw image) from AutoEnhance AI. Note: Brackets are automatically cleaned up after successful processing.",
+                "description": "Runs what the processing-completed webhook does: syncs the order from AutoEnhance, stores watermarked previews\nof the completed images and marks the order previews_ready. Use it when a webhook was missed or failed.",
                 "consumes": [
                     "application/json"
                 ],
@@ -326,9 +487,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "brackets"
+                    "admin"
                 ],
-                "summary": "Delete an uploaded bracket",
+                "summary": "Re-run completion handling",
                 "parameters": [
                     {
                         "type": "string",
@@ -336,23 +497,13 @@ const docTemplate = `{
                         "name": "order_id",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "Bracket ID from AutoEnhance",
-                        "name": "bracket_id",
-                        "in": "path",
-                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "message",
+                        "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/models.AdminOrderActionResponse"
                         }
                     },
                     "400": {
@@ -367,6 +518,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
                     "404": {
                         "description": "Not Found",
                         "schema": {
@@ -382,14 +539,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/orders/{order_id}/files": {
-            "get": {
+        "/admin/orders/{order_id}/reset-error": {
+            "post": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "Returns a list of all processed files associated with an order, including their Supabase Storage URLs",
+                "description": "Clears the order's error message and puts it back to the given status. Without one, the order becomes\npreviews_ready if it has files, uploaded if it has brackets and created otherwise.\nSubscribers of the order get an order_reset event with the new status.",
                 "consumes": [
                     "application/json"
                 ],
@@ -397,9 +554,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "files"
+                    "admin"
                 ],
-                "summary": "Get order files",
+                "summary": "Reset an order's error state",
                 "parameters": [
                     {
                         "type": "string",
@@ -407,13 +564,21 @@ const docTemplate = `{
                         "name": "order_id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "Status to go back to",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/models.ResetOrderErrorRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.FilesResponse"
+                            "$ref": "#/definitions/models.AdminOrderActionResponse"
                         }
                     },
                     "400": {
@@ -428,6 +593,18 @@ const docTemplate = `{
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -437,14 +614,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/orders/{order_id}/images": {
-            "get": {
+        "/admin/orders/{order_id}/resync": {
+            "post": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "Returns a list of all processed images for an order with their download status",
+                "description": "Fetches the order from AutoEnhance and overwrites the cached name, AutoEnhance status, processing flags and image count.",
                 "consumes": [
                     "application/json"
                 ],
@@ -452,9 +629,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "images"
+                    "admin"
                 ],
-                "summary": "List processed images",
+                "summary": "Resync an order from AutoEnhance",
                 "parameters": [
                     {
                         "type": "string",
@@ -468,7 +645,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.ImagesResponse"
+                            "$ref": "#/definitions/models.AdminOrderActionResponse"
                         }
                     },
                     "400": {
@@ -483,6 +660,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
                     "404": {
                         "description": "Not Found",
                         "schema": {
@@ -494,18 +677,24 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
                     }
                 }
             }
         },
-        "/orders/{order_id}/images/{image_id}": {
-            "delete": {
+        "/admin/users/{user_id}/credits": {
+            "get": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "Deletes a processed image from AutoEnhance AI and all associated files from Supabase Storage and database",
+                "description": "Returns what GET /me/credits returns for the given user.",
                 "consumes": [
                     "application/json"
                 ],
@@ -513,33 +702,30 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "images"
+                    "admin"
                 ],
-                "summary": "Delete a processed image",
+                "summary": "Get a user's credits",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Order ID (UUID)",
-                        "name": "order_id",
+                        "description": "User ID (UUID)",
+                        "name": "user_id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "type": "string",
-                        "description": "Image ID from AutoEnhance",
-                        "name": "image_id",
-                        "in": "path",
-                        "required": true
+                        "type": "integer",
+                        "default": 50,
+                        "description": "Maximum number of ledger entries (1-1000)",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "message",
+                        "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/models.CreditsResponse"
                         }
                     },
                     "400": {
@@ -554,8 +740,8 @@ const docTemplate = `{
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
@@ -567,16 +753,14 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/orders/{order_id}/images/{image_id}/download": {
+            },
             "post": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "Downloads a processed image from AutoEnhance and stores it in Supabase Storage.\n\nQuality Options:\n- \"thumbnail\": 400px width (~50-100KB) - List view\n- \"preview\": 800px width (~150-250KB) - Gallery view (DEFAULT)\n- \"medium\": 1920px width (~500KB-1MB) - Full screen\n- \"high\": Full resolution (~2-5MB) - Client delivery\n- \"custom\": Specify max_width or scale\n\nFormat Options: \"jpeg\" (default), \"png\", \"webp\"\n\nWatermark (defaults to true = FREE):\n- true: FREE download with watermark\n- false: COSTS 1 CREDIT (unwatermarked)",
+                "description": "Adds credits to the user's balance, or takes them back with a negative amount, and records it in their ledger.\nReturns the user's credits afterwards.",
                 "consumes": [
                     "application/json"
                 ],
@@ -584,31 +768,24 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "images"
+                    "admin"
                 ],
-                "summary": "Download processed image to Supabase Storage",
+                "summary": "Grant credits to a user",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Order ID (UUID)",
-                        "name": "order_id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "Image ID from AutoEnhance",
-                        "name": "image_id",
+                        "description": "User ID (UUID)",
+                        "name": "user_id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Download options",
+                        "description": "Credits to add",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.DownloadImageRequest"
+                            "$ref": "#/definitions/models.GrantCreditsRequest"
                         }
                     }
                 ],
@@ -616,7 +793,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.DownloadImageResponse"
+                            "$ref": "#/definitions/models.CreditsResponse"
                         }
                     },
                     "400": {
@@ -631,8 +808,8 @@ const docTemplate = `{
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
@@ -646,14 +823,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/orders/{order_id}/process": {
-            "post": {
+        "/admin/users/{user_id}/credits/budget": {
+            "put": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "Initiates HDR processing and merging of uploaded images using AutoEnhance AI.\n\n**Processing Options:**\n\n**enhance_type** (default: \"property\"):\n- \"property\": Best for real estate - balanced enhancement\n- \"warm\": Warm color grading for cozy feel (AI \u003e= 4.0)\n- \"neutral\": Neutral natural look (AI \u003e= 4.0)\n- \"modern\": Contemporary enhancement (AI \u003e= 4.0)\n\n**sky_replacement** (default: true):\n- Replaces dull skies with attractive blue skies\n\n**cloud_type** (optional):\n- \"CLEAR\": Clear blue sky, \"LOW_CLOUD\": Subtle clouds, \"HIGH_CLOUD\": Dramatic clouds\n\n**window_pull_type** (default: \"WINDOWS_WITH_SKIES\"):\n- \"NONE\": No window enhancement\n- \"ONLY_WINDOWS\": Enhance window views only\n- \"WINDOWS_WITH_SKIES\": Enhance windows + replace exterior skies (AI \u003e= 5.2) - BEST RESULTS\n\n**vertical_correction** (default: true):\n- Straightens tilted walls and vertical lines\n\n**lens_correction** (default: true):\n- Removes wide-angle lens distortion\n\n**upscale** (default: false):\n- AI upscaling to double resolution (increases processing time)\n\n**privacy** (default: false):\n- Blurs faces and license plates\n\n**bracket_grouping** (default: \"by_upload_group\"):\n- \"by_upload_group\": Use groups from upload\n- \"auto\": Sequential sets (every N brackets = 1 HDR, where N = brackets_per_image)\n- \"all\": One mega-HDR from all brackets\n- \"individual\": Separate images (no HDR)\n\n**brackets_per_image** (default: 3, only for \"auto\" mode):\n- How many consecutive brackets to merge into one HDR image\n- Example: 6 brackets + brackets_per_image=3 → 2 HDR images ([1,2,3] and [4,5,6])\n- 3 = Standard HDR, 5 = High dynamic range, 7 = Extreme lighting",
+                "description": "Sets how many credits the user may spend per calendar month (UTC); 0 means no budget and null restores CREDITS_MONTHLY_BUDGET.\nReturns the user's credits afterwards.",
                 "consumes": [
                     "application/json"
                 ],
@@ -661,23 +838,24 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "process"
+                    "admin"
                 ],
-                "summary": "Process images with HDR merge",
+                "summary": "Set a user's monthly credit budget",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Order ID (UUID)",
-                        "name": "order_id",
+                        "description": "User ID (UUID)",
+                        "name": "user_id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Processing options with defaults shown in model",
+                        "description": "Monthly budget",
                         "name": "request",
                         "in": "body",
+                        "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.ProcessRequest"
+                            "$ref": "#/definitions/models.SetCreditBudgetRequest"
                         }
                     }
                 ],
@@ -685,7 +863,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.ProcessResponse"
+                            "$ref": "#/definitions/models.CreditsResponse"
                         }
                     },
                     "400": {
@@ -700,8 +878,8 @@ const docTemplate = `{
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
@@ -715,14 +893,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/orders/{order_id}/status": {
+        "/admin/users/{user_id}/usage": {
             "get": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "Returns the current status and progress of an order. For real-time updates, connect to Supabase Realtime.",
+                "description": "Returns what GET /me/usage returns for the given user.",
                 "consumes": [
                     "application/json"
                 ],
@@ -730,14 +908,14 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "status"
+                    "admin"
                 ],
-                "summary": "Get order status",
+                "summary": "Get a user's quota usage",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Order ID (UUID)",
-                        "name": "order_id",
+                        "description": "User ID (UUID)",
+                        "name": "user_id",
                         "in": "path",
                         "required": true
                     }
@@ -746,7 +924,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.StatusResponse"
+                            "$ref": "#/definitions/models.UsageResponse"
                         }
                     },
                     "400": {
@@ -761,8 +939,14 @@ const docTemplate = `{
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
@@ -770,51 +954,104 @@ const docTemplate = `{
                 }
             }
         },
-        "/orders/{order_id}/upload": {
-            "post": {
+        "/api-keys": {
+            "get": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "Uploads multiple bracketed images to an AutoEnhance AI order.\n\n**Automatic Grouping (Default):**\n- All images in one upload call are automatically assigned the same group UUID\n- This makes each upload = one HDR image\n- Example: Upload 3 brackets → They all get the same group_id → Process as 1 HDR\n\n**Custom Grouping (Advanced):**\n- Optionally specify different group IDs for files in the same upload\n- Example: groups=\"living-room,living-room,living-room,kitchen,kitchen,kitchen\"\n- This creates multiple HDR groups in one upload call\n\n**Workflow:**\n1. Upload bedroom brackets (3 images) → Auto-grouped as one HDR\n2. Upload kitchen brackets (3 images) → Auto-grouped as another HDR\n3. Process with bracket_grouping=\"by_upload_group\" → 2 HDR images",
+                "description": "Returns the keys you created, newest first, or all keys of an organization (admin role required). Revoked keys are included.",
                 "consumes": [
-                    "multipart/form-data"
+                    "application/json"
                 ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "upload"
+                    "api-keys"
                 ],
-                "summary": "Upload images with automatic or custom grouping",
+                "summary": "List API keys",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Order ID (UUID)",
-                        "name": "order_id",
-                        "in": "path",
-                        "required": true
+                        "description": "List the organization's keys instead",
+                        "name": "organization_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIKeyListResponse"
+                        }
                     },
-                    {
-                        "type": "file",
-                        "description": "Bracketed images (multiple files allowed)",
-                        "name": "images",
-                        "in": "formData",
-                        "required": true
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
                     },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
                     {
-                        "type": "string",
-                        "description": "Advanced: Custom group ID for each file (comma-separated). If not provided, all files get the same auto-generated UUID.",
-                        "name": "groups",
-                        "in": "formData"
+                        "Bearer": []
+                    }
+                ],
+                "description": "Creates a key for server-to-server integrations. Send it as \"Authorization: Bearer ihdr_...\"; it acts as you,\nlimited to its scopes (orders:read, orders:write, images:download, webhooks:manage). The key is only returned\nby this call - store it safely. Organization keys require the admin role and only reach that organization's orders.\nOnly admins can create keys with the admin scope (the /admin API); those keys can't belong to an organization.\nAdmin keys of role-based admins (ADMIN_ROLE) expire after at most ADMIN_ROLE_KEY_TTL.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "api-keys"
+                ],
+                "summary": "Create an API key",
+                "parameters": [
+                    {
+                        "description": "API key",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.CreateAPIKeyRequest"
+                        }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "201": {
+                        "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/models.UploadResponse"
+                            "$ref": "#/definitions/models.APIKeyResponse"
                         }
                     },
                     "400": {
@@ -829,6 +1066,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
                     "404": {
                         "description": "Not Found",
                         "schema": {
@@ -844,14 +1087,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/orders/{order_id}/verify": {
-            "get": {
+        "/api-keys/{key_id}": {
+            "delete": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "Checks with AutoEnhance API to verify that an order has uploaded brackets/images",
+                "description": "Revokes a key you created, or any key of an organization you are an admin of. Revoked keys stop working immediately.",
                 "consumes": [
                     "application/json"
                 ],
@@ -859,24 +1102,23 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "orders"
+                    "api-keys"
                 ],
-                "summary": "Verify order has uploaded images in AutoEnhance",
+                "summary": "Revoke an API key",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Order ID (UUID)",
-                        "name": "order_id",
+                        "description": "API key ID (UUID)",
+                        "name": "key_id",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "verification result",
+                        "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/models.APIKeyResponse"
                         }
                     },
                     "400": {
@@ -906,9 +1148,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/webhooks/autoenhance": {
-            "post": {
-                "description": "Receives webhook callbacks from AutoEnhance AI for processing status updates. Uses authentication token verification.",
+        "/health": {
+            "get": {
+                "description": "Returns the health status of the API",
                 "consumes": [
                     "application/json"
                 ],
@@ -916,26 +1158,63 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "webhooks"
+                    "health"
                 ],
-                "summary": "AutoEnhance AI webhook endpoint",
+                "summary": "Health check",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.HealthResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/audit-log": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Returns the actions you took and the actions concerning you, newest first: changes to your orders by organization members\nand support, processing results of your orders, and changes to your account.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "audit"
+                ],
+                "summary": "Read your audit log",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Authentication token (configured in AutoEnhance web app)",
-                        "name": "Authorization",
-                        "in": "header",
-                        "required": true
+                        "description": "At or after (RFC 3339 or YYYY-MM-DD)",
+                        "name": "since",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Before (RFC 3339 or YYYY-MM-DD)",
+                        "name": "until",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 100,
+                        "description": "Maximum number of entries (1-1000)",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "status",
+                        "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/models.AuditLogResponse"
                         }
                     },
                     "400": {
@@ -949,465 +1228,5089 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "models.BracketResponse": {
-            "type": "object",
-            "properties": {
-                "bracket_id": {
-                    "description": "AutoEnhance bracket ID",
-                    "type": "string"
-                },
-                "created_at": {
-                    "type": "string"
-                },
-                "filename": {
-                    "description": "Original filename (from upload)",
-                    "type": "string"
-                },
-                "id": {
-                    "description": "Our internal database ID",
-                    "type": "string"
-                },
-                "image_id": {
-                    "description": "AutoEnhance image ID (if processed)",
-                    "type": "string"
-                },
-                "is_uploaded": {
-                    "type": "boolean"
-                },
-                "metadata": {
-                    "description": "Includes group_id and all AutoEnhance metadata",
-                    "type": "object",
-                    "additionalProperties": true
-                },
-                "name": {
-                    "description": "AutoEnhance bracket name",
-                    "type": "string"
-                },
-                "order_id": {
-                    "description": "AutoEnhance order ID",
-                    "type": "string"
-                }
-            }
         },
-        "models.BracketsResponse": {
-            "type": "object",
-            "properties": {
-                "brackets": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/models.BracketResponse"
+        "/me/credits": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
                     }
-                }
-            }
-        },
-        "models.CreateOrderRequest": {
-            "type": "object",
+                ],
+                "description": "Returns the authenticated user's credit balance, monthly budget and credits spent this calendar month (UTC),\nwith the latest ledger entries. Every unwatermarked download from AutoEnhance spends a credit; downloading\nthe same image, quality and format again never spends another. A budget of 0 means none.\nBalances and budgets are only enforced when CREDITS_ENABLED is set (see \"enforced\").",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "usage"
+                ],
+                "summary": "Get credit balance and ledger",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "default": 50,
+                        "description": "Maximum number of ledger entries (1-1000)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.CreditsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/events": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Streams the events of the Supabase channel user:{user_id}, which mirrors the events of all your orders, as\nServer-Sent Events with heartbeats as for GET /orders/{order_id}/events. Payloads carry order_id and the order's seq. Reconnect with Last-Event-ID or ?last_event_id= to receive the events you missed.\nIf some of them are no longer kept, a ` + "`" + `resync` + "`" + ` event comes first: reload what you show.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "status"
+                ],
+                "summary": "Stream your events",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID of the last event received",
+                        "name": "Last-Event-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Same as Last-Event-ID, for clients that can't set headers",
+                        "name": "last_event_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "text/event-stream",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/usage": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Returns the authenticated user's plan, its limits and current consumption: active orders, bytes uploaded this calendar month (UTC) and bytes stored.\nA limit of 0 means unlimited. Limits are only enforced when QUOTA_ENABLED is set (see \"enforced\").",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "usage"
+                ],
+                "summary": "Get current quota usage",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.UsageResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/orders": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Returns a page of the authenticated user's orders and the orders of their organizations from the cached AutoEnhance data (no AutoEnhance calls are made).\nPages are cursor-based: pass next_cursor from the previous response as cursor, keeping the same sort and direction.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orders"
+                ],
+                "summary": "List orders",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "default": 50,
+                        "description": "Page size (1-200)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor from the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "created_at",
+                            "updated_at",
+                            "name"
+                        ],
+                        "type": "string",
+                        "default": "created_at",
+                        "description": "Sort key",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "asc",
+                            "desc"
+                        ],
+                        "type": "string",
+                        "description": "Sort direction (default desc for dates, asc for name)",
+                        "name": "direction",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated order statuses",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only orders that are (or are not) processing",
+                        "name": "is_processing",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only orders of this property (UUID)",
+                        "name": "property_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only orders of this organization (UUID)",
+                        "name": "organization_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated tags; only orders carrying all of them",
+                        "name": "tag",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Saved filter ID; parameters given here override the saved ones",
+                        "name": "saved_filter",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Case-insensitive name substring",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Created at or after (date or RFC 3339)",
+                        "name": "created_after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Created before (date or RFC 3339)",
+                        "name": "created_before",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.OrderListResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Creates a new AutoEnhance AI order for a listing (real estate shoot). You can optionally provide a custom name for the order.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orders"
+                ],
+                "summary": "Create a new order",
+                "parameters": [
+                    {
+                        "description": "Order name (optional, defaults to 'Order')",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/models.CreateOrderRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.OrderResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Quota exceeded, or not an editor of the organization",
+                        "schema": {
+                            "$ref": "#/definitions/models.QuotaExceededResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/orders/tags": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Adds and removes tags on up to 100 orders. Every order is reported: orders that were not found,\nthat the user may not edit or that would exceed 50 tags carry an error and are left unchanged.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tags"
+                ],
+                "summary": "Tag several orders",
+                "parameters": [
+                    {
+                        "description": "Orders and tags",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.BulkTagRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.BulkTagResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/orders/trash": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Returns a page of the deleted orders the authenticated user can see, most recently deleted first. They can be restored until the janitor purges them.\nSupports the same cursor, filter and sort parameters as GET /orders, plus sort=deleted_at (the default here).",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orders"
+                ],
+                "summary": "List orders in the trash",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "default": 50,
+                        "description": "Page size (1-200)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor from the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "deleted_at",
+                            "created_at",
+                            "updated_at",
+                            "name"
+                        ],
+                        "type": "string",
+                        "default": "deleted_at",
+                        "description": "Sort key",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "asc",
+                            "desc"
+                        ],
+                        "type": "string",
+                        "description": "Sort direction (default desc for dates, asc for name)",
+                        "name": "direction",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated order statuses",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Case-insensitive name substring",
+                        "name": "name",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.OrderListResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/orders/{order_id}": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Returns detailed information about a specific order",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orders"
+                ],
+                "summary": "Get order details",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Order ID (UUID)",
+                        "name": "order_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.OrderResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Requires the admin role on organization orders.\nMoves an order to the trash: it disappears from the API but can be restored with POST /orders/{order_id}/restore\nuntil the janitor purges it (RETENTION_DELETED_ORDER_DAYS). With permanent=true the order - also one already in the trash -\nis deleted right away, including the AutoEnhance order and its files in storage.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orders"
+                ],
+                "summary": "Delete an order",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Order ID (UUID)",
+                        "name": "order_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "default": false,
+                        "description": "Delete permanently instead of moving to the trash",
+                        "name": "permanent",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "message",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/orders/{order_id}/audit-log": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Returns what was done to the order and by whom, newest first: uploads, processing, downloads (with the credits they used),\nexports and changes, plus the processing results reported by AutoEnhance. Works for orders in the trash.\nRequires the admin role on organization orders.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "audit"
+                ],
+                "summary": "Read an order's audit log",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Order ID (UUID)",
+                        "name": "order_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "At or after (RFC 3339 or YYYY-MM-DD)",
+                        "name": "since",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Before (RFC 3339 or YYYY-MM-DD)",
+                        "name": "until",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 100,
+                        "description": "Maximum number of entries (1-1000)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.AuditLogResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/orders/{order_id}/brackets": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Returns a list of all uploaded brackets (raw images) for an order",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "brackets"
+                ],
+                "summary": "Get uploaded brackets",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Order ID (UUID)",
+                        "name": "order_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.BracketsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/orders/{order_id}/brackets/{bracket_id}": {
+            "delete": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Deletes a bracket (uploaded raw image) from AutoEnhance AI. Note: Brackets are automatically cleaned up after successful processing.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "brackets"
+                ],
+                "summary": "Delete an uploaded bracket",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Order ID (UUID)",
+                        "name": "order_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bracket ID from AutoEnhance",
+                        "name": "bracket_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "message",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/orders/{order_id}/events": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Streams the order's realtime events as Server-Sent Events when the request accepts text/event-stream (EventSource\ndoes), with the same event names and payloads as the Supabase channel order:{order_id}: upload_started, upload_completed,\nprocessing_started, webhook_image_processed, download_ready, processing_failed and order_reset. A comment line is sent as a heartbeat\nwhile nothing happens.\n\nEvents are numbered 1, 2, 3, ... per order and kept with the order; the number is the SSE id, and ` + "`" + `seq` + "`" + ` in broadcast\npayloads. Reconnect with the Last-Event-ID header (EventSource does this), ?since= or ?last_event_id= to receive the\nevents after it first. Other requests get the events after ?since= as JSON, a page at a time: use this to catch up when\na broadcast ` + "`" + `seq` + "`" + ` skips a number.",
+                "produces": [
+                    "text/event-stream",
+                    "application/json"
+                ],
+                "tags": [
+                    "status"
+                ],
+                "summary": "Stream or list order events",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Order ID (UUID)",
+                        "name": "order_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Last event seq received (0 for all)",
+                        "name": "since",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 100,
+                        "description": "Maximum number of events in a JSON page (1-1000)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Same as since",
+                        "name": "Last-Event-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Same as since",
+                        "name": "last_event_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.OrderEventsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/orders/{order_id}/export.zip": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Streams a ZIP archive of every completed image in the order, plus a manifest.json with processing settings.\n\nStored variants are reused; missing ones are generated or fetched from AutoEnhance on the fly.\nImages that fail are left out of the archive and reported in the manifest with their error.\nIf the client goes away or an image can't be written in full, the export stops and the archive is left\nwithout its central directory (and manifest), so it won't open rather than hold a truncated file.\n\nName template placeholders: {image_name}, {image_id}, {index}, {quality}, {order_name},\n{property_address}, {mls_number}, {client_name} (empty when the order has no property). The file extension is added automatically.\n\nWatermark (defaults to true = FREE): exporting unwatermarked images costs 1 credit per image not downloaded unwatermarked before.\nImages the user can't pay for (CREDITS_ENABLED) are reported in the manifest with a credits error.",
+                "produces": [
+                    "application/zip"
+                ],
+                "tags": [
+                    "images"
+                ],
+                "summary": "Export order images as ZIP",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Order ID (UUID)",
+                        "name": "order_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "default": "high",
+                        "description": "Quality: thumbnail, preview, medium, high",
+                        "name": "quality",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "jpeg",
+                        "description": "Format: jpeg, png, webp",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "default": true,
+                        "description": "Include watermark",
+                        "name": "watermark",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "{image_name}",
+                        "description": "Entry name template",
+                        "name": "name",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/orders/{order_id}/files": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Returns a list of all processed files associated with an order, including their Supabase Storage URLs",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "files"
+                ],
+                "summary": "Get order files",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Order ID (UUID)",
+                        "name": "order_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.FilesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/orders/{order_id}/files/{file_id}/url": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Returns a freshly signed URL for a stored file. Use this when a signed URL from a previous response has expired. For public buckets the permanent URL is returned.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "files"
+                ],
+                "summary": "Refresh a file URL",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Order ID (UUID)",
+                        "name": "order_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "File ID (UUID)",
+                        "name": "file_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.FileURLResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/orders/{order_id}/images": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Returns a list of all processed images for an order with their download status and tags",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "images"
+                ],
+                "summary": "List processed images",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Order ID (UUID)",
+                        "name": "order_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated tags; only images carrying all of them",
+                        "name": "tag",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.ImagesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/orders/{order_id}/images/{image_id}": {
+            "delete": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Deletes a processed image from AutoEnhance AI and all associated files from Supabase Storage and database",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "images"
+                ],
+                "summary": "Delete a processed image",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Order ID (UUID)",
+                        "name": "order_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Image ID from AutoEnhance",
+                        "name": "image_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "message",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/orders/{order_id}/images/{image_id}/content": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Streams a stored image variant through the backend with auth checks and HTTP caching.\n\nSupports single-range ` + "`" + `Range` + "`" + ` requests (206 Partial Content) so large downloads can be resumed,\n` + "`" + `If-None-Match` + "`" + `/` + "`" + `If-Modified-Since` + "`" + ` (304 Not Modified) and ` + "`" + `If-Range` + "`" + `.\n\nWatermarked variants that are not stored yet are generated on demand (FREE).\nUnwatermarked variants are only served once the image was downloaded unwatermarked via the download endpoint.",
+                "produces": [
+                    "image/jpeg",
+                    "image/png",
+                    "image/webp"
+                ],
+                "tags": [
+                    "images"
+                ],
+                "summary": "Stream image content",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Order ID (UUID)",
+                        "name": "order_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Image ID from AutoEnhance",
+                        "name": "image_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "default": "preview",
+                        "description": "Variant: thumbnail, preview, medium, high",
+                        "name": "variant",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "jpeg",
+                        "description": "Format: jpeg, png, webp",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "default": true,
+                        "description": "Watermarked variant",
+                        "name": "watermark",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "default": false,
+                        "description": "Send as attachment instead of inline",
+                        "name": "download",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Byte range, e.g. bytes=0-1023",
+                        "name": "Range",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "206": {
+                        "description": "Partial Content",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "304": {
+                        "description": "Not Modified"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "416": {
+                        "description": "Requested Range Not Satisfiable",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/orders/{order_id}/images/{image_id}/download": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Downloads a processed image from AutoEnhance and stores it in Supabase Storage.\n\nThe full-resolution image is downloaded from AutoEnhance once (per watermark setting).\nThumbnail, preview and medium sizes are generated from it locally, as are PNG/JPEG\nre-encodes, and every variant is cached - repeated requests never contact AutoEnhance.\nWebP variants are still downloaded from AutoEnhance.\n\nQuality Options:\n- \"thumbnail\": 400px width (~50-100KB) - List view\n- \"preview\": 800px width (~150-250KB) - Gallery view (DEFAULT)\n- \"medium\": 1920px width (~500KB-1MB) - Full screen\n- \"high\": Full resolution (~2-5MB) - Client delivery\n- \"custom\": Specify max_width or scale\n\nFormat Options: \"jpeg\" (default), \"png\", \"webp\"\n\nWatermark (defaults to true = FREE):\n- true: FREE download with watermark\n- false: COSTS 1 CREDIT (unwatermarked), spent before the image is fetched from AutoEnhance and\nrecorded in the credit ledger (GET /me/credits). The full-resolution image is paid once per image;\nevery JPEG/PNG size is generated from it for free. WebP sizes are paid once each. Downloading a\npaid image again never spends another credit. With CREDITS_ENABLED, a download beyond the\nbalance or the monthly budget is refused with 402.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "images"
+                ],
+                "summary": "Download processed image to Supabase Storage",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Order ID (UUID)",
+                        "name": "order_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Image ID from AutoEnhance",
+                        "name": "image_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Download options",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.DownloadImageRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.DownloadImageResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "402": {
+                        "description": "Payment Required",
+                        "schema": {
+                            "$ref": "#/definitions/models.CreditsExceededResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/models.QuotaExceededResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/orders/{order_id}/images/{image_id}/tags": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Adds tags to a processed image of an order. Same rules as order tags.\nRequires the editor role on organization orders.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tags"
+                ],
+                "summary": "Tag an image",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Order ID (UUID)",
+                        "name": "order_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Image ID from AutoEnhance",
+                        "name": "image_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Tags to add",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.TagsRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.TagsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/orders/{order_id}/images/{image_id}/tags/{tag}": {
+            "delete": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Removes a tag from an image. Removing a tag the image doesn't carry is not an error.\nRequires the editor role on organization orders.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tags"
+                ],
+                "summary": "Untag an image",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Order ID (UUID)",
+                        "name": "order_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Image ID from AutoEnhance",
+                        "name": "image_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Tag",
+                        "name": "tag",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.TagsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/orders/{order_id}/organization": {
+            "put": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Moves the order into an organization, or back to its creator when organization_id is null.\nRequires the admin role on the order and the editor role in the target organization.\nThe order keeps its creator, whose plan it counts against and under whose storage prefix its files stay.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orders"
+                ],
+                "summary": "Share an order with an organization",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Order ID (UUID)",
+                        "name": "order_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Organization to share with",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.SetOrderOrganizationRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.OrderSummary"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/orders/{order_id}/process": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Initiates HDR processing and merging of uploaded images using AutoEnhance AI.\n\n**Processing Options:**\n\n**enhance_type** (default: \"property\"):\n- \"property\": Best for real estate - balanced enhancement\n- \"warm\": Warm color grading for cozy feel (AI \u003e= 4.0)\n- \"neutral\": Neutral natural look (AI \u003e= 4.0)\n- \"modern\": Contemporary enhancement (AI \u003e= 4.0)\n\n**sky_replacement** (default: true):\n- Replaces dull skies with attractive blue skies\n\n**cloud_type** (optional):\n- \"CLEAR\": Clear blue sky, \"LOW_CLOUD\": Subtle clouds, \"HIGH_CLOUD\": Dramatic clouds\n\n**window_pull_type** (default: \"WINDOWS_WITH_SKIES\"):\n- \"NONE\": No window enhancement\n- \"ONLY_WINDOWS\": Enhance window views only\n- \"WINDOWS_WITH_SKIES\": Enhance windows + replace exterior skies (AI \u003e= 5.2) - BEST RESULTS\n\n**vertical_correction** (default: true):\n- Straightens tilted walls and vertical lines\n\n**lens_correction** (default: true):\n- Removes wide-angle lens distortion\n\n**upscale** (default: false):\n- AI upscaling to double resolution (increases processing time)\n\n**privacy** (default: false):\n- Blurs faces and license plates\n\n**bracket_grouping** (default: \"by_upload_group\"):\n- \"by_upload_group\": Use groups from upload\n- \"auto\": Sequential sets (every N brackets = 1 HDR, where N = brackets_per_image)\n- \"all\": One mega-HDR from all brackets\n- \"individual\": Separate images (no HDR)\n\n**brackets_per_image** (default: 3, only for \"auto\" mode):\n- How many consecutive brackets to merge into one HDR image\n- Example: 6 brackets + brackets_per_image=3 → 2 HDR images ([1,2,3] and [4,5,6])\n- 3 = Standard HDR, 5 = High dynamic range, 7 = Extreme lighting",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "process"
+                ],
+                "summary": "Process images with HDR merge",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Order ID (UUID)",
+                        "name": "order_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Processing options with defaults shown in model",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/models.ProcessRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.ProcessResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/orders/{order_id}/property": {
+            "put": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Moves the order to one of the user's properties, or unlinks it when property_id is null.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orders"
+                ],
+                "summary": "Link an order to a property",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Order ID (UUID)",
+                        "name": "order_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Property to link",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.SetOrderPropertyRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.OrderSummary"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/orders/{order_id}/restore": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Takes a deleted order out of the trash. Restoring counts against the active order limit of the creator's plan.\nRequires the admin role on organization orders.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orders"
+                ],
+                "summary": "Restore an order from the trash",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Order ID (UUID)",
+                        "name": "order_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "message",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Quota exceeded, or not an admin of the order",
+                        "schema": {
+                            "$ref": "#/definitions/models.QuotaExceededResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/orders/{order_id}/status": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Returns the current status and progress of an order. For real-time updates, connect to Supabase Realtime or stream GET /orders/{order_id}/events.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "status"
+                ],
+                "summary": "Get order status",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Order ID (UUID)",
+                        "name": "order_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.StatusResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/orders/{order_id}/tags": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Adds tags to an order. Tags are lowercased, at most 50 characters and may not contain commas; an order carries at most 50 tags.\nRequires the editor role on organization orders.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tags"
+                ],
+                "summary": "Tag an order",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Order ID (UUID)",
+                        "name": "order_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Tags to add",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.TagsRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.TagsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/orders/{order_id}/tags/{tag}": {
+            "delete": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Removes a tag from an order. Removing a tag the order doesn't carry is not an error.\nRequires the editor role on organization orders.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tags"
+                ],
+                "summary": "Untag an order",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Order ID (UUID)",
+                        "name": "order_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Tag",
+                        "name": "tag",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.TagsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/orders/{order_id}/upload": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Uploads multiple bracketed images to an AutoEnhance AI order.\n\n**Automatic Grouping (Default):**\n- All images in one upload call are automatically assigned the same group UUID\n- This makes each upload = one HDR image\n- Example: Upload 3 brackets → They all get the same group_id → Process as 1 HDR\n\n**Custom Grouping (Advanced):**\n- Optionally specify different group IDs for files in the same upload\n- Example: groups=\"living-room,living-room,living-room,kitchen,kitchen,kitchen\"\n- This creates multiple HDR groups in one upload call\n\n**Workflow:**\n1. Upload bedroom brackets (3 images) → Auto-grouped as one HDR\n2. Upload kitchen brackets (3 images) → Auto-grouped as another HDR\n3. Process with bracket_grouping=\"by_upload_group\" → 2 HDR images",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "upload"
+                ],
+                "summary": "Upload images with automatic or custom grouping",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Order ID (UUID)",
+                        "name": "order_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "Bracketed images (multiple files allowed)",
+                        "name": "images",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Advanced: Custom group ID for each file (comma-separated). If not provided, all files get the same auto-generated UUID.",
+                        "name": "groups",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.UploadResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.QuotaExceededResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/models.QuotaExceededResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/orders/{order_id}/verify": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Checks with AutoEnhance API to verify that an order has uploaded brackets/images",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orders"
+                ],
+                "summary": "Verify order has uploaded images in AutoEnhance",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Order ID (UUID)",
+                        "name": "order_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "verification result",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/organizations": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Returns the organizations the authenticated user is a member of, with their role in each",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "organizations"
+                ],
+                "summary": "List organizations",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.OrganizationListResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Creates an organization with the authenticated user as its owner",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "organizations"
+                ],
+                "summary": "Create an organization",
+                "parameters": [
+                    {
+                        "description": "Organization",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.OrganizationRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.OrganizationResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/organizations/{organization_id}": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "organizations"
+                ],
+                "summary": "Get an organization",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Organization ID (UUID)",
+                        "name": "organization_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.OrganizationResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Requires the owner role. The organization's orders are kept and go back to the members who created them.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "organizations"
+                ],
+                "summary": "Delete an organization",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Organization ID (UUID)",
+                        "name": "organization_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "message",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Requires the admin role",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "organizations"
+                ],
+                "summary": "Rename an organization",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Organization ID (UUID)",
+                        "name": "organization_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "New name",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.OrganizationRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.OrganizationResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/organizations/{organization_id}/members": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "organizations"
+                ],
+                "summary": "List organization members",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Organization ID (UUID)",
+                        "name": "organization_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.OrganizationMembersResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/organizations/{organization_id}/members/{user_id}": {
+            "put": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Requires the admin role. Only owners can grant or change the admin and owner roles,\nand the last owner cannot be demoted.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "organizations"
+                ],
+                "summary": "Add a member or change their role",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Organization ID (UUID)",
+                        "name": "organization_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "User ID (UUID)",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Role",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.OrganizationMemberRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.OrganizationMemberResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Requires the admin role, except for members leaving on their own. Only owners can remove admins and owners,\nand the last owner cannot leave.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "organizations"
+                ],
+                "summary": "Remove a member",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Organization ID (UUID)",
+                        "name": "organization_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "User ID (UUID)",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "message",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/properties": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Returns the authenticated user's properties, newest first",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "properties"
+                ],
+                "summary": "List properties",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.PropertyListResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Creates a property (listing) that orders can be linked to. Address is required.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "properties"
+                ],
+                "summary": "Create a property",
+                "parameters": [
+                    {
+                        "description": "Property",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.PropertyRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.PropertyResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/properties/{property_id}": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Returns the property, its orders (from the cached AutoEnhance data) and a delivery summary.\nAn image counts as delivered once it has been downloaded without watermark.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "properties"
+                ],
+                "summary": "Get a property with its orders",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Property ID (UUID)",
+                        "name": "property_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.PropertyDetailResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Deletes the property. Its orders are kept and unlinked.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "properties"
+                ],
+                "summary": "Delete a property",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Property ID (UUID)",
+                        "name": "property_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "message",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Updates the given fields. Omitted fields are left unchanged; \"\" clears an optional field.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "properties"
+                ],
+                "summary": "Update a property",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Property ID (UUID)",
+                        "name": "property_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Fields to change",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.PropertyRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.PropertyResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/retention/report": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Returns what the storage janitor would delete for the authenticated user under the current retention policy, without deleting anything.\nWatermarked previews older than RETENTION_PREVIEW_DAYS expire; unwatermarked (paid) files are kept.\nStorage of orders deleted more than RETENTION_DELETED_ORDER_DAYS ago is purged.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "retention"
+                ],
+                "summary": "Storage retention dry-run report",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.RetentionReport"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/saved-filters": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Returns the authenticated user's saved filters by name",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "saved-filters"
+                ],
+                "summary": "List saved order filters",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.SavedFilterListResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Saves a named GET /orders query string, e.g. \"tag=rush,twilight\u0026status=processing\". Apply it with GET /orders?saved_filter={filter_id};\nparameters given with the request override the saved ones. Names are unique per user.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "saved-filters"
+                ],
+                "summary": "Save an order filter",
+                "parameters": [
+                    {
+                        "description": "Saved filter",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.SavedFilterRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.SavedFilterResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/saved-filters/{filter_id}": {
+            "delete": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "saved-filters"
+                ],
+                "summary": "Delete a saved order filter",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Saved filter ID (UUID)",
+                        "name": "filter_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/autoenhance": {
+            "post": {
+                "description": "Receives webhook callbacks from AutoEnhance AI for processing status updates. Uses authentication token verification.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "AutoEnhance AI webhook endpoint",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Authentication token (configured in AutoEnhance web app)",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "status",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "autoenhance.AutoEnhanceTime": {
+            "type": "object",
+            "properties": {
+                "time.Time": {
+                    "type": "string"
+                }
+            }
+        },
+        "autoenhance.BracketOut": {
+            "type": "object",
+            "properties": {
+                "bracket_id": {
+                    "type": "string"
+                },
+                "image_id": {
+                    "type": "string"
+                },
+                "is_uploaded": {
+                    "type": "boolean"
+                },
+                "metadata": {
+                    "type": "object",
+                    "additionalProperties": true
+                },
+                "name": {
+                    "type": "string"
+                },
+                "order_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "autoenhance.ImageOut": {
+            "type": "object",
+            "properties": {
+                "ai_version": {
+                    "type": "string"
+                },
+                "cloud_type": {
+                    "type": "string"
+                },
+                "date_added": {
+                    "type": "integer"
+                },
+                "downloaded": {
+                    "type": "boolean"
+                },
+                "enhance": {
+                    "type": "boolean"
+                },
+                "enhance_type": {
+                    "type": "string"
+                },
+                "image_id": {
+                    "type": "string"
+                },
+                "image_name": {
+                    "type": "string"
+                },
+                "lens_correction": {
+                    "type": "boolean"
+                },
+                "metadata": {
+                    "type": "object",
+                    "additionalProperties": true
+                },
+                "order_id": {
+                    "type": "string"
+                },
+                "preset_id": {
+                    "type": "string"
+                },
+                "privacy": {
+                    "type": "boolean"
+                },
+                "rating": {
+                    "type": "integer"
+                },
+                "scene": {
+                    "type": "string"
+                },
+                "sky_replacement": {
+                    "type": "boolean"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "status_reason": {
+                    "type": "string"
+                },
+                "upscale": {
+                    "type": "boolean"
+                },
+                "user_id": {
+                    "type": "string"
+                },
+                "vertical_correction": {
+                    "type": "boolean"
+                },
+                "window_pull_type": {
+                    "type": "string"
+                }
+            }
+        },
+        "autoenhance.OrderOut": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "$ref": "#/definitions/autoenhance.AutoEnhanceTime"
+                },
+                "images": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/autoenhance.ImageOut"
+                    }
+                },
+                "is_deleted": {
+                    "type": "boolean"
+                },
+                "is_merging": {
+                    "type": "boolean"
+                },
+                "is_processing": {
+                    "type": "boolean"
+                },
+                "last_updated_at": {
+                    "$ref": "#/definitions/autoenhance.AutoEnhanceTime"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "order_id": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "total_images": {
+                    "type": "number"
+                }
+            }
+        },
+        "models.APIKeyListResponse": {
+            "type": "object",
+            "properties": {
+                "keys": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.APIKeyResponse"
+                    }
+                }
+            }
+        },
+        "models.APIKeyResponse": {
+            "type": "object",
+            "properties": {
+                "admin_grant": {
+                    "description": "Admin keys: \"listed\" (ADMIN_USER_IDS) or \"role\" (ADMIN_ROLE, always expires)",
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "key": {
+                    "type": "string"
+                },
+                "key_id": {
+                    "type": "string"
+                },
+                "last_used_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "organization_id": {
+                    "type": "string"
+                },
+                "prefix": {
+                    "type": "string"
+                },
+                "revoked_at": {
+                    "type": "string"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.AdminOrderActionResponse": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string"
+                },
+                "error_message": {
+                    "type": "string"
+                },
+                "order": {
+                    "$ref": "#/definitions/models.OrderSummary"
+                }
+            }
+        },
+        "models.AdminOrderResponse": {
+            "type": "object",
+            "properties": {
+                "autoenhance": {
+                    "description": "The order as AutoEnhance reports it now",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/autoenhance.OrderOut"
+                        }
+                    ]
+                },
+                "autoenhance_brackets": {
+                    "description": "Brackets AutoEnhance still has",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/autoenhance.BracketOut"
+                    }
+                },
+                "autoenhance_error": {
+                    "description": "Set when AutoEnhance could not be reached",
+                    "type": "string"
+                },
+                "brackets": {
+                    "description": "Bracket rows in our database",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.BracketResponse"
+                    }
+                },
+                "error_message": {
+                    "type": "string"
+                },
+                "metadata": {
+                    "type": "object",
+                    "additionalProperties": true
+                },
+                "order": {
+                    "$ref": "#/definitions/models.OrderSummary"
+                }
+            }
+        },
+        "models.AuditEntryResponse": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string"
+                },
+                "actor_id": {
+                    "description": "Empty for system actions",
+                    "type": "string"
+                },
+                "api_key_id": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "details": {
+                    "type": "object"
+                },
+                "entry_id": {
+                    "type": "string"
+                },
+                "image_id": {
+                    "type": "string"
+                },
+                "ip": {
+                    "type": "string"
+                },
+                "order_id": {
+                    "type": "string"
+                },
+                "request_id": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.AuditLogResponse": {
+            "type": "object",
+            "properties": {
+                "entries": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.AuditEntryResponse"
+                    }
+                }
+            }
+        },
+        "models.BracketResponse": {
+            "type": "object",
+            "properties": {
+                "bracket_id": {
+                    "description": "AutoEnhance bracket ID",
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "filename": {
+                    "description": "Original filename (from upload)",
+                    "type": "string"
+                },
+                "id": {
+                    "description": "Our internal database ID",
+                    "type": "string"
+                },
+                "image_id": {
+                    "description": "AutoEnhance image ID (if processed)",
+                    "type": "string"
+                },
+                "is_uploaded": {
+                    "type": "boolean"
+                },
+                "metadata": {
+                    "description": "Includes group_id and all AutoEnhance metadata",
+                    "type": "object",
+                    "additionalProperties": true
+                },
+                "name": {
+                    "description": "AutoEnhance bracket name",
+                    "type": "string"
+                },
+                "order_id": {
+                    "description": "AutoEnhance order ID",
+                    "type": "string"
+                }
+            }
+        },
+        "models.BracketsResponse": {
+            "type": "object",
+            "properties": {
+                "brackets": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.BracketResponse"
+                    }
+                }
+            }
+        },
+        "models.BulkTagRequest": {
+            "type": "object",
+            "required": [
+                "order_ids"
+            ],
+            "properties": {
+                "add": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "rush"
+                    ]
+                },
+                "order_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "remove": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "client-review"
+                    ]
+                }
+            }
+        },
+        "models.BulkTagResponse": {
+            "type": "object",
+            "properties": {
+                "orders": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.BulkTagResult"
+                    }
+                }
+            }
+        },
+        "models.BulkTagResult": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "order_id": {
+                    "type": "string"
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "models.ConsistencyIssue": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "description": "delete_object, delete_row, relink_row, delete_bracket, none",
+                    "type": "string"
+                },
+                "bracket_id": {
+                    "type": "string"
+                },
+                "bytes": {
+                    "type": "integer"
+                },
+                "detail": {
+                    "type": "string"
+                },
+                "file_id": {
+                    "type": "string"
+                },
+                "kind": {
+                    "description": "orphaned_object, dangling_row, missing_path, orphaned_row, orphaned_bracket",
+                    "type": "string"
+                },
+                "order_id": {
+                    "type": "string"
+                },
+                "repaired": {
+                    "type": "boolean"
+                },
+                "storage_path": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ConsistencyReport": {
+            "type": "object",
+            "properties": {
+                "dry_run": {
+                    "type": "boolean"
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "finished_at": {
+                    "type": "string"
+                },
+                "issues": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ConsistencyIssue"
+                    }
+                },
+                "objects_checked": {
+                    "type": "integer"
+                },
+                "orders_checked": {
+                    "type": "integer"
+                },
+                "repaired": {
+                    "type": "integer"
+                },
+                "rows_checked": {
+                    "type": "integer"
+                },
+                "started_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "description": "Set when the check was limited to one user",
+                    "type": "string"
+                }
+            }
+        },
+        "models.CreateAPIKeyRequest": {
+            "type": "object",
+            "required": [
+                "name",
+                "scopes"
+            ],
+            "properties": {
+                "expires_at": {
+                    "description": "Never expires when omitted",
+                    "type": "string",
+                    "example": "2027-01-01T00:00:00Z"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "CRM sync"
+                },
+                "organization_id": {
+                    "type": "string",
+                    "example": "0b7e8f52-4c1d-4a6e-9f3b-5d2a1c8e7f60"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "orders:read",
+                        "images:download"
+                    ]
+                }
+            }
+        },
+        "models.CreateOrderRequest": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "description": "Order name/description (e.g., \"123 Main St - Living Room\")\nIf not provided, defaults to \"Order\"",
+                    "type": "string",
+                    "example": "Property Shoot - 123 Main St"
+                },
+                "organization_id": {
+                    "description": "Organization that owns the order (optional, requires the editor role in it)",
+                    "type": "string",
+                    "example": "0b7e8f52-4c1d-4a6e-9f3b-5d2a1c8e7f60"
+                },
+                "property_id": {
+                    "description": "Property this shoot belongs to (optional, must be one of the user's properties)",
+                    "type": "string",
+                    "example": "6f1c2a9e-8d4b-4f0a-9a51-2f7c3e1b0d42"
+                },
+                "tags": {
+                    "description": "Tags to start with (optional, lowercased)",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "twilight",
+                        "rush"
+                    ]
+                }
+            }
+        },
+        "models.CreditEntryResponse": {
+            "type": "object",
+            "properties": {
+                "actor_id": {
+                    "description": "Grants: the admin",
+                    "type": "string"
+                },
+                "amount": {
+                    "description": "-1 for a spend",
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "entry_id": {
+                    "type": "string"
+                },
+                "format": {
+                    "type": "string"
+                },
+                "image_id": {
+                    "type": "string"
+                },
+                "kind": {
+                    "description": "spend or grant",
+                    "type": "string"
+                },
+                "order_id": {
+                    "type": "string"
+                },
+                "quality": {
+                    "description": "Spends: the variant downloaded from AutoEnhance",
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "refunded_at": {
+                    "description": "Spends whose download failed",
+                    "type": "string"
+                }
+            }
+        },
+        "models.CreditsExceededResponse": {
+            "type": "object",
+            "properties": {
+                "balance": {
+                    "type": "integer"
+                },
+                "error": {
+                    "description": "Always \"credits_exceeded\"",
+                    "type": "string"
+                },
+                "limit": {
+                    "description": "credit_balance or monthly_credit_budget",
+                    "type": "string"
+                },
+                "max": {
+                    "description": "Monthly budget; 0 when there is none",
+                    "type": "integer"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "used": {
+                    "description": "Credits spent this month",
+                    "type": "integer"
+                }
+            }
+        },
+        "models.CreditsResponse": {
+            "type": "object",
+            "properties": {
+                "balance": {
+                    "type": "integer"
+                },
+                "enforced": {
+                    "type": "boolean"
+                },
+                "ledger": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.CreditEntryResponse"
+                    }
+                },
+                "monthly_budget": {
+                    "description": "Spent this month; a limit of 0 means no budget",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.UsageCounter"
+                        }
+                    ]
+                },
+                "period_start": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.DownloadImageRequest": {
+            "type": "object",
+            "properties": {
+                "format": {
+                    "description": "Format - Image format: \"jpeg\" (default), \"png\", or \"webp\"\nDefault: \"jpeg\"",
+                    "type": "string",
+                    "example": "jpeg"
+                },
+                "max_width": {
+                    "description": "MaxWidth - Custom width in pixels (only used when quality=\"custom\")\nDefault: null (not used unless quality=\"custom\")",
+                    "type": "integer"
+                },
+                "quality": {
+                    "description": "Quality preset - Options: \"thumbnail\" (400px), \"preview\" (800px), \"medium\" (1920px), \"high\" (full res), or \"custom\"\nDefault: \"preview\"",
+                    "type": "string",
+                    "example": "preview"
+                },
+                "scale": {
+                    "description": "Scale - Scale factor (only used when quality=\"custom\")\nDefault: null (not used unless quality=\"custom\")\nExample: 0.5 for 50% of original size",
+                    "type": "number"
+                },
+                "watermark": {
+                    "description": "Watermark - Whether to include watermark. Defaults to true (FREE). Set to false to use 1 credit (unwatermarked)\nDefault: true (FREE - no credits used)",
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "models.DownloadImageResponse": {
+            "type": "object",
+            "properties": {
+                "credit_used": {
+                    "description": "CreditUsed indicates if this download cost a credit",
+                    "type": "boolean",
+                    "example": false
+                },
+                "expires_at": {
+                    "description": "ExpiresAt is set when URL is a signed URL (private bucket)",
+                    "type": "string"
+                },
+                "file_size": {
+                    "description": "FileSize in bytes",
+                    "type": "integer",
+                    "example": 524288
+                },
+                "format": {
+                    "description": "Format of the downloaded image",
+                    "type": "string",
+                    "example": "jpeg"
+                },
+                "height": {
+                    "type": "integer",
+                    "example": 533
+                },
+                "image_id": {
+                    "description": "ImageID from AutoEnhance",
+                    "type": "string",
+                    "example": "img_abc123"
+                },
+                "message": {
+                    "description": "Message with download details",
+                    "type": "string",
+                    "example": "Image downloaded successfully (FREE with watermark) - Quality: preview, Resolution: 800px"
+                },
+                "quality": {
+                    "description": "Quality preset used",
+                    "type": "string",
+                    "example": "preview"
+                },
+                "resolution": {
+                    "description": "Resolution achieved (e.g., \"400px\", \"800px\", \"1920px\", \"full\")",
+                    "type": "string",
+                    "example": "800px"
+                },
+                "source": {
+                    "description": "Source of the file: \"cache\" (already stored), \"generated\" (resized locally from the high-res image) or \"autoenhance\"",
+                    "type": "string",
+                    "example": "generated"
+                },
+                "url": {
+                    "description": "URL to access the image in Supabase Storage (publicly accessible)",
+                    "type": "string",
+                    "example": "https://project.supabase.co/storage/v1/object/public/hdr-images/users/user123/orders/order456/img_abc123_preview.jpg"
+                },
+                "watermark": {
+                    "description": "Watermark indicates if watermark was applied (true = FREE, false = COSTS 1 CREDIT)",
+                    "type": "boolean",
+                    "example": true
+                },
+                "width": {
+                    "description": "Width and Height of the stored image in pixels",
+                    "type": "integer",
+                    "example": 800
+                }
+            }
+        },
+        "models.ErrorResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.FileInfo": {
+            "type": "object",
+            "properties": {
+                "filename": {
+                    "type": "string"
+                },
+                "size": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.FileResponse": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "file_size": {
+                    "type": "integer"
+                },
+                "filename": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "is_final": {
+                    "type": "boolean"
+                },
+                "mime_type": {
+                    "type": "string"
+                },
+                "storage_url": {
+                    "type": "string"
+                },
+                "url_expires_at": {
+                    "description": "Set when storage_url is a signed URL (private bucket)",
+                    "type": "string"
+                }
+            }
+        },
+        "models.FileURLResponse": {
+            "type": "object",
+            "properties": {
+                "expires_at": {
+                    "description": "Nil for permanent public URLs",
+                    "type": "string"
+                },
+                "file_id": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.FilesResponse": {
+            "type": "object",
+            "properties": {
+                "files": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.FileResponse"
+                    }
+                }
+            }
+        },
+        "models.GrantCreditsRequest": {
+            "type": "object",
+            "required": [
+                "amount"
+            ],
+            "properties": {
+                "amount": {
+                    "type": "integer",
+                    "example": 50
+                },
+                "reason": {
+                    "type": "string",
+                    "example": "Monthly top-up"
+                }
+            }
+        },
+        "models.HealthResponse": {
+            "type": "object",
+            "properties": {
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ImageResponse": {
+            "type": "object",
+            "properties": {
+                "downloaded": {
+                    "type": "boolean"
+                },
+                "enhance_type": {
+                    "type": "string"
+                },
+                "high_res_downloaded": {
+                    "type": "boolean"
+                },
+                "high_res_url": {
+                    "description": "Supabase URL for high-res",
+                    "type": "string"
+                },
+                "image_id": {
+                    "type": "string"
+                },
+                "image_name": {
+                    "type": "string"
+                },
+                "preview_downloaded": {
+                    "type": "boolean"
+                },
+                "preview_url": {
+                    "description": "Supabase URL for preview",
+                    "type": "string"
+                },
+                "processing_settings": {
+                    "type": "object",
+                    "additionalProperties": true
+                },
+                "status": {
+                    "type": "string"
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "urls_expire_at": {
+                    "description": "Set when URLs are signed (private bucket)",
+                    "type": "string"
+                }
+            }
+        },
+        "models.ImagesResponse": {
+            "type": "object",
+            "properties": {
+                "images": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ImageResponse"
+                    }
+                }
+            }
+        },
+        "models.OrderEventResponse": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "event": {
+                    "type": "string"
+                },
+                "payload": {
+                    "type": "object"
+                },
+                "seq": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.OrderEventsResponse": {
+            "type": "object",
+            "properties": {
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.OrderEventResponse"
+                    }
+                },
+                "has_more": {
+                    "description": "Ask again with since set to the last seq",
+                    "type": "boolean"
+                },
+                "order_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.OrderListResponse": {
+            "type": "object",
+            "properties": {
+                "next_cursor": {
+                    "description": "Pass as cursor to get the next page; empty on the last page",
+                    "type": "string"
+                },
+                "orders": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.OrderSummary"
+                    }
+                }
+            }
+        },
+        "models.OrderResponse": {
+            "type": "object",
+            "properties": {
+                "autoenhance_last_updated_at": {
+                    "description": "Last update time from AutoEnhance",
+                    "type": "string"
+                },
+                "autoenhance_status": {
+                    "description": "AutoEnhance data (when available)",
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "created_by": {
+                    "type": "string"
+                },
+                "error_message": {
+                    "type": "string"
+                },
+                "images": {
+                    "type": "array",
+                    "items": {
+                        "type": "object",
+                        "additionalProperties": true
+                    }
+                },
+                "is_deleted": {
+                    "description": "Indicates if order was deleted in AutoEnhance",
+                    "type": "boolean"
+                },
+                "is_merging": {
+                    "description": "Indicates if brackets are currently being merged",
+                    "type": "boolean"
+                },
+                "is_processing": {
+                    "type": "boolean"
+                },
+                "metadata": {
+                    "type": "object",
+                    "additionalProperties": true
+                },
+                "name": {
+                    "description": "Order name from AutoEnhance",
+                    "type": "string"
+                },
+                "order_id": {
+                    "type": "string"
+                },
+                "organization_id": {
+                    "type": "string"
+                },
+                "progress": {
+                    "type": "integer"
+                },
+                "property_id": {
+                    "type": "string"
+                },
+                "role": {
+                    "description": "The caller's role on the order",
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "total_brackets": {
+                    "type": "integer"
+                },
+                "total_images": {
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "uploaded_brackets": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.OrderSummary": {
+            "type": "object",
+            "properties": {
+                "autoenhance_status": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "created_by": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "description": "Set for orders in the trash",
+                    "type": "string"
+                },
+                "is_processing": {
+                    "type": "boolean"
+                },
+                "name": {
+                    "description": "Order name from AutoEnhance (cached)",
+                    "type": "string"
+                },
+                "order_id": {
+                    "type": "string"
+                },
+                "organization_id": {
+                    "type": "string"
+                },
+                "progress": {
+                    "type": "integer"
+                },
+                "property_id": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "total_images": {
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.OrganizationListResponse": {
+            "type": "object",
+            "properties": {
+                "organizations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.OrganizationResponse"
+                    }
+                }
+            }
+        },
+        "models.OrganizationMemberRequest": {
+            "type": "object",
+            "required": [
+                "role"
+            ],
+            "properties": {
+                "role": {
+                    "description": "viewer, editor, admin or owner",
+                    "type": "string",
+                    "example": "editor"
+                }
+            }
+        },
+        "models.OrganizationMemberResponse": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "role": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.OrganizationMembersResponse": {
+            "type": "object",
+            "properties": {
+                "members": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.OrganizationMemberResponse"
+                    }
+                }
+            }
+        },
+        "models.OrganizationRequest": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
             "properties": {
                 "name": {
-                    "description": "Order name/description (e.g., \"123 Main St - Living Room\")\nIf not provided, defaults to \"Order\"",
                     "type": "string",
-                    "example": "Property Shoot - 123 Main St"
+                    "example": "Sunset Real Estate Media"
                 }
             }
         },
-        "models.DownloadImageRequest": {
+        "models.OrganizationResponse": {
             "type": "object",
             "properties": {
-                "format": {
-                    "description": "Format - Image format: \"jpeg\" (default), \"png\", or \"webp\"\nDefault: \"jpeg\"",
-                    "type": "string",
-                    "example": "jpeg"
+                "created_at": {
+                    "type": "string"
                 },
-                "max_width": {
-                    "description": "MaxWidth - Custom width in pixels (only used when quality=\"custom\")\nDefault: null (not used unless quality=\"custom\")",
-                    "type": "integer"
+                "created_by": {
+                    "type": "string"
                 },
-                "quality": {
-                    "description": "Quality preset - Options: \"thumbnail\" (400px), \"preview\" (800px), \"medium\" (1920px), \"high\" (full res), or \"custom\"\nDefault: \"preview\"",
-                    "type": "string",
-                    "example": "preview"
+                "name": {
+                    "type": "string"
                 },
-                "scale": {
-                    "description": "Scale - Scale factor (only used when quality=\"custom\")\nDefault: null (not used unless quality=\"custom\")\nExample: 0.5 for 50% of original size",
-                    "type": "number"
+                "organization_id": {
+                    "type": "string"
                 },
-                "watermark": {
-                    "description": "Watermark - Whether to include watermark. Defaults to true (FREE). Set to false to use 1 credit (unwatermarked)\nDefault: true (FREE - no credits used)",
-                    "type": "boolean",
-                    "example": true
+                "role": {
+                    "description": "The caller's role",
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
                 }
             }
         },
-        "models.DownloadImageResponse": {
+        "models.ProcessRequest": {
             "type": "object",
             "properties": {
-                "credit_used": {
-                    "description": "CreditUsed indicates if this download cost a credit",
-                    "type": "boolean",
-                    "example": false
-                },
-                "file_size": {
-                    "description": "FileSize in bytes",
-                    "type": "integer",
-                    "example": 524288
+                "ai_version": {
+                    "description": "AIVersion specifies the AI model version to use for processing.\nExamples: \"4.0\", \"5.2\", \"5.x\"\nVersions ending in .x (e.g., \"5.x\") will automatically use the latest minor version.\nDefault: Latest stable version (automatically selected by AutoEnhance)",
+                    "type": "string"
                 },
-                "format": {
-                    "description": "Format of the downloaded image",
+                "bracket_grouping": {
+                    "description": "BracketGrouping specifies how uploaded brackets are organized into HDR images.\nOptions: \"by_upload_group\", \"auto\", \"all\", \"individual\", or custom array\n- \"by_upload_group\" (RECOMMENDED): Groups brackets by group_id assigned during upload\n- \"auto\": Groups brackets sequentially by sets (e.g., every 3 brackets = 1 HDR)\n- \"all\": Merges ALL brackets into ONE HDR image (maximum dynamic range)\n- \"individual\": Each bracket becomes a separate image (no HDR merging)\n- Custom array: [[id1,id2,id3],[id4,id5]] - Specify exact bracket groupings by bracket_id\nDefault: \"by_upload_group\"",
                     "type": "string",
-                    "example": "jpeg"
+                    "example": "by_upload_group"
                 },
-                "image_id": {
-                    "description": "ImageID from AutoEnhance",
-                    "type": "string",
-                    "example": "img_abc123"
+                "brackets_per_image": {
+                    "description": "BracketsPerImage specifies how many consecutive brackets to group into one HDR image.\nOnly used when bracket_grouping is \"auto\". This tells the system to group brackets sequentially.\nIGNORED if bracket_grouping is \"by_upload_group\" (the default).\n\nExample with 6 brackets and brackets_per_image=3:\n  - Brackets [1,2,3] → HDR Image #1\n  - Brackets [4,5,6] → HDR Image #2\n\nCommon values:\n- 3 (DEFAULT when using \"auto\"): Standard 3-exposure HDR (underexposed, normal, overexposed)\n  Best for: Most real estate shots, typical bracketing workflows\n- 5: 5-exposure HDR for more dynamic range\n  Best for: High-contrast scenes, sunset/sunrise shots\n- 7: 7-exposure HDR for maximum detail in shadows and highlights\n  Best for: Extreme lighting (bright windows + dark interiors)\n\nNote: More brackets = better HDR but longer processing time\nDefault: 3 (only applies when bracket_grouping=\"auto\")",
+                    "type": "integer"
                 },
-                "message": {
-                    "description": "Message with download details",
+                "cloud_type": {
+                    "description": "CloudType specifies what type of clouds to add when sky replacement is enabled.\nOptions: \"CLEAR\", \"LOW_CLOUD\", \"HIGH_CLOUD\"\n- \"CLEAR\": Clear blue sky with minimal clouds\n- \"LOW_CLOUD\": Low-altitude clouds for subtle effect\n- \"HIGH_CLOUD\": High-altitude clouds for more dramatic skies\nDefault: null (AutoEnhance chooses automatically based on scene)",
                     "type": "string",
-                    "example": "Image downloaded successfully (FREE with watermark) - Quality: preview, Resolution: 800px"
+                    "enum": [
+                        "CLEAR",
+                        "LOW_CLOUD",
+                        "HIGH_CLOUD"
+                    ]
                 },
-                "quality": {
-                    "description": "Quality preset used",
+                "enhance_type": {
+                    "description": "EnhanceType specifies the type of enhancement to apply to the image.\nOptions: \"property\", \"property_usa\", \"warm\", \"neutral\", \"modern\"\n- \"property\" (DEFAULT): Best for real estate photography - balanced enhancement\n- \"property_usa\": USA-specific real estate enhancement (for AI version \u003c 4.0)\n- \"warm\": Warm color grading for cozy, inviting feel (AI version \u003e= 4.0)\n- \"neutral\": Neutral color grading for natural look (AI version \u003e= 4.0)\n- \"modern\": Modern, contemporary enhancement style (AI version \u003e= 4.0)\nDefault: \"property\"",
                     "type": "string",
-                    "example": "preview"
+                    "enum": [
+                        "property",
+                        "property_usa",
+                        "warm",
+                        "neutral",
+                        "modern"
+                    ],
+                    "example": "property"
                 },
-                "resolution": {
-                    "description": "Resolution achieved (e.g., \"400px\", \"800px\", \"1920px\", \"full\")",
-                    "type": "string",
-                    "example": "800px"
+                "lens_correction": {
+                    "description": "LensCorrection removes lens distortion (barrel/pincushion effect).\nCorrects curved lines caused by wide-angle lenses commonly used in real estate.\nRecommended for all real estate photos.\nDefault: true",
+                    "type": "boolean",
+                    "example": true
                 },
-                "url": {
-                    "description": "URL to access the image in Supabase Storage (publicly accessible)",
-                    "type": "string",
-                    "example": "https://project.supabase.co/storage/v1/object/public/hdr-images/users/user123/orders/order456/img_abc123_preview.jpg"
+                "metadata": {
+                    "description": "Optional metadata to store with the processing request for your own tracking",
+                    "type": "object",
+                    "additionalProperties": true
                 },
-                "watermark": {
-                    "description": "Watermark indicates if watermark was applied (true = FREE, false = COSTS 1 CREDIT)",
+                "privacy": {
+                    "description": "Privacy blurs faces and license plates for privacy compliance.\nUseful for public listings where privacy protection is required.\nDefault: false",
+                    "type": "boolean",
+                    "example": false
+                },
+                "sky_replacement": {
+                    "description": "SkyReplacement enables AI-powered sky replacement with realistic clouds.\nWhen enabled, dull or overcast skies are replaced with attractive blue skies.\nWorks best with outdoor property photos.\nDefault: true (recommended for real estate)",
+                    "type": "boolean",
+                    "example": true
+                },
+                "upscale": {
+                    "description": "Upscale increases image resolution using AI upscaling technology.\nDoubles the resolution while maintaining quality.\nWarning: Significantly increases processing time and final file size.\nDefault: false",
+                    "type": "boolean",
+                    "example": false
+                },
+                "vertical_correction": {
+                    "description": "VerticalCorrection automatically corrects vertical perspective distortion.\nStraightens walls and vertical lines that appear tilted due to camera angle.\nEssential for professional real estate photography.\nDefault: true (highly recommended)",
                     "type": "boolean",
                     "example": true
+                },
+                "window_pull_type": {
+                    "description": "WindowPullType controls how window views are enhanced.\nOptions: \"NONE\", \"ONLY_WINDOWS\", \"WINDOWS_WITH_SKIES\"\n- \"NONE\": No window enhancement (keep original window views)\n- \"ONLY_WINDOWS\": Enhance window views only (bring out detail)\n- \"WINDOWS_WITH_SKIES\" (DEFAULT): Enhance windows AND replace exterior skies visible through windows (requires AI version \u003e= 5.2)\nDefault: \"WINDOWS_WITH_SKIES\" (recommended for best results)",
+                    "type": "string",
+                    "enum": [
+                        "NONE",
+                        "ONLY_WINDOWS",
+                        "WINDOWS_WITH_SKIES"
+                    ],
+                    "example": "WINDOWS_WITH_SKIES"
                 }
             }
         },
-        "models.ErrorResponse": {
+        "models.ProcessResponse": {
             "type": "object",
             "properties": {
-                "error": {
+                "message": {
                     "type": "string"
                 },
-                "message": {
+                "order_id": {
+                    "type": "string"
+                },
+                "processing_params": {
+                    "type": "object",
+                    "additionalProperties": true
+                },
+                "status": {
                     "type": "string"
                 }
             }
         },
-        "models.FileInfo": {
+        "models.PropertyDetailResponse": {
             "type": "object",
             "properties": {
-                "filename": {
+                "address": {
                     "type": "string"
                 },
-                "size": {
-                    "type": "integer"
-                }
-            }
-        },
-        "models.FileResponse": {
-            "type": "object",
-            "properties": {
-                "created_at": {
+                "client_name": {
                     "type": "string"
                 },
-                "file_size": {
-                    "type": "integer"
+                "created_at": {
+                    "type": "string"
                 },
-                "filename": {
+                "mls_number": {
                     "type": "string"
                 },
-                "id": {
+                "notes": {
                     "type": "string"
                 },
-                "is_final": {
-                    "type": "boolean"
+                "orders": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.PropertyOrder"
+                    }
                 },
-                "mime_type": {
+                "property_id": {
                     "type": "string"
                 },
-                "storage_url": {
+                "summary": {
+                    "$ref": "#/definitions/models.PropertySummary"
+                },
+                "updated_at": {
                     "type": "string"
                 }
             }
         },
-        "models.FilesResponse": {
+        "models.PropertyListResponse": {
             "type": "object",
             "properties": {
-                "files": {
+                "properties": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/models.FileResponse"
+                        "$ref": "#/definitions/models.PropertyResponse"
                     }
                 }
             }
         },
-        "models.HealthResponse": {
+        "models.PropertyOrder": {
             "type": "object",
             "properties": {
-                "status": {
+                "autoenhance_status": {
                     "type": "string"
-                }
-            }
-        },
-        "models.ImageResponse": {
-            "type": "object",
-            "properties": {
-                "downloaded": {
-                    "type": "boolean"
                 },
-                "enhance_type": {
+                "created_at": {
                     "type": "string"
                 },
-                "high_res_downloaded": {
+                "created_by": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "description": "Set for orders in the trash",
+                    "type": "string"
+                },
+                "delivered_images": {
+                    "description": "Images downloaded without watermark",
+                    "type": "integer"
+                },
+                "is_processing": {
                     "type": "boolean"
                 },
-                "high_res_url": {
-                    "description": "Supabase URL for high-res",
+                "name": {
+                    "description": "Order name from AutoEnhance (cached)",
                     "type": "string"
                 },
-                "image_id": {
+                "order_id": {
                     "type": "string"
                 },
-                "image_name": {
+                "organization_id": {
                     "type": "string"
                 },
-                "preview_downloaded": {
-                    "type": "boolean"
+                "progress": {
+                    "type": "integer"
                 },
-                "preview_url": {
-                    "description": "Supabase URL for preview",
+                "property_id": {
                     "type": "string"
                 },
-                "processing_settings": {
-                    "type": "object",
-                    "additionalProperties": true
-                },
                 "status": {
                     "type": "string"
-                }
-            }
-        },
-        "models.ImagesResponse": {
-            "type": "object",
-            "properties": {
-                "images": {
+                },
+                "tags": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/models.ImageResponse"
+                        "type": "string"
                     }
+                },
+                "total_images": {
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
                 }
             }
         },
-        "models.OrderListResponse": {
+        "models.PropertyRequest": {
             "type": "object",
             "properties": {
-                "orders": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/models.OrderSummary"
-                    }
+                "address": {
+                    "type": "string",
+                    "example": "123 Main St, Springfield"
+                },
+                "client_name": {
+                    "type": "string",
+                    "example": "Acme Realty"
+                },
+                "mls_number": {
+                    "type": "string",
+                    "example": "MLS-204417"
+                },
+                "notes": {
+                    "type": "string",
+                    "example": "Lockbox code at the front door"
                 }
             }
         },
-        "models.OrderResponse": {
+        "models.PropertyResponse": {
             "type": "object",
             "properties": {
-                "autoenhance_last_updated_at": {
-                    "description": "Last update time from AutoEnhance",
+                "address": {
                     "type": "string"
                 },
-                "autoenhance_status": {
-                    "description": "AutoEnhance data (when available)",
+                "client_name": {
+                    "type": "string"
+                },
+                "created_at": {
                     "type": "string"
                 },
-                "created_at": {
+                "mls_number": {
                     "type": "string"
                 },
-                "error_message": {
+                "notes": {
                     "type": "string"
                 },
-                "images": {
-                    "type": "array",
-                    "items": {
-                        "type": "object",
-                        "additionalProperties": true
-                    }
+                "property_id": {
+                    "type": "string"
                 },
-                "is_deleted": {
-                    "description": "Indicates if order was deleted in AutoEnhance",
-                    "type": "boolean"
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.PropertySummary": {
+            "type": "object",
+            "properties": {
+                "delivered_images": {
+                    "type": "integer"
                 },
-                "is_merging": {
-                    "description": "Indicates if brackets are currently being merged",
-                    "type": "boolean"
+                "delivery_status": {
+                    "description": "no_orders, processing, pending, partial or delivered",
+                    "type": "string"
                 },
-                "is_processing": {
-                    "type": "boolean"
+                "order_count": {
+                    "type": "integer"
                 },
-                "metadata": {
+                "orders_by_status": {
                     "type": "object",
-                    "additionalProperties": true
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
                 },
-                "name": {
-                    "description": "Order name from AutoEnhance",
+                "total_images": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.QuotaExceededResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "description": "Always \"quota_exceeded\"",
                     "type": "string"
                 },
-                "order_id": {
+                "limit": {
+                    "description": "active_orders, brackets_per_order, upload_bytes_per_month or stored_bytes",
                     "type": "string"
                 },
-                "progress": {
+                "max": {
                     "type": "integer"
                 },
-                "status": {
+                "message": {
                     "type": "string"
                 },
-                "total_brackets": {
-                    "type": "integer"
+                "plan": {
+                    "type": "string"
                 },
-                "total_images": {
+                "requested": {
                     "type": "integer"
                 },
-                "updated_at": {
-                    "type": "string"
-                },
-                "uploaded_brackets": {
+                "used": {
                     "type": "integer"
                 }
             }
         },
-        "models.OrderSummary": {
+        "models.ResetOrderErrorRequest": {
+            "type": "object",
+            "properties": {
+                "status": {
+                    "type": "string",
+                    "example": "uploaded"
+                }
+            }
+        },
+        "models.RetentionFileItem": {
             "type": "object",
             "properties": {
                 "created_at": {
                     "type": "string"
                 },
-                "name": {
-                    "description": "Order name from AutoEnhance",
+                "file_id": {
+                    "type": "string"
+                },
+                "file_size": {
+                    "type": "integer"
+                },
+                "filename": {
                     "type": "string"
                 },
                 "order_id": {
                     "type": "string"
                 },
-                "progress": {
+                "storage_path": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.RetentionOrderItem": {
+            "type": "object",
+            "properties": {
+                "bytes": {
                     "type": "integer"
                 },
-                "status": {
+                "deleted_at": {
                     "type": "string"
                 },
-                "updated_at": {
+                "name": {
+                    "type": "string"
+                },
+                "object_count": {
+                    "type": "integer"
+                },
+                "order_id": {
                     "type": "string"
+                },
+                "permanent": {
+                    "description": "Trashed order deleted entirely; otherwise only its storage is purged",
+                    "type": "boolean"
                 }
             }
         },
-        "models.ProcessRequest": {
+        "models.RetentionReport": {
             "type": "object",
             "properties": {
-                "ai_version": {
-                    "description": "AIVersion specifies the AI model version to use for processing.\nExamples: \"4.0\", \"5.2\", \"5.x\"\nVersions ending in .x (e.g., \"5.x\") will automatically use the latest minor version.\nDefault: Latest stable version (automatically selected by AutoEnhance)",
-                    "type": "string"
-                },
-                "bracket_grouping": {
-                    "description": "BracketGrouping specifies how uploaded brackets are organized into HDR images.\nOptions: \"by_upload_group\", \"auto\", \"all\", \"individual\", or custom array\n- \"by_upload_group\" (RECOMMENDED): Groups brackets by group_id assigned during upload\n- \"auto\": Groups brackets sequentially by sets (e.g., every 3 brackets = 1 HDR)\n- \"all\": Merges ALL brackets into ONE HDR image (maximum dynamic range)\n- \"individual\": Each bracket becomes a separate image (no HDR merging)\n- Custom array: [[id1,id2,id3],[id4,id5]] - Specify exact bracket groupings by bracket_id\nDefault: \"by_upload_group\"",
-                    "type": "string",
-                    "example": "by_upload_group"
+                "bytes_freed": {
+                    "type": "integer"
                 },
-                "brackets_per_image": {
-                    "description": "BracketsPerImage specifies how many consecutive brackets to group into one HDR image.\nOnly used when bracket_grouping is \"auto\". This tells the system to group brackets sequentially.\nIGNORED if bracket_grouping is \"by_upload_group\" (the default).\n\nExample with 6 brackets and brackets_per_image=3:\n  - Brackets [1,2,3] → HDR Image #1\n  - Brackets [4,5,6] → HDR Image #2\n\nCommon values:\n- 3 (DEFAULT when using \"auto\"): Standard 3-exposure HDR (underexposed, normal, overexposed)\n  Best for: Most real estate shots, typical bracketing workflows\n- 5: 5-exposure HDR for more dynamic range\n  Best for: High-contrast scenes, sunset/sunrise shots\n- 7: 7-exposure HDR for maximum detail in shadows and highlights\n  Best for: Extreme lighting (bright windows + dark interiors)\n\nNote: More brackets = better HDR but longer processing time\nDefault: 3 (only applies when bracket_grouping=\"auto\")",
+                "deleted_order_days": {
+                    "description": "0 = deleted orders are never purged",
                     "type": "integer"
                 },
-                "cloud_type": {
-                    "description": "CloudType specifies what type of clouds to add when sky replacement is enabled.\nOptions: \"CLEAR\", \"LOW_CLOUD\", \"HIGH_CLOUD\"\n- \"CLEAR\": Clear blue sky with minimal clouds\n- \"LOW_CLOUD\": Low-altitude clouds for subtle effect\n- \"HIGH_CLOUD\": High-altitude clouds for more dramatic skies\nDefault: null (AutoEnhance chooses automatically based on scene)",
-                    "type": "string",
-                    "enum": [
-                        "CLEAR",
-                        "LOW_CLOUD",
-                        "HIGH_CLOUD"
-                    ]
+                "dry_run": {
+                    "type": "boolean"
                 },
-                "enhance_type": {
-                    "description": "EnhanceType specifies the type of enhancement to apply to the image.\nOptions: \"property\", \"property_usa\", \"warm\", \"neutral\", \"modern\"\n- \"property\" (DEFAULT): Best for real estate photography - balanced enhancement\n- \"property_usa\": USA-specific real estate enhancement (for AI version \u003c 4.0)\n- \"warm\": Warm color grading for cozy, inviting feel (AI version \u003e= 4.0)\n- \"neutral\": Neutral color grading for natural look (AI version \u003e= 4.0)\n- \"modern\": Modern, contemporary enhancement style (AI version \u003e= 4.0)\nDefault: \"property\"",
-                    "type": "string",
-                    "enum": [
-                        "property",
-                        "property_usa",
-                        "warm",
-                        "neutral",
-                        "modern"
-                    ],
-                    "example": "property"
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 },
-                "lens_correction": {
-                    "description": "LensCorrection removes lens distortion (barrel/pincushion effect).\nCorrects curved lines caused by wide-angle lenses commonly used in real estate.\nRecommended for all real estate photos.\nDefault: true",
-                    "type": "boolean",
-                    "example": true
+                "expired_files": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.RetentionFileItem"
+                    }
                 },
-                "metadata": {
-                    "description": "Optional metadata to store with the processing request for your own tracking",
-                    "type": "object",
-                    "additionalProperties": true
+                "files_deleted": {
+                    "description": "Storage objects (would be) deleted",
+                    "type": "integer"
                 },
-                "privacy": {
-                    "description": "Privacy blurs faces and license plates for privacy compliance.\nUseful for public listings where privacy protection is required.\nDefault: false",
-                    "type": "boolean",
-                    "example": false
+                "finished_at": {
+                    "type": "string"
                 },
-                "sky_replacement": {
-                    "description": "SkyReplacement enables AI-powered sky replacement with realistic clouds.\nWhen enabled, dull or overcast skies are replaced with attractive blue skies.\nWorks best with outdoor property photos.\nDefault: true (recommended for real estate)",
-                    "type": "boolean",
-                    "example": true
+                "preview_days": {
+                    "description": "0 = previews are kept forever",
+                    "type": "integer"
                 },
-                "upscale": {
-                    "description": "Upscale increases image resolution using AI upscaling technology.\nDoubles the resolution while maintaining quality.\nWarning: Significantly increases processing time and final file size.\nDefault: false",
-                    "type": "boolean",
-                    "example": false
+                "purged_orders": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.RetentionOrderItem"
+                    }
                 },
-                "vertical_correction": {
-                    "description": "VerticalCorrection automatically corrects vertical perspective distortion.\nStraightens walls and vertical lines that appear tilted due to camera angle.\nEssential for professional real estate photography.\nDefault: true (highly recommended)",
-                    "type": "boolean",
-                    "example": true
+                "started_at": {
+                    "type": "string"
                 },
-                "window_pull_type": {
-                    "description": "WindowPullType controls how window views are enhanced.\nOptions: \"NONE\", \"ONLY_WINDOWS\", \"WINDOWS_WITH_SKIES\"\n- \"NONE\": No window enhancement (keep original window views)\n- \"ONLY_WINDOWS\": Enhance window views only (bring out detail)\n- \"WINDOWS_WITH_SKIES\" (DEFAULT): Enhance windows AND replace exterior skies visible through windows (requires AI version \u003e= 5.2)\nDefault: \"WINDOWS_WITH_SKIES\" (recommended for best results)",
+                "truncated": {
+                    "description": "Dry run stopped at the page limit",
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.SavedFilterListResponse": {
+            "type": "object",
+            "properties": {
+                "filters": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.SavedFilterResponse"
+                    }
+                }
+            }
+        },
+        "models.SavedFilterRequest": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "name": {
                     "type": "string",
-                    "enum": [
-                        "NONE",
-                        "ONLY_WINDOWS",
-                        "WINDOWS_WITH_SKIES"
-                    ],
-                    "example": "WINDOWS_WITH_SKIES"
+                    "example": "Rush twilight shoots"
+                },
+                "query": {
+                    "type": "string",
+                    "example": "tag=rush,twilight\u0026status=processing"
                 }
             }
         },
-        "models.ProcessResponse": {
+        "models.SavedFilterResponse": {
             "type": "object",
             "properties": {
-                "message": {
+                "created_at": {
                     "type": "string"
                 },
-                "order_id": {
+                "filter_id": {
                     "type": "string"
                 },
-                "processing_params": {
-                    "type": "object",
-                    "additionalProperties": true
+                "name": {
+                    "type": "string"
                 },
-                "status": {
+                "query": {
+                    "type": "string"
+                },
+                "updated_at": {
                     "type": "string"
                 }
             }
         },
+        "models.SetCreditBudgetRequest": {
+            "type": "object",
+            "properties": {
+                "monthly_budget": {
+                    "type": "integer",
+                    "example": 100
+                }
+            }
+        },
+        "models.SetOrderOrganizationRequest": {
+            "type": "object",
+            "properties": {
+                "organization_id": {
+                    "type": "string",
+                    "example": "0b7e8f52-4c1d-4a6e-9f3b-5d2a1c8e7f60"
+                }
+            }
+        },
+        "models.SetOrderPropertyRequest": {
+            "type": "object",
+            "properties": {
+                "property_id": {
+                    "type": "string",
+                    "example": "6f1c2a9e-8d4b-4f0a-9a51-2f7c3e1b0d42"
+                }
+            }
+        },
         "models.StatusResponse": {
             "type": "object",
             "properties": {
@@ -1460,6 +6363,35 @@ const docTemplate = `{
                 }
             }
         },
+        "models.TagsRequest": {
+            "type": "object",
+            "required": [
+                "tags"
+            ],
+            "properties": {
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "twilight",
+                        "client-review"
+                    ]
+                }
+            }
+        },
+        "models.TagsResponse": {
+            "type": "object",
+            "properties": {
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
         "models.UploadErrorInfo": {
             "type": "object",
             "properties": {
@@ -1497,11 +6429,56 @@ const docTemplate = `{
                     "type": "string"
                 }
             }
+        },
+        "models.UsageCounter": {
+            "type": "object",
+            "properties": {
+                "limit": {
+                    "type": "integer"
+                },
+                "used": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.UsageLimit": {
+            "type": "object",
+            "properties": {
+                "limit": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.UsageResponse": {
+            "type": "object",
+            "properties": {
+                "active_orders": {
+                    "$ref": "#/definitions/models.UsageCounter"
+                },
+                "brackets_per_order": {
+                    "$ref": "#/definitions/models.UsageLimit"
+                },
+                "enforced": {
+                    "type": "boolean"
+                },
+                "period_start": {
+                    "type": "string"
+                },
+                "plan": {
+                    "type": "string"
+                },
+                "stored_bytes": {
+                    "$ref": "#/definitions/models.UsageCounter"
+                },
+                "upload_bytes_this_month": {
+                    "$ref": "#/definitions/models.UsageCounter"
+                }
+            }
         }
     },
     "securityDefinitions": {
         "Bearer": {
-            "description": "Type \"Bearer\" followed by a space and JWT token.",
+            "description": "Type \"Bearer\" followed by a space and a JWT token or an API key (ihdr_...).",
             "type": "apiKey",
             "name": "Authorization",
             "in": "header"
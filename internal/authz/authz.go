@@ -0,0 +1,78 @@
+package authz
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"instant-hdr-backend/internal/models"
+)
+
+// ErrNotMember is returned when the user has no role on the order or organization at all.
+// Handlers answer it with 404 so they don't reveal that the resource exists.
+var ErrNotMember = errors.New("not a member")
+
+// ForbiddenError is returned when the user has a role, but not a sufficient one
+type ForbiddenError struct {
+	Role     string
+	Required string
+}
+
+func (e *ForbiddenError) Error() string {
+	return fmt.Sprintf("requires the %s role, you have %s", e.Required, e.Role)
+}
+
+// Store looks up memberships (implemented by the repositories)
+type Store interface {
+	GetOrganizationMember(organizationID, userID uuid.UUID) (*models.OrganizationMember, error)
+}
+
+// OrderRole returns the user's role on an order. Personal orders are owned by their creator; orders of an
+// organization grant each member their organization role, and nothing to anyone else - the creator included.
+func OrderRole(store Store, order *models.Order, userID uuid.UUID) (string, error) {
+	if !order.OrganizationID.Valid {
+		if order.UserID == userID {
+			return models.RoleOwner, nil
+		}
+		return "", ErrNotMember
+	}
+	return OrganizationRole(store, order.OrganizationID.UUID, userID)
+}
+
+// OrganizationRole returns the user's role in an organization
+func OrganizationRole(store Store, organizationID, userID uuid.UUID) (string, error) {
+	member, err := store.GetOrganizationMember(organizationID, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrNotMember
+	}
+	if err != nil {
+		return "", err
+	}
+	return member.Role, nil
+}
+
+// RequireOrderRole checks that the user has at least the min role on the order
+func RequireOrderRole(store Store, order *models.Order, userID uuid.UUID, min string) error {
+	role, err := OrderRole(store, order, userID)
+	if err != nil {
+		return err
+	}
+	return require(role, min)
+}
+
+// RequireOrganizationRole checks that the user has at least the min role in the organization and returns that role
+func RequireOrganizationRole(store Store, organizationID, userID uuid.UUID, min string) (string, error) {
+	role, err := OrganizationRole(store, organizationID, userID)
+	if err != nil {
+		return "", err
+	}
+	return role, require(role, min)
+}
+
+func require(role, min string) error {
+	if !models.RoleAtLeast(role, min) {
+		return &ForbiddenError{Role: role, Required: min}
+	}
+	return nil
+}
@@ -329,6 +329,11 @@ func (c *Client) DeleteOrder(orderID string) error {
 	}
 	defer resp.Body.Close()
 
+	// Already deleted - deleting is idempotent
+	if resp.StatusCode == http.StatusNotFound {
+		return nil
+	}
+
 	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
 		return fmt.Errorf("failed to delete order: status %d, body: %s", resp.StatusCode, string(body))
@@ -1,8 +1,17 @@
 package config
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"instant-hdr-backend/internal/quota"
+	"instant-hdr-backend/internal/ratelimit"
 )
 
 type Config struct {
@@ -20,15 +29,90 @@ type Config struct {
 	SupabaseURL            string
 	SupabasePublishableKey string
 	SupabaseServiceRoleKey string
-	SupabaseUseRLS         bool // If true, use publishable key + RLS; if false, use service role key
-	SupabaseJWTSecret      string
+	SupabaseUseRLS         bool   // If true, use publishable key + RLS; if false, use service role key
+	SupabaseJWTSecret      string // HS256 key for legacy tokens
 	SupabaseStorageBucket  string
 
+	// JWT verification: asymmetric tokens (RS256/ES256/EdDSA) are checked against the JWKS endpoint,
+	// HS256 tokens against SUPABASE_JWT_SECRET unless JWTRejectHS256 is set
+	JWTJWKSURL     string
+	JWTJWKSRefresh time.Duration
+	JWTRejectHS256 bool
+	JWTIssuer      string        // Required iss claim (not checked when empty)
+	JWTAudience    string        // Required aud claim (not checked when empty)
+	JWTLeeway      time.Duration // Clock skew allowed on exp, nbf and iat
+
+	// Storage backend: "supabase" (default), "local" or "s3"
+	StorageBackend string
+
+	// Local filesystem storage (STORAGE_BACKEND=local)
+	LocalStoragePath string
+	LocalStorageURL  string // Public URL prefix for the built-in file-serving route
+
+	// S3-compatible storage (STORAGE_BACKEND=s3) - works with AWS S3, MinIO, R2
+	S3Endpoint        string
+	S3Region          string
+	S3Bucket          string
+	S3AccessKeyID     string
+	S3SecretAccessKey string
+	S3ForcePathStyle  bool
+	S3PublicURL       string
+
+	// Private bucket mode: only storage paths are persisted, clients get signed URLs
+	StoragePrivate    bool
+	SignedURLTTL      time.Duration
+	StorageSigningKey string // HMAC key for local storage signed URLs (defaults to a key derived from SUPABASE_JWT_SECRET)
+
+	// Retention: a background janitor expires watermarked previews and purges storage of deleted orders
+	RetentionEnabled          bool
+	RetentionPreviewDays      int // 0 = keep previews forever
+	RetentionDeletedOrderDays int // 0 = never purge deleted orders
+	JanitorInterval           time.Duration
+
+	// Quotas: per-plan limits on orders, brackets, monthly uploads and stored bytes
+	QuotaEnabled     bool
+	QuotaDefaultPlan string // Plan for users without a user_plans row
+
+	// Credits: unwatermarked downloads cost a credit; with CreditsEnabled they need a positive balance and
+	// must stay within the monthly budget (the user's own, or CreditsMonthlyBudget; 0 = none)
+	CreditsEnabled       bool
+	CreditsMonthlyBudget int
+
+	// Admin: users allowed to call /api/v1/admin endpoints - listed by ID (the JWT sub claim), or carrying
+	// AdminRole in the app_metadata.role (or app_metadata.roles) claim of their JWT
+	AdminUserIDs    []string
+	AdminRole       string
+	AdminRoleKeyTTL time.Duration // Longest life of an admin key created by a role-based admin
+
+	// Rate limiting: fixed-window limits per API key, user or client IP, counted in this process ("memory")
+	// or in Postgres ("database", shared by all replicas)
+	RateLimitEnabled  bool
+	RateLimitStore    string
+	RateLimitAPI      ratelimit.Rule // Every authenticated request
+	RateLimitStatus   ratelimit.Rule // Status polling, which calls AutoEnhance on every request
+	RateLimitDownload ratelimit.Rule // Image downloads, streams and exports
+	RateLimitPublic   ratelimit.Rule // Health checks, per IP
+	RateLimitWebhook  ratelimit.Rule // AutoEnhance webhooks, per IP
+
+	// Realtime events: SSE streams send a heartbeat comment at this interval and user streams can resume from the
+	// last EventsHistory events (order streams resume from the outbox). The outbox is checked for events to
+	// publish or retry every EventsDispatchInterval, and right after new ones are stored. Events reach the SSE
+	// subscribers of this process only ("memory") or of every replica through Postgres LISTEN/NOTIFY ("database").
+	EventsTransport        string
+	EventsHeartbeat        time.Duration
+	EventsHistory          int
+	EventsDispatchInterval time.Duration
+
+	// Proxies whose X-Forwarded-For is trusted for the client IP (default: none, the remote address is the client IP)
+	TrustedProxies []string
+
 	// Webhook
 	WebhookCallbackURL string
 
 	// Database
-	DatabaseURL string
+	DatabaseURL           string
+	MigrationFailureFatal bool          // Abort startup when migrations cannot be applied (default: log and keep serving)
+	MigrationLockTimeout  time.Duration // How long to wait for another instance that is migrating
 
 	// Server
 	Port        string
@@ -55,6 +139,27 @@ func Load() (*Config, error) {
 		SupabaseJWTSecret:      getEnv("SUPABASE_JWT_SECRET", ""),
 		SupabaseStorageBucket:  getEnv("SUPABASE_STORAGE_BUCKET", "hdr-images"),
 
+		JWTJWKSURL:     getEnv("JWT_JWKS_URL", ""),
+		JWTRejectHS256: getEnv("JWT_REJECT_HS256", "false") == "true",
+		JWTIssuer:      getEnv("JWT_ISSUER", ""),
+		JWTAudience:    getEnv("JWT_AUDIENCE", ""),
+
+		StorageBackend: getEnv("STORAGE_BACKEND", "supabase"),
+
+		LocalStoragePath: getEnv("LOCAL_STORAGE_PATH", "./data/storage"),
+		LocalStorageURL:  getEnv("LOCAL_STORAGE_URL", ""),
+
+		S3Endpoint:        getEnv("S3_ENDPOINT", ""),
+		S3Region:          getEnv("S3_REGION", "us-east-1"),
+		S3Bucket:          getEnv("S3_BUCKET", ""),
+		S3AccessKeyID:     getEnv("S3_ACCESS_KEY_ID", ""),
+		S3SecretAccessKey: getEnv("S3_SECRET_ACCESS_KEY", ""),
+		S3ForcePathStyle:  getEnv("S3_FORCE_PATH_STYLE", "true") == "true", // MinIO requires path-style
+		S3PublicURL:       getEnv("S3_PUBLIC_URL", ""),
+
+		StoragePrivate:    getEnv("STORAGE_PRIVATE", "false") == "true",
+		StorageSigningKey: getEnv("STORAGE_SIGNING_KEY", ""),
+
 		WebhookCallbackURL: getEnv("WEBHOOK_CALLBACK_URL", ""),
 
 		DatabaseURL: getEnv("DATABASE_URL", ""),
@@ -64,6 +169,96 @@ func Load() (*Config, error) {
 		BaseURL:     getEnv("BASE_URL", "http://localhost:8080"),
 	}
 
+	signedURLTTL, err := time.ParseDuration(getEnv("SIGNED_URL_TTL", "1h"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid SIGNED_URL_TTL: %w", err)
+	}
+	cfg.SignedURLTTL = signedURLTTL
+
+	if cfg.StorageSigningKey == "" && cfg.SupabaseJWTSecret != "" {
+		cfg.StorageSigningKey = deriveKey(cfg.SupabaseJWTSecret, "storage-url")
+	}
+
+	if cfg.JWTJWKSRefresh, err = time.ParseDuration(getEnv("JWT_JWKS_REFRESH", "10m")); err != nil {
+		return nil, fmt.Errorf("invalid JWT_JWKS_REFRESH: %w", err)
+	}
+	if cfg.JWTLeeway, err = time.ParseDuration(getEnv("JWT_LEEWAY", "30s")); err != nil {
+		return nil, fmt.Errorf("invalid JWT_LEEWAY: %w", err)
+	}
+
+	cfg.RetentionEnabled = getEnv("RETENTION_ENABLED", "false") == "true"
+	if cfg.RetentionPreviewDays, err = getEnvInt("RETENTION_PREVIEW_DAYS", 30); err != nil {
+		return nil, err
+	}
+	if cfg.RetentionDeletedOrderDays, err = getEnvInt("RETENTION_DELETED_ORDER_DAYS", 30); err != nil {
+		return nil, err
+	}
+	if cfg.JanitorInterval, err = time.ParseDuration(getEnv("JANITOR_INTERVAL", "6h")); err != nil {
+		return nil, fmt.Errorf("invalid JANITOR_INTERVAL: %w", err)
+	}
+
+	cfg.QuotaEnabled = getEnv("QUOTA_ENABLED", "false") == "true"
+	cfg.QuotaDefaultPlan = getEnv("QUOTA_DEFAULT_PLAN", "free")
+
+	cfg.CreditsEnabled = getEnv("CREDITS_ENABLED", "false") == "true"
+	if cfg.CreditsMonthlyBudget, err = getEnvInt("CREDITS_MONTHLY_BUDGET", 0); err != nil {
+		return nil, err
+	}
+
+	for _, id := range strings.Split(getEnv("ADMIN_USER_IDS", ""), ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			cfg.AdminUserIDs = append(cfg.AdminUserIDs, id)
+		}
+	}
+	cfg.AdminRole = strings.TrimSpace(getEnv("ADMIN_ROLE", ""))
+	if cfg.AdminRoleKeyTTL, err = time.ParseDuration(getEnv("ADMIN_ROLE_KEY_TTL", "24h")); err != nil {
+		return nil, fmt.Errorf("invalid ADMIN_ROLE_KEY_TTL: %w", err)
+	}
+
+	cfg.RateLimitEnabled = getEnv("RATE_LIMIT_ENABLED", "false") == "true"
+	cfg.RateLimitStore = getEnv("RATE_LIMIT_STORE", "memory")
+	for _, rule := range []struct {
+		env      string
+		fallback string
+		rule     *ratelimit.Rule
+	}{
+		{"RATE_LIMIT_API", "600/1m", &cfg.RateLimitAPI},
+		{"RATE_LIMIT_STATUS", "60/1m", &cfg.RateLimitStatus},
+		{"RATE_LIMIT_DOWNLOAD", "120/1m", &cfg.RateLimitDownload},
+		{"RATE_LIMIT_PUBLIC", "120/1m", &cfg.RateLimitPublic},
+		{"RATE_LIMIT_WEBHOOK", "1200/1m", &cfg.RateLimitWebhook},
+	} {
+		if *rule.rule, err = ratelimit.ParseRule(getEnv(rule.env, rule.fallback)); err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", rule.env, err)
+		}
+	}
+	for _, proxy := range strings.Split(getEnv("TRUSTED_PROXIES", ""), ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			cfg.TrustedProxies = append(cfg.TrustedProxies, proxy)
+		}
+	}
+
+	cfg.EventsTransport = getEnv("EVENTS_TRANSPORT", "memory")
+	if cfg.EventsHeartbeat, err = time.ParseDuration(getEnv("EVENTS_HEARTBEAT", "15s")); err != nil {
+		return nil, fmt.Errorf("invalid EVENTS_HEARTBEAT: %w", err)
+	}
+	if cfg.EventsHistory, err = getEnvInt("EVENTS_HISTORY", 1000); err != nil {
+		return nil, err
+	}
+	if cfg.EventsDispatchInterval, err = time.ParseDuration(getEnv("EVENTS_DISPATCH_INTERVAL", "5s")); err != nil {
+		return nil, fmt.Errorf("invalid EVENTS_DISPATCH_INTERVAL: %w", err)
+	}
+
+	cfg.MigrationFailureFatal = getEnv("MIGRATION_FAILURE_FATAL", "false") == "true"
+	if cfg.MigrationLockTimeout, err = time.ParseDuration(getEnv("MIGRATION_LOCK_TIMEOUT", "5m")); err != nil {
+		return nil, fmt.Errorf("invalid MIGRATION_LOCK_TIMEOUT: %w", err)
+	}
+
+	// Local files are served by this server unless another URL is configured
+	if cfg.LocalStorageURL == "" {
+		cfg.LocalStorageURL = cfg.BaseURL + "/files"
+	}
+
 	if err := cfg.Validate(); err != nil {
 		return nil, fmt.Errorf("invalid configuration: %w", err)
 	}
@@ -92,17 +287,122 @@ func (c *Config) Validate() error {
 	if c.SupabaseServiceRoleKey == "" {
 		return fmt.Errorf("SUPABASE_SERVICE_ROLE_KEY is required for Realtime broadcast")
 	}
-	if c.SupabaseJWTSecret == "" {
-		return fmt.Errorf("SUPABASE_JWT_SECRET is required")
+
+	// Tokens must be verifiable one way or the other
+	if c.JWTJWKSURL == "" && !c.AcceptsHS256() {
+		return fmt.Errorf("JWT_JWKS_URL is required unless SUPABASE_JWT_SECRET is set and JWT_REJECT_HS256 is not")
+	}
+	if c.JWTJWKSURL != "" && c.JWTJWKSRefresh <= 0 {
+		return fmt.Errorf("JWT_JWKS_REFRESH must be positive")
+	}
+	if c.JWTLeeway < 0 {
+		return fmt.Errorf("JWT_LEEWAY must not be negative")
+	}
+
+	// Storage backend
+	switch c.StorageBackend {
+	case "supabase", "local":
+	case "s3":
+		if c.S3Endpoint == "" || c.S3Bucket == "" {
+			return fmt.Errorf("S3_ENDPOINT and S3_BUCKET are required when STORAGE_BACKEND=s3")
+		}
+		if c.S3AccessKeyID == "" || c.S3SecretAccessKey == "" {
+			return fmt.Errorf("S3_ACCESS_KEY_ID and S3_SECRET_ACCESS_KEY are required when STORAGE_BACKEND=s3")
+		}
+	default:
+		return fmt.Errorf("STORAGE_BACKEND must be one of: supabase, local, s3")
+	}
+
+	if c.StoragePrivate && c.SignedURLTTL <= 0 {
+		return fmt.Errorf("SIGNED_URL_TTL must be positive")
+	}
+	if c.StoragePrivate && c.StorageBackend == "local" && c.StorageSigningKey == "" {
+		return fmt.Errorf("STORAGE_SIGNING_KEY is required for private local storage when SUPABASE_JWT_SECRET is not set")
+	}
+
+	if c.RetentionPreviewDays < 0 || c.RetentionDeletedOrderDays < 0 {
+		return fmt.Errorf("RETENTION_PREVIEW_DAYS and RETENTION_DELETED_ORDER_DAYS must not be negative")
+	}
+	if c.RetentionEnabled && c.JanitorInterval <= 0 {
+		return fmt.Errorf("JANITOR_INTERVAL must be positive")
+	}
+
+	if c.EventsDispatchInterval <= 0 {
+		return fmt.Errorf("EVENTS_DISPATCH_INTERVAL must be positive")
+	}
+
+	if c.AdminRole != "" && c.AdminRoleKeyTTL <= 0 {
+		return fmt.Errorf("ADMIN_ROLE_KEY_TTL must be positive")
+	}
+
+	if _, ok := quota.Plans[c.QuotaDefaultPlan]; !ok {
+		return fmt.Errorf("QUOTA_DEFAULT_PLAN must be one of: free, pro, unlimited")
+	}
+	if c.CreditsMonthlyBudget < 0 {
+		return fmt.Errorf("CREDITS_MONTHLY_BUDGET must not be negative")
+	}
+
+	switch c.RateLimitStore {
+	case "", "memory":
+	case "database":
+		if c.RateLimitEnabled && c.DatabaseURL == "" {
+			return fmt.Errorf("RATE_LIMIT_STORE=database requires DATABASE_URL")
+		}
+	default:
+		return fmt.Errorf("RATE_LIMIT_STORE must be one of: memory, database")
+	}
+
+	switch c.EventsTransport {
+	case "", "memory":
+	case "database":
+		if c.DatabaseURL == "" {
+			return fmt.Errorf("EVENTS_TRANSPORT=database requires DATABASE_URL")
+		}
+	default:
+		return fmt.Errorf("EVENTS_TRANSPORT must be one of: memory, database")
 	}
 
 	// Imagen API fields are kept for backward compatibility but not validated
 	return nil
 }
 
+// AcceptsHS256 reports whether legacy HS256 tokens signed with SUPABASE_JWT_SECRET are accepted
+func (c *Config) AcceptsHS256() bool {
+	return c.SupabaseJWTSecret != "" && !c.JWTRejectHS256
+}
+
+// IsAdmin reports whether userID is listed in ADMIN_USER_IDS
+func (c *Config) IsAdmin(userID string) bool {
+	for _, id := range c.AdminUserIDs {
+		if strings.EqualFold(id, userID) {
+			return true
+		}
+	}
+	return false
+}
+
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
 	}
 	return defaultValue
 }
+
+func getEnvInt(key string, defaultValue int) (int, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return n, nil
+}
+
+// deriveKey returns a key for purpose derived from secret, so the secret itself never signs anything else
+func deriveKey(secret, purpose string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(purpose))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,155 @@
+package credits
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"instant-hdr-backend/internal/models"
+)
+
+// Ledger entry kinds
+const (
+	KindSpend = "spend" // An unwatermarked download from AutoEnhance, -1
+	KindGrant = "grant" // Credits added (or taken back) by an admin
+)
+
+// Limit names reported in credits_exceeded errors
+const (
+	LimitBalance       = "credit_balance"
+	LimitMonthlyBudget = "monthly_credit_budget"
+)
+
+// Spend is a paid download: one image of an order, in the quality and format downloaded from AutoEnhance
+type Spend struct {
+	UserID  uuid.UUID // Who pays
+	OrderID uuid.UUID
+	ImageID string
+	Quality string
+	Format  string
+}
+
+// Account is a user's balance and spending this month
+type Account struct {
+	Balance        int
+	MonthlyBudget  *int // Set for the user; nil means the default budget
+	SpentThisMonth int
+}
+
+// Store keeps balances, budgets and the ledger (implemented by the repositories)
+type Store interface {
+	GetCreditAccount(userID uuid.UUID, periodStart time.Time) (*Account, error)
+	// SpendCredit records spend and takes a credit from the balance. When the same spend is already recorded it
+	// returns that entry and false instead. check sees the account before the spend, with the balance locked;
+	// its error cancels the spend.
+	SpendCredit(spend Spend, periodStart time.Time, check func(Account) error) (*models.CreditLedgerEntry, bool, error)
+	RefundCredit(entryID uuid.UUID) error // Gives the credit of a spend back; refunding twice does nothing
+	GrantCredits(userID uuid.UUID, amount int, reason string, actorID uuid.NullUUID) (*models.CreditLedgerEntry, error)
+	SetCreditBudget(userID uuid.UUID, monthlyBudget *int) error                       // nil restores the default
+	ListCreditLedger(userID uuid.UUID, limit int) ([]models.CreditLedgerEntry, error) // Newest first
+}
+
+// ExceededError is returned when a user can't pay for a download
+type ExceededError struct {
+	Limit   string
+	Balance int
+	Used    int // Spent this month
+	Max     int // Monthly budget; 0 when there is none
+}
+
+func (e *ExceededError) Error() string {
+	if e.Limit == LimitMonthlyBudget {
+		return fmt.Sprintf("monthly credit budget exceeded: spent %d of %d this month", e.Used, e.Max)
+	}
+	return fmt.Sprintf("not enough credits: balance is %d", e.Balance)
+}
+
+// StatusCode is 402: the download needs credits
+func (e *ExceededError) StatusCode() int {
+	return http.StatusPaymentRequired
+}
+
+// Service charges unwatermarked downloads. When disabled, spends are still recorded (the balance may go negative)
+// but never refused.
+type Service struct {
+	store         Store
+	enabled       bool
+	defaultBudget int
+	now           func() time.Time
+}
+
+// NewService applies defaultBudget (0 = none) to users without a budget of their own
+func NewService(store Store, enabled bool, defaultBudget int) *Service {
+	return &Service{
+		store:         store,
+		enabled:       enabled,
+		defaultBudget: defaultBudget,
+		now:           time.Now,
+	}
+}
+
+// Enabled reports whether balances and budgets are enforced
+func (s *Service) Enabled() bool {
+	return s.enabled
+}
+
+// PeriodStart returns the start of the current budget period (UTC calendar month)
+func (s *Service) PeriodStart() time.Time {
+	now := s.now().UTC()
+	return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// Budget returns the monthly budget that applies to the account (0 = none)
+func (s *Service) Budget(account Account) int {
+	if account.MonthlyBudget != nil {
+		return *account.MonthlyBudget
+	}
+	return s.defaultBudget
+}
+
+// Account returns the user's balance, budget and spending this month
+func (s *Service) Account(userID uuid.UUID) (*Account, error) {
+	account, err := s.store.GetCreditAccount(userID, s.PeriodStart())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get credits: %w", err)
+	}
+	return account, nil
+}
+
+// Spend charges a credit for spend before it is downloaded, and reports whether one was charged: a download
+// that was paid before is free. Returns an *ExceededError when the user can't pay.
+func (s *Service) Spend(spend Spend) (*models.CreditLedgerEntry, bool, error) {
+	return s.store.SpendCredit(spend, s.PeriodStart(), func(account Account) error {
+		if !s.enabled {
+			return nil
+		}
+		if budget := s.Budget(account); budget > 0 && account.SpentThisMonth >= budget {
+			return &ExceededError{Limit: LimitMonthlyBudget, Balance: account.Balance, Used: account.SpentThisMonth, Max: budget}
+		}
+		if account.Balance < 1 {
+			return &ExceededError{Limit: LimitBalance, Balance: account.Balance, Used: account.SpentThisMonth, Max: s.Budget(account)}
+		}
+		return nil
+	})
+}
+
+// Refund gives back the credit of a spend whose download failed
+func (s *Service) Refund(entryID uuid.UUID) error {
+	return s.store.RefundCredit(entryID)
+}
+
+// Grant adds amount credits to the user's balance (a negative amount takes them back)
+func (s *Service) Grant(userID uuid.UUID, amount int, reason string, actorID uuid.NullUUID) (*models.CreditLedgerEntry, error) {
+	return s.store.GrantCredits(userID, amount, reason, actorID)
+}
+
+// SetBudget sets the user's monthly budget; nil restores the default
+func (s *Service) SetBudget(userID uuid.UUID, monthlyBudget *int) error {
+	return s.store.SetCreditBudget(userID, monthlyBudget)
+}
+
+// Ledger returns the user's last limit ledger entries, newest first
+func (s *Service) Ledger(userID uuid.UUID, limit int) ([]models.CreditLedgerEntry, error) {
+	return s.store.ListCreditLedger(userID, limit)
+}
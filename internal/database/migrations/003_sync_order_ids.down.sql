@@ -0,0 +1,15 @@
+-- Migration 003 (down): Drop the id check constraint and allow NULL autoenhance_order_id again
+-- The backfilled autoenhance_order_id values are left in place.
+
+ALTER TABLE orders DROP CONSTRAINT IF EXISTS orders_autoenhance_order_id_matches_id;
+
+DO $$
+BEGIN
+    IF EXISTS (
+        SELECT 1 FROM information_schema.columns 
+        WHERE table_name = 'orders' 
+        AND column_name = 'autoenhance_order_id'
+    ) THEN
+        ALTER TABLE orders ALTER COLUMN autoenhance_order_id DROP NOT NULL;
+    END IF;
+END $$;
@@ -0,0 +1,15 @@
+-- Migration 004 (down): Restore autoenhance_order_id as a copy of id
+
+-- Step 1: Re-add the column, filled from id
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS autoenhance_order_id TEXT;
+UPDATE orders SET autoenhance_order_id = id::TEXT WHERE autoenhance_order_id IS NULL;
+ALTER TABLE orders ALTER COLUMN autoenhance_order_id SET NOT NULL;
+
+-- Step 2: Restore the index
+CREATE INDEX IF NOT EXISTS idx_orders_autoenhance_order_id ON orders(autoenhance_order_id);
+
+-- Step 3: Restore the check constraint added by migration 003
+ALTER TABLE orders DROP CONSTRAINT IF EXISTS orders_autoenhance_order_id_matches_id;
+ALTER TABLE orders 
+ADD CONSTRAINT orders_autoenhance_order_id_matches_id 
+CHECK (autoenhance_order_id = id::TEXT);
@@ -0,0 +1,16 @@
+-- Migration 006 (down): Remove the cached AutoEnhance fields from orders
+
+-- Step 1: Drop indexes
+DROP INDEX IF EXISTS idx_orders_user_status_processing;
+DROP INDEX IF EXISTS idx_orders_is_processing;
+DROP INDEX IF EXISTS idx_orders_autoenhance_status;
+
+-- Step 2: Drop columns
+ALTER TABLE orders
+    DROP COLUMN IF EXISTS autoenhance_last_updated_at,
+    DROP COLUMN IF EXISTS total_images,
+    DROP COLUMN IF EXISTS is_deleted,
+    DROP COLUMN IF EXISTS is_merging,
+    DROP COLUMN IF EXISTS is_processing,
+    DROP COLUMN IF EXISTS autoenhance_status,
+    DROP COLUMN IF EXISTS name;
@@ -0,0 +1,15 @@
+-- Migration 007 (down): Remove derivative tracking from order_files
+-- Derivative rows stay behind as plain files; their objects are not deleted.
+
+-- Step 1: Drop indexes
+DROP INDEX IF EXISTS idx_order_files_parent_file_id;
+DROP INDEX IF EXISTS idx_order_files_variant_lookup;
+
+-- Step 2: Drop columns
+ALTER TABLE order_files
+    DROP COLUMN IF EXISTS height,
+    DROP COLUMN IF EXISTS width,
+    DROP COLUMN IF EXISTS parent_file_id,
+    DROP COLUMN IF EXISTS watermark,
+    DROP COLUMN IF EXISTS format,
+    DROP COLUMN IF EXISTS variant;
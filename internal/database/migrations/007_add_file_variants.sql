@@ -0,0 +1,65 @@
+-- Migration 007: Track image derivatives on order_files
+-- The high-res image is downloaded from AutoEnhance once; thumbnail, preview and medium
+-- sizes (and PNG/JPEG re-encodes) are generated locally and linked to it via parent_file_id.
+
+-- Step 1: Add derivative columns
+DO $$
+BEGIN
+    -- Size preset: thumbnail, preview, medium, high or custom_{width}w
+    IF NOT EXISTS (
+        SELECT 1 FROM information_schema.columns 
+        WHERE table_name = 'order_files' 
+        AND column_name = 'variant'
+    ) THEN
+        ALTER TABLE order_files ADD COLUMN variant TEXT;
+    END IF;
+
+    -- Encoded format: jpeg, png or webp
+    IF NOT EXISTS (
+        SELECT 1 FROM information_schema.columns 
+        WHERE table_name = 'order_files' 
+        AND column_name = 'format'
+    ) THEN
+        ALTER TABLE order_files ADD COLUMN format TEXT;
+    END IF;
+
+    IF NOT EXISTS (
+        SELECT 1 FROM information_schema.columns 
+        WHERE table_name = 'order_files' 
+        AND column_name = 'watermark'
+    ) THEN
+        ALTER TABLE order_files ADD COLUMN watermark BOOLEAN;
+    END IF;
+
+    -- Source file this derivative was generated from (NULL for AutoEnhance downloads)
+    IF NOT EXISTS (
+        SELECT 1 FROM information_schema.columns 
+        WHERE table_name = 'order_files' 
+        AND column_name = 'parent_file_id'
+    ) THEN
+        ALTER TABLE order_files ADD COLUMN parent_file_id UUID REFERENCES order_files(id) ON DELETE SET NULL;
+    END IF;
+
+    IF NOT EXISTS (
+        SELECT 1 FROM information_schema.columns 
+        WHERE table_name = 'order_files' 
+        AND column_name = 'width'
+    ) THEN
+        ALTER TABLE order_files ADD COLUMN width INTEGER;
+    END IF;
+
+    IF NOT EXISTS (
+        SELECT 1 FROM information_schema.columns 
+        WHERE table_name = 'order_files' 
+        AND column_name = 'height'
+    ) THEN
+        ALTER TABLE order_files ADD COLUMN height INTEGER;
+    END IF;
+END $$;
+
+-- Step 2: Index for derivative cache lookups
+CREATE INDEX IF NOT EXISTS idx_order_files_variant_lookup
+    ON order_files(order_id, autoenhance_image_id, variant, format, watermark);
+
+-- Step 3: Index for finding derivatives of a source file
+CREATE INDEX IF NOT EXISTS idx_order_files_parent_file_id ON order_files(parent_file_id);
@@ -0,0 +1,5 @@
+-- Migration 008 (down): Drop plans and usage tracking
+-- idx_order_files_user_id predates this migration (002) and is kept.
+
+DROP TABLE IF EXISTS usage_events;
+DROP TABLE IF EXISTS user_plans;
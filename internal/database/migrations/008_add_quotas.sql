@@ -0,0 +1,42 @@
+-- Migration 008: Per-user plans and usage tracking for quotas
+-- Stored bytes come from order_files.file_size; uploaded bracket bytes are recorded in usage_events
+-- because brackets go straight to AutoEnhance and have no order_files row.
+
+-- Step 1: Plan assignment per user (users without a row get QUOTA_DEFAULT_PLAN)
+CREATE TABLE IF NOT EXISTS user_plans (
+    user_id UUID PRIMARY KEY,
+    plan TEXT NOT NULL DEFAULT 'free',
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW()
+);
+
+-- Step 2: Metered usage events (kind: 'upload')
+CREATE TABLE IF NOT EXISTS usage_events (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL,
+    order_id UUID REFERENCES orders(id) ON DELETE SET NULL,
+    kind TEXT NOT NULL,
+    bytes BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+-- Step 3: Index for monthly usage sums
+CREATE INDEX IF NOT EXISTS idx_usage_events_user_kind_created ON usage_events(user_id, kind, created_at);
+
+-- Step 4: Index for stored bytes per user
+CREATE INDEX IF NOT EXISTS idx_order_files_user_id ON order_files(user_id);
+
+-- Step 5: Row Level Security - users can read their own plan and usage, only the backend writes
+ALTER TABLE user_plans ENABLE ROW LEVEL SECURITY;
+ALTER TABLE usage_events ENABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS "Users can select their own plan" ON user_plans;
+DROP POLICY IF EXISTS "Users can select their own usage" ON usage_events;
+
+CREATE POLICY "Users can select their own plan" ON user_plans
+    FOR SELECT
+    USING (auth.uid() = user_id);
+
+CREATE POLICY "Users can select their own usage" ON usage_events
+    FOR SELECT
+    USING (auth.uid() = user_id);
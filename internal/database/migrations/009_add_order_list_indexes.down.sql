@@ -0,0 +1,5 @@
+-- Migration 009 (down): Drop the order listing indexes
+
+DROP INDEX IF EXISTS idx_orders_user_name_id;
+DROP INDEX IF EXISTS idx_orders_user_updated_id;
+DROP INDEX IF EXISTS idx_orders_user_created_id;
@@ -0,0 +1,6 @@
+-- Migration 009: Indexes for keyset-paginated order listings
+-- GET /orders pages on (sort key, id) within a user, newest first by default.
+
+CREATE INDEX IF NOT EXISTS idx_orders_user_created_id ON orders(user_id, created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_orders_user_updated_id ON orders(user_id, updated_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_orders_user_name_id ON orders(user_id, (COALESCE(name, '')), id);
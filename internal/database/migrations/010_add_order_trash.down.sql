@@ -0,0 +1,5 @@
+-- Migration 010 (down): Remove the trash
+-- Orders still in the trash become visible again.
+
+DROP INDEX IF EXISTS idx_orders_user_deleted_at;
+ALTER TABLE orders DROP COLUMN IF EXISTS deleted_at;
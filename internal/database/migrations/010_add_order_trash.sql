@@ -0,0 +1,9 @@
+-- Migration 010: Trash for orders
+-- DELETE /orders/:order_id sets deleted_at instead of deleting the order. Trashed orders are hidden from
+-- the API until restored, and the janitor purges them (AutoEnhance order, storage and row) after the retention window.
+
+-- Step 1: Add deleted_at
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+
+-- Step 2: Index for the trash listing and the janitor
+CREATE INDEX IF NOT EXISTS idx_orders_user_deleted_at ON orders(user_id, deleted_at) WHERE deleted_at IS NOT NULL;
@@ -0,0 +1,6 @@
+-- Migration 011 (down): Remove properties
+-- Orders keep their data; only the link to a property is lost.
+
+DROP INDEX IF EXISTS idx_orders_property_id;
+ALTER TABLE orders DROP COLUMN IF EXISTS property_id;
+DROP TABLE IF EXISTS properties;
@@ -0,0 +1,51 @@
+-- Migration 011: Properties above orders
+-- A property (address, MLS number, client) groups the shoots done for it. Orders link to at most one
+-- property; deleting a property unlinks its orders instead of deleting them.
+
+-- Step 1: Properties table
+CREATE TABLE IF NOT EXISTS properties (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL,
+    address TEXT NOT NULL,
+    mls_number TEXT,
+    client_name TEXT,
+    notes TEXT,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_properties_user_created ON properties(user_id, created_at DESC);
+
+DROP TRIGGER IF EXISTS update_properties_updated_at ON properties;
+CREATE TRIGGER update_properties_updated_at
+    BEFORE UPDATE ON properties
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- Step 2: Link orders to properties
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS property_id UUID REFERENCES properties(id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS idx_orders_property_id ON orders(property_id) WHERE property_id IS NOT NULL;
+
+-- Step 3: Row Level Security for properties
+ALTER TABLE properties ENABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS "Users can select their own properties" ON properties;
+DROP POLICY IF EXISTS "Users can insert their own properties" ON properties;
+DROP POLICY IF EXISTS "Users can update their own properties" ON properties;
+DROP POLICY IF EXISTS "Users can delete their own properties" ON properties;
+
+CREATE POLICY "Users can select their own properties" ON properties
+    FOR SELECT
+    USING (auth.uid() = user_id);
+
+CREATE POLICY "Users can insert their own properties" ON properties
+    FOR INSERT
+    WITH CHECK (auth.uid() = user_id);
+
+CREATE POLICY "Users can update their own properties" ON properties
+    FOR UPDATE
+    USING (auth.uid() = user_id);
+
+CREATE POLICY "Users can delete their own properties" ON properties
+    FOR DELETE
+    USING (auth.uid() = user_id);
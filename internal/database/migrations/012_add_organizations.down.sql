@@ -0,0 +1,70 @@
+-- Migration 012 (down): Remove organizations
+-- Organization orders become personal orders of their creators again, and the per-user policies come back.
+
+-- Step 1: Per-user policies for orders, order_files and brackets
+DROP POLICY IF EXISTS "Members can select orders" ON orders;
+DROP POLICY IF EXISTS "Members can insert orders" ON orders;
+DROP POLICY IF EXISTS "Editors can update orders" ON orders;
+DROP POLICY IF EXISTS "Admins can delete orders" ON orders;
+
+CREATE POLICY "Users can select their own orders" ON orders
+    FOR SELECT
+    USING (auth.uid() = user_id);
+
+CREATE POLICY "Users can insert their own orders" ON orders
+    FOR INSERT
+    WITH CHECK (auth.uid() = user_id);
+
+CREATE POLICY "Users can update their own orders" ON orders
+    FOR UPDATE
+    USING (auth.uid() = user_id);
+
+CREATE POLICY "Users can delete their own orders" ON orders
+    FOR DELETE
+    USING (auth.uid() = user_id);
+
+DROP POLICY IF EXISTS "Members can select order files" ON order_files;
+DROP POLICY IF EXISTS "Editors can manage order files" ON order_files;
+
+CREATE POLICY "Users can select their own files" ON order_files
+    FOR SELECT
+    USING (auth.uid() = user_id);
+
+CREATE POLICY "Users can insert their own files" ON order_files
+    FOR INSERT
+    WITH CHECK (auth.uid() = user_id);
+
+CREATE POLICY "Users can update their own files" ON order_files
+    FOR UPDATE
+    USING (auth.uid() = user_id);
+
+CREATE POLICY "Users can delete their own files" ON order_files
+    FOR DELETE
+    USING (auth.uid() = user_id);
+
+DROP POLICY IF EXISTS "Members can select brackets" ON brackets;
+DROP POLICY IF EXISTS "Editors can manage brackets" ON brackets;
+
+CREATE POLICY "Users can select their own brackets" ON brackets
+    FOR SELECT
+    USING (EXISTS (SELECT 1 FROM orders WHERE orders.id = brackets.order_id AND orders.user_id = auth.uid()));
+
+CREATE POLICY "Users can insert their own brackets" ON brackets
+    FOR INSERT
+    WITH CHECK (EXISTS (SELECT 1 FROM orders WHERE orders.id = brackets.order_id AND orders.user_id = auth.uid()));
+
+CREATE POLICY "Users can update their own brackets" ON brackets
+    FOR UPDATE
+    USING (EXISTS (SELECT 1 FROM orders WHERE orders.id = brackets.order_id AND orders.user_id = auth.uid()));
+
+CREATE POLICY "Users can delete their own brackets" ON brackets
+    FOR DELETE
+    USING (EXISTS (SELECT 1 FROM orders WHERE orders.id = brackets.order_id AND orders.user_id = auth.uid()));
+
+-- Step 2: Organizations
+DROP INDEX IF EXISTS idx_orders_organization_created;
+ALTER TABLE orders DROP COLUMN IF EXISTS organization_id;
+DROP FUNCTION IF EXISTS order_role_at_least(UUID, UUID, TEXT);
+DROP FUNCTION IF EXISTS organization_role_at_least(UUID, TEXT);
+DROP TABLE IF EXISTS organization_members;
+DROP TABLE IF EXISTS organizations;
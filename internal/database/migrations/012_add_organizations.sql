@@ -0,0 +1,186 @@
+-- Migration 012: Organizations with members and roles
+-- Orders can belong to an organization; every member then sees them and may act on them according to their role
+-- (viewer < editor < admin < owner). orders.user_id stays the creator: their plan is charged and their storage prefix is used.
+-- Deleting an organization hands its orders back to their creators.
+
+-- Step 1: Organizations and members
+CREATE TABLE IF NOT EXISTS organizations (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name TEXT NOT NULL,
+    created_by UUID NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW()
+);
+
+DROP TRIGGER IF EXISTS update_organizations_updated_at ON organizations;
+CREATE TRIGGER update_organizations_updated_at
+    BEFORE UPDATE ON organizations
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TABLE IF NOT EXISTS organization_members (
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL,
+    role TEXT NOT NULL CHECK (role IN ('viewer', 'editor', 'admin', 'owner')),
+    created_at TIMESTAMP DEFAULT NOW(),
+    PRIMARY KEY (organization_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_organization_members_user_id ON organization_members(user_id);
+
+-- Step 2: Orders owned by an organization
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS organization_id UUID REFERENCES organizations(id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS idx_orders_organization_created ON orders(organization_id, created_at DESC) WHERE organization_id IS NOT NULL;
+
+-- Step 3: Role checks for the policies. SECURITY DEFINER so policies on organization_members can use them without recursing.
+CREATE OR REPLACE FUNCTION organization_role_at_least(org UUID, min_role TEXT)
+RETURNS BOOLEAN
+LANGUAGE sql STABLE SECURITY DEFINER SET search_path = public
+AS $$
+    SELECT EXISTS (
+        SELECT 1 FROM organization_members
+        WHERE organization_id = org
+          AND user_id = auth.uid()
+          AND array_position(ARRAY['viewer', 'editor', 'admin', 'owner'], role)
+              >= array_position(ARRAY['viewer', 'editor', 'admin', 'owner'], min_role)
+    )
+$$;
+
+-- Personal orders are owned by their creator; organization orders grant the member's role
+CREATE OR REPLACE FUNCTION order_role_at_least(owner UUID, org UUID, min_role TEXT)
+RETURNS BOOLEAN
+LANGUAGE sql STABLE SECURITY DEFINER SET search_path = public
+AS $$
+    SELECT CASE WHEN org IS NULL THEN owner = auth.uid() ELSE organization_role_at_least(org, min_role) END
+$$;
+
+-- Step 4: Row Level Security for orders
+DROP POLICY IF EXISTS "Users can select their own orders" ON orders;
+DROP POLICY IF EXISTS "Users can insert their own orders" ON orders;
+DROP POLICY IF EXISTS "Users can update their own orders" ON orders;
+DROP POLICY IF EXISTS "Users can delete their own orders" ON orders;
+DROP POLICY IF EXISTS "Members can select orders" ON orders;
+DROP POLICY IF EXISTS "Members can insert orders" ON orders;
+DROP POLICY IF EXISTS "Editors can update orders" ON orders;
+DROP POLICY IF EXISTS "Admins can delete orders" ON orders;
+
+CREATE POLICY "Members can select orders" ON orders
+    FOR SELECT
+    USING (order_role_at_least(user_id, organization_id, 'viewer'));
+
+CREATE POLICY "Members can insert orders" ON orders
+    FOR INSERT
+    WITH CHECK (auth.uid() = user_id AND (organization_id IS NULL OR organization_role_at_least(organization_id, 'editor')));
+
+CREATE POLICY "Editors can update orders" ON orders
+    FOR UPDATE
+    USING (order_role_at_least(user_id, organization_id, 'editor'));
+
+CREATE POLICY "Admins can delete orders" ON orders
+    FOR DELETE
+    USING (order_role_at_least(user_id, organization_id, 'admin'));
+
+-- Step 5: Row Level Security for order_files and brackets, through their order
+DROP POLICY IF EXISTS "Users can select their own files" ON order_files;
+DROP POLICY IF EXISTS "Users can insert their own files" ON order_files;
+DROP POLICY IF EXISTS "Users can update their own files" ON order_files;
+DROP POLICY IF EXISTS "Users can delete their own files" ON order_files;
+DROP POLICY IF EXISTS "Members can select order files" ON order_files;
+DROP POLICY IF EXISTS "Editors can manage order files" ON order_files;
+
+CREATE POLICY "Members can select order files" ON order_files
+    FOR SELECT
+    USING (
+        EXISTS (
+            SELECT 1 FROM orders
+            WHERE orders.id = order_files.order_id
+            AND order_role_at_least(orders.user_id, orders.organization_id, 'viewer')
+        )
+    );
+
+CREATE POLICY "Editors can manage order files" ON order_files
+    FOR ALL
+    USING (
+        EXISTS (
+            SELECT 1 FROM orders
+            WHERE orders.id = order_files.order_id
+            AND order_role_at_least(orders.user_id, orders.organization_id, 'editor')
+        )
+    );
+
+DROP POLICY IF EXISTS "Users can select their own brackets" ON brackets;
+DROP POLICY IF EXISTS "Users can insert their own brackets" ON brackets;
+DROP POLICY IF EXISTS "Users can update their own brackets" ON brackets;
+DROP POLICY IF EXISTS "Users can delete their own brackets" ON brackets;
+DROP POLICY IF EXISTS "Members can select brackets" ON brackets;
+DROP POLICY IF EXISTS "Editors can manage brackets" ON brackets;
+
+CREATE POLICY "Members can select brackets" ON brackets
+    FOR SELECT
+    USING (
+        EXISTS (
+            SELECT 1 FROM orders
+            WHERE orders.id = brackets.order_id
+            AND order_role_at_least(orders.user_id, orders.organization_id, 'viewer')
+        )
+    );
+
+CREATE POLICY "Editors can manage brackets" ON brackets
+    FOR ALL
+    USING (
+        EXISTS (
+            SELECT 1 FROM orders
+            WHERE orders.id = brackets.order_id
+            AND order_role_at_least(orders.user_id, orders.organization_id, 'editor')
+        )
+    );
+
+-- Step 6: Row Level Security for organizations and members
+ALTER TABLE organizations ENABLE ROW LEVEL SECURITY;
+ALTER TABLE organization_members ENABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS "Members can select their organizations" ON organizations;
+DROP POLICY IF EXISTS "Users can create organizations" ON organizations;
+DROP POLICY IF EXISTS "Admins can update organizations" ON organizations;
+DROP POLICY IF EXISTS "Owners can delete organizations" ON organizations;
+DROP POLICY IF EXISTS "Members can select members" ON organization_members;
+DROP POLICY IF EXISTS "Admins can manage members" ON organization_members;
+DROP POLICY IF EXISTS "Creators become owners" ON organization_members;
+DROP POLICY IF EXISTS "Members can leave" ON organization_members;
+
+CREATE POLICY "Members can select their organizations" ON organizations
+    FOR SELECT
+    USING (organization_role_at_least(id, 'viewer'));
+
+CREATE POLICY "Users can create organizations" ON organizations
+    FOR INSERT
+    WITH CHECK (auth.uid() = created_by);
+
+CREATE POLICY "Admins can update organizations" ON organizations
+    FOR UPDATE
+    USING (organization_role_at_least(id, 'admin'));
+
+CREATE POLICY "Owners can delete organizations" ON organizations
+    FOR DELETE
+    USING (organization_role_at_least(id, 'owner'));
+
+CREATE POLICY "Members can select members" ON organization_members
+    FOR SELECT
+    USING (organization_role_at_least(organization_id, 'viewer'));
+
+-- Admins manage viewers and editors, owners manage everyone (the API enforces the same and keeps the last owner)
+CREATE POLICY "Admins can manage members" ON organization_members
+    FOR ALL
+    USING (organization_role_at_least(organization_id, CASE WHEN role IN ('admin', 'owner') THEN 'owner' ELSE 'admin' END))
+    WITH CHECK (organization_role_at_least(organization_id, CASE WHEN role IN ('admin', 'owner') THEN 'owner' ELSE 'admin' END));
+
+CREATE POLICY "Creators become owners" ON organization_members
+    FOR INSERT
+    WITH CHECK (
+        auth.uid() = user_id AND role = 'owner'
+        AND EXISTS (SELECT 1 FROM organizations WHERE organizations.id = organization_id AND organizations.created_by = auth.uid())
+    );
+
+CREATE POLICY "Members can leave" ON organization_members
+    FOR DELETE
+    USING (auth.uid() = user_id);
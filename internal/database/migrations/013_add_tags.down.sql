@@ -0,0 +1,6 @@
+-- Migration 013 (down): Remove tags and saved filters
+
+DROP TABLE IF EXISTS saved_filters;
+DROP TABLE IF EXISTS image_tags;
+DROP INDEX IF EXISTS idx_orders_tags;
+ALTER TABLE orders DROP COLUMN IF EXISTS tags;
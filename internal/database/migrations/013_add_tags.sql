@@ -0,0 +1,72 @@
+-- Migration 013: Tags on orders and images, saved order filters
+-- Tags are lowercase labels ("twilight", "rush", "client-review"). Order tags live in an array column with a GIN index
+-- so GET /orders?tag= can use containment (tags @> ...); image tags are rows keyed by the AutoEnhance image ID.
+-- Saved filters are named GET /orders query strings, private to the user who saved them.
+
+-- Step 1: Order tags
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS tags TEXT[] NOT NULL DEFAULT '{}';
+CREATE INDEX IF NOT EXISTS idx_orders_tags ON orders USING GIN (tags);
+
+-- Step 2: Image tags
+CREATE TABLE IF NOT EXISTS image_tags (
+    order_id UUID NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    image_id TEXT NOT NULL,
+    tag TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW(),
+    PRIMARY KEY (order_id, image_id, tag)
+);
+
+CREATE INDEX IF NOT EXISTS idx_image_tags_tag ON image_tags(tag);
+
+-- Step 3: Saved filters
+CREATE TABLE IF NOT EXISTS saved_filters (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL,
+    name TEXT NOT NULL,
+    query TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW(),
+    UNIQUE (user_id, name)
+);
+
+DROP TRIGGER IF EXISTS update_saved_filters_updated_at ON saved_filters;
+CREATE TRIGGER update_saved_filters_updated_at
+    BEFORE UPDATE ON saved_filters
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- Step 4: Row Level Security for image_tags, through their order
+ALTER TABLE image_tags ENABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS "Members can select image tags" ON image_tags;
+DROP POLICY IF EXISTS "Editors can manage image tags" ON image_tags;
+
+CREATE POLICY "Members can select image tags" ON image_tags
+    FOR SELECT
+    USING (
+        EXISTS (
+            SELECT 1 FROM orders
+            WHERE orders.id = image_tags.order_id
+            AND order_role_at_least(orders.user_id, orders.organization_id, 'viewer')
+        )
+    );
+
+CREATE POLICY "Editors can manage image tags" ON image_tags
+    FOR ALL
+    USING (
+        EXISTS (
+            SELECT 1 FROM orders
+            WHERE orders.id = image_tags.order_id
+            AND order_role_at_least(orders.user_id, orders.organization_id, 'editor')
+        )
+    );
+
+-- Step 5: Row Level Security for saved_filters
+ALTER TABLE saved_filters ENABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS "Users can manage their own saved filters" ON saved_filters;
+
+CREATE POLICY "Users can manage their own saved filters" ON saved_filters
+    FOR ALL
+    USING (auth.uid() = user_id)
+    WITH CHECK (auth.uid() = user_id);
@@ -0,0 +1,3 @@
+-- Migration 014 (down): Remove API keys
+
+DROP TABLE IF EXISTS api_keys;
@@ -0,0 +1,30 @@
+-- Migration 014: API keys for server-to-server integrations
+-- Only the SHA-256 hash of a key is stored; the key itself is shown once, when it is created. A key acts as the user who
+-- created it, limited to its scopes. Organization keys only reach that organization's orders and stop working when
+-- their creator leaves it. Revoked keys are kept (revoked_at) so the key list shows what was revoked and when.
+
+CREATE TABLE IF NOT EXISTS api_keys (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL,
+    organization_id UUID REFERENCES organizations(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    prefix TEXT NOT NULL,
+    key_hash TEXT NOT NULL UNIQUE,
+    scopes TEXT[] NOT NULL DEFAULT '{}',
+    expires_at TIMESTAMP,
+    last_used_at TIMESTAMP,
+    revoked_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_api_keys_user_id ON api_keys(user_id);
+CREATE INDEX IF NOT EXISTS idx_api_keys_organization_id ON api_keys(organization_id) WHERE organization_id IS NOT NULL;
+
+-- Keys are only read and written by the server; clients see them through the API
+ALTER TABLE api_keys ENABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS "Users can select their own api keys" ON api_keys;
+
+CREATE POLICY "Users can select their own api keys" ON api_keys
+    FOR SELECT
+    USING (auth.uid() = user_id);
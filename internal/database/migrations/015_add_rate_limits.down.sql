@@ -0,0 +1,3 @@
+-- Migration 015 (down): Remove rate limit counters
+
+DROP TABLE IF EXISTS rate_limits;
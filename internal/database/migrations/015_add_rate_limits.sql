@@ -0,0 +1,15 @@
+-- Migration 015: Rate limit counters shared by all replicas (RATE_LIMIT_STORE=database)
+-- One fixed-window counter per key (route group + user, API key or IP). The table is UNLOGGED: counters are cheap
+-- to lose on a crash and skipping the WAL keeps the per-request upsert fast. Ended windows are pruned by the server.
+
+CREATE UNLOGGED TABLE IF NOT EXISTS rate_limits (
+    key TEXT PRIMARY KEY,
+    window_start TIMESTAMP NOT NULL,
+    window_end TIMESTAMP NOT NULL,
+    count INTEGER NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_rate_limits_window_end ON rate_limits(window_end);
+
+-- Only the server touches the counters
+ALTER TABLE rate_limits ENABLE ROW LEVEL SECURITY;
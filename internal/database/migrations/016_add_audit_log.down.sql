@@ -0,0 +1,4 @@
+-- Migration 016 (down): Remove the audit log
+
+DROP TABLE IF EXISTS audit_log;
+DROP FUNCTION IF EXISTS audit_log_append_only();
@@ -0,0 +1,38 @@
+-- Migration 016: Audit log
+-- Append-only record of who did what: admin actions for now, with room for user and system actions. Entries keep
+-- plain IDs instead of foreign keys, so they outlive the orders, users and keys they mention.
+
+CREATE TABLE IF NOT EXISTS audit_log (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    actor_id UUID,   -- User who acted (the creator, for API keys); NULL for the system
+    api_key_id UUID, -- Set when the actor used an API key
+    action TEXT NOT NULL,
+    order_id UUID,
+    user_id UUID,    -- User the action concerns, e.g. the owner of an order an admin looked at
+    details JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_created_at ON audit_log(created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_log_actor ON audit_log(actor_id, created_at DESC) WHERE actor_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_audit_log_order ON audit_log(order_id, created_at DESC) WHERE order_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_audit_log_user ON audit_log(user_id, created_at DESC) WHERE user_id IS NOT NULL;
+
+-- Entries can be added, never changed or removed
+CREATE OR REPLACE FUNCTION audit_log_append_only()
+RETURNS TRIGGER
+LANGUAGE plpgsql
+AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_log is append-only';
+END
+$$;
+
+DROP TRIGGER IF EXISTS audit_log_append_only ON audit_log;
+
+CREATE TRIGGER audit_log_append_only
+    BEFORE UPDATE OR DELETE ON audit_log
+    FOR EACH ROW EXECUTE FUNCTION audit_log_append_only();
+
+-- Only the server reads and writes the log
+ALTER TABLE audit_log ENABLE ROW LEVEL SECURITY;
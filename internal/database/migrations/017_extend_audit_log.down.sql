@@ -0,0 +1,6 @@
+-- Migration 017 (down): Remove the image, request and IP columns from the audit log
+
+DROP INDEX IF EXISTS idx_audit_log_action;
+ALTER TABLE audit_log DROP COLUMN IF EXISTS ip;
+ALTER TABLE audit_log DROP COLUMN IF EXISTS request_id;
+ALTER TABLE audit_log DROP COLUMN IF EXISTS image_id;
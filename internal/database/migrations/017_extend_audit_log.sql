@@ -0,0 +1,9 @@
+-- Migration 017: Audit log for user and system actions
+-- Every mutating endpoint and the webhook processing now write to the audit log. Entries also record the image they
+-- concern, the request ID (X-Request-ID) and the client IP, so a billing dispute over a download can be traced.
+
+ALTER TABLE audit_log ADD COLUMN IF NOT EXISTS image_id TEXT;
+ALTER TABLE audit_log ADD COLUMN IF NOT EXISTS request_id TEXT;
+ALTER TABLE audit_log ADD COLUMN IF NOT EXISTS ip TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_action ON audit_log(action, created_at DESC);
@@ -0,0 +1,3 @@
+-- Migration 018 (down): Remove the order event outbox
+
+DROP TABLE IF EXISTS order_events;
@@ -0,0 +1,24 @@
+-- Migration 018: Order event outbox
+-- Realtime events are written here in the same transaction as the order change they announce, numbered per order,
+-- and a dispatcher publishes them to Supabase broadcast with retries. Clients that missed events read them back with
+-- GET /orders/{order_id}/events?since=N. Sequence numbers are assigned under the order's row lock, so they have no gaps.
+
+CREATE TABLE IF NOT EXISTS order_events (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    order_id UUID NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    seq BIGINT NOT NULL,            -- 1, 2, 3, ... per order
+    event TEXT NOT NULL,            -- Broadcast event name, e.g. download_ready
+    payload JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    dispatched_at TIMESTAMP,        -- Set once broadcast accepted the event
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP DEFAULT NOW(), -- NULL once the dispatcher gave up
+    last_error TEXT,
+    UNIQUE (order_id, seq)
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_events_pending ON order_events(next_attempt_at)
+    WHERE dispatched_at IS NULL AND next_attempt_at IS NOT NULL;
+
+-- Only the server reads and writes the outbox
+ALTER TABLE order_events ENABLE ROW LEVEL SECURITY;
@@ -0,0 +1,4 @@
+-- Migration 019 (down): Drop credits
+
+DROP TABLE IF EXISTS credit_ledger;
+DROP TABLE IF EXISTS user_credits;
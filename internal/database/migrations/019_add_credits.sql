@@ -0,0 +1,49 @@
+-- Migration 019: Credits for unwatermarked downloads
+-- Every unwatermarked download from AutoEnhance costs a credit. Balances and monthly budgets are kept per user and
+-- every spend or grant is recorded in the ledger. A spend is unique per order, image, quality and format, so an image
+-- downloaded again (the stored copy was lost, or two requests raced) is not paid twice. Like the audit log, the ledger
+-- keeps plain IDs so it outlives the orders it mentions.
+
+-- Step 1: Balance and budget per user (users without a row have no credits and CREDITS_MONTHLY_BUDGET)
+CREATE TABLE IF NOT EXISTS user_credits (
+    user_id UUID PRIMARY KEY,
+    balance INTEGER NOT NULL DEFAULT 0,
+    monthly_budget INTEGER CHECK (monthly_budget >= 0), -- Credits that may be spent per calendar month (UTC); NULL = default, 0 = none
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Step 2: Ledger of spends (-1 each) and grants
+CREATE TABLE IF NOT EXISTS credit_ledger (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL,
+    kind TEXT NOT NULL,    -- 'spend' or 'grant'
+    amount INTEGER NOT NULL,
+    order_id UUID,         -- Spends: the image downloaded
+    image_id TEXT,
+    quality TEXT,
+    format TEXT,
+    reason TEXT,           -- Grants: why, as given by the admin
+    actor_id UUID,         -- Grants: the admin
+    refunded_at TIMESTAMP, -- Spends whose download failed; the credit went back to the balance
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_credit_ledger_user ON credit_ledger(user_id, created_at DESC);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_credit_ledger_spend ON credit_ledger(order_id, image_id, quality, format)
+    WHERE kind = 'spend' AND refunded_at IS NULL;
+
+-- Step 3: Row Level Security - users can read their own balance and ledger, only the backend writes
+ALTER TABLE user_credits ENABLE ROW LEVEL SECURITY;
+ALTER TABLE credit_ledger ENABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS "Users can select their own credits" ON user_credits;
+DROP POLICY IF EXISTS "Users can select their own credit ledger" ON credit_ledger;
+
+CREATE POLICY "Users can select their own credits" ON user_credits
+    FOR SELECT
+    USING (auth.uid() = user_id);
+
+CREATE POLICY "Users can select their own credit ledger" ON credit_ledger
+    FOR SELECT
+    USING (auth.uid() = user_id);
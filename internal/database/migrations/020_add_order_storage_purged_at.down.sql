@@ -0,0 +1,3 @@
+-- Migration 020 (down): Remove storage_purged_at from orders
+
+ALTER TABLE orders DROP COLUMN IF EXISTS storage_purged_at;
@@ -0,0 +1,6 @@
+-- Migration 020: Remember which orders deleted in AutoEnhance had their storage purged
+-- The janitor purges the storage of orders deleted in AutoEnhance (is_deleted) but keeps their rows. Once both the
+-- objects and the order_files rows are gone it sets storage_purged_at, and those orders are no longer listed as
+-- purgeable. Orders in the trash are listed until the order itself is deleted.
+
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS storage_purged_at TIMESTAMP;
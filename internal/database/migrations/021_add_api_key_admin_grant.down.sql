@@ -0,0 +1,3 @@
+-- Migration 021 (down): Remove api_keys.admin_grant
+
+ALTER TABLE api_keys DROP COLUMN IF EXISTS admin_grant;
@@ -0,0 +1,6 @@
+-- Migration 021: Record how the creator of an admin key qualified as an admin
+-- 'listed': the creator is in ADMIN_USER_IDS, which is checked again on every request. 'role': the creator's JWT carried
+-- ADMIN_ROLE, which a key can't check again, so these keys always expire. Admin keys created before this migration are
+-- left NULL and treated as 'listed'.
+
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS admin_grant TEXT CHECK (admin_grant IN ('listed', 'role'));
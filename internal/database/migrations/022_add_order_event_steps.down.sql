@@ -0,0 +1,5 @@
+-- Migration 022 (down): Remove delivered_at and broadcast_at from order_events
+
+ALTER TABLE order_events
+    DROP COLUMN IF EXISTS delivered_at,
+    DROP COLUMN IF EXISTS broadcast_at;
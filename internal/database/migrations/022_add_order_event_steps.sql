@@ -0,0 +1,9 @@
+-- Migration 022: Track each step of dispatching an order event
+-- An event is delivered through the bus (the SSE subscribers of every instance) and broadcast through Supabase. Each
+-- step is retried with backoff until it succeeds and is not repeated once it has: delivered_at and broadcast_at record
+-- them, and dispatched_at is set once both are done. Events pending when this runs are delivered through the bus again;
+-- SSE streams skip the ones they already sent.
+
+ALTER TABLE order_events
+    ADD COLUMN IF NOT EXISTS delivered_at TIMESTAMP,
+    ADD COLUMN IF NOT EXISTS broadcast_at TIMESTAMP;
@@ -1,10 +1,17 @@
 package database
 
 import (
+	"context"
+	"crypto/sha256"
 	"database/sql"
 	"embed"
+	"encoding/hex"
 	"fmt"
+	"io/fs"
 	"log"
+	"sort"
+	"strings"
+	"time"
 
 	_ "github.com/lib/pq"
 )
@@ -12,114 +19,432 @@ import (
 //go:embed migrations/*.sql
 var migrationsFS embed.FS
 
+// Down migrations sit next to their up file: 008_add_quotas.sql / 008_add_quotas.down.sql
+const downSuffix = ".down.sql"
+
+// migrationLockKey is the Postgres advisory lock held while migrating, so replicas booting together take turns
+const migrationLockKey int64 = 0x48445231
+
+// DefaultLockTimeout is how long a migration run waits for another instance to release the lock
+const DefaultLockTimeout = 5 * time.Minute
+
+// Migration is an embedded up migration and its optional down migration
+type Migration struct {
+	Name     string // Up file name, e.g. 008_add_quotas.sql - the key in schema_migrations
+	Version  string // Numeric prefix, e.g. 008
+	Up       string
+	Down     string // Empty when the migration cannot be rolled back
+	Checksum string // SHA-256 of Up
+}
+
+func (m Migration) Reversible() bool {
+	return m.Down != ""
+}
+
+// MigrationStatus compares a migration with its schema_migrations row
+type MigrationStatus struct {
+	Name            string
+	Applied         bool
+	AppliedAt       time.Time
+	Checksum        string // Of the embedded file
+	AppliedChecksum string // Recorded when the migration was applied
+	Drifted         bool   // File was edited after it was applied
+	Unknown         bool   // Applied, but not embedded in this binary (newer build or removed file)
+	Reversible      bool
+}
+
+// DriftError lists applied migrations whose file no longer matches the recorded checksum
+type DriftError struct {
+	Names []string
+}
+
+func (e *DriftError) Error() string {
+	return fmt.Sprintf("migrations changed after being applied: %s", strings.Join(e.Names, ", "))
+}
+
+// Migrations returns the embedded migrations in the order they are applied
+func Migrations() ([]Migration, error) {
+	sub, err := fs.Sub(migrationsFS, "migrations")
+	if err != nil {
+		return nil, err
+	}
+	return LoadMigrations(sub)
+}
+
+// LoadMigrations pairs the up and down .sql files at the root of fsys and sorts them by name.
+// Every migration needs a unique numeric prefix; a down file without its up file is an error.
+func LoadMigrations(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations directory: %w", err)
+	}
+
+	byName := make(map[string]*Migration)
+	downs := make(map[string]string)
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".sql") {
+			continue
+		}
+
+		content, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", name, err)
+		}
+
+		if strings.HasSuffix(name, downSuffix) {
+			downs[strings.TrimSuffix(name, downSuffix)+".sql"] = string(content)
+			continue
+		}
+
+		version, _, ok := strings.Cut(name, "_")
+		if !ok || strings.Trim(version, "0123456789") != "" {
+			return nil, fmt.Errorf("migration %s must start with a numeric prefix such as 009_", name)
+		}
+		sum := sha256.Sum256(content)
+		byName[name] = &Migration{
+			Name:     name,
+			Version:  version,
+			Up:       string(content),
+			Checksum: hex.EncodeToString(sum[:]),
+		}
+	}
+
+	for upName, down := range downs {
+		migration, ok := byName[upName]
+		if !ok {
+			return nil, fmt.Errorf("down migration for %s has no matching up migration", upName)
+		}
+		migration.Down = down
+	}
+
+	migrations := make([]Migration, 0, len(byName))
+	for _, migration := range byName {
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Name < migrations[j].Name })
+
+	for i := 1; i < len(migrations); i++ {
+		if migrations[i].Version == migrations[i-1].Version {
+			return nil, fmt.Errorf("migrations %s and %s share version %s", migrations[i-1].Name, migrations[i].Name, migrations[i].Version)
+		}
+	}
+
+	return migrations, nil
+}
+
 type Migrator struct {
-	db *sql.DB
+	db          *sql.DB
+	migrations  []Migration
+	LockTimeout time.Duration
 }
 
 func NewMigrator(dbURL string) (*Migrator, error) {
+	migrations, err := Migrations()
+	if err != nil {
+		return nil, err
+	}
+
 	db, err := sql.Open("postgres", dbURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
 
 	if err := db.Ping(); err != nil {
+		db.Close()
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
-	return &Migrator{db: db}, nil
+	return &Migrator{db: db, migrations: migrations, LockTimeout: DefaultLockTimeout}, nil
 }
 
+// Run applies every pending migration in order. It refuses to run when an applied migration
+// has been edited since, and only warns about applied migrations this binary does not know.
 func (m *Migrator) Run() error {
-	// Create migrations table if it doesn't exist
-	if err := m.createMigrationsTable(); err != nil {
-		return fmt.Errorf("failed to create migrations table: %w", err)
-	}
+	return m.withLock(func(ctx context.Context, conn *sql.Conn) error {
+		statuses, err := m.status(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if err := checkDrift(statuses); err != nil {
+			return err
+		}
 
-	// Read migration files
-	entries, err := migrationsFS.ReadDir("migrations")
-	if err != nil {
-		return fmt.Errorf("failed to read migrations directory: %w", err)
-	}
+		for _, status := range statuses {
+			if status.Unknown {
+				log.Printf("Warning: Migration %s is applied but not known to this build", status.Name)
+			}
+		}
 
-	for _, entry := range entries {
-		if entry.IsDir() {
-			continue
+		for _, migration := range m.migrations {
+			if isApplied(statuses, migration.Name) {
+				continue
+			}
+
+			log.Printf("Applying migration: %s", migration.Name)
+
+			tx, err := conn.BeginTx(ctx, nil)
+			if err != nil {
+				return fmt.Errorf("failed to begin transaction: %w", err)
+			}
+
+			if _, err := tx.Exec(migration.Up); err != nil {
+				tx.Rollback()
+				return fmt.Errorf("failed to execute migration %s: %w", migration.Name, err)
+			}
+
+			if _, err := tx.Exec(
+				"INSERT INTO schema_migrations (name, applied_at, checksum) VALUES ($1, NOW(), $2)",
+				migration.Name, migration.Checksum,
+			); err != nil {
+				tx.Rollback()
+				return fmt.Errorf("failed to record migration %s: %w", migration.Name, err)
+			}
+
+			if err := tx.Commit(); err != nil {
+				return fmt.Errorf("failed to commit migration %s: %w", migration.Name, err)
+			}
+
+			log.Printf("Successfully applied migration: %s", migration.Name)
 		}
 
-		migrationName := entry.Name()
+		return nil
+	})
+}
+
+// Down rolls back the last n applied migrations, newest first, and returns their names.
+// Nothing is rolled back unless all n have a down migration.
+func (m *Migrator) Down(n int) ([]string, error) {
+	if n < 1 {
+		return nil, fmt.Errorf("number of migrations to roll back must be at least 1")
+	}
 
-		// Check if migration already applied
-		applied, err := m.isMigrationApplied(migrationName)
+	var rolledBack []string
+	err := m.withLock(func(ctx context.Context, conn *sql.Conn) error {
+		statuses, err := m.status(ctx, conn)
 		if err != nil {
-			return fmt.Errorf("failed to check migration status: %w", err)
+			return err
+		}
+		if err := checkDrift(statuses); err != nil {
+			return err
 		}
 
-		if applied {
-			log.Printf("Migration %s already applied, skipping", migrationName)
-			continue
+		var applied []MigrationStatus
+		for _, status := range statuses {
+			if status.Applied {
+				applied = append(applied, status)
+			}
+		}
+		if n > len(applied) {
+			return fmt.Errorf("cannot roll back %d migrations, only %d are applied", n, len(applied))
 		}
 
-		// Read and execute migration
-		migrationSQL, err := migrationsFS.ReadFile("migrations/" + migrationName)
-		if err != nil {
-			return fmt.Errorf("failed to read migration %s: %w", migrationName, err)
+		targets := make([]Migration, 0, n)
+		for i := len(applied) - 1; i >= len(applied)-n; i-- {
+			status := applied[i]
+			if status.Unknown {
+				return fmt.Errorf("migration %s is not known to this build and cannot be rolled back", status.Name)
+			}
+			migration := m.find(status.Name)
+			if !migration.Reversible() {
+				return fmt.Errorf("migration %s has no down migration", status.Name)
+			}
+			targets = append(targets, migration)
 		}
 
-		log.Printf("Applying migration: %s", migrationName)
+		for _, migration := range targets {
+			log.Printf("Rolling back migration: %s", migration.Name)
 
-		// Execute migration in a transaction
-		tx, err := m.db.Begin()
-		if err != nil {
-			return fmt.Errorf("failed to begin transaction: %w", err)
-		}
+			tx, err := conn.BeginTx(ctx, nil)
+			if err != nil {
+				return fmt.Errorf("failed to begin transaction: %w", err)
+			}
 
-		if _, err := tx.Exec(string(migrationSQL)); err != nil {
-			tx.Rollback()
-			return fmt.Errorf("failed to execute migration %s: %w", migrationName, err)
-		}
+			if _, err := tx.Exec(migration.Down); err != nil {
+				tx.Rollback()
+				return fmt.Errorf("failed to roll back migration %s: %w", migration.Name, err)
+			}
 
-		// Record migration
-		if _, err := tx.Exec(
-			"INSERT INTO schema_migrations (name, applied_at) VALUES ($1, NOW())",
-			migrationName,
-		); err != nil {
-			tx.Rollback()
-			return fmt.Errorf("failed to record migration %s: %w", migrationName, err)
+			if _, err := tx.Exec("DELETE FROM schema_migrations WHERE name = $1", migration.Name); err != nil {
+				tx.Rollback()
+				return fmt.Errorf("failed to unrecord migration %s: %w", migration.Name, err)
+			}
+
+			if err := tx.Commit(); err != nil {
+				return fmt.Errorf("failed to commit rollback of %s: %w", migration.Name, err)
+			}
+
+			rolledBack = append(rolledBack, migration.Name)
+			log.Printf("Successfully rolled back migration: %s", migration.Name)
 		}
 
-		if err := tx.Commit(); err != nil {
-			return fmt.Errorf("failed to commit migration %s: %w", migrationName, err)
+		return nil
+	})
+	return rolledBack, err
+}
+
+// Status lists the embedded migrations followed by any applied migrations this build does not know
+func (m *Migrator) Status() ([]MigrationStatus, error) {
+	var statuses []MigrationStatus
+	err := m.withLock(func(ctx context.Context, conn *sql.Conn) error {
+		var err error
+		statuses, err = m.status(ctx, conn)
+		return err
+	})
+	return statuses, err
+}
+
+// Verify returns a *DriftError when an applied migration was edited after it ran
+func (m *Migrator) Verify() ([]MigrationStatus, error) {
+	statuses, err := m.Status()
+	if err != nil {
+		return nil, err
+	}
+	return statuses, checkDrift(statuses)
+}
+
+func (m *Migrator) Close() error {
+	return m.db.Close()
+}
+
+// withLock runs fn on a single connection holding the migration advisory lock.
+// Session-level advisory locks belong to a connection, so everything inside fn must use conn.
+func (m *Migrator) withLock(fn func(ctx context.Context, conn *sql.Conn) error) error {
+	ctx := context.Background()
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get database connection: %w", err)
+	}
+	defer conn.Close()
+
+	var locked bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", migrationLockKey).Scan(&locked); err != nil {
+		return fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+	if !locked {
+		log.Printf("Another instance is running migrations, waiting up to %s", m.LockTimeout)
+		lockCtx, cancel := context.WithTimeout(ctx, m.LockTimeout)
+		defer cancel()
+		if _, err := conn.ExecContext(lockCtx, "SELECT pg_advisory_lock($1)", migrationLockKey); err != nil {
+			return fmt.Errorf("failed to acquire migration lock: %w", err)
 		}
+	}
+	defer conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", migrationLockKey)
 
-		log.Printf("Successfully applied migration: %s", migrationName)
+	if err := m.createMigrationsTable(ctx, conn); err != nil {
+		return fmt.Errorf("failed to create migrations table: %w", err)
 	}
 
-	return nil
+	return fn(ctx, conn)
 }
 
-func (m *Migrator) createMigrationsTable() error {
+func (m *Migrator) createMigrationsTable(ctx context.Context, conn *sql.Conn) error {
 	query := `
 		CREATE TABLE IF NOT EXISTS schema_migrations (
 			name TEXT PRIMARY KEY,
-			applied_at TIMESTAMP DEFAULT NOW()
-		)
+			applied_at TIMESTAMP DEFAULT NOW(),
+			checksum TEXT
+		);
+		ALTER TABLE schema_migrations ADD COLUMN IF NOT EXISTS checksum TEXT;
 	`
-	_, err := m.db.Exec(query)
+	_, err := conn.ExecContext(ctx, query)
 	return err
 }
 
-func (m *Migrator) isMigrationApplied(name string) (bool, error) {
-	var count int
-	err := m.db.QueryRow(
-		"SELECT COUNT(*) FROM schema_migrations WHERE name = $1",
-		name,
-	).Scan(&count)
+// status reads schema_migrations. Rows applied before checksums were tracked adopt the current file's checksum.
+func (m *Migrator) status(ctx context.Context, conn *sql.Conn) ([]MigrationStatus, error) {
+	rows, err := conn.QueryContext(ctx, "SELECT name, applied_at, COALESCE(checksum, '') FROM schema_migrations ORDER BY name")
 	if err != nil {
-		return false, err
+		return nil, fmt.Errorf("failed to read applied migrations: %w", err)
+	}
+	defer rows.Close()
+
+	type appliedRow struct {
+		appliedAt sql.NullTime
+		checksum  string
+	}
+	applied := make(map[string]appliedRow)
+	var appliedNames []string
+	for rows.Next() {
+		var name string
+		var row appliedRow
+		if err := rows.Scan(&name, &row.appliedAt, &row.checksum); err != nil {
+			return nil, fmt.Errorf("failed to scan applied migration: %w", err)
+		}
+		applied[name] = row
+		appliedNames = append(appliedNames, name)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := MigrationStatus{
+			Name:       migration.Name,
+			Checksum:   migration.Checksum,
+			Reversible: migration.Reversible(),
+		}
+		if row, ok := applied[migration.Name]; ok {
+			status.Applied = true
+			status.AppliedAt = row.appliedAt.Time
+			status.AppliedChecksum = row.checksum
+			if row.checksum == "" {
+				if _, err := conn.ExecContext(ctx, "UPDATE schema_migrations SET checksum = $1 WHERE name = $2", migration.Checksum, migration.Name); err != nil {
+					return nil, fmt.Errorf("failed to record checksum for %s: %w", migration.Name, err)
+				}
+				log.Printf("Recorded checksum for migration %s (applied before checksums were tracked)", migration.Name)
+				status.AppliedChecksum = migration.Checksum
+			}
+			status.Drifted = status.AppliedChecksum != migration.Checksum
+		}
+		statuses = append(statuses, status)
+	}
+
+	for _, name := range appliedNames {
+		if m.find(name).Name != "" {
+			continue
+		}
+		statuses = append(statuses, MigrationStatus{
+			Name:            name,
+			Applied:         true,
+			AppliedAt:       applied[name].appliedAt.Time,
+			AppliedChecksum: applied[name].checksum,
+			Unknown:         true,
+		})
 	}
-	return count > 0, nil
+	sort.SliceStable(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+
+	return statuses, nil
 }
 
-func (m *Migrator) Close() error {
-	return m.db.Close()
+func (m *Migrator) find(name string) Migration {
+	for _, migration := range m.migrations {
+		if migration.Name == name {
+			return migration
+		}
+	}
+	return Migration{}
+}
+
+func isApplied(statuses []MigrationStatus, name string) bool {
+	for _, status := range statuses {
+		if status.Name == name {
+			return status.Applied
+		}
+	}
+	return false
+}
+
+func checkDrift(statuses []MigrationStatus) error {
+	var drifted []string
+	for _, status := range statuses {
+		if status.Drifted {
+			drifted = append(drifted, status.Name)
+		}
+	}
+	if len(drifted) > 0 {
+		return &DriftError{Names: drifted}
+	}
+	return nil
 }
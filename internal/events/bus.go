@@ -0,0 +1,194 @@
+// Package events defines the realtime event payloads and fans events out to the subscribers (the SSE endpoints)
+// of every instance. Broadcasting them is up to the publisher (services.EventDispatcher).
+package events
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// subscriberBuffer is how many events a subscriber may fall behind before it is dropped
+const subscriberBuffer = 64
+
+// Publisher sends events beyond this process; *supabase.RealtimeClient, which broadcasts them, is one
+type Publisher interface {
+	PublishEvent(channel, event string, payload map[string]interface{}) error
+}
+
+// Event is a published event as subscribers see it
+type Event struct {
+	ID      uint64 // Increases by one per event; 0 when published to have each bus number it
+	Channel string // OrderChannel or UserChannel
+	Name    string
+	Data    json.RawMessage // Payload, including its timestamp
+	Source  uuid.UUID       // order_events row the event comes from, if any; transports may send this instead of a large Data
+}
+
+// Transport carries published events to the bus of every instance, this one included. It hands them to the
+// Receiver passed to Start, in the order they were sent.
+type Transport interface {
+	Start(receiver Receiver) error
+	Send(e Event) error
+}
+
+// Receiver takes events from a Transport; *Bus is one
+type Receiver interface {
+	Receive(e Event)
+	// Lost is called when events may have been missed, e.g. after the transport reconnected
+	Lost()
+}
+
+func OrderChannel(orderID uuid.UUID) string {
+	return "order:" + orderID.String()
+}
+
+func UserChannel(userID uuid.UUID) string {
+	return "user:" + userID.String()
+}
+
+// Bus delivers events to subscribers of their channel, through its transport when it has one. The last
+// events it numbered are kept so subscribers can resume after a reconnect. A nil Bus drops everything.
+type Bus struct {
+	historySize int
+	transport   Transport
+
+	mu          sync.Mutex
+	lastID      uint64
+	history     []Event // Oldest first, IDs contiguous
+	subscribers map[*Subscription]struct{}
+}
+
+// NewBus keeps the last historySize events for resumption. IDs start at the current time in microseconds,
+// so IDs issued before a restart are older than anything the new process keeps and resuming from them is
+// reported as incomplete.
+func NewBus(historySize int) *Bus {
+	return &Bus{
+		historySize: historySize,
+		lastID:      uint64(time.Now().UnixMicro()),
+		subscribers: make(map[*Subscription]struct{}),
+	}
+}
+
+// Connect sends published events through transport, so they reach the subscribers of every instance connected to it
+func (b *Bus) Connect(transport Transport) error {
+	if err := transport.Start(b); err != nil {
+		return err
+	}
+	b.mu.Lock()
+	b.transport = transport
+	b.mu.Unlock()
+	return nil
+}
+
+// Publish delivers e to the channel's subscribers on every instance. An event without an ID is numbered by each
+// bus and kept for resumption; order events keep their outbox seq as ID.
+func (b *Bus) Publish(e Event) error {
+	if b == nil {
+		return nil
+	}
+
+	b.mu.Lock()
+	transport := b.transport
+	b.mu.Unlock()
+	if transport == nil {
+		b.Receive(e)
+		return nil
+	}
+	return transport.Send(e)
+}
+
+// Receive delivers an event from the transport to this instance's subscribers
+func (b *Bus) Receive(e Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if e.ID == 0 {
+		b.lastID++
+		e.ID = b.lastID
+		if b.historySize > 0 {
+			if len(b.history) == b.historySize {
+				b.history = append(b.history[:0], b.history[1:]...)
+			}
+			b.history = append(b.history, e)
+		}
+	}
+	for sub := range b.subscribers {
+		if sub.channel == e.Channel {
+			b.deliver(sub, e)
+		}
+	}
+}
+
+// Lost closes every subscription and forgets the history, as events may have been missed: streams end and their
+// clients reconnect, resuming from the outbox (order streams) or reloading (user streams, which get a resync).
+func (b *Bus) Lost() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for sub := range b.subscribers {
+		delete(b.subscribers, sub)
+		close(sub.events)
+	}
+	// Skipping an ID makes resuming from any earlier event incomplete
+	b.history = nil
+	b.lastID++
+}
+
+// Subscribe follows channel. With lastEventID set, the channel's events after it are returned as missed;
+// complete is false when some of them are no longer kept (or lastEventID is from before a restart), so the
+// subscriber should reload the state instead of relying on the events.
+func (b *Bus) Subscribe(channel string, lastEventID uint64) (sub *Subscription, missed []Event, complete bool) {
+	sub = &Subscription{channel: channel, events: make(chan Event, subscriberBuffer), bus: b}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	complete = true
+	if lastEventID > 0 {
+		oldest := b.lastID + 1 - uint64(len(b.history))
+		complete = lastEventID <= b.lastID && lastEventID+1 >= oldest
+		for _, e := range b.history {
+			if e.ID > lastEventID && e.Channel == channel {
+				missed = append(missed, e)
+			}
+		}
+	}
+	b.subscribers[sub] = struct{}{}
+	return sub, missed, complete
+}
+
+// deliver hands e to sub without blocking the publisher. A subscriber that fell too far behind is closed;
+// it can resubscribe from its last event. Called with b.mu held.
+func (b *Bus) deliver(sub *Subscription, e Event) {
+	select {
+	case sub.events <- e:
+	default:
+		delete(b.subscribers, sub)
+		close(sub.events)
+	}
+}
+
+// Subscription receives the events of one channel until it is closed
+type Subscription struct {
+	channel string
+	events  chan Event
+	bus     *Bus
+}
+
+// Events is closed when the subscription is closed or fell behind
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+func (s *Subscription) Close() {
+	s.bus.mu.Lock()
+	defer s.bus.mu.Unlock()
+
+	if _, ok := s.bus.subscribers[s]; ok {
+		delete(s.bus.subscribers, s)
+		close(s.events)
+	}
+}
@@ -0,0 +1,98 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// JSONSchema describes every event payload as a JSON Schema (draft 2020-12) for client code generation.
+// Each event is a definition in $defs, named after the event; the document matches any of them (the event name
+// comes with the payload, not in it).
+func JSONSchema() ([]byte, error) {
+	defs := make(map[string]interface{})
+	var anyOf []interface{}
+	for _, entry := range catalog {
+		name := entry.payload.EventName()
+		schema := objectSchema(reflect.TypeOf(entry.payload).Elem())
+		schema["title"] = name
+		schema["description"] = entry.description
+		defs[name] = schema
+		anyOf = append(anyOf, map[string]interface{}{"$ref": "#/$defs/" + name})
+	}
+
+	data, err := json.MarshalIndent(map[string]interface{}{
+		"$schema":     "https://json-schema.org/draft/2020-12/schema",
+		"title":       "Realtime events",
+		"description": fmt.Sprintf("Payloads of the events on the Supabase channels order:{order_id} and user:{user_id}, and of the SSE streams. Schema version %d.", SchemaVersion),
+		"anyOf":       anyOf,
+		"$defs":       defs,
+	}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// objectSchema describes a payload struct; embedded structs (Header) contribute their fields
+func objectSchema(t reflect.Type) map[string]interface{} {
+	properties := make(map[string]interface{})
+	required := []string{}
+	var addFields func(t reflect.Type)
+	addFields = func(t reflect.Type) {
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if field.Anonymous {
+				addFields(field.Type)
+				continue
+			}
+			tag := strings.Split(field.Tag.Get("json"), ",")
+			if tag[0] == "" || tag[0] == "-" {
+				continue
+			}
+
+			property := typeSchema(field.Type)
+			if desc := field.Tag.Get("desc"); desc != "" {
+				property["description"] = desc
+			}
+			if tag[0] == "version" {
+				property["const"] = SchemaVersion
+			}
+			properties[tag[0]] = property
+			if len(tag) == 1 || tag[1] != "omitempty" {
+				required = append(required, tag[0])
+			}
+		}
+	}
+	addFields(t)
+
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+		"required":   required,
+	}
+}
+
+func typeSchema(t reflect.Type) map[string]interface{} {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == timeType {
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Slice:
+		return map[string]interface{}{"type": "array", "items": typeSchema(t.Elem())}
+	}
+	panic(fmt.Sprintf("events: no JSON Schema for %s", t))
+}
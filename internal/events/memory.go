@@ -0,0 +1,32 @@
+package events
+
+import "sync"
+
+// MemoryTransport links the buses of one process: every event sent reaches every started receiver. It is the
+// transport of a single instance, and lets tests stand in several instances with several buses.
+type MemoryTransport struct {
+	mu        sync.Mutex // Also keeps events in the order they were sent
+	receivers []Receiver
+}
+
+func NewMemoryTransport() *MemoryTransport {
+	return &MemoryTransport{}
+}
+
+func (t *MemoryTransport) Start(receiver Receiver) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.receivers = append(t.receivers, receiver)
+	return nil
+}
+
+func (t *MemoryTransport) Send(e Event) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, receiver := range t.receivers {
+		receiver.Receive(e)
+	}
+	return nil
+}
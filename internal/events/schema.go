@@ -0,0 +1,198 @@
+package events
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"instant-hdr-backend/internal/models"
+)
+
+//go:generate go run ../../cmd/eventschema -o ../../docs/events.schema.json
+
+// SchemaVersion is the version of every payload. It goes up when a field is removed or changes meaning;
+// adding a field doesn't change it, so clients should ignore fields they don't know.
+const SchemaVersion = 1
+
+// Event names, on the channels order:{order_id} and user:{user_id} of the order's owner
+const (
+	EventUploadStarted     = "upload_started"
+	EventUploadCompleted   = "upload_completed"
+	EventProcessingStarted = "processing_started"
+	EventImageProcessed    = "webhook_image_processed"
+	EventDownloadReady     = "download_ready"
+	EventProcessingFailed  = "processing_failed"
+	EventOrderReset        = "order_reset"
+)
+
+// Header is the part every payload has. Encode fills it in.
+type Header struct {
+	Version   int       `json:"version" desc:"Schema version of the payload"`
+	OrderID   string    `json:"order_id" desc:"Order the event is about"`
+	Seq       int64     `json:"seq,omitempty" desc:"Number of the event within the order: 1, 2, 3, ... A gap means events were missed; GET /orders/{order_id}/events?since= returns them"`
+	Status    string    `json:"status,omitempty" desc:"Status of the order after the event, when the event changed it"`
+	Timestamp time.Time `json:"timestamp" desc:"When the event happened"`
+}
+
+func (h *Header) header() *Header {
+	return h
+}
+
+// Payload is a typed event payload
+type Payload interface {
+	EventName() string
+	Validate() error // Checks the event's own fields; Encode checks the header
+	header() *Header
+}
+
+type UploadStarted struct {
+	Header
+	FileCount int `json:"file_count" desc:"Number of files received"`
+}
+
+func (*UploadStarted) EventName() string { return EventUploadStarted }
+
+func (e *UploadStarted) Validate() error {
+	if e.FileCount < 1 {
+		return errors.New("file_count must be positive")
+	}
+	return nil
+}
+
+type UploadCompleted struct {
+	Header
+	FileCount int `json:"file_count" desc:"Number of files uploaded to AutoEnhance"`
+}
+
+func (*UploadCompleted) EventName() string { return EventUploadCompleted }
+
+func (e *UploadCompleted) Validate() error {
+	if e.FileCount < 0 {
+		return errors.New("file_count must not be negative")
+	}
+	return nil
+}
+
+type ProcessingStarted struct {
+	Header
+}
+
+func (*ProcessingStarted) EventName() string { return EventProcessingStarted }
+
+func (*ProcessingStarted) Validate() error { return nil }
+
+// ImageProcessed is sent for every image AutoEnhance reports as processed
+type ImageProcessed struct {
+	Header
+	ImageID           string `json:"image_id" desc:"AutoEnhance image ID"`
+	Error             bool   `json:"error" desc:"Processing the image failed"`
+	OrderIsProcessing bool   `json:"order_is_processing" desc:"Other images of the order are still being processed"`
+}
+
+func (*ImageProcessed) EventName() string { return EventImageProcessed }
+
+func (*ImageProcessed) Validate() error { return nil }
+
+type DownloadReady struct {
+	Header
+	StorageURLs []string   `json:"storage_urls" desc:"Preview URLs of the processed images"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty" desc:"When the URLs stop working, if they are signed (private storage)"`
+}
+
+func (*DownloadReady) EventName() string { return EventDownloadReady }
+
+func (e *DownloadReady) Validate() error {
+	if len(e.StorageURLs) == 0 {
+		return errors.New("storage_urls must not be empty")
+	}
+	return nil
+}
+
+type ProcessingFailed struct {
+	Header
+	Error string `json:"error" desc:"What went wrong"`
+}
+
+func (*ProcessingFailed) EventName() string { return EventProcessingFailed }
+
+func (e *ProcessingFailed) Validate() error {
+	if e.Error == "" {
+		return errors.New("error must not be empty")
+	}
+	return nil
+}
+
+// OrderReset is sent when an admin clears the order's error; the header's status is the one it went back to
+type OrderReset struct {
+	Header
+}
+
+func (*OrderReset) EventName() string { return EventOrderReset }
+
+func (*OrderReset) Validate() error { return nil }
+
+// catalog lists every event, in the order they usually happen. Encode only accepts these, and JSONSchema describes them.
+var catalog = []struct {
+	payload     Payload
+	description string
+}{
+	{&UploadStarted{}, "Files were received and are being uploaded to AutoEnhance"},
+	{&UploadCompleted{}, "The upload finished; files that failed are reported in the upload response"},
+	{&ProcessingStarted{}, "AutoEnhance started processing the order"},
+	{&ImageProcessed{}, "AutoEnhance processed an image"},
+	{&DownloadReady{}, "Previews of the processed images are stored and can be downloaded"},
+	{&ProcessingFailed{}, "Processing the order failed"},
+	{&OrderReset{}, "An admin cleared the order's error and put it back to an earlier status"},
+}
+
+// Encode validates p and prepares it for the order_events outbox. status is the order's status after the event,
+// empty when the event doesn't change it.
+func Encode(orderID uuid.UUID, status string, p Payload) (*models.OrderEvent, error) {
+	name := p.EventName()
+	known := false
+	for _, entry := range catalog {
+		known = known || entry.payload.EventName() == name
+	}
+	if !known {
+		return nil, fmt.Errorf("unknown event %q", name)
+	}
+	if orderID == uuid.Nil {
+		return nil, fmt.Errorf("invalid %s event: order_id is missing", name)
+	}
+
+	*p.header() = Header{
+		Version:   SchemaVersion,
+		OrderID:   orderID.String(),
+		Status:    status,
+		Timestamp: time.Now().UTC().Truncate(time.Second),
+	}
+	if err := p.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid %s event: %w", name, err)
+	}
+	data, err := json.Marshal(p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal %s event: %w", name, err)
+	}
+	return &models.OrderEvent{OrderID: orderID, Event: name, Payload: data}, nil
+}
+
+// FromOrderEvent is how subscribers see an outbox event: its seq is the ID and is added to the payload
+func FromOrderEvent(e models.OrderEvent) Event {
+	return Event{ID: uint64(e.Seq), Channel: OrderChannel(e.OrderID), Name: e.Event, Data: WithSeq(e), Source: e.ID}
+}
+
+// WithSeq returns the event's payload with its seq, as clients receive it
+func WithSeq(e models.OrderEvent) json.RawMessage {
+	payload := make(map[string]interface{})
+	if err := json.Unmarshal(e.Payload, &payload); err != nil {
+		return e.Payload
+	}
+	payload["seq"] = e.Seq
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return e.Payload
+	}
+	return data
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"instant-hdr-backend/internal/authz"
+	"instant-hdr-backend/internal/middleware"
+	"instant-hdr-backend/internal/models"
+)
+
+// authorizeOrder checks that the user's role on an order is at least min. Being able to load the order
+// (GetOrder) already means the user is its owner or a member of its organization, so read-only endpoints
+// don't need this. Writes the error response and returns false when the role is not sufficient.
+func authorizeOrder(c *gin.Context, store authz.Store, order *models.Order, userID uuid.UUID, min string) bool {
+	return !respondAccessError(c, authz.RequireOrderRole(store, order, userID, min), "order not found")
+}
+
+// respondAccessError writes the response for an authz error and reports whether there was one.
+// Users without any role get notFound (404), users whose role is too low get 403.
+func respondAccessError(c *gin.Context, err error, notFound string) bool {
+	if err == nil {
+		return false
+	}
+
+	var forbidden *authz.ForbiddenError
+	switch {
+	case errors.As(err, &forbidden):
+		c.JSON(http.StatusForbidden, models.ErrorResponse{
+			Error:   "forbidden",
+			Message: forbidden.Error(),
+		})
+	case errors.Is(err, authz.ErrNotMember):
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   notFound,
+			Message: err.Error(),
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "failed to check access",
+			Message: err.Error(),
+		})
+	}
+	return true
+}
+
+// apiKeyOrganization returns the organization an organization API key is limited to. It is not valid for
+// JWTs and personal keys. Routes with :order_id are checked by APIKeys.RequireScope; this is for the rest.
+func apiKeyOrganization(c *gin.Context) uuid.NullUUID {
+	if key := middleware.RequestAPIKey(c); key != nil {
+		return key.OrganizationID
+	}
+	return uuid.NullUUID{}
+}
+
+// respondAPIKeyOrganization answers requests of organization API keys that name another organization
+func respondAPIKeyOrganization(c *gin.Context) {
+	c.JSON(http.StatusForbidden, models.ErrorResponse{
+		Error:   "forbidden",
+		Message: "the api key is limited to organization " + apiKeyOrganization(c).UUID.String(),
+	})
+}
@@ -0,0 +1,743 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"instant-hdr-backend/internal/autoenhance"
+	"instant-hdr-backend/internal/credits"
+	"instant-hdr-backend/internal/middleware"
+	"instant-hdr-backend/internal/models"
+	"instant-hdr-backend/internal/quota"
+	"instant-hdr-backend/internal/repository"
+	"instant-hdr-backend/internal/services"
+)
+
+// resettableStatuses are the statuses an order can be put back to when its error is cleared
+var resettableStatuses = []string{"created", "uploading", "uploaded", "processing", "previews_ready"}
+
+type AdminHandler struct {
+	consistencyChecker *services.ConsistencyChecker
+	autoenhanceClient  *autoenhance.Client
+	dbClient           repository.Repository
+	storageService     *services.StorageService
+	dispatcher         *services.EventDispatcher
+	quotaService       *quota.Service
+	creditService      *credits.Service
+}
+
+func NewAdminHandler(
+	consistencyChecker *services.ConsistencyChecker,
+	autoenhanceClient *autoenhance.Client,
+	dbClient repository.Repository,
+	storageService *services.StorageService,
+	dispatcher *services.EventDispatcher,
+	quotaService *quota.Service,
+	creditService *credits.Service,
+) *AdminHandler {
+	return &AdminHandler{
+		consistencyChecker: consistencyChecker,
+		autoenhanceClient:  autoenhanceClient,
+		dbClient:           dbClient,
+		storageService:     storageService,
+		dispatcher:         dispatcher,
+		quotaService:       quotaService,
+		creditService:      creditService,
+	}
+}
+
+// CheckConsistency godoc
+// @Summary     Storage/database consistency report
+// @Description Compares storage objects under users/{user_id}/orders/{order_id}/ with the order_files and brackets tables and reports
+// @Description orphaned objects, dangling rows, rows with a missing storage_path/user_id, and rows or brackets whose order is gone. Nothing is changed.
+// @Description Requires an admin (see GET /admin/orders).
+// @Tags        admin
+// @Accept      json
+// @Produce     json
+// @Security    Bearer
+// @Param       user_id query string false "Limit the check to one user (UUID)"
+// @Param       min_age query string false "Ignore objects modified more recently than this (Go duration)" default(1h)
+// @Success     200 {object} models.ConsistencyReport
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     401 {object} models.ErrorResponse
+// @Failure     403 {object} models.ErrorResponse
+// @Failure     500 {object} models.ErrorResponse
+// @Router      /admin/consistency [get]
+func (h *AdminHandler) CheckConsistency(c *gin.Context) {
+	h.runConsistency(c, false)
+}
+
+// RepairConsistency godoc
+// @Summary     Repair storage/database inconsistencies
+// @Description Runs the consistency check and repairs what it finds: orphaned objects are deleted, dangling rows are deleted,
+// @Description rows with a missing path are relinked to the object at the expected path (or deleted if there is none).
+// @Description Pass dry_run=true to see what would be repaired without changing anything.
+// @Description Requires an admin (see GET /admin/orders).
+// @Tags        admin
+// @Accept      json
+// @Produce     json
+// @Security    Bearer
+// @Param       user_id query string false "Limit the repair to one user (UUID)"
+// @Param       min_age query string false "Ignore objects modified more recently than this (Go duration)" default(1h)
+// @Param       dry_run query bool   false "Report only" default(false)
+// @Success     200 {object} models.ConsistencyReport
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     401 {object} models.ErrorResponse
+// @Failure     403 {object} models.ErrorResponse
+// @Failure     500 {object} models.ErrorResponse
+// @Router      /admin/consistency/repair [post]
+func (h *AdminHandler) RepairConsistency(c *gin.Context) {
+	h.runConsistency(c, c.DefaultQuery("dry_run", "false") != "true")
+}
+
+func (h *AdminHandler) runConsistency(c *gin.Context, repair bool) {
+	if h.consistencyChecker == nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "database not available"})
+		return
+	}
+
+	opts := services.ConsistencyOptions{
+		Repair:       repair,
+		MinObjectAge: services.DefaultMinObjectAge,
+	}
+
+	if userIDStr := c.Query("user_id"); userIDStr != "" {
+		userID, err := uuid.Parse(userIDStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid user id"})
+			return
+		}
+		opts.UserID = uuid.NullUUID{UUID: userID, Valid: true}
+	}
+
+	if minAge := c.Query("min_age"); minAge != "" {
+		d, err := time.ParseDuration(minAge)
+		if err != nil || d < 0 {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "min_age must be a non-negative duration such as 30m or 2h"})
+			return
+		}
+		opts.MinObjectAge = d
+	}
+
+	report := h.consistencyChecker.Check(opts)
+
+	action := models.AuditAdminConsistencyCheck
+	if repair {
+		action = models.AuditAdminConsistencyRepair
+	}
+	h.audit(c, action, uuid.NullUUID{}, opts.UserID, gin.H{
+		"min_age":  opts.MinObjectAge.String(),
+		"issues":   len(report.Issues),
+		"repaired": report.Repaired,
+	})
+	c.JSON(http.StatusOK, report)
+}
+
+// SearchOrders godoc
+// @Summary     Search all orders
+// @Description Lists the orders of every user, with the filters, sorting and paging of GET /orders, optionally limited to one user.
+// @Description Admin endpoints require a user listed in ADMIN_USER_IDS, a JWT with ADMIN_ROLE in app_metadata.role(s),
+// @Description or an API key with the admin scope. Every call is recorded in the audit log.
+// @Tags        admin
+// @Accept      json
+// @Produce     json
+// @Security    Bearer
+// @Param       user_id         query string false "Only orders created by this user (UUID)"
+// @Param       trashed         query bool   false "Search the trash instead of active orders" default(false)
+// @Param       status          query string false "Comma-separated statuses"
+// @Param       is_processing   query bool   false "Filter by AutoEnhance processing state"
+// @Param       name            query string false "Case-insensitive substring of the order name"
+// @Param       tag             query string false "Comma-separated tags; orders must carry all of them"
+// @Param       property_id     query string false "Only orders of this property"
+// @Param       organization_id query string false "Only orders of this organization"
+// @Param       created_after   query string false "Created at or after (RFC 3339 or YYYY-MM-DD)"
+// @Param       created_before  query string false "Created before (RFC 3339 or YYYY-MM-DD)"
+// @Param       sort            query string false "Sort key" Enums(created_at, updated_at, name, deleted_at) default(created_at)
+// @Param       direction       query string false "Sort direction (default desc for dates, asc for name)" Enums(asc, desc)
+// @Param       limit           query int    false "Page size (1-200)" default(50)
+// @Param       cursor          query string false "next_cursor from the previous page"
+// @Success     200 {object} models.OrderListResponse
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     401 {object} models.ErrorResponse
+// @Failure     403 {object} models.ErrorResponse
+// @Failure     500 {object} models.ErrorResponse
+// @Router      /admin/orders [get]
+func (h *AdminHandler) SearchOrders(c *gin.Context) {
+	if h.dbClient == nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "database not available"})
+		return
+	}
+
+	query := c.Request.URL.Query()
+	opts, err := parseOrderListOptions(query, query.Get("trashed") == "true")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid query", Message: err.Error()})
+		return
+	}
+	if userIDStr := query.Get("user_id"); userIDStr != "" {
+		userID, err := uuid.Parse(userIDStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid query", Message: "user_id must be a UUID"})
+			return
+		}
+		opts.UserID = uuid.NullUUID{UUID: userID, Valid: true}
+	}
+
+	pageSize := opts.Limit
+	opts.Limit = pageSize + 1
+	orders, err := h.dbClient.SearchOrders(opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "failed to search orders",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	response := orderPage(orders, opts, pageSize)
+	h.audit(c, models.AuditAdminOrdersSearch, uuid.NullUUID{}, opts.UserID, gin.H{
+		"query":   c.Request.URL.RawQuery,
+		"results": len(response.Orders),
+	})
+	c.JSON(http.StatusOK, response)
+}
+
+// GetOrder godoc
+// @Summary     Inspect any order
+// @Description Returns an order of any user with its error message and metadata, the order as AutoEnhance reports it now,
+// @Description our bracket rows and the brackets AutoEnhance still has. AutoEnhance failures are reported in autoenhance_error.
+// @Tags        admin
+// @Accept      json
+// @Produce     json
+// @Security    Bearer
+// @Param       order_id path string true "Order ID (UUID)"
+// @Success     200 {object} models.AdminOrderResponse
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     401 {object} models.ErrorResponse
+// @Failure     403 {object} models.ErrorResponse
+// @Failure     404 {object} models.ErrorResponse
+// @Failure     500 {object} models.ErrorResponse
+// @Router      /admin/orders/{order_id} [get]
+func (h *AdminHandler) GetOrder(c *gin.Context) {
+	order, ok := h.order(c)
+	if !ok {
+		return
+	}
+
+	brackets, err := h.dbClient.GetBracketsByOrderID(order.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "failed to get brackets",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	response := models.AdminOrderResponse{
+		Order:               orderSummary(*order),
+		ErrorMessage:        order.ErrorMessage.String,
+		Brackets:            make([]models.BracketResponse, len(brackets)),
+		AutoEnhanceBrackets: []autoenhance.BracketOut{},
+	}
+	if len(order.Metadata) > 0 {
+		json.Unmarshal(order.Metadata, &response.Metadata)
+	}
+	for i, bracket := range brackets {
+		response.Brackets[i] = models.BracketResponse{
+			ID:         bracket.ID.String(),
+			BracketID:  bracket.BracketID,
+			ImageID:    bracket.ImageID.String,
+			Filename:   bracket.Filename,
+			IsUploaded: bracket.IsUploaded,
+			CreatedAt:  bracket.CreatedAt,
+		}
+		if len(bracket.Metadata) > 0 {
+			json.Unmarshal(bracket.Metadata, &response.Brackets[i].Metadata)
+		}
+	}
+
+	if h.autoenhanceClient == nil {
+		response.AutoEnhanceError = "autoenhance client not available"
+	} else if aeOrder, err := h.autoenhanceClient.GetOrder(order.ID.String()); err != nil {
+		response.AutoEnhanceError = err.Error()
+	} else {
+		response.AutoEnhance = aeOrder
+		if aeBrackets, err := h.autoenhanceClient.GetOrderBrackets(order.ID.String()); err != nil {
+			response.AutoEnhanceError = "failed to get brackets: " + err.Error()
+		} else if aeBrackets.Brackets != nil {
+			response.AutoEnhanceBrackets = aeBrackets.Brackets
+		}
+	}
+
+	h.audit(c, models.AuditAdminOrderView, uuid.NullUUID{UUID: order.ID, Valid: true}, uuid.NullUUID{UUID: order.UserID, Valid: true}, nil)
+	c.JSON(http.StatusOK, response)
+}
+
+// ResyncOrder godoc
+// @Summary     Resync an order from AutoEnhance
+// @Description Fetches the order from AutoEnhance and overwrites the cached name, AutoEnhance status, processing flags and image count.
+// @Tags        admin
+// @Accept      json
+// @Produce     json
+// @Security    Bearer
+// @Param       order_id path string true "Order ID (UUID)"
+// @Success     200 {object} models.AdminOrderActionResponse
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     401 {object} models.ErrorResponse
+// @Failure     403 {object} models.ErrorResponse
+// @Failure     404 {object} models.ErrorResponse
+// @Failure     500 {object} models.ErrorResponse
+// @Failure     502 {object} models.ErrorResponse
+// @Router      /admin/orders/{order_id}/resync [post]
+func (h *AdminHandler) ResyncOrder(c *gin.Context) {
+	order, ok := h.order(c)
+	if !ok {
+		return
+	}
+	if h.autoenhanceClient == nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "autoenhance client not available"})
+		return
+	}
+
+	aeOrder, err := h.autoenhanceClient.GetOrder(order.ID.String())
+	if err != nil {
+		c.JSON(http.StatusBadGateway, models.ErrorResponse{
+			Error:   "failed to get order from AutoEnhance",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	var lastUpdated *time.Time
+	if !aeOrder.LastUpdatedAt.Time.IsZero() {
+		lastUpdated = &aeOrder.LastUpdatedAt.Time
+	}
+	err = h.dbClient.SyncAutoEnhanceOrderData(
+		order.ID,
+		aeOrder.Name,
+		aeOrder.Status,
+		aeOrder.IsProcessing,
+		aeOrder.IsMerging,
+		aeOrder.IsDeleted,
+		int(aeOrder.TotalImages),
+		lastUpdated,
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "failed to sync order",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	h.respondAction(c, models.AuditAdminOrderResync, order, gin.H{
+		"autoenhance_status": aeOrder.Status,
+		"is_processing":      aeOrder.IsProcessing,
+		"total_images":       int(aeOrder.TotalImages),
+	})
+}
+
+// CompleteOrder godoc
+// @Summary     Re-run completion handling
+// @Description Runs what the processing-completed webhook does: syncs the order from AutoEnhance, stores watermarked previews
+// @Description of the completed images and marks the order previews_ready. Use it when a webhook was missed or failed.
+// @Tags        admin
+// @Accept      json
+// @Produce     json
+// @Security    Bearer
+// @Param       order_id path string true "Order ID (UUID)"
+// @Success     200 {object} models.AdminOrderActionResponse
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     401 {object} models.ErrorResponse
+// @Failure     403 {object} models.ErrorResponse
+// @Failure     404 {object} models.ErrorResponse
+// @Failure     500 {object} models.ErrorResponse
+// @Router      /admin/orders/{order_id}/complete [post]
+func (h *AdminHandler) CompleteOrder(c *gin.Context) {
+	order, ok := h.order(c)
+	if !ok {
+		return
+	}
+	if h.storageService == nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "storage service not available"})
+		return
+	}
+
+	// Synchronous, unlike the webhook, so the response shows the outcome
+	h.storageService.HandleProcessingCompleted(order.ID.String(), "")
+
+	h.respondAction(c, models.AuditAdminOrderComplete, order, gin.H{"previous_status": order.Status})
+}
+
+// ResetOrderError godoc
+// @Summary     Reset an order's error state
+// @Description Clears the order's error message and puts it back to the given status. Without one, the order becomes
+// @Description previews_ready if it has files, uploaded if it has brackets and created otherwise.
+// @Description Subscribers of the order get an order_reset event with the new status.
+// @Tags        admin
+// @Accept      json
+// @Produce     json
+// @Security    Bearer
+// @Param       order_id path string true "Order ID (UUID)"
+// @Param       request body models.ResetOrderErrorRequest false "Status to go back to"
+// @Success     200 {object} models.AdminOrderActionResponse
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     401 {object} models.ErrorResponse
+// @Failure     403 {object} models.ErrorResponse
+// @Failure     404 {object} models.ErrorResponse
+// @Failure     500 {object} models.ErrorResponse
+// @Router      /admin/orders/{order_id}/reset-error [post]
+func (h *AdminHandler) ResetOrderError(c *gin.Context) {
+	order, ok := h.order(c)
+	if !ok {
+		return
+	}
+
+	var req models.ResetOrderErrorRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid request body", Message: err.Error()})
+			return
+		}
+	}
+
+	status := req.Status
+	if status == "" {
+		var err error
+		if status, err = h.recoveredStatus(order.ID); err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error:   "failed to determine order status",
+				Message: err.Error(),
+			})
+			return
+		}
+	} else if !slices.Contains(resettableStatuses, status) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "invalid status",
+			Message: fmt.Sprintf("status must be one of %v", resettableStatuses),
+		})
+		return
+	}
+
+	progress := 0
+	if status == "previews_ready" {
+		progress = 100
+	}
+	// Through the outbox, so clients watching the order see it leave the failed state
+	if err := h.dispatcher.PublishReset(order.ID, status, progress); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "failed to reset order",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	h.respondAction(c, models.AuditAdminOrderResetError, order, gin.H{
+		"previous_status": order.Status,
+		"previous_error":  order.ErrorMessage.String,
+		"status":          status,
+	})
+}
+
+// recoveredStatus is the status an order has reached judging by its files and brackets
+func (h *AdminHandler) recoveredStatus(orderID uuid.UUID) (string, error) {
+	files, err := h.dbClient.GetOrderFilesByOrderID(orderID)
+	if err != nil {
+		return "", err
+	}
+	if len(files) > 0 {
+		return "previews_ready", nil
+	}
+	brackets, err := h.dbClient.CountBrackets(orderID)
+	if err != nil {
+		return "", err
+	}
+	if brackets > 0 {
+		return "uploaded", nil
+	}
+	return "created", nil
+}
+
+// GetUserUsage godoc
+// @Summary     Get a user's quota usage
+// @Description Returns what GET /me/usage returns for the given user.
+// @Tags        admin
+// @Accept      json
+// @Produce     json
+// @Security    Bearer
+// @Param       user_id path string true "User ID (UUID)"
+// @Success     200 {object} models.UsageResponse
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     401 {object} models.ErrorResponse
+// @Failure     403 {object} models.ErrorResponse
+// @Failure     500 {object} models.ErrorResponse
+// @Router      /admin/users/{user_id}/usage [get]
+func (h *AdminHandler) GetUserUsage(c *gin.Context) {
+	if h.quotaService == nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "database not available"})
+		return
+	}
+
+	userID, err := uuid.Parse(c.Param("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid user id"})
+		return
+	}
+
+	plan, usage, err := h.quotaService.Usage(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "failed to get usage",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	h.audit(c, models.AuditAdminUsageView, uuid.NullUUID{}, uuid.NullUUID{UUID: userID, Valid: true}, nil)
+	c.JSON(http.StatusOK, usageResponse(h.quotaService, plan, usage))
+}
+
+// GetUserCredits godoc
+// @Summary     Get a user's credits
+// @Description Returns what GET /me/credits returns for the given user.
+// @Tags        admin
+// @Accept      json
+// @Produce     json
+// @Security    Bearer
+// @Param       user_id path  string true  "User ID (UUID)"
+// @Param       limit   query int    false "Maximum number of ledger entries (1-1000)" default(50)
+// @Success     200 {object} models.CreditsResponse
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     401 {object} models.ErrorResponse
+// @Failure     403 {object} models.ErrorResponse
+// @Failure     500 {object} models.ErrorResponse
+// @Router      /admin/users/{user_id}/credits [get]
+func (h *AdminHandler) GetUserCredits(c *gin.Context) {
+	userID, ok := h.creditUser(c)
+	if !ok {
+		return
+	}
+	if respondCredits(c, h.creditService, userID) {
+		h.audit(c, models.AuditAdminCreditsView, uuid.NullUUID{}, uuid.NullUUID{UUID: userID, Valid: true}, nil)
+	}
+}
+
+// GrantCredits godoc
+// @Summary     Grant credits to a user
+// @Description Adds credits to the user's balance, or takes them back with a negative amount, and records it in their ledger.
+// @Description Returns the user's credits afterwards.
+// @Tags        admin
+// @Accept      json
+// @Produce     json
+// @Security    Bearer
+// @Param       user_id path string                     true "User ID (UUID)"
+// @Param       request body models.GrantCreditsRequest true "Credits to add"
+// @Success     200 {object} models.CreditsResponse
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     401 {object} models.ErrorResponse
+// @Failure     403 {object} models.ErrorResponse
+// @Failure     500 {object} models.ErrorResponse
+// @Router      /admin/users/{user_id}/credits [post]
+func (h *AdminHandler) GrantCredits(c *gin.Context) {
+	userID, ok := h.creditUser(c)
+	if !ok {
+		return
+	}
+
+	var req models.GrantCreditsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "invalid request body",
+			Message: "amount must be a non-zero number of credits",
+		})
+		return
+	}
+
+	var actorID uuid.NullUUID
+	if id, err := uuid.Parse(c.GetString(middleware.UserIDKey)); err == nil {
+		actorID = uuid.NullUUID{UUID: id, Valid: true}
+	}
+	entry, err := h.creditService.Grant(userID, req.Amount, req.Reason, actorID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "failed to grant credits",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	h.audit(c, models.AuditAdminCreditsGrant, uuid.NullUUID{}, uuid.NullUUID{UUID: userID, Valid: true}, gin.H{
+		"amount": req.Amount, "reason": req.Reason, "entry_id": entry.ID,
+	})
+	respondCredits(c, h.creditService, userID)
+}
+
+// SetCreditBudget godoc
+// @Summary     Set a user's monthly credit budget
+// @Description Sets how many credits the user may spend per calendar month (UTC); 0 means no budget and null restores CREDITS_MONTHLY_BUDGET.
+// @Description Returns the user's credits afterwards.
+// @Tags        admin
+// @Accept      json
+// @Produce     json
+// @Security    Bearer
+// @Param       user_id path string                        true "User ID (UUID)"
+// @Param       request body models.SetCreditBudgetRequest true "Monthly budget"
+// @Success     200 {object} models.CreditsResponse
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     401 {object} models.ErrorResponse
+// @Failure     403 {object} models.ErrorResponse
+// @Failure     500 {object} models.ErrorResponse
+// @Router      /admin/users/{user_id}/credits/budget [put]
+func (h *AdminHandler) SetCreditBudget(c *gin.Context) {
+	userID, ok := h.creditUser(c)
+	if !ok {
+		return
+	}
+
+	var req models.SetCreditBudgetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+	if req.MonthlyBudget != nil && *req.MonthlyBudget < 0 {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "monthly_budget must not be negative"})
+		return
+	}
+
+	if err := h.creditService.SetBudget(userID, req.MonthlyBudget); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "failed to set credit budget",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	h.audit(c, models.AuditAdminCreditsBudget, uuid.NullUUID{}, uuid.NullUUID{UUID: userID, Valid: true}, gin.H{
+		"monthly_budget": req.MonthlyBudget,
+	})
+	respondCredits(c, h.creditService, userID)
+}
+
+// ListAuditLog godoc
+// @Summary     Read the audit log
+// @Description Returns audit log entries, newest first. Filter by who acted (actor_id), the order or the user concerned, and time.
+// @Tags        admin
+// @Accept      json
+// @Produce     json
+// @Security    Bearer
+// @Param       actor_id query string false "Only actions by this user (UUID)"
+// @Param       order_id query string false "Only actions on this order (UUID)"
+// @Param       user_id  query string false "Only actions concerning this user (UUID)"
+// @Param       since    query string false "At or after (RFC 3339 or YYYY-MM-DD)"
+// @Param       until    query string false "Before (RFC 3339 or YYYY-MM-DD)"
+// @Param       limit    query int    false "Maximum number of entries (1-1000)" default(100)
+// @Success     200 {object} models.AuditLogResponse
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     401 {object} models.ErrorResponse
+// @Failure     403 {object} models.ErrorResponse
+// @Failure     500 {object} models.ErrorResponse
+// @Router      /admin/audit-log [get]
+func (h *AdminHandler) ListAuditLog(c *gin.Context) {
+	if h.dbClient == nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "database not available"})
+		return
+	}
+
+	opts, ok := auditListOptions(c)
+	if !ok {
+		return
+	}
+	for param, id := range map[string]*uuid.NullUUID{"actor_id": &opts.ActorID, "order_id": &opts.OrderID, "user_id": &opts.UserID} {
+		if value := c.Query(param); value != "" {
+			parsed, err := uuid.Parse(value)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid query", Message: param + " must be a UUID"})
+				return
+			}
+			*id = uuid.NullUUID{UUID: parsed, Valid: true}
+		}
+	}
+
+	entries, err := h.dbClient.ListAuditEntries(opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "failed to list audit log",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	h.audit(c, models.AuditAdminAuditView, opts.OrderID, opts.UserID, gin.H{"query": c.Request.URL.RawQuery})
+	c.JSON(http.StatusOK, auditLogResponse(entries))
+}
+
+// creditUser returns the :user_id user of the credit endpoints
+func (h *AdminHandler) creditUser(c *gin.Context) (uuid.UUID, bool) {
+	if h.creditService == nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "database not available"})
+		return uuid.Nil, false
+	}
+
+	userID, err := uuid.Parse(c.Param("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid user id"})
+		return uuid.Nil, false
+	}
+	return userID, true
+}
+
+// order loads the :order_id order of any user, including orders in the trash
+func (h *AdminHandler) order(c *gin.Context) (*models.Order, bool) {
+	if h.dbClient == nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "database not available"})
+		return nil, false
+	}
+
+	orderID, err := uuid.Parse(c.Param("order_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid order id"})
+		return nil, false
+	}
+
+	order, err := h.dbClient.GetOrderByAutoEnhanceOrderID(orderID.String())
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "order not found",
+			Message: err.Error(),
+		})
+		return nil, false
+	}
+	return order, true
+}
+
+// respondAction audits an action on order and responds with the order as it is now
+func (h *AdminHandler) respondAction(c *gin.Context, action string, order *models.Order, details gin.H) {
+	h.audit(c, action, uuid.NullUUID{UUID: order.ID, Valid: true}, uuid.NullUUID{UUID: order.UserID, Valid: true}, details)
+
+	updated, err := h.dbClient.GetOrderByAutoEnhanceOrderID(order.ID.String())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "failed to get order",
+			Message: err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, models.AdminOrderActionResponse{
+		Action:       action,
+		Order:        orderSummary(*updated),
+		ErrorMessage: updated.ErrorMessage.String,
+	})
+}
+
+// audit records an admin action concerning orderID and userID
+func (h *AdminHandler) audit(c *gin.Context, action string, orderID, userID uuid.NullUUID, details gin.H) {
+	recordAudit(c, h.dbClient, models.AuditEntry{Action: action, OrderID: orderID, UserID: userID}, details)
+}
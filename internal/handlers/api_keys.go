@@ -0,0 +1,337 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"instant-hdr-backend/internal/authz"
+	"instant-hdr-backend/internal/config"
+	"instant-hdr-backend/internal/middleware"
+	"instant-hdr-backend/internal/models"
+	"instant-hdr-backend/internal/repository"
+)
+
+const maxAPIKeyName = 100
+
+type APIKeysHandler struct {
+	dbClient repository.Repository
+	cfg      *config.Config
+}
+
+func NewAPIKeysHandler(dbClient repository.Repository, cfg *config.Config) *APIKeysHandler {
+	return &APIKeysHandler{
+		dbClient: dbClient,
+		cfg:      cfg,
+	}
+}
+
+// CreateAPIKey godoc
+// @Summary     Create an API key
+// @Description Creates a key for server-to-server integrations. Send it as "Authorization: Bearer ihdr_..."; it acts as you,
+// @Description limited to its scopes (orders:read, orders:write, images:download, webhooks:manage). The key is only returned
+// @Description by this call - store it safely. Organization keys require the admin role and only reach that organization's orders.
+// @Description Only admins can create keys with the admin scope (the /admin API); those keys can't belong to an organization.
+// @Description Admin keys of role-based admins (ADMIN_ROLE) expire after at most ADMIN_ROLE_KEY_TTL.
+// @Tags        api-keys
+// @Accept      json
+// @Produce     json
+// @Security    Bearer
+// @Param       request body models.CreateAPIKeyRequest true "API key"
+// @Success     201 {object} models.APIKeyResponse
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     401 {object} models.ErrorResponse
+// @Failure     403 {object} models.ErrorResponse
+// @Failure     404 {object} models.ErrorResponse
+// @Failure     500 {object} models.ErrorResponse
+// @Router      /api-keys [post]
+func (h *APIKeysHandler) CreateAPIKey(c *gin.Context) {
+	userID, ok := h.userID(c)
+	if !ok {
+		return
+	}
+
+	var req models.CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid request body", Message: err.Error()})
+		return
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" || len(name) > maxAPIKeyName {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "invalid request body",
+			Message: fmt.Sprintf("name must be between 1 and %d characters", maxAPIKeyName),
+		})
+		return
+	}
+	scopes, err := normalizeScopes(req.Scopes)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid scopes", Message: err.Error()})
+		return
+	}
+	var expiresAt sql.NullTime
+	if req.ExpiresAt != nil {
+		if !req.ExpiresAt.After(time.Now()) {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid request body", Message: "expires_at must be in the future"})
+			return
+		}
+		expiresAt = sql.NullTime{Time: req.ExpiresAt.UTC(), Valid: true}
+	}
+
+	var organizationID uuid.NullUUID
+	var adminGrant sql.NullString
+	if req.OrganizationID != "" {
+		id, err := uuid.Parse(req.OrganizationID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid organization id"})
+			return
+		}
+		if _, err := authz.RequireOrganizationRole(h.dbClient, id, userID, models.RoleAdmin); respondAccessError(c, err, "organization not found") {
+			return
+		}
+		organizationID = uuid.NullUUID{UUID: id, Valid: true}
+	}
+
+	if slices.Contains(scopes, models.ScopeAdmin) {
+		if organizationID.Valid {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid scopes", Message: "organization keys can't have the admin scope"})
+			return
+		}
+		if !middleware.IsAdmin(c, h.cfg) {
+			c.JSON(http.StatusForbidden, models.ErrorResponse{Error: "forbidden", Message: "only admins can create keys with the admin scope"})
+			return
+		}
+		adminGrant, expiresAt = h.adminKeyGrant(userID, expiresAt)
+	}
+
+	rawKey, prefix, hash, err := middleware.GenerateAPIKey()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "failed to generate api key", Message: err.Error()})
+		return
+	}
+	key := models.APIKey{
+		UserID:         userID,
+		OrganizationID: organizationID,
+		Name:           name,
+		Prefix:         prefix,
+		KeyHash:        hash,
+		Scopes:         scopes,
+		ExpiresAt:      expiresAt,
+		AdminGrant:     adminGrant,
+	}
+	if err := h.dbClient.CreateAPIKey(&key); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "failed to create api key",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	recordAudit(c, h.dbClient, models.AuditEntry{Action: models.AuditAPIKeyCreate}, gin.H{
+		"key_id": key.ID, "prefix": key.Prefix, "scopes": key.Scopes, "organization_id": key.OrganizationID,
+	})
+	response := apiKeyResponse(key)
+	response.Key = rawKey
+	c.JSON(http.StatusCreated, response)
+}
+
+// ListAPIKeys godoc
+// @Summary     List API keys
+// @Description Returns the keys you created, newest first, or all keys of an organization (admin role required). Revoked keys are included.
+// @Tags        api-keys
+// @Accept      json
+// @Produce     json
+// @Security    Bearer
+// @Param       organization_id query string false "List the organization's keys instead"
+// @Success     200 {object} models.APIKeyListResponse
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     401 {object} models.ErrorResponse
+// @Failure     403 {object} models.ErrorResponse
+// @Failure     404 {object} models.ErrorResponse
+// @Failure     500 {object} models.ErrorResponse
+// @Router      /api-keys [get]
+func (h *APIKeysHandler) ListAPIKeys(c *gin.Context) {
+	userID, ok := h.userID(c)
+	if !ok {
+		return
+	}
+
+	var keys []models.APIKey
+	var err error
+	if orgParam := c.Query("organization_id"); orgParam != "" {
+		organizationID, parseErr := uuid.Parse(orgParam)
+		if parseErr != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid organization id"})
+			return
+		}
+		if _, err := authz.RequireOrganizationRole(h.dbClient, organizationID, userID, models.RoleAdmin); respondAccessError(c, err, "organization not found") {
+			return
+		}
+		keys, err = h.dbClient.ListOrganizationAPIKeys(organizationID)
+	} else {
+		keys, err = h.dbClient.ListAPIKeys(userID)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "failed to list api keys",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	response := models.APIKeyListResponse{Keys: make([]models.APIKeyResponse, len(keys))}
+	for i, key := range keys {
+		response.Keys[i] = apiKeyResponse(key)
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// RevokeAPIKey godoc
+// @Summary     Revoke an API key
+// @Description Revokes a key you created, or any key of an organization you are an admin of. Revoked keys stop working immediately.
+// @Tags        api-keys
+// @Accept      json
+// @Produce     json
+// @Security    Bearer
+// @Param       key_id path string true "API key ID (UUID)"
+// @Success     200 {object} models.APIKeyResponse
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     401 {object} models.ErrorResponse
+// @Failure     404 {object} models.ErrorResponse
+// @Failure     500 {object} models.ErrorResponse
+// @Router      /api-keys/{key_id} [delete]
+func (h *APIKeysHandler) RevokeAPIKey(c *gin.Context) {
+	userID, ok := h.userID(c)
+	if !ok {
+		return
+	}
+
+	keyID, err := uuid.Parse(c.Param("key_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid api key id"})
+		return
+	}
+	key, err := h.dbClient.GetAPIKey(keyID)
+	if err == nil && key.UserID != userID {
+		// Organization admins may revoke any key of the organization; everybody else doesn't see the key
+		err = authz.ErrNotMember
+		if key.OrganizationID.Valid {
+			_, err = authz.RequireOrganizationRole(h.dbClient, key.OrganizationID.UUID, userID, models.RoleAdmin)
+		}
+	}
+	if err != nil {
+		var forbidden *authz.ForbiddenError
+		if errors.Is(err, sql.ErrNoRows) || errors.As(err, &forbidden) {
+			err = authz.ErrNotMember
+		}
+		respondAccessError(c, err, "api key not found")
+		return
+	}
+
+	if err := h.dbClient.RevokeAPIKey(keyID); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "failed to revoke api key",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	// Keys revoked by an organization admin concern their creator
+	recordAudit(c, h.dbClient, models.AuditEntry{
+		Action: models.AuditAPIKeyRevoke,
+		UserID: uuid.NullUUID{UUID: key.UserID, Valid: true},
+	}, gin.H{"key_id": key.ID, "prefix": key.Prefix, "organization_id": key.OrganizationID})
+
+	key, err = h.dbClient.GetAPIKey(keyID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "failed to get api key", Message: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, apiKeyResponse(*key))
+}
+
+func (h *APIKeysHandler) userID(c *gin.Context) (uuid.UUID, bool) {
+	if h.dbClient == nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "database not available"})
+		return uuid.Nil, false
+	}
+
+	userIDStr, exists := c.Get(middleware.UserIDKey)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{Error: "user id not found"})
+		return uuid.Nil, false
+	}
+
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid user id"})
+		return uuid.Nil, false
+	}
+	return userID, true
+}
+
+// normalizeScopes checks the requested scopes and returns them deduplicated and sorted
+func normalizeScopes(scopes []string) ([]string, error) {
+	seen := make(map[string]bool, len(scopes))
+	var normalized []string
+	for _, scope := range scopes {
+		scope = strings.ToLower(strings.TrimSpace(scope))
+		if !models.ValidScope(scope) {
+			return nil, fmt.Errorf("unknown scope %q (valid scopes: %s)", scope, strings.Join(models.Scopes, ", "))
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			normalized = append(normalized, scope)
+		}
+	}
+	if len(normalized) == 0 {
+		return nil, fmt.Errorf("at least one scope is required")
+	}
+	sort.Strings(normalized)
+	return normalized, nil
+}
+
+// adminKeyGrant records how the creator of an admin key qualified as an admin. Role-based admins can't be checked again
+// once the key is in use, so their keys expire within ADMIN_ROLE_KEY_TTL, whatever expiry was asked for.
+func (h *APIKeysHandler) adminKeyGrant(userID uuid.UUID, expiresAt sql.NullTime) (sql.NullString, sql.NullTime) {
+	if h.cfg.IsAdmin(userID.String()) {
+		return sql.NullString{String: models.AdminGrantListed, Valid: true}, expiresAt
+	}
+	limit := time.Now().Add(h.cfg.AdminRoleKeyTTL).UTC()
+	if !expiresAt.Valid || expiresAt.Time.After(limit) {
+		expiresAt = sql.NullTime{Time: limit, Valid: true}
+	}
+	return sql.NullString{String: models.AdminGrantRole, Valid: true}, expiresAt
+}
+
+func apiKeyResponse(key models.APIKey) models.APIKeyResponse {
+	response := models.APIKeyResponse{
+		ID:         key.ID.String(),
+		Name:       key.Name,
+		Prefix:     key.Prefix,
+		Scopes:     key.Scopes,
+		UserID:     key.UserID.String(),
+		AdminGrant: key.AdminGrant.String,
+		CreatedAt:  key.CreatedAt,
+	}
+	if key.OrganizationID.Valid {
+		response.OrganizationID = key.OrganizationID.UUID.String()
+	}
+	if key.ExpiresAt.Valid {
+		response.ExpiresAt = &key.ExpiresAt.Time
+	}
+	if key.LastUsedAt.Valid {
+		response.LastUsedAt = &key.LastUsedAt.Time
+	}
+	if key.RevokedAt.Valid {
+		response.RevokedAt = &key.RevokedAt.Time
+	}
+	return response
+}
@@ -0,0 +1,242 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"instant-hdr-backend/internal/middleware"
+	"instant-hdr-backend/internal/models"
+	"instant-hdr-backend/internal/repository"
+)
+
+const (
+	defaultAuditPageSize = 100
+	maxAuditPageSize     = 1000
+)
+
+type AuditHandler struct {
+	dbClient repository.Repository
+}
+
+func NewAuditHandler(dbClient repository.Repository) *AuditHandler {
+	return &AuditHandler{
+		dbClient: dbClient,
+	}
+}
+
+// ListOrderAuditLog godoc
+// @Summary     Read an order's audit log
+// @Description Returns what was done to the order and by whom, newest first: uploads, processing, downloads (with the credits they used),
+// @Description exports and changes, plus the processing results reported by AutoEnhance. Works for orders in the trash.
+// @Description Requires the admin role on organization orders.
+// @Tags        audit
+// @Accept      json
+// @Produce     json
+// @Security    Bearer
+// @Param       order_id path  string true  "Order ID (UUID)"
+// @Param       since    query string false "At or after (RFC 3339 or YYYY-MM-DD)"
+// @Param       until    query string false "Before (RFC 3339 or YYYY-MM-DD)"
+// @Param       limit    query int    false "Maximum number of entries (1-1000)" default(100)
+// @Success     200 {object} models.AuditLogResponse
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     401 {object} models.ErrorResponse
+// @Failure     403 {object} models.ErrorResponse
+// @Failure     404 {object} models.ErrorResponse
+// @Failure     500 {object} models.ErrorResponse
+// @Router      /orders/{order_id}/audit-log [get]
+func (h *AuditHandler) ListOrderAuditLog(c *gin.Context) {
+	userID, ok := h.userID(c)
+	if !ok {
+		return
+	}
+
+	orderID, err := uuid.Parse(c.Param("order_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid order id"})
+		return
+	}
+	order, err := h.dbClient.GetOrder(orderID, userID)
+	if err != nil {
+		order, err = h.dbClient.GetTrashedOrder(orderID, userID)
+	}
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "order not found",
+			Message: err.Error(),
+		})
+		return
+	}
+	if !authorizeOrder(c, h.dbClient, order, userID, models.RoleAdmin) {
+		return
+	}
+
+	opts, ok := auditListOptions(c)
+	if !ok {
+		return
+	}
+	opts.OrderID = uuid.NullUUID{UUID: order.ID, Valid: true}
+	h.list(c, opts)
+}
+
+// ListUserAuditLog godoc
+// @Summary     Read your audit log
+// @Description Returns the actions you took and the actions concerning you, newest first: changes to your orders by organization members
+// @Description and support, processing results of your orders, and changes to your account.
+// @Tags        audit
+// @Accept      json
+// @Produce     json
+// @Security    Bearer
+// @Param       since query string false "At or after (RFC 3339 or YYYY-MM-DD)"
+// @Param       until query string false "Before (RFC 3339 or YYYY-MM-DD)"
+// @Param       limit query int    false "Maximum number of entries (1-1000)" default(100)
+// @Success     200 {object} models.AuditLogResponse
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     401 {object} models.ErrorResponse
+// @Failure     500 {object} models.ErrorResponse
+// @Router      /me/audit-log [get]
+func (h *AuditHandler) ListUserAuditLog(c *gin.Context) {
+	userID, ok := h.userID(c)
+	if !ok {
+		return
+	}
+
+	opts, ok := auditListOptions(c)
+	if !ok {
+		return
+	}
+	opts.Involving = uuid.NullUUID{UUID: userID, Valid: true}
+	h.list(c, opts)
+}
+
+func (h *AuditHandler) list(c *gin.Context, opts models.AuditListOptions) {
+	entries, err := h.dbClient.ListAuditEntries(opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "failed to list audit log",
+			Message: err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, auditLogResponse(entries))
+}
+
+func (h *AuditHandler) userID(c *gin.Context) (uuid.UUID, bool) {
+	if h.dbClient == nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "database not available"})
+		return uuid.Nil, false
+	}
+
+	userIDStr, exists := c.Get(middleware.UserIDKey)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{Error: "user id not found"})
+		return uuid.Nil, false
+	}
+
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid user id"})
+		return uuid.Nil, false
+	}
+	return userID, true
+}
+
+// recordAudit appends an action of the request's user to the audit log, with the API key, request ID and client IP.
+// Failures are logged, not returned: the action has already happened and the response must say so.
+func recordAudit(c *gin.Context, store repository.AuditRepository, entry models.AuditEntry, details gin.H) {
+	if store == nil {
+		return
+	}
+
+	if actorID, err := uuid.Parse(c.GetString(middleware.UserIDKey)); err == nil {
+		entry.ActorID = uuid.NullUUID{UUID: actorID, Valid: true}
+	}
+	if key := middleware.RequestAPIKey(c); key != nil {
+		entry.APIKeyID = uuid.NullUUID{UUID: key.ID, Valid: true}
+	}
+	entry.RequestID = c.GetString(middleware.RequestIDKey)
+	entry.IP = c.ClientIP()
+	if len(details) > 0 {
+		entry.Details, _ = json.Marshal(details)
+	}
+	if err := store.CreateAuditEntry(&entry); err != nil {
+		log.Printf("[Audit] Failed to record %s: %v", entry.Action, err)
+	}
+}
+
+// orderAudit is an audit entry for an action on order, concerning its owner
+func orderAudit(action string, order *models.Order) models.AuditEntry {
+	return models.AuditEntry{
+		Action:  action,
+		OrderID: uuid.NullUUID{UUID: order.ID, Valid: true},
+		UserID:  uuid.NullUUID{UUID: order.UserID, Valid: true},
+	}
+}
+
+// auditListOptions parses the since, until and limit query parameters of the audit log endpoints,
+// writing a 400 response when they are invalid
+func auditListOptions(c *gin.Context) (models.AuditListOptions, bool) {
+	opts := models.AuditListOptions{Limit: defaultAuditPageSize}
+	var err error
+	if opts.Since, err = parseListTime(c.Query("since")); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid query", Message: "since: " + err.Error()})
+		return opts, false
+	}
+	if opts.Until, err = parseListTime(c.Query("until")); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid query", Message: "until: " + err.Error()})
+		return opts, false
+	}
+	if limit := c.Query("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 || n > maxAuditPageSize {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "invalid query",
+				Message: fmt.Sprintf("limit must be between 1 and %d", maxAuditPageSize),
+			})
+			return opts, false
+		}
+		opts.Limit = n
+	}
+	return opts, true
+}
+
+func auditLogResponse(entries []models.AuditEntry) models.AuditLogResponse {
+	response := models.AuditLogResponse{Entries: make([]models.AuditEntryResponse, len(entries))}
+	for i, entry := range entries {
+		response.Entries[i] = auditEntryResponse(entry)
+	}
+	return response
+}
+
+func auditEntryResponse(entry models.AuditEntry) models.AuditEntryResponse {
+	response := models.AuditEntryResponse{
+		ID:        entry.ID.String(),
+		Action:    entry.Action,
+		ImageID:   entry.ImageID,
+		RequestID: entry.RequestID,
+		IP:        entry.IP,
+		Details:   entry.Details,
+		CreatedAt: entry.CreatedAt,
+	}
+	for _, id := range []struct {
+		value uuid.NullUUID
+		field *string
+	}{
+		{entry.ActorID, &response.ActorID},
+		{entry.APIKeyID, &response.APIKeyID},
+		{entry.OrderID, &response.OrderID},
+		{entry.UserID, &response.UserID},
+	} {
+		if id.value.Valid {
+			*id.field = id.value.UUID.String()
+		}
+	}
+	if len(response.Details) == 0 {
+		response.Details = json.RawMessage(`{}`)
+	}
+	return response
+}
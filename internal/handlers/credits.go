@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"instant-hdr-backend/internal/credits"
+	"instant-hdr-backend/internal/middleware"
+	"instant-hdr-backend/internal/models"
+)
+
+const (
+	defaultCreditLedgerLimit = 50
+	maxCreditLedgerLimit     = 1000
+)
+
+type CreditsHandler struct {
+	creditService *credits.Service
+}
+
+func NewCreditsHandler(creditService *credits.Service) *CreditsHandler {
+	return &CreditsHandler{
+		creditService: creditService,
+	}
+}
+
+// GetCredits godoc
+// @Summary     Get credit balance and ledger
+// @Description Returns the authenticated user's credit balance, monthly budget and credits spent this calendar month (UTC),
+// @Description with the latest ledger entries. Every unwatermarked download from AutoEnhance spends a credit; downloading
+// @Description the same image, quality and format again never spends another. A budget of 0 means none.
+// @Description Balances and budgets are only enforced when CREDITS_ENABLED is set (see "enforced").
+// @Tags        usage
+// @Accept      json
+// @Produce     json
+// @Security    Bearer
+// @Param       limit query int false "Maximum number of ledger entries (1-1000)" default(50)
+// @Success     200 {object} models.CreditsResponse
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     401 {object} models.ErrorResponse
+// @Failure     500 {object} models.ErrorResponse
+// @Router      /me/credits [get]
+func (h *CreditsHandler) GetCredits(c *gin.Context) {
+	if h.creditService == nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "database not available"})
+		return
+	}
+
+	userIDStr, exists := c.Get(middleware.UserIDKey)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{Error: "user id not found"})
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid user id"})
+		return
+	}
+
+	respondCredits(c, h.creditService, userID)
+}
+
+// respondCredits writes the user's CreditsResponse, reading ?limit ledger entries
+func respondCredits(c *gin.Context, creditService *credits.Service, userID uuid.UUID) bool {
+	limit := defaultCreditLedgerLimit
+	if value := c.Query("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > maxCreditLedgerLimit {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid query", Message: "limit must be between 1 and 1000"})
+			return false
+		}
+		limit = n
+	}
+
+	account, err := creditService.Account(userID)
+	if err == nil {
+		var entries []models.CreditLedgerEntry
+		if entries, err = creditService.Ledger(userID, limit); err == nil {
+			c.JSON(http.StatusOK, creditsResponse(creditService, account, entries))
+			return true
+		}
+	}
+	c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+		Error:   "failed to get credits",
+		Message: err.Error(),
+	})
+	return false
+}
+
+func creditsResponse(creditService *credits.Service, account *credits.Account, entries []models.CreditLedgerEntry) models.CreditsResponse {
+	response := models.CreditsResponse{
+		Balance:       account.Balance,
+		Enforced:      creditService.Enabled(),
+		PeriodStart:   creditService.PeriodStart(),
+		MonthlyBudget: models.UsageCounter{Used: int64(account.SpentThisMonth), Limit: int64(creditService.Budget(*account))},
+		Ledger:        make([]models.CreditEntryResponse, 0, len(entries)),
+	}
+	for _, e := range entries {
+		entry := models.CreditEntryResponse{
+			ID:        e.ID.String(),
+			Kind:      e.Kind,
+			Amount:    e.Amount,
+			ImageID:   e.ImageID.String,
+			Quality:   e.Quality.String,
+			Format:    e.Format.String,
+			Reason:    e.Reason.String,
+			CreatedAt: e.CreatedAt,
+		}
+		if e.OrderID.Valid {
+			entry.OrderID = e.OrderID.UUID.String()
+		}
+		if e.ActorID.Valid {
+			entry.ActorID = e.ActorID.UUID.String()
+		}
+		if e.RefundedAt.Valid {
+			refundedAt := e.RefundedAt.Time
+			entry.RefundedAt = &refundedAt
+		}
+		response.Ledger = append(response.Ledger, entry)
+	}
+	return response
+}
+
+// respondCreditsError writes a credits_exceeded response for an *credits.ExceededError and reports whether it did
+func respondCreditsError(c *gin.Context, err error) bool {
+	var exceeded *credits.ExceededError
+	if !errors.As(err, &exceeded) {
+		return false
+	}
+	c.JSON(exceeded.StatusCode(), models.CreditsExceededResponse{
+		Error:   "credits_exceeded",
+		Message: exceeded.Error(),
+		Limit:   exceeded.Limit,
+		Balance: exceeded.Balance,
+		Used:    exceeded.Used,
+		Max:     exceeded.Max,
+	})
+	return true
+}
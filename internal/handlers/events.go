@@ -0,0 +1,309 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"instant-hdr-backend/internal/events"
+	"instant-hdr-backend/internal/middleware"
+	"instant-hdr-backend/internal/models"
+	"instant-hdr-backend/internal/repository"
+)
+
+const (
+	sseRetry                   = 3000 // How long browsers wait before reconnecting a dropped stream, in milliseconds
+	defaultHeartbeat           = 15 * time.Second
+	defaultOrderEventsPageSize = 100
+	maxOrderEventsPageSize     = 1000
+)
+
+type EventsHandler struct {
+	dbClient  repository.Repository
+	eventBus  *events.Bus
+	heartbeat time.Duration
+}
+
+func NewEventsHandler(dbClient repository.Repository, eventBus *events.Bus, heartbeat time.Duration) *EventsHandler {
+	if heartbeat <= 0 {
+		heartbeat = defaultHeartbeat
+	}
+	return &EventsHandler{
+		dbClient:  dbClient,
+		eventBus:  eventBus,
+		heartbeat: heartbeat,
+	}
+}
+
+// StreamOrderEvents godoc
+// @Summary     Stream or list order events
+// @Description Streams the order's realtime events as Server-Sent Events when the request accepts text/event-stream (EventSource
+// @Description does), with the same event names and payloads as the Supabase channel order:{order_id}: upload_started, upload_completed,
+// @Description processing_started, webhook_image_processed, download_ready, processing_failed and order_reset. A comment line is sent as a heartbeat
+// @Description while nothing happens.
+// @Description
+// @Description Events are numbered 1, 2, 3, ... per order and kept with the order; the number is the SSE id, and `seq` in broadcast
+// @Description payloads. Reconnect with the Last-Event-ID header (EventSource does this), ?since= or ?last_event_id= to receive the
+// @Description events after it first. Other requests get the events after ?since= as JSON, a page at a time: use this to catch up when
+// @Description a broadcast `seq` skips a number.
+// @Tags        status
+// @Produce     text/event-stream
+// @Produce     json
+// @Security    Bearer
+// @Param       order_id      path   string true  "Order ID (UUID)"
+// @Param       since         query  int    false "Last event seq received (0 for all)"
+// @Param       limit         query  int    false "Maximum number of events in a JSON page (1-1000)" default(100)
+// @Param       Last-Event-ID header string false "Same as since"
+// @Param       last_event_id query  string false "Same as since"
+// @Success     200 {object} models.OrderEventsResponse
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     401 {object} models.ErrorResponse
+// @Failure     404 {object} models.ErrorResponse
+// @Failure     500 {object} models.ErrorResponse
+// @Router      /orders/{order_id}/events [get]
+func (h *EventsHandler) StreamOrderEvents(c *gin.Context) {
+	userID, ok := h.userID(c)
+	if !ok {
+		return
+	}
+
+	orderID, err := uuid.Parse(c.Param("order_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid order id"})
+		return
+	}
+	order, err := h.dbClient.GetOrder(orderID, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "order not found",
+			Message: err.Error(),
+		})
+		return
+	}
+	if !authorizeOrder(c, h.dbClient, order, userID, models.RoleViewer) {
+		return
+	}
+
+	since, ok := lastEventID(c)
+	if !ok {
+		return
+	}
+	if !strings.Contains(c.GetHeader("Accept"), "text/event-stream") {
+		h.listOrderEvents(c, order.ID, since)
+		return
+	}
+	if h.eventBus == nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "events not available"})
+		return
+	}
+
+	// Subscribe before reading the outbox so nothing stored in between is missed; the stream skips what it already sent
+	sub, _, _ := h.eventBus.Subscribe(events.OrderChannel(order.ID), 0)
+	defer sub.Close()
+
+	startStream(c)
+	last := since
+	for {
+		stored, err := h.dbClient.ListOrderEvents(order.ID, int64(last), maxOrderEventsPageSize)
+		if err != nil {
+			// Live events still come; the client reloads the order to cover the gap
+			log.Printf("[Events] Failed to list events of order %s: %v", order.ID, err)
+			writeResync(c, since)
+			break
+		}
+		for _, e := range stored {
+			writeSSE(c, events.FromOrderEvent(e))
+			last = uint64(e.Seq)
+		}
+		if len(stored) < maxOrderEventsPageSize {
+			break
+		}
+	}
+	h.follow(c, sub, last)
+}
+
+// listOrderEvents writes a JSON page of the order's events after since
+func (h *EventsHandler) listOrderEvents(c *gin.Context, orderID uuid.UUID, since uint64) {
+	limit := defaultOrderEventsPageSize
+	if value := c.Query("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > maxOrderEventsPageSize {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "invalid query",
+				Message: fmt.Sprintf("limit must be between 1 and %d", maxOrderEventsPageSize),
+			})
+			return
+		}
+		limit = n
+	}
+
+	// One more than asked tells whether there are more
+	stored, err := h.dbClient.ListOrderEvents(orderID, int64(since), limit+1)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "failed to list order events",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	response := models.OrderEventsResponse{
+		OrderID: orderID.String(),
+		Events:  make([]models.OrderEventResponse, 0, len(stored)),
+		HasMore: len(stored) > limit,
+	}
+	for i, e := range stored {
+		if i == limit {
+			break
+		}
+		response.Events = append(response.Events, models.OrderEventResponse{
+			Seq:       e.Seq,
+			Event:     e.Event,
+			Payload:   events.WithSeq(e),
+			CreatedAt: e.CreatedAt,
+		})
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// StreamUserEvents godoc
+// @Summary     Stream your events
+// @Description Streams the events of the Supabase channel user:{user_id}, which mirrors the events of all your orders, as
+// @Description Server-Sent Events with heartbeats as for GET /orders/{order_id}/events. Payloads carry order_id and the order's seq. Reconnect with Last-Event-ID or ?last_event_id= to receive the events you missed.
+// @Description If some of them are no longer kept, a `resync` event comes first: reload what you show.
+// @Tags        status
+// @Produce     text/event-stream
+// @Security    Bearer
+// @Param       Last-Event-ID header string false "ID of the last event received"
+// @Param       last_event_id query  string false "Same as Last-Event-ID, for clients that can't set headers"
+// @Success     200 {string} string "text/event-stream"
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     401 {object} models.ErrorResponse
+// @Failure     500 {object} models.ErrorResponse
+// @Router      /me/events [get]
+func (h *EventsHandler) StreamUserEvents(c *gin.Context) {
+	userID, ok := h.userID(c)
+	if !ok {
+		return
+	}
+	h.stream(c, events.UserChannel(userID))
+}
+
+// stream writes the channel's events until the client goes away. Events missed since Last-Event-ID are sent first.
+func (h *EventsHandler) stream(c *gin.Context, channel string) {
+	if h.eventBus == nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "events not available"})
+		return
+	}
+
+	since, ok := lastEventID(c)
+	if !ok {
+		return
+	}
+
+	sub, missed, complete := h.eventBus.Subscribe(channel, since)
+	defer sub.Close()
+
+	startStream(c)
+	if !complete {
+		writeResync(c, since)
+	}
+	last := since
+	for _, e := range missed {
+		writeSSE(c, e)
+		last = e.ID
+	}
+	h.follow(c, sub, last)
+}
+
+// follow writes the subscription's events after last, and heartbeats in between, until the client goes away
+func (h *EventsHandler) follow(c *gin.Context, sub *events.Subscription, last uint64) {
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(h.heartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case e, ok := <-sub.Events():
+			if !ok {
+				// Fell behind or the bus missed events; the client reconnects and resumes from its last event
+				return
+			}
+			if e.ID <= last {
+				continue
+			}
+			writeSSE(c, e)
+			last = e.ID
+		case <-heartbeat.C:
+			fmt.Fprint(c.Writer, ": heartbeat\n\n")
+		}
+		c.Writer.Flush()
+	}
+}
+
+// lastEventID reads the ID to resume after from Last-Event-ID, ?since= or ?last_event_id=, writing a 400 response
+// when it is invalid. 0 means from the start.
+func lastEventID(c *gin.Context) (uint64, bool) {
+	value := c.GetHeader("Last-Event-ID")
+	if value == "" {
+		value = c.Query("since")
+	}
+	if value == "" {
+		value = c.Query("last_event_id")
+	}
+	if value == "" {
+		return 0, true
+	}
+	id, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid last event id"})
+		return 0, false
+	}
+	return id, true
+}
+
+func startStream(c *gin.Context) {
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // Keep nginx from buffering the stream
+	c.Status(http.StatusOK)
+
+	fmt.Fprintf(c.Writer, "retry: %d\n\n", sseRetry)
+}
+
+// writeResync asks the client to reload the state it follows. It has no ID: the client keeps resuming from its last real event.
+func writeResync(c *gin.Context, since uint64) {
+	fmt.Fprintf(c.Writer, "event: resync\ndata: {\"reason\":\"events since %d are no longer available\"}\n\n", since)
+}
+
+func writeSSE(c *gin.Context, e events.Event) {
+	fmt.Fprintf(c.Writer, "id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.Name, e.Data)
+}
+
+func (h *EventsHandler) userID(c *gin.Context) (uuid.UUID, bool) {
+	if h.dbClient == nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "database not available"})
+		return uuid.Nil, false
+	}
+
+	userIDStr, exists := c.Get(middleware.UserIDKey)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{Error: "user id not found"})
+		return uuid.Nil, false
+	}
+
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid user id"})
+		return uuid.Nil, false
+	}
+	return userID, true
+}
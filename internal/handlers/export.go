@@ -0,0 +1,349 @@
+package handlers
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"instant-hdr-backend/internal/autoenhance"
+	"instant-hdr-backend/internal/imaging"
+	"instant-hdr-backend/internal/middleware"
+	"instant-hdr-backend/internal/models"
+	"instant-hdr-backend/internal/repository"
+	"instant-hdr-backend/internal/services"
+	"instant-hdr-backend/internal/storage"
+)
+
+// DefaultExportNameTemplate names ZIP entries after the AutoEnhance image name
+const DefaultExportNameTemplate = "{image_name}"
+
+// errExportAborted marks a failure that leaves the archive unusable: an entry can't be taken back once started
+var errExportAborted = errors.New("export aborted")
+
+type ExportHandler struct {
+	autoenhanceClient *autoenhance.Client
+	dbClient          repository.Repository
+	storageClient     storage.Storage
+	imageService      *services.ImageService
+}
+
+func NewExportHandler(autoenhanceClient *autoenhance.Client, dbClient repository.Repository, storageClient storage.Storage, imageService *services.ImageService) *ExportHandler {
+	return &ExportHandler{
+		autoenhanceClient: autoenhanceClient,
+		dbClient:          dbClient,
+		storageClient:     storageClient,
+		imageService:      imageService,
+	}
+}
+
+// ExportOrder godoc
+// @Summary     Export order images as ZIP
+// @Description Streams a ZIP archive of every completed image in the order, plus a manifest.json with processing settings.
+// @Description
+// @Description Stored variants are reused; missing ones are generated or fetched from AutoEnhance on the fly.
+// @Description Images that fail are left out of the archive and reported in the manifest with their error.
+// @Description If the client goes away or an image can't be written in full, the export stops and the archive is left
+// @Description without its central directory (and manifest), so it won't open rather than hold a truncated file.
+// @Description
+// @Description Name template placeholders: {image_name}, {image_id}, {index}, {quality}, {order_name},
+// @Description {property_address}, {mls_number}, {client_name} (empty when the order has no property). The file extension is added automatically.
+// @Description
+// @Description Watermark (defaults to true = FREE): exporting unwatermarked images costs 1 credit per image not downloaded unwatermarked before.
+// @Description Images the user can't pay for (CREDITS_ENABLED) are reported in the manifest with a credits error.
+// @Tags        images
+// @Produce     application/zip
+// @Security    Bearer
+// @Param       order_id  path  string true  "Order ID (UUID)"
+// @Param       quality   query string false "Quality: thumbnail, preview, medium, high" default(high)
+// @Param       format    query string false "Format: jpeg, png, webp" default(jpeg)
+// @Param       watermark query bool   false "Include watermark" default(true)
+// @Param       name      query string false "Entry name template" default({image_name})
+// @Success     200 {file} binary
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     401 {object} models.ErrorResponse
+// @Failure     403 {object} models.ErrorResponse
+// @Failure     404 {object} models.ErrorResponse
+// @Failure     500 {object} models.ErrorResponse
+// @Router      /orders/{order_id}/export.zip [get]
+func (h *ExportHandler) ExportOrder(c *gin.Context) {
+	if h.dbClient == nil || h.imageService == nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "storage not available"})
+		return
+	}
+
+	userIDStr, exists := c.Get(middleware.UserIDKey)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{Error: "user id not found"})
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid user id"})
+		return
+	}
+
+	userToken, _ := c.Get(middleware.UserTokenKey)
+	userTokenStr, _ := userToken.(string)
+
+	orderIDStr := c.Param("order_id")
+	orderID, err := uuid.Parse(orderIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid order id"})
+		return
+	}
+
+	quality := c.DefaultQuery("quality", "high")
+	if _, ok := imaging.VariantByName(quality); !ok {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "quality must be one of: thumbnail, preview, medium, high",
+		})
+		return
+	}
+
+	format := c.DefaultQuery("format", "jpeg")
+	if format != imaging.FormatJPEG && format != imaging.FormatPNG && format != imaging.FormatWebP {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "format must be one of: jpeg, png, webp"})
+		return
+	}
+
+	watermark := c.DefaultQuery("watermark", "true") != "false"
+	nameTemplate := c.DefaultQuery("name", DefaultExportNameTemplate)
+
+	// Verify the user can see the order. Unwatermarked exports spend credits, which takes the editor role.
+	order, err := h.dbClient.GetOrder(orderID, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "order not found",
+			Message: err.Error(),
+		})
+		return
+	}
+	if !watermark && !authorizeOrder(c, h.dbClient, order, userID, models.RoleEditor) {
+		return
+	}
+
+	// The property belongs to whoever linked it, which for organization orders may be another member
+	var property *models.Property
+	if order.PropertyID.Valid {
+		property, _ = h.dbClient.GetProperty(order.PropertyID.UUID, userID)
+	}
+
+	autoenhanceOrder, err := h.autoenhanceClient.GetOrder(order.ID.String())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "failed to get order from AutoEnhance",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	archiveName := sanitizeFilename(order.Name.String)
+	if archiveName == "" {
+		archiveName = "order-" + order.ID.String()[:8]
+	}
+
+	// Headers go out before the first entry - from here on failures are reported in the manifest
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{
+		"filename": archiveName + ".zip",
+	}))
+	c.Header("Cache-Control", "no-store")
+	c.Status(http.StatusOK)
+
+	zw := zip.NewWriter(c.Writer)
+
+	manifest := models.ExportManifest{
+		OrderID:      order.ID.String(),
+		OrderName:    order.Name.String,
+		ExportedAt:   time.Now().UTC(),
+		Quality:      quality,
+		Format:       format,
+		Watermark:    watermark,
+		NameTemplate: nameTemplate,
+		Images:       make([]models.ExportManifestEntry, 0, len(autoenhanceOrder.Images)),
+	}
+	if property != nil {
+		response := propertyResponse(*property)
+		manifest.Property = &response
+	}
+	usedNames := make(map[string]bool)
+
+	// Set when the export stops early; no further image is fetched (nor paid for)
+	var aborted error
+	for i, img := range autoenhanceOrder.Images {
+		if err := c.Request.Context().Err(); err != nil {
+			aborted = fmt.Errorf("%w: %v", errExportAborted, err)
+			break
+		}
+
+		entry := models.ExportManifestEntry{
+			ImageID:            img.ImageID,
+			ImageName:          img.ImageName,
+			Status:             img.Status,
+			ProcessingSettings: processingSettings(img),
+		}
+
+		if img.Status != "completed" || img.StatusReason != "" {
+			entry.Error = "image is not processed"
+			if img.StatusReason != "" {
+				entry.Error += ": " + img.StatusReason
+			}
+			manifest.Images = append(manifest.Images, entry)
+			continue
+		}
+
+		result, err := h.imageService.EnsureVariant(services.VariantRequest{
+			Order:     order,
+			ImageID:   img.ImageID,
+			Quality:   quality,
+			Format:    format,
+			Watermark: watermark,
+			UserID:    userID,
+			UserToken: userTokenStr,
+		})
+		if err != nil {
+			entry.Error = err.Error()
+			manifest.Images = append(manifest.Images, entry)
+			continue
+		}
+		entry.Source = result.Source
+		entry.CreditUsed = result.CreditUsed
+
+		entry.Filename = uniqueEntryName(exportEntryName(nameTemplate, order, property, img, i+1, quality)+imaging.Extension(format), usedNames)
+		size, err := h.writeEntry(zw, entry.Filename, result.File.StoragePath)
+		if errors.Is(err, errExportAborted) {
+			entry.Error = err.Error()
+			manifest.Images = append(manifest.Images, entry)
+			aborted = err
+			break
+		}
+		if err != nil {
+			entry.Error = err.Error()
+		} else {
+			entry.FileSize = size
+		}
+		manifest.Images = append(manifest.Images, entry)
+	}
+
+	if aborted != nil {
+		// The zip writer is not closed: without a central directory the archive is rejected as a whole
+		log.Printf("[Export] Stopped exporting order %s after %d of %d images: %v", order.ID, len(manifest.Images), len(autoenhanceOrder.Images), aborted)
+	} else {
+		manifestJSON, _ := json.MarshalIndent(manifest, "", "  ")
+		if w, err := zw.Create("manifest.json"); err == nil {
+			w.Write(manifestJSON)
+		}
+		zw.Close()
+	}
+
+	// Credits are listed per image, so a disputed charge can be matched to what was exported
+	exported, credited := 0, []string{}
+	for _, entry := range manifest.Images {
+		if entry.Error == "" {
+			exported++
+		}
+		if entry.CreditUsed {
+			credited = append(credited, entry.ImageID)
+		}
+	}
+	details := gin.H{
+		"quality": quality, "format": format, "watermark": watermark, "images": len(manifest.Images),
+		"exported": exported, "credits_used": len(credited), "credit_image_ids": credited,
+	}
+	if aborted != nil {
+		details["aborted"] = aborted.Error()
+	}
+	recordAudit(c, h.dbClient, orderAudit(models.AuditOrderExport, order), details)
+}
+
+// writeEntry streams a stored file into the archive. Images are already compressed, so entries are stored as-is.
+// Once the entry is started any failure is an errExportAborted: a zip entry can't be removed, and a client that
+// stopped reading can't be written to anyway.
+func (h *ExportHandler) writeEntry(zw *zip.Writer, name, storagePath string) (int64, error) {
+	body, obj, err := h.storageClient.Download(storagePath)
+	if err != nil {
+		return 0, err
+	}
+	defer body.Close()
+
+	header := &zip.FileHeader{
+		Name:     name,
+		Method:   zip.Store,
+		Modified: obj.LastModified,
+	}
+	if header.Modified.IsZero() {
+		header.Modified = time.Now()
+	}
+
+	w, err := zw.CreateHeader(header)
+	if err != nil {
+		return 0, fmt.Errorf("%w: failed to create zip entry: %v", errExportAborted, err)
+	}
+	size, err := io.Copy(w, body)
+	if err != nil {
+		return size, fmt.Errorf("%w: failed to write zip entry: %v", errExportAborted, err)
+	}
+	return size, nil
+}
+
+// exportEntryName expands the name template for an image (without extension)
+// property may be nil, in which case the property placeholders expand to nothing
+func exportEntryName(template string, order *models.Order, property *models.Property, img autoenhance.ImageOut, index int, quality string) string {
+	imageName := img.ImageName
+	if imageName == "" {
+		imageName = img.ImageID
+	}
+	// Drop the original extension - the export format decides it
+	if dot := strings.LastIndex(imageName, "."); dot > 0 {
+		imageName = imageName[:dot]
+	}
+
+	var propertyAddress, mlsNumber, clientName string
+	if property != nil {
+		propertyAddress = property.Address
+		mlsNumber = property.MLSNumber.String
+		clientName = property.ClientName.String
+	}
+
+	name := strings.NewReplacer(
+		"{image_name}", imageName,
+		"{image_id}", img.ImageID,
+		"{index}", fmt.Sprintf("%03d", index),
+		"{quality}", quality,
+		"{order_name}", order.Name.String,
+		"{property_address}", propertyAddress,
+		"{mls_number}", mlsNumber,
+		"{client_name}", clientName,
+	).Replace(template)
+
+	name = strings.Trim(sanitizeFilename(name), ".")
+	if name == "" {
+		name = img.ImageID
+	}
+	return name
+}
+
+// uniqueEntryName appends -2, -3, ... when several images map to the same entry name
+func uniqueEntryName(name string, used map[string]bool) string {
+	candidate := name
+	ext := ""
+	if dot := strings.LastIndex(name, "."); dot > 0 {
+		name, ext = name[:dot], name[dot:]
+	}
+	for n := 2; used[candidate]; n++ {
+		candidate = name + "-" + strconv.Itoa(n) + ext
+	}
+	used[candidate] = true
+	return candidate
+}
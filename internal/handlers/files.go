@@ -9,18 +9,21 @@ import (
 	"instant-hdr-backend/internal/autoenhance"
 	"instant-hdr-backend/internal/middleware"
 	"instant-hdr-backend/internal/models"
-	"instant-hdr-backend/internal/supabase"
+	"instant-hdr-backend/internal/repository"
+	"instant-hdr-backend/internal/storage"
 )
 
 type FilesHandler struct {
-	dbClient          *supabase.DatabaseClient
+	dbClient          repository.Repository
 	autoenhanceClient *autoenhance.Client
+	urlResolver       *storage.URLResolver
 }
 
-func NewFilesHandler(dbClient *supabase.DatabaseClient, autoenhanceClient *autoenhance.Client) *FilesHandler {
+func NewFilesHandler(dbClient repository.Repository, autoenhanceClient *autoenhance.Client, urlResolver *storage.URLResolver) *FilesHandler {
 	return &FilesHandler{
 		dbClient:          dbClient,
 		autoenhanceClient: autoenhanceClient,
+		urlResolver:       urlResolver,
 	}
 }
 
@@ -35,6 +38,7 @@ func NewFilesHandler(dbClient *supabase.DatabaseClient, autoenhanceClient *autoe
 // @Success     200 {object} models.FilesResponse
 // @Failure     400 {object} models.ErrorResponse
 // @Failure     401 {object} models.ErrorResponse
+// @Failure     404 {object} models.ErrorResponse
 // @Failure     500 {object} models.ErrorResponse
 // @Router      /orders/{order_id}/files [get]
 func (h *FilesHandler) GetFiles(c *gin.Context) {
@@ -62,8 +66,18 @@ func (h *FilesHandler) GetFiles(c *gin.Context) {
 		return
 	}
 
-	// Get processed files (final images) only
-	files, err := h.dbClient.GetOrderFiles(orderID, userID)
+	// Verify the user can see the order (own or organization order)
+	order, err := h.dbClient.GetOrder(orderID, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "order not found",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	// Get processed files (final images) only. Files are stored under the order's creator.
+	files, err := h.dbClient.GetOrderFiles(orderID, order.UserID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Error:   "failed to get files",
@@ -78,20 +92,109 @@ func (h *FilesHandler) GetFiles(c *gin.Context) {
 		if file.FileSize.Valid {
 			fileSize = file.FileSize.Int64
 		}
+
+		// Signed URL when the bucket is private, stored public URL otherwise
+		fileURL, expiresAt, err := h.urlResolver.Resolve(file.StoragePath, file.StorageURL)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error:   "failed to create file url",
+				Message: err.Error(),
+			})
+			return
+		}
+
 		fileResponses[i] = models.FileResponse{
-			ID:         file.ID.String(),
-			Filename:   file.Filename,
-			StorageURL: file.StorageURL,
-			FileSize:   fileSize,
-			MimeType:   file.MimeType,
-			IsFinal:    file.IsFinal,
-			CreatedAt:  file.CreatedAt,
+			ID:           file.ID.String(),
+			Filename:     file.Filename,
+			StorageURL:   fileURL,
+			URLExpiresAt: expiresAt,
+			FileSize:     fileSize,
+			MimeType:     file.MimeType,
+			IsFinal:      file.IsFinal,
+			CreatedAt:    file.CreatedAt,
 		}
 	}
 
 	c.JSON(http.StatusOK, models.FilesResponse{Files: fileResponses})
 }
 
+// RefreshFileURL godoc
+// @Summary     Refresh a file URL
+// @Description Returns a freshly signed URL for a stored file. Use this when a signed URL from a previous response has expired. For public buckets the permanent URL is returned.
+// @Tags        files
+// @Accept      json
+// @Produce     json
+// @Security    Bearer
+// @Param       order_id path string true "Order ID (UUID)"
+// @Param       file_id path string true "File ID (UUID)"
+// @Success     200 {object} models.FileURLResponse
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     401 {object} models.ErrorResponse
+// @Failure     404 {object} models.ErrorResponse
+// @Failure     500 {object} models.ErrorResponse
+// @Router      /orders/{order_id}/files/{file_id}/url [post]
+func (h *FilesHandler) RefreshFileURL(c *gin.Context) {
+	if h.dbClient == nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "database not available"})
+		return
+	}
+
+	userIDStr, exists := c.Get(middleware.UserIDKey)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{Error: "user id not found"})
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid user id"})
+		return
+	}
+
+	orderIDStr := c.Param("order_id")
+	orderID, err := uuid.Parse(orderIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid order id"})
+		return
+	}
+
+	fileID, err := uuid.Parse(c.Param("file_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid file id"})
+		return
+	}
+
+	order, err := h.dbClient.GetOrder(orderID, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "order not found",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	file, err := h.dbClient.GetOrderFile(fileID, order.UserID)
+	if err != nil || file.OrderID != orderID {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "file not found"})
+		return
+	}
+
+	fileURL, expiresAt, err := h.urlResolver.Resolve(file.StoragePath, file.StorageURL)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "failed to create file url",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.FileURLResponse{
+		FileID:    file.ID.String(),
+		URL:       fileURL,
+		ExpiresAt: expiresAt,
+	})
+}
+
 // GetBrackets godoc
 // @Summary     Get uploaded brackets
 // @Description Returns a list of all uploaded brackets (raw images) for an order
@@ -130,7 +233,7 @@ func (h *FilesHandler) GetBrackets(c *gin.Context) {
 		return
 	}
 
-	// Verify order belongs to user
+	// Verify the user can see the order
 	_, err = h.dbClient.GetOrder(orderID, userID)
 	if err != nil {
 		c.JSON(http.StatusNotFound, models.ErrorResponse{
@@ -231,6 +334,7 @@ func (h *FilesHandler) GetBrackets(c *gin.Context) {
 // @Success     200 {object} map[string]string "message"
 // @Failure     400 {object} models.ErrorResponse
 // @Failure     401 {object} models.ErrorResponse
+// @Failure     403 {object} models.ErrorResponse
 // @Failure     404 {object} models.ErrorResponse
 // @Failure     500 {object} models.ErrorResponse
 // @Router      /orders/{order_id}/brackets/{bracket_id} [delete]
@@ -265,8 +369,8 @@ func (h *FilesHandler) DeleteBracket(c *gin.Context) {
 		return
 	}
 
-	// Verify order belongs to user
-	_, err = h.dbClient.GetOrder(orderID, userID)
+	// Verify the user can edit the order
+	order, err := h.dbClient.GetOrder(orderID, userID)
 	if err != nil {
 		c.JSON(http.StatusNotFound, models.ErrorResponse{
 			Error:   "order not found",
@@ -274,6 +378,9 @@ func (h *FilesHandler) DeleteBracket(c *gin.Context) {
 		})
 		return
 	}
+	if !authorizeOrder(c, h.dbClient, order, userID, models.RoleEditor) {
+		return
+	}
 
 	// Delete from AutoEnhance AI
 	err = h.autoenhanceClient.RetryWithBackoff(func() error {
@@ -290,6 +397,7 @@ func (h *FilesHandler) DeleteBracket(c *gin.Context) {
 	// Note: We keep the bracket record in our database for record-keeping
 	// Only the AutoEnhance bracket is deleted
 
+	recordAudit(c, h.dbClient, orderAudit(models.AuditBracketDelete, order), gin.H{"bracket_id": bracketID})
 	c.JSON(http.StatusOK, gin.H{
 		"message":    "Bracket deleted successfully from AutoEnhance",
 		"bracket_id": bracketID,
@@ -1,40 +1,53 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
+	"mime"
 	"net/http"
 	"strings"
+	"time"
+	"unicode"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"instant-hdr-backend/internal/autoenhance"
+	"instant-hdr-backend/internal/imaging"
 	"instant-hdr-backend/internal/middleware"
 	"instant-hdr-backend/internal/models"
-	"instant-hdr-backend/internal/supabase"
+	"instant-hdr-backend/internal/quota"
+	"instant-hdr-backend/internal/repository"
+	"instant-hdr-backend/internal/services"
+	"instant-hdr-backend/internal/storage"
 )
 
 type ImagesHandler struct {
 	autoenhanceClient *autoenhance.Client
-	dbClient          *supabase.DatabaseClient
-	storageClient     *supabase.StorageClient
+	dbClient          repository.Repository
+	storageClient     storage.Storage
+	urlResolver       *storage.URLResolver
+	imageService      *services.ImageService
 }
 
-func NewImagesHandler(autoenhanceClient *autoenhance.Client, dbClient *supabase.DatabaseClient, storageClient *supabase.StorageClient) *ImagesHandler {
+func NewImagesHandler(autoenhanceClient *autoenhance.Client, dbClient repository.Repository, storageClient storage.Storage, urlResolver *storage.URLResolver, imageService *services.ImageService) *ImagesHandler {
 	return &ImagesHandler{
 		autoenhanceClient: autoenhanceClient,
 		dbClient:          dbClient,
 		storageClient:     storageClient,
+		urlResolver:       urlResolver,
+		imageService:      imageService,
 	}
 }
 
 // ListImages godoc
 // @Summary     List processed images
-// @Description Returns a list of all processed images for an order with their download status
+// @Description Returns a list of all processed images for an order with their download status and tags
 // @Tags        images
 // @Accept      json
 // @Produce     json
 // @Security    Bearer
-// @Param       order_id path string true "Order ID (UUID)"
+// @Param       order_id path  string true  "Order ID (UUID)"
+// @Param       tag      query string false "Comma-separated tags; only images carrying all of them"
 // @Success     200 {object} models.ImagesResponse
 // @Failure     400 {object} models.ErrorResponse
 // @Failure     401 {object} models.ErrorResponse
@@ -76,6 +89,22 @@ func (h *ImagesHandler) ListImages(c *gin.Context) {
 		return
 	}
 
+	var wantTags []string
+	if tags := c.Query("tag"); tags != "" {
+		if wantTags, err = normalizeTags(strings.Split(tags, ",")); err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid query", Message: "tag: " + err.Error()})
+			return
+		}
+	}
+	imageTags, err := h.dbClient.ListImageTags(orderID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "failed to load image tags",
+			Message: err.Error(),
+		})
+		return
+	}
+
 	// Get images from AutoEnhance
 	autoenhanceOrder, err := h.autoenhanceClient.GetOrder(order.ID.String())
 	if err != nil {
@@ -87,33 +116,50 @@ func (h *ImagesHandler) ListImages(c *gin.Context) {
 	}
 
 	// Get processed files from our database to check what's already downloaded
-	dbFiles, _ := h.dbClient.GetOrderFiles(orderID, userID)
+	dbFiles, _ := h.dbClient.GetOrderFiles(orderID, order.UserID)
 	
 	// Create a map of downloaded files for quick lookup
 	downloadedFiles := make(map[string]models.FileResponse)
 	for _, file := range dbFiles {
 		// Key format: imageID_quality (e.g., "img123_preview" or "img123_high")
 		key := extractImageIDFromFilename(file.Filename)
+		if file.Variant.Valid && file.AutoEnhanceImageID.Valid {
+			key = fmt.Sprintf("%s_%s", file.AutoEnhanceImageID.String, file.Variant.String)
+		}
+		if _, exists := downloadedFiles[key]; exists {
+			// Files are newest first - keep the latest variant
+			continue
+		}
+		fileURL, expiresAt, err := h.urlResolver.Resolve(file.StoragePath, file.StorageURL)
+		if err != nil {
+			continue
+		}
 		downloadedFiles[key] = models.FileResponse{
-			ID:         file.ID.String(),
-			Filename:   file.Filename,
-			StorageURL: file.StorageURL,
-			FileSize:   file.FileSize.Int64,
-			MimeType:   file.MimeType,
-			IsFinal:    file.IsFinal,
-			CreatedAt:  file.CreatedAt,
+			ID:           file.ID.String(),
+			Filename:     file.Filename,
+			StorageURL:   fileURL,
+			URLExpiresAt: expiresAt,
+			FileSize:     file.FileSize.Int64,
+			MimeType:     file.MimeType,
+			IsFinal:      file.IsFinal,
+			CreatedAt:    file.CreatedAt,
 		}
 	}
 
 	// Build response with images from AutoEnhance
 	imageResponses := make([]models.ImageResponse, 0, len(autoenhanceOrder.Images))
 	for _, img := range autoenhanceOrder.Images {
+		tags := imageTags[img.ImageID]
+		if !containsAllTags(tags, wantTags) {
+			continue
+		}
 		imageResp := models.ImageResponse{
 			ImageID:     img.ImageID,
 			ImageName:   img.ImageName,
 			Status:      img.Status,
 			EnhanceType: img.EnhanceType,
 			Downloaded:  img.Downloaded,
+			Tags:        nonNilTags(tags),
 		}
 
 		// Check if preview is downloaded
@@ -121,6 +167,7 @@ func (h *ImagesHandler) ListImages(c *gin.Context) {
 		if previewFile, exists := downloadedFiles[previewKey]; exists {
 			imageResp.PreviewDownloaded = true
 			imageResp.PreviewURL = previewFile.StorageURL
+			imageResp.URLsExpireAt = previewFile.URLExpiresAt
 		}
 
 		// Check if high-res is downloaded
@@ -128,26 +175,11 @@ func (h *ImagesHandler) ListImages(c *gin.Context) {
 		if highResFile, exists := downloadedFiles[highResKey]; exists {
 			imageResp.HighResDownloaded = true
 			imageResp.HighResURL = highResFile.StorageURL
+			imageResp.URLsExpireAt = highResFile.URLExpiresAt
 		}
 
 		// Add processing settings
-		settings := make(map[string]interface{})
-		if img.EnhanceType != "" {
-			settings["enhance_type"] = img.EnhanceType
-		}
-		if img.SkyReplacement {
-			settings["sky_replacement"] = img.SkyReplacement
-		}
-		if img.VerticalCorrection {
-			settings["vertical_correction"] = img.VerticalCorrection
-		}
-		if img.LensCorrection {
-			settings["lens_correction"] = img.LensCorrection
-		}
-		if img.WindowPullType != nil {
-			settings["window_pull_type"] = *img.WindowPullType
-		}
-		if len(settings) > 0 {
+		if settings := processingSettings(img); len(settings) > 0 {
 			imageResp.ProcessingSettings = settings
 		}
 
@@ -161,6 +193,11 @@ func (h *ImagesHandler) ListImages(c *gin.Context) {
 // @Summary     Download processed image to Supabase Storage
 // @Description Downloads a processed image from AutoEnhance and stores it in Supabase Storage.
 // @Description
+// @Description The full-resolution image is downloaded from AutoEnhance once (per watermark setting).
+// @Description Thumbnail, preview and medium sizes are generated from it locally, as are PNG/JPEG
+// @Description re-encodes, and every variant is cached - repeated requests never contact AutoEnhance.
+// @Description WebP variants are still downloaded from AutoEnhance.
+// @Description
 // @Description Quality Options:
 // @Description - "thumbnail": 400px width (~50-100KB) - List view
 // @Description - "preview": 800px width (~150-250KB) - Gallery view (DEFAULT)
@@ -172,7 +209,11 @@ func (h *ImagesHandler) ListImages(c *gin.Context) {
 // @Description
 // @Description Watermark (defaults to true = FREE):
 // @Description - true: FREE download with watermark
-// @Description - false: COSTS 1 CREDIT (unwatermarked)
+// @Description - false: COSTS 1 CREDIT (unwatermarked), spent before the image is fetched from AutoEnhance and
+// @Description   recorded in the credit ledger (GET /me/credits). The full-resolution image is paid once per image;
+// @Description   every JPEG/PNG size is generated from it for free. WebP sizes are paid once each. Downloading a
+// @Description   paid image again never spends another credit. With CREDITS_ENABLED, a download beyond the
+// @Description   balance or the monthly budget is refused with 402.
 // @Tags        images
 // @Accept      json
 // @Produce     json
@@ -183,11 +224,14 @@ func (h *ImagesHandler) ListImages(c *gin.Context) {
 // @Success     200 {object} models.DownloadImageResponse
 // @Failure     400 {object} models.ErrorResponse
 // @Failure     401 {object} models.ErrorResponse
+// @Failure     403 {object} models.ErrorResponse
+// @Failure     402 {object} models.CreditsExceededResponse
 // @Failure     404 {object} models.ErrorResponse
+// @Failure     413 {object} models.QuotaExceededResponse
 // @Failure     500 {object} models.ErrorResponse
 // @Router      /orders/{order_id}/images/{image_id}/download [post]
 func (h *ImagesHandler) DownloadImage(c *gin.Context) {
-	if h.dbClient == nil || h.storageClient == nil {
+	if h.dbClient == nil || h.imageService == nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "storage not available"})
 		return
 	}
@@ -261,7 +305,7 @@ func (h *ImagesHandler) DownloadImage(c *gin.Context) {
 		return
 	}
 
-	// Verify order belongs to user
+	// Verify the user can edit the order - downloads may spend credits
 	order, err := h.dbClient.GetOrder(orderID, userID)
 	if err != nil {
 		c.JSON(http.StatusNotFound, models.ErrorResponse{
@@ -270,14 +314,7 @@ func (h *ImagesHandler) DownloadImage(c *gin.Context) {
 		})
 		return
 	}
-
-	// Get image info from AutoEnhance to verify it exists
-	_, err = h.autoenhanceClient.GetImage(imageID)
-	if err != nil {
-		c.JSON(http.StatusNotFound, models.ErrorResponse{
-			Error:   "image not found",
-			Message: err.Error(),
-		})
+	if !authorizeOrder(c, h.dbClient, order, userID, models.RoleEditor) {
 		return
 	}
 
@@ -286,125 +323,375 @@ func (h *ImagesHandler) DownloadImage(c *gin.Context) {
 	if req.Watermark != nil {
 		watermark = *req.Watermark
 	}
-	
-	// Set download options based on quality
-	options := autoenhance.DownloadOptions{
-		Format:    "jpeg", // Default format
-		Watermark: &watermark, // Defaults to true (FREE), but can be overridden
-	}
 
-	// Allow custom format if specified
+	// Default format is jpeg; unknown formats fall back to it
+	format := "jpeg"
 	if req.Format != "" {
 		validFormats := map[string]bool{"jpeg": true, "png": true, "webp": true}
 		if validFormats[req.Format] {
-			options.Format = req.Format
+			format = req.Format
 		}
 	}
 
 	var resolution string
 
-	// Map quality presets to settings
+	// Map quality presets to resolutions
 	switch req.Quality {
 	case "thumbnail":
-		maxWidth := 400
-		options.MaxWidth = &maxWidth
 		resolution = "400px"
-
 	case "preview":
-		maxWidth := 800
-		options.MaxWidth = &maxWidth
 		resolution = "800px"
-
 	case "medium":
-		maxWidth := 1920
-		options.MaxWidth = &maxWidth
 		resolution = "1920px"
-
 	case "high":
 		// Full resolution - no maxWidth limit
 		resolution = "full"
-
 	case "custom":
 		if req.MaxWidth != nil {
-			options.MaxWidth = req.MaxWidth
 			resolution = fmt.Sprintf("%dpx", *req.MaxWidth)
 		}
 		if req.Scale != nil {
-			options.Scale = req.Scale
 			resolution = fmt.Sprintf("%.0f%%", *req.Scale*100)
 		}
 	}
 
-	// Download from AutoEnhance
-	var imageData []byte
-	err = h.autoenhanceClient.RetryWithBackoff(func() error {
-		data, err := h.autoenhanceClient.DownloadEnhanced(imageID, options)
-		if err != nil {
-			return err
-		}
-		imageData = data
-		return nil
-	}, 3)
+	// Smaller sizes are generated locally from a single high-res download and cached
+	result, err := h.imageService.EnsureVariant(services.VariantRequest{
+		Order:     order,
+		ImageID:   imageID,
+		Quality:   req.Quality,
+		MaxWidth:  req.MaxWidth,
+		Scale:     req.Scale,
+		Format:    format,
+		Watermark: watermark,
+		UserID:    userID,
+		UserToken: userTokenStr,
+	})
 	if err != nil {
+		if respondCreditsError(c, err) {
+			return
+		}
+		if errors.Is(err, services.ErrImageNotFound) {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{
+				Error:   "image not found",
+				Message: err.Error(),
+			})
+			return
+		}
+		var exceeded *quota.ExceededError
+		if errors.As(err, &exceeded) {
+			respondQuotaError(c, err)
+			return
+		}
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Error:   "failed to download image from AutoEnhance",
+			Error:   "failed to download image",
 			Message: err.Error(),
 		})
 		return
 	}
 
-	// Generate filename: {image_id}_{quality}.jpg
-	filename := fmt.Sprintf("%s_%s.jpg", imageID, req.Quality)
+	// Recorded before the URL is signed: a used credit is spent even if the response fails
+	download := orderAudit(models.AuditImageDownload, order)
+	download.ImageID = imageID
+	recordAudit(c, h.dbClient, download, gin.H{
+		"quality": req.Quality, "resolution": resolution, "format": format, "watermark": watermark,
+		"credit_used": result.CreditUsed, "source": result.Source, "file_id": result.File.ID,
+	})
 
-	// Upload to Supabase Storage with user's JWT token for RLS
-	_, publicURL, err := h.storageClient.UploadFileWithToken(userID, orderID, filename, imageData, userTokenStr)
+	fileURL, expiresAt, err := h.urlResolver.Resolve(result.File.StoragePath, result.File.StorageURL)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Error:   "failed to upload to storage",
+			Error:   "failed to create file url",
 			Message: err.Error(),
 		})
 		return
 	}
 
-	// Store file record in database
-	orderFile := &models.OrderFile{
-		ID:         uuid.New(),
-		OrderID:    order.ID,
-		Filename:   filename,
-		StorageURL: publicURL,
-		MimeType:   "image/jpeg",
-		IsFinal:    true,
-	}
-
-	if err := h.dbClient.CreateOrderFile(orderFile); err != nil {
-		// Log error but don't fail - file is already in storage
-		// The file will still be accessible via the URL
-	}
-
-	// Determine if credit was used
-	creditUsed := !watermark
-
 	// Build appropriate message
 	var message string
-	if watermark {
+	switch {
+	case watermark:
 		message = fmt.Sprintf("Image downloaded successfully (FREE with watermark) - Quality: %s, Resolution: %s", req.Quality, resolution)
-	} else {
+	case result.CreditUsed:
 		message = fmt.Sprintf("Image downloaded successfully (1 CREDIT USED - unwatermarked) - Quality: %s, Resolution: %s", req.Quality, resolution)
+	default:
+		message = fmt.Sprintf("Image ready (no credit used - the unwatermarked image was already paid for) - Quality: %s, Resolution: %s", req.Quality, resolution)
 	}
 
 	c.JSON(http.StatusOK, models.DownloadImageResponse{
 		ImageID:    imageID,
 		Quality:    req.Quality,
-		URL:        publicURL,
-		FileSize:   int64(len(imageData)),
+		URL:        fileURL,
+		ExpiresAt:  expiresAt,
+		FileSize:   result.File.FileSize.Int64,
 		Watermark:  watermark,
 		Resolution: resolution,
-		Format:     options.Format,
-		CreditUsed: creditUsed,
+		Format:     format,
+		Width:      int(result.File.Width.Int64),
+		Height:     int(result.File.Height.Int64),
+		Source:     result.Source,
+		CreditUsed: result.CreditUsed,
 		Message:    message,
 	})
 }
 
+// GetImageContent godoc
+// @Summary     Stream image content
+// @Description Streams a stored image variant through the backend with auth checks and HTTP caching.
+// @Description
+// @Description Supports single-range `Range` requests (206 Partial Content) so large downloads can be resumed,
+// @Description `If-None-Match`/`If-Modified-Since` (304 Not Modified) and `If-Range`.
+// @Description
+// @Description Watermarked variants that are not stored yet are generated on demand (FREE).
+// @Description Unwatermarked variants are only served once the image was downloaded unwatermarked via the download endpoint.
+// @Tags        images
+// @Produce     image/jpeg
+// @Produce     image/png
+// @Produce     image/webp
+// @Security    Bearer
+// @Param       order_id  path   string true  "Order ID (UUID)"
+// @Param       image_id  path   string true  "Image ID from AutoEnhance"
+// @Param       variant   query  string false "Variant: thumbnail, preview, medium, high" default(preview)
+// @Param       format    query  string false "Format: jpeg, png, webp" default(jpeg)
+// @Param       watermark query  bool   false "Watermarked variant" default(true)
+// @Param       download  query  bool   false "Send as attachment instead of inline" default(false)
+// @Param       Range     header string false "Byte range, e.g. bytes=0-1023"
+// @Success     200 {file} binary
+// @Success     206 {file} binary
+// @Success     304 "Not Modified"
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     401 {object} models.ErrorResponse
+// @Failure     404 {object} models.ErrorResponse
+// @Failure     416 {object} models.ErrorResponse
+// @Failure     500 {object} models.ErrorResponse
+// @Router      /orders/{order_id}/images/{image_id}/content [get]
+func (h *ImagesHandler) GetImageContent(c *gin.Context) {
+	if h.dbClient == nil || h.storageClient == nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "storage not available"})
+		return
+	}
+
+	userIDStr, exists := c.Get(middleware.UserIDKey)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{Error: "user id not found"})
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid user id"})
+		return
+	}
+
+	orderIDStr := c.Param("order_id")
+	orderID, err := uuid.Parse(orderIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid order id"})
+		return
+	}
+
+	imageID := c.Param("image_id")
+	if imageID == "" {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "image_id is required"})
+		return
+	}
+
+	variant := c.DefaultQuery("variant", "preview")
+	if _, ok := imaging.VariantByName(variant); !ok {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "variant must be one of: thumbnail, preview, medium, high",
+		})
+		return
+	}
+
+	format := c.DefaultQuery("format", "jpeg")
+	if format != imaging.FormatJPEG && format != imaging.FormatPNG && format != imaging.FormatWebP {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "format must be one of: jpeg, png, webp"})
+		return
+	}
+
+	watermark := c.DefaultQuery("watermark", "true") != "false"
+
+	// Verify order belongs to user
+	order, err := h.dbClient.GetOrder(orderID, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "order not found",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	file, err := h.findOrGenerateVariant(c, order, imageID, variant, format, watermark)
+	if err != nil {
+		return // Response already written
+	}
+
+	obj, err := h.storageClient.Stat(file.StoragePath)
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "image content not found",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	// Variants are never modified in place, so the file ID is a stable fallback validator
+	etag := obj.ETag
+	if etag == "" {
+		etag = fmt.Sprintf(`"%s"`, file.ID)
+	}
+	lastModified := obj.LastModified
+	if lastModified.IsZero() {
+		lastModified = file.CreatedAt
+	}
+
+	dispositionType := "inline"
+	if c.Query("download") == "true" {
+		dispositionType = "attachment"
+	}
+
+	header := c.Writer.Header()
+	header.Set("ETag", etag)
+	header.Set("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	header.Set("Cache-Control", "private, max-age=3600")
+	header.Set("Accept-Ranges", "bytes")
+	header.Set("Content-Disposition", mime.FormatMediaType(dispositionType, map[string]string{
+		"filename": friendlyFilename(order, imageID, variant, format),
+	}))
+
+	if notModified(c.Request, etag, lastModified) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	// If-Range: only honour Range when the client's copy is still current
+	byteRange, err := storage.ParseRange(c.GetHeader("Range"), obj.Size)
+	if ifRange := c.GetHeader("If-Range"); ifRange != "" && ifRange != etag {
+		byteRange, err = nil, nil
+	}
+	if err != nil {
+		header.Set("Content-Range", fmt.Sprintf("bytes */%d", obj.Size))
+		c.JSON(http.StatusRequestedRangeNotSatisfiable, models.ErrorResponse{Error: "range not satisfiable"})
+		return
+	}
+
+	offset, length, status := int64(0), obj.Size, http.StatusOK
+	if byteRange != nil {
+		offset, length, status = byteRange.Offset, byteRange.Length, http.StatusPartialContent
+		header.Set("Content-Range", byteRange.ContentRange(obj.Size))
+	}
+
+	body, _, err := h.storageClient.DownloadRange(file.StoragePath, offset, length)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "failed to read image content",
+			Message: err.Error(),
+		})
+		return
+	}
+	defer body.Close()
+
+	// Stream straight from storage without buffering the image
+	c.DataFromReader(status, length, obj.ContentType, body, nil)
+}
+
+// findOrGenerateVariant returns the stored variant. Missing variants are generated when that is free:
+// watermarked variants always, unwatermarked ones only if the unwatermarked source is already stored.
+func (h *ImagesHandler) findOrGenerateVariant(c *gin.Context, order *models.Order, imageID, variant, format string, watermark bool) (*models.OrderFile, error) {
+	file, err := h.dbClient.GetOrderFileVariant(order.ID, imageID, variant, format, watermark)
+	if err == nil {
+		return file, nil
+	}
+
+	canGenerate := h.imageService != nil
+	if canGenerate && !watermark {
+		// Fetching an unwatermarked image from AutoEnhance costs a credit - only derive from a stored one
+		_, err := h.dbClient.GetOrderFileVariant(order.ID, imageID, imaging.VariantHigh.Name, imaging.FormatJPEG, false)
+		canGenerate = err == nil && imaging.CanEncode(format)
+	}
+	if !canGenerate {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "variant not available",
+			Message: "download the image first via POST /orders/{order_id}/images/{image_id}/download",
+		})
+		return nil, fmt.Errorf("variant not available")
+	}
+
+	userToken, _ := c.Get(middleware.UserTokenKey)
+	userTokenStr, _ := userToken.(string)
+
+	result, err := h.imageService.EnsureVariant(services.VariantRequest{
+		Order:     order,
+		ImageID:   imageID,
+		Quality:   variant,
+		Format:    format,
+		Watermark: watermark,
+		UserToken: userTokenStr,
+	})
+	if err != nil {
+		var exceeded *quota.ExceededError
+		if errors.As(err, &exceeded) {
+			respondQuotaError(c, err)
+			return nil, err
+		}
+		status := http.StatusInternalServerError
+		if errors.Is(err, services.ErrImageNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, models.ErrorResponse{
+			Error:   "failed to generate image variant",
+			Message: err.Error(),
+		})
+		return nil, err
+	}
+	return result.File, nil
+}
+
+// notModified evaluates If-None-Match (preferred) and If-Modified-Since
+func notModified(r *http.Request, etag string, lastModified time.Time) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		for _, candidate := range strings.Split(inm, ",") {
+			candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+			if candidate == "*" || candidate == strings.TrimPrefix(etag, "W/") {
+				return true
+			}
+		}
+		return false
+	}
+	if ims, err := http.ParseTime(r.Header.Get("If-Modified-Since")); err == nil {
+		return !lastModified.Truncate(time.Second).After(ims)
+	}
+	return false
+}
+
+// friendlyFilename builds a download name like "Sunset-Villa_abc12345_preview.jpg"
+func friendlyFilename(order *models.Order, imageID, variant, format string) string {
+	base := sanitizeFilename(order.Name.String)
+	if base == "" {
+		base = "order"
+	}
+
+	shortID := imageID
+	if len(shortID) > 8 {
+		shortID = shortID[:8]
+	}
+	return fmt.Sprintf("%s_%s_%s%s", base, shortID, variant, imaging.Extension(format))
+}
+
+// sanitizeFilename keeps letters, digits, '-', '_' and '.', and turns spaces into '-'
+func sanitizeFilename(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == ' ':
+			return '-'
+		case r == '-' || r == '_' || r == '.' || unicode.IsLetter(r) || unicode.IsDigit(r):
+			return r
+		}
+		return -1
+	}, name)
+}
+
 // DeleteImage godoc
 // @Summary     Delete a processed image
 // @Description Deletes a processed image from AutoEnhance AI and all associated files from Supabase Storage and database
@@ -417,6 +704,7 @@ func (h *ImagesHandler) DownloadImage(c *gin.Context) {
 // @Success     200 {object} map[string]string "message"
 // @Failure     400 {object} models.ErrorResponse
 // @Failure     401 {object} models.ErrorResponse
+// @Failure     403 {object} models.ErrorResponse
 // @Failure     404 {object} models.ErrorResponse
 // @Failure     500 {object} models.ErrorResponse
 // @Router      /orders/{order_id}/images/{image_id} [delete]
@@ -451,8 +739,8 @@ func (h *ImagesHandler) DeleteImage(c *gin.Context) {
 		return
 	}
 
-	// Verify order belongs to user
-	_, err = h.dbClient.GetOrder(orderID, userID)
+	// Verify the user can edit the order
+	order, err := h.dbClient.GetOrder(orderID, userID)
 	if err != nil {
 		c.JSON(http.StatusNotFound, models.ErrorResponse{
 			Error:   "order not found",
@@ -460,6 +748,9 @@ func (h *ImagesHandler) DeleteImage(c *gin.Context) {
 		})
 		return
 	}
+	if !authorizeOrder(c, h.dbClient, order, userID, models.RoleEditor) {
+		return
+	}
 
 	// Delete from AutoEnhance AI (this is the main delete)
 	err = h.autoenhanceClient.RetryWithBackoff(func() error {
@@ -474,24 +765,28 @@ func (h *ImagesHandler) DeleteImage(c *gin.Context) {
 	}
 
 	// Get all files associated with this image from our database
-	dbFiles, _ := h.dbClient.GetOrderFiles(orderID, userID)
+	dbFiles, _ := h.dbClient.GetOrderFiles(orderID, order.UserID)
 	
-	// Delete all files from Supabase Storage that match this image_id
+	// Delete all stored files of this image. The row is kept if its object could not be deleted,
+	// so storage and order_files stay in sync.
 	deletedCount := 0
 	for _, file := range dbFiles {
-		// Check if this file belongs to the deleted image
-		if strings.Contains(file.Filename, imageID) {
-			// Delete from Supabase Storage
-			if file.StoragePath != "" {
-				_ = h.storageClient.DeleteFile(file.StoragePath)
+		if !fileBelongsToImage(file, imageID) {
+			continue
+		}
+		if file.StoragePath != "" {
+			if err := h.storageClient.DeleteFile(file.StoragePath); err != nil {
+				continue
 			}
-			
-			// Delete from database
-			_ = h.dbClient.DeleteOrderFile(file.ID)
+		}
+		if err := h.dbClient.DeleteOrderFile(file.ID); err == nil {
 			deletedCount++
 		}
 	}
 
+	entry := orderAudit(models.AuditImageDelete, order)
+	entry.ImageID = imageID
+	recordAudit(c, h.dbClient, entry, gin.H{"deleted_files": deletedCount})
 	c.JSON(http.StatusOK, gin.H{
 		"message": fmt.Sprintf("Image deleted successfully from AutoEnhance and %d associated file(s) removed from Supabase", deletedCount),
 		"image_id": imageID,
@@ -499,6 +794,37 @@ func (h *ImagesHandler) DeleteImage(c *gin.Context) {
 	})
 }
 
+// fileBelongsToImage matches by autoenhance_image_id. Rows without it fall back to the
+// {image_id}_{variant}.{ext} / {image_id}.{ext} filename conventions (never a substring match).
+func fileBelongsToImage(file models.OrderFile, imageID string) bool {
+	if file.AutoEnhanceImageID.Valid && file.AutoEnhanceImageID.String != "" {
+		return file.AutoEnhanceImageID.String == imageID
+	}
+	name := extractImageIDFromFilename(file.Filename)
+	return name == imageID || strings.HasPrefix(name, imageID+"_")
+}
+
+// processingSettings collects the AutoEnhance settings an image was processed with
+func processingSettings(img autoenhance.ImageOut) map[string]interface{} {
+	settings := make(map[string]interface{})
+	if img.EnhanceType != "" {
+		settings["enhance_type"] = img.EnhanceType
+	}
+	if img.SkyReplacement {
+		settings["sky_replacement"] = img.SkyReplacement
+	}
+	if img.VerticalCorrection {
+		settings["vertical_correction"] = img.VerticalCorrection
+	}
+	if img.LensCorrection {
+		settings["lens_correction"] = img.LensCorrection
+	}
+	if img.WindowPullType != nil {
+		settings["window_pull_type"] = *img.WindowPullType
+	}
+	return settings
+}
+
 // Helper function to extract image ID from filename
 // Filename format: {image_id}_{quality}.jpg
 func extractImageIDFromFilename(filename string) string {
@@ -506,6 +832,7 @@ func extractImageIDFromFilename(filename string) string {
 	name := strings.TrimSuffix(filename, ".jpg")
 	name = strings.TrimSuffix(name, ".jpeg")
 	name = strings.TrimSuffix(name, ".png")
+	name = strings.TrimSuffix(name, ".webp")
 	
 	return name
 }
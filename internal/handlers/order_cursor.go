@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"instant-hdr-backend/internal/models"
+)
+
+const (
+	defaultOrderPageSize = 50
+	maxOrderPageSize     = 200
+)
+
+// orderCursor is the opaque next_cursor handed to clients. It remembers the sort it was
+// issued for, so a cursor cannot be replayed against a different ordering.
+type orderCursor struct {
+	Sort      string    `json:"s"`
+	Ascending bool      `json:"a,omitempty"`
+	Time      time.Time `json:"t,omitempty"`
+	Name      string    `json:"n,omitempty"`
+	ID        uuid.UUID `json:"id"`
+}
+
+func encodeOrderCursor(opts models.OrderListOptions, last models.Order) string {
+	cursor := orderCursor{Sort: opts.Sort, Ascending: opts.Ascending, ID: last.ID}
+	switch opts.Sort {
+	case models.OrderSortUpdatedAt:
+		cursor.Time = last.UpdatedAt
+	case models.OrderSortName:
+		cursor.Name = last.Name.String
+	case models.OrderSortDeletedAt:
+		cursor.Time = last.DeletedAt.Time
+	default:
+		cursor.Time = last.CreatedAt
+	}
+	raw, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeOrderCursor(value string, opts models.OrderListOptions) (*models.OrderCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+	var cursor orderCursor
+	if err := json.Unmarshal(raw, &cursor); err != nil || cursor.ID == uuid.Nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+	if cursor.Sort != opts.Sort || cursor.Ascending != opts.Ascending {
+		return nil, fmt.Errorf("cursor was issued for a different sort")
+	}
+	return &models.OrderCursor{Time: cursor.Time, Name: cursor.Name, ID: cursor.ID}, nil
+}
+
+// parseOrderListOptions reads the GET /orders (or GET /orders/trash) query parameters. The returned Limit is the page size.
+func parseOrderListOptions(query url.Values, trashed bool) (models.OrderListOptions, error) {
+	opts := models.OrderListOptions{
+		Sort:    query.Get("sort"),
+		Limit:   defaultOrderPageSize,
+		Trashed: trashed,
+	}
+	if opts.Sort == "" {
+		opts.Sort = models.OrderSortCreatedAt
+		if trashed {
+			opts.Sort = models.OrderSortDeletedAt
+		}
+	}
+
+	switch opts.Sort {
+	case models.OrderSortCreatedAt, models.OrderSortUpdatedAt, models.OrderSortName:
+	case models.OrderSortDeletedAt:
+		if !trashed {
+			return opts, fmt.Errorf("sort by deleted_at is only available for the trash")
+		}
+	default:
+		return opts, fmt.Errorf("sort must be created_at, updated_at or name")
+	}
+
+	switch query.Get("direction") {
+	case "":
+		// Newest first for dates, A-Z for names
+		opts.Ascending = opts.Sort == models.OrderSortName
+	case "asc":
+		opts.Ascending = true
+	case "desc":
+	default:
+		return opts, fmt.Errorf("direction must be asc or desc")
+	}
+
+	if limit := query.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 || n > maxOrderPageSize {
+			return opts, fmt.Errorf("limit must be between 1 and %d", maxOrderPageSize)
+		}
+		opts.Limit = n
+	}
+
+	for _, status := range strings.Split(query.Get("status"), ",") {
+		if status = strings.TrimSpace(status); status != "" {
+			opts.Statuses = append(opts.Statuses, status)
+		}
+	}
+
+	if processing := query.Get("is_processing"); processing != "" {
+		value, err := strconv.ParseBool(processing)
+		if err != nil {
+			return opts, fmt.Errorf("is_processing must be true or false")
+		}
+		opts.IsProcessing = &value
+	}
+
+	opts.NameContains = strings.TrimSpace(query.Get("name"))
+
+	if tags := query.Get("tag"); tags != "" {
+		var err error
+		if opts.Tags, err = normalizeTags(strings.Split(tags, ",")); err != nil {
+			return opts, fmt.Errorf("tag: %w", err)
+		}
+	}
+
+	if propertyID := query.Get("property_id"); propertyID != "" {
+		id, err := uuid.Parse(propertyID)
+		if err != nil {
+			return opts, fmt.Errorf("property_id must be a UUID")
+		}
+		opts.PropertyID = uuid.NullUUID{UUID: id, Valid: true}
+	}
+
+	if organizationID := query.Get("organization_id"); organizationID != "" {
+		id, err := uuid.Parse(organizationID)
+		if err != nil {
+			return opts, fmt.Errorf("organization_id must be a UUID")
+		}
+		opts.OrganizationID = uuid.NullUUID{UUID: id, Valid: true}
+	}
+
+	var err error
+	if opts.CreatedAfter, err = parseListTime(query.Get("created_after")); err != nil {
+		return opts, fmt.Errorf("created_after: %w", err)
+	}
+	if opts.CreatedBefore, err = parseListTime(query.Get("created_before")); err != nil {
+		return opts, fmt.Errorf("created_before: %w", err)
+	}
+
+	if cursor := query.Get("cursor"); cursor != "" {
+		if opts.After, err = decodeOrderCursor(cursor, opts); err != nil {
+			return opts, err
+		}
+	}
+
+	return opts, nil
+}
+
+// parseListTime accepts RFC 3339 timestamps and plain dates (midnight UTC).
+// Timestamps are converted to UTC because created_at is stored without a time zone.
+func parseListTime(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		t = t.UTC()
+		return &t, nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return nil, fmt.Errorf("must be a date (2006-01-02) or RFC 3339 timestamp")
+	}
+	return &t, nil
+}
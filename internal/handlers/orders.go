@@ -8,23 +8,31 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"instant-hdr-backend/internal/authz"
 	"instant-hdr-backend/internal/autoenhance"
 	"instant-hdr-backend/internal/middleware"
 	"instant-hdr-backend/internal/models"
-	"instant-hdr-backend/internal/supabase"
+	"instant-hdr-backend/internal/quota"
+	"instant-hdr-backend/internal/repository"
+	"instant-hdr-backend/internal/services"
+	"instant-hdr-backend/internal/storage"
 )
 
 type OrdersHandler struct {
 	autoenhanceClient *autoenhance.Client
-	dbClient          *supabase.DatabaseClient
-	storageClient     *supabase.StorageClient
+	dbClient          repository.Repository
+	storageClient     storage.Storage
+	quotaService      *quota.Service
+	purger            *services.OrderPurger
 }
 
-func NewOrdersHandler(autoenhanceClient *autoenhance.Client, dbClient *supabase.DatabaseClient, storageClient *supabase.StorageClient) *OrdersHandler {
+func NewOrdersHandler(autoenhanceClient *autoenhance.Client, dbClient repository.Repository, storageClient storage.Storage, quotaService *quota.Service, purger *services.OrderPurger) *OrdersHandler {
 	return &OrdersHandler{
 		autoenhanceClient: autoenhanceClient,
 		dbClient:          dbClient,
 		storageClient:     storageClient,
+		quotaService:      quotaService,
+		purger:            purger,
 	}
 }
 
@@ -39,6 +47,8 @@ func NewOrdersHandler(autoenhanceClient *autoenhance.Client, dbClient *supabase.
 // @Success     200 {object} models.OrderResponse
 // @Failure     400 {object} models.ErrorResponse
 // @Failure     401 {object} models.ErrorResponse
+// @Failure     403 {object} models.QuotaExceededResponse "Quota exceeded, or not an editor of the organization"
+// @Failure     404 {object} models.ErrorResponse
 // @Failure     500 {object} models.ErrorResponse
 // @Router      /orders [post]
 func (h *OrdersHandler) CreateOrder(c *gin.Context) {
@@ -59,10 +69,65 @@ func (h *OrdersHandler) CreateOrder(c *gin.Context) {
 		return
 	}
 
+	// Check the active order limit before creating anything in AutoEnhance
+	if h.quotaService != nil {
+		if respondQuotaError(c, h.quotaService.CheckCreateOrder(userID)) {
+			return
+		}
+	}
+
 	var req models.CreateOrderRequest
 	// JSON body is optional - if not provided or invalid, req will just have empty Name
 	_ = c.ShouldBindJSON(&req)
 
+	var propertyID uuid.NullUUID
+	if req.PropertyID != "" {
+		id, err := uuid.Parse(req.PropertyID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid property id"})
+			return
+		}
+		if _, err := h.dbClient.GetProperty(id, userID); err != nil {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{
+				Error:   "property not found",
+				Message: err.Error(),
+			})
+			return
+		}
+		propertyID = uuid.NullUUID{UUID: id, Valid: true}
+	}
+
+	// Orders created with an organization API key belong to its organization
+	if org := apiKeyOrganization(c); org.Valid {
+		if req.OrganizationID != "" && req.OrganizationID != org.UUID.String() {
+			respondAPIKeyOrganization(c)
+			return
+		}
+		req.OrganizationID = org.UUID.String()
+	}
+
+	var organizationID uuid.NullUUID
+	if req.OrganizationID != "" {
+		id, err := uuid.Parse(req.OrganizationID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid organization id"})
+			return
+		}
+		if _, err := authz.RequireOrganizationRole(h.dbClient, id, userID, models.RoleEditor); respondAccessError(c, err, "organization not found") {
+			return
+		}
+		organizationID = uuid.NullUUID{UUID: id, Valid: true}
+	}
+
+	tags, err := normalizeTags(req.Tags)
+	if err == nil && len(tags) > maxTags {
+		err = fmt.Errorf("an order can carry at most %d tags", maxTags)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid tags", Message: err.Error()})
+		return
+	}
+
 	// Use provided name or default
 	orderName := req.Name
 	if orderName == "" {
@@ -127,6 +192,40 @@ func (h *OrdersHandler) CreateOrder(c *gin.Context) {
 		order, _ = h.dbClient.GetOrder(orderID, userID)
 	}
 
+	if propertyID.Valid {
+		if err := h.dbClient.SetOrderProperty(orderID, userID, propertyID); err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error:   "failed to link order to property",
+				Message: err.Error(),
+			})
+			return
+		}
+		order.PropertyID = propertyID
+	}
+
+	role := models.RoleOwner
+	if organizationID.Valid {
+		if err := h.dbClient.SetOrderOrganization(orderID, organizationID); err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error:   "failed to add order to organization",
+				Message: err.Error(),
+			})
+			return
+		}
+		order.OrganizationID = organizationID
+		role, _ = authz.OrderRole(h.dbClient, order, userID)
+	}
+
+	if len(tags) > 0 {
+		if order.Tags, err = h.dbClient.UpdateOrderTags(orderID, tags, nil); err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error:   "failed to tag order",
+				Message: err.Error(),
+			})
+			return
+		}
+	}
+
 	var metadata map[string]interface{}
 	if len(order.Metadata) > 0 {
 		json.Unmarshal(order.Metadata, &metadata)
@@ -139,6 +238,15 @@ func (h *OrdersHandler) CreateOrder(c *gin.Context) {
 		Metadata:  metadata,
 		CreatedAt: order.CreatedAt,
 		UpdatedAt: order.UpdatedAt,
+		CreatedBy: order.UserID.String(),
+		Role:      role,
+		Tags:      nonNilTags(order.Tags),
+	}
+	if order.PropertyID.Valid {
+		response.PropertyID = order.PropertyID.UUID.String()
+	}
+	if order.OrganizationID.Valid {
+		response.OrganizationID = order.OrganizationID.UUID.String()
 	}
 
 	// Include cached AutoEnhance data
@@ -156,21 +264,66 @@ func (h *OrdersHandler) CreateOrder(c *gin.Context) {
 		response.AutoEnhanceLastUpdatedAt = &order.AutoEnhanceLastUpdatedAt.Time
 	}
 
+	recordAudit(c, h.dbClient, orderAudit(models.AuditOrderCreate, order), gin.H{
+		"name": response.Name, "property_id": order.PropertyID, "organization_id": order.OrganizationID, "tags": response.Tags,
+	})
 	c.JSON(http.StatusOK, response)
 }
 
 // ListOrders godoc
-// @Summary     List all orders
-// @Description Returns a list of all orders for the authenticated user
+// @Summary     List orders
+// @Description Returns a page of the authenticated user's orders and the orders of their organizations from the cached AutoEnhance data (no AutoEnhance calls are made).
+// @Description Pages are cursor-based: pass next_cursor from the previous response as cursor, keeping the same sort and direction.
 // @Tags        orders
 // @Accept      json
 // @Produce     json
 // @Security    Bearer
+// @Param       limit           query int    false "Page size (1-200)" default(50)
+// @Param       cursor          query string false "next_cursor from the previous page"
+// @Param       sort            query string false "Sort key" Enums(created_at, updated_at, name) default(created_at)
+// @Param       direction       query string false "Sort direction (default desc for dates, asc for name)" Enums(asc, desc)
+// @Param       status          query string false "Comma-separated order statuses"
+// @Param       is_processing   query bool   false "Only orders that are (or are not) processing"
+// @Param       property_id     query string false "Only orders of this property (UUID)"
+// @Param       organization_id query string false "Only orders of this organization (UUID)"
+// @Param       tag             query string false "Comma-separated tags; only orders carrying all of them"
+// @Param       saved_filter    query string false "Saved filter ID; parameters given here override the saved ones"
+// @Param       name            query string false "Case-insensitive name substring"
+// @Param       created_after   query string false "Created at or after (date or RFC 3339)"
+// @Param       created_before  query string false "Created before (date or RFC 3339)"
 // @Success     200 {object} models.OrderListResponse
+// @Failure     400 {object} models.ErrorResponse
 // @Failure     401 {object} models.ErrorResponse
 // @Failure     500 {object} models.ErrorResponse
 // @Router      /orders [get]
 func (h *OrdersHandler) ListOrders(c *gin.Context) {
+	h.listOrders(c, false)
+}
+
+// ListTrash godoc
+// @Summary     List orders in the trash
+// @Description Returns a page of the deleted orders the authenticated user can see, most recently deleted first. They can be restored until the janitor purges them.
+// @Description Supports the same cursor, filter and sort parameters as GET /orders, plus sort=deleted_at (the default here).
+// @Tags        orders
+// @Accept      json
+// @Produce     json
+// @Security    Bearer
+// @Param       limit          query int    false "Page size (1-200)" default(50)
+// @Param       cursor         query string false "next_cursor from the previous page"
+// @Param       sort           query string false "Sort key" Enums(deleted_at, created_at, updated_at, name) default(deleted_at)
+// @Param       direction      query string false "Sort direction (default desc for dates, asc for name)" Enums(asc, desc)
+// @Param       status         query string false "Comma-separated order statuses"
+// @Param       name           query string false "Case-insensitive name substring"
+// @Success     200 {object} models.OrderListResponse
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     401 {object} models.ErrorResponse
+// @Failure     500 {object} models.ErrorResponse
+// @Router      /orders/trash [get]
+func (h *OrdersHandler) ListTrash(c *gin.Context) {
+	h.listOrders(c, true)
+}
+
+func (h *OrdersHandler) listOrders(c *gin.Context, trashed bool) {
 	if h.dbClient == nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "database not available"})
 		return
@@ -188,7 +341,44 @@ func (h *OrdersHandler) ListOrders(c *gin.Context) {
 		return
 	}
 
-	orders, err := h.dbClient.ListOrders(userID)
+	query := c.Request.URL.Query()
+	if filterID := query.Get("saved_filter"); filterID != "" {
+		id, err := uuid.Parse(filterID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid saved filter id"})
+			return
+		}
+		filter, err := h.dbClient.GetSavedFilter(id, userID)
+		if err != nil {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{
+				Error:   "saved filter not found",
+				Message: err.Error(),
+			})
+			return
+		}
+		if err := applySavedFilter(query, filter); err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "invalid saved filter", Message: err.Error()})
+			return
+		}
+	}
+
+	opts, err := parseOrderListOptions(query, trashed)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid query", Message: err.Error()})
+		return
+	}
+	if org := apiKeyOrganization(c); org.Valid {
+		if opts.OrganizationID.Valid && opts.OrganizationID != org {
+			respondAPIKeyOrganization(c)
+			return
+		}
+		opts.OrganizationID = org
+	}
+
+	// Fetch one extra row to know whether there is a next page
+	pageSize := opts.Limit
+	opts.Limit = pageSize + 1
+	orders, err := h.dbClient.ListOrders(userID, opts)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Error:   "failed to list orders",
@@ -197,48 +387,22 @@ func (h *OrdersHandler) ListOrders(c *gin.Context) {
 		return
 	}
 
-	summaries := make([]models.OrderSummary, len(orders))
-	for i, o := range orders {
-		summary := models.OrderSummary{
-			ID:        o.ID.String(),
-			Status:    o.Status,
-			Progress:  o.Progress,
-			CreatedAt: o.CreatedAt,
-			UpdatedAt: o.UpdatedAt,
-		}
-
-		// Use cached name from database if available and not empty
-		if o.Name.Valid && o.Name.String != "" {
-			summary.Name = o.Name.String
-		} else if h.autoenhanceClient != nil {
-			// If name not cached or is empty, fetch from AutoEnhance and sync to DB
-			autoenhanceOrder, err := h.autoenhanceClient.GetOrder(o.ID.String())
-			if err == nil && autoenhanceOrder != nil && autoenhanceOrder.Name != "" {
-				summary.Name = autoenhanceOrder.Name
-				// Sync to database for future requests
-				var lastUpdated *time.Time
-				if !autoenhanceOrder.LastUpdatedAt.Time.IsZero() {
-					lastUpdated = &autoenhanceOrder.LastUpdatedAt.Time
-				}
-				go func(orderID uuid.UUID) {
-					_ = h.dbClient.SyncAutoEnhanceOrderData(
-						orderID,
-						autoenhanceOrder.Name,
-						autoenhanceOrder.Status,
-						autoenhanceOrder.IsProcessing,
-						autoenhanceOrder.IsMerging,
-						autoenhanceOrder.IsDeleted,
-						int(autoenhanceOrder.TotalImages),
-						lastUpdated,
-					)
-				}(o.ID)
-			}
-		}
+	c.JSON(http.StatusOK, orderPage(orders, opts, pageSize))
+}
 
-		summaries[i] = summary
+// orderPage builds a list response from up to pageSize+1 orders; the extra one only tells that there is a next page
+func orderPage(orders []models.Order, opts models.OrderListOptions, pageSize int) models.OrderListResponse {
+	var response models.OrderListResponse
+	if len(orders) > pageSize {
+		orders = orders[:pageSize]
+		response.NextCursor = encodeOrderCursor(opts, orders[pageSize-1])
 	}
 
-	c.JSON(http.StatusOK, models.OrderListResponse{Orders: summaries})
+	response.Orders = make([]models.OrderSummary, len(orders))
+	for i, o := range orders {
+		response.Orders[i] = orderSummary(o)
+	}
+	return response
 }
 
 // GetOrder godoc
@@ -288,6 +452,11 @@ func (h *OrdersHandler) GetOrder(c *gin.Context) {
 		return
 	}
 
+	role, err := authz.OrderRole(h.dbClient, order, userID)
+	if respondAccessError(c, err, "order not found") {
+		return
+	}
+
 	var metadata map[string]interface{}
 	if len(order.Metadata) > 0 {
 		json.Unmarshal(order.Metadata, &metadata)
@@ -300,6 +469,15 @@ func (h *OrdersHandler) GetOrder(c *gin.Context) {
 		Metadata:  metadata,
 		CreatedAt: order.CreatedAt,
 		UpdatedAt: order.UpdatedAt,
+		CreatedBy: order.UserID.String(),
+		Role:      role,
+		Tags:      nonNilTags(order.Tags),
+	}
+	if order.PropertyID.Valid {
+		response.PropertyID = order.PropertyID.UUID.String()
+	}
+	if order.OrganizationID.Valid {
+		response.OrganizationID = order.OrganizationID.UUID.String()
 	}
 
 	if order.ErrorMessage.Valid {
@@ -485,17 +663,23 @@ func (h *OrdersHandler) VerifyOrderUploads(c *gin.Context) {
 
 // DeleteOrder godoc
 // @Summary     Delete an order
-// @Description Deletes an order, including associated AutoEnhance AI order and files from Supabase Storage
+// @Description Requires the admin role on organization orders.
+// @Description Moves an order to the trash: it disappears from the API but can be restored with POST /orders/{order_id}/restore
+// @Description until the janitor purges it (RETENTION_DELETED_ORDER_DAYS). With permanent=true the order - also one already in the trash -
+// @Description is deleted right away, including the AutoEnhance order and its files in storage.
 // @Tags        orders
 // @Accept      json
 // @Produce     json
 // @Security    Bearer
-// @Param       order_id path string true "Order ID (UUID)"
+// @Param       order_id  path  string true  "Order ID (UUID)"
+// @Param       permanent query bool   false "Delete permanently instead of moving to the trash" default(false)
 // @Success     200 {object} map[string]string "message"
 // @Failure     400 {object} models.ErrorResponse
 // @Failure     401 {object} models.ErrorResponse
+// @Failure     403 {object} models.ErrorResponse
 // @Failure     404 {object} models.ErrorResponse
 // @Failure     500 {object} models.ErrorResponse
+// @Failure     502 {object} models.ErrorResponse
 // @Router      /orders/{order_id} [delete]
 func (h *OrdersHandler) DeleteOrder(c *gin.Context) {
 	if h.dbClient == nil {
@@ -522,8 +706,13 @@ func (h *OrdersHandler) DeleteOrder(c *gin.Context) {
 		return
 	}
 
-	// Verify order exists
-	_, err = h.dbClient.GetOrder(orderID, userID)
+	permanent := c.DefaultQuery("permanent", "false") == "true"
+
+	// Verify order exists (a permanent delete also empties it from the trash)
+	order, err := h.dbClient.GetOrder(orderID, userID)
+	if err != nil && permanent {
+		order, err = h.dbClient.GetTrashedOrder(orderID, userID)
+	}
 	if err != nil {
 		c.JSON(http.StatusNotFound, models.ErrorResponse{
 			Error:   "order not found",
@@ -531,29 +720,312 @@ func (h *OrdersHandler) DeleteOrder(c *gin.Context) {
 		})
 		return
 	}
+	if !authorizeOrder(c, h.dbClient, order, userID, models.RoleAdmin) {
+		return
+	}
 
-	// Delete from AutoEnhance with retry - use the same order_id
-	err = h.autoenhanceClient.RetryWithBackoff(func() error {
-		return h.autoenhanceClient.DeleteOrder(orderID.String())
-	}, 3)
+	if !permanent {
+		if err := h.dbClient.TrashOrder(orderID, order.UserID); err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error:   "failed to delete order",
+				Message: err.Error(),
+			})
+			return
+		}
+		recordAudit(c, h.dbClient, orderAudit(models.AuditOrderTrash, order), nil)
+		c.JSON(http.StatusOK, gin.H{"message": "order moved to trash"})
+		return
+	}
+
+	if err := h.purger.Purge(*order); err != nil {
+		c.JSON(http.StatusBadGateway, models.ErrorResponse{
+			Error:   "failed to delete order",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	recordAudit(c, h.dbClient, orderAudit(models.AuditOrderPurge, order), nil)
+	c.JSON(http.StatusOK, gin.H{"message": "order deleted permanently"})
+}
+
+// SetOrderProperty godoc
+// @Summary     Link an order to a property
+// @Description Moves the order to one of the user's properties, or unlinks it when property_id is null.
+// @Tags        orders
+// @Accept      json
+// @Produce     json
+// @Security    Bearer
+// @Param       order_id path string                         true "Order ID (UUID)"
+// @Param       request  body models.SetOrderPropertyRequest true "Property to link"
+// @Success     200 {object} models.OrderSummary
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     401 {object} models.ErrorResponse
+// @Failure     403 {object} models.ErrorResponse
+// @Failure     404 {object} models.ErrorResponse
+// @Failure     500 {object} models.ErrorResponse
+// @Router      /orders/{order_id}/property [put]
+func (h *OrdersHandler) SetOrderProperty(c *gin.Context) {
+	if h.dbClient == nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "database not available"})
+		return
+	}
+
+	userIDStr, exists := c.Get(middleware.UserIDKey)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{Error: "user id not found"})
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr.(string))
 	if err != nil {
-		// Log error but continue with database deletion
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid user id"})
+		return
+	}
+
+	orderID, err := uuid.Parse(c.Param("order_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid order id"})
+		return
 	}
 
-	// Delete files from storage
-	if err := h.storageClient.DeleteOrderFiles(userID, orderID); err != nil {
-		// Log error but continue
+	var req models.SetOrderPropertyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid request body", Message: err.Error()})
+		return
 	}
 
-	// Delete from database (cascade will delete files)
-	if err := h.dbClient.DeleteOrder(orderID, userID); err != nil {
+	var propertyID uuid.NullUUID
+	if req.PropertyID != nil {
+		id, err := uuid.Parse(*req.PropertyID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid property id"})
+			return
+		}
+		if _, err := h.dbClient.GetProperty(id, userID); err != nil {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{
+				Error:   "property not found",
+				Message: err.Error(),
+			})
+			return
+		}
+		propertyID = uuid.NullUUID{UUID: id, Valid: true}
+	}
+
+	order, err := h.dbClient.GetOrder(orderID, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "order not found",
+			Message: err.Error(),
+		})
+		return
+	}
+	if !authorizeOrder(c, h.dbClient, order, userID, models.RoleEditor) {
+		return
+	}
+
+	if err := h.dbClient.SetOrderProperty(orderID, userID, propertyID); err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Error:   "failed to delete order",
+			Error:   "failed to link order to property",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	recordAudit(c, h.dbClient, orderAudit(models.AuditOrderPropertySet, order), gin.H{
+		"previous_property_id": order.PropertyID, "property_id": propertyID,
+	})
+
+	order, err = h.dbClient.GetOrder(orderID, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "failed to get order", Message: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, orderSummary(*order))
+}
+
+// SetOrderOrganization godoc
+// @Summary     Share an order with an organization
+// @Description Moves the order into an organization, or back to its creator when organization_id is null.
+// @Description Requires the admin role on the order and the editor role in the target organization.
+// @Description The order keeps its creator, whose plan it counts against and under whose storage prefix its files stay.
+// @Tags        orders
+// @Accept      json
+// @Produce     json
+// @Security    Bearer
+// @Param       order_id path string                             true "Order ID (UUID)"
+// @Param       request  body models.SetOrderOrganizationRequest true "Organization to share with"
+// @Success     200 {object} models.OrderSummary
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     401 {object} models.ErrorResponse
+// @Failure     403 {object} models.ErrorResponse
+// @Failure     404 {object} models.ErrorResponse
+// @Failure     500 {object} models.ErrorResponse
+// @Router      /orders/{order_id}/organization [put]
+func (h *OrdersHandler) SetOrderOrganization(c *gin.Context) {
+	if h.dbClient == nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "database not available"})
+		return
+	}
+
+	userIDStr, exists := c.Get(middleware.UserIDKey)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{Error: "user id not found"})
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid user id"})
+		return
+	}
+
+	orderID, err := uuid.Parse(c.Param("order_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid order id"})
+		return
+	}
+
+	var req models.SetOrderOrganizationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid request body", Message: err.Error()})
+		return
+	}
+
+	order, err := h.dbClient.GetOrder(orderID, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "order not found",
+			Message: err.Error(),
+		})
+		return
+	}
+	if !authorizeOrder(c, h.dbClient, order, userID, models.RoleAdmin) {
+		return
+	}
+
+	var organizationID uuid.NullUUID
+	if req.OrganizationID != nil {
+		id, err := uuid.Parse(*req.OrganizationID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid organization id"})
+			return
+		}
+		if _, err := authz.RequireOrganizationRole(h.dbClient, id, userID, models.RoleEditor); respondAccessError(c, err, "organization not found") {
+			return
+		}
+		organizationID = uuid.NullUUID{UUID: id, Valid: true}
+	}
+
+	if err := h.dbClient.SetOrderOrganization(orderID, organizationID); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "failed to change order organization",
 			Message: err.Error(),
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "order deleted successfully"})
+	recordAudit(c, h.dbClient, orderAudit(models.AuditOrderOrganizationSet, order), gin.H{
+		"previous_organization_id": order.OrganizationID, "organization_id": organizationID,
+	})
+	order.OrganizationID = organizationID
+	c.JSON(http.StatusOK, orderSummary(*order))
 }
 
+// RestoreOrder godoc
+// @Summary     Restore an order from the trash
+// @Description Takes a deleted order out of the trash. Restoring counts against the active order limit of the creator's plan.
+// @Description Requires the admin role on organization orders.
+// @Tags        orders
+// @Accept      json
+// @Produce     json
+// @Security    Bearer
+// @Param       order_id path string true "Order ID (UUID)"
+// @Success     200 {object} map[string]string "message"
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     401 {object} models.ErrorResponse
+// @Failure     403 {object} models.QuotaExceededResponse "Quota exceeded, or not an admin of the order"
+// @Failure     404 {object} models.ErrorResponse
+// @Failure     500 {object} models.ErrorResponse
+// @Router      /orders/{order_id}/restore [post]
+func (h *OrdersHandler) RestoreOrder(c *gin.Context) {
+	if h.dbClient == nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "database not available"})
+		return
+	}
+
+	userIDStr, exists := c.Get(middleware.UserIDKey)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{Error: "user id not found"})
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid user id"})
+		return
+	}
+
+	orderID, err := uuid.Parse(c.Param("order_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid order id"})
+		return
+	}
+
+	order, err := h.dbClient.GetTrashedOrder(orderID, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "order not found in trash",
+			Message: err.Error(),
+		})
+		return
+	}
+	if !authorizeOrder(c, h.dbClient, order, userID, models.RoleAdmin) {
+		return
+	}
+
+	// Active orders count against their creator's plan
+	if h.quotaService != nil {
+		if respondQuotaError(c, h.quotaService.CheckCreateOrder(order.UserID)) {
+			return
+		}
+	}
+
+	if err := h.dbClient.RestoreOrder(orderID, order.UserID); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "failed to restore order",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	recordAudit(c, h.dbClient, orderAudit(models.AuditOrderRestore, order), nil)
+	c.JSON(http.StatusOK, gin.H{"message": "order restored"})
+}
+
+// orderSummary builds the list entry for an order from its cached columns
+func orderSummary(o models.Order) models.OrderSummary {
+	summary := models.OrderSummary{
+		ID:                o.ID.String(),
+		Name:              o.Name.String,
+		Status:            o.Status,
+		Progress:          o.Progress,
+		AutoEnhanceStatus: o.AutoEnhanceStatus.String,
+		IsProcessing:      o.IsProcessing,
+		TotalImages:       o.TotalImages,
+		CreatedAt:         o.CreatedAt,
+		UpdatedAt:         o.UpdatedAt,
+		CreatedBy:         o.UserID.String(),
+		Tags:              nonNilTags(o.Tags),
+	}
+	if o.DeletedAt.Valid {
+		summary.DeletedAt = &o.DeletedAt.Time
+	}
+	if o.PropertyID.Valid {
+		summary.PropertyID = o.PropertyID.UUID.String()
+	}
+	if o.OrganizationID.Valid {
+		summary.OrganizationID = o.OrganizationID.UUID.String()
+	}
+	return summary
+}
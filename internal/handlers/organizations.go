@@ -0,0 +1,490 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"instant-hdr-backend/internal/authz"
+	"instant-hdr-backend/internal/middleware"
+	"instant-hdr-backend/internal/models"
+	"instant-hdr-backend/internal/repository"
+)
+
+type OrganizationsHandler struct {
+	dbClient repository.Repository
+}
+
+func NewOrganizationsHandler(dbClient repository.Repository) *OrganizationsHandler {
+	return &OrganizationsHandler{
+		dbClient: dbClient,
+	}
+}
+
+// CreateOrganization godoc
+// @Summary     Create an organization
+// @Description Creates an organization with the authenticated user as its owner
+// @Tags        organizations
+// @Accept      json
+// @Produce     json
+// @Security    Bearer
+// @Param       request body models.OrganizationRequest true "Organization"
+// @Success     201 {object} models.OrganizationResponse
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     401 {object} models.ErrorResponse
+// @Failure     500 {object} models.ErrorResponse
+// @Router      /organizations [post]
+func (h *OrganizationsHandler) CreateOrganization(c *gin.Context) {
+	userID, ok := h.userID(c)
+	if !ok {
+		return
+	}
+
+	var req models.OrganizationRequest
+	if err := c.ShouldBindJSON(&req); err != nil || strings.TrimSpace(req.Name) == "" {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "name is required"})
+		return
+	}
+
+	org := &models.Organization{Name: strings.TrimSpace(req.Name), CreatedBy: userID}
+	if err := h.dbClient.CreateOrganization(org); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "failed to create organization",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	recordAudit(c, h.dbClient, models.AuditEntry{Action: models.AuditOrganizationCreate}, gin.H{
+		"organization_id": org.ID, "name": org.Name,
+	})
+	c.JSON(http.StatusCreated, organizationResponse(*org, models.RoleOwner))
+}
+
+// ListOrganizations godoc
+// @Summary     List organizations
+// @Description Returns the organizations the authenticated user is a member of, with their role in each
+// @Tags        organizations
+// @Accept      json
+// @Produce     json
+// @Security    Bearer
+// @Success     200 {object} models.OrganizationListResponse
+// @Failure     401 {object} models.ErrorResponse
+// @Failure     500 {object} models.ErrorResponse
+// @Router      /organizations [get]
+func (h *OrganizationsHandler) ListOrganizations(c *gin.Context) {
+	userID, ok := h.userID(c)
+	if !ok {
+		return
+	}
+
+	memberships, err := h.dbClient.ListOrganizations(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "failed to list organizations",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	response := models.OrganizationListResponse{Organizations: make([]models.OrganizationResponse, len(memberships))}
+	for i, m := range memberships {
+		response.Organizations[i] = organizationResponse(m.Organization, m.Role)
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// GetOrganization godoc
+// @Summary     Get an organization
+// @Tags        organizations
+// @Accept      json
+// @Produce     json
+// @Security    Bearer
+// @Param       organization_id path string true "Organization ID (UUID)"
+// @Success     200 {object} models.OrganizationResponse
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     401 {object} models.ErrorResponse
+// @Failure     404 {object} models.ErrorResponse
+// @Router      /organizations/{organization_id} [get]
+func (h *OrganizationsHandler) GetOrganization(c *gin.Context) {
+	userID, ok := h.userID(c)
+	if !ok {
+		return
+	}
+	org, role, ok := h.organization(c, userID, models.RoleViewer)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, organizationResponse(*org, role))
+}
+
+// UpdateOrganization godoc
+// @Summary     Rename an organization
+// @Description Requires the admin role
+// @Tags        organizations
+// @Accept      json
+// @Produce     json
+// @Security    Bearer
+// @Param       organization_id path string                     true "Organization ID (UUID)"
+// @Param       request         body models.OrganizationRequest true "New name"
+// @Success     200 {object} models.OrganizationResponse
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     401 {object} models.ErrorResponse
+// @Failure     403 {object} models.ErrorResponse
+// @Failure     404 {object} models.ErrorResponse
+// @Failure     500 {object} models.ErrorResponse
+// @Router      /organizations/{organization_id} [patch]
+func (h *OrganizationsHandler) UpdateOrganization(c *gin.Context) {
+	userID, ok := h.userID(c)
+	if !ok {
+		return
+	}
+	org, role, ok := h.organization(c, userID, models.RoleAdmin)
+	if !ok {
+		return
+	}
+
+	var req models.OrganizationRequest
+	if err := c.ShouldBindJSON(&req); err != nil || strings.TrimSpace(req.Name) == "" {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "name is required"})
+		return
+	}
+
+	org.Name = strings.TrimSpace(req.Name)
+	if err := h.dbClient.UpdateOrganization(org); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "failed to update organization",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	recordAudit(c, h.dbClient, models.AuditEntry{Action: models.AuditOrganizationUpdate}, gin.H{
+		"organization_id": org.ID, "name": org.Name,
+	})
+	c.JSON(http.StatusOK, organizationResponse(*org, role))
+}
+
+// DeleteOrganization godoc
+// @Summary     Delete an organization
+// @Description Requires the owner role. The organization's orders are kept and go back to the members who created them.
+// @Tags        organizations
+// @Accept      json
+// @Produce     json
+// @Security    Bearer
+// @Param       organization_id path string true "Organization ID (UUID)"
+// @Success     200 {object} map[string]string "message"
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     401 {object} models.ErrorResponse
+// @Failure     403 {object} models.ErrorResponse
+// @Failure     404 {object} models.ErrorResponse
+// @Failure     500 {object} models.ErrorResponse
+// @Router      /organizations/{organization_id} [delete]
+func (h *OrganizationsHandler) DeleteOrganization(c *gin.Context) {
+	userID, ok := h.userID(c)
+	if !ok {
+		return
+	}
+	org, _, ok := h.organization(c, userID, models.RoleOwner)
+	if !ok {
+		return
+	}
+
+	if err := h.dbClient.DeleteOrganization(org.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "failed to delete organization",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	recordAudit(c, h.dbClient, models.AuditEntry{Action: models.AuditOrganizationDelete}, gin.H{
+		"organization_id": org.ID, "name": org.Name,
+	})
+	c.JSON(http.StatusOK, gin.H{"message": "organization deleted successfully"})
+}
+
+// ListMembers godoc
+// @Summary     List organization members
+// @Tags        organizations
+// @Accept      json
+// @Produce     json
+// @Security    Bearer
+// @Param       organization_id path string true "Organization ID (UUID)"
+// @Success     200 {object} models.OrganizationMembersResponse
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     401 {object} models.ErrorResponse
+// @Failure     404 {object} models.ErrorResponse
+// @Failure     500 {object} models.ErrorResponse
+// @Router      /organizations/{organization_id}/members [get]
+func (h *OrganizationsHandler) ListMembers(c *gin.Context) {
+	userID, ok := h.userID(c)
+	if !ok {
+		return
+	}
+	org, _, ok := h.organization(c, userID, models.RoleViewer)
+	if !ok {
+		return
+	}
+
+	members, err := h.dbClient.ListOrganizationMembers(org.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "failed to list members",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	response := models.OrganizationMembersResponse{Members: make([]models.OrganizationMemberResponse, len(members))}
+	for i, member := range members {
+		response.Members[i] = memberResponse(member)
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// SetMember godoc
+// @Summary     Add a member or change their role
+// @Description Requires the admin role. Only owners can grant or change the admin and owner roles,
+// @Description and the last owner cannot be demoted.
+// @Tags        organizations
+// @Accept      json
+// @Produce     json
+// @Security    Bearer
+// @Param       organization_id path string                           true "Organization ID (UUID)"
+// @Param       user_id         path string                           true "User ID (UUID)"
+// @Param       request         body models.OrganizationMemberRequest true "Role"
+// @Success     200 {object} models.OrganizationMemberResponse
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     401 {object} models.ErrorResponse
+// @Failure     403 {object} models.ErrorResponse
+// @Failure     404 {object} models.ErrorResponse
+// @Failure     409 {object} models.ErrorResponse
+// @Failure     500 {object} models.ErrorResponse
+// @Router      /organizations/{organization_id}/members/{user_id} [put]
+func (h *OrganizationsHandler) SetMember(c *gin.Context) {
+	userID, ok := h.userID(c)
+	if !ok {
+		return
+	}
+	org, role, ok := h.organization(c, userID, models.RoleAdmin)
+	if !ok {
+		return
+	}
+
+	memberID, err := uuid.Parse(c.Param("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid user id"})
+		return
+	}
+
+	var req models.OrganizationMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil || !models.ValidRole(req.Role) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "role must be one of: viewer, editor, admin, owner"})
+		return
+	}
+
+	current, err := h.dbClient.GetOrganizationMember(org.ID, memberID)
+	currentRole := ""
+	if err == nil {
+		currentRole = current.Role
+	}
+	if !h.canManage(c, role, currentRole, req.Role) {
+		return
+	}
+	if currentRole == models.RoleOwner && req.Role != models.RoleOwner && !h.keepsAnOwner(c, org.ID, memberID) {
+		return
+	}
+
+	member := &models.OrganizationMember{OrganizationID: org.ID, UserID: memberID, Role: req.Role}
+	if err := h.dbClient.SetOrganizationMember(member); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "failed to set member",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	recordAudit(c, h.dbClient, models.AuditEntry{
+		Action: models.AuditOrganizationMemberSet,
+		UserID: uuid.NullUUID{UUID: memberID, Valid: true},
+	}, gin.H{"organization_id": org.ID, "previous_role": currentRole, "role": req.Role})
+	c.JSON(http.StatusOK, memberResponse(*member))
+}
+
+// RemoveMember godoc
+// @Summary     Remove a member
+// @Description Requires the admin role, except for members leaving on their own. Only owners can remove admins and owners,
+// @Description and the last owner cannot leave.
+// @Tags        organizations
+// @Accept      json
+// @Produce     json
+// @Security    Bearer
+// @Param       organization_id path string true "Organization ID (UUID)"
+// @Param       user_id         path string true "User ID (UUID)"
+// @Success     200 {object} map[string]string "message"
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     401 {object} models.ErrorResponse
+// @Failure     403 {object} models.ErrorResponse
+// @Failure     404 {object} models.ErrorResponse
+// @Failure     409 {object} models.ErrorResponse
+// @Failure     500 {object} models.ErrorResponse
+// @Router      /organizations/{organization_id}/members/{user_id} [delete]
+func (h *OrganizationsHandler) RemoveMember(c *gin.Context) {
+	userID, ok := h.userID(c)
+	if !ok {
+		return
+	}
+
+	memberID, err := uuid.Parse(c.Param("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid user id"})
+		return
+	}
+
+	// Anyone can leave; removing someone else takes the admin role
+	minRole := models.RoleAdmin
+	if memberID == userID {
+		minRole = models.RoleViewer
+	}
+	org, role, ok := h.organization(c, userID, minRole)
+	if !ok {
+		return
+	}
+
+	member, err := h.dbClient.GetOrganizationMember(org.ID, memberID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "member not found",
+			Message: err.Error(),
+		})
+		return
+	}
+	if memberID != userID && !h.canManage(c, role, member.Role, "") {
+		return
+	}
+	if member.Role == models.RoleOwner && !h.keepsAnOwner(c, org.ID, memberID) {
+		return
+	}
+
+	if err := h.dbClient.RemoveOrganizationMember(org.ID, memberID); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "failed to remove member",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	recordAudit(c, h.dbClient, models.AuditEntry{
+		Action: models.AuditOrganizationMemberRemove,
+		UserID: uuid.NullUUID{UUID: memberID, Valid: true},
+	}, gin.H{"organization_id": org.ID, "role": member.Role})
+	c.JSON(http.StatusOK, gin.H{"message": "member removed successfully"})
+}
+
+// userID checks the database and returns the authenticated user, or writes the error response
+func (h *OrganizationsHandler) userID(c *gin.Context) (uuid.UUID, bool) {
+	if h.dbClient == nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "database not available"})
+		return uuid.Nil, false
+	}
+
+	userIDStr, exists := c.Get(middleware.UserIDKey)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{Error: "user id not found"})
+		return uuid.Nil, false
+	}
+
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid user id"})
+		return uuid.Nil, false
+	}
+	return userID, true
+}
+
+// organization loads the :organization_id of the request and checks the user's role in it,
+// or writes the error response
+func (h *OrganizationsHandler) organization(c *gin.Context, userID uuid.UUID, min string) (*models.Organization, string, bool) {
+	organizationID, err := uuid.Parse(c.Param("organization_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid organization id"})
+		return nil, "", false
+	}
+
+	role, err := authz.RequireOrganizationRole(h.dbClient, organizationID, userID, min)
+	if respondAccessError(c, err, "organization not found") {
+		return nil, "", false
+	}
+
+	org, err := h.dbClient.GetOrganization(organizationID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "organization not found",
+			Message: err.Error(),
+		})
+		return nil, "", false
+	}
+	return org, role, true
+}
+
+// canManage checks that a member with role may change a member from currentRole to newRole
+// (either may be "" for adding or removing). Admins manage viewers and editors; owners manage everyone.
+func (h *OrganizationsHandler) canManage(c *gin.Context, role, currentRole, newRole string) bool {
+	if role == models.RoleOwner {
+		return true
+	}
+	for _, r := range []string{currentRole, newRole} {
+		if r != "" && models.RoleAtLeast(r, models.RoleAdmin) {
+			c.JSON(http.StatusForbidden, models.ErrorResponse{
+				Error:   "forbidden",
+				Message: "only owners can manage admins and owners",
+			})
+			return false
+		}
+	}
+	return true
+}
+
+// keepsAnOwner checks that the organization has an owner besides userID
+func (h *OrganizationsHandler) keepsAnOwner(c *gin.Context, organizationID, userID uuid.UUID) bool {
+	members, err := h.dbClient.ListOrganizationMembers(organizationID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "failed to list members",
+			Message: err.Error(),
+		})
+		return false
+	}
+	for _, member := range members {
+		if member.Role == models.RoleOwner && member.UserID != userID {
+			return true
+		}
+	}
+	c.JSON(http.StatusConflict, models.ErrorResponse{
+		Error:   "organization needs an owner",
+		Message: "make another member owner first, or delete the organization",
+	})
+	return false
+}
+
+func organizationResponse(org models.Organization, role string) models.OrganizationResponse {
+	return models.OrganizationResponse{
+		ID:        org.ID.String(),
+		Name:      org.Name,
+		CreatedBy: org.CreatedBy.String(),
+		Role:      role,
+		CreatedAt: org.CreatedAt,
+		UpdatedAt: org.UpdatedAt,
+	}
+}
+
+func memberResponse(member models.OrganizationMember) models.OrganizationMemberResponse {
+	return models.OrganizationMemberResponse{
+		UserID:    member.UserID.String(),
+		Role:      member.Role,
+		CreatedAt: member.CreatedAt,
+	}
+}
@@ -9,22 +9,24 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"instant-hdr-backend/internal/autoenhance"
+	"instant-hdr-backend/internal/events"
 	"instant-hdr-backend/internal/middleware"
 	"instant-hdr-backend/internal/models"
-	"instant-hdr-backend/internal/supabase"
+	"instant-hdr-backend/internal/repository"
+	"instant-hdr-backend/internal/services"
 )
 
 type ProcessHandler struct {
 	autoenhanceClient *autoenhance.Client
-	dbClient          *supabase.DatabaseClient
-	realtimeClient    *supabase.RealtimeClient
+	dbClient          repository.Repository
+	dispatcher        *services.EventDispatcher
 }
 
-func NewProcessHandler(autoenhanceClient *autoenhance.Client, dbClient *supabase.DatabaseClient, realtimeClient *supabase.RealtimeClient) *ProcessHandler {
+func NewProcessHandler(autoenhanceClient *autoenhance.Client, dbClient repository.Repository, dispatcher *services.EventDispatcher) *ProcessHandler {
 	return &ProcessHandler{
 		autoenhanceClient: autoenhanceClient,
 		dbClient:          dbClient,
-		realtimeClient:    realtimeClient,
+		dispatcher:        dispatcher,
 	}
 }
 
@@ -82,6 +84,7 @@ func NewProcessHandler(autoenhanceClient *autoenhance.Client, dbClient *supabase
 // @Success     200 {object} models.ProcessResponse
 // @Failure     400 {object} models.ErrorResponse
 // @Failure     401 {object} models.ErrorResponse
+// @Failure     403 {object} models.ErrorResponse
 // @Failure     404 {object} models.ErrorResponse
 // @Failure     500 {object} models.ErrorResponse
 // @Router      /orders/{order_id}/process [post]
@@ -110,7 +113,7 @@ func (h *ProcessHandler) Process(c *gin.Context) {
 		return
 	}
 
-	// Verify order belongs to user
+	// Verify the user can edit the order
 	order, err := h.dbClient.GetOrder(orderID, userID)
 	if err != nil {
 		c.JSON(http.StatusNotFound, models.ErrorResponse{
@@ -119,6 +122,9 @@ func (h *ProcessHandler) Process(c *gin.Context) {
 		})
 		return
 	}
+	if !authorizeOrder(c, h.dbClient, order, userID, models.RoleEditor) {
+		return
+	}
 
 	var req models.ProcessRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -241,7 +247,7 @@ func (h *ProcessHandler) Process(c *gin.Context) {
 	}
 
 	// Update order status
-	h.dbClient.UpdateOrderStatus(orderID, "processing", 0)
+	h.dispatcher.PublishStatus(orderID, "processing", 0, &events.ProcessingStarted{})
 
 	// Sync AutoEnhance data to database (status, is_processing, etc.)
 	if processResult != nil {
@@ -261,10 +267,6 @@ func (h *ProcessHandler) Process(c *gin.Context) {
 		)
 	}
 
-	// Publish processing_started event
-	h.realtimeClient.PublishOrderEvent(orderID, "processing_started",
-		supabase.ProcessingStartedPayload(orderID, ""))
-
 	// Calculate total brackets from all image groups
 	totalBrackets := 0
 	for _, group := range imageGroups {
@@ -303,6 +305,7 @@ func (h *ProcessHandler) Process(c *gin.Context) {
 		ProcessingParams: processingParams,
 	}
 
+	recordAudit(c, h.dbClient, orderAudit(models.AuditOrderProcess, order), processingParams)
 	c.JSON(http.StatusOK, response)
 }
 
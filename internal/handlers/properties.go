@@ -0,0 +1,353 @@
+package handlers
+
+import (
+	"database/sql"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"instant-hdr-backend/internal/middleware"
+	"instant-hdr-backend/internal/models"
+	"instant-hdr-backend/internal/repository"
+)
+
+type PropertiesHandler struct {
+	dbClient repository.Repository
+}
+
+func NewPropertiesHandler(dbClient repository.Repository) *PropertiesHandler {
+	return &PropertiesHandler{
+		dbClient: dbClient,
+	}
+}
+
+// CreateProperty godoc
+// @Summary     Create a property
+// @Description Creates a property (listing) that orders can be linked to. Address is required.
+// @Tags        properties
+// @Accept      json
+// @Produce     json
+// @Security    Bearer
+// @Param       request body models.PropertyRequest true "Property"
+// @Success     201 {object} models.PropertyResponse
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     401 {object} models.ErrorResponse
+// @Failure     500 {object} models.ErrorResponse
+// @Router      /properties [post]
+func (h *PropertiesHandler) CreateProperty(c *gin.Context) {
+	userID, ok := h.userID(c)
+	if !ok {
+		return
+	}
+
+	var req models.PropertyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid request body", Message: err.Error()})
+		return
+	}
+	if req.Address == nil || strings.TrimSpace(*req.Address) == "" {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "address is required"})
+		return
+	}
+
+	property := &models.Property{UserID: userID}
+	applyPropertyRequest(property, req)
+
+	if err := h.dbClient.CreateProperty(property); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "failed to create property",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	recordAudit(c, h.dbClient, models.AuditEntry{Action: models.AuditPropertyCreate}, gin.H{"property_id": property.ID})
+	c.JSON(http.StatusCreated, propertyResponse(*property))
+}
+
+// ListProperties godoc
+// @Summary     List properties
+// @Description Returns the authenticated user's properties, newest first
+// @Tags        properties
+// @Accept      json
+// @Produce     json
+// @Security    Bearer
+// @Success     200 {object} models.PropertyListResponse
+// @Failure     401 {object} models.ErrorResponse
+// @Failure     500 {object} models.ErrorResponse
+// @Router      /properties [get]
+func (h *PropertiesHandler) ListProperties(c *gin.Context) {
+	userID, ok := h.userID(c)
+	if !ok {
+		return
+	}
+
+	properties, err := h.dbClient.ListProperties(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "failed to list properties",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	response := models.PropertyListResponse{Properties: make([]models.PropertyResponse, len(properties))}
+	for i, property := range properties {
+		response.Properties[i] = propertyResponse(property)
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// GetProperty godoc
+// @Summary     Get a property with its orders
+// @Description Returns the property, its orders (from the cached AutoEnhance data) and a delivery summary.
+// @Description An image counts as delivered once it has been downloaded without watermark.
+// @Tags        properties
+// @Accept      json
+// @Produce     json
+// @Security    Bearer
+// @Param       property_id path string true "Property ID (UUID)"
+// @Success     200 {object} models.PropertyDetailResponse
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     401 {object} models.ErrorResponse
+// @Failure     404 {object} models.ErrorResponse
+// @Failure     500 {object} models.ErrorResponse
+// @Router      /properties/{property_id} [get]
+func (h *PropertiesHandler) GetProperty(c *gin.Context) {
+	userID, ok := h.userID(c)
+	if !ok {
+		return
+	}
+	property, ok := h.property(c, userID)
+	if !ok {
+		return
+	}
+
+	orders, err := h.dbClient.ListOrders(userID, models.OrderListOptions{
+		PropertyID: uuid.NullUUID{UUID: property.ID, Valid: true},
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "failed to list orders",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	response := models.PropertyDetailResponse{
+		PropertyResponse: propertyResponse(*property),
+		Orders:           make([]models.PropertyOrder, 0, len(orders)),
+		Summary: models.PropertySummary{
+			OrderCount:     len(orders),
+			OrdersByStatus: make(map[string]int),
+		},
+	}
+
+	processing := false
+	for _, order := range orders {
+		files, err := h.dbClient.GetOrderFilesByOrderID(order.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error:   "failed to get order files",
+				Message: err.Error(),
+			})
+			return
+		}
+
+		delivered := deliveredImages(files)
+		response.Orders = append(response.Orders, models.PropertyOrder{
+			OrderSummary:    orderSummary(order),
+			DeliveredImages: delivered,
+		})
+		response.Summary.OrdersByStatus[order.Status]++
+		response.Summary.TotalImages += order.TotalImages
+		response.Summary.DeliveredImages += delivered
+		processing = processing || order.IsProcessing
+	}
+
+	summary := &response.Summary
+	switch {
+	case summary.OrderCount == 0:
+		summary.DeliveryStatus = models.DeliveryNoOrders
+	case processing:
+		summary.DeliveryStatus = models.DeliveryProcessing
+	case summary.TotalImages > 0 && summary.DeliveredImages >= summary.TotalImages:
+		summary.DeliveryStatus = models.DeliveryDelivered
+	case summary.DeliveredImages > 0:
+		summary.DeliveryStatus = models.DeliveryPartial
+	default:
+		summary.DeliveryStatus = models.DeliveryPending
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// UpdateProperty godoc
+// @Summary     Update a property
+// @Description Updates the given fields. Omitted fields are left unchanged; "" clears an optional field.
+// @Tags        properties
+// @Accept      json
+// @Produce     json
+// @Security    Bearer
+// @Param       property_id path string                true "Property ID (UUID)"
+// @Param       request     body models.PropertyRequest true "Fields to change"
+// @Success     200 {object} models.PropertyResponse
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     401 {object} models.ErrorResponse
+// @Failure     404 {object} models.ErrorResponse
+// @Failure     500 {object} models.ErrorResponse
+// @Router      /properties/{property_id} [patch]
+func (h *PropertiesHandler) UpdateProperty(c *gin.Context) {
+	userID, ok := h.userID(c)
+	if !ok {
+		return
+	}
+	property, ok := h.property(c, userID)
+	if !ok {
+		return
+	}
+
+	var req models.PropertyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid request body", Message: err.Error()})
+		return
+	}
+	if req.Address != nil && strings.TrimSpace(*req.Address) == "" {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "address cannot be empty"})
+		return
+	}
+
+	applyPropertyRequest(property, req)
+	if err := h.dbClient.UpdateProperty(property); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "failed to update property",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	recordAudit(c, h.dbClient, models.AuditEntry{Action: models.AuditPropertyUpdate}, gin.H{"property_id": property.ID})
+	c.JSON(http.StatusOK, propertyResponse(*property))
+}
+
+// DeleteProperty godoc
+// @Summary     Delete a property
+// @Description Deletes the property. Its orders are kept and unlinked.
+// @Tags        properties
+// @Accept      json
+// @Produce     json
+// @Security    Bearer
+// @Param       property_id path string true "Property ID (UUID)"
+// @Success     200 {object} map[string]string "message"
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     401 {object} models.ErrorResponse
+// @Failure     404 {object} models.ErrorResponse
+// @Failure     500 {object} models.ErrorResponse
+// @Router      /properties/{property_id} [delete]
+func (h *PropertiesHandler) DeleteProperty(c *gin.Context) {
+	userID, ok := h.userID(c)
+	if !ok {
+		return
+	}
+	property, ok := h.property(c, userID)
+	if !ok {
+		return
+	}
+
+	if err := h.dbClient.DeleteProperty(property.ID, userID); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "failed to delete property",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	recordAudit(c, h.dbClient, models.AuditEntry{Action: models.AuditPropertyDelete}, gin.H{
+		"property_id": property.ID, "address": property.Address,
+	})
+	c.JSON(http.StatusOK, gin.H{"message": "property deleted successfully"})
+}
+
+// userID checks the database and returns the authenticated user, or writes the error response
+func (h *PropertiesHandler) userID(c *gin.Context) (uuid.UUID, bool) {
+	if h.dbClient == nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "database not available"})
+		return uuid.Nil, false
+	}
+
+	userIDStr, exists := c.Get(middleware.UserIDKey)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{Error: "user id not found"})
+		return uuid.Nil, false
+	}
+
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid user id"})
+		return uuid.Nil, false
+	}
+	return userID, true
+}
+
+// property loads the :property_id of the request, or writes the error response
+func (h *PropertiesHandler) property(c *gin.Context, userID uuid.UUID) (*models.Property, bool) {
+	propertyID, err := uuid.Parse(c.Param("property_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid property id"})
+		return nil, false
+	}
+
+	property, err := h.dbClient.GetProperty(propertyID, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "property not found",
+			Message: err.Error(),
+		})
+		return nil, false
+	}
+	return property, true
+}
+
+func applyPropertyRequest(property *models.Property, req models.PropertyRequest) {
+	if req.Address != nil {
+		property.Address = strings.TrimSpace(*req.Address)
+	}
+	if req.MLSNumber != nil {
+		property.MLSNumber = optionalString(*req.MLSNumber)
+	}
+	if req.ClientName != nil {
+		property.ClientName = optionalString(*req.ClientName)
+	}
+	if req.Notes != nil {
+		property.Notes = optionalString(*req.Notes)
+	}
+}
+
+func optionalString(value string) sql.NullString {
+	value = strings.TrimSpace(value)
+	return sql.NullString{String: value, Valid: value != ""}
+}
+
+func propertyResponse(property models.Property) models.PropertyResponse {
+	return models.PropertyResponse{
+		ID:         property.ID.String(),
+		Address:    property.Address,
+		MLSNumber:  property.MLSNumber.String,
+		ClientName: property.ClientName.String,
+		Notes:      property.Notes.String,
+		CreatedAt:  property.CreatedAt,
+		UpdatedAt:  property.UpdatedAt,
+	}
+}
+
+// deliveredImages counts the distinct AutoEnhance images stored without watermark
+func deliveredImages(files []models.OrderFile) int {
+	delivered := make(map[string]bool)
+	for _, file := range files {
+		if file.AutoEnhanceImageID.Valid && file.Watermark.Valid && !file.Watermark.Bool {
+			delivered[file.AutoEnhanceImageID.String] = true
+		}
+	}
+	return len(delivered)
+}
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"instant-hdr-backend/internal/middleware"
+	"instant-hdr-backend/internal/models"
+	"instant-hdr-backend/internal/services"
+)
+
+type RetentionHandler struct {
+	janitor *services.Janitor
+}
+
+func NewRetentionHandler(janitor *services.Janitor) *RetentionHandler {
+	return &RetentionHandler{
+		janitor: janitor,
+	}
+}
+
+// GetReport godoc
+// @Summary     Storage retention dry-run report
+// @Description Returns what the storage janitor would delete for the authenticated user under the current retention policy, without deleting anything.
+// @Description Watermarked previews older than RETENTION_PREVIEW_DAYS expire; unwatermarked (paid) files are kept.
+// @Description Storage of orders deleted more than RETENTION_DELETED_ORDER_DAYS ago is purged.
+// @Tags        retention
+// @Accept      json
+// @Produce     json
+// @Security    Bearer
+// @Success     200 {object} models.RetentionReport
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     401 {object} models.ErrorResponse
+// @Failure     500 {object} models.ErrorResponse
+// @Router      /retention/report [get]
+func (h *RetentionHandler) GetReport(c *gin.Context) {
+	if h.janitor == nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "database not available"})
+		return
+	}
+
+	userIDStr, exists := c.Get(middleware.UserIDKey)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{Error: "user id not found"})
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid user id"})
+		return
+	}
+
+	report := h.janitor.Run(true, uuid.NullUUID{UUID: userID, Valid: true})
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,247 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"instant-hdr-backend/internal/middleware"
+	"instant-hdr-backend/internal/models"
+	"instant-hdr-backend/internal/repository"
+)
+
+const maxSavedFilterName = 100
+
+// savedFilterParams are the GET /orders parameters a saved filter may set. Cursors belong to one listing and are never saved.
+var savedFilterParams = map[string]bool{
+	"sort": true, "direction": true, "limit": true, "status": true, "is_processing": true, "name": true,
+	"tag": true, "property_id": true, "organization_id": true, "created_after": true, "created_before": true,
+}
+
+type SavedFiltersHandler struct {
+	dbClient repository.Repository
+}
+
+func NewSavedFiltersHandler(dbClient repository.Repository) *SavedFiltersHandler {
+	return &SavedFiltersHandler{
+		dbClient: dbClient,
+	}
+}
+
+// CreateSavedFilter godoc
+// @Summary     Save an order filter
+// @Description Saves a named GET /orders query string, e.g. "tag=rush,twilight&status=processing". Apply it with GET /orders?saved_filter={filter_id};
+// @Description parameters given with the request override the saved ones. Names are unique per user.
+// @Tags        saved-filters
+// @Accept      json
+// @Produce     json
+// @Security    Bearer
+// @Param       request body models.SavedFilterRequest true "Saved filter"
+// @Success     201 {object} models.SavedFilterResponse
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     401 {object} models.ErrorResponse
+// @Failure     409 {object} models.ErrorResponse
+// @Failure     500 {object} models.ErrorResponse
+// @Router      /saved-filters [post]
+func (h *SavedFiltersHandler) CreateSavedFilter(c *gin.Context) {
+	userID, ok := h.userID(c)
+	if !ok {
+		return
+	}
+
+	var req models.SavedFilterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid request body", Message: err.Error()})
+		return
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" || len(name) > maxSavedFilterName {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "invalid request body",
+			Message: fmt.Sprintf("name must be between 1 and %d characters", maxSavedFilterName),
+		})
+		return
+	}
+	query, err := normalizeSavedFilterQuery(req.Query)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid query", Message: err.Error()})
+		return
+	}
+
+	existing, err := h.dbClient.ListSavedFilters(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "failed to list saved filters",
+			Message: err.Error(),
+		})
+		return
+	}
+	for _, filter := range existing {
+		if filter.Name == name {
+			c.JSON(http.StatusConflict, models.ErrorResponse{
+				Error:   "saved filter already exists",
+				Message: fmt.Sprintf("a filter named %q already exists", name),
+			})
+			return
+		}
+	}
+
+	filter := models.SavedFilter{UserID: userID, Name: name, Query: query}
+	if err := h.dbClient.CreateSavedFilter(&filter); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "failed to create saved filter",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	recordAudit(c, h.dbClient, models.AuditEntry{Action: models.AuditSavedFilterCreate}, gin.H{
+		"filter_id": filter.ID, "name": filter.Name, "query": filter.Query,
+	})
+	c.JSON(http.StatusCreated, savedFilterResponse(filter))
+}
+
+// ListSavedFilters godoc
+// @Summary     List saved order filters
+// @Description Returns the authenticated user's saved filters by name
+// @Tags        saved-filters
+// @Accept      json
+// @Produce     json
+// @Security    Bearer
+// @Success     200 {object} models.SavedFilterListResponse
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     401 {object} models.ErrorResponse
+// @Failure     500 {object} models.ErrorResponse
+// @Router      /saved-filters [get]
+func (h *SavedFiltersHandler) ListSavedFilters(c *gin.Context) {
+	userID, ok := h.userID(c)
+	if !ok {
+		return
+	}
+
+	filters, err := h.dbClient.ListSavedFilters(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "failed to list saved filters",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	response := models.SavedFilterListResponse{Filters: make([]models.SavedFilterResponse, len(filters))}
+	for i, filter := range filters {
+		response.Filters[i] = savedFilterResponse(filter)
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// DeleteSavedFilter godoc
+// @Summary     Delete a saved order filter
+// @Tags        saved-filters
+// @Accept      json
+// @Produce     json
+// @Security    Bearer
+// @Param       filter_id path string true "Saved filter ID (UUID)"
+// @Success     200 {object} map[string]string
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     401 {object} models.ErrorResponse
+// @Failure     404 {object} models.ErrorResponse
+// @Failure     500 {object} models.ErrorResponse
+// @Router      /saved-filters/{filter_id} [delete]
+func (h *SavedFiltersHandler) DeleteSavedFilter(c *gin.Context) {
+	userID, ok := h.userID(c)
+	if !ok {
+		return
+	}
+
+	filterID, err := uuid.Parse(c.Param("filter_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid saved filter id"})
+		return
+	}
+	filter, err := h.dbClient.GetSavedFilter(filterID, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "saved filter not found",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	if err := h.dbClient.DeleteSavedFilter(filterID, userID); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "failed to delete saved filter",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	recordAudit(c, h.dbClient, models.AuditEntry{Action: models.AuditSavedFilterDelete}, gin.H{
+		"filter_id": filter.ID, "name": filter.Name,
+	})
+	c.JSON(http.StatusOK, gin.H{"message": "saved filter deleted successfully"})
+}
+
+func (h *SavedFiltersHandler) userID(c *gin.Context) (uuid.UUID, bool) {
+	if h.dbClient == nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "database not available"})
+		return uuid.Nil, false
+	}
+
+	userIDStr, exists := c.Get(middleware.UserIDKey)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{Error: "user id not found"})
+		return uuid.Nil, false
+	}
+
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid user id"})
+		return uuid.Nil, false
+	}
+	return userID, true
+}
+
+// normalizeSavedFilterQuery checks a query string the way GET /orders would and re-encodes it
+func normalizeSavedFilterQuery(query string) (string, error) {
+	values, err := url.ParseQuery(strings.TrimPrefix(query, "?"))
+	if err != nil {
+		return "", fmt.Errorf("query must be a URL query string")
+	}
+	for key := range values {
+		if !savedFilterParams[key] {
+			return "", fmt.Errorf("%s can't be saved in a filter", key)
+		}
+	}
+	if _, err := parseOrderListOptions(values, false); err != nil {
+		return "", err
+	}
+	return values.Encode(), nil
+}
+
+// applySavedFilter adds the saved parameters to a GET /orders query. Parameters already in the query win.
+func applySavedFilter(query url.Values, filter *models.SavedFilter) error {
+	saved, err := url.ParseQuery(filter.Query)
+	if err != nil {
+		return err
+	}
+	for key, values := range saved {
+		if !query.Has(key) {
+			query[key] = values
+		}
+	}
+	return nil
+}
+
+func savedFilterResponse(filter models.SavedFilter) models.SavedFilterResponse {
+	return models.SavedFilterResponse{
+		ID:        filter.ID.String(),
+		Name:      filter.Name,
+		Query:     filter.Query,
+		CreatedAt: filter.CreatedAt,
+		UpdatedAt: filter.UpdatedAt,
+	}
+}
@@ -9,15 +9,15 @@ import (
 	"instant-hdr-backend/internal/autoenhance"
 	"instant-hdr-backend/internal/middleware"
 	"instant-hdr-backend/internal/models"
-	"instant-hdr-backend/internal/supabase"
+	"instant-hdr-backend/internal/repository"
 )
 
 type StatusHandler struct {
-	dbClient          *supabase.DatabaseClient
+	dbClient          repository.Repository
 	autoenhanceClient *autoenhance.Client
 }
 
-func NewStatusHandler(dbClient *supabase.DatabaseClient, autoenhanceClient *autoenhance.Client) *StatusHandler {
+func NewStatusHandler(dbClient repository.Repository, autoenhanceClient *autoenhance.Client) *StatusHandler {
 	return &StatusHandler{
 		dbClient:          dbClient,
 		autoenhanceClient: autoenhanceClient,
@@ -26,7 +26,7 @@ func NewStatusHandler(dbClient *supabase.DatabaseClient, autoenhanceClient *auto
 
 // GetStatus godoc
 // @Summary     Get order status
-// @Description Returns the current status and progress of an order. For real-time updates, connect to Supabase Realtime.
+// @Description Returns the current status and progress of an order. For real-time updates, connect to Supabase Realtime or stream GET /orders/{order_id}/events.
 // @Tags        status
 // @Accept      json
 // @Produce     json
@@ -0,0 +1,440 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"instant-hdr-backend/internal/authz"
+	"instant-hdr-backend/internal/autoenhance"
+	"instant-hdr-backend/internal/middleware"
+	"instant-hdr-backend/internal/models"
+	"instant-hdr-backend/internal/repository"
+)
+
+const (
+	maxTagLength     = 50
+	maxTags          = 50  // Per order and per image
+	maxBulkTagOrders = 100 // Orders per bulk request
+)
+
+type TagsHandler struct {
+	autoenhanceClient *autoenhance.Client
+	dbClient          repository.Repository
+}
+
+func NewTagsHandler(autoenhanceClient *autoenhance.Client, dbClient repository.Repository) *TagsHandler {
+	return &TagsHandler{
+		autoenhanceClient: autoenhanceClient,
+		dbClient:          dbClient,
+	}
+}
+
+// AddOrderTags godoc
+// @Summary     Tag an order
+// @Description Adds tags to an order. Tags are lowercased, at most 50 characters and may not contain commas; an order carries at most 50 tags.
+// @Description Requires the editor role on organization orders.
+// @Tags        tags
+// @Accept      json
+// @Produce     json
+// @Security    Bearer
+// @Param       order_id path string true "Order ID (UUID)"
+// @Param       request body models.TagsRequest true "Tags to add"
+// @Success     200 {object} models.TagsResponse
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     401 {object} models.ErrorResponse
+// @Failure     403 {object} models.ErrorResponse
+// @Failure     404 {object} models.ErrorResponse
+// @Failure     500 {object} models.ErrorResponse
+// @Router      /orders/{order_id}/tags [post]
+func (h *TagsHandler) AddOrderTags(c *gin.Context) {
+	userID, ok := h.userID(c)
+	if !ok {
+		return
+	}
+	order, ok := h.order(c, userID)
+	if !ok {
+		return
+	}
+
+	tags, ok := bindTags(c)
+	if !ok {
+		return
+	}
+	if n := len(mergeTags(order.Tags, tags)); n > maxTags {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "too many tags",
+			Message: fmt.Sprintf("an order can carry at most %d tags", maxTags),
+		})
+		return
+	}
+
+	h.updateOrderTags(c, order, tags, nil)
+}
+
+// RemoveOrderTag godoc
+// @Summary     Untag an order
+// @Description Removes a tag from an order. Removing a tag the order doesn't carry is not an error.
+// @Description Requires the editor role on organization orders.
+// @Tags        tags
+// @Accept      json
+// @Produce     json
+// @Security    Bearer
+// @Param       order_id path string true "Order ID (UUID)"
+// @Param       tag      path string true "Tag"
+// @Success     200 {object} models.TagsResponse
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     401 {object} models.ErrorResponse
+// @Failure     403 {object} models.ErrorResponse
+// @Failure     404 {object} models.ErrorResponse
+// @Failure     500 {object} models.ErrorResponse
+// @Router      /orders/{order_id}/tags/{tag} [delete]
+func (h *TagsHandler) RemoveOrderTag(c *gin.Context) {
+	userID, ok := h.userID(c)
+	if !ok {
+		return
+	}
+	order, ok := h.order(c, userID)
+	if !ok {
+		return
+	}
+
+	h.updateOrderTags(c, order, nil, []string{normalizeTag(c.Param("tag"))})
+}
+
+// BulkTagOrders godoc
+// @Summary     Tag several orders
+// @Description Adds and removes tags on up to 100 orders. Every order is reported: orders that were not found,
+// @Description that the user may not edit or that would exceed 50 tags carry an error and are left unchanged.
+// @Tags        tags
+// @Accept      json
+// @Produce     json
+// @Security    Bearer
+// @Param       request body models.BulkTagRequest true "Orders and tags"
+// @Success     200 {object} models.BulkTagResponse
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     401 {object} models.ErrorResponse
+// @Failure     500 {object} models.ErrorResponse
+// @Router      /orders/tags [post]
+func (h *TagsHandler) BulkTagOrders(c *gin.Context) {
+	userID, ok := h.userID(c)
+	if !ok {
+		return
+	}
+
+	var req models.BulkTagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid request body", Message: err.Error()})
+		return
+	}
+	if len(req.OrderIDs) == 0 || len(req.OrderIDs) > maxBulkTagOrders {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "invalid request body",
+			Message: fmt.Sprintf("order_ids must list between 1 and %d orders", maxBulkTagOrders),
+		})
+		return
+	}
+	add, err := normalizeTags(req.Add)
+	if err == nil && len(req.Remove) > 0 {
+		req.Remove, err = normalizeTags(req.Remove)
+	}
+	if err == nil && len(add) == 0 && len(req.Remove) == 0 {
+		err = fmt.Errorf("add or remove at least one tag")
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid tags", Message: err.Error()})
+		return
+	}
+
+	response := models.BulkTagResponse{Orders: make([]models.BulkTagResult, 0, len(req.OrderIDs))}
+	for _, id := range req.OrderIDs {
+		result := models.BulkTagResult{OrderID: id}
+		result.Tags, err = h.bulkTagOrder(c, id, userID, apiKeyOrganization(c), add, req.Remove)
+		if err != nil {
+			result.Error = err.Error()
+		}
+		response.Orders = append(response.Orders, result)
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// bulkTagOrder applies one order of a bulk request. Its errors are reported to the client.
+// Organization API keys (keyOrganization) only reach the orders of their organization.
+func (h *TagsHandler) bulkTagOrder(c *gin.Context, id string, userID uuid.UUID, keyOrganization uuid.NullUUID, add, remove []string) ([]string, error) {
+	orderID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid order id")
+	}
+	order, err := h.dbClient.GetOrder(orderID, userID)
+	if err != nil || (keyOrganization.Valid && order.OrganizationID != keyOrganization) {
+		return nil, fmt.Errorf("order not found")
+	}
+
+	err = authz.RequireOrderRole(h.dbClient, order, userID, models.RoleEditor)
+	var forbidden *authz.ForbiddenError
+	switch {
+	case errors.As(err, &forbidden):
+		return nil, forbidden
+	case errors.Is(err, authz.ErrNotMember):
+		return nil, fmt.Errorf("order not found")
+	case err != nil:
+		return nil, fmt.Errorf("failed to check access")
+	}
+
+	if n := len(mergeTags(order.Tags, add)); n > maxTags {
+		return nil, fmt.Errorf("an order can carry at most %d tags", maxTags)
+	}
+	tags, err := h.dbClient.UpdateOrderTags(orderID, add, remove)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update tags")
+	}
+	recordAudit(c, h.dbClient, orderAudit(models.AuditOrderTagsUpdate, order), gin.H{"add": add, "remove": remove, "bulk": true})
+	return nonNilTags(tags), nil
+}
+
+// AddImageTags godoc
+// @Summary     Tag an image
+// @Description Adds tags to a processed image of an order. Same rules as order tags.
+// @Description Requires the editor role on organization orders.
+// @Tags        tags
+// @Accept      json
+// @Produce     json
+// @Security    Bearer
+// @Param       order_id path string true "Order ID (UUID)"
+// @Param       image_id path string true "Image ID from AutoEnhance"
+// @Param       request body models.TagsRequest true "Tags to add"
+// @Success     200 {object} models.TagsResponse
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     401 {object} models.ErrorResponse
+// @Failure     403 {object} models.ErrorResponse
+// @Failure     404 {object} models.ErrorResponse
+// @Failure     500 {object} models.ErrorResponse
+// @Router      /orders/{order_id}/images/{image_id}/tags [post]
+func (h *TagsHandler) AddImageTags(c *gin.Context) {
+	userID, ok := h.userID(c)
+	if !ok {
+		return
+	}
+	order, ok := h.order(c, userID)
+	if !ok {
+		return
+	}
+
+	tags, ok := bindTags(c)
+	if !ok {
+		return
+	}
+
+	imageID := c.Param("image_id")
+	autoenhanceOrder, err := h.autoenhanceClient.GetOrder(order.ID.String())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "failed to get order from AutoEnhance",
+			Message: err.Error(),
+		})
+		return
+	}
+	if !slices.ContainsFunc(autoenhanceOrder.Images, func(img autoenhance.ImageOut) bool { return img.ImageID == imageID }) {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "image not found"})
+		return
+	}
+
+	existing, err := h.dbClient.ListImageTags(order.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "failed to load image tags",
+			Message: err.Error(),
+		})
+		return
+	}
+	if n := len(mergeTags(existing[imageID], tags)); n > maxTags {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "too many tags",
+			Message: fmt.Sprintf("an image can carry at most %d tags", maxTags),
+		})
+		return
+	}
+
+	h.updateImageTags(c, order, imageID, tags, nil)
+}
+
+// RemoveImageTag godoc
+// @Summary     Untag an image
+// @Description Removes a tag from an image. Removing a tag the image doesn't carry is not an error.
+// @Description Requires the editor role on organization orders.
+// @Tags        tags
+// @Accept      json
+// @Produce     json
+// @Security    Bearer
+// @Param       order_id path string true "Order ID (UUID)"
+// @Param       image_id path string true "Image ID from AutoEnhance"
+// @Param       tag      path string true "Tag"
+// @Success     200 {object} models.TagsResponse
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     401 {object} models.ErrorResponse
+// @Failure     403 {object} models.ErrorResponse
+// @Failure     404 {object} models.ErrorResponse
+// @Failure     500 {object} models.ErrorResponse
+// @Router      /orders/{order_id}/images/{image_id}/tags/{tag} [delete]
+func (h *TagsHandler) RemoveImageTag(c *gin.Context) {
+	userID, ok := h.userID(c)
+	if !ok {
+		return
+	}
+	order, ok := h.order(c, userID)
+	if !ok {
+		return
+	}
+
+	h.updateImageTags(c, order, c.Param("image_id"), nil, []string{normalizeTag(c.Param("tag"))})
+}
+
+func (h *TagsHandler) updateOrderTags(c *gin.Context, order *models.Order, add, remove []string) {
+	tags, err := h.dbClient.UpdateOrderTags(order.ID, add, remove)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "failed to update tags",
+			Message: err.Error(),
+		})
+		return
+	}
+	recordAudit(c, h.dbClient, orderAudit(models.AuditOrderTagsUpdate, order), gin.H{"add": add, "remove": remove})
+	c.JSON(http.StatusOK, models.TagsResponse{Tags: nonNilTags(tags)})
+}
+
+func (h *TagsHandler) updateImageTags(c *gin.Context, order *models.Order, imageID string, add, remove []string) {
+	tags, err := h.dbClient.UpdateImageTags(order.ID, imageID, add, remove)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "failed to update tags",
+			Message: err.Error(),
+		})
+		return
+	}
+	entry := orderAudit(models.AuditImageTagsUpdate, order)
+	entry.ImageID = imageID
+	recordAudit(c, h.dbClient, entry, gin.H{"add": add, "remove": remove})
+	c.JSON(http.StatusOK, models.TagsResponse{Tags: nonNilTags(tags)})
+}
+
+func (h *TagsHandler) userID(c *gin.Context) (uuid.UUID, bool) {
+	if h.dbClient == nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "database not available"})
+		return uuid.Nil, false
+	}
+
+	userIDStr, exists := c.Get(middleware.UserIDKey)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{Error: "user id not found"})
+		return uuid.Nil, false
+	}
+
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid user id"})
+		return uuid.Nil, false
+	}
+	return userID, true
+}
+
+// order loads the order from the path and checks that the user may edit it
+func (h *TagsHandler) order(c *gin.Context, userID uuid.UUID) (*models.Order, bool) {
+	orderID, err := uuid.Parse(c.Param("order_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid order id"})
+		return nil, false
+	}
+
+	order, err := h.dbClient.GetOrder(orderID, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "order not found",
+			Message: err.Error(),
+		})
+		return nil, false
+	}
+	if !authorizeOrder(c, h.dbClient, order, userID, models.RoleEditor) {
+		return nil, false
+	}
+	return order, true
+}
+
+func bindTags(c *gin.Context) ([]string, bool) {
+	var req models.TagsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid request body", Message: err.Error()})
+		return nil, false
+	}
+	tags, err := normalizeTags(req.Tags)
+	if err == nil && len(tags) == 0 {
+		err = fmt.Errorf("tags must not be empty")
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid tags", Message: err.Error()})
+		return nil, false
+	}
+	return tags, true
+}
+
+func normalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimSpace(tag))
+}
+
+// normalizeTags lowercases and trims tags, drops duplicates and sorts them. Commas are rejected
+// because ?tag= uses them as the separator.
+func normalizeTags(tags []string) ([]string, error) {
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = normalizeTag(tag)
+		switch {
+		case tag == "":
+			return nil, fmt.Errorf("tags must not be empty")
+		case utf8.RuneCountInString(tag) > maxTagLength:
+			return nil, fmt.Errorf("tag %q is longer than %d characters", tag, maxTagLength)
+		case strings.ContainsFunc(tag, func(r rune) bool { return r == ',' || unicode.IsControl(r) }):
+			return nil, fmt.Errorf("tag %q contains a comma or control character", tag)
+		}
+		if !slices.Contains(normalized, tag) {
+			normalized = append(normalized, tag)
+		}
+	}
+	sort.Strings(normalized)
+	return normalized, nil
+}
+
+// mergeTags returns the union of two tag lists
+func mergeTags(tags, add []string) []string {
+	merged := append([]string(nil), tags...)
+	for _, tag := range add {
+		if !slices.Contains(merged, tag) {
+			merged = append(merged, tag)
+		}
+	}
+	return merged
+}
+
+// containsAllTags reports whether tags includes every one of wanted
+func containsAllTags(tags, wanted []string) bool {
+	for _, tag := range wanted {
+		if !slices.Contains(tags, tag) {
+			return false
+		}
+	}
+	return true
+}
+
+// nonNilTags makes untagged orders and images serialize as [] rather than null
+func nonNilTags(tags []string) []string {
+	if tags == nil {
+		return []string{}
+	}
+	return tags
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"log"
 	"mime/multipart"
 	"net/http"
 	"strings"
@@ -13,22 +14,27 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"instant-hdr-backend/internal/autoenhance"
+	"instant-hdr-backend/internal/events"
 	"instant-hdr-backend/internal/middleware"
 	"instant-hdr-backend/internal/models"
-	"instant-hdr-backend/internal/supabase"
+	"instant-hdr-backend/internal/quota"
+	"instant-hdr-backend/internal/repository"
+	"instant-hdr-backend/internal/services"
 )
 
 type UploadHandler struct {
 	autoenhanceClient *autoenhance.Client
-	dbClient          *supabase.DatabaseClient
-	realtimeClient    *supabase.RealtimeClient
+	dbClient          repository.Repository
+	dispatcher        *services.EventDispatcher
+	quotaService      *quota.Service
 }
 
-func NewUploadHandler(autoenhanceClient *autoenhance.Client, dbClient *supabase.DatabaseClient, realtimeClient *supabase.RealtimeClient) *UploadHandler {
+func NewUploadHandler(autoenhanceClient *autoenhance.Client, dbClient repository.Repository, dispatcher *services.EventDispatcher, quotaService *quota.Service) *UploadHandler {
 	return &UploadHandler{
 		autoenhanceClient: autoenhanceClient,
 		dbClient:          dbClient,
-		realtimeClient:    realtimeClient,
+		dispatcher:        dispatcher,
+		quotaService:      quotaService,
 	}
 }
 
@@ -60,7 +66,9 @@ func NewUploadHandler(autoenhanceClient *autoenhance.Client, dbClient *supabase.
 // @Success     200 {object} models.UploadResponse
 // @Failure     400 {object} models.ErrorResponse
 // @Failure     401 {object} models.ErrorResponse
+// @Failure     403 {object} models.QuotaExceededResponse
 // @Failure     404 {object} models.ErrorResponse
+// @Failure     413 {object} models.QuotaExceededResponse
 // @Failure     500 {object} models.ErrorResponse
 // @Router      /orders/{order_id}/upload [post]
 func (h *UploadHandler) Upload(c *gin.Context) {
@@ -88,7 +96,7 @@ func (h *UploadHandler) Upload(c *gin.Context) {
 		return
 	}
 
-	// Verify order belongs to user
+	// Verify the user can edit the order
 	order, err := h.dbClient.GetOrder(orderID, userID)
 	if err != nil {
 		c.JSON(http.StatusNotFound, models.ErrorResponse{
@@ -97,6 +105,9 @@ func (h *UploadHandler) Upload(c *gin.Context) {
 		})
 		return
 	}
+	if !authorizeOrder(c, h.dbClient, order, userID, models.RoleEditor) {
+		return
+	}
 
 	// Set max memory for multipart form (32MB)
 	err = c.Request.ParseMultipartForm(32 << 20)
@@ -172,12 +183,19 @@ func (h *UploadHandler) Upload(c *gin.Context) {
 		}
 	}
 
-	// Publish upload_started event
-	h.realtimeClient.PublishOrderEvent(orderID, "upload_started",
-		supabase.UploadStartedPayload(orderID, len(files)))
+	// Enforce bracket and monthly upload limits before anything is sent to AutoEnhance
+	if h.quotaService != nil {
+		var totalBytes int64
+		for _, file := range files {
+			totalBytes += file.Size
+		}
+		if respondQuotaError(c, h.quotaService.CheckUpload(userID, orderID, len(files), totalBytes)) {
+			return
+		}
+	}
 
-	// Update status
-	h.dbClient.UpdateOrderStatus(orderID, "uploading", 0)
+	// Update status, with the upload_started event
+	h.dispatcher.PublishStatus(orderID, "uploading", 0, &events.UploadStarted{FileCount: len(files)})
 
 	// Create brackets and upload files
 	uploadedFiles := make([]models.FileInfo, 0)
@@ -380,12 +398,20 @@ func (h *UploadHandler) Upload(c *gin.Context) {
 		return
 	}
 
-	// Update status
-	h.dbClient.UpdateOrderStatus(orderID, "uploaded", 0)
+	var uploadedBytes int64
+	for _, file := range uploadedFiles {
+		uploadedBytes += file.Size
+	}
+
+	// Count only what actually reached AutoEnhance towards the monthly upload quota
+	if h.quotaService != nil {
+		if err := h.quotaService.RecordUpload(userID, orderID, uploadedBytes); err != nil {
+			log.Printf("[Quota] Failed to record upload usage for order %s: %v", orderID, err)
+		}
+	}
 
-	// Publish upload_completed event
-	h.realtimeClient.PublishOrderEvent(orderID, "upload_completed",
-		supabase.UploadCompletedPayload(orderID, len(uploadedFiles)))
+	// Update status, with the upload_completed event
+	h.dispatcher.PublishStatus(orderID, "uploaded", 0, &events.UploadCompleted{FileCount: len(uploadedFiles)})
 
 	// Include errors in response if any files failed
 	response := models.UploadResponse{
@@ -404,5 +430,8 @@ func (h *UploadHandler) Upload(c *gin.Context) {
 		h.dbClient.UpdateOrderError(orderID, errorMsg)
 	}
 
+	recordAudit(c, h.dbClient, orderAudit(models.AuditOrderUpload, order), gin.H{
+		"files": len(uploadedFiles), "bytes": uploadedBytes, "failed": len(uploadErrors),
+	})
 	c.JSON(http.StatusOK, response)
 }
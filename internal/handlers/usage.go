@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"instant-hdr-backend/internal/middleware"
+	"instant-hdr-backend/internal/models"
+	"instant-hdr-backend/internal/quota"
+)
+
+type UsageHandler struct {
+	quotaService *quota.Service
+}
+
+func NewUsageHandler(quotaService *quota.Service) *UsageHandler {
+	return &UsageHandler{
+		quotaService: quotaService,
+	}
+}
+
+// GetUsage godoc
+// @Summary     Get current quota usage
+// @Description Returns the authenticated user's plan, its limits and current consumption: active orders, bytes uploaded this calendar month (UTC) and bytes stored.
+// @Description A limit of 0 means unlimited. Limits are only enforced when QUOTA_ENABLED is set (see "enforced").
+// @Tags        usage
+// @Accept      json
+// @Produce     json
+// @Security    Bearer
+// @Success     200 {object} models.UsageResponse
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     401 {object} models.ErrorResponse
+// @Failure     500 {object} models.ErrorResponse
+// @Router      /me/usage [get]
+func (h *UsageHandler) GetUsage(c *gin.Context) {
+	if h.quotaService == nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "database not available"})
+		return
+	}
+
+	userIDStr, exists := c.Get(middleware.UserIDKey)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{Error: "user id not found"})
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid user id"})
+		return
+	}
+
+	plan, usage, err := h.quotaService.Usage(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "failed to get usage",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, usageResponse(h.quotaService, plan, usage))
+}
+
+func usageResponse(quotaService *quota.Service, plan quota.Plan, usage *quota.Usage) models.UsageResponse {
+	return models.UsageResponse{
+		Plan:         plan.Name,
+		Enforced:     quotaService.Enabled(),
+		PeriodStart:  quotaService.PeriodStart(),
+		ActiveOrders: models.UsageCounter{Used: int64(usage.ActiveOrders), Limit: int64(plan.MaxActiveOrders)},
+		UploadBytes:  models.UsageCounter{Used: usage.UploadedBytesThisMonth, Limit: plan.MaxUploadBytesPerMonth},
+		StoredBytes:  models.UsageCounter{Used: usage.StoredBytes, Limit: plan.MaxStoredBytes},
+		Brackets:     models.UsageLimit{Limit: int64(plan.MaxBracketsPerOrder)},
+	}
+}
+
+// respondQuotaError writes a quota_exceeded response for limit errors and a 500 for anything else.
+// It reports whether a response was written (false when err is nil).
+func respondQuotaError(c *gin.Context, err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var exceeded *quota.ExceededError
+	if errors.As(err, &exceeded) {
+		c.JSON(exceeded.StatusCode(), models.QuotaExceededResponse{
+			Error:     "quota_exceeded",
+			Message:   exceeded.Error(),
+			Limit:     exceeded.Limit,
+			Plan:      exceeded.Plan,
+			Used:      exceeded.Used,
+			Max:       exceeded.Max,
+			Requested: exceeded.Requested,
+		})
+		return true
+	}
+
+	c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+		Error:   "failed to check quota",
+		Message: err.Error(),
+	})
+	return true
+}
@@ -11,9 +11,9 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"instant-hdr-backend/internal/config"
+	"instant-hdr-backend/internal/events"
 	"instant-hdr-backend/internal/models"
 	"instant-hdr-backend/internal/services"
-	"instant-hdr-backend/internal/supabase"
 )
 
 type WebhookHandler struct {
@@ -138,21 +138,14 @@ func (h *WebhookHandler) HandleWebhook(c *gin.Context) {
 		if err == nil && h.storageService != nil {
 			// Publish EVERY webhook event to frontend immediately
 			// Frontend can track individual image processing progress
-			webhookPayload := supabase.WebhookEventPayload(
-				event.OrderID,
-				event.ImageID,
-				event.Error,
-				event.OrderIsProcessing,
-			)
-			
-			// Publish to realtime channel (async, don't block webhook response)
-			go func() {
-				_ = h.storageService.GetRealtimeClient().PublishOrderEvent(
-					orderID,
-					"webhook_image_processed",
-					webhookPayload,
-				)
-			}()
+			processed := &events.ImageProcessed{
+				ImageID:           event.ImageID,
+				Error:             event.Error,
+				OrderIsProcessing: event.OrderIsProcessing,
+			}
+
+			// Store and publish to realtime channel (async, don't block webhook response)
+			go h.storageService.PublishOrderEvent(orderID, processed)
 		}
 
 		// Handle business logic based on webhook data
@@ -0,0 +1,137 @@
+package imaging
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"math"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp" // Register WebP decoder for source images
+)
+
+// Output formats that can be encoded locally.
+// WebP has no pure-Go encoder, so WebP variants still come from AutoEnhance.
+const (
+	FormatJPEG = "jpeg"
+	FormatPNG  = "png"
+	FormatWebP = "webp"
+)
+
+// JPEGQuality is used for all locally encoded JPEG derivatives
+const JPEGQuality = 90
+
+// Variant is a derivative size preset
+type Variant struct {
+	Name     string
+	MaxWidth int // 0 = full resolution
+}
+
+// Standard variants, largest first. "high" is the source every other variant is generated from.
+var (
+	VariantHigh      = Variant{Name: "high"}
+	VariantMedium    = Variant{Name: "medium", MaxWidth: 1920}
+	VariantPreview   = Variant{Name: "preview", MaxWidth: 800}
+	VariantThumbnail = Variant{Name: "thumbnail", MaxWidth: 400}
+
+	// Derivatives are generated eagerly from a freshly downloaded high-res source
+	Derivatives = []Variant{VariantMedium, VariantPreview, VariantThumbnail}
+)
+
+// VariantByName returns a standard variant preset
+func VariantByName(name string) (Variant, bool) {
+	for _, v := range append([]Variant{VariantHigh}, Derivatives...) {
+		if v.Name == name {
+			return v, true
+		}
+	}
+	return Variant{}, false
+}
+
+// CanEncode reports whether format can be produced locally
+func CanEncode(format string) bool {
+	return format == FormatJPEG || format == FormatPNG
+}
+
+// Extension returns the file extension for a format
+func Extension(format string) string {
+	switch format {
+	case FormatPNG:
+		return ".png"
+	case FormatWebP:
+		return ".webp"
+	default:
+		return ".jpg"
+	}
+}
+
+// MimeType returns the content type for a format
+func MimeType(format string) string {
+	switch format {
+	case FormatPNG:
+		return "image/png"
+	case FormatWebP:
+		return "image/webp"
+	default:
+		return "image/jpeg"
+	}
+}
+
+// Decode decodes a JPEG, PNG or WebP image
+func Decode(data []byte) (image.Image, error) {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+	return img, nil
+}
+
+// Resize scales img down to maxWidth, keeping the aspect ratio.
+// Images that are already small enough are returned unchanged (never upscaled).
+func Resize(img image.Image, maxWidth int) image.Image {
+	bounds := img.Bounds()
+	if maxWidth <= 0 || bounds.Dx() <= maxWidth {
+		return img
+	}
+
+	height := int(math.Round(float64(bounds.Dy()) * float64(maxWidth) / float64(bounds.Dx())))
+	if height < 1 {
+		height = 1
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, maxWidth, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, bounds, draw.Src, nil)
+	return dst
+}
+
+// Scale resizes img by a factor (0 < factor <= 1)
+func Scale(img image.Image, factor float64) image.Image {
+	if factor <= 0 || factor >= 1 {
+		return img
+	}
+	width := int(math.Round(float64(img.Bounds().Dx()) * factor))
+	if width < 1 {
+		width = 1
+	}
+	return Resize(img, width)
+}
+
+// Encode encodes img as JPEG or PNG
+func Encode(img image.Image, format string) ([]byte, error) {
+	var buf bytes.Buffer
+	switch format {
+	case FormatJPEG:
+		if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: JPEGQuality}); err != nil {
+			return nil, fmt.Errorf("failed to encode jpeg: %w", err)
+		}
+	case FormatPNG:
+		if err := png.Encode(&buf, img); err != nil {
+			return nil, fmt.Errorf("failed to encode png: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported output format: %s", format)
+	}
+	return buf.Bytes(), nil
+}
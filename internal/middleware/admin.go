@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"net/http"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+	"instant-hdr-backend/internal/config"
+	"instant-hdr-backend/internal/models"
+)
+
+// AdminRoleKey is set to true by AuthMiddleware when the JWT carries ADMIN_ROLE
+const AdminRoleKey = "admin_role"
+
+// AdminMiddleware only lets admins through (see IsAdmin). It must run after AuthMiddleware.
+func AdminMiddleware(cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !IsAdmin(c, cfg) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "admin access required"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// IsAdmin reports whether the request was made by an admin: a user listed in ADMIN_USER_IDS or whose JWT carries
+// ADMIN_ROLE, or an API key with the admin scope. Other API keys are never admins, whoever created them. Keys of listed
+// admins (and admin keys older than admin_grant) need their creator to still be listed. A key carries no JWT, so the
+// role of a role-based admin can't be checked again: their keys are only trusted while ADMIN_ROLE is set, and always
+// expire (see CreateAPIKey).
+func IsAdmin(c *gin.Context, cfg *config.Config) bool {
+	if key := RequestAPIKey(c); key != nil {
+		if !key.HasScope(models.ScopeAdmin) {
+			return false
+		}
+		if key.AdminGrant.String == models.AdminGrantRole {
+			return cfg.AdminRole != "" && key.ExpiresAt.Valid
+		}
+		return cfg.IsAdmin(key.UserID.String())
+	}
+	if c.GetBool(AdminRoleKey) {
+		return true
+	}
+	userID := c.GetString(UserIDKey)
+	return userID != "" && cfg.IsAdmin(userID)
+}
+
+// hasAdminRole reports whether claims carry role in app_metadata.role or app_metadata.roles.
+// Supabase only lets the service role write app_metadata, so users can't grant it to themselves.
+func hasAdminRole(claims map[string]interface{}, role string) bool {
+	if role == "" {
+		return false
+	}
+	appMetadata, _ := claims["app_metadata"].(map[string]interface{})
+	if value, _ := appMetadata["role"].(string); value == role {
+		return true
+	}
+	roles, _ := appMetadata["roles"].([]interface{})
+	return slices.Contains(roles, interface{}(role))
+}
@@ -3,8 +3,10 @@ package middleware
 import (
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/url"
+	"slices"
 	"strings"
 
 	"github.com/gin-gonic/gin"
@@ -17,7 +19,13 @@ const (
 	UserTokenKey = "user_token" // Raw JWT token for RLS
 )
 
-func AuthMiddleware(cfg *config.Config) gin.HandlerFunc {
+// asymmetricMethods are verified with keys from the JWKS endpoint
+var asymmetricMethods = []string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512", "EdDSA"}
+
+// AuthMiddleware verifies the bearer JWT and stores its sub claim as the user ID. Asymmetric tokens are
+// checked against jwks (nil disables them), HS256 tokens against SUPABASE_JWT_SECRET unless JWT_REJECT_HS256 is set.
+// Bearer API keys (ihdr_...) are verified with apiKeys (nil disables them) and act as the user who created them.
+func AuthMiddleware(cfg *config.Config, jwks *JWKS, apiKeys *APIKeys) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -41,6 +49,16 @@ func AuthMiddleware(cfg *config.Config) gin.HandlerFunc {
 			return
 		}
 
+		if isAPIKey(tokenString) {
+			if apiKeys == nil {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "api keys are not available"})
+				c.Abort()
+				return
+			}
+			apiKeys.authenticate(c, tokenString)
+			return
+		}
+
 		// Try URL decoding in case the token was URL-encoded
 		decodedToken, err := url.QueryUnescape(tokenString)
 		if err == nil && decodedToken != tokenString {
@@ -136,11 +154,13 @@ func AuthMiddleware(cfg *config.Config) gin.HandlerFunc {
 			return
 		}
 
-		// Verify the signing method matches what Supabase uses (HS256)
-		if unverifiedToken.Method.Alg() != "HS256" {
+		// Check the algorithm before looking up keys, so the error says what is accepted
+		alg := unverifiedToken.Method.Alg()
+		methods := acceptedMethods(cfg, jwks)
+		if !slices.Contains(methods, alg) {
 			c.JSON(http.StatusUnauthorized, gin.H{
 				"error":   "invalid token algorithm",
-				"message": "token must use HS256 algorithm, got: " + unverifiedToken.Method.Alg(),
+				"message": "token must use one of " + strings.Join(methods, ", ") + ", got: " + alg,
 			})
 			c.Abort()
 			return
@@ -148,30 +168,16 @@ func AuthMiddleware(cfg *config.Config) gin.HandlerFunc {
 
 		// Now parse and validate with signature verification
 		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			// Verify signing method - Supabase uses HS256 (HMAC)
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, jwt.ErrSignatureInvalid
-			}
-			if cfg.SupabaseJWTSecret == "" {
-				return nil, jwt.ErrSignatureInvalid
+			if _, ok := token.Method.(*jwt.SigningMethodHMAC); ok {
+				// Supabase JWT secret is used directly as the signing key
+				return []byte(cfg.SupabaseJWTSecret), nil
 			}
-			// Supabase JWT secret is used directly as the signing key
-			return []byte(cfg.SupabaseJWTSecret), nil
-		}, jwt.WithValidMethods([]string{"HS256"}))
+			kid, _ := token.Header["kid"].(string)
+			return jwks.Key(kid, token.Method.Alg())
+		}, parserOptions(cfg, alg)...)
 
 		if err != nil {
-			// Provide more helpful error messages
-			var errorMsg string
-			if strings.Contains(err.Error(), "signature is invalid") {
-				errorMsg = "token signature is invalid - check JWT secret"
-			} else if strings.Contains(err.Error(), "token is expired") {
-				errorMsg = "token has expired"
-			} else if strings.Contains(err.Error(), "could not JSON decode") {
-				errorMsg = "token is malformed - ensure you're using a valid Supabase JWT token"
-			} else {
-				errorMsg = err.Error()
-			}
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token", "message": errorMsg})
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token", "message": tokenErrorMessage(err)})
 			c.Abort()
 			return
 		}
@@ -198,9 +204,66 @@ func AuthMiddleware(cfg *config.Config) gin.HandlerFunc {
 			return
 		}
 
+	if hasAdminRole(claims, cfg.AdminRole) {
+		c.Set(AdminRoleKey, true)
+	}
+
 	// Store user_id and token in context
 	c.Set(UserIDKey, sub)
 	c.Set(UserTokenKey, tokenString) // Store raw token for RLS
 	c.Next()
 	}
 }
+
+// acceptedMethods lists the algorithms the configuration can verify
+func acceptedMethods(cfg *config.Config, jwks *JWKS) []string {
+	var methods []string
+	if cfg.AcceptsHS256() {
+		methods = append(methods, "HS256")
+	}
+	if jwks != nil {
+		methods = append(methods, asymmetricMethods...)
+	}
+	return methods
+}
+
+// parserOptions validates exp/nbf/iat with the configured leeway and, when configured, iss and aud.
+// Tokens from a JWKS provider must expire; legacy HS256 tokens are accepted without exp as before.
+func parserOptions(cfg *config.Config, alg string) []jwt.ParserOption {
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{alg}),
+		jwt.WithLeeway(cfg.JWTLeeway),
+		jwt.WithIssuedAt(),
+	}
+	if alg != "HS256" {
+		opts = append(opts, jwt.WithExpirationRequired())
+	}
+	if cfg.JWTIssuer != "" {
+		opts = append(opts, jwt.WithIssuer(cfg.JWTIssuer))
+	}
+	if cfg.JWTAudience != "" {
+		opts = append(opts, jwt.WithAudience(cfg.JWTAudience))
+	}
+	return opts
+}
+
+// tokenErrorMessage turns a verification error into a message for the client
+func tokenErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, jwt.ErrTokenExpired):
+		return "token has expired"
+	case errors.Is(err, jwt.ErrTokenRequiredClaimMissing):
+		return "token is missing a required claim: " + err.Error()
+	case errors.Is(err, jwt.ErrTokenNotValidYet), errors.Is(err, jwt.ErrTokenUsedBeforeIssued):
+		return "token is not valid yet - check the server clock"
+	case errors.Is(err, jwt.ErrTokenInvalidIssuer):
+		return "token issuer is not accepted"
+	case errors.Is(err, jwt.ErrTokenInvalidAudience):
+		return "token audience is not accepted"
+	case errors.Is(err, jwt.ErrTokenSignatureInvalid):
+		return "token signature is invalid - check JWT secret or signing keys"
+	case errors.Is(err, jwt.ErrTokenMalformed):
+		return "token is malformed - ensure you're using a valid Supabase JWT token"
+	}
+	return err.Error()
+}
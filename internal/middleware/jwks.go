@@ -0,0 +1,247 @@
+package middleware
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// minJWKSFetchInterval throttles the fetches triggered by tokens with an unknown kid,
+// so a flood of forged tokens can't hammer the identity provider
+const minJWKSFetchInterval = 30 * time.Second
+
+// JWKS caches the public keys published at a JWKS endpoint, keyed by kid. Keys are refreshed in the
+// background, and on demand when a token names a kid that isn't cached (key rotation). If a refresh
+// fails the previous keys stay in use.
+type JWKS struct {
+	url      string
+	client   *http.Client
+	interval time.Duration
+
+	mu          sync.RWMutex
+	keys        map[string]jwk
+	lastAttempt time.Time
+	fetchMu     sync.Mutex // One fetch at a time
+}
+
+type jwk struct {
+	key crypto.PublicKey
+	alg string // Optional: the only algorithm the key may be used with
+}
+
+func NewJWKS(url string, interval time.Duration) *JWKS {
+	return &JWKS{
+		url:      url,
+		client:   &http.Client{Timeout: 10 * time.Second},
+		interval: interval,
+		keys:     make(map[string]jwk),
+	}
+}
+
+// Start fetches the keys immediately and then every interval until stop is closed
+func (j *JWKS) Start(stop <-chan struct{}) {
+	go func() {
+		ticker := time.NewTicker(j.interval)
+		defer ticker.Stop()
+
+		for {
+			if err := j.Refresh(); err != nil {
+				log.Printf("[JWKS] Refresh failed, keeping %d cached keys: %v", j.size(), err)
+			}
+
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Refresh fetches the key set and replaces the cached keys
+func (j *JWKS) Refresh() error {
+	j.fetchMu.Lock()
+	defer j.fetchMu.Unlock()
+	return j.fetch()
+}
+
+// Key returns the key for a token's kid and alg. An unknown kid triggers a fetch unless one was attempted recently.
+// Tokens without a kid are accepted when the set holds exactly one key.
+func (j *JWKS) Key(kid, alg string) (crypto.PublicKey, error) {
+	key, ok := j.lookup(kid)
+	if !ok {
+		j.fetchMu.Lock()
+		// Another request may have fetched the key while we waited
+		if key, ok = j.lookup(kid); !ok && j.sinceLastAttempt() >= minJWKSFetchInterval {
+			if err := j.fetch(); err != nil {
+				log.Printf("[JWKS] Fetch for unknown key %q failed: %v", kid, err)
+			}
+			key, ok = j.lookup(kid)
+		}
+		j.fetchMu.Unlock()
+	}
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	if key.alg != "" && key.alg != alg {
+		return nil, fmt.Errorf("signing key %q is for %s, not %s", kid, key.alg, alg)
+	}
+	return key.key, nil
+}
+
+func (j *JWKS) lookup(kid string) (jwk, bool) {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+
+	if kid == "" && len(j.keys) == 1 {
+		for _, key := range j.keys {
+			return key, true
+		}
+	}
+	key, ok := j.keys[kid]
+	return key, ok
+}
+
+func (j *JWKS) size() int {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	return len(j.keys)
+}
+
+func (j *JWKS) sinceLastAttempt() time.Duration {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	return time.Since(j.lastAttempt)
+}
+
+// fetch downloads the key set. Callers hold fetchMu.
+func (j *JWKS) fetch() error {
+	j.mu.Lock()
+	j.lastAttempt = time.Now()
+	j.mu.Unlock()
+
+	resp, err := j.client.Get(j.url)
+	if err != nil {
+		return fmt.Errorf("failed to fetch JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to fetch JWKS: %s returned %d", j.url, resp.StatusCode)
+	}
+
+	var set struct {
+		Keys []json.RawMessage `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return fmt.Errorf("failed to decode JWKS: %w", err)
+	}
+
+	keys := make(map[string]jwk, len(set.Keys))
+	for _, raw := range set.Keys {
+		kid, key, err := parseJWK(raw)
+		if err != nil {
+			// One unsupported key (e.g. an encryption key) must not take the others down
+			log.Printf("[JWKS] Skipping key %q: %v", kid, err)
+			continue
+		}
+		keys[kid] = key
+	}
+	if len(keys) == 0 {
+		return fmt.Errorf("JWKS at %s has no usable signing keys", j.url)
+	}
+
+	j.mu.Lock()
+	j.keys = keys
+	j.mu.Unlock()
+	return nil
+}
+
+// parseJWK decodes an RSA, EC (P-256/384/521) or Ed25519 public key (RFC 7517, RFC 8037)
+func parseJWK(raw json.RawMessage) (string, jwk, error) {
+	var k struct {
+		Kid string `json:"kid"`
+		Kty string `json:"kty"`
+		Use string `json:"use"`
+		Alg string `json:"alg"`
+		Crv string `json:"crv"`
+		N   string `json:"n"`
+		E   string `json:"e"`
+		X   string `json:"x"`
+		Y   string `json:"y"`
+	}
+	if err := json.Unmarshal(raw, &k); err != nil {
+		return "", jwk{}, err
+	}
+	if k.Use != "" && k.Use != "sig" {
+		return k.Kid, jwk{}, fmt.Errorf("key use is %q", k.Use)
+	}
+
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeJWKInt(k.N)
+		if err != nil {
+			return k.Kid, jwk{}, fmt.Errorf("invalid n: %w", err)
+		}
+		e, err := decodeJWKInt(k.E)
+		if err != nil || !e.IsInt64() {
+			return k.Kid, jwk{}, fmt.Errorf("invalid e")
+		}
+		return k.Kid, jwk{key: &rsa.PublicKey{N: n, E: int(e.Int64())}, alg: k.Alg}, nil
+
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return k.Kid, jwk{}, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, errX := base64.RawURLEncoding.DecodeString(k.X)
+		y, errY := base64.RawURLEncoding.DecodeString(k.Y)
+		if errX != nil || errY != nil {
+			return k.Kid, jwk{}, fmt.Errorf("invalid EC point")
+		}
+		// Checks the coordinate sizes and that the point is on the curve
+		key, err := ecdsa.ParseUncompressedPublicKey(curve, append(append([]byte{4}, x...), y...))
+		if err != nil {
+			return k.Kid, jwk{}, fmt.Errorf("invalid EC point: %w", err)
+		}
+		return k.Kid, jwk{key: key, alg: k.Alg}, nil
+
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return k.Kid, jwk{}, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return k.Kid, jwk{}, fmt.Errorf("invalid x")
+		}
+		return k.Kid, jwk{key: ed25519.PublicKey(x), alg: k.Alg}, nil
+	}
+	return k.Kid, jwk{}, fmt.Errorf("unsupported key type %q", k.Kty)
+}
+
+func decodeJWKInt(value string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) == 0 {
+		return nil, fmt.Errorf("empty value")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
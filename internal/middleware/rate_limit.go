@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"instant-hdr-backend/internal/ratelimit"
+)
+
+// RateLimit limits requests to rule per client and name, so route groups with their own rules count separately.
+// Clients are the API key or user from AuthMiddleware, or the client IP on public routes. Responses carry the
+// RateLimit-* headers; rejected requests get 429 with Retry-After. A nil limiter or a disabled rule lets everything
+// through, and so does a failing store: rate limiting must not take the API down.
+func RateLimit(limiter *ratelimit.Limiter, name string, rule ratelimit.Rule) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if limiter == nil || !rule.Enabled() {
+			c.Next()
+			return
+		}
+
+		result, err := limiter.Allow(name+":"+rateLimitClient(c), rule)
+		if err != nil {
+			log.Printf("[RateLimit] %s: %v", name, err)
+			c.Next()
+			return
+		}
+
+		reset := secondsUntil(result.Reset)
+		c.Header("RateLimit-Limit", strconv.Itoa(result.Limit))
+		c.Header("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		c.Header("RateLimit-Reset", strconv.Itoa(reset))
+		c.Header("RateLimit-Policy", rule.Policy())
+
+		if !result.Allowed {
+			c.Header("Retry-After", strconv.Itoa(reset))
+			c.JSON(http.StatusTooManyRequests, gin.H{
+				"error":   "rate limit exceeded",
+				"message": "at most " + rule.String() + " requests, retry in " + strconv.Itoa(reset) + "s",
+			})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// rateLimitClient identifies who a request is counted against. API keys get their own budget,
+// so an integration can't starve the apps of the user who created it.
+func rateLimitClient(c *gin.Context) string {
+	if key := RequestAPIKey(c); key != nil {
+		return "key:" + key.ID.String()
+	}
+	if userID, _ := c.Get(UserIDKey); userID != nil {
+		if id, _ := userID.(string); id != "" {
+			return "user:" + id
+		}
+	}
+	return "ip:" + c.ClientIP()
+}
+
+func secondsUntil(t time.Time) int {
+	return max(int(math.Ceil(time.Until(t).Seconds())), 1)
+}
@@ -0,0 +1,60 @@
+package models
+
+import (
+	"database/sql"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// APIKey is a credential for server-to-server integrations. It acts as the user who created it, limited to its scopes;
+// organization keys only reach the orders of their organization. Only the hash of the key is stored.
+type APIKey struct {
+	ID             uuid.UUID
+	UserID         uuid.UUID     // Creator - the key acts as this user
+	OrganizationID uuid.NullUUID // Set for organization keys
+	Name           string
+	Prefix         string // Start of the key, shown so keys can be told apart
+	KeyHash        string // Hex SHA-256 of the key
+	Scopes         []string
+	ExpiresAt      sql.NullTime
+	AdminGrant     sql.NullString // For keys with the admin scope: how the creator qualified as an admin (AdminGrant*)
+	LastUsedAt     sql.NullTime
+	RevokedAt      sql.NullTime
+	CreatedAt      time.Time
+}
+
+// API key scopes
+const (
+	ScopeOrdersRead     = "orders:read"     // List and read orders, images, files and status
+	ScopeOrdersWrite    = "orders:write"    // Create, upload, process, tag and delete orders
+	ScopeImagesDownload = "images:download" // Download, stream and export images, re-sign file URLs
+	ScopeWebhooksManage = "webhooks:manage" // Manage webhook subscriptions
+	ScopeAdmin          = "admin"           // Admin API (/admin); only admins can create personal keys with it
+)
+
+// How the creator of an admin key qualified as an admin. Keys of listed admins are checked against ADMIN_USER_IDS on
+// every request; keys of role-based admins can't be, as the role is only in the creator's JWT, so they always expire.
+const (
+	AdminGrantListed = "listed" // Creator is in ADMIN_USER_IDS
+	AdminGrantRole   = "role"   // Creator's JWT carried ADMIN_ROLE
+)
+
+// Scopes lists every scope a key can be given
+var Scopes = []string{ScopeOrdersRead, ScopeOrdersWrite, ScopeImagesDownload, ScopeWebhooksManage, ScopeAdmin}
+
+// ValidScope reports whether scope is one of the Scope* constants
+func ValidScope(scope string) bool {
+	return slices.Contains(Scopes, scope)
+}
+
+// HasScope reports whether the key was given scope
+func (k *APIKey) HasScope(scope string) bool {
+	return slices.Contains(k.Scopes, scope)
+}
+
+// Active reports whether the key can still be used at now
+func (k *APIKey) Active(now time.Time) bool {
+	return !k.RevokedAt.Valid && (!k.ExpiresAt.Valid || now.Before(k.ExpiresAt.Time))
+}
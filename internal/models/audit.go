@@ -0,0 +1,87 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AuditEntry records an action in the append-only audit log
+type AuditEntry struct {
+	ID        uuid.UUID
+	ActorID   uuid.NullUUID // User who acted (the creator, for API keys); not valid for the system
+	APIKeyID  uuid.NullUUID // Set when the actor used an API key
+	Action    string        // Audit* constant
+	OrderID   uuid.NullUUID
+	ImageID   string          // AutoEnhance image ID, for actions on a single image
+	UserID    uuid.NullUUID   // User the action concerns, e.g. the owner of an order an admin looked at
+	RequestID string          // X-Request-ID of the request that acted; empty for the system
+	IP        string          // Client IP of that request
+	Details   json.RawMessage // JSON object; nil is stored as {}
+	CreatedAt time.Time
+}
+
+// AuditListOptions filters the audit log. The zero value lists every entry, newest first.
+type AuditListOptions struct {
+	ActorID uuid.NullUUID
+	OrderID uuid.NullUUID
+	UserID  uuid.NullUUID
+	// Involving lists entries by or concerning this user: ActorID or UserID matches
+	Involving uuid.NullUUID
+	Since     *time.Time // Inclusive
+	Until     *time.Time // Exclusive
+	Limit     int        // 0 = no limit
+}
+
+// Admin actions
+const (
+	AuditAdminOrdersSearch      = "admin.orders.search"
+	AuditAdminOrderView         = "admin.order.view"
+	AuditAdminOrderResync       = "admin.order.resync"
+	AuditAdminOrderComplete     = "admin.order.complete"
+	AuditAdminOrderResetError   = "admin.order.reset_error"
+	AuditAdminUsageView         = "admin.usage.view"
+	AuditAdminCreditsView       = "admin.credits.view"
+	AuditAdminCreditsGrant      = "admin.credits.grant"
+	AuditAdminCreditsBudget     = "admin.credits.budget"
+	AuditAdminConsistencyCheck  = "admin.consistency.check"
+	AuditAdminConsistencyRepair = "admin.consistency.repair"
+	AuditAdminAuditView         = "admin.audit.view"
+)
+
+// Order actions
+const (
+	AuditOrderCreate             = "order.create"
+	AuditOrderTrash              = "order.trash"
+	AuditOrderPurge              = "order.purge"
+	AuditOrderRestore            = "order.restore"
+	AuditOrderPropertySet        = "order.property.set"
+	AuditOrderOrganizationSet    = "order.organization.set"
+	AuditOrderTagsUpdate         = "order.tags.update"
+	AuditOrderUpload             = "order.upload"
+	AuditOrderProcess            = "order.process"
+	AuditOrderExport             = "order.export"
+	AuditBracketDelete           = "bracket.delete"
+	AuditImageTagsUpdate         = "image.tags.update"
+	AuditImageDownload           = "image.download"
+	AuditImageDelete             = "image.delete"
+	AuditOrderProcessingComplete = "order.processing.completed" // System
+	AuditOrderProcessingFailed   = "order.processing.failed"    // System
+)
+
+// Account actions
+const (
+	AuditPropertyCreate           = "property.create"
+	AuditPropertyUpdate           = "property.update"
+	AuditPropertyDelete           = "property.delete"
+	AuditOrganizationCreate       = "organization.create"
+	AuditOrganizationUpdate       = "organization.update"
+	AuditOrganizationDelete       = "organization.delete"
+	AuditOrganizationMemberSet    = "organization.member.set"
+	AuditOrganizationMemberRemove = "organization.member.remove"
+	AuditAPIKeyCreate             = "api_key.create"
+	AuditAPIKeyRevoke             = "api_key.revoke"
+	AuditSavedFilterCreate        = "saved_filter.create"
+	AuditSavedFilterDelete        = "saved_filter.delete"
+)
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CreditLedgerEntry records a change to a user's credit balance: a spend on an unwatermarked download or a grant
+type CreditLedgerEntry struct {
+	ID         uuid.UUID
+	UserID     uuid.UUID
+	Kind       string // credits.KindSpend or credits.KindGrant
+	Amount     int    // -1 for a spend
+	OrderID    uuid.NullUUID
+	ImageID    sql.NullString
+	Quality    sql.NullString // Spends: the variant downloaded from AutoEnhance
+	Format     sql.NullString
+	Reason     sql.NullString
+	ActorID    uuid.NullUUID // Grants: the admin
+	RefundedAt sql.NullTime  // Spends whose download failed
+	CreatedAt  time.Time
+}
@@ -25,6 +25,65 @@ type Order struct {
 	IsDeleted                bool
 	TotalImages               int
 	AutoEnhanceLastUpdatedAt sql.NullTime
+	DeletedAt                sql.NullTime  // Set while the order is in the trash
+	PropertyID               uuid.NullUUID // Property this shoot belongs to
+	OrganizationID           uuid.NullUUID // Set when the order is shared with an organization; UserID stays the creator
+	Tags                     []string      // Sorted, lowercase
+}
+
+// Property is a real estate property (listing) that one or more orders were shot for
+type Property struct {
+	ID         uuid.UUID
+	UserID     uuid.UUID
+	Address    string
+	MLSNumber  sql.NullString
+	ClientName sql.NullString
+	Notes      sql.NullString
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+// SavedFilter is a named GET /orders query a user can apply again with ?saved_filter=
+type SavedFilter struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	Name      string
+	Query     string // Encoded query string, e.g. "status=completed&tag=rush"
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// Sort keys for order listings
+const (
+	OrderSortCreatedAt = "created_at"
+	OrderSortUpdatedAt = "updated_at"
+	OrderSortName      = "name"       // NULL names sort as ""
+	OrderSortDeletedAt = "deleted_at" // Trash listings only
+)
+
+// OrderListOptions filters, sorts and pages the orders a user can see (their own and their organizations'). The zero value lists every order, newest first.
+type OrderListOptions struct {
+	Statuses       []string // Any of these orders.status values
+	IsProcessing   *bool
+	NameContains   string     // Case-insensitive substring of the cached name
+	CreatedAfter   *time.Time // Inclusive
+	CreatedBefore  *time.Time // Exclusive
+	Sort           string     // OrderSort* (default created_at)
+	Ascending      bool
+	After          *OrderCursor // Keyset position: only orders sorting after this one
+	Limit          int          // 0 = no limit
+	Trashed        bool         // List the trash instead of active orders
+	PropertyID     uuid.NullUUID
+	OrganizationID uuid.NullUUID // Only orders of this organization
+	UserID         uuid.NullUUID // Only orders created by this user
+	Tags           []string      // Only orders carrying all of these tags
+}
+
+// OrderCursor is the sort key and ID of the last order on a page. Only the field for the sort key is set.
+type OrderCursor struct {
+	Time time.Time
+	Name string
+	ID   uuid.UUID
 }
 
 type OrderFile struct {
@@ -39,6 +98,13 @@ type OrderFile struct {
 	MimeType           string
 	IsFinal            bool
 	CreatedAt          time.Time
+	// Derivative tracking (NULL for files stored before variants existed)
+	Variant            sql.NullString // thumbnail, preview, medium, high, custom_{width}w
+	Format             sql.NullString // jpeg, png, webp
+	Watermark          sql.NullBool
+	ParentFileID       uuid.NullUUID // Source file this derivative was generated from
+	Width              sql.NullInt64
+	Height             sql.NullInt64
 }
 
 type Bracket struct {
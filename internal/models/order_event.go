@@ -0,0 +1,27 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OrderEvent is a realtime event in the order_events outbox. It is stored with the order change it announces
+// and published to Supabase broadcast afterwards; it stays readable for clients catching up.
+type OrderEvent struct {
+	ID            uuid.UUID
+	OrderID       uuid.UUID
+	UserID        uuid.UUID // The order's owner, whose user channel mirrors the event; read from the order
+	Seq           int64     // 1, 2, 3, ... per order, without gaps
+	Event         string    // Broadcast event name, e.g. download_ready
+	Payload       json.RawMessage
+	CreatedAt     time.Time
+	DeliveredAt   sql.NullTime // Set once the bus took the event (SSE subscribers of every instance)
+	BroadcastAt   sql.NullTime // Set once broadcast accepted the event
+	DispatchedAt  sql.NullTime // Set once both steps are done
+	Attempts      int
+	NextAttemptAt sql.NullTime // Not valid once the dispatcher gave up
+	LastError     sql.NullString
+}
@@ -0,0 +1,59 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Organization is a team (studio) whose members share orders
+type Organization struct {
+	ID        uuid.UUID
+	Name      string
+	CreatedBy uuid.UUID
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// OrganizationMember is a user's membership and role in an organization
+type OrganizationMember struct {
+	OrganizationID uuid.UUID
+	UserID         uuid.UUID
+	Role           string
+	CreatedAt      time.Time
+}
+
+// OrganizationMembership is an organization as seen by one of its members
+type OrganizationMembership struct {
+	Organization
+	Role string
+}
+
+// Roles, from least to most privileged. Each role can do everything the roles before it can:
+//   - viewer: read orders, images and files
+//   - editor: upload, process, download and edit orders
+//   - admin:  delete and restore orders, manage members
+//   - owner:  manage admins and owners, delete the organization
+const (
+	RoleViewer = "viewer"
+	RoleEditor = "editor"
+	RoleAdmin  = "admin"
+	RoleOwner  = "owner"
+)
+
+var roleRanks = map[string]int{
+	RoleViewer: 1,
+	RoleEditor: 2,
+	RoleAdmin:  3,
+	RoleOwner:  4,
+}
+
+// ValidRole reports whether role is one of the Role* constants
+func ValidRole(role string) bool {
+	return roleRanks[role] > 0
+}
+
+// RoleAtLeast reports whether role grants everything min does. Unknown roles grant nothing.
+func RoleAtLeast(role, min string) bool {
+	return roleRanks[role] > 0 && roleRanks[role] >= roleRanks[min]
+}
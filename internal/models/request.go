@@ -1,9 +1,75 @@
 package models
 
+import "time"
+
 type CreateOrderRequest struct {
 	// Order name/description (e.g., "123 Main St - Living Room")
 	// If not provided, defaults to "Order"
 	Name string `json:"name,omitempty" example:"Property Shoot - 123 Main St"`
+
+	// Property this shoot belongs to (optional, must be one of the user's properties)
+	PropertyID string `json:"property_id,omitempty" example:"6f1c2a9e-8d4b-4f0a-9a51-2f7c3e1b0d42"`
+
+	// Organization that owns the order (optional, requires the editor role in it)
+	OrganizationID string `json:"organization_id,omitempty" example:"0b7e8f52-4c1d-4a6e-9f3b-5d2a1c8e7f60"`
+
+	// Tags to start with (optional, lowercased)
+	Tags []string `json:"tags,omitempty" example:"twilight,rush"`
+}
+
+// PropertyRequest creates or updates a property. Address is required on create;
+// on update, omitted fields are left unchanged and "" clears an optional field.
+type PropertyRequest struct {
+	Address    *string `json:"address,omitempty" example:"123 Main St, Springfield"`
+	MLSNumber  *string `json:"mls_number,omitempty" example:"MLS-204417"`
+	ClientName *string `json:"client_name,omitempty" example:"Acme Realty"`
+	Notes      *string `json:"notes,omitempty" example:"Lockbox code at the front door"`
+}
+
+// SetOrderPropertyRequest links an order to a property; a null property_id unlinks it
+type SetOrderPropertyRequest struct {
+	PropertyID *string `json:"property_id" example:"6f1c2a9e-8d4b-4f0a-9a51-2f7c3e1b0d42"`
+}
+
+// SetOrderOrganizationRequest shares an order with an organization; a null organization_id makes it personal again
+type SetOrderOrganizationRequest struct {
+	OrganizationID *string `json:"organization_id" example:"0b7e8f52-4c1d-4a6e-9f3b-5d2a1c8e7f60"`
+}
+
+// OrganizationRequest creates or renames an organization
+type OrganizationRequest struct {
+	Name string `json:"name" binding:"required" example:"Sunset Real Estate Media"`
+}
+
+// OrganizationMemberRequest adds a member or changes their role
+type OrganizationMemberRequest struct {
+	Role string `json:"role" binding:"required" example:"editor"` // viewer, editor, admin or owner
+}
+
+// TagsRequest adds tags to an order or an image. Tags are lowercased; commas are not allowed.
+type TagsRequest struct {
+	Tags []string `json:"tags" binding:"required" example:"twilight,client-review"`
+}
+
+// BulkTagRequest adds and removes tags on several orders at once
+type BulkTagRequest struct {
+	OrderIDs []string `json:"order_ids" binding:"required"`
+	Add      []string `json:"add,omitempty" example:"rush"`
+	Remove   []string `json:"remove,omitempty" example:"client-review"`
+}
+
+// CreateAPIKeyRequest creates an API key. Organization keys require the admin role in the organization.
+type CreateAPIKeyRequest struct {
+	Name           string     `json:"name" binding:"required" example:"CRM sync"`
+	Scopes         []string   `json:"scopes" binding:"required" example:"orders:read,images:download"`
+	OrganizationID string     `json:"organization_id,omitempty" example:"0b7e8f52-4c1d-4a6e-9f3b-5d2a1c8e7f60"`
+	ExpiresAt      *time.Time `json:"expires_at,omitempty" example:"2027-01-01T00:00:00Z"` // Never expires when omitted
+}
+
+// SavedFilterRequest saves a named GET /orders query (without cursor)
+type SavedFilterRequest struct {
+	Name  string `json:"name" binding:"required" example:"Rush twilight shoots"`
+	Query string `json:"query" example:"tag=rush,twilight&status=processing"`
 }
 
 type ProcessRequest struct {
@@ -102,7 +168,25 @@ type ProcessRequest struct {
 	Metadata map[string]interface{} `json:"metadata,omitempty"`
 }
 
+// ResetOrderErrorRequest is the status an order goes back to when its error is cleared.
+// Without one, the status follows from what the order has: previews, brackets or nothing yet.
+type ResetOrderErrorRequest struct {
+	Status string `json:"status,omitempty" example:"uploaded"`
+}
+
 type ErrorResponse struct {
 	Error   string `json:"error"`
 	Message string `json:"message,omitempty"`
 }
+
+// GrantCreditsRequest adds credits to a user's balance; a negative amount takes them back
+type GrantCreditsRequest struct {
+	Amount int    `json:"amount" binding:"required" example:"50"`
+	Reason string `json:"reason,omitempty" example:"Monthly top-up"`
+}
+
+// SetCreditBudgetRequest sets how many credits a user may spend per calendar month (0 = no budget).
+// Null restores CREDITS_MONTHLY_BUDGET.
+type SetCreditBudgetRequest struct {
+	MonthlyBudget *int `json:"monthly_budget" example:"100"`
+}
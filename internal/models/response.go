@@ -1,6 +1,11 @@
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+
+	"instant-hdr-backend/internal/autoenhance"
+)
 
 type OrderResponse struct {
 	ID                string                 `json:"order_id"`
@@ -21,21 +26,148 @@ type OrderResponse struct {
 	IsProcessing      bool                     `json:"is_processing,omitempty"`
 	IsMerging         bool                     `json:"is_merging,omitempty"` // Indicates if brackets are currently being merged
 	IsDeleted         bool                     `json:"is_deleted,omitempty"` // Indicates if order was deleted in AutoEnhance
+	PropertyID        string                   `json:"property_id,omitempty"`
+	OrganizationID    string                   `json:"organization_id,omitempty"`
+	CreatedBy         string                   `json:"created_by"`
+	Role              string                   `json:"role"` // The caller's role on the order
+	Tags              []string                 `json:"tags"`
 }
 
 type OrderListResponse struct {
-	Orders []OrderSummary `json:"orders"`
+	Orders     []OrderSummary `json:"orders"`
+	NextCursor string         `json:"next_cursor,omitempty"` // Pass as cursor to get the next page; empty on the last page
 }
 
 type OrderSummary struct {
-	ID        string    `json:"order_id"`
-	Name      string    `json:"name,omitempty"` // Order name from AutoEnhance
-	Status    string    `json:"status"`
-	Progress  int       `json:"progress"`
+	ID                string     `json:"order_id"`
+	Name              string     `json:"name,omitempty"` // Order name from AutoEnhance (cached)
+	Status            string     `json:"status"`
+	Progress          int        `json:"progress"`
+	AutoEnhanceStatus string     `json:"autoenhance_status,omitempty"`
+	IsProcessing      bool       `json:"is_processing"`
+	TotalImages       int        `json:"total_images"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+	DeletedAt         *time.Time `json:"deleted_at,omitempty"` // Set for orders in the trash
+	PropertyID        string     `json:"property_id,omitempty"`
+	OrganizationID    string     `json:"organization_id,omitempty"`
+	CreatedBy         string     `json:"created_by"`
+	Tags              []string   `json:"tags"`
+}
+
+type OrganizationResponse struct {
+	ID        string    `json:"organization_id"`
+	Name      string    `json:"name"`
+	CreatedBy string    `json:"created_by"`
+	Role      string    `json:"role"` // The caller's role
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type OrganizationListResponse struct {
+	Organizations []OrganizationResponse `json:"organizations"`
+}
+
+type OrganizationMemberResponse struct {
+	UserID    string    `json:"user_id"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type OrganizationMembersResponse struct {
+	Members []OrganizationMemberResponse `json:"members"`
+}
+
+type TagsResponse struct {
+	Tags []string `json:"tags"`
+}
+
+// BulkTagResponse reports every requested order; orders that could not be tagged carry an error
+type BulkTagResponse struct {
+	Orders []BulkTagResult `json:"orders"`
+}
+
+type BulkTagResult struct {
+	OrderID string   `json:"order_id"`
+	Tags    []string `json:"tags,omitempty"`
+	Error   string   `json:"error,omitempty"`
+}
+
+// APIKeyResponse describes an API key. Key is only set in the response that creates it.
+type APIKeyResponse struct {
+	ID             string     `json:"key_id"`
+	Key            string     `json:"key,omitempty"`
+	Name           string     `json:"name"`
+	Prefix         string     `json:"prefix"`
+	Scopes         []string   `json:"scopes"`
+	UserID         string     `json:"user_id"`
+	OrganizationID string     `json:"organization_id,omitempty"`
+	ExpiresAt      *time.Time `json:"expires_at,omitempty"`
+	AdminGrant     string     `json:"admin_grant,omitempty"` // Admin keys: "listed" (ADMIN_USER_IDS) or "role" (ADMIN_ROLE, always expires)
+	LastUsedAt     *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt      *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+type APIKeyListResponse struct {
+	Keys []APIKeyResponse `json:"keys"`
+}
+
+type SavedFilterResponse struct {
+	ID        string    `json:"filter_id"`
+	Name      string    `json:"name"`
+	Query     string    `json:"query"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
 
+type SavedFilterListResponse struct {
+	Filters []SavedFilterResponse `json:"filters"`
+}
+
+type PropertyResponse struct {
+	ID         string    `json:"property_id"`
+	Address    string    `json:"address"`
+	MLSNumber  string    `json:"mls_number,omitempty"`
+	ClientName string    `json:"client_name,omitempty"`
+	Notes      string    `json:"notes,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+type PropertyListResponse struct {
+	Properties []PropertyResponse `json:"properties"`
+}
+
+// PropertyDetailResponse is a property with its orders and how far delivery has come
+type PropertyDetailResponse struct {
+	PropertyResponse
+	Orders  []PropertyOrder `json:"orders"`
+	Summary PropertySummary `json:"summary"`
+}
+
+type PropertyOrder struct {
+	OrderSummary
+	DeliveredImages int `json:"delivered_images"` // Images downloaded without watermark
+}
+
+// Delivery states of a property
+const (
+	DeliveryNoOrders   = "no_orders"
+	DeliveryProcessing = "processing" // At least one order is still being processed
+	DeliveryPending    = "pending"    // Processed, nothing delivered yet
+	DeliveryPartial    = "partial"
+	DeliveryDelivered  = "delivered" // Every image has been downloaded without watermark
+)
+
+type PropertySummary struct {
+	OrderCount      int            `json:"order_count"`
+	OrdersByStatus  map[string]int `json:"orders_by_status"`
+	TotalImages     int            `json:"total_images"`
+	DeliveredImages int            `json:"delivered_images"`
+	DeliveryStatus  string         `json:"delivery_status"` // no_orders, processing, pending, partial or delivered
+}
+
 type UploadResponse struct {
 	OrderID string            `json:"order_id"`
 	Files   []FileInfo        `json:"files"`
@@ -99,13 +231,21 @@ type BracketResponse struct {
 }
 
 type FileResponse struct {
-	ID         string    `json:"id"`
-	Filename   string    `json:"filename"`
-	StorageURL string    `json:"storage_url"`
-	FileSize   int64     `json:"file_size"`
-	MimeType   string    `json:"mime_type"`
-	IsFinal    bool      `json:"is_final"`
-	CreatedAt  time.Time `json:"created_at"`
+	ID           string     `json:"id"`
+	Filename     string     `json:"filename"`
+	StorageURL   string     `json:"storage_url"`
+	URLExpiresAt *time.Time `json:"url_expires_at,omitempty"` // Set when storage_url is a signed URL (private bucket)
+	FileSize     int64      `json:"file_size"`
+	MimeType     string     `json:"mime_type"`
+	IsFinal      bool       `json:"is_final"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// FileURLResponse is returned when re-signing the URL of a stored file
+type FileURLResponse struct {
+	FileID    string     `json:"file_id"`
+	URL       string     `json:"url"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"` // Nil for permanent public URLs
 }
 
 type HealthResponse struct {
@@ -124,9 +264,11 @@ type ImageResponse struct {
 	Downloaded         bool                   `json:"downloaded"`
 	PreviewURL         string                 `json:"preview_url,omitempty"`          // Supabase URL for preview
 	HighResURL         string                 `json:"high_res_url,omitempty"`         // Supabase URL for high-res
+	URLsExpireAt       *time.Time             `json:"urls_expire_at,omitempty"`       // Set when URLs are signed (private bucket)
 	PreviewDownloaded  bool                   `json:"preview_downloaded"`
 	HighResDownloaded  bool                   `json:"high_res_downloaded"`
 	ProcessingSettings map[string]interface{} `json:"processing_settings,omitempty"`
+	Tags               []string               `json:"tags"`
 }
 
 // DownloadImageRequest defines the options for downloading a processed image
@@ -164,6 +306,9 @@ type DownloadImageResponse struct {
 	// URL to access the image in Supabase Storage (publicly accessible)
 	URL string `json:"url" example:"https://project.supabase.co/storage/v1/object/public/hdr-images/users/user123/orders/order456/img_abc123_preview.jpg"`
 	
+	// ExpiresAt is set when URL is a signed URL (private bucket)
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	
 	// FileSize in bytes
 	FileSize int64 `json:"file_size" example:"524288"`
 	
@@ -176,9 +321,215 @@ type DownloadImageResponse struct {
 	// Format of the downloaded image
 	Format string `json:"format" example:"jpeg"`
 	
+	// Width and Height of the stored image in pixels
+	Width  int `json:"width,omitempty" example:"800"`
+	Height int `json:"height,omitempty" example:"533"`
+	
+	// Source of the file: "cache" (already stored), "generated" (resized locally from the high-res image) or "autoenhance"
+	Source string `json:"source" example:"generated"`
+	
 	// CreditUsed indicates if this download cost a credit
 	CreditUsed bool `json:"credit_used" example:"false"`
 	
 	// Message with download details
 	Message string `json:"message" example:"Image downloaded successfully (FREE with watermark) - Quality: preview, Resolution: 800px"`
 }
+
+// ExportManifest is written as manifest.json into order ZIP exports
+type ExportManifest struct {
+	OrderID      string                `json:"order_id"`
+	OrderName    string                `json:"order_name,omitempty"`
+	Property     *PropertyResponse     `json:"property,omitempty"`
+	ExportedAt   time.Time             `json:"exported_at"`
+	Quality      string                `json:"quality"`
+	Format       string                `json:"format"`
+	Watermark    bool                  `json:"watermark"`
+	NameTemplate string                `json:"name_template"`
+	Images       []ExportManifestEntry `json:"images"`
+}
+
+type ExportManifestEntry struct {
+	ImageID            string                 `json:"image_id"`
+	ImageName          string                 `json:"image_name"`
+	Status             string                 `json:"status"`
+	Filename           string                 `json:"filename,omitempty"` // Entry name in the archive (empty if the image failed)
+	FileSize           int64                  `json:"file_size,omitempty"`
+	Source             string                 `json:"source,omitempty"` // cache, generated or autoenhance
+	CreditUsed         bool                   `json:"credit_used"`
+	ProcessingSettings map[string]interface{} `json:"processing_settings,omitempty"`
+	Error              string                 `json:"error,omitempty"`
+}
+
+// RetentionReport describes what the storage janitor deleted (or would delete in a dry run)
+type RetentionReport struct {
+	DryRun           bool                 `json:"dry_run"`
+	StartedAt        time.Time            `json:"started_at"`
+	FinishedAt       time.Time            `json:"finished_at"`
+	PreviewDays      int                  `json:"preview_days"`       // 0 = previews are kept forever
+	DeletedOrderDays int                  `json:"deleted_order_days"` // 0 = deleted orders are never purged
+	ExpiredFiles     []RetentionFileItem  `json:"expired_files"`
+	PurgedOrders     []RetentionOrderItem `json:"purged_orders"`
+	FilesDeleted     int                  `json:"files_deleted"` // Storage objects (would be) deleted
+	BytesFreed       int64                `json:"bytes_freed"`
+	Truncated        bool                 `json:"truncated,omitempty"` // Dry run stopped at the page limit
+	Errors           []string             `json:"errors,omitempty"`
+}
+
+type RetentionFileItem struct {
+	FileID      string    `json:"file_id"`
+	OrderID     string    `json:"order_id"`
+	Filename    string    `json:"filename"`
+	StoragePath string    `json:"storage_path"`
+	FileSize    int64     `json:"file_size"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type RetentionOrderItem struct {
+	OrderID     string    `json:"order_id"`
+	Name        string    `json:"name,omitempty"`
+	DeletedAt   time.Time `json:"deleted_at"`
+	ObjectCount int       `json:"object_count"`
+	Bytes       int64     `json:"bytes"`
+	Permanent   bool      `json:"permanent"` // Trashed order deleted entirely; otherwise only its storage is purged
+}
+
+// QuotaExceededResponse is returned with 403 (count limits) or 413 (byte limits) when a plan limit is hit
+type QuotaExceededResponse struct {
+	Error     string `json:"error"` // Always "quota_exceeded"
+	Message   string `json:"message"`
+	Limit     string `json:"limit"` // active_orders, brackets_per_order, upload_bytes_per_month or stored_bytes
+	Plan      string `json:"plan"`
+	Used      int64  `json:"used"`
+	Max       int64  `json:"max"`
+	Requested int64  `json:"requested"`
+}
+
+// UsageResponse reports the user's consumption against their plan. A limit of 0 means unlimited.
+type UsageResponse struct {
+	Plan         string        `json:"plan"`
+	Enforced     bool          `json:"enforced"`
+	PeriodStart  time.Time     `json:"period_start"`
+	ActiveOrders UsageCounter  `json:"active_orders"`
+	UploadBytes  UsageCounter  `json:"upload_bytes_this_month"`
+	StoredBytes  UsageCounter  `json:"stored_bytes"`
+	Brackets     UsageLimit    `json:"brackets_per_order"`
+}
+
+type UsageCounter struct {
+	Used  int64 `json:"used"`
+	Limit int64 `json:"limit"`
+}
+
+// UsageLimit is a per-order limit, so only the limit is reported
+type UsageLimit struct {
+	Limit int64 `json:"limit"`
+}
+
+// ConsistencyReport lists mismatches between storage objects and the order_files/brackets tables
+type ConsistencyReport struct {
+	DryRun         bool               `json:"dry_run"`
+	StartedAt      time.Time          `json:"started_at"`
+	FinishedAt     time.Time          `json:"finished_at"`
+	UserID         string             `json:"user_id,omitempty"` // Set when the check was limited to one user
+	OrdersChecked  int                `json:"orders_checked"`
+	RowsChecked    int                `json:"rows_checked"`
+	ObjectsChecked int                `json:"objects_checked"`
+	Issues         []ConsistencyIssue `json:"issues"`
+	Repaired       int                `json:"repaired"`
+	Errors         []string           `json:"errors,omitempty"`
+}
+
+// ConsistencyIssue is a single mismatch and the repair for it
+type ConsistencyIssue struct {
+	Kind        string `json:"kind"` // orphaned_object, dangling_row, missing_path, orphaned_row, orphaned_bracket
+	OrderID     string `json:"order_id,omitempty"`
+	FileID      string `json:"file_id,omitempty"`
+	BracketID   string `json:"bracket_id,omitempty"`
+	StoragePath string `json:"storage_path,omitempty"`
+	Bytes       int64  `json:"bytes,omitempty"`
+	Detail      string `json:"detail"`
+	Action      string `json:"action"` // delete_object, delete_row, relink_row, delete_bracket, none
+	Repaired    bool   `json:"repaired"`
+}
+
+// AdminOrderResponse is everything support needs about an order, whoever owns it
+type AdminOrderResponse struct {
+	Order               OrderSummary             `json:"order"`
+	ErrorMessage        string                   `json:"error_message,omitempty"`
+	Metadata            map[string]interface{}   `json:"metadata,omitempty"`
+	AutoEnhance         *autoenhance.OrderOut    `json:"autoenhance,omitempty"`       // The order as AutoEnhance reports it now
+	AutoEnhanceError    string                   `json:"autoenhance_error,omitempty"` // Set when AutoEnhance could not be reached
+	Brackets            []BracketResponse        `json:"brackets"`                    // Bracket rows in our database
+	AutoEnhanceBrackets []autoenhance.BracketOut `json:"autoenhance_brackets"`        // Brackets AutoEnhance still has
+}
+
+// AdminOrderActionResponse is the order after an admin action
+type AdminOrderActionResponse struct {
+	Action       string       `json:"action"`
+	Order        OrderSummary `json:"order"`
+	ErrorMessage string       `json:"error_message,omitempty"`
+}
+
+type AuditLogResponse struct {
+	Entries []AuditEntryResponse `json:"entries"`
+}
+
+type AuditEntryResponse struct {
+	ID        string          `json:"entry_id"`
+	ActorID   string          `json:"actor_id,omitempty"` // Empty for system actions
+	APIKeyID  string          `json:"api_key_id,omitempty"`
+	Action    string          `json:"action"`
+	OrderID   string          `json:"order_id,omitempty"`
+	ImageID   string          `json:"image_id,omitempty"`
+	UserID    string          `json:"user_id,omitempty"`
+	RequestID string          `json:"request_id,omitempty"`
+	IP        string          `json:"ip,omitempty"`
+	Details   json.RawMessage `json:"details" swaggertype:"object"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+type OrderEventsResponse struct {
+	OrderID string               `json:"order_id"`
+	Events  []OrderEventResponse `json:"events"`
+	HasMore bool                 `json:"has_more"` // Ask again with since set to the last seq
+}
+
+type OrderEventResponse struct {
+	Seq       int64           `json:"seq"`
+	Event     string          `json:"event"`
+	Payload   json.RawMessage `json:"payload" swaggertype:"object"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// CreditsExceededResponse is returned with 402 when an unwatermarked download can't be paid for
+type CreditsExceededResponse struct {
+	Error   string `json:"error"` // Always "credits_exceeded"
+	Message string `json:"message"`
+	Limit   string `json:"limit"` // credit_balance or monthly_credit_budget
+	Balance int    `json:"balance"`
+	Used    int    `json:"used"` // Credits spent this month
+	Max     int    `json:"max"`  // Monthly budget; 0 when there is none
+}
+
+// CreditsResponse reports a user's credits and their latest ledger entries, newest first
+type CreditsResponse struct {
+	Balance       int                   `json:"balance"`
+	Enforced      bool                  `json:"enforced"`
+	PeriodStart   time.Time             `json:"period_start"`
+	MonthlyBudget UsageCounter          `json:"monthly_budget"` // Spent this month; a limit of 0 means no budget
+	Ledger        []CreditEntryResponse `json:"ledger"`
+}
+
+type CreditEntryResponse struct {
+	ID         string     `json:"entry_id"`
+	Kind       string     `json:"kind"`   // spend or grant
+	Amount     int        `json:"amount"` // -1 for a spend
+	OrderID    string     `json:"order_id,omitempty"`
+	ImageID    string     `json:"image_id,omitempty"`
+	Quality    string     `json:"quality,omitempty"` // Spends: the variant downloaded from AutoEnhance
+	Format     string     `json:"format,omitempty"`
+	Reason     string     `json:"reason,omitempty"`
+	ActorID    string     `json:"actor_id,omitempty"`    // Grants: the admin
+	RefundedAt *time.Time `json:"refunded_at,omitempty"` // Spends whose download failed
+	CreatedAt  time.Time  `json:"created_at"`
+}
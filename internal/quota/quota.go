@@ -0,0 +1,216 @@
+package quota
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Limit names reported in quota_exceeded errors
+const (
+	LimitActiveOrders       = "active_orders"
+	LimitBracketsPerOrder   = "brackets_per_order"
+	LimitUploadBytesMonthly = "upload_bytes_per_month"
+	LimitStoredBytes        = "stored_bytes"
+)
+
+// UsageKindUpload is the usage_events kind for uploaded bracket bytes
+const UsageKindUpload = "upload"
+
+const gb = int64(1) << 30
+
+// Plan holds the limits of a subscription plan. Zero means unlimited.
+type Plan struct {
+	Name                   string `json:"name"`
+	MaxActiveOrders        int    `json:"max_active_orders"`
+	MaxBracketsPerOrder    int    `json:"max_brackets_per_order"`
+	MaxUploadBytesPerMonth int64  `json:"max_upload_bytes_per_month"`
+	MaxStoredBytes         int64  `json:"max_stored_bytes"`
+}
+
+// Plans are the built-in plans, assigned per user in the user_plans table
+var Plans = map[string]Plan{
+	"free": {
+		Name:                   "free",
+		MaxActiveOrders:        5,
+		MaxBracketsPerOrder:    150,
+		MaxUploadBytesPerMonth: 5 * gb,
+		MaxStoredBytes:         1 * gb,
+	},
+	"pro": {
+		Name:                   "pro",
+		MaxActiveOrders:        100,
+		MaxBracketsPerOrder:    1000,
+		MaxUploadBytesPerMonth: 200 * gb,
+		MaxStoredBytes:         50 * gb,
+	},
+	"unlimited": {
+		Name: "unlimited",
+	},
+}
+
+// Usage is a user's current consumption
+type Usage struct {
+	ActiveOrders           int
+	UploadedBytesThisMonth int64
+	StoredBytes            int64
+}
+
+// Store provides plan assignments and usage numbers (implemented by the repositories)
+type Store interface {
+	GetUserPlan(userID uuid.UUID) (string, error)
+	GetUsage(userID uuid.UUID, periodStart time.Time) (*Usage, error)
+	CountBrackets(orderID uuid.UUID) (int, error)
+	RecordUsageEvent(userID, orderID uuid.UUID, kind string, bytes int64) error
+}
+
+// ExceededError is returned when an action would exceed a plan limit
+type ExceededError struct {
+	Limit     string
+	Plan      string
+	Used      int64
+	Max       int64
+	Requested int64
+}
+
+func (e *ExceededError) Error() string {
+	return fmt.Sprintf("%s quota exceeded on %s plan: using %d of %d, requested %d more", e.Limit, e.Plan, e.Used, e.Max, e.Requested)
+}
+
+// StatusCode is 413 for byte limits (the payload is too large) and 403 for count limits
+func (e *ExceededError) StatusCode() int {
+	if e.Limit == LimitUploadBytesMonthly || e.Limit == LimitStoredBytes {
+		return http.StatusRequestEntityTooLarge
+	}
+	return http.StatusForbidden
+}
+
+// Service enforces plan limits. When disabled, checks always pass but usage is still tracked and reported.
+type Service struct {
+	store       Store
+	enabled     bool
+	defaultPlan string
+	now         func() time.Time
+}
+
+func NewService(store Store, enabled bool, defaultPlan string) *Service {
+	if _, ok := Plans[defaultPlan]; !ok {
+		defaultPlan = "free"
+	}
+	return &Service{
+		store:       store,
+		enabled:     enabled,
+		defaultPlan: defaultPlan,
+		now:         time.Now,
+	}
+}
+
+// Enabled reports whether limits are enforced
+func (s *Service) Enabled() bool {
+	return s.enabled
+}
+
+// PlanFor returns the user's plan (the default plan if none is assigned)
+func (s *Service) PlanFor(userID uuid.UUID) (Plan, error) {
+	name, err := s.store.GetUserPlan(userID)
+	if err != nil {
+		return Plan{}, fmt.Errorf("failed to get user plan: %w", err)
+	}
+	plan, ok := Plans[name]
+	if !ok {
+		plan = Plans[s.defaultPlan]
+	}
+	return plan, nil
+}
+
+// PeriodStart returns the start of the current monthly billing period (UTC calendar month)
+func (s *Service) PeriodStart() time.Time {
+	now := s.now().UTC()
+	return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// Usage returns the user's plan and current consumption
+func (s *Service) Usage(userID uuid.UUID) (Plan, *Usage, error) {
+	plan, err := s.PlanFor(userID)
+	if err != nil {
+		return Plan{}, nil, err
+	}
+	usage, err := s.store.GetUsage(userID, s.PeriodStart())
+	if err != nil {
+		return Plan{}, nil, fmt.Errorf("failed to get usage: %w", err)
+	}
+	return plan, usage, nil
+}
+
+// CheckCreateOrder verifies the user may create another order
+func (s *Service) CheckCreateOrder(userID uuid.UUID) error {
+	if !s.enabled {
+		return nil
+	}
+	plan, usage, err := s.Usage(userID)
+	if err != nil {
+		return err
+	}
+	return check(LimitActiveOrders, plan, int64(usage.ActiveOrders), int64(plan.MaxActiveOrders), 1)
+}
+
+// CheckUpload verifies an upload of fileCount brackets totalling bytes fits the plan
+func (s *Service) CheckUpload(userID, orderID uuid.UUID, fileCount int, bytes int64) error {
+	if !s.enabled {
+		return nil
+	}
+	plan, usage, err := s.Usage(userID)
+	if err != nil {
+		return err
+	}
+
+	if plan.MaxBracketsPerOrder > 0 {
+		brackets, err := s.store.CountBrackets(orderID)
+		if err != nil {
+			return fmt.Errorf("failed to count brackets: %w", err)
+		}
+		if err := check(LimitBracketsPerOrder, plan, int64(brackets), int64(plan.MaxBracketsPerOrder), int64(fileCount)); err != nil {
+			return err
+		}
+	}
+	return check(LimitUploadBytesMonthly, plan, usage.UploadedBytesThisMonth, plan.MaxUploadBytesPerMonth, bytes)
+}
+
+// CheckStore verifies bytes more can be stored. Pass 0 when the size is not known yet -
+// the check then fails only if the user is already at the limit.
+func (s *Service) CheckStore(userID uuid.UUID, bytes int64) error {
+	if !s.enabled {
+		return nil
+	}
+	plan, usage, err := s.Usage(userID)
+	if err != nil {
+		return err
+	}
+	if bytes == 0 && plan.MaxStoredBytes > 0 && usage.StoredBytes >= plan.MaxStoredBytes {
+		bytes = 1
+	}
+	return check(LimitStoredBytes, plan, usage.StoredBytes, plan.MaxStoredBytes, bytes)
+}
+
+// RecordUpload adds uploaded bytes to the user's monthly usage
+func (s *Service) RecordUpload(userID, orderID uuid.UUID, bytes int64) error {
+	if bytes <= 0 {
+		return nil
+	}
+	return s.store.RecordUsageEvent(userID, orderID, UsageKindUpload, bytes)
+}
+
+func check(limit string, plan Plan, used, max, requested int64) error {
+	if max <= 0 || used+requested <= max {
+		return nil
+	}
+	return &ExceededError{
+		Limit:     limit,
+		Plan:      plan.Name,
+		Used:      used,
+		Max:       max,
+		Requested: requested,
+	}
+}
@@ -0,0 +1,55 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// Memory is a Store for a single instance. Every replica counts on its own, so use the Postgres store
+// when the API runs on several.
+type Memory struct {
+	mu       sync.Mutex
+	counters map[string]counter
+}
+
+type counter struct {
+	windowStart time.Time
+	windowEnd   time.Time
+	count       int
+}
+
+var _ Store = (*Memory)(nil)
+
+func NewMemory() *Memory {
+	return &Memory{counters: make(map[string]counter)}
+}
+
+func (m *Memory) IncrementRateLimit(key string, windowStart time.Time, window time.Duration) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	c, ok := m.counters[key]
+	switch {
+	case !ok || windowStart.After(c.windowStart):
+		c = counter{windowStart: windowStart, windowEnd: windowStart.Add(window), count: 1}
+	default:
+		// Same window, or a request that was late for the previous one
+		c.count++
+	}
+	m.counters[key] = c
+	return c.count, nil
+}
+
+func (m *Memory) PruneRateLimits(before time.Time) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var pruned int64
+	for key, c := range m.counters {
+		if c.windowEnd.Before(before) {
+			delete(m.counters, key)
+			pruned++
+		}
+	}
+	return pruned, nil
+}
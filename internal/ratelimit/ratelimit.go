@@ -0,0 +1,120 @@
+package ratelimit
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// pruneInterval is how often Start removes counters of windows that have ended
+const pruneInterval = 10 * time.Minute
+
+// Rule allows Limit requests per Window. The zero Rule doesn't limit anything.
+type Rule struct {
+	Limit  int
+	Window time.Duration
+}
+
+// ParseRule parses "<requests>/<window>", e.g. "120/1m". "off" and "0" disable limiting.
+func ParseRule(s string) (Rule, error) {
+	s = strings.TrimSpace(s)
+	if s == "off" || s == "0" {
+		return Rule{}, nil
+	}
+	limit, window, ok := strings.Cut(s, "/")
+	if !ok {
+		return Rule{}, fmt.Errorf("%q is not <requests>/<window>, e.g. 120/1m", s)
+	}
+	n, err := strconv.Atoi(limit)
+	if err != nil || n <= 0 {
+		return Rule{}, fmt.Errorf("%q: requests must be a positive number", s)
+	}
+	d, err := time.ParseDuration(window)
+	if err != nil || d < time.Second {
+		return Rule{}, fmt.Errorf("%q: window must be a duration of at least 1s", s)
+	}
+	return Rule{Limit: n, Window: d}, nil
+}
+
+// Enabled reports whether the rule limits anything
+func (r Rule) Enabled() bool {
+	return r.Limit > 0 && r.Window > 0
+}
+
+// Policy formats the rule for the RateLimit-Policy header, e.g. "120;w=60"
+func (r Rule) Policy() string {
+	return fmt.Sprintf("%d;w=%d", r.Limit, int(r.Window.Seconds()))
+}
+
+func (r Rule) String() string {
+	if !r.Enabled() {
+		return "off"
+	}
+	return fmt.Sprintf("%d/%s", r.Limit, r.Window)
+}
+
+// Store counts requests per key in fixed windows. It is implemented by the repositories (Postgres, shared by
+// every replica) and by Memory (one process).
+type Store interface {
+	// IncrementRateLimit counts a request in the window starting at windowStart and returns the window's count.
+	// A key has one window at a time; a later window starts again from 1.
+	IncrementRateLimit(key string, windowStart time.Time, window time.Duration) (int, error)
+	// PruneRateLimits removes the counters of windows that ended before the given time
+	PruneRateLimits(before time.Time) (int64, error)
+}
+
+// Result is the outcome of one request
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	Reset     time.Time // End of the current window
+}
+
+// Limiter applies rules to keys with a fixed window counter
+type Limiter struct {
+	store Store
+	now   func() time.Time
+}
+
+func NewLimiter(store Store) *Limiter {
+	return &Limiter{store: store, now: time.Now}
+}
+
+// Allow counts a request for key and reports whether it is within the rule
+func (l *Limiter) Allow(key string, rule Rule) (Result, error) {
+	now := l.now().UTC()
+	start := now.Truncate(rule.Window)
+	count, err := l.store.IncrementRateLimit(key, start, rule.Window)
+	if err != nil {
+		return Result{}, err
+	}
+	return Result{
+		Allowed:   count <= rule.Limit,
+		Limit:     rule.Limit,
+		Remaining: max(rule.Limit-count, 0),
+		Reset:     start.Add(rule.Window),
+	}, nil
+}
+
+// Start prunes ended windows now and then every 10 minutes until stop is closed
+func (l *Limiter) Start(stop <-chan struct{}) {
+	go func() {
+		ticker := time.NewTicker(pruneInterval)
+		defer ticker.Stop()
+
+		for {
+			if _, err := l.store.PruneRateLimits(l.now().UTC()); err != nil {
+				log.Printf("[RateLimit] Failed to prune counters: %v", err)
+			}
+
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
@@ -0,0 +1,1509 @@
+package repository
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"instant-hdr-backend/internal/credits"
+	"instant-hdr-backend/internal/models"
+	"instant-hdr-backend/internal/quota"
+	"instant-hdr-backend/internal/ratelimit"
+)
+
+// Memory is a thread-safe in-memory Repository. It mirrors the Postgres behaviour the handlers
+// rely on (ownership checks, cascading deletes, sql.ErrNoRows for missing variants) so the API
+// can run and be tested without a database. Nothing survives a restart.
+type Memory struct {
+	mu       sync.RWMutex
+	seq      int64               // Insertion counter, breaks created_at ties like a serial column would
+	order    map[uuid.UUID]int64 // Record ID -> insertion sequence
+	orders   map[uuid.UUID]models.Order
+	purged   map[uuid.UUID]bool // Orders whose storage was purged (orders.storage_purged_at)
+	files    map[uuid.UUID]models.OrderFile
+	brackets map[uuid.UUID]models.Bracket
+	props    map[uuid.UUID]models.Property
+	orgs     map[uuid.UUID]models.Organization
+	members  map[memberKey]models.OrganizationMember
+	tags     map[uuid.UUID]map[string][]string // Order ID -> image ID -> sorted image tags
+	filters  map[uuid.UUID]models.SavedFilter
+	apiKeys  map[uuid.UUID]models.APIKey
+	audit    []models.AuditEntry // Oldest first
+	events   []models.OrderEvent // Oldest first
+	limits   *ratelimit.Memory
+	plans    map[uuid.UUID]string
+	usage    []usageEvent
+	credits  map[uuid.UUID]creditAccount
+	ledger   []models.CreditLedgerEntry // Oldest first
+	now      func() time.Time
+}
+
+type memberKey struct {
+	organizationID uuid.UUID
+	userID         uuid.UUID
+}
+
+type creditAccount struct {
+	balance int
+	budget  *int
+}
+
+type usageEvent struct {
+	userID    uuid.UUID
+	orderID   uuid.NullUUID
+	kind      string
+	bytes     int64
+	createdAt time.Time
+}
+
+var _ Repository = (*Memory)(nil)
+
+func NewMemory() *Memory {
+	return &Memory{
+		order:    make(map[uuid.UUID]int64),
+		orders:   make(map[uuid.UUID]models.Order),
+		purged:   make(map[uuid.UUID]bool),
+		files:    make(map[uuid.UUID]models.OrderFile),
+		brackets: make(map[uuid.UUID]models.Bracket),
+		props:    make(map[uuid.UUID]models.Property),
+		orgs:     make(map[uuid.UUID]models.Organization),
+		members:  make(map[memberKey]models.OrganizationMember),
+		tags:     make(map[uuid.UUID]map[string][]string),
+		filters:  make(map[uuid.UUID]models.SavedFilter),
+		apiKeys:  make(map[uuid.UUID]models.APIKey),
+		limits:   ratelimit.NewMemory(),
+		plans:    make(map[uuid.UUID]string),
+		credits:  make(map[uuid.UUID]creditAccount),
+		now:      time.Now,
+	}
+}
+
+// SetUserPlan assigns a quota plan (the user_plans table in Postgres)
+func (m *Memory) SetUserPlan(userID uuid.UUID, plan string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.plans[userID] = plan
+}
+
+// SetClock replaces the clock that timestamps records, so tests can create them in the past
+func (m *Memory) SetClock(now func() time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.now = now
+}
+
+func (m *Memory) Close() error {
+	return nil
+}
+
+func (m *Memory) track(id uuid.UUID) {
+	m.seq++
+	m.order[id] = m.seq
+}
+
+// before orders records by created_at, then by insertion
+func (m *Memory) before(a, b time.Time, idA, idB uuid.UUID) bool {
+	if !a.Equal(b) {
+		return a.Before(b)
+	}
+	return m.order[idA] < m.order[idB]
+}
+
+func notFound(what string) error {
+	return fmt.Errorf("failed to get %s: %w", what, sql.ErrNoRows)
+}
+
+// Orders
+
+func (m *Memory) CreateOrder(orderID, userID uuid.UUID, metadata map[string]interface{}) (*models.Order, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.orders[orderID]; exists {
+		return nil, fmt.Errorf("failed to create order: order %s already exists", orderID)
+	}
+
+	metadataJSON, _ := json.Marshal(metadata)
+	now := m.now().UTC()
+	order := models.Order{
+		ID:        orderID,
+		UserID:    userID,
+		Status:    "created",
+		Metadata:  metadataJSON,
+		CreatedAt: now,
+		UpdatedAt: now,
+		Tags:      []string{},
+	}
+	m.orders[orderID] = order
+	m.track(orderID)
+	return &order, nil
+}
+
+func (m *Memory) GetOrder(orderID, userID uuid.UUID) (*models.Order, error) {
+	return m.getOrder(orderID, userID, false)
+}
+
+func (m *Memory) GetTrashedOrder(orderID, userID uuid.UUID) (*models.Order, error) {
+	return m.getOrder(orderID, userID, true)
+}
+
+func (m *Memory) getOrder(orderID, userID uuid.UUID, trashed bool) (*models.Order, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	order, ok := m.orders[orderID]
+	if !ok || !m.visibleTo(order, userID) || order.DeletedAt.Valid != trashed {
+		return nil, notFound("order")
+	}
+	return &order, nil
+}
+
+// visibleTo reports whether the user can see the order: a personal order of theirs,
+// or an order of one of their organizations. Callers hold m.mu.
+func (m *Memory) visibleTo(order models.Order, userID uuid.UUID) bool {
+	if order.OrganizationID.Valid {
+		_, member := m.members[memberKey{order.OrganizationID.UUID, userID}]
+		return member
+	}
+	return order.UserID == userID
+}
+
+func (m *Memory) TrashOrder(orderID, userID uuid.UUID) error {
+	now := m.now().UTC()
+	return m.updateOrder(orderID, func(o *models.Order) {
+		if o.UserID == userID && !o.DeletedAt.Valid {
+			o.DeletedAt = sql.NullTime{Time: now, Valid: true}
+		}
+	})
+}
+
+func (m *Memory) RestoreOrder(orderID, userID uuid.UUID) error {
+	return m.updateOrder(orderID, func(o *models.Order) {
+		if o.UserID == userID {
+			o.DeletedAt = sql.NullTime{}
+		}
+	})
+}
+
+func (m *Memory) GetOrderByAutoEnhanceOrderID(autoenhanceOrderID string) (*models.Order, error) {
+	orderID, err := uuid.Parse(autoenhanceOrderID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid order id: %w", err)
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	order, ok := m.orders[orderID]
+	if !ok {
+		return nil, notFound("order")
+	}
+	return &order, nil
+}
+
+func (m *Memory) ListOrders(userID uuid.UUID, opts models.OrderListOptions) ([]models.Order, error) {
+	return m.listOrders(func(o models.Order) bool { return m.visibleTo(o, userID) }, opts)
+}
+
+func (m *Memory) SearchOrders(opts models.OrderListOptions) ([]models.Order, error) {
+	return m.listOrders(func(models.Order) bool { return true }, opts)
+}
+
+// listOrders filters, sorts and pages the orders that pass visible
+func (m *Memory) listOrders(visible func(models.Order) bool, opts models.OrderListOptions) ([]models.Order, error) {
+	name := strings.ToLower(opts.NameContains)
+	orders := m.filterOrders(func(o models.Order) bool {
+		switch {
+		case !visible(o) || o.DeletedAt.Valid != opts.Trashed:
+			return false
+		case opts.UserID.Valid && o.UserID != opts.UserID.UUID:
+			return false
+		case opts.PropertyID.Valid && o.PropertyID != opts.PropertyID:
+			return false
+		case opts.OrganizationID.Valid && o.OrganizationID != opts.OrganizationID:
+			return false
+		case !containsAll(o.Tags, opts.Tags):
+			return false
+		case len(opts.Statuses) > 0 && !contains(opts.Statuses, o.Status):
+			return false
+		case opts.IsProcessing != nil && o.IsProcessing != *opts.IsProcessing:
+			return false
+		case name != "" && !strings.Contains(strings.ToLower(o.Name.String), name):
+			return false
+		case opts.CreatedAfter != nil && o.CreatedAt.Before(*opts.CreatedAfter):
+			return false
+		case opts.CreatedBefore != nil && !o.CreatedAt.Before(*opts.CreatedBefore):
+			return false
+		}
+		return true
+	})
+
+	// Same ordering as Postgres: (sort key, id), then keyset filtering on the cursor
+	compare := func(o models.Order, key models.OrderCursor) int {
+		var c int
+		switch opts.Sort {
+		case models.OrderSortUpdatedAt:
+			c = o.UpdatedAt.Compare(key.Time)
+		case models.OrderSortName:
+			c = strings.Compare(o.Name.String, key.Name)
+		case models.OrderSortDeletedAt:
+			c = o.DeletedAt.Time.Compare(key.Time)
+		default:
+			c = o.CreatedAt.Compare(key.Time)
+		}
+		if c == 0 {
+			c = bytes.Compare(o.ID[:], key.ID[:])
+		}
+		if !opts.Ascending {
+			c = -c
+		}
+		return c
+	}
+	cursorOf := func(o models.Order) models.OrderCursor {
+		return models.OrderCursor{Time: o.CreatedAt, Name: o.Name.String, ID: o.ID}
+	}
+	switch opts.Sort {
+	case models.OrderSortUpdatedAt:
+		cursorOf = func(o models.Order) models.OrderCursor {
+			return models.OrderCursor{Time: o.UpdatedAt, ID: o.ID}
+		}
+	case models.OrderSortDeletedAt:
+		cursorOf = func(o models.Order) models.OrderCursor {
+			return models.OrderCursor{Time: o.DeletedAt.Time, ID: o.ID}
+		}
+	}
+	sort.SliceStable(orders, func(i, j int) bool { return compare(orders[i], cursorOf(orders[j])) < 0 })
+
+	page := make([]models.Order, 0, len(orders))
+	for _, order := range orders {
+		if opts.After != nil && compare(order, *opts.After) <= 0 {
+			continue
+		}
+		if opts.Limit > 0 && len(page) == opts.Limit {
+			break
+		}
+		page = append(page, order)
+	}
+	return page, nil
+}
+
+func (m *Memory) ListAllOrders(userID uuid.NullUUID) ([]models.Order, error) {
+	return m.filterOrders(func(o models.Order) bool {
+		return !userID.Valid || o.UserID == userID.UUID
+	}), nil
+}
+
+func (m *Memory) ListPurgeableOrders(before time.Time, userID uuid.NullUUID) ([]models.Order, error) {
+	// Trashed orders purge from deleted_at, orders deleted in AutoEnhance from their last update
+	purgeFrom := func(o models.Order) time.Time {
+		if o.DeletedAt.Valid {
+			return o.DeletedAt.Time
+		}
+		return o.UpdatedAt
+	}
+	m.mu.RLock()
+	purged := make(map[uuid.UUID]bool, len(m.purged))
+	for id := range m.purged {
+		purged[id] = true
+	}
+	m.mu.RUnlock()
+
+	orders := m.filterOrders(func(o models.Order) bool {
+		return (o.DeletedAt.Valid || o.IsDeleted && !purged[o.ID]) && purgeFrom(o).Before(before) && (!userID.Valid || o.UserID == userID.UUID)
+	})
+	sort.SliceStable(orders, func(i, j int) bool { return purgeFrom(orders[i]).Before(purgeFrom(orders[j])) })
+	return orders, nil
+}
+
+func (m *Memory) MarkOrderStoragePurged(orderID uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.orders[orderID]; !ok {
+		return notFound("order")
+	}
+	m.purged[orderID] = true
+	return nil
+}
+
+// filterOrders returns matching orders, oldest first
+func (m *Memory) filterOrders(match func(models.Order) bool) []models.Order {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var orders []models.Order
+	for _, order := range m.orders {
+		if match(order) {
+			orders = append(orders, order)
+		}
+	}
+	sort.Slice(orders, func(i, j int) bool {
+		return m.before(orders[i].CreatedAt, orders[j].CreatedAt, orders[i].ID, orders[j].ID)
+	})
+	return orders
+}
+
+// updateOrder applies fn to an existing order. Missing orders are ignored, like an UPDATE matching no rows.
+func (m *Memory) updateOrder(orderID uuid.UUID, fn func(*models.Order)) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	order, ok := m.orders[orderID]
+	if !ok {
+		return nil
+	}
+	fn(&order)
+	order.UpdatedAt = m.now().UTC()
+	m.orders[orderID] = order
+	return nil
+}
+
+func (m *Memory) UpdateOrderStatus(orderID uuid.UUID, status string, progress int) error {
+	return m.updateOrder(orderID, func(o *models.Order) {
+		o.Status = status
+		o.Progress = progress
+	})
+}
+
+func (m *Memory) UpdateOrderError(orderID uuid.UUID, errorMsg string) error {
+	return m.updateOrder(orderID, func(o *models.Order) {
+		o.Status = "failed"
+		o.ErrorMessage = sql.NullString{String: errorMsg, Valid: true}
+	})
+}
+
+func (m *Memory) ResetOrderError(orderID uuid.UUID, status string, progress int) error {
+	return m.updateOrder(orderID, func(o *models.Order) {
+		o.Status = status
+		o.Progress = progress
+		o.ErrorMessage = sql.NullString{}
+	})
+}
+
+func (m *Memory) SyncAutoEnhanceOrderData(orderID uuid.UUID, name string, status string, isProcessing, isMerging, isDeleted bool, totalImages int, lastUpdatedAt *time.Time) error {
+	return m.updateOrder(orderID, func(o *models.Order) {
+		o.Name = sql.NullString{String: name, Valid: true}
+		o.AutoEnhanceStatus = sql.NullString{String: status, Valid: true}
+		o.IsProcessing = isProcessing
+		o.IsMerging = isMerging
+		o.IsDeleted = isDeleted
+		o.TotalImages = totalImages
+		o.AutoEnhanceLastUpdatedAt = sql.NullTime{}
+		if lastUpdatedAt != nil {
+			o.AutoEnhanceLastUpdatedAt = sql.NullTime{Time: *lastUpdatedAt, Valid: true}
+		}
+	})
+}
+
+func (m *Memory) DeleteOrder(orderID, userID uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	order, ok := m.orders[orderID]
+	if !ok || order.UserID != userID {
+		return nil
+	}
+	delete(m.orders, orderID)
+	delete(m.purged, orderID)
+
+	// ON DELETE CASCADE / SET NULL
+	for id, file := range m.files {
+		if file.OrderID == orderID {
+			delete(m.files, id)
+		}
+	}
+	for id, bracket := range m.brackets {
+		if bracket.OrderID == orderID {
+			delete(m.brackets, id)
+		}
+	}
+	delete(m.tags, orderID)
+	kept := m.events[:0]
+	for _, e := range m.events {
+		if e.OrderID != orderID {
+			kept = append(kept, e)
+		}
+	}
+	m.events = kept
+	for i := range m.usage {
+		if m.usage[i].orderID.Valid && m.usage[i].orderID.UUID == orderID {
+			m.usage[i].orderID = uuid.NullUUID{}
+		}
+	}
+	return nil
+}
+
+// Order files
+
+func (m *Memory) CreateOrderFile(file *models.OrderFile) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.orders[file.OrderID]; !ok {
+		return fmt.Errorf("order %s does not exist", file.OrderID)
+	}
+	if file.ID == uuid.Nil {
+		file.ID = uuid.New()
+	}
+	if _, exists := m.files[file.ID]; exists {
+		return fmt.Errorf("order file %s already exists", file.ID)
+	}
+
+	stored := *file
+	stored.CreatedAt = m.now().UTC()
+	m.files[stored.ID] = stored
+	m.track(stored.ID)
+	return nil
+}
+
+func (m *Memory) GetOrderFile(fileID, userID uuid.UUID) (*models.OrderFile, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	file, ok := m.files[fileID]
+	if !ok || file.UserID != userID {
+		return nil, notFound("order file")
+	}
+	return &file, nil
+}
+
+func (m *Memory) GetOrderFiles(orderID, userID uuid.UUID) ([]models.OrderFile, error) {
+	files := m.filterFiles(func(f models.OrderFile) bool { return f.OrderID == orderID && f.UserID == userID })
+	for i, j := 0, len(files)-1; i < j; i, j = i+1, j-1 {
+		files[i], files[j] = files[j], files[i]
+	}
+	return files, nil
+}
+
+func (m *Memory) GetOrderFilesByOrderID(orderID uuid.UUID) ([]models.OrderFile, error) {
+	return m.filterFiles(func(f models.OrderFile) bool { return f.OrderID == orderID }), nil
+}
+
+func (m *Memory) GetOrderFileVariant(orderID uuid.UUID, imageID, variant, format string, watermark bool) (*models.OrderFile, error) {
+	files := m.filterFiles(func(f models.OrderFile) bool {
+		return f.OrderID == orderID &&
+			f.AutoEnhanceImageID.Valid && f.AutoEnhanceImageID.String == imageID &&
+			f.Variant.Valid && f.Variant.String == variant &&
+			f.Format.Valid && f.Format.String == format &&
+			f.Watermark.Valid && f.Watermark.Bool == watermark
+	})
+	if len(files) == 0 {
+		return nil, notFound("order file variant")
+	}
+	newest := files[len(files)-1]
+	return &newest, nil
+}
+
+func (m *Memory) ListExpiredPreviewFiles(before time.Time, userID uuid.NullUUID, limit int) ([]models.OrderFile, error) {
+	files := m.filterFiles(func(f models.OrderFile) bool {
+		preview := (f.Watermark.Valid && f.Watermark.Bool) || (!f.Watermark.Valid && !f.IsFinal)
+		return preview && f.CreatedAt.Before(before) && (!userID.Valid || f.UserID == userID.UUID)
+	})
+	if limit > 0 && len(files) > limit {
+		files = files[:limit]
+	}
+	return files, nil
+}
+
+func (m *Memory) ListOrphanedOrderFiles(userID uuid.NullUUID) ([]models.OrderFile, error) {
+	// filterFiles holds the read lock while match runs
+	return m.filterFiles(func(f models.OrderFile) bool {
+		_, ok := m.orders[f.OrderID]
+		return !ok && (!userID.Valid || f.UserID == userID.UUID)
+	}), nil
+}
+
+// filterFiles returns matching files, oldest first
+func (m *Memory) filterFiles(match func(models.OrderFile) bool) []models.OrderFile {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var files []models.OrderFile
+	for _, file := range m.files {
+		if match(file) {
+			files = append(files, file)
+		}
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return m.before(files[i].CreatedAt, files[j].CreatedAt, files[i].ID, files[j].ID)
+	})
+	return files
+}
+
+func (m *Memory) UpdateOrderFileLocation(fileID, userID uuid.UUID, storagePath string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if file, ok := m.files[fileID]; ok {
+		file.StoragePath = storagePath
+		file.UserID = userID
+		m.files[fileID] = file
+	}
+	return nil
+}
+
+func (m *Memory) DeleteOrderFile(fileID uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.files, fileID)
+	return nil
+}
+
+func (m *Memory) DeleteOrderFilesByOrderID(orderID uuid.UUID) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var deleted int64
+	for id, file := range m.files {
+		if file.OrderID == orderID {
+			delete(m.files, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// Brackets
+
+func (m *Memory) CreateBracket(bracket *models.Bracket) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.orders[bracket.OrderID]; !ok {
+		return fmt.Errorf("order %s does not exist", bracket.OrderID)
+	}
+	for _, existing := range m.brackets {
+		if existing.BracketID == bracket.BracketID {
+			return fmt.Errorf("bracket %s already exists", bracket.BracketID)
+		}
+	}
+
+	// The id column is generated by the database; the caller's ID is not used
+	stored := *bracket
+	stored.ID = uuid.New()
+	stored.CreatedAt = m.now().UTC()
+	if stored.Metadata == nil {
+		stored.Metadata = json.RawMessage("{}")
+	}
+	m.brackets[stored.ID] = stored
+	m.track(stored.ID)
+	return nil
+}
+
+func (m *Memory) GetBracketsByOrderID(orderID uuid.UUID) ([]models.Bracket, error) {
+	return m.filterBrackets(func(b models.Bracket) bool { return b.OrderID == orderID }), nil
+}
+
+func (m *Memory) CountBrackets(orderID uuid.UUID) (int, error) {
+	return len(m.filterBrackets(func(b models.Bracket) bool { return b.OrderID == orderID })), nil
+}
+
+func (m *Memory) UpdateBracketImageID(bracketID string, imageID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for id, bracket := range m.brackets {
+		if bracket.BracketID == bracketID {
+			bracket.ImageID = sql.NullString{String: imageID, Valid: true}
+			m.brackets[id] = bracket
+		}
+	}
+	return nil
+}
+
+func (m *Memory) ListOrphanedBrackets() ([]models.Bracket, error) {
+	// filterBrackets holds the read lock while match runs
+	return m.filterBrackets(func(b models.Bracket) bool {
+		_, ok := m.orders[b.OrderID]
+		return !ok
+	}), nil
+}
+
+func (m *Memory) DeleteBracketRecord(id uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.brackets, id)
+	return nil
+}
+
+// filterBrackets returns matching brackets, oldest first
+func (m *Memory) filterBrackets(match func(models.Bracket) bool) []models.Bracket {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var brackets []models.Bracket
+	for _, bracket := range m.brackets {
+		if match(bracket) {
+			brackets = append(brackets, bracket)
+		}
+	}
+	sort.Slice(brackets, func(i, j int) bool {
+		return m.before(brackets[i].CreatedAt, brackets[j].CreatedAt, brackets[i].ID, brackets[j].ID)
+	})
+	return brackets
+}
+
+// Properties
+
+func (m *Memory) CreateProperty(property *models.Property) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now().UTC()
+	property.ID = uuid.New()
+	property.CreatedAt = now
+	property.UpdatedAt = now
+	m.props[property.ID] = *property
+	m.track(property.ID)
+	return nil
+}
+
+func (m *Memory) GetProperty(propertyID, userID uuid.UUID) (*models.Property, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	property, ok := m.props[propertyID]
+	if !ok || property.UserID != userID {
+		return nil, notFound("property")
+	}
+	return &property, nil
+}
+
+func (m *Memory) ListProperties(userID uuid.UUID) ([]models.Property, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var properties []models.Property
+	for _, property := range m.props {
+		if property.UserID == userID {
+			properties = append(properties, property)
+		}
+	}
+	// Newest first
+	sort.Slice(properties, func(i, j int) bool {
+		return m.before(properties[j].CreatedAt, properties[i].CreatedAt, properties[j].ID, properties[i].ID)
+	})
+	return properties, nil
+}
+
+func (m *Memory) UpdateProperty(property *models.Property) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	existing, ok := m.props[property.ID]
+	if !ok || existing.UserID != property.UserID {
+		return fmt.Errorf("failed to update property: %w", sql.ErrNoRows)
+	}
+	existing.Address = property.Address
+	existing.MLSNumber = property.MLSNumber
+	existing.ClientName = property.ClientName
+	existing.Notes = property.Notes
+	existing.UpdatedAt = m.now().UTC()
+	m.props[property.ID] = existing
+	property.UpdatedAt = existing.UpdatedAt
+	return nil
+}
+
+func (m *Memory) DeleteProperty(propertyID, userID uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	property, ok := m.props[propertyID]
+	if !ok || property.UserID != userID {
+		return nil
+	}
+	delete(m.props, propertyID)
+
+	// ON DELETE SET NULL
+	for id, order := range m.orders {
+		if order.PropertyID.Valid && order.PropertyID.UUID == propertyID {
+			order.PropertyID = uuid.NullUUID{}
+			m.orders[id] = order
+		}
+	}
+	return nil
+}
+
+func (m *Memory) SetOrderProperty(orderID, userID uuid.UUID, propertyID uuid.NullUUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	order, ok := m.orders[orderID]
+	if !ok || !m.visibleTo(order, userID) {
+		return fmt.Errorf("failed to set order property: %w", sql.ErrNoRows)
+	}
+	if propertyID.Valid {
+		if property, ok := m.props[propertyID.UUID]; !ok || property.UserID != userID {
+			return fmt.Errorf("failed to set order property: %w", sql.ErrNoRows)
+		}
+	}
+	order.PropertyID = propertyID
+	order.UpdatedAt = m.now().UTC()
+	m.orders[orderID] = order
+	return nil
+}
+
+// Organizations
+
+func (m *Memory) CreateOrganization(org *models.Organization) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now().UTC()
+	org.ID = uuid.New()
+	org.CreatedAt = now
+	org.UpdatedAt = now
+	m.orgs[org.ID] = *org
+	m.track(org.ID)
+	m.members[memberKey{org.ID, org.CreatedBy}] = models.OrganizationMember{
+		OrganizationID: org.ID,
+		UserID:         org.CreatedBy,
+		Role:           models.RoleOwner,
+		CreatedAt:      now,
+	}
+	return nil
+}
+
+func (m *Memory) GetOrganization(organizationID uuid.UUID) (*models.Organization, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	org, ok := m.orgs[organizationID]
+	if !ok {
+		return nil, notFound("organization")
+	}
+	return &org, nil
+}
+
+func (m *Memory) ListOrganizations(userID uuid.UUID) ([]models.OrganizationMembership, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var memberships []models.OrganizationMembership
+	for key, member := range m.members {
+		if key.userID == userID {
+			memberships = append(memberships, models.OrganizationMembership{Organization: m.orgs[key.organizationID], Role: member.Role})
+		}
+	}
+	sort.Slice(memberships, func(i, j int) bool {
+		if memberships[i].Name != memberships[j].Name {
+			return memberships[i].Name < memberships[j].Name
+		}
+		return bytes.Compare(memberships[i].ID[:], memberships[j].ID[:]) < 0
+	})
+	return memberships, nil
+}
+
+func (m *Memory) UpdateOrganization(org *models.Organization) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	existing, ok := m.orgs[org.ID]
+	if !ok {
+		return fmt.Errorf("failed to update organization: %w", sql.ErrNoRows)
+	}
+	existing.Name = org.Name
+	existing.UpdatedAt = m.now().UTC()
+	m.orgs[org.ID] = existing
+	org.UpdatedAt = existing.UpdatedAt
+	return nil
+}
+
+func (m *Memory) DeleteOrganization(organizationID uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.orgs, organizationID)
+	for key := range m.members {
+		if key.organizationID == organizationID {
+			delete(m.members, key)
+		}
+	}
+	for id, key := range m.apiKeys {
+		if key.OrganizationID.Valid && key.OrganizationID.UUID == organizationID {
+			delete(m.apiKeys, id)
+		}
+	}
+	// ON DELETE SET NULL
+	for id, order := range m.orders {
+		if order.OrganizationID.Valid && order.OrganizationID.UUID == organizationID {
+			order.OrganizationID = uuid.NullUUID{}
+			m.orders[id] = order
+		}
+	}
+	return nil
+}
+
+func (m *Memory) GetOrganizationMember(organizationID, userID uuid.UUID) (*models.OrganizationMember, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	member, ok := m.members[memberKey{organizationID, userID}]
+	if !ok {
+		return nil, notFound("organization member")
+	}
+	return &member, nil
+}
+
+func (m *Memory) ListOrganizationMembers(organizationID uuid.UUID) ([]models.OrganizationMember, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var members []models.OrganizationMember
+	for key, member := range m.members {
+		if key.organizationID == organizationID {
+			members = append(members, member)
+		}
+	}
+	// Most privileged first, like the Postgres query
+	sort.Slice(members, func(i, j int) bool {
+		a, b := members[i], members[j]
+		if a.Role != b.Role {
+			return models.RoleAtLeast(a.Role, b.Role)
+		}
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.Before(b.CreatedAt)
+		}
+		return bytes.Compare(a.UserID[:], b.UserID[:]) < 0
+	})
+	return members, nil
+}
+
+func (m *Memory) SetOrganizationMember(member *models.OrganizationMember) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.orgs[member.OrganizationID]; !ok {
+		return fmt.Errorf("failed to set organization member: organization %s does not exist", member.OrganizationID)
+	}
+	key := memberKey{member.OrganizationID, member.UserID}
+	existing, ok := m.members[key]
+	if !ok {
+		existing = models.OrganizationMember{OrganizationID: member.OrganizationID, UserID: member.UserID, CreatedAt: m.now().UTC()}
+	}
+	existing.Role = member.Role
+	m.members[key] = existing
+	member.CreatedAt = existing.CreatedAt
+	return nil
+}
+
+func (m *Memory) RemoveOrganizationMember(organizationID, userID uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.members, memberKey{organizationID, userID})
+	return nil
+}
+
+func (m *Memory) SetOrderOrganization(orderID uuid.UUID, organizationID uuid.NullUUID) error {
+	return m.updateOrder(orderID, func(o *models.Order) {
+		o.OrganizationID = organizationID
+	})
+}
+
+// Tags and saved filters
+
+func (m *Memory) UpdateOrderTags(orderID uuid.UUID, add, remove []string) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	order, ok := m.orders[orderID]
+	if !ok {
+		return nil, fmt.Errorf("failed to update order tags: %w", sql.ErrNoRows)
+	}
+	order.Tags = updateTags(order.Tags, add, remove)
+	order.UpdatedAt = m.now().UTC()
+	m.orders[orderID] = order
+	return order.Tags, nil
+}
+
+func (m *Memory) ListImageTags(orderID uuid.UUID) (map[string][]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	tags := make(map[string][]string, len(m.tags[orderID]))
+	for imageID, imageTags := range m.tags[orderID] {
+		tags[imageID] = append([]string(nil), imageTags...)
+	}
+	return tags, nil
+}
+
+func (m *Memory) UpdateImageTags(orderID uuid.UUID, imageID string, add, remove []string) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.orders[orderID]; !ok {
+		return nil, fmt.Errorf("failed to update image tags: order %s does not exist", orderID)
+	}
+	images := m.tags[orderID]
+	if images == nil {
+		images = make(map[string][]string)
+		m.tags[orderID] = images
+	}
+	tags := updateTags(images[imageID], add, remove)
+	if len(tags) == 0 {
+		delete(images, imageID)
+	} else {
+		images[imageID] = tags
+	}
+	return tags, nil
+}
+
+// updateTags returns a new sorted set of tags, leaving the input untouched
+func updateTags(tags, add, remove []string) []string {
+	updated := make([]string, 0, len(tags)+len(add))
+	for _, tag := range append(append([]string(nil), tags...), add...) {
+		if !contains(remove, tag) && !contains(updated, tag) {
+			updated = append(updated, tag)
+		}
+	}
+	sort.Strings(updated)
+	return updated
+}
+
+func (m *Memory) CreateSavedFilter(filter *models.SavedFilter) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, existing := range m.filters {
+		if existing.UserID == filter.UserID && existing.Name == filter.Name {
+			return fmt.Errorf("failed to create saved filter: %q already exists", filter.Name)
+		}
+	}
+	now := m.now().UTC()
+	filter.ID = uuid.New()
+	filter.CreatedAt = now
+	filter.UpdatedAt = now
+	m.filters[filter.ID] = *filter
+	return nil
+}
+
+func (m *Memory) GetSavedFilter(filterID, userID uuid.UUID) (*models.SavedFilter, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	filter, ok := m.filters[filterID]
+	if !ok || filter.UserID != userID {
+		return nil, notFound("saved filter")
+	}
+	return &filter, nil
+}
+
+func (m *Memory) ListSavedFilters(userID uuid.UUID) ([]models.SavedFilter, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var filters []models.SavedFilter
+	for _, filter := range m.filters {
+		if filter.UserID == userID {
+			filters = append(filters, filter)
+		}
+	}
+	sort.Slice(filters, func(i, j int) bool { return filters[i].Name < filters[j].Name })
+	return filters, nil
+}
+
+func (m *Memory) DeleteSavedFilter(filterID, userID uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if filter, ok := m.filters[filterID]; ok && filter.UserID == userID {
+		delete(m.filters, filterID)
+	}
+	return nil
+}
+
+// API keys
+
+func (m *Memory) CreateAPIKey(key *models.APIKey) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, existing := range m.apiKeys {
+		if existing.KeyHash == key.KeyHash {
+			return fmt.Errorf("failed to create api key: duplicate key hash")
+		}
+	}
+	key.ID = uuid.New()
+	key.CreatedAt = m.now().UTC()
+	key.Scopes = append([]string(nil), key.Scopes...)
+	m.apiKeys[key.ID] = *key
+	m.track(key.ID)
+	return nil
+}
+
+func (m *Memory) GetAPIKey(keyID uuid.UUID) (*models.APIKey, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	key, ok := m.apiKeys[keyID]
+	if !ok {
+		return nil, notFound("api key")
+	}
+	return &key, nil
+}
+
+func (m *Memory) GetAPIKeyByHash(keyHash string) (*models.APIKey, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, key := range m.apiKeys {
+		if key.KeyHash == keyHash {
+			return &key, nil
+		}
+	}
+	return nil, notFound("api key")
+}
+
+func (m *Memory) ListAPIKeys(userID uuid.UUID) ([]models.APIKey, error) {
+	return m.listAPIKeys(func(key models.APIKey) bool { return key.UserID == userID }), nil
+}
+
+func (m *Memory) ListOrganizationAPIKeys(organizationID uuid.UUID) ([]models.APIKey, error) {
+	return m.listAPIKeys(func(key models.APIKey) bool {
+		return key.OrganizationID.Valid && key.OrganizationID.UUID == organizationID
+	}), nil
+}
+
+// listAPIKeys returns the matching keys, newest first
+func (m *Memory) listAPIKeys(match func(models.APIKey) bool) []models.APIKey {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var keys []models.APIKey
+	for _, key := range m.apiKeys {
+		if match(key) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return m.before(keys[j].CreatedAt, keys[i].CreatedAt, keys[j].ID, keys[i].ID)
+	})
+	return keys
+}
+
+func (m *Memory) RevokeAPIKey(keyID uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key, ok := m.apiKeys[keyID]
+	if !ok {
+		return notFound("api key")
+	}
+	if !key.RevokedAt.Valid {
+		key.RevokedAt = sql.NullTime{Time: m.now().UTC(), Valid: true}
+		m.apiKeys[keyID] = key
+	}
+	return nil
+}
+
+func (m *Memory) TouchAPIKey(keyID uuid.UUID, usedAt time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if key, ok := m.apiKeys[keyID]; ok {
+		key.LastUsedAt = sql.NullTime{Time: usedAt.UTC(), Valid: true}
+		m.apiKeys[keyID] = key
+	}
+	return nil
+}
+
+// Audit log
+
+func (m *Memory) CreateAuditEntry(entry *models.AuditEntry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry.ID = uuid.New()
+	entry.CreatedAt = m.now().UTC()
+	if entry.Details == nil {
+		entry.Details = json.RawMessage(`{}`)
+	}
+	m.audit = append(m.audit, *entry)
+	m.track(entry.ID)
+	return nil
+}
+
+func (m *Memory) ListAuditEntries(opts models.AuditListOptions) ([]models.AuditEntry, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var entries []models.AuditEntry
+	// Newest first: entries are appended in order
+	for i := len(m.audit) - 1; i >= 0; i-- {
+		entry := m.audit[i]
+		switch {
+		case opts.ActorID.Valid && entry.ActorID != opts.ActorID:
+			continue
+		case opts.OrderID.Valid && entry.OrderID != opts.OrderID:
+			continue
+		case opts.UserID.Valid && entry.UserID != opts.UserID:
+			continue
+		case opts.Involving.Valid && entry.ActorID != opts.Involving && entry.UserID != opts.Involving:
+			continue
+		case opts.Since != nil && entry.CreatedAt.Before(*opts.Since):
+			continue
+		case opts.Until != nil && !entry.CreatedAt.Before(*opts.Until):
+			continue
+		}
+		if opts.Limit > 0 && len(entries) == opts.Limit {
+			break
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// Order event outbox
+
+func (m *Memory) CreateOrderEvent(event *models.OrderEvent) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.orders[event.OrderID]; ok {
+		m.appendOrderEvent(event)
+	}
+	return nil
+}
+
+func (m *Memory) UpdateOrderStatusWithEvent(orderID uuid.UUID, status string, progress int, event *models.OrderEvent) error {
+	return m.updateOrderWithEvent(orderID, event, func(o *models.Order) {
+		o.Status = status
+		o.Progress = progress
+	})
+}
+
+func (m *Memory) UpdateOrderErrorWithEvent(orderID uuid.UUID, errorMsg string, event *models.OrderEvent) error {
+	return m.updateOrderWithEvent(orderID, event, func(o *models.Order) {
+		o.Status = "failed"
+		o.ErrorMessage = sql.NullString{String: errorMsg, Valid: true}
+	})
+}
+
+func (m *Memory) ResetOrderErrorWithEvent(orderID uuid.UUID, status string, progress int, event *models.OrderEvent) error {
+	return m.updateOrderWithEvent(orderID, event, func(o *models.Order) {
+		o.Status = status
+		o.Progress = progress
+		o.ErrorMessage = sql.NullString{}
+	})
+}
+
+// updateOrderWithEvent is updateOrder, appending event under the same lock
+func (m *Memory) updateOrderWithEvent(orderID uuid.UUID, event *models.OrderEvent, fn func(*models.Order)) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	order, ok := m.orders[orderID]
+	if !ok {
+		return nil
+	}
+	fn(&order)
+	order.UpdatedAt = m.now().UTC()
+	m.orders[orderID] = order
+
+	event.OrderID = orderID
+	m.appendOrderEvent(event)
+	return nil
+}
+
+// appendOrderEvent numbers event after the order's last one and stores it. Called with m.mu held.
+func (m *Memory) appendOrderEvent(event *models.OrderEvent) {
+	event.ID = uuid.New()
+	event.UserID = m.orders[event.OrderID].UserID
+	event.Seq = 1
+	for _, e := range m.events {
+		if e.OrderID == event.OrderID && e.Seq >= event.Seq {
+			event.Seq = e.Seq + 1
+		}
+	}
+	event.CreatedAt = m.now().UTC()
+	event.NextAttemptAt = sql.NullTime{Time: event.CreatedAt, Valid: true}
+	if event.Payload == nil {
+		event.Payload = json.RawMessage(`{}`)
+	}
+	m.events = append(m.events, *event)
+	m.track(event.ID)
+}
+
+func (m *Memory) ListOrderEvents(orderID uuid.UUID, afterSeq int64, limit int) ([]models.OrderEvent, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	// Appended in seq order
+	var events []models.OrderEvent
+	for _, e := range m.events {
+		if e.OrderID == orderID && e.Seq > afterSeq {
+			if len(events) == limit {
+				break
+			}
+			events = append(events, e)
+		}
+	}
+	return events, nil
+}
+
+func (m *Memory) GetOrderEvent(eventID uuid.UUID) (*models.OrderEvent, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, e := range m.events {
+		if e.ID == eventID {
+			return &e, nil
+		}
+	}
+	return nil, notFound("order event")
+}
+
+func (m *Memory) ClaimOrderEvents(now time.Time, lease time.Duration, limit int) ([]models.OrderEvent, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var events []models.OrderEvent
+	for i := range m.events {
+		e := &m.events[i]
+		if e.DispatchedAt.Valid || !e.NextAttemptAt.Valid || e.NextAttemptAt.Time.After(now) {
+			continue
+		}
+		if len(events) == limit {
+			break
+		}
+		e.Attempts++
+		e.NextAttemptAt = sql.NullTime{Time: now.Add(lease).UTC(), Valid: true}
+		events = append(events, *e)
+	}
+	return events, nil
+}
+
+func (m *Memory) MarkOrderEventDelivered(eventID uuid.UUID) error {
+	return m.updateOrderEvent(eventID, func(e *models.OrderEvent) {
+		e.DeliveredAt = sql.NullTime{Time: m.now().UTC(), Valid: true}
+	})
+}
+
+func (m *Memory) MarkOrderEventBroadcast(eventID uuid.UUID) error {
+	return m.updateOrderEvent(eventID, func(e *models.OrderEvent) {
+		e.BroadcastAt = sql.NullTime{Time: m.now().UTC(), Valid: true}
+	})
+}
+
+func (m *Memory) MarkOrderEventDispatched(eventID uuid.UUID) error {
+	return m.updateOrderEvent(eventID, func(e *models.OrderEvent) {
+		e.DispatchedAt = sql.NullTime{Time: m.now().UTC(), Valid: true}
+		e.NextAttemptAt = sql.NullTime{}
+		e.LastError = sql.NullString{}
+	})
+}
+
+func (m *Memory) MarkOrderEventFailed(eventID uuid.UUID, errorMsg string, retryAt *time.Time) error {
+	return m.updateOrderEvent(eventID, func(e *models.OrderEvent) {
+		e.NextAttemptAt = sql.NullTime{}
+		if retryAt != nil {
+			e.NextAttemptAt = sql.NullTime{Time: retryAt.UTC(), Valid: true}
+		}
+		e.LastError = sql.NullString{String: errorMsg, Valid: true}
+	})
+}
+
+func (m *Memory) updateOrderEvent(eventID uuid.UUID, fn func(*models.OrderEvent)) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i := range m.events {
+		if m.events[i].ID == eventID {
+			fn(&m.events[i])
+		}
+	}
+	return nil
+}
+
+// Rate limits (ratelimit.Memory has its own lock)
+
+func (m *Memory) IncrementRateLimit(key string, windowStart time.Time, window time.Duration) (int, error) {
+	return m.limits.IncrementRateLimit(key, windowStart, window)
+}
+
+func (m *Memory) PruneRateLimits(before time.Time) (int64, error) {
+	return m.limits.PruneRateLimits(before)
+}
+
+// Quota usage
+
+func (m *Memory) GetUserPlan(userID uuid.UUID) (string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.plans[userID], nil
+}
+
+func (m *Memory) GetUsage(userID uuid.UUID, periodStart time.Time) (*quota.Usage, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var usage quota.Usage
+	for _, order := range m.orders {
+		if order.UserID == userID && !order.IsDeleted && !order.DeletedAt.Valid {
+			usage.ActiveOrders++
+		}
+	}
+	for _, event := range m.usage {
+		if event.userID == userID && event.kind == quota.UsageKindUpload && !event.createdAt.Before(periodStart) {
+			usage.UploadedBytesThisMonth += event.bytes
+		}
+	}
+	for _, file := range m.files {
+		if file.UserID == userID {
+			usage.StoredBytes += file.FileSize.Int64
+		}
+	}
+	return &usage, nil
+}
+
+func (m *Memory) RecordUsageEvent(userID, orderID uuid.UUID, kind string, bytes int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.usage = append(m.usage, usageEvent{
+		userID:    userID,
+		orderID:   uuid.NullUUID{UUID: orderID, Valid: true},
+		kind:      kind,
+		bytes:     bytes,
+		createdAt: m.now().UTC(),
+	})
+	return nil
+}
+
+// Credits
+
+func (m *Memory) GetCreditAccount(userID uuid.UUID, periodStart time.Time) (*credits.Account, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	account := m.creditAccount(userID, periodStart)
+	return &account, nil
+}
+
+// creditAccount is called with m.mu held
+func (m *Memory) creditAccount(userID uuid.UUID, periodStart time.Time) credits.Account {
+	account := credits.Account{Balance: m.credits[userID].balance, MonthlyBudget: m.credits[userID].budget}
+	for _, e := range m.ledger {
+		if e.UserID == userID && e.Kind == credits.KindSpend && !e.RefundedAt.Valid && !e.CreatedAt.Before(periodStart) {
+			account.SpentThisMonth++
+		}
+	}
+	return account
+}
+
+func (m *Memory) SpendCredit(spend credits.Spend, periodStart time.Time, check func(credits.Account) error) (*models.CreditLedgerEntry, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, e := range m.ledger {
+		if e.Kind == credits.KindSpend && !e.RefundedAt.Valid && e.OrderID.UUID == spend.OrderID &&
+			e.ImageID.String == spend.ImageID && e.Quality.String == spend.Quality && e.Format.String == spend.Format {
+			return &e, false, nil
+		}
+	}
+	if err := check(m.creditAccount(spend.UserID, periodStart)); err != nil {
+		return nil, false, err
+	}
+
+	entry := m.appendCreditEntry(models.CreditLedgerEntry{
+		UserID:  spend.UserID,
+		Kind:    credits.KindSpend,
+		Amount:  -1,
+		OrderID: uuid.NullUUID{UUID: spend.OrderID, Valid: true},
+		ImageID: sql.NullString{String: spend.ImageID, Valid: true},
+		Quality: sql.NullString{String: spend.Quality, Valid: true},
+		Format:  sql.NullString{String: spend.Format, Valid: true},
+	})
+	return &entry, true, nil
+}
+
+func (m *Memory) RefundCredit(entryID uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i := range m.ledger {
+		e := &m.ledger[i]
+		if e.ID == entryID && e.Kind == credits.KindSpend && !e.RefundedAt.Valid {
+			e.RefundedAt = sql.NullTime{Time: m.now().UTC(), Valid: true}
+			account := m.credits[e.UserID]
+			account.balance -= e.Amount
+			m.credits[e.UserID] = account
+		}
+	}
+	return nil
+}
+
+func (m *Memory) GrantCredits(userID uuid.UUID, amount int, reason string, actorID uuid.NullUUID) (*models.CreditLedgerEntry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry := m.appendCreditEntry(models.CreditLedgerEntry{
+		UserID:  userID,
+		Kind:    credits.KindGrant,
+		Amount:  amount,
+		Reason:  sql.NullString{String: reason, Valid: reason != ""},
+		ActorID: actorID,
+	})
+	return &entry, nil
+}
+
+// appendCreditEntry records e and applies it to the balance. Called with m.mu held.
+func (m *Memory) appendCreditEntry(e models.CreditLedgerEntry) models.CreditLedgerEntry {
+	e.ID = uuid.New()
+	e.CreatedAt = m.now().UTC()
+	m.ledger = append(m.ledger, e)
+	m.track(e.ID)
+
+	account := m.credits[e.UserID]
+	account.balance += e.Amount
+	m.credits[e.UserID] = account
+	return e
+}
+
+func (m *Memory) SetCreditBudget(userID uuid.UUID, monthlyBudget *int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	account := m.credits[userID]
+	account.budget = monthlyBudget
+	m.credits[userID] = account
+	return nil
+}
+
+func (m *Memory) ListCreditLedger(userID uuid.UUID, limit int) ([]models.CreditLedgerEntry, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var entries []models.CreditLedgerEntry
+	for i := len(m.ledger) - 1; i >= 0 && len(entries) < limit; i-- {
+		if m.ledger[i].UserID == userID {
+			entries = append(entries, m.ledger[i])
+		}
+	}
+	return entries, nil
+}
+
+// containsAll reports whether values includes every one of wanted
+func containsAll(values, wanted []string) bool {
+	for _, w := range wanted {
+		if !contains(values, w) {
+			return false
+		}
+	}
+	return true
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
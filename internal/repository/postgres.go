@@ -0,0 +1,17 @@
+package repository
+
+import (
+	"instant-hdr-backend/internal/supabase"
+)
+
+// supabase.DatabaseClient is the Postgres implementation
+var _ Repository = (*supabase.DatabaseClient)(nil)
+
+// NewPostgres connects to Postgres (the Supabase database or any other)
+func NewPostgres(connectionString string) (Repository, error) {
+	client, err := supabase.NewDatabaseClient(connectionString)
+	if err != nil {
+		return nil, err
+	}
+	return client, nil
+}
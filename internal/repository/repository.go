@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"instant-hdr-backend/internal/credits"
+	"instant-hdr-backend/internal/models"
+	"instant-hdr-backend/internal/quota"
+	"instant-hdr-backend/internal/ratelimit"
+)
+
+// OrderRepository stores orders. Lookups that find nothing return an error.
+// GetOrder, GetTrashedOrder and ListOrders find the user's personal orders and the orders of their organizations;
+// the mutations are keyed by the order's creator (order.UserID) and leave role checks to the caller.
+type OrderRepository interface {
+	CreateOrder(orderID, userID uuid.UUID, metadata map[string]interface{}) (*models.Order, error)
+	GetOrder(orderID, userID uuid.UUID) (*models.Order, error)                     // Orders in the trash are not found
+	GetTrashedOrder(orderID, userID uuid.UUID) (*models.Order, error)              // Only orders in the trash are found
+	GetOrderByAutoEnhanceOrderID(autoenhanceOrderID string) (*models.Order, error) // No user check - used for webhooks
+	ListOrders(userID uuid.UUID, opts models.OrderListOptions) ([]models.Order, error)
+	SearchOrders(opts models.OrderListOptions) ([]models.Order, error) // Every user's orders - used by admins
+	ListAllOrders(userID uuid.NullUUID) ([]models.Order, error)
+	ListPurgeableOrders(before time.Time, userID uuid.NullUUID) ([]models.Order, error)
+	MarkOrderStoragePurged(orderID uuid.UUID) error // ListPurgeableOrders then skips the order unless it is in the trash
+	UpdateOrderStatus(orderID uuid.UUID, status string, progress int) error
+	UpdateOrderError(orderID uuid.UUID, errorMsg string) error
+	ResetOrderError(orderID uuid.UUID, status string, progress int) error // Clears error_message
+	SyncAutoEnhanceOrderData(orderID uuid.UUID, name string, status string, isProcessing, isMerging, isDeleted bool, totalImages int, lastUpdatedAt *time.Time) error
+	TrashOrder(orderID, userID uuid.UUID) error
+	RestoreOrder(orderID, userID uuid.UUID) error
+	DeleteOrder(orderID, userID uuid.UUID) error // Permanent. Also removes the order's files and brackets
+}
+
+// OrderFileRepository stores order_files records.
+// GetOrderFileVariant wraps sql.ErrNoRows when the variant has not been stored yet.
+type OrderFileRepository interface {
+	CreateOrderFile(file *models.OrderFile) error
+	GetOrderFile(fileID, userID uuid.UUID) (*models.OrderFile, error)
+	GetOrderFiles(orderID, userID uuid.UUID) ([]models.OrderFile, error)
+	GetOrderFilesByOrderID(orderID uuid.UUID) ([]models.OrderFile, error)
+	GetOrderFileVariant(orderID uuid.UUID, imageID, variant, format string, watermark bool) (*models.OrderFile, error)
+	ListExpiredPreviewFiles(before time.Time, userID uuid.NullUUID, limit int) ([]models.OrderFile, error)
+	ListOrphanedOrderFiles(userID uuid.NullUUID) ([]models.OrderFile, error)
+	UpdateOrderFileLocation(fileID, userID uuid.UUID, storagePath string) error
+	DeleteOrderFile(fileID uuid.UUID) error
+	DeleteOrderFilesByOrderID(orderID uuid.UUID) (int64, error)
+}
+
+// BracketRepository stores uploaded bracket records
+type BracketRepository interface {
+	CreateBracket(bracket *models.Bracket) error
+	GetBracketsByOrderID(orderID uuid.UUID) ([]models.Bracket, error)
+	CountBrackets(orderID uuid.UUID) (int, error)
+	UpdateBracketImageID(bracketID string, imageID string) error
+	ListOrphanedBrackets() ([]models.Bracket, error)
+	DeleteBracketRecord(id uuid.UUID) error
+}
+
+// PropertyRepository stores properties. Orders of a property are listed with OrderListOptions.PropertyID.
+type PropertyRepository interface {
+	CreateProperty(property *models.Property) error // Sets ID, CreatedAt and UpdatedAt
+	GetProperty(propertyID, userID uuid.UUID) (*models.Property, error)
+	ListProperties(userID uuid.UUID) ([]models.Property, error)
+	UpdateProperty(property *models.Property) error
+	DeleteProperty(propertyID, userID uuid.UUID) error                          // Unlinks the property's orders
+	SetOrderProperty(orderID, userID uuid.UUID, propertyID uuid.NullUUID) error // The order must be visible to userID, the property belong to them
+}
+
+// OrganizationRepository stores organizations and their members.
+// GetOrganizationMember wraps sql.ErrNoRows when the user is not a member.
+type OrganizationRepository interface {
+	CreateOrganization(org *models.Organization) error // Sets ID and timestamps; org.CreatedBy becomes its owner
+	GetOrganization(organizationID uuid.UUID) (*models.Organization, error)
+	ListOrganizations(userID uuid.UUID) ([]models.OrganizationMembership, error)
+	UpdateOrganization(org *models.Organization) error
+	DeleteOrganization(organizationID uuid.UUID) error // Its orders go back to their creators
+	GetOrganizationMember(organizationID, userID uuid.UUID) (*models.OrganizationMember, error)
+	ListOrganizationMembers(organizationID uuid.UUID) ([]models.OrganizationMember, error)
+	SetOrganizationMember(member *models.OrganizationMember) error // Adds the member or changes their role
+	RemoveOrganizationMember(organizationID, userID uuid.UUID) error
+	SetOrderOrganization(orderID uuid.UUID, organizationID uuid.NullUUID) error
+}
+
+// TagRepository stores order and image tags and saved order filters. Tags are normalized by the caller.
+// Orders are filtered by tag with OrderListOptions.Tags.
+type TagRepository interface {
+	UpdateOrderTags(orderID uuid.UUID, add, remove []string) ([]string, error) // Returns the order's tags afterwards, sorted
+	ListImageTags(orderID uuid.UUID) (map[string][]string, error)              // Image ID -> sorted tags
+	UpdateImageTags(orderID uuid.UUID, imageID string, add, remove []string) ([]string, error)
+	CreateSavedFilter(filter *models.SavedFilter) error // Sets ID and timestamps; names are unique per user
+	GetSavedFilter(filterID, userID uuid.UUID) (*models.SavedFilter, error)
+	ListSavedFilters(userID uuid.UUID) ([]models.SavedFilter, error)
+	DeleteSavedFilter(filterID, userID uuid.UUID) error
+}
+
+// APIKeyRepository stores API keys. Lookups that find nothing wrap sql.ErrNoRows.
+type APIKeyRepository interface {
+	CreateAPIKey(key *models.APIKey) error                  // Sets ID and CreatedAt
+	GetAPIKey(keyID uuid.UUID) (*models.APIKey, error)      // Revoked and expired keys are found too
+	GetAPIKeyByHash(keyHash string) (*models.APIKey, error) // Revoked and expired keys are found too
+	ListAPIKeys(userID uuid.UUID) ([]models.APIKey, error)  // Keys the user created, newest first
+	ListOrganizationAPIKeys(organizationID uuid.UUID) ([]models.APIKey, error)
+	RevokeAPIKey(keyID uuid.UUID) error
+	TouchAPIKey(keyID uuid.UUID, usedAt time.Time) error // Records last_used_at
+}
+
+// AuditRepository appends to and reads the audit log. Entries are never changed or deleted.
+type AuditRepository interface {
+	CreateAuditEntry(entry *models.AuditEntry) error // Sets ID and CreatedAt
+	ListAuditEntries(opts models.AuditListOptions) ([]models.AuditEntry, error)
+}
+
+// OrderEventRepository stores the order_events outbox. The *WithEvent methods change the order and append the event
+// in one transaction, setting the event's ID, Seq and CreatedAt; like UpdateOrderStatus, they do nothing for a missing order.
+type OrderEventRepository interface {
+	CreateOrderEvent(event *models.OrderEvent) error // An event without an order change
+	UpdateOrderStatusWithEvent(orderID uuid.UUID, status string, progress int, event *models.OrderEvent) error
+	UpdateOrderErrorWithEvent(orderID uuid.UUID, errorMsg string, event *models.OrderEvent) error
+	ResetOrderErrorWithEvent(orderID uuid.UUID, status string, progress int, event *models.OrderEvent) error
+	ListOrderEvents(orderID uuid.UUID, afterSeq int64, limit int) ([]models.OrderEvent, error) // By seq
+	GetOrderEvent(eventID uuid.UUID) (*models.OrderEvent, error)                               // Wraps sql.ErrNoRows if it is gone
+	// ClaimOrderEvents returns up to limit undispatched events due at now, oldest first, counting an attempt for each
+	// and holding them for lease: events not marked by then are claimed again.
+	ClaimOrderEvents(now time.Time, lease time.Duration, limit int) ([]models.OrderEvent, error)
+	MarkOrderEventDelivered(eventID uuid.UUID) error                                   // The bus took the event
+	MarkOrderEventBroadcast(eventID uuid.UUID) error                                   // The sink (Supabase broadcast) accepted the event
+	MarkOrderEventDispatched(eventID uuid.UUID) error                                  // Every step is done
+	MarkOrderEventFailed(eventID uuid.UUID, errorMsg string, retryAt *time.Time) error // nil retryAt gives up
+}
+
+// Repository is everything the handlers and services persist.
+// supabase.DatabaseClient is the Postgres implementation; Memory keeps everything in process.
+type Repository interface {
+	OrderRepository
+	OrderFileRepository
+	BracketRepository
+	PropertyRepository
+	OrganizationRepository
+	TagRepository
+	APIKeyRepository
+	AuditRepository
+	OrderEventRepository
+	quota.Store
+	credits.Store
+	ratelimit.Store
+	Close() error
+}
@@ -0,0 +1,298 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"instant-hdr-backend/internal/models"
+	"instant-hdr-backend/internal/repository"
+	"instant-hdr-backend/internal/storage"
+)
+
+// Consistency issue kinds
+const (
+	IssueOrphanedObject  = "orphaned_object"  // Object in storage with no order_files row
+	IssueDanglingRow     = "dangling_row"     // order_files row whose object does not exist
+	IssueMissingPath     = "missing_path"     // order_files row without storage_path or user_id
+	IssueOrphanedRow     = "orphaned_row"     // order_files row whose order does not exist
+	IssueOrphanedBracket = "orphaned_bracket" // brackets row whose order does not exist
+)
+
+// Repairs applied for an issue
+const (
+	ActionDeleteObject  = "delete_object"
+	ActionDeleteRow     = "delete_row"
+	ActionRelinkRow     = "relink_row" // Set storage_path/user_id to the object found at the expected path
+	ActionDeleteBracket = "delete_bracket"
+	ActionNone          = "none" // Reported only - needs a human
+)
+
+// DefaultMinObjectAge protects objects whose order_files row is still being written
+const DefaultMinObjectAge = time.Hour
+
+// ConsistencyOptions configures a consistency check
+type ConsistencyOptions struct {
+	Repair       bool          // false = dry run, nothing is changed
+	UserID       uuid.NullUUID // Limit the check to one user's prefix and orders
+	MinObjectAge time.Duration // Objects modified more recently are never reported as orphaned
+}
+
+// ConsistencyChecker compares the storage bucket with the order_files and brackets tables
+type ConsistencyChecker struct {
+	dbClient      repository.Repository
+	storageClient storage.Storage
+	mu            sync.Mutex // One run at a time
+}
+
+func NewConsistencyChecker(dbClient repository.Repository, storageClient storage.Storage) *ConsistencyChecker {
+	return &ConsistencyChecker{
+		dbClient:      dbClient,
+		storageClient: storageClient,
+	}
+}
+
+// Check walks users/{id}/orders/{id}/ and the tables and reports (or repairs) every mismatch.
+// Listing failures abort the run, since a partial listing would make every row look dangling.
+func (c *ConsistencyChecker) Check(opts ConsistencyOptions) *models.ConsistencyReport {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	report := &models.ConsistencyReport{
+		DryRun:    !opts.Repair,
+		StartedAt: time.Now().UTC(),
+		Issues:    make([]models.ConsistencyIssue, 0),
+	}
+	defer func() { report.FinishedAt = time.Now().UTC() }()
+
+	prefix := "users/"
+	if opts.UserID.Valid {
+		prefix = fmt.Sprintf("users/%s/", opts.UserID.UUID)
+		report.UserID = opts.UserID.UUID.String()
+	}
+
+	orders, err := c.dbClient.ListAllOrders(opts.UserID)
+	if err != nil {
+		report.Errors = append(report.Errors, err.Error())
+		return report
+	}
+	report.OrdersChecked = len(orders)
+
+	objectList, err := c.storageClient.ListFiles(prefix)
+	if err != nil {
+		report.Errors = append(report.Errors, fmt.Sprintf("failed to list storage: %v", err))
+		return report
+	}
+	report.ObjectsChecked = len(objectList)
+	objects := make(map[string]storage.Object, len(objectList))
+	for _, obj := range objectList {
+		objects[obj.Path] = obj
+	}
+
+	knownOrders := make(map[uuid.UUID]bool, len(orders))
+	skippedOrders := make(map[uuid.UUID]bool) // Rows could not be read - leave their objects alone
+	referenced := make(map[string]bool)
+
+	for _, order := range orders {
+		knownOrders[order.ID] = true
+
+		files, err := c.dbClient.GetOrderFilesByOrderID(order.ID)
+		if err != nil {
+			skippedOrders[order.ID] = true
+			report.Errors = append(report.Errors, fmt.Sprintf("order %s: %v", order.ID, err))
+			continue
+		}
+		report.RowsChecked += len(files)
+
+		for _, file := range files {
+			c.checkRow(report, order, file, prefix, objects, referenced)
+		}
+	}
+
+	orphanedRows, err := c.dbClient.ListOrphanedOrderFiles(opts.UserID)
+	if err != nil {
+		report.Errors = append(report.Errors, err.Error())
+	}
+	for _, file := range orphanedRows {
+		report.RowsChecked++
+		fileID := file.ID
+		c.apply(report, models.ConsistencyIssue{
+			Kind:        IssueOrphanedRow,
+			OrderID:     file.OrderID.String(),
+			FileID:      file.ID.String(),
+			StoragePath: file.StoragePath,
+			Bytes:       file.FileSize.Int64,
+			Detail:      "order does not exist",
+			Action:      ActionDeleteRow,
+		}, func() error { return c.dbClient.DeleteOrderFile(fileID) })
+	}
+
+	// Brackets carry no user_id, so they are only checked in a full run
+	if !opts.UserID.Valid {
+		brackets, err := c.dbClient.ListOrphanedBrackets()
+		if err != nil {
+			report.Errors = append(report.Errors, err.Error())
+		}
+		for _, bracket := range brackets {
+			bracketID := bracket.ID
+			c.apply(report, models.ConsistencyIssue{
+				Kind:      IssueOrphanedBracket,
+				OrderID:   bracket.OrderID.String(),
+				BracketID: bracket.BracketID,
+				Detail:    "order does not exist",
+				Action:    ActionDeleteBracket,
+			}, func() error { return c.dbClient.DeleteBracketRecord(bracketID) })
+		}
+	}
+
+	// Whatever no row points to is orphaned
+	paths := make([]string, 0, len(objects))
+	for p := range objects {
+		if !referenced[p] {
+			paths = append(paths, p)
+		}
+	}
+	sort.Strings(paths)
+
+	cutoff := time.Now().Add(-opts.MinObjectAge)
+	for _, p := range paths {
+		obj := objects[p]
+		if opts.MinObjectAge > 0 && obj.LastModified.After(cutoff) {
+			continue
+		}
+
+		issue := models.ConsistencyIssue{
+			Kind:        IssueOrphanedObject,
+			StoragePath: p,
+			Bytes:       obj.Size,
+			Detail:      "no order_files row references this object",
+			Action:      ActionDeleteObject,
+		}
+		if orderID, ok := orderIDFromPath(p); ok {
+			issue.OrderID = orderID.String()
+			if skippedOrders[orderID] {
+				continue
+			}
+			if !knownOrders[orderID] {
+				issue.Detail = "order does not exist"
+			}
+		} else {
+			issue.Detail = "object is outside the users/{user_id}/orders/{order_id}/ layout"
+			issue.Action = ActionNone
+		}
+
+		path := p
+		c.apply(report, issue, func() error { return c.storageClient.DeleteFile(path) })
+	}
+
+	return report
+}
+
+// checkRow verifies a single order_files row of an existing order
+func (c *ConsistencyChecker) checkRow(report *models.ConsistencyReport, order models.Order, file models.OrderFile, prefix string, objects map[string]storage.Object, referenced map[string]bool) {
+	issue := models.ConsistencyIssue{
+		OrderID:     order.ID.String(),
+		FileID:      file.ID.String(),
+		StoragePath: file.StoragePath,
+		Bytes:       file.FileSize.Int64,
+	}
+	fileID := file.ID
+
+	// Rows written without a path or owner: relink them if the object is where it should be
+	if file.StoragePath == "" || file.UserID == uuid.Nil {
+		candidate := file.StoragePath
+		if candidate == "" && file.Filename != "" {
+			candidate = storage.OrderPath(order.UserID, order.ID, file.Filename)
+		}
+
+		issue.Kind = IssueMissingPath
+		if candidate != "" && c.exists(candidate, prefix, objects) {
+			referenced[candidate] = true
+			issue.StoragePath = candidate
+			issue.Detail = "row is missing storage_path or user_id; object found at the expected path"
+			issue.Action = ActionRelinkRow
+			c.apply(report, issue, func() error {
+				return c.dbClient.UpdateOrderFileLocation(fileID, order.UserID, candidate)
+			})
+			return
+		}
+
+		issue.Detail = "row is missing storage_path or user_id and no object was found"
+		issue.Action = ActionDeleteRow
+		c.apply(report, issue, func() error { return c.dbClient.DeleteOrderFile(fileID) })
+		return
+	}
+
+	if _, ok := objects[file.StoragePath]; ok {
+		referenced[file.StoragePath] = true
+		return
+	}
+
+	issue.Kind = IssueDanglingRow
+	if !strings.HasPrefix(file.StoragePath, prefix) {
+		// Outside the listed prefix - a failed Stat may be a permissions problem, not a missing object
+		if _, err := c.storageClient.Stat(file.StoragePath); err == nil {
+			return
+		}
+		issue.Detail = "object outside the checked prefix could not be found"
+		issue.Action = ActionNone
+		c.apply(report, issue, nil)
+		return
+	}
+
+	issue.Detail = "object does not exist"
+	issue.Action = ActionDeleteRow
+	c.apply(report, issue, func() error { return c.dbClient.DeleteOrderFile(fileID) })
+}
+
+// exists checks the listing for paths under prefix and asks storage for anything else
+func (c *ConsistencyChecker) exists(storagePath, prefix string, objects map[string]storage.Object) bool {
+	if _, ok := objects[storagePath]; ok {
+		return true
+	}
+	if strings.HasPrefix(storagePath, prefix) {
+		return false
+	}
+	_, err := c.storageClient.Stat(storagePath)
+	return err == nil
+}
+
+// apply records the issue and, unless this is a dry run, performs its repair
+func (c *ConsistencyChecker) apply(report *models.ConsistencyReport, issue models.ConsistencyIssue, repair func() error) {
+	if !report.DryRun && repair != nil && issue.Action != ActionNone {
+		if err := repair(); err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("%s %s: %v", issue.Action, issueSubject(issue), err))
+		} else {
+			issue.Repaired = true
+			report.Repaired++
+		}
+	}
+	report.Issues = append(report.Issues, issue)
+}
+
+func issueSubject(issue models.ConsistencyIssue) string {
+	switch {
+	case issue.FileID != "":
+		return "file " + issue.FileID
+	case issue.BracketID != "":
+		return "bracket " + issue.BracketID
+	default:
+		return issue.StoragePath
+	}
+}
+
+// orderIDFromPath extracts the order ID from users/{user_id}/orders/{order_id}/...
+func orderIDFromPath(storagePath string) (uuid.UUID, bool) {
+	parts := strings.Split(storagePath, "/")
+	if len(parts) < 5 || parts[0] != "users" || parts[2] != "orders" {
+		return uuid.Nil, false
+	}
+	orderID, err := uuid.Parse(parts[3])
+	if err != nil {
+		return uuid.Nil, false
+	}
+	return orderID, true
+}
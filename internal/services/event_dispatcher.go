@@ -0,0 +1,251 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"instant-hdr-backend/internal/events"
+	"instant-hdr-backend/internal/models"
+	"instant-hdr-backend/internal/repository"
+)
+
+const (
+	dispatchBatchSize   = 100
+	dispatchLease       = time.Minute // A claimed event is dispatched again after this if its publish never finished
+	maxDispatchAttempts = 10
+	dispatchBackoffBase = 2 * time.Second
+	dispatchBackoffMax  = 10 * time.Minute
+)
+
+// EventDispatcher is how order events are published. Publish* validate an event and store it in the order_events
+// outbox, with the order change it announces; dispatching then delivers it to the SSE subscribers of every instance
+// (through the bus) and to the sink (Supabase broadcast). Each step is retried until it succeeds, backing off between
+// attempts, and is not repeated once it has. Every event goes to the
+// order's channel and is mirrored to its owner's user channel. Events are kept after dispatch so clients can catch up.
+type EventDispatcher struct {
+	store    repository.Repository
+	bus      *events.Bus
+	sink     events.Publisher
+	interval time.Duration
+	wake     chan struct{}
+	mu       sync.Mutex // One pass at a time
+	now      func() time.Time
+}
+
+// NewEventDispatcher polls the outbox every interval; Wake dispatches right away. sink may be nil.
+func NewEventDispatcher(store repository.Repository, bus *events.Bus, sink events.Publisher, interval time.Duration) *EventDispatcher {
+	return &EventDispatcher{
+		store:    store,
+		bus:      bus,
+		sink:     sink,
+		interval: interval,
+		wake:     make(chan struct{}, 1),
+		now:      time.Now,
+	}
+}
+
+// Publish stores p, an event without an order change, for dispatch. Failures are logged and returned.
+func (d *EventDispatcher) Publish(orderID uuid.UUID, p events.Payload) error {
+	event, err := events.Encode(orderID, "", p)
+	if err == nil {
+		err = d.store.CreateOrderEvent(event)
+	}
+	return d.stored(orderID, p, err)
+}
+
+// PublishStatus sets the order's status and progress and stores p in the same transaction. An invalid event is
+// dropped, but the status is still set. Failures are logged and returned.
+func (d *EventDispatcher) PublishStatus(orderID uuid.UUID, status string, progress int, p events.Payload) error {
+	event, err := events.Encode(orderID, status, p)
+	if err != nil {
+		if updateErr := d.store.UpdateOrderStatus(orderID, status, progress); updateErr != nil {
+			log.Printf("[Events] Failed to update order %s: %v", orderID, updateErr)
+		}
+	} else {
+		err = d.store.UpdateOrderStatusWithEvent(orderID, status, progress, event)
+	}
+	return d.stored(orderID, p, err)
+}
+
+// PublishError marks the order failed with errorMsg and stores p in the same transaction. An invalid event is
+// dropped, but the order is still marked failed. Failures are logged and returned.
+func (d *EventDispatcher) PublishError(orderID uuid.UUID, errorMsg string, p events.Payload) error {
+	event, err := events.Encode(orderID, "failed", p)
+	if err != nil {
+		if updateErr := d.store.UpdateOrderError(orderID, errorMsg); updateErr != nil {
+			log.Printf("[Events] Failed to update order %s: %v", orderID, updateErr)
+		}
+	} else {
+		err = d.store.UpdateOrderErrorWithEvent(orderID, errorMsg, event)
+	}
+	return d.stored(orderID, p, err)
+}
+
+// PublishReset clears the order's error, puts it back to status and stores the order_reset event in the same
+// transaction. Failures are logged and returned.
+func (d *EventDispatcher) PublishReset(orderID uuid.UUID, status string, progress int) error {
+	p := &events.OrderReset{}
+	event, err := events.Encode(orderID, status, p)
+	if err == nil {
+		err = d.store.ResetOrderErrorWithEvent(orderID, status, progress, event)
+	}
+	return d.stored(orderID, p, err)
+}
+
+// stored wakes the dispatcher for a stored event, or logs why it wasn't stored
+func (d *EventDispatcher) stored(orderID uuid.UUID, p events.Payload, err error) error {
+	if err != nil {
+		log.Printf("[Events] Failed to publish %s for order %s: %v", p.EventName(), orderID, err)
+		return err
+	}
+	d.Wake()
+	return nil
+}
+
+// Start dispatches immediately, then on every Wake and every interval until stop is closed
+func (d *EventDispatcher) Start(stop <-chan struct{}) {
+	go func() {
+		ticker := time.NewTicker(d.interval)
+		defer ticker.Stop()
+
+		for {
+			d.Dispatch()
+
+			select {
+			case <-stop:
+				return
+			case <-d.wake:
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Wake asks for a pass after new events were stored. It never blocks; a nil dispatcher ignores it.
+func (d *EventDispatcher) Wake() {
+	if d == nil {
+		return
+	}
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Dispatch publishes the due events and returns how many were fully dispatched
+func (d *EventDispatcher) Dispatch() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	dispatched := 0
+	for {
+		claimed, err := d.store.ClaimOrderEvents(d.now(), dispatchLease, dispatchBatchSize)
+		if err != nil {
+			log.Printf("[Events] Failed to claim order events: %v", err)
+			return dispatched
+		}
+		for _, e := range claimed {
+			if d.publish(e) {
+				dispatched++
+			}
+		}
+		if len(claimed) < dispatchBatchSize {
+			return dispatched
+		}
+	}
+}
+
+// publish runs the steps of e that haven't succeeded yet, delivery then broadcast, and records the outcome,
+// reporting whether e is now fully dispatched
+func (d *EventDispatcher) publish(e models.OrderEvent) bool {
+	local := events.FromOrderEvent(e)
+	var errs []error
+	if !e.DeliveredAt.Valid {
+		if err := d.deliver(e, local); err != nil {
+			errs = append(errs, err)
+		} else if err := d.store.MarkOrderEventDelivered(e.ID); err != nil {
+			log.Printf("[Events] Failed to mark %s #%d of order %s delivered: %v", e.Event, e.Seq, e.OrderID, err)
+		}
+	}
+	if !e.BroadcastAt.Valid {
+		if err := d.broadcast(e, local.Data); err != nil {
+			errs = append(errs, err)
+		} else if err := d.store.MarkOrderEventBroadcast(e.ID); err != nil {
+			log.Printf("[Events] Failed to mark %s #%d of order %s broadcast: %v", e.Event, e.Seq, e.OrderID, err)
+		}
+	}
+
+	err := errors.Join(errs...)
+	if err == nil {
+		if err := d.store.MarkOrderEventDispatched(e.ID); err != nil {
+			log.Printf("[Events] Failed to mark %s #%d of order %s dispatched: %v", e.Event, e.Seq, e.OrderID, err)
+		}
+		return true
+	}
+
+	var retryAt *time.Time
+	if e.Attempts < maxDispatchAttempts {
+		at := d.now().Add(dispatchBackoff(e.Attempts))
+		retryAt = &at
+		log.Printf("[Events] Failed to publish %s #%d of order %s (attempt %d), retrying at %s: %v",
+			e.Event, e.Seq, e.OrderID, e.Attempts, at.Format(time.RFC3339), err)
+	} else {
+		log.Printf("[Events] Giving up on %s #%d of order %s after %d attempts: %v", e.Event, e.Seq, e.OrderID, e.Attempts, err)
+	}
+	if err := d.store.MarkOrderEventFailed(e.ID, err.Error(), retryAt); err != nil {
+		log.Printf("[Events] Failed to mark %s #%d of order %s failed: %v", e.Event, e.Seq, e.OrderID, err)
+	}
+	return false
+}
+
+// deliver hands the event to the bus for the order's channel and its owner's. A retry delivers it to both again;
+// SSE streams skip events they already sent.
+func (d *EventDispatcher) deliver(e models.OrderEvent, local events.Event) error {
+	if err := d.bus.Publish(local); err != nil {
+		return fmt.Errorf("failed to deliver to subscribers: %w", err)
+	}
+	if e.UserID == uuid.Nil {
+		return nil
+	}
+	mirror := events.Event{Channel: events.UserChannel(e.UserID), Name: e.Event, Data: local.Data, Source: e.ID}
+	if err := d.bus.Publish(mirror); err != nil {
+		return fmt.Errorf("failed to deliver to the owner's subscribers: %w", err)
+	}
+	return nil
+}
+
+// broadcast sends the event to the order's channel and its owner's. A retry sends it to both again;
+// listeners tell repeats by order_id and seq.
+func (d *EventDispatcher) broadcast(e models.OrderEvent, data []byte) error {
+	if d.sink == nil {
+		return nil
+	}
+	payload := make(map[string]interface{})
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return err
+	}
+	if err := d.sink.PublishEvent(events.OrderChannel(e.OrderID), e.Event, payload); err != nil {
+		return err
+	}
+	if e.UserID == uuid.Nil {
+		return nil
+	}
+	return d.sink.PublishEvent(events.UserChannel(e.UserID), e.Event, payload)
+}
+
+// dispatchBackoff doubles the wait after every failed attempt, up to dispatchBackoffMax
+func dispatchBackoff(attempts int) time.Duration {
+	wait := dispatchBackoffBase
+	for i := 1; i < attempts && wait < dispatchBackoffMax; i++ {
+		wait *= 2
+	}
+	if wait > dispatchBackoffMax {
+		wait = dispatchBackoffMax
+	}
+	return wait
+}
@@ -0,0 +1,417 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"image"
+	"log"
+	"math"
+	"sync"
+
+	"github.com/google/uuid"
+	"instant-hdr-backend/internal/autoenhance"
+	"instant-hdr-backend/internal/credits"
+	"instant-hdr-backend/internal/imaging"
+	"instant-hdr-backend/internal/models"
+	"instant-hdr-backend/internal/quota"
+	"instant-hdr-backend/internal/repository"
+	"instant-hdr-backend/internal/storage"
+)
+
+// Where the file returned by EnsureVariant came from
+const (
+	VariantSourceCache       = "cache"       // Already stored - no work done
+	VariantSourceGenerated   = "generated"   // Resized/re-encoded locally from the high-res source
+	VariantSourceAutoEnhance = "autoenhance" // Downloaded from AutoEnhance
+)
+
+// ErrImageNotFound is returned when AutoEnhance does not know the image
+var ErrImageNotFound = errors.New("image not found")
+
+// ImageService produces image variants (sizes and formats) for processed AutoEnhance images.
+// The high-res image is downloaded from AutoEnhance once per watermark setting; every smaller
+// size and PNG/JPEG re-encode is generated locally and linked to it via parent_file_id.
+// Unwatermarked downloads are paid for with a credit before AutoEnhance is called.
+type ImageService struct {
+	autoenhanceClient *autoenhance.Client
+	dbClient          repository.Repository
+	storageClient     storage.Storage
+	urlResolver       *storage.URLResolver
+	quotaService      *quota.Service
+	creditService     *credits.Service
+
+	mu     sync.Mutex
+	images map[string]*imageLock // Variants being produced, by order, image and watermark setting
+}
+
+// imageLock is held while an image's variants are produced; refs counts the requests holding or waiting for it
+type imageLock struct {
+	mu   sync.Mutex
+	refs int
+}
+
+func NewImageService(
+	autoenhanceClient *autoenhance.Client,
+	dbClient repository.Repository,
+	storageClient storage.Storage,
+	urlResolver *storage.URLResolver,
+	quotaService *quota.Service,
+	creditService *credits.Service,
+) *ImageService {
+	return &ImageService{
+		autoenhanceClient: autoenhanceClient,
+		dbClient:          dbClient,
+		storageClient:     storageClient,
+		urlResolver:       urlResolver,
+		quotaService:      quotaService,
+		creditService:     creditService,
+		images:            make(map[string]*imageLock),
+	}
+}
+
+// VariantRequest describes the variant to produce
+type VariantRequest struct {
+	Order     *models.Order
+	ImageID   string
+	Quality   string   // thumbnail, preview, medium, high or custom
+	MaxWidth  *int     // custom only
+	Scale     *float64 // custom only
+	Format    string   // jpeg, png or webp
+	Watermark bool
+	UserID    uuid.UUID // Caller, who pays for unwatermarked downloads
+	UserToken string    // Caller's JWT for storage RLS
+}
+
+// VariantResult is the stored variant
+type VariantResult struct {
+	File       *models.OrderFile
+	Source     string // VariantSourceCache, VariantSourceGenerated or VariantSourceAutoEnhance
+	CreditUsed bool   // A credit was spent on an unwatermarked download from AutoEnhance for this request
+}
+
+// EnsureVariant returns the requested variant, generating it if it is not stored yet
+func (s *ImageService) EnsureVariant(req VariantRequest) (*VariantResult, error) {
+	variantName := variantName(req)
+
+	// Serve from storage when this exact variant already exists
+	if cached, err := s.cachedVariant(req, variantName); cached != nil || err != nil {
+		return cached, err
+	}
+
+	// One request at a time produces an image's variants, so a new image is downloaded once; the others wait and
+	// find what it stored
+	unlock := s.lockImage(req)
+	defer unlock()
+	if cached, err := s.cachedVariant(req, variantName); cached != nil || err != nil {
+		return cached, err
+	}
+
+	// WebP cannot be encoded locally - ask AutoEnhance for it directly
+	if !imaging.CanEncode(req.Format) {
+		file, charged, err := s.downloadVariant(req, variantName)
+		if err != nil {
+			return nil, err
+		}
+		return &VariantResult{File: file, Source: VariantSourceAutoEnhance, CreditUsed: charged}, nil
+	}
+
+	source, sourceImg, fetched, charged, err := s.ensureSource(req)
+	if err != nil {
+		return nil, err
+	}
+	result := &VariantResult{Source: VariantSourceGenerated, CreditUsed: charged}
+
+	if variantName == imaging.VariantHigh.Name && req.Format == imaging.FormatJPEG {
+		result.File = source
+		if fetched {
+			result.Source = VariantSourceAutoEnhance
+		} else {
+			result.Source = VariantSourceCache
+		}
+		return result, nil
+	}
+
+	// The standard JPEG sizes were generated together with a fresh source
+	if fetched && req.Format == imaging.FormatJPEG {
+		if file, err := s.dbClient.GetOrderFileVariant(req.Order.ID, req.ImageID, variantName, req.Format, req.Watermark); err == nil {
+			result.File = file
+			return result, nil
+		}
+	}
+
+	var resized image.Image
+	switch {
+	case req.Quality == "custom" && req.MaxWidth != nil:
+		resized = imaging.Resize(sourceImg, *req.MaxWidth)
+	case req.Quality == "custom" && req.Scale != nil:
+		resized = imaging.Scale(sourceImg, *req.Scale)
+	default:
+		preset, _ := imaging.VariantByName(variantName)
+		resized = imaging.Resize(sourceImg, preset.MaxWidth)
+	}
+
+	file, err := s.storeDerivative(req, source, resized, variantName, req.Format)
+	if err != nil {
+		return nil, err
+	}
+	result.File = file
+	return result, nil
+}
+
+// cachedVariant returns the stored variant, or nil if there is none yet
+func (s *ImageService) cachedVariant(req VariantRequest, variantName string) (*VariantResult, error) {
+	cached, err := s.dbClient.GetOrderFileVariant(req.Order.ID, req.ImageID, variantName, req.Format, req.Watermark)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &VariantResult{File: cached, Source: VariantSourceCache}, nil
+}
+
+// lockImage holds the lock of the request's order, image and watermark setting until the returned func is called.
+// It only covers this instance: replicas may still download the same image at the same time.
+func (s *ImageService) lockImage(req VariantRequest) func() {
+	key := fmt.Sprintf("%s/%s/%t", req.Order.ID, req.ImageID, req.Watermark)
+
+	s.mu.Lock()
+	lock, ok := s.images[key]
+	if !ok {
+		lock = &imageLock{}
+		s.images[key] = lock
+	}
+	lock.refs++
+	s.mu.Unlock()
+
+	lock.mu.Lock()
+	return func() {
+		lock.mu.Unlock()
+
+		s.mu.Lock()
+		if lock.refs--; lock.refs == 0 {
+			delete(s.images, key)
+		}
+		s.mu.Unlock()
+	}
+}
+
+// ensureSource returns the high-res JPEG for the image, downloading it from AutoEnhance
+// (and generating the standard derivatives) if it is not stored yet. It also reports whether
+// it was downloaded and whether a credit was spent on it.
+func (s *ImageService) ensureSource(req VariantRequest) (*models.OrderFile, image.Image, bool, bool, error) {
+	source, err := s.dbClient.GetOrderFileVariant(req.Order.ID, req.ImageID, imaging.VariantHigh.Name, imaging.FormatJPEG, req.Watermark)
+	if err == nil {
+		data, err := s.storageClient.DownloadFile(source.StoragePath)
+		if err == nil {
+			img, err := imaging.Decode(data)
+			if err != nil {
+				return nil, nil, false, false, err
+			}
+			return source, img, false, false, nil
+		}
+		// Stored object is missing - fall through and fetch it again (it was paid for already)
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return nil, nil, false, false, err
+	}
+
+	watermark := req.Watermark
+	data, spend, charged, err := s.download(req, imaging.VariantHigh.Name, autoenhance.DownloadOptions{
+		Format:    imaging.FormatJPEG,
+		Watermark: &watermark,
+	})
+	if err != nil {
+		return nil, nil, false, false, err
+	}
+
+	// Nothing usable was downloaded, so nothing is paid for
+	img, err := imaging.Decode(data)
+	if err != nil {
+		s.refund(req, spend)
+		return nil, nil, false, false, fmt.Errorf("failed to decode image from AutoEnhance: %w", err)
+	}
+
+	source, err = s.storeFile(req, data, img.Bounds(), imaging.VariantHigh.Name, imaging.FormatJPEG, nil)
+	if err != nil {
+		return nil, nil, false, charged, err
+	}
+
+	// Generate the standard sizes now so later requests never reach AutoEnhance
+	for _, variant := range imaging.Derivatives {
+		if _, err := s.storeDerivative(req, source, imaging.Resize(img, variant.MaxWidth), variant.Name, imaging.FormatJPEG); err != nil {
+			log.Printf("[Derivatives] Failed to generate %s for image %s: %v", variant.Name, req.ImageID, err)
+		}
+	}
+
+	return source, img, true, charged, nil
+}
+
+// downloadVariant fetches a variant from AutoEnhance (used for formats we cannot encode) and
+// reports whether a credit was spent on it
+func (s *ImageService) downloadVariant(req VariantRequest, variantName string) (*models.OrderFile, bool, error) {
+	watermark := req.Watermark
+	options := autoenhance.DownloadOptions{
+		Format:    req.Format,
+		Watermark: &watermark,
+	}
+	switch {
+	case req.Quality == "custom":
+		options.MaxWidth = req.MaxWidth
+		options.Scale = req.Scale
+	default:
+		if preset, ok := imaging.VariantByName(variantName); ok && preset.MaxWidth > 0 {
+			maxWidth := preset.MaxWidth
+			options.MaxWidth = &maxWidth
+		}
+	}
+
+	data, _, charged, err := s.download(req, variantName, options)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// Dimensions are best effort - the file is stored even if it cannot be decoded
+	var bounds image.Rectangle
+	if img, err := imaging.Decode(data); err == nil {
+		bounds = img.Bounds()
+	}
+
+	file, err := s.storeFile(req, data, bounds, variantName, req.Format, nil)
+	return file, charged, err
+}
+
+// download verifies the image exists in AutoEnhance and downloads it. An unwatermarked download
+// is paid for first, once per image, quality and format: the credit is given back if the download
+// fails, and a download that was paid before is free. Returns the spend (nil when nothing was spent) and reports
+// whether a credit was spent.
+func (s *ImageService) download(req VariantRequest, quality string, options autoenhance.DownloadOptions) ([]byte, *models.CreditLedgerEntry, bool, error) {
+	if _, err := s.autoenhanceClient.GetImage(req.ImageID); err != nil {
+		return nil, nil, false, fmt.Errorf("%w: %v", ErrImageNotFound, err)
+	}
+
+	charged := !req.Watermark
+	var spend *models.CreditLedgerEntry
+	if !req.Watermark && s.creditService != nil {
+		payer := req.UserID
+		if payer == uuid.Nil {
+			payer = req.Order.UserID
+		}
+		var err error
+		spend, charged, err = s.creditService.Spend(credits.Spend{
+			UserID:  payer,
+			OrderID: req.Order.ID,
+			ImageID: req.ImageID,
+			Quality: quality,
+			Format:  options.Format,
+		})
+		if err != nil {
+			return nil, nil, false, err
+		}
+		if !charged {
+			spend = nil
+		}
+	}
+
+	var data []byte
+	err := s.autoenhanceClient.RetryWithBackoff(func() error {
+		d, err := s.autoenhanceClient.DownloadEnhanced(req.ImageID, options)
+		if err != nil {
+			return err
+		}
+		data = d
+		return nil
+	}, 3)
+	if err != nil {
+		s.refund(req, spend)
+		return nil, nil, false, fmt.Errorf("failed to download image from AutoEnhance: %w", err)
+	}
+	return data, spend, charged, nil
+}
+
+// refund gives back the credit of a spend whose download can't be used. A nil spend was free.
+func (s *ImageService) refund(req VariantRequest, spend *models.CreditLedgerEntry) {
+	if spend == nil {
+		return
+	}
+	if err := s.creditService.Refund(spend.ID); err != nil {
+		log.Printf("[Credits] Failed to refund the credit for image %s: %v", req.ImageID, err)
+	}
+}
+
+func (s *ImageService) storeDerivative(req VariantRequest, source *models.OrderFile, img image.Image, variantName, format string) (*models.OrderFile, error) {
+	data, err := imaging.Encode(img, format)
+	if err != nil {
+		return nil, err
+	}
+	return s.storeFile(req, data, img.Bounds(), variantName, format, &source.ID)
+}
+
+// storeFile uploads a variant under the order and records it in order_files.
+// Cached variants never get here, so a user at the stored-bytes limit can still fetch what they have.
+func (s *ImageService) storeFile(req VariantRequest, data []byte, bounds image.Rectangle, variantName, format string, parentID *uuid.UUID) (*models.OrderFile, error) {
+	if s.quotaService != nil {
+		if err := s.quotaService.CheckStore(req.Order.UserID, int64(len(data))); err != nil {
+			return nil, err
+		}
+	}
+
+	filename := variantFilename(req.ImageID, variantName, format, req.Watermark)
+
+	storagePath, publicURL, err := s.storageClient.UploadFileWithToken(req.Order.UserID, req.Order.ID, filename, data, req.UserToken)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload to storage: %w", err)
+	}
+
+	file := &models.OrderFile{
+		ID:                 uuid.New(),
+		OrderID:            req.Order.ID,
+		UserID:             req.Order.UserID,
+		Filename:           filename,
+		AutoEnhanceImageID: sql.NullString{String: req.ImageID, Valid: true},
+		StoragePath:        storagePath,
+		StorageURL:         s.urlResolver.StoredURL(publicURL),
+		FileSize:           sql.NullInt64{Int64: int64(len(data)), Valid: true},
+		MimeType:           imaging.MimeType(format),
+		IsFinal:            true,
+		Variant:            sql.NullString{String: variantName, Valid: true},
+		Format:             sql.NullString{String: format, Valid: true},
+		Watermark:          sql.NullBool{Bool: req.Watermark, Valid: true},
+	}
+	if parentID != nil {
+		file.ParentFileID = uuid.NullUUID{UUID: *parentID, Valid: true}
+	}
+	if !bounds.Empty() {
+		file.Width = sql.NullInt64{Int64: int64(bounds.Dx()), Valid: true}
+		file.Height = sql.NullInt64{Int64: int64(bounds.Dy()), Valid: true}
+	}
+
+	if err := s.dbClient.CreateOrderFile(file); err != nil {
+		return nil, fmt.Errorf("failed to record file: %w", err)
+	}
+
+	return file, nil
+}
+
+// variantName returns the stored variant name: the preset name, or custom_{width}w / custom_{percent}pct
+func variantName(req VariantRequest) string {
+	if req.Quality != "custom" {
+		return req.Quality
+	}
+	if req.MaxWidth != nil {
+		return fmt.Sprintf("custom_%dw", *req.MaxWidth)
+	}
+	if req.Scale != nil {
+		return fmt.Sprintf("custom_%dpct", int(math.Round(*req.Scale*100)))
+	}
+	return req.Quality
+}
+
+// variantFilename follows the {image_id}_{quality}.{ext} convention; unwatermarked files get a suffix
+func variantFilename(imageID, variantName, format string, watermark bool) string {
+	name := fmt.Sprintf("%s_%s", imageID, variantName)
+	if !watermark {
+		name += "_unwatermarked"
+	}
+	return name + imaging.Extension(format)
+}
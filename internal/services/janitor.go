@@ -0,0 +1,225 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"instant-hdr-backend/internal/models"
+	"instant-hdr-backend/internal/repository"
+	"instant-hdr-backend/internal/storage"
+)
+
+// janitorPageSize is how many expired files are fetched per query
+const janitorPageSize = 500
+
+// RetentionPolicy configures what the janitor removes. A zero value disables a rule.
+type RetentionPolicy struct {
+	PreviewDays      int // Watermarked previews/derivatives older than this are deleted. Paid finals are kept.
+	DeletedOrderDays int // Orders in the trash longer than this are purged, as is the storage of orders deleted in AutoEnhance
+}
+
+// Janitor enforces the retention policy. Storage objects are deleted before their order_files
+// rows, and a row is only removed once its object is gone, so the two never drift apart.
+type Janitor struct {
+	dbClient      repository.Repository
+	storageClient storage.Storage
+	purger        *OrderPurger
+	policy        RetentionPolicy
+	interval      time.Duration
+	mu            sync.Mutex // One run at a time
+}
+
+func NewJanitor(dbClient repository.Repository, storageClient storage.Storage, purger *OrderPurger, policy RetentionPolicy, interval time.Duration) *Janitor {
+	return &Janitor{
+		dbClient:      dbClient,
+		storageClient: storageClient,
+		purger:        purger,
+		policy:        policy,
+		interval:      interval,
+	}
+}
+
+// Start runs the janitor immediately and then every interval until stop is closed
+func (j *Janitor) Start(stop <-chan struct{}) {
+	go func() {
+		ticker := time.NewTicker(j.interval)
+		defer ticker.Stop()
+
+		for {
+			report := j.Run(false, uuid.NullUUID{})
+			log.Printf("[Janitor] Deleted %d files (%d bytes), purged %d orders, %d errors",
+				report.FilesDeleted, report.BytesFreed, len(report.PurgedOrders), len(report.Errors))
+
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Run applies the retention policy. With dryRun nothing is deleted and the report lists what would be.
+// userID limits the run to one user when valid.
+func (j *Janitor) Run(dryRun bool, userID uuid.NullUUID) *models.RetentionReport {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	report := &models.RetentionReport{
+		DryRun:           dryRun,
+		StartedAt:        time.Now().UTC(),
+		PreviewDays:      j.policy.PreviewDays,
+		DeletedOrderDays: j.policy.DeletedOrderDays,
+		ExpiredFiles:     make([]models.RetentionFileItem, 0),
+		PurgedOrders:     make([]models.RetentionOrderItem, 0),
+	}
+
+	if j.policy.PreviewDays > 0 {
+		j.expirePreviews(report, userID)
+	}
+	if j.policy.DeletedOrderDays > 0 {
+		j.purgeDeletedOrders(report, userID)
+	}
+
+	report.FinishedAt = time.Now().UTC()
+	return report
+}
+
+func (j *Janitor) expirePreviews(report *models.RetentionReport, userID uuid.NullUUID) {
+	cutoff := time.Now().AddDate(0, 0, -j.policy.PreviewDays)
+
+	for {
+		files, err := j.dbClient.ListExpiredPreviewFiles(cutoff, userID, janitorPageSize)
+		if err != nil {
+			report.Errors = append(report.Errors, err.Error())
+			return
+		}
+
+		deleted := 0
+		for _, file := range files {
+			if !report.DryRun {
+				if err := j.deleteFile(file); err != nil {
+					report.Errors = append(report.Errors, err.Error())
+					continue
+				}
+			}
+
+			deleted++
+			report.FilesDeleted++
+			report.BytesFreed += file.FileSize.Int64
+			report.ExpiredFiles = append(report.ExpiredFiles, models.RetentionFileItem{
+				FileID:      file.ID.String(),
+				OrderID:     file.OrderID.String(),
+				Filename:    file.Filename,
+				StoragePath: file.StoragePath,
+				FileSize:    file.FileSize.Int64,
+				CreatedAt:   file.CreatedAt,
+			})
+		}
+
+		if len(files) < janitorPageSize {
+			return
+		}
+		// A dry run deletes nothing, so the next query would return the same page
+		if report.DryRun {
+			report.Truncated = true
+			return
+		}
+		// Every deletion in this page failed - stop instead of retrying the same rows forever
+		if deleted == 0 {
+			return
+		}
+	}
+}
+
+// deleteFile removes the object and then its row. The row stays if the object could not be deleted.
+func (j *Janitor) deleteFile(file models.OrderFile) error {
+	if file.StoragePath != "" {
+		if err := j.storageClient.DeleteFile(file.StoragePath); err != nil {
+			return fmt.Errorf("file %s: %w", file.ID, err)
+		}
+	}
+	if err := j.dbClient.DeleteOrderFile(file.ID); err != nil {
+		return fmt.Errorf("file %s: storage object deleted but row remains: %w", file.ID, err)
+	}
+	return nil
+}
+
+func (j *Janitor) purgeDeletedOrders(report *models.RetentionReport, userID uuid.NullUUID) {
+	cutoff := time.Now().AddDate(0, 0, -j.policy.DeletedOrderDays)
+
+	orders, err := j.dbClient.ListPurgeableOrders(cutoff, userID)
+	if err != nil {
+		report.Errors = append(report.Errors, err.Error())
+		return
+	}
+
+	for _, order := range orders {
+		objects, err := j.storageClient.ListFiles(storage.OrderPrefix(order.UserID, order.ID))
+		if err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("order %s: %v", order.ID, err))
+			continue
+		}
+		files, err := j.dbClient.GetOrderFiles(order.ID, order.UserID)
+		if err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("order %s: %v", order.ID, err))
+			continue
+		}
+		// Nothing left to purge (trashed orders still need their row removed)
+		if len(objects) == 0 && len(files) == 0 && !order.DeletedAt.Valid {
+			if !report.DryRun {
+				j.markStoragePurged(report, order)
+			}
+			continue
+		}
+
+		item := models.RetentionOrderItem{
+			OrderID:     order.ID.String(),
+			Name:        order.Name.String,
+			DeletedAt:   order.UpdatedAt,
+			ObjectCount: len(objects),
+			Permanent:   order.DeletedAt.Valid,
+		}
+		if order.DeletedAt.Valid {
+			item.DeletedAt = order.DeletedAt.Time
+		}
+		for _, obj := range objects {
+			item.Bytes += obj.Size
+		}
+
+		if item.Permanent {
+			// Trash past the retention window: remove the order entirely
+			if !report.DryRun {
+				if err := j.purger.Purge(order); err != nil {
+					report.Errors = append(report.Errors, err.Error())
+					continue
+				}
+			}
+		} else if !report.DryRun {
+			if err := j.storageClient.DeleteOrderFiles(order.UserID, order.ID); err != nil {
+				report.Errors = append(report.Errors, fmt.Sprintf("order %s: %v", order.ID, err))
+				continue
+			}
+			// The order is listed again until its rows are gone too
+			if _, err := j.dbClient.DeleteOrderFilesByOrderID(order.ID); err != nil {
+				report.Errors = append(report.Errors, fmt.Sprintf("order %s: storage purged but rows remain: %v", order.ID, err))
+			} else {
+				j.markStoragePurged(report, order)
+			}
+		}
+
+		report.FilesDeleted += item.ObjectCount
+		report.BytesFreed += item.Bytes
+		report.PurgedOrders = append(report.PurgedOrders, item)
+	}
+}
+
+// markStoragePurged stops listing an order deleted in AutoEnhance once nothing of it is left in storage
+func (j *Janitor) markStoragePurged(report *models.RetentionReport, order models.Order) {
+	if err := j.dbClient.MarkOrderStoragePurged(order.ID); err != nil {
+		report.Errors = append(report.Errors, fmt.Sprintf("order %s: %v", order.ID, err))
+	}
+}
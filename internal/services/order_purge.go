@@ -0,0 +1,50 @@
+package services
+
+import (
+	"fmt"
+
+	"instant-hdr-backend/internal/autoenhance"
+	"instant-hdr-backend/internal/models"
+	"instant-hdr-backend/internal/repository"
+	"instant-hdr-backend/internal/storage"
+)
+
+// OrderPurger permanently deletes orders. It is used for "delete permanently" and by the janitor
+// once an order has been in the trash longer than the retention window.
+type OrderPurger struct {
+	autoenhanceClient *autoenhance.Client
+	dbClient          repository.Repository
+	storageClient     storage.Storage
+}
+
+func NewOrderPurger(autoenhanceClient *autoenhance.Client, dbClient repository.Repository, storageClient storage.Storage) *OrderPurger {
+	return &OrderPurger{
+		autoenhanceClient: autoenhanceClient,
+		dbClient:          dbClient,
+		storageClient:     storageClient,
+	}
+}
+
+// Purge deletes the AutoEnhance order, every storage object of the order and finally the row
+// (its files and brackets cascade). The row is kept when an earlier step fails, so a later
+// attempt can finish the job instead of leaving objects nobody knows about.
+func (p *OrderPurger) Purge(order models.Order) error {
+	if p.autoenhanceClient != nil {
+		err := p.autoenhanceClient.RetryWithBackoff(func() error {
+			return p.autoenhanceClient.DeleteOrder(order.ID.String())
+		}, 3)
+		if err != nil {
+			return fmt.Errorf("order %s: failed to delete AutoEnhance order: %w", order.ID, err)
+		}
+	}
+
+	if err := p.storageClient.DeleteOrderFiles(order.UserID, order.ID); err != nil {
+		return fmt.Errorf("order %s: failed to delete files: %w", order.ID, err)
+	}
+
+	if err := p.dbClient.DeleteOrder(order.ID, order.UserID); err != nil {
+		return fmt.Errorf("order %s: files deleted but row remains: %w", order.ID, err)
+	}
+
+	return nil
+}
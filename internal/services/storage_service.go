@@ -2,38 +2,45 @@ package services
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
+	"log"
 	"time"
 
 	"github.com/google/uuid"
 	"instant-hdr-backend/internal/autoenhance"
+	"instant-hdr-backend/internal/events"
 	"instant-hdr-backend/internal/models"
-	"instant-hdr-backend/internal/supabase"
+	"instant-hdr-backend/internal/repository"
+	"instant-hdr-backend/internal/storage"
 )
 
 type StorageService struct {
 	autoenhanceClient *autoenhance.Client
-	dbClient          *supabase.DatabaseClient
-	storageClient     *supabase.StorageClient
-	realtimeClient    *supabase.RealtimeClient
+	dbClient          repository.Repository
+	storageClient     storage.Storage
+	urlResolver       *storage.URLResolver
+	dispatcher        *EventDispatcher
 }
 
-// GetRealtimeClient returns the realtime client for publishing events
-func (s *StorageService) GetRealtimeClient() *supabase.RealtimeClient {
-	return s.realtimeClient
+// PublishOrderEvent publishes a realtime event that comes without an order change. Failures are logged.
+func (s *StorageService) PublishOrderEvent(orderID uuid.UUID, p events.Payload) {
+	s.dispatcher.Publish(orderID, p)
 }
 
 func NewStorageService(
 	autoenhanceClient *autoenhance.Client,
-	dbClient *supabase.DatabaseClient,
-	storageClient *supabase.StorageClient,
-	realtimeClient *supabase.RealtimeClient,
+	dbClient repository.Repository,
+	storageClient storage.Storage,
+	urlResolver *storage.URLResolver,
+	dispatcher *EventDispatcher,
 ) *StorageService {
 	return &StorageService{
 		autoenhanceClient: autoenhanceClient,
 		dbClient:          dbClient,
 		storageClient:     storageClient,
-		realtimeClient:    realtimeClient,
+		urlResolver:       urlResolver,
+		dispatcher:        dispatcher,
 	}
 }
 
@@ -75,6 +82,7 @@ func (s *StorageService) HandleProcessingCompleted(autoenhanceOrderID, imageID s
 
 	// Download and store each processed image AS PREVIEW with watermark
 	storageURLs := make([]string, 0)
+	var urlsExpireAt *time.Time
 	for _, image := range autoenhanceOrder.Images {
 		// Skip if image has error or not completed
 		if image.Status != "completed" || image.StatusReason != "" {
@@ -112,7 +120,7 @@ func (s *StorageService) HandleProcessingCompleted(autoenhanceOrderID, imageID s
 			Filename:           filename,
 			AutoEnhanceImageID: sql.NullString{String: image.ImageID, Valid: true},
 			StoragePath:        storagePath,
-			StorageURL:         storageURL,
+			StorageURL:         s.urlResolver.StoredURL(storageURL),
 			FileSize:           sql.NullInt64{Int64: int64(len(fileData)), Valid: true},
 			MimeType:           "image/jpeg",
 			IsFinal:            false, // This is a preview, not final high-res
@@ -123,7 +131,16 @@ func (s *StorageService) HandleProcessingCompleted(autoenhanceOrderID, imageID s
 			// Log error but continue
 		}
 
-		storageURLs = append(storageURLs, storageURL)
+		// Private buckets get a signed URL instead of the stored public one
+		clientURL, expiresAt, err := s.urlResolver.Resolve(storagePath, file.StorageURL)
+		if err != nil {
+			continue
+		}
+		if expiresAt != nil {
+			urlsExpireAt = expiresAt
+		}
+
+		storageURLs = append(storageURLs, clientURL)
 	}
 
 	if len(storageURLs) == 0 {
@@ -131,12 +148,11 @@ func (s *StorageService) HandleProcessingCompleted(autoenhanceOrderID, imageID s
 		return
 	}
 
-	// Update order status to "previews_ready" instead of "completed"
-	s.dbClient.UpdateOrderStatus(order.ID, "previews_ready", 100)
-
-	// Publish download_ready event with preview URLs
-	s.realtimeClient.PublishOrderEvent(order.ID, "download_ready",
-		supabase.DownloadReadyPayload(order.ID, storageURLs))
+	// Update order status to "previews_ready" instead of "completed", with the download_ready event carrying the preview URLs
+	s.dispatcher.PublishStatus(order.ID, "previews_ready", 100, &events.DownloadReady{StorageURLs: storageURLs, ExpiresAt: urlsExpireAt})
+	s.audit(models.AuditOrderProcessingComplete, order, imageID, map[string]interface{}{
+		"images": len(autoenhanceOrder.Images), "previews": len(storageURLs),
+	})
 
 	// Auto-cleanup: Delete brackets from AutoEnhance after successful processing
 	// Brackets are no longer needed once images are processed
@@ -169,8 +185,9 @@ func (s *StorageService) HandleProcessingFailed(autoenhanceOrderID, errorMsg str
 		return
 	}
 
-	// Update order with error
-	s.dbClient.UpdateOrderError(order.ID, errorMsg)
+	// Update order with error, with the processing_failed event
+	s.dispatcher.PublishError(order.ID, errorMsg, &events.ProcessingFailed{Error: errorMsg})
+	s.audit(models.AuditOrderProcessingFailed, order, "", map[string]interface{}{"error": errorMsg})
 
 	// Sync AutoEnhance data to database (to get latest status, is_processing, etc.)
 	autoenhanceOrder, err := s.autoenhanceClient.GetOrder(order.ID.String())
@@ -190,8 +207,18 @@ func (s *StorageService) HandleProcessingFailed(autoenhanceOrderID, errorMsg str
 			lastUpdated,
 		)
 	}
+}
 
-	// Publish failed event
-	s.realtimeClient.PublishOrderEvent(order.ID, "processing_failed",
-		supabase.ProcessingFailedPayload(order.ID, errorMsg))
+// audit records a system action on order in the audit log; it has no actor. Failures are logged, not returned.
+func (s *StorageService) audit(action string, order *models.Order, imageID string, details map[string]interface{}) {
+	entry := models.AuditEntry{
+		Action:  action,
+		OrderID: uuid.NullUUID{UUID: order.ID, Valid: true},
+		ImageID: imageID,
+		UserID:  uuid.NullUUID{UUID: order.UserID, Valid: true},
+	}
+	entry.Details, _ = json.Marshal(details)
+	if err := s.dbClient.CreateAuditEntry(&entry); err != nil {
+		log.Printf("[Audit] Failed to record %s for order %s: %v", action, order.ID, err)
+	}
 }
@@ -0,0 +1,316 @@
+package storage
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LocalStorage stores objects on the local filesystem.
+// Intended for local development and tests - files are served by Handler().
+type LocalStorage struct {
+	root       string
+	baseURL    string // Public URL prefix of the file-serving route (e.g. http://localhost:8080/files)
+	signingKey []byte // When set, Handler() only serves requests with a valid signature
+}
+
+func NewLocalStorage(root, baseURL string) (*LocalStorage, error) {
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve storage root: %w", err)
+	}
+	if err := os.MkdirAll(absRoot, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create storage root: %w", err)
+	}
+
+	return &LocalStorage{
+		root:    absRoot,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+	}, nil
+}
+
+func (s *LocalStorage) UploadFile(userID, orderID uuid.UUID, filename string, data []byte) (string, string, error) {
+	return s.UploadFileWithToken(userID, orderID, filename, data, "")
+}
+
+// UploadFileWithToken writes the file to disk. The token is ignored (no RLS on local storage).
+func (s *LocalStorage) UploadFileWithToken(userID, orderID uuid.UUID, filename string, data []byte, userToken string) (string, string, error) {
+	storagePath := OrderPath(userID, orderID, filename)
+
+	fullPath, err := s.fullPath(storagePath)
+	if err != nil {
+		return "", "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(fullPath), 0o755); err != nil {
+		return "", "", fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	// Write to a temp file first so readers never see a partial object
+	tmp, err := os.CreateTemp(filepath.Dir(fullPath), ".upload-*")
+	if err != nil {
+		return "", "", fmt.Errorf("failed to upload file: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return "", "", fmt.Errorf("failed to upload file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return "", "", fmt.Errorf("failed to upload file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), fullPath); err != nil {
+		os.Remove(tmp.Name())
+		return "", "", fmt.Errorf("failed to upload file: %w", err)
+	}
+
+	return storagePath, s.GetPublicURL(storagePath), nil
+}
+
+func (s *LocalStorage) Download(storagePath string) (io.ReadCloser, *Object, error) {
+	return s.DownloadRange(storagePath, 0, -1)
+}
+
+func (s *LocalStorage) DownloadRange(storagePath string, offset, length int64) (io.ReadCloser, *Object, error) {
+	fullPath, err := s.fullPath(storagePath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	f, err := os.Open(fullPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to download file: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, nil, fmt.Errorf("failed to stat file: %w", err)
+	}
+
+	if offset > 0 {
+		if _, err := f.Seek(offset, io.SeekStart); err != nil {
+			f.Close()
+			return nil, nil, fmt.Errorf("failed to seek file: %w", err)
+		}
+	}
+	if length < 0 {
+		return f, s.objectFromInfo(storagePath, info), nil
+	}
+	return limitedReadCloser{io.LimitReader(f, length), f}, s.objectFromInfo(storagePath, info), nil
+}
+
+func (s *LocalStorage) Stat(storagePath string) (*Object, error) {
+	fullPath, err := s.fullPath(storagePath)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := os.Stat(fullPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat file: %w", err)
+	}
+	return s.objectFromInfo(storagePath, info), nil
+}
+
+func (s *LocalStorage) DownloadFile(storagePath string) ([]byte, error) {
+	fullPath, err := s.fullPath(storagePath)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(fullPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download file: %w", err)
+	}
+	return data, nil
+}
+
+func (s *LocalStorage) DeleteFile(storagePath string) error {
+	fullPath, err := s.fullPath(storagePath)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(fullPath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete file: %w", err)
+	}
+	return nil
+}
+
+func (s *LocalStorage) DeleteOrderFiles(userID, orderID uuid.UUID) error {
+	fullPath, err := s.fullPath(OrderPrefix(userID, orderID))
+	if err != nil {
+		return err
+	}
+
+	if err := os.RemoveAll(fullPath); err != nil {
+		return fmt.Errorf("failed to delete files: %w", err)
+	}
+	return nil
+}
+
+func (s *LocalStorage) ListFiles(prefix string) ([]Object, error) {
+	objects := make([]Object, 0)
+
+	// Only walk the directory containing the prefix
+	walkRoot := s.root
+	if dir := path.Dir(prefix); dir != "." && dir != "/" {
+		fullDir, err := s.fullPath(dir)
+		if err != nil {
+			return nil, err
+		}
+		walkRoot = fullDir
+	}
+	if _, err := os.Stat(walkRoot); os.IsNotExist(err) {
+		return objects, nil
+	}
+
+	err := filepath.WalkDir(walkRoot, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || strings.HasPrefix(d.Name(), ".upload-") {
+			return nil
+		}
+
+		rel, err := filepath.Rel(s.root, p)
+		if err != nil {
+			return err
+		}
+		storagePath := filepath.ToSlash(rel)
+		if !strings.HasPrefix(storagePath, prefix) {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		objects = append(objects, *s.objectFromInfo(storagePath, info))
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files: %w", err)
+	}
+
+	return objects, nil
+}
+
+func (s *LocalStorage) GetPublicURL(storagePath string) string {
+	return s.baseURL + "/" + storagePath
+}
+
+// RequireSignedURLs makes the file-serving route private: only URLs from SignedURL are served
+func (s *LocalStorage) RequireSignedURLs(signingKey []byte) {
+	s.signingKey = signingKey
+}
+
+// SignedURL returns a URL with an HMAC signature and expiry, verified by Handler()
+func (s *LocalStorage) SignedURL(storagePath string, expiresIn time.Duration) (string, error) {
+	if len(s.signingKey) == 0 {
+		return "", fmt.Errorf("signed urls are not enabled for local storage")
+	}
+	cleaned, err := cleanPath(storagePath)
+	if err != nil {
+		return "", err
+	}
+
+	expires := strconv.FormatInt(time.Now().Add(expiresIn).Unix(), 10)
+	return fmt.Sprintf("%s/%s?expires=%s&signature=%s",
+		s.baseURL, cleaned, expires, s.signature(cleaned, expires)), nil
+}
+
+// Handler serves stored files over HTTP. Mount it with the route prefix stripped,
+// e.g. http.StripPrefix("/files", local.Handler())
+func (s *LocalStorage) Handler() http.Handler {
+	fileServer := http.FileServer(noDirFS{http.Dir(s.root)})
+	if len(s.signingKey) == 0 {
+		return fileServer
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cleaned, err := cleanPath(r.URL.Path)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+
+		expires := r.URL.Query().Get("expires")
+		expiresUnix, err := strconv.ParseInt(expires, 10, 64)
+		if err != nil || time.Now().Unix() > expiresUnix {
+			http.Error(w, "signed url expired or missing", http.StatusForbidden)
+			return
+		}
+		expected := s.signature(cleaned, expires)
+		if !hmac.Equal([]byte(expected), []byte(r.URL.Query().Get("signature"))) {
+			http.Error(w, "invalid signature", http.StatusForbidden)
+			return
+		}
+
+		fileServer.ServeHTTP(w, r)
+	})
+}
+
+func (s *LocalStorage) signature(storagePath, expires string) string {
+	mac := hmac.New(sha256.New, s.signingKey)
+	mac.Write([]byte(storagePath + "\n" + expires))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (s *LocalStorage) fullPath(storagePath string) (string, error) {
+	cleaned, err := cleanPath(storagePath)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(s.root, filepath.FromSlash(cleaned)), nil
+}
+
+func (s *LocalStorage) objectFromInfo(storagePath string, info fs.FileInfo) *Object {
+	return &Object{
+		Path:         storagePath,
+		Size:         info.Size(),
+		ContentType:  ContentTypeForFilename(storagePath),
+		ETag:         fmt.Sprintf(`"%x-%x"`, info.ModTime().UnixNano(), info.Size()),
+		LastModified: info.ModTime(),
+	}
+}
+
+// limitedReadCloser closes the underlying file of a range read
+type limitedReadCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// noDirFS hides directory listings from the file server
+type noDirFS struct {
+	fs http.FileSystem
+}
+
+func (n noDirFS) Open(name string) (http.File, error) {
+	f, err := n.fs.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	if info.IsDir() {
+		f.Close()
+		return nil, os.ErrNotExist
+	}
+	return f, nil
+}
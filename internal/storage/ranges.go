@@ -0,0 +1,129 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ErrRangeNotSatisfiable is returned by ParseRange when no part of the range lies within the object
+var ErrRangeNotSatisfiable = errors.New("range not satisfiable")
+
+// ByteRange is a resolved byte range of an object
+type ByteRange struct {
+	Offset int64
+	Length int64
+}
+
+// ContentRange formats the Content-Range response header for the range
+func (r ByteRange) ContentRange(size int64) string {
+	return fmt.Sprintf("bytes %d-%d/%d", r.Offset, r.Offset+r.Length-1, size)
+}
+
+// ParseRange parses an HTTP Range header against an object of the given size.
+// It returns nil when the whole object should be served: no header, a unit other than bytes,
+// or multiple ranges (serving the full content is always a valid response to those).
+func ParseRange(header string, size int64) (*ByteRange, error) {
+	if header == "" {
+		return nil, nil
+	}
+	spec, ok := strings.CutPrefix(header, "bytes=")
+	if !ok || strings.Contains(spec, ",") {
+		return nil, nil
+	}
+
+	startStr, endStr, ok := strings.Cut(strings.TrimSpace(spec), "-")
+	if !ok {
+		return nil, ErrRangeNotSatisfiable
+	}
+
+	// Suffix range: bytes=-N is the last N bytes
+	if startStr == "" {
+		n, err := strconv.ParseInt(endStr, 10, 64)
+		if err != nil || n <= 0 || size == 0 {
+			return nil, ErrRangeNotSatisfiable
+		}
+		if n > size {
+			n = size
+		}
+		return &ByteRange{Offset: size - n, Length: n}, nil
+	}
+
+	start, err := strconv.ParseInt(startStr, 10, 64)
+	if err != nil || start < 0 || start >= size {
+		return nil, ErrRangeNotSatisfiable
+	}
+
+	end := size - 1
+	if endStr != "" {
+		end, err = strconv.ParseInt(endStr, 10, 64)
+		if err != nil || end < start {
+			return nil, ErrRangeNotSatisfiable
+		}
+		if end >= size {
+			end = size - 1
+		}
+	}
+
+	return &ByteRange{Offset: start, Length: end - start + 1}, nil
+}
+
+// RangeHeader formats a Range request header for a backend download ("" for the whole object)
+func RangeHeader(offset, length int64) string {
+	if offset <= 0 && length < 0 {
+		return ""
+	}
+	if length < 0 {
+		return fmt.Sprintf("bytes=%d-", offset)
+	}
+	return fmt.Sprintf("bytes=%d-%d", offset, offset+length-1)
+}
+
+// ObjectFromResponse builds object metadata from an HTTP GET/HEAD response.
+// For 206 responses the size is taken from Content-Range so it describes the whole object.
+func ObjectFromResponse(storagePath string, resp *http.Response) *Object {
+	obj := &Object{
+		Path:        storagePath,
+		ContentType: resp.Header.Get("Content-Type"),
+		ETag:        resp.Header.Get("ETag"),
+	}
+	if size, err := strconv.ParseInt(resp.Header.Get("Content-Length"), 10, 64); err == nil {
+		obj.Size = size
+	}
+	if resp.StatusCode == http.StatusPartialContent {
+		// Content-Range: bytes 0-99/1234
+		if _, total, ok := strings.Cut(resp.Header.Get("Content-Range"), "/"); ok {
+			if size, err := strconv.ParseInt(total, 10, 64); err == nil {
+				obj.Size = size
+			}
+		}
+	}
+	if modified, err := http.ParseTime(resp.Header.Get("Last-Modified")); err == nil {
+		obj.LastModified = modified
+	}
+	if obj.ContentType == "" {
+		obj.ContentType = ContentTypeForFilename(storagePath)
+	}
+	return obj
+}
+
+// SliceBody cuts the range out of a whole-object body, for backends that answer a Range request with 200.
+// The returned reader closes body.
+func SliceBody(body io.ReadCloser, offset, length int64) (io.ReadCloser, error) {
+	if offset > 0 {
+		if _, err := io.CopyN(io.Discard, body, offset); err != nil {
+			body.Close()
+			return nil, fmt.Errorf("failed to skip to offset %d: %w", offset, err)
+		}
+	}
+	if length < 0 {
+		return body, nil
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{io.LimitReader(body, length), body}, nil
+}
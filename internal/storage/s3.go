@@ -0,0 +1,485 @@
+package storage
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// S3Config configures an S3-compatible backend (AWS S3, MinIO, R2, ...)
+type S3Config struct {
+	Endpoint        string // e.g. http://localhost:9000 or https://s3.us-east-1.amazonaws.com
+	Region          string
+	Bucket          string
+	AccessKeyID     string
+	SecretAccessKey string
+	ForcePathStyle  bool   // Required for MinIO: {endpoint}/{bucket}/{key}
+	PublicURL       string // Optional public base URL for objects (CDN or public bucket)
+}
+
+// S3Storage talks to S3-compatible storage using the REST API with AWS Signature Version 4
+type S3Storage struct {
+	cfg        S3Config
+	endpoint   *url.URL
+	httpClient *http.Client
+}
+
+const (
+	s3Service          = "s3"
+	s3Algorithm        = "AWS4-HMAC-SHA256"
+	s3DeleteBatchLimit = 1000
+)
+
+func NewS3Storage(cfg S3Config) (*S3Storage, error) {
+	if cfg.Endpoint == "" || cfg.Bucket == "" {
+		return nil, fmt.Errorf("S3 endpoint and bucket are required")
+	}
+	if cfg.AccessKeyID == "" || cfg.SecretAccessKey == "" {
+		return nil, fmt.Errorf("S3 access key id and secret access key are required")
+	}
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+
+	endpoint, err := url.Parse(strings.TrimSuffix(cfg.Endpoint, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid S3 endpoint: %w", err)
+	}
+
+	return &S3Storage{
+		cfg:      cfg,
+		endpoint: endpoint,
+		httpClient: &http.Client{
+			Timeout: 60 * time.Second,
+		},
+	}, nil
+}
+
+func (s *S3Storage) UploadFile(userID, orderID uuid.UUID, filename string, data []byte) (string, string, error) {
+	return s.UploadFileWithToken(userID, orderID, filename, data, "")
+}
+
+// UploadFileWithToken uploads the object. The token is ignored (S3 uses the configured access keys).
+func (s *S3Storage) UploadFileWithToken(userID, orderID uuid.UUID, filename string, data []byte, userToken string) (string, string, error) {
+	storagePath := OrderPath(userID, orderID, filename)
+
+	req, err := s.newRequest(http.MethodPut, storagePath, nil, data)
+	if err != nil {
+		return "", "", err
+	}
+	req.Header.Set("Content-Type", ContentTypeForFilename(filename))
+
+	resp, err := s.do(req, data)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to upload file: %w", err)
+	}
+	resp.Body.Close()
+
+	return storagePath, s.GetPublicURL(storagePath), nil
+}
+
+func (s *S3Storage) Download(storagePath string) (io.ReadCloser, *Object, error) {
+	return s.DownloadRange(storagePath, 0, -1)
+}
+
+func (s *S3Storage) DownloadRange(storagePath string, offset, length int64) (io.ReadCloser, *Object, error) {
+	req, err := s.newRequest(http.MethodGet, storagePath, nil, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	if rangeHeader := RangeHeader(offset, length); rangeHeader != "" {
+		req.Header.Set("Range", rangeHeader)
+	}
+
+	resp, err := s.do(req, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to download file: %w", err)
+	}
+
+	obj := ObjectFromResponse(storagePath, resp)
+	// Servers may ignore Range and send the whole object
+	if resp.StatusCode == http.StatusOK && (offset > 0 || length >= 0) {
+		body, err := SliceBody(resp.Body, offset, length)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to download file: %w", err)
+		}
+		return body, obj, nil
+	}
+	return resp.Body, obj, nil
+}
+
+func (s *S3Storage) Stat(storagePath string) (*Object, error) {
+	req, err := s.newRequest(http.MethodHead, storagePath, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.do(req, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat file: %w", err)
+	}
+	resp.Body.Close()
+
+	return ObjectFromResponse(storagePath, resp), nil
+}
+
+func (s *S3Storage) DownloadFile(storagePath string) ([]byte, error) {
+	body, _, err := s.Download(storagePath)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download file: %w", err)
+	}
+	return data, nil
+}
+
+func (s *S3Storage) DeleteFile(storagePath string) error {
+	req, err := s.newRequest(http.MethodDelete, storagePath, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.do(req, nil)
+	if err != nil {
+		return fmt.Errorf("failed to delete file: %w", err)
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func (s *S3Storage) DeleteOrderFiles(userID, orderID uuid.UUID) error {
+	objects, err := s.ListFiles(OrderPrefix(userID, orderID))
+	if err != nil {
+		return fmt.Errorf("failed to list files: %w", err)
+	}
+
+	paths := make([]string, len(objects))
+	for i, obj := range objects {
+		paths[i] = obj.Path
+	}
+	return s.deleteObjects(paths)
+}
+
+// ListFiles pages through ListObjectsV2 until all objects under prefix are returned
+func (s *S3Storage) ListFiles(prefix string) ([]Object, error) {
+	objects := make([]Object, 0)
+	continuationToken := ""
+
+	for {
+		query := url.Values{}
+		query.Set("list-type", "2")
+		query.Set("prefix", prefix)
+		if continuationToken != "" {
+			query.Set("continuation-token", continuationToken)
+		}
+
+		req, err := s.newRequest(http.MethodGet, "", query, nil)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := s.do(req, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list files: %w", err)
+		}
+
+		var result struct {
+			Contents []struct {
+				Key          string    `xml:"Key"`
+				Size         int64     `xml:"Size"`
+				ETag         string    `xml:"ETag"`
+				LastModified time.Time `xml:"LastModified"`
+			} `xml:"Contents"`
+			IsTruncated           bool   `xml:"IsTruncated"`
+			NextContinuationToken string `xml:"NextContinuationToken"`
+		}
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode list response: %w", err)
+		}
+
+		for _, c := range result.Contents {
+			objects = append(objects, Object{
+				Path:         c.Key,
+				Size:         c.Size,
+				ContentType:  ContentTypeForFilename(c.Key),
+				ETag:         c.ETag,
+				LastModified: c.LastModified,
+			})
+		}
+
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			break
+		}
+		continuationToken = result.NextContinuationToken
+	}
+
+	return objects, nil
+}
+
+func (s *S3Storage) GetPublicURL(storagePath string) string {
+	if s.cfg.PublicURL != "" {
+		return strings.TrimSuffix(s.cfg.PublicURL, "/") + "/" + storagePath
+	}
+	return s.objectURL(storagePath, nil).String()
+}
+
+// SignedURL returns a presigned GET URL (query-string SigV4)
+// Docs: https://docs.aws.amazon.com/AmazonS3/latest/API/sigv4-query-string-auth.html
+func (s *S3Storage) SignedURL(storagePath string, expiresIn time.Duration) (string, error) {
+	cleaned, err := cleanPath(storagePath)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	dateStamp := now.Format("20060102")
+	scope := dateStamp + "/" + s.cfg.Region + "/" + s3Service + "/aws4_request"
+
+	query := url.Values{}
+	query.Set("X-Amz-Algorithm", s3Algorithm)
+	query.Set("X-Amz-Credential", s.cfg.AccessKeyID+"/"+scope)
+	query.Set("X-Amz-Date", amzDate)
+	query.Set("X-Amz-Expires", strconv.Itoa(int(expiresIn.Seconds())))
+	query.Set("X-Amz-SignedHeaders", "host")
+
+	u := s.objectURL(cleaned, query)
+	canonicalRequest := strings.Join([]string{
+		http.MethodGet,
+		u.EscapedPath(),
+		canonicalQuery(query),
+		"host:" + u.Host + "\n",
+		"host",
+		"UNSIGNED-PAYLOAD",
+	}, "\n")
+
+	query.Set("X-Amz-Signature", s.signature(dateStamp, amzDate, scope, canonicalRequest))
+	u.RawQuery = canonicalQuery(query)
+	return u.String(), nil
+}
+
+// deleteObjects removes objects using the multi-object delete API in batches of 1000
+func (s *S3Storage) deleteObjects(paths []string) error {
+	for start := 0; start < len(paths); start += s3DeleteBatchLimit {
+		end := start + s3DeleteBatchLimit
+		if end > len(paths) {
+			end = len(paths)
+		}
+
+		var body bytes.Buffer
+		body.WriteString(`<?xml version="1.0" encoding="UTF-8"?><Delete><Quiet>true</Quiet>`)
+		for _, p := range paths[start:end] {
+			body.WriteString("<Object><Key>")
+			xml.EscapeText(&body, []byte(p))
+			body.WriteString("</Key></Object>")
+		}
+		body.WriteString("</Delete>")
+
+		query := url.Values{}
+		query.Set("delete", "")
+		data := body.Bytes()
+
+		req, err := s.newRequest(http.MethodPost, "", query, data)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/xml")
+		// Multi-object delete requires Content-MD5
+		sum := md5.Sum(data)
+		req.Header.Set("Content-MD5", base64.StdEncoding.EncodeToString(sum[:]))
+
+		resp, err := s.do(req, data)
+		if err != nil {
+			return fmt.Errorf("failed to delete files: %w", err)
+		}
+		resp.Body.Close()
+	}
+	return nil
+}
+
+func (s *S3Storage) objectURL(key string, query url.Values) *url.URL {
+	u := *s.endpoint
+	if s.cfg.ForcePathStyle {
+		u.Path = "/" + s.cfg.Bucket
+		if key != "" {
+			u.Path += "/" + key
+		}
+	} else {
+		u.Host = s.cfg.Bucket + "." + u.Host
+		u.Path = "/" + key
+	}
+	// Keep the wire path identical to the canonical URI used for signing
+	u.RawPath = uriEncode(u.Path, false)
+	if query != nil {
+		u.RawQuery = canonicalQuery(query)
+	}
+	return &u
+}
+
+func (s *S3Storage) newRequest(method, key string, query url.Values, body []byte) (*http.Request, error) {
+	if key != "" {
+		cleaned, err := cleanPath(key)
+		if err != nil {
+			return nil, err
+		}
+		key = cleaned
+	}
+
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequest(method, s.objectURL(key, query).String(), reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if body != nil {
+		req.ContentLength = int64(len(body))
+	}
+	return req, nil
+}
+
+// do signs and executes the request, turning non-2xx responses into errors
+func (s *S3Storage) do(req *http.Request, body []byte) (*http.Response, error) {
+	s.sign(req, body, time.Now().UTC())
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 2048))
+		resp.Body.Close()
+		return nil, fmt.Errorf("s3 request failed: status %d, body: %s", resp.StatusCode, string(respBody))
+	}
+	return resp, nil
+}
+
+// sign adds AWS Signature Version 4 headers to the request
+// Docs: https://docs.aws.amazon.com/AmazonS3/latest/API/sig-v4-header-based-auth.html
+func (s *S3Storage) sign(req *http.Request, body []byte, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	dateStamp := now.Format("20060102")
+
+	payloadHash := sha256.Sum256(body)
+	payloadHex := hex.EncodeToString(payloadHash[:])
+
+	req.Header.Set("Host", req.URL.Host)
+	req.Header.Set("x-amz-date", amzDate)
+	req.Header.Set("x-amz-content-sha256", payloadHex)
+
+	// Canonical headers: host plus every x-amz-* and content-type header, sorted
+	headerNames := []string{"host"}
+	for name := range req.Header {
+		lower := strings.ToLower(name)
+		if strings.HasPrefix(lower, "x-amz-") || lower == "content-type" {
+			headerNames = append(headerNames, lower)
+		}
+	}
+	sort.Strings(headerNames)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range headerNames {
+		value := req.URL.Host
+		if name != "host" {
+			value = strings.TrimSpace(req.Header.Get(name))
+		}
+		canonicalHeaders.WriteString(name + ":" + value + "\n")
+	}
+	signedHeaders := strings.Join(headerNames, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHex,
+	}, "\n")
+
+	scope := dateStamp + "/" + s.cfg.Region + "/" + s3Service + "/aws4_request"
+	signature := s.signature(dateStamp, amzDate, scope, canonicalRequest)
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s3Algorithm, s.cfg.AccessKeyID, scope, signedHeaders, signature))
+}
+
+// signature derives the SigV4 signing key and signs the canonical request
+func (s *S3Storage) signature(dateStamp, amzDate, scope, canonicalRequest string) string {
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		s3Algorithm,
+		amzDate,
+		scope,
+		hex.EncodeToString(requestHash[:]),
+	}, "\n")
+
+	signingKey := hmacSHA256([]byte("AWS4"+s.cfg.SecretAccessKey), dateStamp)
+	signingKey = hmacSHA256(signingKey, s.cfg.Region)
+	signingKey = hmacSHA256(signingKey, s3Service)
+	signingKey = hmacSHA256(signingKey, "aws4_request")
+	return hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// canonicalQuery encodes query parameters sorted by key as required by SigV4
+func canonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		values := append([]string(nil), query[k]...)
+		sort.Strings(values)
+		for _, v := range values {
+			parts = append(parts, uriEncode(k, true)+"="+uriEncode(v, true))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// uriEncode percent-encodes everything except RFC 3986 unreserved characters.
+// Slashes are kept when encoding a path.
+func uriEncode(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9'),
+			c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,106 @@
+package storage
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Backend names accepted by STORAGE_BACKEND
+const (
+	BackendSupabase = "supabase"
+	BackendLocal    = "local"
+	BackendS3       = "s3"
+)
+
+// Storage is implemented by every object storage backend (Supabase, local filesystem, S3-compatible).
+// All backends use the same object layout: users/{user_id}/orders/{order_id}/{filename}
+type Storage interface {
+	// UploadFile stores data for an order and returns (storagePath, url)
+	UploadFile(userID, orderID uuid.UUID, filename string, data []byte) (string, string, error)
+
+	// UploadFileWithToken is UploadFile using the caller's JWT where the backend supports RLS.
+	// Backends without per-user auth ignore the token.
+	UploadFileWithToken(userID, orderID uuid.UUID, filename string, data []byte, userToken string) (string, string, error)
+
+	// Download streams an object. The caller must close the returned reader.
+	Download(storagePath string) (io.ReadCloser, *Object, error)
+
+	// DownloadRange streams length bytes from offset (length < 0 reads to the end).
+	// The returned Object describes the whole object.
+	DownloadRange(storagePath string, offset, length int64) (io.ReadCloser, *Object, error)
+
+	// Stat returns object metadata (size, content type, ETag, last modified)
+	Stat(storagePath string) (*Object, error)
+
+	// DownloadFile reads a whole object into memory
+	DownloadFile(storagePath string) ([]byte, error)
+
+	DeleteFile(storagePath string) error
+
+	// DeleteOrderFiles removes every object under the order's prefix
+	DeleteOrderFiles(userID, orderID uuid.UUID) error
+
+	// ListFiles returns all objects under prefix (recursively) with full storage paths
+	ListFiles(prefix string) ([]Object, error)
+
+	GetPublicURL(storagePath string) string
+
+	// SignedURL returns a time-limited URL for an object in a private bucket
+	SignedURL(storagePath string, expiresIn time.Duration) (string, error)
+}
+
+// Object describes a stored object
+type Object struct {
+	Path         string
+	Size         int64
+	ContentType  string
+	ETag         string
+	LastModified time.Time
+}
+
+// OrderPrefix returns the storage prefix for all files of an order (with trailing slash)
+func OrderPrefix(userID, orderID uuid.UUID) string {
+	return fmt.Sprintf("users/%s/orders/%s/", userID.String(), orderID.String())
+}
+
+// OrderPath returns the storage path for a file of an order
+func OrderPath(userID, orderID uuid.UUID, filename string) string {
+	return OrderPrefix(userID, orderID) + filename
+}
+
+// ContentTypeForFilename guesses the MIME type from the file extension (defaults to image/jpeg)
+func ContentTypeForFilename(filename string) string {
+	ext := strings.ToLower(path.Ext(filename))
+	switch ext {
+	case ".jpg", ".jpeg":
+		return "image/jpeg"
+	case ".png":
+		return "image/png"
+	case ".webp":
+		return "image/webp"
+	}
+	if ct := mime.TypeByExtension(ext); ct != "" {
+		return ct
+	}
+	return "image/jpeg"
+}
+
+// cleanPath normalises a storage path and rejects anything that escapes the root
+func cleanPath(storagePath string) (string, error) {
+	for _, segment := range strings.Split(storagePath, "/") {
+		if segment == ".." {
+			return "", fmt.Errorf("invalid storage path: %q", storagePath)
+		}
+	}
+	cleaned := strings.TrimPrefix(path.Clean("/"+storagePath), "/")
+	if cleaned == "" {
+		return "", fmt.Errorf("invalid storage path: %q", storagePath)
+	}
+	return cleaned, nil
+}
@@ -0,0 +1,70 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+)
+
+// URLResolver decides which URL clients receive for a stored object.
+// In public mode the permanent public URL is used. In private mode only the storage path is
+// persisted and every API response carries a freshly signed URL that expires after the TTL.
+type URLResolver struct {
+	backend Storage
+	private bool
+	ttl     time.Duration
+}
+
+func NewURLResolver(backend Storage, private bool, ttl time.Duration) *URLResolver {
+	if ttl <= 0 {
+		ttl = time.Hour
+	}
+	return &URLResolver{
+		backend: backend,
+		private: private,
+		ttl:     ttl,
+	}
+}
+
+// Private reports whether the bucket is private (signed URLs only)
+func (r *URLResolver) Private() bool {
+	return r.private
+}
+
+// TTL returns the lifetime of signed URLs
+func (r *URLResolver) TTL() time.Duration {
+	return r.ttl
+}
+
+// StoredURL returns the URL to persist in order_files.storage_url.
+// Private buckets store no URL at all - only the storage path is kept.
+func (r *URLResolver) StoredURL(publicURL string) string {
+	if r.private {
+		return ""
+	}
+	return publicURL
+}
+
+// Resolve returns the URL to hand to clients for a stored file.
+// expiresAt is nil for permanent public URLs.
+func (r *URLResolver) Resolve(storagePath, storedURL string) (string, *time.Time, error) {
+	if !r.private {
+		if storedURL != "" {
+			return storedURL, nil, nil
+		}
+		if storagePath == "" {
+			return "", nil, nil
+		}
+		return r.backend.GetPublicURL(storagePath), nil, nil
+	}
+
+	if storagePath == "" {
+		return "", nil, fmt.Errorf("file has no storage path")
+	}
+
+	expiresAt := time.Now().Add(r.ttl).UTC()
+	signedURL, err := r.backend.SignedURL(storagePath, r.ttl)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to sign url: %w", err)
+	}
+	return signedURL, &expiresAt, nil
+}
@@ -4,11 +4,15 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
+	"instant-hdr-backend/internal/credits"
 	"instant-hdr-backend/internal/models"
+	"instant-hdr-backend/internal/quota"
 )
 
 type DatabaseClient struct {
@@ -36,11 +40,11 @@ func (d *DatabaseClient) CreateOrder(orderID, userID uuid.UUID, metadata map[str
 		INSERT INTO orders (id, user_id, status, metadata)
 		VALUES ($1, $2, $3, $4)
 		RETURNING id, user_id, status, progress, metadata, error_message, created_at, updated_at,
-		          name, autoenhance_status, is_processing, is_merging, is_deleted, total_images, autoenhance_last_updated_at
+		          name, autoenhance_status, is_processing, is_merging, is_deleted, total_images, autoenhance_last_updated_at, deleted_at, property_id, organization_id, tags
 	`, orderID, userID, "created", metadataJSON).Scan(
 		&order.ID, &order.UserID, &order.Status,
 		&order.Progress, &order.Metadata, &order.ErrorMessage, &order.CreatedAt, &order.UpdatedAt,
-		&order.Name, &order.AutoEnhanceStatus, &order.IsProcessing, &order.IsMerging, &order.IsDeleted, &order.TotalImages, &order.AutoEnhanceLastUpdatedAt,
+		&order.Name, &order.AutoEnhanceStatus, &order.IsProcessing, &order.IsMerging, &order.IsDeleted, &order.TotalImages, &order.AutoEnhanceLastUpdatedAt, &order.DeletedAt, &order.PropertyID, &order.OrganizationID, pq.Array(&order.Tags),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create order: %w", err)
@@ -49,17 +53,27 @@ func (d *DatabaseClient) CreateOrder(orderID, userID uuid.UUID, metadata map[str
 	return &order, nil
 }
 
+// GetOrder returns an order that is not in the trash
 func (d *DatabaseClient) GetOrder(orderID, userID uuid.UUID) (*models.Order, error) {
+	return d.getOrder(orderID, userID, "deleted_at IS NULL")
+}
+
+// GetTrashedOrder returns an order only while it is in the trash
+func (d *DatabaseClient) GetTrashedOrder(orderID, userID uuid.UUID) (*models.Order, error) {
+	return d.getOrder(orderID, userID, "deleted_at IS NOT NULL")
+}
+
+func (d *DatabaseClient) getOrder(orderID, userID uuid.UUID, trashCondition string) (*models.Order, error) {
 	var order models.Order
 	err := d.db.QueryRow(`
 		SELECT id, user_id, status, progress, metadata, error_message, created_at, updated_at,
-		       name, autoenhance_status, is_processing, is_merging, is_deleted, total_images, autoenhance_last_updated_at
+		       name, autoenhance_status, is_processing, is_merging, is_deleted, total_images, autoenhance_last_updated_at, deleted_at, property_id, organization_id, tags
 		FROM orders
-		WHERE id = $1 AND user_id = $2
+		WHERE id = $1 AND `+orderVisibleTo("$2")+` AND `+trashCondition+`
 	`, orderID, userID).Scan(
 		&order.ID, &order.UserID, &order.Status,
 		&order.Progress, &order.Metadata, &order.ErrorMessage, &order.CreatedAt, &order.UpdatedAt,
-		&order.Name, &order.AutoEnhanceStatus, &order.IsProcessing, &order.IsMerging, &order.IsDeleted, &order.TotalImages, &order.AutoEnhanceLastUpdatedAt,
+		&order.Name, &order.AutoEnhanceStatus, &order.IsProcessing, &order.IsMerging, &order.IsDeleted, &order.TotalImages, &order.AutoEnhanceLastUpdatedAt, &order.DeletedAt, &order.PropertyID, &order.OrganizationID, pq.Array(&order.Tags),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get order: %w", err)
@@ -68,14 +82,118 @@ func (d *DatabaseClient) GetOrder(orderID, userID uuid.UUID) (*models.Order, err
 	return &order, nil
 }
 
-func (d *DatabaseClient) ListOrders(userID uuid.UUID) ([]models.Order, error) {
-	rows, err := d.db.Query(`
+// orderVisibleTo is the condition for orders the user in param can see:
+// their personal orders and the orders of every organization they are a member of
+func orderVisibleTo(param string) string {
+	return fmt.Sprintf(`((organization_id IS NULL AND user_id = %[1]s)
+		OR organization_id IN (SELECT organization_id FROM organization_members WHERE user_id = %[1]s))`, param)
+}
+
+// TrashOrder moves an order to the trash. Orders already in the trash keep their original deleted_at.
+func (d *DatabaseClient) TrashOrder(orderID, userID uuid.UUID) error {
+	_, err := d.db.Exec(`
+		UPDATE orders
+		SET deleted_at = NOW()
+		WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL
+	`, orderID, userID)
+	return err
+}
+
+// RestoreOrder takes an order out of the trash
+func (d *DatabaseClient) RestoreOrder(orderID, userID uuid.UUID) error {
+	_, err := d.db.Exec(`
+		UPDATE orders
+		SET deleted_at = NULL
+		WHERE id = $1 AND user_id = $2
+	`, orderID, userID)
+	return err
+}
+
+// ListOrders returns the orders a user can see, filtered, sorted and paged by opts.
+// Pages are keyset-paginated on (sort key, id) so deep pages cost the same as the first.
+func (d *DatabaseClient) ListOrders(userID uuid.UUID, opts models.OrderListOptions) ([]models.Order, error) {
+	return d.listOrders([]string{orderVisibleTo("$1")}, []interface{}{userID}, opts)
+}
+
+// SearchOrders lists every user's orders, filtered, sorted and paged like ListOrders
+func (d *DatabaseClient) SearchOrders(opts models.OrderListOptions) ([]models.Order, error) {
+	return d.listOrders(nil, nil, opts)
+}
+
+// listOrders adds the conditions of opts to where, whose placeholders are bound to args
+func (d *DatabaseClient) listOrders(where []string, args []interface{}, opts models.OrderListOptions) ([]models.Order, error) {
+	if opts.Trashed {
+		where = append(where, "deleted_at IS NOT NULL")
+	} else {
+		where = append(where, "deleted_at IS NULL")
+	}
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if opts.UserID.Valid {
+		where = append(where, "user_id = "+arg(opts.UserID.UUID))
+	}
+	if len(opts.Statuses) > 0 {
+		where = append(where, "status = ANY("+arg(pq.Array(opts.Statuses))+")")
+	}
+	if opts.PropertyID.Valid {
+		where = append(where, "property_id = "+arg(opts.PropertyID.UUID))
+	}
+	if opts.OrganizationID.Valid {
+		where = append(where, "organization_id = "+arg(opts.OrganizationID.UUID))
+	}
+	if len(opts.Tags) > 0 {
+		where = append(where, "tags @> "+arg(pq.Array(opts.Tags)))
+	}
+	if opts.IsProcessing != nil {
+		where = append(where, "COALESCE(is_processing, false) = "+arg(*opts.IsProcessing))
+	}
+	if opts.NameContains != "" {
+		where = append(where, "name ILIKE "+arg("%"+likeEscaper.Replace(opts.NameContains)+"%"))
+	}
+	if opts.CreatedAfter != nil {
+		where = append(where, "created_at >= "+arg(*opts.CreatedAfter))
+	}
+	if opts.CreatedBefore != nil {
+		where = append(where, "created_at < "+arg(*opts.CreatedBefore))
+	}
+
+	sortExpr := "created_at"
+	switch opts.Sort {
+	case models.OrderSortUpdatedAt:
+		sortExpr = "updated_at"
+	case models.OrderSortName:
+		sortExpr = "COALESCE(name, '')"
+	case models.OrderSortDeletedAt:
+		sortExpr = "deleted_at"
+	}
+	direction, comparison := "DESC", "<"
+	if opts.Ascending {
+		direction, comparison = "ASC", ">"
+	}
+
+	if opts.After != nil {
+		var value interface{} = opts.After.Time
+		if opts.Sort == models.OrderSortName {
+			value = opts.After.Name
+		}
+		where = append(where, fmt.Sprintf("(%s, id) %s (%s, %s)", sortExpr, comparison, arg(value), arg(opts.After.ID)))
+	}
+
+	query := fmt.Sprintf(`
 		SELECT id, user_id, status, progress, metadata, error_message, created_at, updated_at,
-		       name, autoenhance_status, is_processing, is_merging, is_deleted, total_images, autoenhance_last_updated_at
+		       name, autoenhance_status, is_processing, is_merging, is_deleted, total_images, autoenhance_last_updated_at, deleted_at, property_id, organization_id, tags
 		FROM orders
-		WHERE user_id = $1
-		ORDER BY created_at DESC
-	`, userID)
+		WHERE %s
+		ORDER BY %s %s, id %s
+	`, strings.Join(where, " AND "), sortExpr, direction, direction)
+	if opts.Limit > 0 {
+		query += " LIMIT " + arg(opts.Limit)
+	}
+
+	rows, err := d.db.Query(query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list orders: %w", err)
 	}
@@ -87,7 +205,7 @@ func (d *DatabaseClient) ListOrders(userID uuid.UUID) ([]models.Order, error) {
 		err := rows.Scan(
 			&order.ID, &order.UserID, &order.Status,
 			&order.Progress, &order.Metadata, &order.ErrorMessage, &order.CreatedAt, &order.UpdatedAt,
-			&order.Name, &order.AutoEnhanceStatus, &order.IsProcessing, &order.IsMerging, &order.IsDeleted, &order.TotalImages, &order.AutoEnhanceLastUpdatedAt,
+			&order.Name, &order.AutoEnhanceStatus, &order.IsProcessing, &order.IsMerging, &order.IsDeleted, &order.TotalImages, &order.AutoEnhanceLastUpdatedAt, &order.DeletedAt, &order.PropertyID, &order.OrganizationID, pq.Array(&order.Tags),
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan order: %w", err)
@@ -95,9 +213,12 @@ func (d *DatabaseClient) ListOrders(userID uuid.UUID) ([]models.Order, error) {
 		orders = append(orders, order)
 	}
 
-	return orders, nil
+	return orders, rows.Err()
 }
 
+// likeEscaper escapes LIKE wildcards so user input matches literally
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
 func (d *DatabaseClient) UpdateOrderStatus(orderID uuid.UUID, status string, progress int) error {
 	_, err := d.db.Exec(`
 		UPDATE orders
@@ -116,6 +237,16 @@ func (d *DatabaseClient) UpdateOrderError(orderID uuid.UUID, errorMsg string) er
 	return err
 }
 
+// ResetOrderError clears an order's error and puts it back to status
+func (d *DatabaseClient) ResetOrderError(orderID uuid.UUID, status string, progress int) error {
+	_, err := d.db.Exec(`
+		UPDATE orders
+		SET status = $1, progress = $2, error_message = NULL
+		WHERE id = $3
+	`, status, progress, orderID)
+	return err
+}
+
 func (d *DatabaseClient) DeleteOrder(orderID, userID uuid.UUID) error {
 	_, err := d.db.Exec(`
 		DELETE FROM orders
@@ -135,13 +266,13 @@ func (d *DatabaseClient) GetOrderByAutoEnhanceOrderID(autoenhanceOrderID string)
 	var order models.Order
 	err = d.db.QueryRow(`
 		SELECT id, user_id, status, progress, metadata, error_message, created_at, updated_at,
-		       name, autoenhance_status, is_processing, is_merging, is_deleted, total_images, autoenhance_last_updated_at
+		       name, autoenhance_status, is_processing, is_merging, is_deleted, total_images, autoenhance_last_updated_at, deleted_at, property_id, organization_id, tags
 		FROM orders
 		WHERE id = $1
 	`, orderID).Scan(
 		&order.ID, &order.UserID, &order.Status,
 		&order.Progress, &order.Metadata, &order.ErrorMessage, &order.CreatedAt, &order.UpdatedAt,
-		&order.Name, &order.AutoEnhanceStatus, &order.IsProcessing, &order.IsMerging, &order.IsDeleted, &order.TotalImages, &order.AutoEnhanceLastUpdatedAt,
+		&order.Name, &order.AutoEnhanceStatus, &order.IsProcessing, &order.IsMerging, &order.IsDeleted, &order.TotalImages, &order.AutoEnhanceLastUpdatedAt, &order.DeletedAt, &order.PropertyID, &order.OrganizationID, pq.Array(&order.Tags),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get order: %w", err)
@@ -151,17 +282,23 @@ func (d *DatabaseClient) GetOrderByAutoEnhanceOrderID(autoenhanceOrderID string)
 }
 
 func (d *DatabaseClient) CreateOrderFile(file *models.OrderFile) error {
+	if file.ID == uuid.Nil {
+		file.ID = uuid.New()
+	}
 	_, err := d.db.Exec(`
-		INSERT INTO order_files (order_id, user_id, filename, autoenhance_image_id, storage_path, storage_url, file_size, mime_type, is_final)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
-	`, file.OrderID, file.UserID, file.Filename, file.AutoEnhanceImageID, file.StoragePath,
-		file.StorageURL, file.FileSize, file.MimeType, file.IsFinal)
+		INSERT INTO order_files (id, order_id, user_id, filename, autoenhance_image_id, storage_path, storage_url, file_size, mime_type, is_final,
+		                         variant, format, watermark, parent_file_id, width, height)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
+	`, file.ID, file.OrderID, file.UserID, file.Filename, file.AutoEnhanceImageID, file.StoragePath,
+		file.StorageURL, file.FileSize, file.MimeType, file.IsFinal,
+		file.Variant, file.Format, file.Watermark, file.ParentFileID, file.Width, file.Height)
 	return err
 }
 
 func (d *DatabaseClient) GetOrderFiles(orderID, userID uuid.UUID) ([]models.OrderFile, error) {
 	rows, err := d.db.Query(`
-		SELECT id, order_id, user_id, filename, autoenhance_image_id, storage_path, storage_url, file_size, mime_type, is_final, created_at
+		SELECT id, order_id, user_id, filename, autoenhance_image_id, storage_path, storage_url, file_size, mime_type, is_final, created_at,
+		       variant, format, watermark, parent_file_id, width, height
 		FROM order_files
 		WHERE order_id = $1 AND user_id = $2
 		ORDER BY created_at DESC
@@ -178,6 +315,7 @@ func (d *DatabaseClient) GetOrderFiles(orderID, userID uuid.UUID) ([]models.Orde
 			&file.ID, &file.OrderID, &file.UserID, &file.Filename,
 			&file.AutoEnhanceImageID, &file.StoragePath, &file.StorageURL,
 			&file.FileSize, &file.MimeType, &file.IsFinal, &file.CreatedAt,
+			&file.Variant, &file.Format, &file.Watermark, &file.ParentFileID, &file.Width, &file.Height,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan file: %w", err)
@@ -188,6 +326,49 @@ func (d *DatabaseClient) GetOrderFiles(orderID, userID uuid.UUID) ([]models.Orde
 	return files, nil
 }
 
+func (d *DatabaseClient) GetOrderFile(fileID, userID uuid.UUID) (*models.OrderFile, error) {
+	var file models.OrderFile
+	err := d.db.QueryRow(`
+		SELECT id, order_id, user_id, filename, autoenhance_image_id, storage_path, storage_url, file_size, mime_type, is_final, created_at,
+		       variant, format, watermark, parent_file_id, width, height
+		FROM order_files
+		WHERE id = $1 AND user_id = $2
+	`, fileID, userID).Scan(
+		&file.ID, &file.OrderID, &file.UserID, &file.Filename,
+		&file.AutoEnhanceImageID, &file.StoragePath, &file.StorageURL,
+		&file.FileSize, &file.MimeType, &file.IsFinal, &file.CreatedAt,
+		&file.Variant, &file.Format, &file.Watermark, &file.ParentFileID, &file.Width, &file.Height,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order file: %w", err)
+	}
+
+	return &file, nil
+}
+
+// GetOrderFileVariant returns a stored variant of an AutoEnhance image (sql.ErrNoRows if not generated yet)
+func (d *DatabaseClient) GetOrderFileVariant(orderID uuid.UUID, imageID, variant, format string, watermark bool) (*models.OrderFile, error) {
+	var file models.OrderFile
+	err := d.db.QueryRow(`
+		SELECT id, order_id, user_id, filename, autoenhance_image_id, storage_path, storage_url, file_size, mime_type, is_final, created_at,
+		       variant, format, watermark, parent_file_id, width, height
+		FROM order_files
+		WHERE order_id = $1 AND autoenhance_image_id = $2 AND variant = $3 AND format = $4 AND watermark = $5
+		ORDER BY created_at DESC
+		LIMIT 1
+	`, orderID, imageID, variant, format, watermark).Scan(
+		&file.ID, &file.OrderID, &file.UserID, &file.Filename,
+		&file.AutoEnhanceImageID, &file.StoragePath, &file.StorageURL,
+		&file.FileSize, &file.MimeType, &file.IsFinal, &file.CreatedAt,
+		&file.Variant, &file.Format, &file.Watermark, &file.ParentFileID, &file.Width, &file.Height,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order file variant: %w", err)
+	}
+
+	return &file, nil
+}
+
 func (d *DatabaseClient) DeleteOrderFile(fileID uuid.UUID) error {
 	_, err := d.db.Exec(`
 		DELETE FROM order_files
@@ -196,6 +377,213 @@ func (d *DatabaseClient) DeleteOrderFile(fileID uuid.UUID) error {
 	return err
 }
 
+// ListExpiredPreviewFiles returns watermarked (free) files created before the cutoff, oldest first.
+// Files from before variant tracking count as previews when they are not final.
+// Unwatermarked (paid) files are never returned. userID limits the result to one user when valid.
+func (d *DatabaseClient) ListExpiredPreviewFiles(before time.Time, userID uuid.NullUUID, limit int) ([]models.OrderFile, error) {
+	rows, err := d.db.Query(`
+		SELECT id, order_id, user_id, filename, autoenhance_image_id, storage_path, storage_url, file_size, mime_type, is_final, created_at,
+		       variant, format, watermark, parent_file_id, width, height
+		FROM order_files
+		WHERE created_at < $1
+		  AND (watermark = true OR (watermark IS NULL AND is_final = false))
+		  AND ($2::uuid IS NULL OR user_id = $2)
+		ORDER BY created_at ASC
+		LIMIT $3
+	`, before, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list expired preview files: %w", err)
+	}
+	defer rows.Close()
+
+	var files []models.OrderFile
+	for rows.Next() {
+		var file models.OrderFile
+		err := rows.Scan(
+			&file.ID, &file.OrderID, &file.UserID, &file.Filename,
+			&file.AutoEnhanceImageID, &file.StoragePath, &file.StorageURL,
+			&file.FileSize, &file.MimeType, &file.IsFinal, &file.CreatedAt,
+			&file.Variant, &file.Format, &file.Watermark, &file.ParentFileID, &file.Width, &file.Height,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan file: %w", err)
+		}
+		files = append(files, file)
+	}
+
+	return files, nil
+}
+
+// ListPurgeableOrders returns orders in the trash since before the cutoff, and orders deleted in
+// AutoEnhance (is_deleted) and untouched since then whose storage is not purged yet. userID limits
+// the result to one user when valid.
+func (d *DatabaseClient) ListPurgeableOrders(before time.Time, userID uuid.NullUUID) ([]models.Order, error) {
+	rows, err := d.db.Query(`
+		SELECT id, user_id, status, progress, metadata, error_message, created_at, updated_at,
+		       name, autoenhance_status, is_processing, is_merging, is_deleted, total_images, autoenhance_last_updated_at, deleted_at, property_id, organization_id, tags
+		FROM orders
+		WHERE COALESCE(deleted_at, CASE WHEN is_deleted AND storage_purged_at IS NULL THEN updated_at END) < $1
+		  AND ($2::uuid IS NULL OR user_id = $2)
+		ORDER BY COALESCE(deleted_at, updated_at) ASC
+	`, before, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list purgeable orders: %w", err)
+	}
+	defer rows.Close()
+
+	var orders []models.Order
+	for rows.Next() {
+		var order models.Order
+		err := rows.Scan(
+			&order.ID, &order.UserID, &order.Status,
+			&order.Progress, &order.Metadata, &order.ErrorMessage, &order.CreatedAt, &order.UpdatedAt,
+			&order.Name, &order.AutoEnhanceStatus, &order.IsProcessing, &order.IsMerging, &order.IsDeleted, &order.TotalImages, &order.AutoEnhanceLastUpdatedAt, &order.DeletedAt, &order.PropertyID, &order.OrganizationID, pq.Array(&order.Tags),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan order: %w", err)
+		}
+		orders = append(orders, order)
+	}
+
+	return orders, nil
+}
+
+// MarkOrderStoragePurged records that the storage of an order deleted in AutoEnhance was purged
+func (d *DatabaseClient) MarkOrderStoragePurged(orderID uuid.UUID) error {
+	_, err := d.db.Exec(`
+		UPDATE orders
+		SET storage_purged_at = NOW()
+		WHERE id = $1
+	`, orderID)
+	if err != nil {
+		return fmt.Errorf("failed to mark order storage purged: %w", err)
+	}
+	return nil
+}
+
+// DeleteOrderFilesByOrderID removes all file records of an order and returns how many were deleted
+func (d *DatabaseClient) DeleteOrderFilesByOrderID(orderID uuid.UUID) (int64, error) {
+	result, err := d.db.Exec(`
+		DELETE FROM order_files
+		WHERE order_id = $1
+	`, orderID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete order files: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// ListAllOrders returns every order, including deleted ones. userID limits the result to one user when valid.
+func (d *DatabaseClient) ListAllOrders(userID uuid.NullUUID) ([]models.Order, error) {
+	rows, err := d.db.Query(`
+		SELECT id, user_id, status, progress, metadata, error_message, created_at, updated_at,
+		       name, autoenhance_status, is_processing, is_merging, is_deleted, total_images, autoenhance_last_updated_at, deleted_at, property_id, organization_id, tags
+		FROM orders
+		WHERE ($1::uuid IS NULL OR user_id = $1)
+		ORDER BY created_at ASC
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list orders: %w", err)
+	}
+	defer rows.Close()
+
+	var orders []models.Order
+	for rows.Next() {
+		var order models.Order
+		err := rows.Scan(
+			&order.ID, &order.UserID, &order.Status,
+			&order.Progress, &order.Metadata, &order.ErrorMessage, &order.CreatedAt, &order.UpdatedAt,
+			&order.Name, &order.AutoEnhanceStatus, &order.IsProcessing, &order.IsMerging, &order.IsDeleted, &order.TotalImages, &order.AutoEnhanceLastUpdatedAt, &order.DeletedAt, &order.PropertyID, &order.OrganizationID, pq.Array(&order.Tags),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan order: %w", err)
+		}
+		orders = append(orders, order)
+	}
+
+	return orders, nil
+}
+
+// GetOrderFilesByOrderID returns all file records of an order, including rows with a missing user_id
+func (d *DatabaseClient) GetOrderFilesByOrderID(orderID uuid.UUID) ([]models.OrderFile, error) {
+	rows, err := d.db.Query(`
+		SELECT id, order_id, user_id, filename, autoenhance_image_id, storage_path, storage_url, file_size, mime_type, is_final, created_at,
+		       variant, format, watermark, parent_file_id, width, height
+		FROM order_files
+		WHERE order_id = $1
+		ORDER BY created_at ASC
+	`, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order files: %w", err)
+	}
+	defer rows.Close()
+
+	var files []models.OrderFile
+	for rows.Next() {
+		var file models.OrderFile
+		err := rows.Scan(
+			&file.ID, &file.OrderID, &file.UserID, &file.Filename,
+			&file.AutoEnhanceImageID, &file.StoragePath, &file.StorageURL,
+			&file.FileSize, &file.MimeType, &file.IsFinal, &file.CreatedAt,
+			&file.Variant, &file.Format, &file.Watermark, &file.ParentFileID, &file.Width, &file.Height,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan file: %w", err)
+		}
+		files = append(files, file)
+	}
+
+	return files, nil
+}
+
+// ListOrphanedOrderFiles returns file records whose order no longer exists.
+// userID limits the result to one user when valid.
+func (d *DatabaseClient) ListOrphanedOrderFiles(userID uuid.NullUUID) ([]models.OrderFile, error) {
+	rows, err := d.db.Query(`
+		SELECT f.id, f.order_id, f.user_id, f.filename, f.autoenhance_image_id, f.storage_path, f.storage_url, f.file_size, f.mime_type, f.is_final, f.created_at,
+		       f.variant, f.format, f.watermark, f.parent_file_id, f.width, f.height
+		FROM order_files f
+		LEFT JOIN orders o ON o.id = f.order_id
+		WHERE o.id IS NULL
+		  AND ($1::uuid IS NULL OR f.user_id = $1)
+		ORDER BY f.created_at ASC
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list orphaned order files: %w", err)
+	}
+	defer rows.Close()
+
+	var files []models.OrderFile
+	for rows.Next() {
+		var file models.OrderFile
+		err := rows.Scan(
+			&file.ID, &file.OrderID, &file.UserID, &file.Filename,
+			&file.AutoEnhanceImageID, &file.StoragePath, &file.StorageURL,
+			&file.FileSize, &file.MimeType, &file.IsFinal, &file.CreatedAt,
+			&file.Variant, &file.Format, &file.Watermark, &file.ParentFileID, &file.Width, &file.Height,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan file: %w", err)
+		}
+		files = append(files, file)
+	}
+
+	return files, nil
+}
+
+// UpdateOrderFileLocation sets the storage path and owner of a file record
+func (d *DatabaseClient) UpdateOrderFileLocation(fileID, userID uuid.UUID, storagePath string) error {
+	_, err := d.db.Exec(`
+		UPDATE order_files
+		SET storage_path = $1, user_id = $2
+		WHERE id = $3
+	`, storagePath, userID, fileID)
+	if err != nil {
+		return fmt.Errorf("failed to update order file: %w", err)
+	}
+	return nil
+}
+
 func (d *DatabaseClient) CreateBracket(bracket *models.Bracket) error {
 	_, err := d.db.Exec(`
 		INSERT INTO brackets (order_id, bracket_id, image_id, filename, upload_url, is_uploaded, metadata)
@@ -234,6 +622,48 @@ func (d *DatabaseClient) GetBracketsByOrderID(orderID uuid.UUID) ([]models.Brack
 	return brackets, nil
 }
 
+// ListOrphanedBrackets returns bracket records whose order no longer exists
+func (d *DatabaseClient) ListOrphanedBrackets() ([]models.Bracket, error) {
+	rows, err := d.db.Query(`
+		SELECT b.id, b.order_id, b.bracket_id, b.image_id, b.filename, b.upload_url, b.is_uploaded, b.metadata, b.created_at
+		FROM brackets b
+		LEFT JOIN orders o ON o.id = b.order_id
+		WHERE o.id IS NULL
+		ORDER BY b.created_at ASC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list orphaned brackets: %w", err)
+	}
+	defer rows.Close()
+
+	var brackets []models.Bracket
+	for rows.Next() {
+		var bracket models.Bracket
+		err := rows.Scan(
+			&bracket.ID, &bracket.OrderID, &bracket.BracketID, &bracket.ImageID,
+			&bracket.Filename, &bracket.UploadURL, &bracket.IsUploaded,
+			&bracket.Metadata, &bracket.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan bracket: %w", err)
+		}
+		brackets = append(brackets, bracket)
+	}
+
+	return brackets, nil
+}
+
+func (d *DatabaseClient) DeleteBracketRecord(id uuid.UUID) error {
+	_, err := d.db.Exec(`
+		DELETE FROM brackets
+		WHERE id = $1
+	`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete bracket: %w", err)
+	}
+	return nil
+}
+
 func (d *DatabaseClient) UpdateBracketImageID(bracketID string, imageID string) error {
 	_, err := d.db.Exec(`
 		UPDATE brackets
@@ -267,6 +697,1038 @@ func (d *DatabaseClient) SyncAutoEnhanceOrderData(orderID uuid.UUID, name string
 	return err
 }
 
+func (d *DatabaseClient) CreateProperty(property *models.Property) error {
+	err := d.db.QueryRow(`
+		INSERT INTO properties (user_id, address, mls_number, client_name, notes)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at, updated_at
+	`, property.UserID, property.Address, property.MLSNumber, property.ClientName, property.Notes).Scan(
+		&property.ID, &property.CreatedAt, &property.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create property: %w", err)
+	}
+	return nil
+}
+
+func (d *DatabaseClient) GetProperty(propertyID, userID uuid.UUID) (*models.Property, error) {
+	var property models.Property
+	err := d.db.QueryRow(`
+		SELECT id, user_id, address, mls_number, client_name, notes, created_at, updated_at
+		FROM properties
+		WHERE id = $1 AND user_id = $2
+	`, propertyID, userID).Scan(
+		&property.ID, &property.UserID, &property.Address, &property.MLSNumber,
+		&property.ClientName, &property.Notes, &property.CreatedAt, &property.UpdatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get property: %w", err)
+	}
+	return &property, nil
+}
+
+// ListProperties returns a user's properties, newest first
+func (d *DatabaseClient) ListProperties(userID uuid.UUID) ([]models.Property, error) {
+	rows, err := d.db.Query(`
+		SELECT id, user_id, address, mls_number, client_name, notes, created_at, updated_at
+		FROM properties
+		WHERE user_id = $1
+		ORDER BY created_at DESC, id DESC
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list properties: %w", err)
+	}
+	defer rows.Close()
+
+	var properties []models.Property
+	for rows.Next() {
+		var property models.Property
+		err := rows.Scan(
+			&property.ID, &property.UserID, &property.Address, &property.MLSNumber,
+			&property.ClientName, &property.Notes, &property.CreatedAt, &property.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan property: %w", err)
+		}
+		properties = append(properties, property)
+	}
+
+	return properties, rows.Err()
+}
+
+// UpdateProperty saves the editable fields of a property owned by property.UserID
+func (d *DatabaseClient) UpdateProperty(property *models.Property) error {
+	err := d.db.QueryRow(`
+		UPDATE properties
+		SET address = $1, mls_number = $2, client_name = $3, notes = $4
+		WHERE id = $5 AND user_id = $6
+		RETURNING updated_at
+	`, property.Address, property.MLSNumber, property.ClientName, property.Notes, property.ID, property.UserID).Scan(&property.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to update property: %w", err)
+	}
+	return nil
+}
+
+// DeleteProperty deletes a property. Its orders are unlinked, not deleted.
+func (d *DatabaseClient) DeleteProperty(propertyID, userID uuid.UUID) error {
+	_, err := d.db.Exec(`
+		DELETE FROM properties
+		WHERE id = $1 AND user_id = $2
+	`, propertyID, userID)
+	return err
+}
+
+// SetOrderProperty links an order to one of the user's properties, or unlinks it when propertyID is not valid
+func (d *DatabaseClient) SetOrderProperty(orderID, userID uuid.UUID, propertyID uuid.NullUUID) error {
+	result, err := d.db.Exec(`
+		UPDATE orders
+		SET property_id = $3
+		WHERE id = $1 AND `+orderVisibleTo("$2")+`
+		  AND ($3::uuid IS NULL OR EXISTS (SELECT 1 FROM properties WHERE id = $3 AND user_id = $2))
+	`, orderID, userID, propertyID)
+	if err != nil {
+		return fmt.Errorf("failed to set order property: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("failed to set order property: %w", sql.ErrNoRows)
+	}
+	return nil
+}
+
+// CreateOrganization creates an organization with org.CreatedBy as its owner
+func (d *DatabaseClient) CreateOrganization(org *models.Organization) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to create organization: %w", err)
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow(`
+		INSERT INTO organizations (name, created_by)
+		VALUES ($1, $2)
+		RETURNING id, created_at, updated_at
+	`, org.Name, org.CreatedBy).Scan(&org.ID, &org.CreatedAt, &org.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create organization: %w", err)
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO organization_members (organization_id, user_id, role)
+		VALUES ($1, $2, $3)
+	`, org.ID, org.CreatedBy, models.RoleOwner)
+	if err != nil {
+		return fmt.Errorf("failed to add organization owner: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+func (d *DatabaseClient) GetOrganization(organizationID uuid.UUID) (*models.Organization, error) {
+	var org models.Organization
+	err := d.db.QueryRow(`
+		SELECT id, name, created_by, created_at, updated_at
+		FROM organizations
+		WHERE id = $1
+	`, organizationID).Scan(&org.ID, &org.Name, &org.CreatedBy, &org.CreatedAt, &org.UpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get organization: %w", err)
+	}
+	return &org, nil
+}
+
+// ListOrganizations returns the organizations the user is a member of, with their role, ordered by name
+func (d *DatabaseClient) ListOrganizations(userID uuid.UUID) ([]models.OrganizationMembership, error) {
+	rows, err := d.db.Query(`
+		SELECT o.id, o.name, o.created_by, o.created_at, o.updated_at, m.role
+		FROM organizations o
+		JOIN organization_members m ON m.organization_id = o.id
+		WHERE m.user_id = $1
+		ORDER BY o.name, o.id
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list organizations: %w", err)
+	}
+	defer rows.Close()
+
+	var memberships []models.OrganizationMembership
+	for rows.Next() {
+		var m models.OrganizationMembership
+		if err := rows.Scan(&m.ID, &m.Name, &m.CreatedBy, &m.CreatedAt, &m.UpdatedAt, &m.Role); err != nil {
+			return nil, fmt.Errorf("failed to scan organization: %w", err)
+		}
+		memberships = append(memberships, m)
+	}
+
+	return memberships, rows.Err()
+}
+
+func (d *DatabaseClient) UpdateOrganization(org *models.Organization) error {
+	err := d.db.QueryRow(`
+		UPDATE organizations
+		SET name = $1
+		WHERE id = $2
+		RETURNING updated_at
+	`, org.Name, org.ID).Scan(&org.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to update organization: %w", err)
+	}
+	return nil
+}
+
+// DeleteOrganization deletes an organization and its memberships. Its orders become personal orders of their creators.
+func (d *DatabaseClient) DeleteOrganization(organizationID uuid.UUID) error {
+	_, err := d.db.Exec(`
+		DELETE FROM organizations
+		WHERE id = $1
+	`, organizationID)
+	return err
+}
+
+func (d *DatabaseClient) GetOrganizationMember(organizationID, userID uuid.UUID) (*models.OrganizationMember, error) {
+	var member models.OrganizationMember
+	err := d.db.QueryRow(`
+		SELECT organization_id, user_id, role, created_at
+		FROM organization_members
+		WHERE organization_id = $1 AND user_id = $2
+	`, organizationID, userID).Scan(&member.OrganizationID, &member.UserID, &member.Role, &member.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get organization member: %w", err)
+	}
+	return &member, nil
+}
+
+// ListOrganizationMembers returns the members of an organization, most privileged first
+func (d *DatabaseClient) ListOrganizationMembers(organizationID uuid.UUID) ([]models.OrganizationMember, error) {
+	rows, err := d.db.Query(`
+		SELECT organization_id, user_id, role, created_at
+		FROM organization_members
+		WHERE organization_id = $1
+		ORDER BY CASE role WHEN 'owner' THEN 1 WHEN 'admin' THEN 2 WHEN 'editor' THEN 3 ELSE 4 END, created_at, user_id
+	`, organizationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list organization members: %w", err)
+	}
+	defer rows.Close()
+
+	var members []models.OrganizationMember
+	for rows.Next() {
+		var member models.OrganizationMember
+		if err := rows.Scan(&member.OrganizationID, &member.UserID, &member.Role, &member.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan organization member: %w", err)
+		}
+		members = append(members, member)
+	}
+
+	return members, rows.Err()
+}
+
+// SetOrganizationMember adds a member or changes the role of an existing one
+func (d *DatabaseClient) SetOrganizationMember(member *models.OrganizationMember) error {
+	err := d.db.QueryRow(`
+		INSERT INTO organization_members (organization_id, user_id, role)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (organization_id, user_id) DO UPDATE SET role = EXCLUDED.role
+		RETURNING created_at
+	`, member.OrganizationID, member.UserID, member.Role).Scan(&member.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to set organization member: %w", err)
+	}
+	return nil
+}
+
+func (d *DatabaseClient) RemoveOrganizationMember(organizationID, userID uuid.UUID) error {
+	_, err := d.db.Exec(`
+		DELETE FROM organization_members
+		WHERE organization_id = $1 AND user_id = $2
+	`, organizationID, userID)
+	return err
+}
+
+// SetOrderOrganization shares an order with an organization, or makes it personal again when organizationID is not valid
+func (d *DatabaseClient) SetOrderOrganization(orderID uuid.UUID, organizationID uuid.NullUUID) error {
+	_, err := d.db.Exec(`
+		UPDATE orders
+		SET organization_id = $2
+		WHERE id = $1
+	`, orderID, organizationID)
+	return err
+}
+
+// UpdateOrderTags adds and removes order tags in one statement, so concurrent updates don't lose tags
+func (d *DatabaseClient) UpdateOrderTags(orderID uuid.UUID, add, remove []string) ([]string, error) {
+	var tags []string
+	err := d.db.QueryRow(`
+		UPDATE orders
+		SET tags = ARRAY(
+			SELECT DISTINCT tag FROM unnest(tags || $2::text[]) AS tag
+			WHERE tag <> ALL($3::text[])
+			ORDER BY tag COLLATE "C"
+		)
+		WHERE id = $1
+		RETURNING tags
+	`, orderID, pq.Array(add), pq.Array(remove)).Scan(pq.Array(&tags))
+	if err != nil {
+		return nil, fmt.Errorf("failed to update order tags: %w", err)
+	}
+	return tags, nil
+}
+
+// ListImageTags returns the tags of the order's images by AutoEnhance image ID
+func (d *DatabaseClient) ListImageTags(orderID uuid.UUID) (map[string][]string, error) {
+	rows, err := d.db.Query(`
+		SELECT image_id, tag
+		FROM image_tags
+		WHERE order_id = $1
+		ORDER BY image_id, tag COLLATE "C"
+	`, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list image tags: %w", err)
+	}
+	defer rows.Close()
+
+	tags := make(map[string][]string)
+	for rows.Next() {
+		var imageID, tag string
+		if err := rows.Scan(&imageID, &tag); err != nil {
+			return nil, fmt.Errorf("failed to scan image tag: %w", err)
+		}
+		tags[imageID] = append(tags[imageID], tag)
+	}
+
+	return tags, rows.Err()
+}
+
+// UpdateImageTags adds and removes the tags of one image and returns its tags afterwards
+func (d *DatabaseClient) UpdateImageTags(orderID uuid.UUID, imageID string, add, remove []string) ([]string, error) {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`
+		INSERT INTO image_tags (order_id, image_id, tag)
+		SELECT $1, $2, unnest($3::text[])
+		ON CONFLICT DO NOTHING
+	`, orderID, imageID, pq.Array(add)); err != nil {
+		return nil, fmt.Errorf("failed to add image tags: %w", err)
+	}
+	if _, err := tx.Exec(`
+		DELETE FROM image_tags
+		WHERE order_id = $1 AND image_id = $2 AND tag = ANY($3)
+	`, orderID, imageID, pq.Array(remove)); err != nil {
+		return nil, fmt.Errorf("failed to remove image tags: %w", err)
+	}
+
+	var tags []string
+	if err := tx.QueryRow(`
+		SELECT ARRAY(
+			SELECT tag FROM image_tags
+			WHERE order_id = $1 AND image_id = $2
+			ORDER BY tag COLLATE "C"
+		)
+	`, orderID, imageID).Scan(pq.Array(&tags)); err != nil {
+		return nil, fmt.Errorf("failed to get image tags: %w", err)
+	}
+
+	return tags, tx.Commit()
+}
+
+func (d *DatabaseClient) CreateSavedFilter(filter *models.SavedFilter) error {
+	err := d.db.QueryRow(`
+		INSERT INTO saved_filters (user_id, name, query)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_at, updated_at
+	`, filter.UserID, filter.Name, filter.Query).Scan(&filter.ID, &filter.CreatedAt, &filter.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create saved filter: %w", err)
+	}
+	return nil
+}
+
+func (d *DatabaseClient) GetSavedFilter(filterID, userID uuid.UUID) (*models.SavedFilter, error) {
+	var filter models.SavedFilter
+	err := d.db.QueryRow(`
+		SELECT id, user_id, name, query, created_at, updated_at
+		FROM saved_filters
+		WHERE id = $1 AND user_id = $2
+	`, filterID, userID).Scan(&filter.ID, &filter.UserID, &filter.Name, &filter.Query, &filter.CreatedAt, &filter.UpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get saved filter: %w", err)
+	}
+	return &filter, nil
+}
+
+// ListSavedFilters returns a user's saved filters by name
+func (d *DatabaseClient) ListSavedFilters(userID uuid.UUID) ([]models.SavedFilter, error) {
+	rows, err := d.db.Query(`
+		SELECT id, user_id, name, query, created_at, updated_at
+		FROM saved_filters
+		WHERE user_id = $1
+		ORDER BY name COLLATE "C"
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list saved filters: %w", err)
+	}
+	defer rows.Close()
+
+	var filters []models.SavedFilter
+	for rows.Next() {
+		var filter models.SavedFilter
+		if err := rows.Scan(&filter.ID, &filter.UserID, &filter.Name, &filter.Query, &filter.CreatedAt, &filter.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan saved filter: %w", err)
+		}
+		filters = append(filters, filter)
+	}
+
+	return filters, rows.Err()
+}
+
+func (d *DatabaseClient) DeleteSavedFilter(filterID, userID uuid.UUID) error {
+	_, err := d.db.Exec(`
+		DELETE FROM saved_filters
+		WHERE id = $1 AND user_id = $2
+	`, filterID, userID)
+	return err
+}
+
+const apiKeyColumns = `id, user_id, organization_id, name, prefix, key_hash, scopes, expires_at, admin_grant, last_used_at, revoked_at, created_at`
+
+func scanAPIKey(row interface{ Scan(...interface{}) error }, key *models.APIKey) error {
+	return row.Scan(&key.ID, &key.UserID, &key.OrganizationID, &key.Name, &key.Prefix, &key.KeyHash, pq.Array(&key.Scopes),
+		&key.ExpiresAt, &key.AdminGrant, &key.LastUsedAt, &key.RevokedAt, &key.CreatedAt)
+}
+
+func (d *DatabaseClient) CreateAPIKey(key *models.APIKey) error {
+	err := d.db.QueryRow(`
+		INSERT INTO api_keys (user_id, organization_id, name, prefix, key_hash, scopes, expires_at, admin_grant)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, created_at
+	`, key.UserID, key.OrganizationID, key.Name, key.Prefix, key.KeyHash, pq.Array(key.Scopes), key.ExpiresAt,
+		key.AdminGrant).Scan(&key.ID, &key.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create api key: %w", err)
+	}
+	return nil
+}
+
+func (d *DatabaseClient) GetAPIKey(keyID uuid.UUID) (*models.APIKey, error) {
+	var key models.APIKey
+	if err := scanAPIKey(d.db.QueryRow(`SELECT `+apiKeyColumns+` FROM api_keys WHERE id = $1`, keyID), &key); err != nil {
+		return nil, fmt.Errorf("failed to get api key: %w", err)
+	}
+	return &key, nil
+}
+
+func (d *DatabaseClient) GetAPIKeyByHash(keyHash string) (*models.APIKey, error) {
+	var key models.APIKey
+	if err := scanAPIKey(d.db.QueryRow(`SELECT `+apiKeyColumns+` FROM api_keys WHERE key_hash = $1`, keyHash), &key); err != nil {
+		return nil, fmt.Errorf("failed to get api key: %w", err)
+	}
+	return &key, nil
+}
+
+// ListAPIKeys returns the keys a user created, newest first
+func (d *DatabaseClient) ListAPIKeys(userID uuid.UUID) ([]models.APIKey, error) {
+	return d.listAPIKeys(`WHERE user_id = $1`, userID)
+}
+
+// ListOrganizationAPIKeys returns an organization's keys, newest first
+func (d *DatabaseClient) ListOrganizationAPIKeys(organizationID uuid.UUID) ([]models.APIKey, error) {
+	return d.listAPIKeys(`WHERE organization_id = $1`, organizationID)
+}
+
+func (d *DatabaseClient) listAPIKeys(where string, arg interface{}) ([]models.APIKey, error) {
+	rows, err := d.db.Query(`SELECT `+apiKeyColumns+` FROM api_keys `+where+` ORDER BY created_at DESC, id`, arg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list api keys: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []models.APIKey
+	for rows.Next() {
+		var key models.APIKey
+		if err := scanAPIKey(rows, &key); err != nil {
+			return nil, fmt.Errorf("failed to scan api key: %w", err)
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, rows.Err()
+}
+
+// RevokeAPIKey marks a key revoked. Revoking it again keeps the first revocation time.
+func (d *DatabaseClient) RevokeAPIKey(keyID uuid.UUID) error {
+	_, err := d.db.Exec(`
+		UPDATE api_keys SET revoked_at = COALESCE(revoked_at, NOW())
+		WHERE id = $1
+	`, keyID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke api key: %w", err)
+	}
+	return nil
+}
+
+func (d *DatabaseClient) TouchAPIKey(keyID uuid.UUID, usedAt time.Time) error {
+	_, err := d.db.Exec(`UPDATE api_keys SET last_used_at = $2 WHERE id = $1`, keyID, usedAt)
+	if err != nil {
+		return fmt.Errorf("failed to update api key: %w", err)
+	}
+	return nil
+}
+
+// CreateAuditEntry appends an entry to the audit log
+func (d *DatabaseClient) CreateAuditEntry(entry *models.AuditEntry) error {
+	details := "{}"
+	if len(entry.Details) > 0 {
+		details = string(entry.Details)
+	}
+	err := d.db.QueryRow(`
+		INSERT INTO audit_log (actor_id, api_key_id, action, order_id, image_id, user_id, request_id, ip, details)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6, NULLIF($7, ''), NULLIF($8, ''), $9)
+		RETURNING id, created_at
+	`, entry.ActorID, entry.APIKeyID, entry.Action, entry.OrderID, entry.ImageID, entry.UserID, entry.RequestID, entry.IP, details).Scan(&entry.ID, &entry.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create audit entry: %w", err)
+	}
+	return nil
+}
+
+// ListAuditEntries returns the matching audit log entries, newest first
+func (d *DatabaseClient) ListAuditEntries(opts models.AuditListOptions) ([]models.AuditEntry, error) {
+	var where []string
+	var args []interface{}
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if opts.ActorID.Valid {
+		where = append(where, "actor_id = "+arg(opts.ActorID.UUID))
+	}
+	if opts.OrderID.Valid {
+		where = append(where, "order_id = "+arg(opts.OrderID.UUID))
+	}
+	if opts.UserID.Valid {
+		where = append(where, "user_id = "+arg(opts.UserID.UUID))
+	}
+	if opts.Involving.Valid {
+		user := arg(opts.Involving.UUID)
+		where = append(where, "(actor_id = "+user+" OR user_id = "+user+")")
+	}
+	if opts.Since != nil {
+		where = append(where, "created_at >= "+arg(*opts.Since))
+	}
+	if opts.Until != nil {
+		where = append(where, "created_at < "+arg(*opts.Until))
+	}
+
+	query := `
+		SELECT id, actor_id, api_key_id, action, order_id, COALESCE(image_id, ''), user_id,
+			COALESCE(request_id, ''), COALESCE(ip, ''), details, created_at
+		FROM audit_log`
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY created_at DESC, id"
+	if opts.Limit > 0 {
+		query += " LIMIT " + arg(opts.Limit)
+	}
+
+	rows, err := d.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit entries: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []models.AuditEntry
+	for rows.Next() {
+		var entry models.AuditEntry
+		if err := rows.Scan(&entry.ID, &entry.ActorID, &entry.APIKeyID, &entry.Action, &entry.OrderID, &entry.ImageID, &entry.UserID,
+			&entry.RequestID, &entry.IP, &entry.Details, &entry.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan audit entry: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+// CreateOrderEvent appends an event to the order's outbox without changing the order
+func (d *DatabaseClient) CreateOrderEvent(event *models.OrderEvent) error {
+	return d.writeOrderEvent(event, `SELECT id, user_id FROM orders WHERE id = $1 FOR UPDATE`, event.OrderID)
+}
+
+// UpdateOrderStatusWithEvent is UpdateOrderStatus, appending event in the same transaction
+func (d *DatabaseClient) UpdateOrderStatusWithEvent(orderID uuid.UUID, status string, progress int, event *models.OrderEvent) error {
+	event.OrderID = orderID
+	return d.writeOrderEvent(event, `
+		UPDATE orders
+		SET status = $2, progress = $3
+		WHERE id = $1
+		RETURNING id, user_id
+	`, orderID, status, progress)
+}
+
+// UpdateOrderErrorWithEvent is UpdateOrderError, appending event in the same transaction
+func (d *DatabaseClient) UpdateOrderErrorWithEvent(orderID uuid.UUID, errorMsg string, event *models.OrderEvent) error {
+	event.OrderID = orderID
+	return d.writeOrderEvent(event, `
+		UPDATE orders
+		SET status = 'failed', error_message = $2
+		WHERE id = $1
+		RETURNING id, user_id
+	`, orderID, errorMsg)
+}
+
+// ResetOrderErrorWithEvent is ResetOrderError, appending event in the same transaction
+func (d *DatabaseClient) ResetOrderErrorWithEvent(orderID uuid.UUID, status string, progress int, event *models.OrderEvent) error {
+	event.OrderID = orderID
+	return d.writeOrderEvent(event, `
+		UPDATE orders
+		SET status = $2, progress = $3, error_message = NULL
+		WHERE id = $1
+		RETURNING id, user_id
+	`, orderID, status, progress)
+}
+
+// writeOrderEvent runs lock, which must lock the order's row and return its id and owner, and appends event in the same
+// transaction. Holding the row lock while reading the last seq keeps concurrent writers from taking the same number.
+func (d *DatabaseClient) writeOrderEvent(event *models.OrderEvent, lock string, args ...interface{}) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to create order event: %w", err)
+	}
+	defer tx.Rollback()
+
+	var orderID uuid.UUID
+	err = tx.QueryRow(lock, args...).Scan(&orderID, &event.UserID)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update order: %w", err)
+	}
+
+	payload := "{}"
+	if len(event.Payload) > 0 {
+		payload = string(event.Payload)
+	}
+	err = tx.QueryRow(`
+		INSERT INTO order_events (order_id, seq, event, payload)
+		SELECT $1, COALESCE(MAX(seq), 0) + 1, $2, $3
+		FROM order_events
+		WHERE order_id = $1
+		RETURNING id, seq, created_at, next_attempt_at
+	`, orderID, event.Event, payload).Scan(&event.ID, &event.Seq, &event.CreatedAt, &event.NextAttemptAt)
+	if err != nil {
+		return fmt.Errorf("failed to create order event: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// ListOrderEvents returns the order's events after afterSeq, by seq
+func (d *DatabaseClient) ListOrderEvents(orderID uuid.UUID, afterSeq int64, limit int) ([]models.OrderEvent, error) {
+	rows, err := d.db.Query(`
+		SELECT `+orderEventColumns+`
+		FROM order_events
+		WHERE order_id = $1 AND seq > $2
+		ORDER BY seq
+		LIMIT $3
+	`, orderID, afterSeq, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list order events: %w", err)
+	}
+	return scanOrderEvents(rows)
+}
+
+// GetOrderEvent returns one event, e.g. to load a payload too large for a notification
+func (d *DatabaseClient) GetOrderEvent(eventID uuid.UUID) (*models.OrderEvent, error) {
+	rows, err := d.db.Query(`SELECT `+orderEventColumns+` FROM order_events WHERE id = $1`, eventID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order event: %w", err)
+	}
+	events, err := scanOrderEvents(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(events) == 0 {
+		return nil, fmt.Errorf("failed to get order event: %w", sql.ErrNoRows)
+	}
+	return &events[0], nil
+}
+
+// ClaimOrderEvents claims due events with SKIP LOCKED, so replicas dispatching at the same time get different events
+func (d *DatabaseClient) ClaimOrderEvents(now time.Time, lease time.Duration, limit int) ([]models.OrderEvent, error) {
+	rows, err := d.db.Query(`
+		UPDATE order_events
+		SET attempts = attempts + 1, next_attempt_at = $2
+		WHERE id IN (
+			SELECT id FROM order_events
+			WHERE dispatched_at IS NULL AND next_attempt_at <= $1
+			ORDER BY created_at, seq
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+orderEventColumns, now.UTC(), now.Add(lease).UTC(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim order events: %w", err)
+	}
+	events, err := scanOrderEvents(rows)
+	if err != nil {
+		return nil, err
+	}
+	// RETURNING has no order
+	sort.Slice(events, func(i, j int) bool {
+		if !events[i].CreatedAt.Equal(events[j].CreatedAt) {
+			return events[i].CreatedAt.Before(events[j].CreatedAt)
+		}
+		return events[i].Seq < events[j].Seq
+	})
+	return events, nil
+}
+
+func (d *DatabaseClient) MarkOrderEventDelivered(eventID uuid.UUID) error {
+	if _, err := d.db.Exec(`UPDATE order_events SET delivered_at = NOW() WHERE id = $1`, eventID); err != nil {
+		return fmt.Errorf("failed to mark order event delivered: %w", err)
+	}
+	return nil
+}
+
+func (d *DatabaseClient) MarkOrderEventBroadcast(eventID uuid.UUID) error {
+	if _, err := d.db.Exec(`UPDATE order_events SET broadcast_at = NOW() WHERE id = $1`, eventID); err != nil {
+		return fmt.Errorf("failed to mark order event broadcast: %w", err)
+	}
+	return nil
+}
+
+func (d *DatabaseClient) MarkOrderEventDispatched(eventID uuid.UUID) error {
+	_, err := d.db.Exec(`
+		UPDATE order_events
+		SET dispatched_at = NOW(), next_attempt_at = NULL, last_error = NULL
+		WHERE id = $1
+	`, eventID)
+	if err != nil {
+		return fmt.Errorf("failed to mark order event dispatched: %w", err)
+	}
+	return nil
+}
+
+func (d *DatabaseClient) MarkOrderEventFailed(eventID uuid.UUID, errorMsg string, retryAt *time.Time) error {
+	_, err := d.db.Exec(`
+		UPDATE order_events
+		SET next_attempt_at = $2, last_error = $3
+		WHERE id = $1
+	`, eventID, retryAt, errorMsg)
+	if err != nil {
+		return fmt.Errorf("failed to mark order event failed: %w", err)
+	}
+	return nil
+}
+
+const orderEventColumns = `id, order_id, (SELECT user_id FROM orders WHERE orders.id = order_events.order_id),
+	seq, event, payload, created_at, delivered_at, broadcast_at, dispatched_at, attempts, next_attempt_at, last_error`
+
+func scanOrderEvents(rows *sql.Rows) ([]models.OrderEvent, error) {
+	defer rows.Close()
+
+	var events []models.OrderEvent
+	for rows.Next() {
+		var e models.OrderEvent
+		if err := rows.Scan(&e.ID, &e.OrderID, &e.UserID, &e.Seq, &e.Event, &e.Payload, &e.CreatedAt, &e.DeliveredAt,
+			&e.BroadcastAt, &e.DispatchedAt, &e.Attempts, &e.NextAttemptAt, &e.LastError); err != nil {
+			return nil, fmt.Errorf("failed to scan order event: %w", err)
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
+// IncrementRateLimit counts a request in a fixed window. The upsert is atomic, so replicas share one count;
+// a request for an older window than the stored one (clock skew between replicas) counts toward the newer one.
+func (d *DatabaseClient) IncrementRateLimit(key string, windowStart time.Time, window time.Duration) (int, error) {
+	var count int
+	err := d.db.QueryRow(`
+		INSERT INTO rate_limits (key, window_start, window_end, count)
+		VALUES ($1, $2, $3, 1)
+		ON CONFLICT (key) DO UPDATE SET
+			count = CASE WHEN rate_limits.window_start >= EXCLUDED.window_start THEN rate_limits.count + 1 ELSE 1 END,
+			window_start = GREATEST(rate_limits.window_start, EXCLUDED.window_start),
+			window_end = GREATEST(rate_limits.window_end, EXCLUDED.window_end)
+		RETURNING count
+	`, key, windowStart.UTC(), windowStart.Add(window).UTC()).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count request: %w", err)
+	}
+	return count, nil
+}
+
+func (d *DatabaseClient) PruneRateLimits(before time.Time) (int64, error) {
+	result, err := d.db.Exec(`DELETE FROM rate_limits WHERE window_end < $1`, before.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune rate limits: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// GetUserPlan returns the plan assigned to the user, or "" when none is assigned
+func (d *DatabaseClient) GetUserPlan(userID uuid.UUID) (string, error) {
+	var plan string
+	err := d.db.QueryRow(`
+		SELECT plan FROM user_plans WHERE user_id = $1
+	`, userID).Scan(&plan)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get user plan: %w", err)
+	}
+	return plan, nil
+}
+
+// GetUsage returns the user's active orders, stored bytes and bytes uploaded since periodStart
+func (d *DatabaseClient) GetUsage(userID uuid.UUID, periodStart time.Time) (*quota.Usage, error) {
+	var usage quota.Usage
+	err := d.db.QueryRow(`
+		SELECT
+			(SELECT COUNT(*) FROM orders WHERE user_id = $1 AND COALESCE(is_deleted, false) = false AND deleted_at IS NULL),
+			(SELECT COALESCE(SUM(bytes), 0) FROM usage_events WHERE user_id = $1 AND kind = $2 AND created_at >= $3),
+			(SELECT COALESCE(SUM(file_size), 0) FROM order_files WHERE user_id = $1)
+	`, userID, quota.UsageKindUpload, periodStart).Scan(&usage.ActiveOrders, &usage.UploadedBytesThisMonth, &usage.StoredBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get usage: %w", err)
+	}
+	return &usage, nil
+}
+
+func (d *DatabaseClient) CountBrackets(orderID uuid.UUID) (int, error) {
+	var count int
+	err := d.db.QueryRow(`
+		SELECT COUNT(*) FROM brackets WHERE order_id = $1
+	`, orderID).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count brackets: %w", err)
+	}
+	return count, nil
+}
+
+func (d *DatabaseClient) RecordUsageEvent(userID, orderID uuid.UUID, kind string, bytes int64) error {
+	_, err := d.db.Exec(`
+		INSERT INTO usage_events (user_id, order_id, kind, bytes)
+		VALUES ($1, $2, $3, $4)
+	`, userID, orderID, kind, bytes)
+	if err != nil {
+		return fmt.Errorf("failed to record usage: %w", err)
+	}
+	return nil
+}
+
+// GetCreditAccount returns the user's balance, budget and credits spent since periodStart (no credits without a row)
+func (d *DatabaseClient) GetCreditAccount(userID uuid.UUID, periodStart time.Time) (*credits.Account, error) {
+	var account credits.Account
+	var budget sql.NullInt64
+	err := d.db.QueryRow(`
+		SELECT
+			COALESCE((SELECT balance FROM user_credits WHERE user_id = $1), 0),
+			(SELECT monthly_budget FROM user_credits WHERE user_id = $1),
+			(SELECT COUNT(*) FROM credit_ledger WHERE user_id = $1 AND kind = $2 AND refunded_at IS NULL AND created_at >= $3)
+	`, userID, credits.KindSpend, periodStart).Scan(&account.Balance, &budget, &account.SpentThisMonth)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get credit account: %w", err)
+	}
+	if budget.Valid {
+		monthlyBudget := int(budget.Int64)
+		account.MonthlyBudget = &monthlyBudget
+	}
+	return &account, nil
+}
+
+// SpendCredit locks the payer's balance row, so their spends are checked one at a time. The unique index on spends
+// catches the same download paid by another user at the same time.
+func (d *DatabaseClient) SpendCredit(spend credits.Spend, periodStart time.Time, check func(credits.Account) error) (*models.CreditLedgerEntry, bool, error) {
+	if entry, err := d.getCreditSpend(spend); err != nil || entry != nil {
+		return entry, false, err
+	}
+
+	tx, err := d.db.Begin()
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`
+		INSERT INTO user_credits (user_id) VALUES ($1) ON CONFLICT (user_id) DO NOTHING
+	`, spend.UserID); err != nil {
+		return nil, false, fmt.Errorf("failed to create credit account: %w", err)
+	}
+	var account credits.Account
+	var budget sql.NullInt64
+	err = tx.QueryRow(`
+		SELECT balance, monthly_budget,
+			(SELECT COUNT(*) FROM credit_ledger WHERE user_id = $1 AND kind = $2 AND refunded_at IS NULL AND created_at >= $3)
+		FROM user_credits WHERE user_id = $1
+		FOR UPDATE
+	`, spend.UserID, credits.KindSpend, periodStart).Scan(&account.Balance, &budget, &account.SpentThisMonth)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get credit account: %w", err)
+	}
+	if budget.Valid {
+		monthlyBudget := int(budget.Int64)
+		account.MonthlyBudget = &monthlyBudget
+	}
+	if err := check(account); err != nil {
+		return nil, false, err
+	}
+
+	rows, err := tx.Query(`
+		INSERT INTO credit_ledger (user_id, kind, amount, order_id, image_id, quality, format)
+		VALUES ($1, $2, -1, $3, $4, $5, $6)
+		ON CONFLICT (order_id, image_id, quality, format) WHERE kind = 'spend' AND refunded_at IS NULL DO NOTHING
+		RETURNING `+creditLedgerColumns,
+		spend.UserID, credits.KindSpend, spend.OrderID, spend.ImageID, spend.Quality, spend.Format)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to record credit spend: %w", err)
+	}
+	entries, err := scanCreditLedger(rows)
+	if err != nil {
+		return nil, false, err
+	}
+	if len(entries) == 0 {
+		// Paid by a concurrent request
+		tx.Rollback()
+		entry, err := d.getCreditSpend(spend)
+		return entry, false, err
+	}
+
+	if _, err := tx.Exec(`
+		UPDATE user_credits SET balance = balance - 1, updated_at = NOW() WHERE user_id = $1
+	`, spend.UserID); err != nil {
+		return nil, false, fmt.Errorf("failed to update credit balance: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, false, fmt.Errorf("failed to commit credit spend: %w", err)
+	}
+	return &entries[0], true, nil
+}
+
+// getCreditSpend returns the unrefunded entry of spend, or nil
+func (d *DatabaseClient) getCreditSpend(spend credits.Spend) (*models.CreditLedgerEntry, error) {
+	rows, err := d.db.Query(`
+		SELECT `+creditLedgerColumns+`
+		FROM credit_ledger
+		WHERE kind = $1 AND refunded_at IS NULL AND order_id = $2 AND image_id = $3 AND quality = $4 AND format = $5
+	`, credits.KindSpend, spend.OrderID, spend.ImageID, spend.Quality, spend.Format)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get credit spend: %w", err)
+	}
+	entries, err := scanCreditLedger(rows)
+	if err != nil || len(entries) == 0 {
+		return nil, err
+	}
+	return &entries[0], nil
+}
+
+func (d *DatabaseClient) RefundCredit(entryID uuid.UUID) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var userID uuid.UUID
+	var amount int
+	err = tx.QueryRow(`
+		UPDATE credit_ledger SET refunded_at = NOW()
+		WHERE id = $1 AND kind = $2 AND refunded_at IS NULL
+		RETURNING user_id, amount
+	`, entryID, credits.KindSpend).Scan(&userID, &amount)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to refund credit: %w", err)
+	}
+	if _, err := tx.Exec(`
+		UPDATE user_credits SET balance = balance - $2, updated_at = NOW() WHERE user_id = $1
+	`, userID, amount); err != nil {
+		return fmt.Errorf("failed to update credit balance: %w", err)
+	}
+	return tx.Commit()
+}
+
+func (d *DatabaseClient) GrantCredits(userID uuid.UUID, amount int, reason string, actorID uuid.NullUUID) (*models.CreditLedgerEntry, error) {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`
+		INSERT INTO user_credits (user_id, balance) VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE SET balance = user_credits.balance + EXCLUDED.balance, updated_at = NOW()
+	`, userID, amount); err != nil {
+		return nil, fmt.Errorf("failed to update credit balance: %w", err)
+	}
+	rows, err := tx.Query(`
+		INSERT INTO credit_ledger (user_id, kind, amount, reason, actor_id)
+		VALUES ($1, $2, $3, NULLIF($4, ''), $5)
+		RETURNING `+creditLedgerColumns, userID, credits.KindGrant, amount, reason, actorID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record credit grant: %w", err)
+	}
+	entries, err := scanCreditLedger(rows)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit credit grant: %w", err)
+	}
+	return &entries[0], nil
+}
+
+func (d *DatabaseClient) SetCreditBudget(userID uuid.UUID, monthlyBudget *int) error {
+	_, err := d.db.Exec(`
+		INSERT INTO user_credits (user_id, monthly_budget) VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE SET monthly_budget = EXCLUDED.monthly_budget, updated_at = NOW()
+	`, userID, monthlyBudget)
+	if err != nil {
+		return fmt.Errorf("failed to set credit budget: %w", err)
+	}
+	return nil
+}
+
+func (d *DatabaseClient) ListCreditLedger(userID uuid.UUID, limit int) ([]models.CreditLedgerEntry, error) {
+	rows, err := d.db.Query(`
+		SELECT `+creditLedgerColumns+`
+		FROM credit_ledger
+		WHERE user_id = $1
+		ORDER BY created_at DESC, id
+		LIMIT $2
+	`, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list credit ledger: %w", err)
+	}
+	return scanCreditLedger(rows)
+}
+
+const creditLedgerColumns = `id, user_id, kind, amount, order_id, image_id, quality, format, reason, actor_id, refunded_at, created_at`
+
+func scanCreditLedger(rows *sql.Rows) ([]models.CreditLedgerEntry, error) {
+	defer rows.Close()
+
+	var entries []models.CreditLedgerEntry
+	for rows.Next() {
+		var e models.CreditLedgerEntry
+		if err := rows.Scan(&e.ID, &e.UserID, &e.Kind, &e.Amount, &e.OrderID, &e.ImageID, &e.Quality, &e.Format,
+			&e.Reason, &e.ActorID, &e.RefundedAt, &e.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan credit ledger entry: %w", err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
 func (d *DatabaseClient) Close() error {
 	return d.db.Close()
 }
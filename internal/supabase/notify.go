@@ -0,0 +1,162 @@
+package supabase
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"instant-hdr-backend/internal/events"
+	"instant-hdr-backend/internal/models"
+)
+
+const (
+	notifyChannel = "realtime_events"
+	// Postgres rejects NOTIFY payloads of 8000 bytes or more
+	maxNotifyPayload = 7900
+	// An idle listener pings at this interval so a dead connection is noticed and reconnected
+	listenerPingInterval = 90 * time.Second
+)
+
+// OrderEventLoader loads the outbox events whose payload was too large to send in a notification
+type OrderEventLoader interface {
+	GetOrderEvent(eventID uuid.UUID) (*models.OrderEvent, error)
+}
+
+// NotifyTransport carries events between instances with Postgres LISTEN/NOTIFY. Each instance listens on its own
+// connection, which reconnects by itself; events sent while it was down are lost, so the receiver is told and
+// subscribers resume from the outbox. The connection must be direct or through a session pooler (a transaction
+// pooler does not keep LISTEN).
+type NotifyTransport struct {
+	dsn    string
+	db     *sql.DB
+	loader OrderEventLoader
+}
+
+// notifyMessage is the NOTIFY payload. Data is left out when it is too large; receivers then load it by Source.
+type notifyMessage struct {
+	Channel string          `json:"c"`
+	Name    string          `json:"n"`
+	ID      uint64          `json:"i,omitempty"`
+	Source  string          `json:"s,omitempty"`
+	Data    json.RawMessage `json:"d,omitempty"`
+}
+
+func NewNotifyTransport(connectionString string, loader OrderEventLoader) (*NotifyTransport, error) {
+	db, err := sql.Open("postgres", connectionString)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to ping database: %w", err)
+	}
+	db.SetMaxOpenConns(4)
+
+	return NewNotifyTransportWithDB(db, connectionString, loader), nil
+}
+
+// NewNotifyTransportWithDB sends notifications through db instead of opening a pool; Start still listens on connectionString
+func NewNotifyTransportWithDB(db *sql.DB, connectionString string, loader OrderEventLoader) *NotifyTransport {
+	return &NotifyTransport{dsn: connectionString, db: db, loader: loader}
+}
+
+// Start listens for events and hands them to receiver until the process exits
+func (t *NotifyTransport) Start(receiver events.Receiver) error {
+	listener := pq.NewListener(t.dsn, 10*time.Second, time.Minute, func(event pq.ListenerEventType, err error) {
+		switch event {
+		case pq.ListenerEventDisconnected:
+			log.Printf("[Events] Lost the LISTEN connection, reconnecting: %v", err)
+		case pq.ListenerEventConnectionAttemptFailed:
+			log.Printf("[Events] Failed to reconnect the LISTEN connection: %v", err)
+		case pq.ListenerEventReconnected:
+			log.Println("[Events] Reconnected the LISTEN connection")
+		}
+	})
+	if err := listener.Listen(notifyChannel); err != nil {
+		listener.Close()
+		return fmt.Errorf("failed to listen on %s: %w", notifyChannel, err)
+	}
+
+	go func() {
+		ping := time.NewTicker(listenerPingInterval)
+		defer ping.Stop()
+		for range ping.C {
+			listener.Ping()
+		}
+	}()
+	go t.Deliver(listener.Notify, receiver)
+	return nil
+}
+
+// Deliver hands notifications to receiver until the channel is closed
+func (t *NotifyTransport) Deliver(notifications <-chan *pq.Notification, receiver events.Receiver) {
+	for n := range notifications {
+		// nil after a reconnect: notifications sent in between were missed
+		if n == nil {
+			receiver.Lost()
+			continue
+		}
+		if e, ok := t.decode(n.Extra); ok {
+			receiver.Receive(e)
+		}
+	}
+}
+
+// Send notifies every instance, this one included. An event with a Source is sent without its data when the data
+// is too large for a notification.
+func (t *NotifyTransport) Send(e events.Event) error {
+	message := notifyMessage{Channel: e.Channel, Name: e.Name, ID: e.ID, Data: e.Data}
+	if e.Source != uuid.Nil {
+		message.Source = e.Source.String()
+	}
+	payload, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s event: %w", e.Name, err)
+	}
+	if len(payload) > maxNotifyPayload {
+		if e.Source == uuid.Nil {
+			return fmt.Errorf("%s event is too large to send (%d bytes)", e.Name, len(payload))
+		}
+		message.Data = nil
+		if payload, err = json.Marshal(message); err != nil {
+			return fmt.Errorf("failed to marshal %s event: %w", e.Name, err)
+		}
+	}
+
+	if _, err := t.db.Exec(`SELECT pg_notify($1, $2)`, notifyChannel, string(payload)); err != nil {
+		return fmt.Errorf("failed to notify %s event: %w", e.Name, err)
+	}
+	return nil
+}
+
+// decode parses a notification, loading the data of an event sent without it. Failures are logged and the event skipped.
+func (t *NotifyTransport) decode(payload string) (events.Event, bool) {
+	var message notifyMessage
+	if err := json.Unmarshal([]byte(payload), &message); err != nil {
+		log.Printf("[Events] Skipping invalid notification: %v", err)
+		return events.Event{}, false
+	}
+	e := events.Event{ID: message.ID, Channel: message.Channel, Name: message.Name, Data: message.Data}
+	if message.Source != "" {
+		source, err := uuid.Parse(message.Source)
+		if err != nil {
+			log.Printf("[Events] Skipping %s event with invalid source %q", message.Name, message.Source)
+			return events.Event{}, false
+		}
+		e.Source = source
+	}
+
+	if len(e.Data) == 0 && e.Source != uuid.Nil {
+		orderEvent, err := t.loader.GetOrderEvent(e.Source)
+		if err != nil {
+			log.Printf("[Events] Skipping %s event: failed to load %s: %v", e.Name, e.Source, err)
+			return events.Event{}, false
+		}
+		e.Data = events.WithSeq(*orderEvent)
+	}
+	return e, true
+}
@@ -8,7 +8,6 @@ import (
 	"net/http"
 	"time"
 
-	"github.com/google/uuid"
 	"github.com/supabase-community/supabase-go"
 )
 
@@ -48,11 +47,13 @@ func (r *RealtimeClient) PublishEvent(channel string, event string, payload map[
 
 	log.Printf("[Realtime] Publishing event: channel=%s, event=%s", channel, event)
 
-	// Add timestamp to payload
+	// Add timestamp to payload (typed events already have one)
 	if payload == nil {
 		payload = make(map[string]interface{})
 	}
-	payload["timestamp"] = time.Now().Format(time.RFC3339)
+	if _, ok := payload["timestamp"]; !ok {
+		payload["timestamp"] = time.Now().Format(time.RFC3339)
+	}
 
 	// Prepare request body according to Supabase API format
 	// Format: { "messages": [{ "topic": "...", "event": "...", "payload": {...} }] }
@@ -121,85 +122,3 @@ func (r *RealtimeClient) PublishEvent(channel string, event string, payload map[
 		channel, event, resp.StatusCode)
 	return nil
 }
-
-func (r *RealtimeClient) PublishOrderEvent(orderID uuid.UUID, event string, payload map[string]interface{}) error {
-	channel := fmt.Sprintf("order:%s", orderID.String())
-	return r.PublishEvent(channel, event, payload)
-}
-
-// Deprecated: Use PublishOrderEvent instead
-func (r *RealtimeClient) PublishProjectEvent(projectID uuid.UUID, event string, payload map[string]interface{}) error {
-	return r.PublishOrderEvent(projectID, event, payload)
-}
-
-func (r *RealtimeClient) PublishUserEvent(userID uuid.UUID, event string, payload map[string]interface{}) error {
-	channel := fmt.Sprintf("user:%s", userID.String())
-	return r.PublishEvent(channel, event, payload)
-}
-
-// Event payloads
-func UploadStartedPayload(orderID uuid.UUID, fileCount int) map[string]interface{} {
-	return map[string]interface{}{
-		"order_id":   orderID.String(),
-		"status":     "uploading",
-		"file_count": fileCount,
-	}
-}
-
-func UploadCompletedPayload(orderID uuid.UUID, fileCount int) map[string]interface{} {
-	return map[string]interface{}{
-		"order_id":   orderID.String(),
-		"status":     "uploaded",
-		"file_count": fileCount,
-	}
-}
-
-func ProcessingStartedPayload(orderID uuid.UUID, editID string) map[string]interface{} {
-	return map[string]interface{}{
-		"order_id": orderID.String(),
-		"status":   "processing",
-	}
-}
-
-func ProcessingProgressPayload(orderID uuid.UUID, progress int) map[string]interface{} {
-	return map[string]interface{}{
-		"order_id": orderID.String(),
-		"status":   "processing",
-		"progress": progress,
-	}
-}
-
-func ProcessingCompletedPayload(orderID uuid.UUID, fileCount int) map[string]interface{} {
-	return map[string]interface{}{
-		"order_id":   orderID.String(),
-		"status":     "completed",
-		"progress":   100,
-		"file_count": fileCount,
-	}
-}
-
-func ProcessingFailedPayload(orderID uuid.UUID, errorMsg string) map[string]interface{} {
-	return map[string]interface{}{
-		"order_id": orderID.String(),
-		"status":   "failed",
-		"error":    errorMsg,
-	}
-}
-
-func DownloadReadyPayload(orderID uuid.UUID, storageURLs []string) map[string]interface{} {
-	return map[string]interface{}{
-		"order_id":     orderID.String(),
-		"status":       "previews_ready",
-		"storage_urls": storageURLs,
-	}
-}
-
-// WebhookEventPayload creates a payload from AutoEnhance webhook data
-func WebhookEventPayload(orderID, imageID string, error bool, orderIsProcessing bool) map[string]interface{} {
-	return map[string]interface{}{
-		"order_id":            orderID,
-		"image_id":            imageID,
-		"error":               error,
-		"order_is_processing": orderIsProcessing,
-	}
-}
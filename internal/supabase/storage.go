@@ -3,17 +3,27 @@ package supabase
 import (
 	"bytes"
 	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	storage "github.com/supabase-community/storage-go"
+	objectstorage "instant-hdr-backend/internal/storage"
 )
 
 type StorageClient struct {
-	client  *storage.Client
-	bucket  string
-	baseURL string
+	client     *storage.Client
+	bucket     string
+	baseURL    string
+	apiKey     string
+	httpClient *http.Client
 }
 
+// Ensure StorageClient satisfies the storage backend interface
+var _ objectstorage.Storage = (*StorageClient)(nil)
+
 func NewStorageClient(supabaseURL, serviceRoleKey, bucket string) (*StorageClient, error) {
 	// Ensure URL doesn't have trailing slash
 	baseURL := supabaseURL
@@ -27,6 +37,10 @@ func NewStorageClient(supabaseURL, serviceRoleKey, bucket string) (*StorageClien
 		client:  client,
 		bucket:  bucket,
 		baseURL: baseURL,
+		apiKey:  serviceRoleKey,
+		httpClient: &http.Client{
+			Timeout: 5 * time.Minute, // Downloads are streamed, allow large files
+		},
 	}, nil
 }
 
@@ -39,7 +53,7 @@ func (s *StorageClient) UploadFile(userID, orderID uuid.UUID, filename string, d
 // If userToken is provided, creates a new client with that token for RLS-protected uploads
 func (s *StorageClient) UploadFileWithToken(userID, orderID uuid.UUID, filename string, data []byte, userToken string) (string, string, error) {
 	// Create storage path: users/{user_id}/orders/{order_id}/{filename}
-	storagePath := fmt.Sprintf("users/%s/orders/%s/%s", userID.String(), orderID.String(), filename)
+	storagePath := objectstorage.OrderPath(userID, orderID, filename)
 
 	// Determine which client to use
 	clientToUse := s.client
@@ -49,7 +63,7 @@ func (s *StorageClient) UploadFileWithToken(userID, orderID uuid.UUID, filename
 	}
 
 	// Upload file
-	contentType := "image/jpeg"
+	contentType := objectstorage.ContentTypeForFilename(filename)
 	upsert := true
 	_, err := clientToUse.UploadFile(s.bucket, storagePath, bytes.NewReader(data), storage.FileOptions{
 		ContentType: &contentType,
@@ -71,30 +85,40 @@ func (s *StorageClient) GetPublicURL(storagePath string) string {
 		s.baseURL, s.bucket, storagePath)
 }
 
+// SignedURL creates a time-limited URL for an object in a private bucket
+func (s *StorageClient) SignedURL(storagePath string, expiresIn time.Duration) (string, error) {
+	resp, err := s.client.CreateSignedUrl(s.bucket, storagePath, int(expiresIn.Seconds()))
+	if err != nil {
+		return "", fmt.Errorf("failed to create signed url: %w", err)
+	}
+	return resp.SignedURL, nil
+}
+
 func (s *StorageClient) DeleteFile(storagePath string) error {
 	_, err := s.client.RemoveFile(s.bucket, []string{storagePath})
 	return err
 }
 
 func (s *StorageClient) DeleteOrderFiles(userID, orderID uuid.UUID) error {
-	prefix := fmt.Sprintf("users/%s/orders/%s/", userID.String(), orderID.String())
+	prefix := objectstorage.OrderPrefix(userID, orderID)
 
 	// List files with prefix
-	files, err := s.client.ListFiles(s.bucket, prefix, storage.FileSearchOptions{
-		Limit: 1000,
-	})
+	files, err := s.ListFiles(prefix)
 	if err != nil {
 		return fmt.Errorf("failed to list files: %w", err)
 	}
 
-	// Delete all files
-	if len(files) > 0 {
-		filePaths := make([]string, len(files))
-		for i, file := range files {
-			filePaths[i] = file.Name
+	// Delete all files in batches
+	for start := 0; start < len(files); start += listPageSize {
+		end := start + listPageSize
+		if end > len(files) {
+			end = len(files)
 		}
-		_, err = s.client.RemoveFile(s.bucket, filePaths)
-		if err != nil {
+		filePaths := make([]string, 0, end-start)
+		for _, file := range files[start:end] {
+			filePaths = append(filePaths, file.Path)
+		}
+		if _, err := s.client.RemoveFile(s.bucket, filePaths); err != nil {
 			return fmt.Errorf("failed to delete files: %w", err)
 		}
 	}
@@ -102,6 +126,121 @@ func (s *StorageClient) DeleteOrderFiles(userID, orderID uuid.UUID) error {
 	return nil
 }
 
+// listPageSize is the page size for listing and the batch size for bulk deletes
+const listPageSize = 1000
+
+// ListFiles lists objects under prefix, descending into sub-folders and following pagination.
+// Supabase returns names relative to the listed folder, so full paths are rebuilt here.
+func (s *StorageClient) ListFiles(prefix string) ([]objectstorage.Object, error) {
+	folder := strings.TrimSuffix(prefix, "/")
+	objects := make([]objectstorage.Object, 0)
+
+	for offset := 0; ; offset += listPageSize {
+		files, err := s.client.ListFiles(s.bucket, folder, storage.FileSearchOptions{
+			Limit:  listPageSize,
+			Offset: offset,
+			SortByOptions: storage.SortBy{
+				Column: "name",
+				Order:  "asc",
+			},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list files: %w", err)
+		}
+
+		for _, file := range files {
+			fullPath := file.Name
+			if folder != "" {
+				fullPath = folder + "/" + file.Name
+			}
+
+			// Folders have no id - recurse into them
+			if file.Id == "" {
+				nested, err := s.ListFiles(fullPath + "/")
+				if err != nil {
+					return nil, err
+				}
+				objects = append(objects, nested...)
+				continue
+			}
+
+			objects = append(objects, objectFromFileObject(fullPath, file))
+		}
+
+		if len(files) < listPageSize {
+			return objects, nil
+		}
+	}
+}
+
+// Download streams an object from Supabase Storage without buffering it in memory
+func (s *StorageClient) Download(storagePath string) (io.ReadCloser, *objectstorage.Object, error) {
+	return s.DownloadRange(storagePath, 0, -1)
+}
+
+// DownloadRange streams part of an object (length < 0 reads to the end).
+// The returned Object describes the whole object, not the range.
+func (s *StorageClient) DownloadRange(storagePath string, offset, length int64) (io.ReadCloser, *objectstorage.Object, error) {
+	req, err := s.objectRequest(http.MethodGet, storagePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	if rangeHeader := objectstorage.RangeHeader(offset, length); rangeHeader != "" {
+		req.Header.Set("Range", rangeHeader)
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to download file: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusPartialContent {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 2048))
+		resp.Body.Close()
+		return nil, nil, fmt.Errorf("failed to download file: status %d, body: %s", resp.StatusCode, string(body))
+	}
+
+	obj := objectstorage.ObjectFromResponse(storagePath, resp)
+	// The Range header was ignored and the whole object sent: cut the range out ourselves
+	if resp.StatusCode == http.StatusOK && (offset > 0 || length >= 0) {
+		body, err := objectstorage.SliceBody(resp.Body, offset, length)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to download file: %w", err)
+		}
+		return body, obj, nil
+	}
+	return resp.Body, obj, nil
+}
+
+// Stat returns object metadata without downloading the content
+func (s *StorageClient) Stat(storagePath string) (*objectstorage.Object, error) {
+	req, err := s.objectRequest(http.MethodHead, storagePath)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat file: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to stat file: status %d", resp.StatusCode)
+	}
+
+	return objectstorage.ObjectFromResponse(storagePath, resp), nil
+}
+
+func (s *StorageClient) objectRequest(method, storagePath string) (*http.Request, error) {
+	url := fmt.Sprintf("%s/storage/v1/object/%s/%s", s.baseURL, s.bucket, storagePath)
+	req, err := http.NewRequest(method, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+s.apiKey)
+	req.Header.Set("apikey", s.apiKey)
+	return req, nil
+}
+
 func (s *StorageClient) DownloadFile(storagePath string) ([]byte, error) {
 	data, err := s.client.DownloadFile(s.bucket, storagePath)
 	if err != nil {
@@ -110,3 +249,29 @@ func (s *StorageClient) DownloadFile(storagePath string) ([]byte, error) {
 
 	return data, nil
 }
+
+// objectFromFileObject converts a Supabase list entry (metadata holds size, mimetype, eTag)
+func objectFromFileObject(fullPath string, file storage.FileObject) objectstorage.Object {
+	obj := objectstorage.Object{
+		Path:        fullPath,
+		ContentType: objectstorage.ContentTypeForFilename(fullPath),
+	}
+	if metadata, ok := file.Metadata.(map[string]interface{}); ok {
+		if size, ok := metadata["size"].(float64); ok {
+			obj.Size = int64(size)
+		}
+		if mimeType, ok := metadata["mimetype"].(string); ok && mimeType != "" {
+			obj.ContentType = mimeType
+		}
+		if eTag, ok := metadata["eTag"].(string); ok {
+			obj.ETag = eTag
+		}
+	}
+	for _, ts := range []string{file.UpdatedAt, file.CreatedAt} {
+		if t, err := time.Parse(time.RFC3339, ts); err == nil {
+			obj.LastModified = t
+			break
+		}
+	}
+	return obj
+}
@@ -0,0 +1,102 @@
+package credits_test
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"instant-hdr-backend/internal/credits"
+	"instant-hdr-backend/internal/repository"
+)
+
+func TestSpend(t *testing.T) {
+	repo := repository.NewMemory()
+	service := credits.NewService(repo, true, 0)
+	userID, orderID := uuid.New(), uuid.New()
+	spend := credits.Spend{UserID: userID, OrderID: orderID, ImageID: "img-1", Quality: "high", Format: "jpeg"}
+
+	// No credits yet
+	_, _, err := service.Spend(spend)
+	var exceeded *credits.ExceededError
+	require.True(t, errors.As(err, &exceeded))
+	assert.Equal(t, credits.LimitBalance, exceeded.Limit)
+	assert.Equal(t, http.StatusPaymentRequired, exceeded.StatusCode())
+
+	_, err = service.Grant(userID, 2, "Welcome", uuid.NullUUID{})
+	require.NoError(t, err)
+	entry, charged, err := service.Spend(spend)
+	require.NoError(t, err)
+	assert.True(t, charged)
+	assert.Equal(t, -1, entry.Amount)
+
+	// The same image, quality and format is paid once, whoever downloads it
+	again, charged, err := service.Spend(credits.Spend{UserID: uuid.New(), OrderID: orderID, ImageID: "img-1", Quality: "high", Format: "jpeg"})
+	require.NoError(t, err)
+	assert.False(t, charged)
+	assert.Equal(t, entry.ID, again.ID)
+
+	account, err := service.Account(userID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, account.Balance)
+	assert.Equal(t, 1, account.SpentThisMonth)
+
+	// A refunded spend gives the credit back and can be paid again
+	require.NoError(t, service.Refund(entry.ID))
+	require.NoError(t, service.Refund(entry.ID))
+	account, err = service.Account(userID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, account.Balance)
+	assert.Equal(t, 0, account.SpentThisMonth)
+	_, charged, err = service.Spend(spend)
+	require.NoError(t, err)
+	assert.True(t, charged)
+
+	ledger, err := service.Ledger(userID, 10)
+	require.NoError(t, err)
+	require.Len(t, ledger, 3)
+	assert.Equal(t, credits.KindSpend, ledger[0].Kind)
+	assert.True(t, ledger[1].RefundedAt.Valid)
+	assert.Equal(t, credits.KindGrant, ledger[2].Kind)
+}
+
+func TestSpend_MonthlyBudget(t *testing.T) {
+	repo := repository.NewMemory()
+	service := credits.NewService(repo, true, 1)
+	userID := uuid.New()
+	_, err := service.Grant(userID, 10, "", uuid.NullUUID{})
+	require.NoError(t, err)
+
+	_, _, err = service.Spend(credits.Spend{UserID: userID, OrderID: uuid.New(), ImageID: "img-1", Quality: "high", Format: "jpeg"})
+	require.NoError(t, err)
+	_, _, err = service.Spend(credits.Spend{UserID: userID, OrderID: uuid.New(), ImageID: "img-2", Quality: "high", Format: "jpeg"})
+	var exceeded *credits.ExceededError
+	require.True(t, errors.As(err, &exceeded))
+	assert.Equal(t, credits.LimitMonthlyBudget, exceeded.Limit)
+	assert.Equal(t, 1, exceeded.Max)
+
+	// The user's own budget replaces the default; 0 means none
+	none := 0
+	require.NoError(t, service.SetBudget(userID, &none))
+	_, _, err = service.Spend(credits.Spend{UserID: userID, OrderID: uuid.New(), ImageID: "img-2", Quality: "high", Format: "jpeg"})
+	require.NoError(t, err)
+}
+
+func TestSpend_Disabled(t *testing.T) {
+	repo := repository.NewMemory()
+	service := credits.NewService(repo, false, 1)
+	userID := uuid.New()
+
+	// Recorded but not refused
+	for _, imageID := range []string{"img-1", "img-2"} {
+		_, charged, err := service.Spend(credits.Spend{UserID: userID, OrderID: uuid.New(), ImageID: imageID, Quality: "high", Format: "jpeg"})
+		require.NoError(t, err)
+		assert.True(t, charged)
+	}
+	account, err := service.Account(userID)
+	require.NoError(t, err)
+	assert.Equal(t, -2, account.Balance)
+	assert.Equal(t, 2, account.SpentThisMonth)
+}
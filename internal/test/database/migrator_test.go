@@ -0,0 +1,73 @@
+package database_test
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"instant-hdr-backend/internal/database"
+)
+
+func TestMigrations_Embedded(t *testing.T) {
+	migrations, err := database.Migrations()
+	require.NoError(t, err)
+	require.NotEmpty(t, migrations)
+
+	assert.Equal(t, "001_initial.sql", migrations[0].Name)
+	for i, migration := range migrations {
+		assert.False(t, strings.HasSuffix(migration.Name, ".down.sql"), migration.Name)
+		assert.Len(t, migration.Checksum, 64)
+		if i > 0 {
+			assert.Less(t, migrations[i-1].Name, migration.Name)
+		}
+	}
+
+	// The schema rewrite in 002 cannot be undone; later migrations can
+	byName := make(map[string]database.Migration)
+	for _, migration := range migrations {
+		byName[migration.Name] = migration
+	}
+	assert.False(t, byName["002_migrate_to_autoenhance_orders.sql"].Reversible())
+	assert.True(t, byName["008_add_quotas.sql"].Reversible())
+}
+
+func TestLoadMigrations(t *testing.T) {
+	fsys := fstest.MapFS{
+		"002_second.sql":      {Data: []byte("CREATE TABLE b ();")},
+		"001_first.sql":       {Data: []byte("CREATE TABLE a ();")},
+		"002_second.down.sql": {Data: []byte("DROP TABLE b;")},
+		"README.md":           {Data: []byte("not a migration")},
+	}
+
+	migrations, err := database.LoadMigrations(fsys)
+	require.NoError(t, err)
+	require.Len(t, migrations, 2)
+
+	assert.Equal(t, "001_first.sql", migrations[0].Name)
+	assert.Equal(t, "001", migrations[0].Version)
+	assert.False(t, migrations[0].Reversible())
+	assert.Equal(t, "DROP TABLE b;", migrations[1].Down)
+
+	// Checksums follow the up SQL only
+	again, err := database.LoadMigrations(fstest.MapFS{"002_second.sql": {Data: []byte("CREATE TABLE b ();")}})
+	require.NoError(t, err)
+	assert.Equal(t, migrations[1].Checksum, again[0].Checksum)
+	assert.NotEqual(t, migrations[0].Checksum, migrations[1].Checksum)
+}
+
+func TestLoadMigrations_Invalid(t *testing.T) {
+	tests := map[string]fstest.MapFS{
+		"down without up":   {"003_gone.down.sql": {Data: []byte("SELECT 1;")}},
+		"duplicate version": {"004_a.sql": {Data: []byte("SELECT 1;")}, "004_b.sql": {Data: []byte("SELECT 1;")}},
+		"missing prefix":    {"add_things.sql": {Data: []byte("SELECT 1;")}},
+	}
+
+	for name, fsys := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := database.LoadMigrations(fsys)
+			assert.Error(t, err)
+		})
+	}
+}
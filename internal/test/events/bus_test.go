@@ -0,0 +1,125 @@
+package events_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"instant-hdr-backend/internal/events"
+)
+
+func TestBus_FanOut(t *testing.T) {
+	bus := events.NewBus(10)
+	orderID := uuid.New()
+
+	sub, missed, complete := bus.Subscribe(events.OrderChannel(orderID), 0)
+	defer sub.Close()
+	assert.Empty(t, missed)
+	assert.True(t, complete)
+
+	require.NoError(t, bus.Publish(events.Event{Channel: events.OrderChannel(orderID), Name: "upload_started", Data: json.RawMessage(`{"file_count":3}`)}))
+	require.NoError(t, bus.Publish(events.Event{Channel: events.OrderChannel(uuid.New()), Name: "upload_started"})) // Another order
+
+	e := <-sub.Events()
+	assert.Equal(t, "upload_started", e.Name)
+	var payload map[string]interface{}
+	require.NoError(t, json.Unmarshal(e.Data, &payload))
+	assert.Equal(t, float64(3), payload["file_count"])
+	assert.Empty(t, sub.Events())
+
+	// Events with an ID are not numbered by the bus, and only reach subscribers
+	require.NoError(t, bus.Publish(events.Event{ID: 7, Channel: events.OrderChannel(orderID), Name: "download_ready"}))
+	assert.Equal(t, uint64(7), (<-sub.Events()).ID)
+	resumed, missed, _ := bus.Subscribe(events.OrderChannel(orderID), e.ID)
+	resumed.Close()
+	assert.Empty(t, missed)
+
+	var nilBus *events.Bus
+	assert.NoError(t, nilBus.Publish(events.Event{Channel: events.OrderChannel(orderID), Name: "upload_started"}))
+}
+
+func TestBus_Resume(t *testing.T) {
+	bus := events.NewBus(3)
+	orderID := uuid.New()
+	channel := events.OrderChannel(orderID)
+
+	var ids []uint64
+	for i := 0; i < 3; i++ {
+		sub, _, _ := bus.Subscribe(channel, 0)
+		bus.Publish(events.Event{Channel: channel, Name: "processing_started"})
+		ids = append(ids, (<-sub.Events()).ID)
+		sub.Close()
+	}
+	assert.Equal(t, ids[0]+1, ids[1])
+
+	sub, missed, complete := bus.Subscribe(channel, ids[0])
+	sub.Close()
+	assert.True(t, complete)
+	require.Len(t, missed, 2)
+	assert.Equal(t, ids[1], missed[0].ID)
+
+	// Only three events are kept: after the fourth, the first is gone
+	bus.Publish(events.Event{Channel: channel, Name: "download_ready"})
+	sub, missed, complete = bus.Subscribe(channel, ids[0]-1)
+	sub.Close()
+	assert.False(t, complete)
+	assert.Len(t, missed, 3)
+
+	// IDs from before a restart are older than anything the new bus issues
+	sub, _, complete = events.NewBus(3).Subscribe(channel, ids[2])
+	sub.Close()
+	assert.False(t, complete)
+}
+
+func TestBus_SlowSubscriber(t *testing.T) {
+	bus := events.NewBus(0)
+	orderID := uuid.New()
+	sub, _, _ := bus.Subscribe(events.OrderChannel(orderID), 0)
+
+	for i := 0; i < 100; i++ {
+		bus.Publish(events.Event{Channel: events.OrderChannel(orderID), Name: "webhook_image_processed"})
+	}
+
+	// Dropped instead of blocking the publisher; the buffered events are still readable
+	received := 0
+	for range sub.Events() {
+		received++
+	}
+	assert.Less(t, received, 100)
+	sub.Close()
+}
+
+func TestBus_Transport(t *testing.T) {
+	// Two instances linked by a transport
+	transport := events.NewMemoryTransport()
+	first, second := events.NewBus(10), events.NewBus(10)
+	require.NoError(t, first.Connect(transport))
+	require.NoError(t, second.Connect(transport))
+
+	userID := uuid.New()
+	channel := events.UserChannel(userID)
+	firstSub, _, _ := first.Subscribe(channel, 0)
+	secondSub, _, _ := second.Subscribe(channel, 0)
+	defer firstSub.Close()
+
+	// Published on one, received by the subscribers of both; each bus numbers the event for its own history
+	require.NoError(t, first.Publish(events.Event{Channel: channel, Name: "download_ready"}))
+	assert.Equal(t, "download_ready", (<-firstSub.Events()).Name)
+	e := <-secondSub.Events()
+	assert.Equal(t, "download_ready", e.Name)
+	resumed, missed, complete := second.Subscribe(channel, e.ID-1)
+	resumed.Close()
+	assert.True(t, complete)
+	assert.Len(t, missed, 1)
+
+	// After missing events, subscribers are closed and can't resume from the history
+	second.Lost()
+	_, ok := <-secondSub.Events()
+	assert.False(t, ok)
+	resumed, _, complete = second.Subscribe(channel, e.ID)
+	resumed.Close()
+	assert.False(t, complete)
+	secondSub.Close()
+}
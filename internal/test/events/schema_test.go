@@ -0,0 +1,61 @@
+package events_test
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"instant-hdr-backend/internal/events"
+)
+
+type unknownEvent struct {
+	events.Header
+}
+
+func (*unknownEvent) EventName() string { return "processing_progress" }
+func (*unknownEvent) Validate() error   { return nil }
+
+func TestEncode(t *testing.T) {
+	orderID := uuid.New()
+
+	event, err := events.Encode(orderID, "uploading", &events.UploadStarted{FileCount: 3})
+	require.NoError(t, err)
+	assert.Equal(t, events.EventUploadStarted, event.Event)
+	var payload map[string]interface{}
+	require.NoError(t, json.Unmarshal(event.Payload, &payload))
+	assert.Equal(t, float64(events.SchemaVersion), payload["version"])
+	assert.Equal(t, orderID.String(), payload["order_id"])
+	assert.Equal(t, "uploading", payload["status"])
+	assert.Equal(t, float64(3), payload["file_count"])
+	assert.NotEmpty(t, payload["timestamp"])
+	assert.NotContains(t, payload, "seq") // Numbered when stored
+
+	_, err = events.Encode(orderID, "uploading", &events.UploadStarted{})
+	assert.ErrorContains(t, err, "file_count")
+	_, err = events.Encode(uuid.Nil, "", &events.ProcessingStarted{})
+	assert.Error(t, err)
+	_, err = events.Encode(orderID, "", &unknownEvent{})
+	assert.ErrorContains(t, err, "unknown event")
+}
+
+// The schema the app teams use is generated: go generate ./internal/events
+func TestJSONSchema_UpToDate(t *testing.T) {
+	schema, err := events.JSONSchema()
+	require.NoError(t, err)
+	committed, err := os.ReadFile("../../../docs/events.schema.json")
+	require.NoError(t, err)
+	assert.Equal(t, string(committed), string(schema), "docs/events.schema.json is out of date")
+
+	var doc struct {
+		Defs map[string]struct {
+			Required []string `json:"required"`
+		} `json:"$defs"`
+	}
+	require.NoError(t, json.Unmarshal(schema, &doc))
+	assert.Len(t, doc.Defs, 7)
+	assert.Contains(t, doc.Defs[events.EventDownloadReady].Required, "storage_urls")
+	assert.NotContains(t, doc.Defs[events.EventDownloadReady].Required, "expires_at")
+}
@@ -0,0 +1,270 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"instant-hdr-backend/internal/autoenhance"
+	"instant-hdr-backend/internal/config"
+	"instant-hdr-backend/internal/credits"
+	"instant-hdr-backend/internal/events"
+	"instant-hdr-backend/internal/handlers"
+	"instant-hdr-backend/internal/middleware"
+	"instant-hdr-backend/internal/models"
+	"instant-hdr-backend/internal/quota"
+	"instant-hdr-backend/internal/repository"
+	"instant-hdr-backend/internal/services"
+)
+
+// adminAutoEnhance reports every order as processed, with one bracket left
+func adminAutoEnhance(t *testing.T) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := strings.TrimPrefix(r.URL.Path, "/v3/orders/")
+		if orderID, ok := strings.CutSuffix(path, "/brackets"); ok {
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"brackets": []map[string]interface{}{{"bracket_id": "b-1", "order_id": orderID, "name": "IMG_1.jpg", "is_uploaded": true}},
+			})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"order_id": path, "name": "Seaside Villa", "status": "processed", "is_processing": false, "total_images": 4,
+		})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// adminRouter wires auth, API keys and the admin routes the way main does. Tokens with app_metadata.role "support" are admins.
+func adminRouter(t *testing.T, repo repository.Repository) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	cfg := &config.Config{SupabaseJWTSecret: testJWTSecret, AdminRole: "support", AdminRoleKeyTTL: time.Hour}
+	ae := autoenhance.NewClient(adminAutoEnhance(t).URL, "test-key")
+	admin := handlers.NewAdminHandler(nil, ae, repo, nil, services.NewEventDispatcher(repo, events.NewBus(10), nil, time.Hour),
+		quota.NewService(repo, false, "free"), credits.NewService(repo, true, 0))
+	keys := handlers.NewAPIKeysHandler(repo, cfg)
+
+	router := gin.New()
+	api := router.Group("", middleware.AuthMiddleware(cfg, nil, middleware.NewAPIKeys(repo)))
+	api.POST("/api-keys", middleware.RejectAPIKeys(), keys.CreateAPIKey)
+	group := api.Group("/admin", middleware.AdminMiddleware(cfg))
+	group.GET("/orders", admin.SearchOrders)
+	group.GET("/orders/:order_id", admin.GetOrder)
+	group.POST("/orders/:order_id/resync", admin.ResyncOrder)
+	group.POST("/orders/:order_id/reset-error", admin.ResetOrderError)
+	group.GET("/users/:user_id/usage", admin.GetUserUsage)
+	group.GET("/users/:user_id/credits", admin.GetUserCredits)
+	group.POST("/users/:user_id/credits", admin.GrantCredits)
+	group.PUT("/users/:user_id/credits/budget", admin.SetCreditBudget)
+	group.GET("/audit-log", admin.ListAuditLog)
+	return router
+}
+
+func adminToken(t *testing.T, userID string) string {
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub":          userID,
+		"app_metadata": map[string]interface{}{"roles": []string{"billing", "support"}},
+	}).SignedString([]byte(testJWTSecret))
+	require.NoError(t, err)
+	return token
+}
+
+func TestAdmin_OrdersAcrossUsers(t *testing.T) {
+	repo := repository.NewMemory()
+	router := adminRouter(t, repo)
+	adminID, aliceID, bobID := uuid.NewString(), uuid.New(), uuid.New()
+	admin := adminToken(t, adminID)
+
+	stuck, err := repo.CreateOrder(uuid.New(), aliceID, nil)
+	require.NoError(t, err)
+	_, err = repo.CreateOrder(uuid.New(), bobID, nil)
+	require.NoError(t, err)
+	require.NoError(t, repo.UpdateOrderError(stuck.ID, "failed to upload to storage"))
+	require.NoError(t, repo.CreateBracket(&models.Bracket{OrderID: stuck.ID, BracketID: "b-1", Filename: "IMG_1.jpg", IsUploaded: true}))
+
+	// Users without the role don't get in, not even to their own orders
+	assert.Equal(t, http.StatusForbidden, call(router, http.MethodGet, "/admin/orders", userToken(t, aliceID.String()), "").Code)
+
+	w := call(router, http.MethodGet, "/admin/orders", admin, "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var list models.OrderListResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	assert.Len(t, list.Orders, 2)
+
+	w = call(router, http.MethodGet, "/admin/orders?status=failed&user_id="+aliceID.String(), admin, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	require.Len(t, list.Orders, 1)
+	assert.Equal(t, stuck.ID.String(), list.Orders[0].ID)
+
+	w = call(router, http.MethodGet, "/admin/orders/"+stuck.ID.String(), admin, "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var detail models.AdminOrderResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &detail))
+	assert.Equal(t, "failed to upload to storage", detail.ErrorMessage)
+	require.NotNil(t, detail.AutoEnhance)
+	assert.Equal(t, "processed", detail.AutoEnhance.Status)
+	assert.Len(t, detail.Brackets, 1)
+	assert.Len(t, detail.AutoEnhanceBrackets, 1)
+	assert.Equal(t, http.StatusNotFound, call(router, http.MethodGet, "/admin/orders/"+uuid.NewString(), admin, "").Code)
+
+	// Resync caches AutoEnhance's view; resetting the error goes back to where the order got to
+	w = call(router, http.MethodPost, "/admin/orders/"+stuck.ID.String()+"/resync", admin, "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var action models.AdminOrderActionResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &action))
+	assert.Equal(t, "Seaside Villa", action.Order.Name)
+	assert.Equal(t, "processed", action.Order.AutoEnhanceStatus)
+
+	assert.Equal(t, http.StatusBadRequest, call(router, http.MethodPost, "/admin/orders/"+stuck.ID.String()+"/reset-error", admin, `{"status":"failed"}`).Code)
+	w = call(router, http.MethodPost, "/admin/orders/"+stuck.ID.String()+"/reset-error", admin, "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var reset models.AdminOrderActionResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &reset))
+	assert.Equal(t, "uploaded", reset.Order.Status)
+	assert.Empty(t, reset.ErrorMessage)
+
+	// Clients watching the order are told
+	outbox, err := repo.ListOrderEvents(stuck.ID, 0, 10)
+	require.NoError(t, err)
+	require.NotEmpty(t, outbox)
+	last := outbox[len(outbox)-1]
+	assert.Equal(t, events.EventOrderReset, last.Event)
+	assert.Contains(t, string(last.Payload), `"status":"uploaded"`)
+
+	w = call(router, http.MethodGet, "/admin/users/"+aliceID.String()+"/usage", admin, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var usage models.UsageResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &usage))
+	assert.Equal(t, int64(1), usage.ActiveOrders.Used)
+
+	// Every admin call is in the audit log, attributed to the admin and the user it concerned
+	entries, err := repo.ListAuditEntries(models.AuditListOptions{OrderID: uuid.NullUUID{UUID: stuck.ID, Valid: true}})
+	require.NoError(t, err)
+	var actions []string
+	for _, entry := range entries {
+		actions = append(actions, entry.Action)
+		assert.Equal(t, adminID, entry.ActorID.UUID.String())
+		assert.Equal(t, aliceID, entry.UserID.UUID)
+	}
+	assert.Equal(t, []string{models.AuditAdminOrderResetError, models.AuditAdminOrderResync, models.AuditAdminOrderView}, actions)
+
+	w = call(router, http.MethodGet, "/admin/audit-log?user_id="+aliceID.String()+"&limit=2", admin, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var log models.AuditLogResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &log))
+	require.Len(t, log.Entries, 2)
+	assert.Equal(t, models.AuditAdminUsageView, log.Entries[0].Action)
+	assert.Contains(t, string(log.Entries[1].Details), `"previous_error":"failed to upload to storage"`)
+}
+
+func TestAdmin_APIKeys(t *testing.T) {
+	repo := repository.NewMemory()
+	router := adminRouter(t, repo)
+	adminID, userID := uuid.NewString(), uuid.NewString()
+
+	// Only admins create admin keys, and only personal ones
+	body := `{"name":"Support tooling","scopes":["admin"]}`
+	assert.Equal(t, http.StatusForbidden, call(router, http.MethodPost, "/api-keys", userToken(t, userID), body).Code)
+	org := models.Organization{Name: "Support", CreatedBy: uuid.MustParse(adminID)}
+	require.NoError(t, repo.CreateOrganization(&org))
+	orgBody := `{"name":"x","scopes":["admin"],"organization_id":"` + org.ID.String() + `"}`
+	assert.Equal(t, http.StatusBadRequest, call(router, http.MethodPost, "/api-keys", adminToken(t, adminID), orgBody).Code)
+
+	// The admin only qualifies by role, which the key can't check again: it expires within the hour, however long was asked for
+	longBody := `{"name":"Support tooling","scopes":["admin"],"expires_at":"` + time.Now().AddDate(1, 0, 0).Format(time.RFC3339) + `"}`
+	adminKey := createKey(t, router, adminToken(t, adminID), longBody)
+	assert.Equal(t, models.AdminGrantRole, adminKey.AdminGrant)
+	require.NotNil(t, adminKey.ExpiresAt)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), *adminKey.ExpiresAt, time.Minute)
+	w := call(router, http.MethodGet, "/admin/orders", adminKey.Key, "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	entries, err := repo.ListAuditEntries(models.AuditListOptions{ActorID: uuid.NullUUID{UUID: uuid.MustParse(adminID), Valid: true}})
+	require.NoError(t, err)
+	require.Len(t, entries, 2) // Creating the key is audited too
+	assert.Equal(t, models.AuditAdminOrdersSearch, entries[0].Action)
+	assert.Equal(t, adminKey.ID, entries[0].APIKeyID.UUID.String())
+
+	// An admin's other keys are not admin keys
+	readKey := createKey(t, router, adminToken(t, adminID), `{"name":"Reports","scopes":["orders:read"]}`)
+	assert.Equal(t, http.StatusForbidden, call(router, http.MethodGet, "/admin/orders", readKey.Key, "").Code)
+}
+
+func TestAdmin_Credits(t *testing.T) {
+	repo := repository.NewMemory()
+	router := adminRouter(t, repo)
+	adminID, userID := uuid.NewString(), uuid.NewString()
+	admin := adminToken(t, adminID)
+	path := "/admin/users/" + userID + "/credits"
+
+	assert.Equal(t, http.StatusForbidden, call(router, http.MethodPost, path, userToken(t, userID), `{"amount":100}`).Code)
+	assert.Equal(t, http.StatusBadRequest, call(router, http.MethodPost, path, admin, `{"amount":0}`).Code)
+
+	w := call(router, http.MethodPost, path, admin, `{"amount":25,"reason":"Monthly top-up"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var response models.CreditsResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, 25, response.Balance)
+	require.Len(t, response.Ledger, 1)
+	assert.Equal(t, "Monthly top-up", response.Ledger[0].Reason)
+	assert.Equal(t, adminID, response.Ledger[0].ActorID)
+
+	assert.Equal(t, http.StatusBadRequest, call(router, http.MethodPut, path+"/budget", admin, `{"monthly_budget":-1}`).Code)
+	w = call(router, http.MethodPut, path+"/budget", admin, `{"monthly_budget":10}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, int64(10), response.MonthlyBudget.Limit)
+	assert.True(t, response.Enforced)
+
+	w = call(router, http.MethodGet, path+"?limit=1", admin, "")
+	require.Equal(t, http.StatusOK, w.Code)
+
+	entries, err := repo.ListAuditEntries(models.AuditListOptions{UserID: uuid.NullUUID{UUID: uuid.MustParse(userID), Valid: true}})
+	require.NoError(t, err)
+	var actions []string
+	for _, entry := range entries {
+		actions = append(actions, entry.Action)
+	}
+	assert.Equal(t, []string{models.AuditAdminCreditsView, models.AuditAdminCreditsBudget, models.AuditAdminCreditsGrant}, actions)
+}
+
+func TestAdmin_APIKeyCreatorNoLongerAdmin(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	repo := repository.NewMemory()
+	adminID := uuid.NewString()
+
+	cfg := &config.Config{SupabaseJWTSecret: testJWTSecret, AdminUserIDs: []string{adminID}, AdminRole: "support", AdminRoleKeyTTL: time.Hour}
+	keys := handlers.NewAPIKeysHandler(repo, cfg)
+	router := gin.New()
+	api := router.Group("", middleware.AuthMiddleware(cfg, nil, middleware.NewAPIKeys(repo)))
+	api.POST("/api-keys", middleware.RejectAPIKeys(), keys.CreateAPIKey)
+	api.GET("/admin/ping", middleware.AdminMiddleware(cfg), func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	// A listed admin's key doesn't expire, and stops working when they are no longer listed, even with ADMIN_ROLE set
+	adminKey := createKey(t, router, userToken(t, adminID), `{"name":"Support tooling","scopes":["admin"]}`)
+	assert.Equal(t, models.AdminGrantListed, adminKey.AdminGrant)
+	assert.Nil(t, adminKey.ExpiresAt)
+	assert.Equal(t, http.StatusNoContent, call(router, http.MethodGet, "/admin/ping", adminKey.Key, "").Code)
+
+	// A role-based admin's key stops working once ADMIN_ROLE is unset
+	roleKey := createKey(t, router, adminToken(t, uuid.NewString()), `{"name":"Support tooling","scopes":["admin"]}`)
+	assert.Equal(t, models.AdminGrantRole, roleKey.AdminGrant)
+	assert.Equal(t, http.StatusNoContent, call(router, http.MethodGet, "/admin/ping", roleKey.Key, "").Code)
+
+	cfg.AdminUserIDs = nil
+	assert.Equal(t, http.StatusForbidden, call(router, http.MethodGet, "/admin/ping", adminKey.Key, "").Code)
+	assert.Equal(t, http.StatusNoContent, call(router, http.MethodGet, "/admin/ping", roleKey.Key, "").Code)
+
+	cfg.AdminRole = ""
+	assert.Equal(t, http.StatusForbidden, call(router, http.MethodGet, "/admin/ping", roleKey.Key, "").Code)
+}
@@ -0,0 +1,193 @@
+package handlers_test
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"instant-hdr-backend/internal/autoenhance"
+	"instant-hdr-backend/internal/config"
+	"instant-hdr-backend/internal/handlers"
+	"instant-hdr-backend/internal/middleware"
+	"instant-hdr-backend/internal/models"
+	"instant-hdr-backend/internal/repository"
+	"instant-hdr-backend/internal/services"
+	"instant-hdr-backend/internal/storage"
+)
+
+const testJWTSecret = "api-key-test-secret"
+
+// apiKeysRouter wires the real auth middleware and scopes the way main does
+func apiKeysRouter(t *testing.T, repo repository.Repository) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	localStorage, err := storage.NewLocalStorage(t.TempDir(), "http://localhost/files")
+	require.NoError(t, err)
+	ae := autoenhance.NewClient(fakeAutoEnhance(t).URL, "test-key")
+	orders := handlers.NewOrdersHandler(ae, repo, localStorage, nil, services.NewOrderPurger(ae, repo, localStorage))
+	cfg := &config.Config{SupabaseJWTSecret: testJWTSecret}
+	keys := handlers.NewAPIKeysHandler(repo, cfg)
+
+	apiKeys := middleware.NewAPIKeys(repo)
+	router := gin.New()
+	api := router.Group("", middleware.AuthMiddleware(cfg, nil, apiKeys))
+	account := api.Group("", middleware.RejectAPIKeys())
+	api.POST("/orders", apiKeys.RequireScope(models.ScopeOrdersWrite), orders.CreateOrder)
+	api.GET("/orders", apiKeys.RequireScope(models.ScopeOrdersRead), orders.ListOrders)
+	api.GET("/orders/:order_id", apiKeys.RequireScope(models.ScopeOrdersRead), orders.GetOrder)
+	account.POST("/api-keys", keys.CreateAPIKey)
+	account.GET("/api-keys", keys.ListAPIKeys)
+	account.DELETE("/api-keys/:key_id", keys.RevokeAPIKey)
+	return router
+}
+
+func userToken(t *testing.T, userID string) string {
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": userID}).SignedString([]byte(testJWTSecret))
+	require.NoError(t, err)
+	return token
+}
+
+func call(router *gin.Engine, method, path, bearer, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+bearer)
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func createKey(t *testing.T, router *gin.Engine, token, body string) models.APIKeyResponse {
+	w := call(router, http.MethodPost, "/api-keys", token, body)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var key models.APIKeyResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &key))
+	return key
+}
+
+func TestAPIKeys_ScopesAndRevocation(t *testing.T) {
+	repo := repository.NewMemory()
+	router := apiKeysRouter(t, repo)
+	userID := uuid.NewString()
+	user := userToken(t, userID)
+
+	key := createKey(t, router, user, `{"name":"CRM sync","scopes":["orders:read","orders:read"]}`)
+	assert.True(t, strings.HasPrefix(key.Key, middleware.APIKeyPrefix))
+	assert.True(t, strings.HasPrefix(key.Key, key.Prefix))
+	assert.Equal(t, []string{models.ScopeOrdersRead}, key.Scopes)
+
+	for _, body := range []string{
+		`{"name":"x","scopes":[]}`,
+		`{"name":"x","scopes":["orders:admin"]}`,
+		`{"name":" ","scopes":["orders:read"]}`,
+		`{"name":"x","scopes":["orders:read"],"expires_at":"2000-01-01T00:00:00Z"}`,
+	} {
+		assert.Equal(t, http.StatusBadRequest, call(router, http.MethodPost, "/api-keys", user, body).Code, body)
+	}
+
+	// The key acts as its creator, within its scopes
+	w := call(router, http.MethodPost, "/orders", user, `{"name":"Kitchen"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	var order models.OrderResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &order))
+	assert.Equal(t, http.StatusOK, call(router, http.MethodGet, "/orders/"+order.ID, key.Key, "").Code)
+	w = call(router, http.MethodPost, "/orders", key.Key, `{"name":"Garden"}`)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "orders:write")
+
+	// Keys can't manage keys, and the listing never shows the key itself
+	assert.Equal(t, http.StatusForbidden, call(router, http.MethodGet, "/api-keys", key.Key, "").Code)
+	w = call(router, http.MethodGet, "/api-keys", user, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), key.Key)
+	var list models.APIKeyListResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	require.Len(t, list.Keys, 1)
+	assert.NotNil(t, list.Keys[0].LastUsedAt)
+
+	// Only the creator can revoke a personal key; revoked keys stop working
+	assert.Equal(t, http.StatusNotFound, call(router, http.MethodDelete, "/api-keys/"+key.ID, userToken(t, uuid.NewString()), "").Code)
+	w = call(router, http.MethodDelete, "/api-keys/"+key.ID, user, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "revoked_at")
+	assert.Equal(t, http.StatusUnauthorized, call(router, http.MethodGet, "/orders", key.Key, "").Code)
+	assert.Equal(t, http.StatusUnauthorized, call(router, http.MethodGet, "/orders", key.Key+"x", "").Code)
+
+	// Expired keys are rejected
+	rawKey, prefix, hash, err := middleware.GenerateAPIKey()
+	require.NoError(t, err)
+	require.NoError(t, repo.CreateAPIKey(&models.APIKey{
+		UserID:    uuid.MustParse(userID),
+		Name:      "Old export",
+		Prefix:    prefix,
+		KeyHash:   hash,
+		Scopes:    []string{models.ScopeOrdersRead},
+		ExpiresAt: sql.NullTime{Time: time.Now().Add(-time.Minute), Valid: true},
+	}))
+	w = call(router, http.MethodGet, "/orders", rawKey, "")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), "expired")
+}
+
+func TestAPIKeys_OrganizationKeys(t *testing.T) {
+	repo := repository.NewMemory()
+	router := apiKeysRouter(t, repo)
+	ownerID, adminID, editorID := uuid.NewString(), uuid.NewString(), uuid.NewString()
+	owner, admin, editor := userToken(t, ownerID), userToken(t, adminID), userToken(t, editorID)
+
+	org := models.Organization{Name: "Sunset Media", CreatedBy: uuid.MustParse(ownerID)}
+	require.NoError(t, repo.CreateOrganization(&org))
+	for userID, role := range map[string]string{adminID: models.RoleAdmin, editorID: models.RoleEditor} {
+		require.NoError(t, repo.SetOrganizationMember(&models.OrganizationMember{OrganizationID: org.ID, UserID: uuid.MustParse(userID), Role: role}))
+	}
+	body := `{"name":"Scheduler","scopes":["orders:read","orders:write"],"organization_id":"` + org.ID.String() + `"}`
+
+	// Only admins create organization keys
+	assert.Equal(t, http.StatusForbidden, call(router, http.MethodPost, "/api-keys", editor, body).Code)
+	key := createKey(t, router, admin, body)
+	assert.Equal(t, org.ID.String(), key.OrganizationID)
+
+	// Orders created with the key belong to the organization; the creator's personal orders are out of reach
+	w := call(router, http.MethodPost, "/orders", key.Key, `{"name":"Shared"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var shared models.OrderResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &shared))
+	assert.Equal(t, org.ID.String(), shared.OrganizationID)
+
+	w = call(router, http.MethodPost, "/orders", admin, `{"name":"Personal"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	var personal models.OrderResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &personal))
+	assert.Equal(t, http.StatusNotFound, call(router, http.MethodGet, "/orders/"+personal.ID, key.Key, "").Code)
+	assert.Equal(t, http.StatusOK, call(router, http.MethodGet, "/orders/"+shared.ID, key.Key, "").Code)
+	assert.Equal(t, http.StatusForbidden, call(router, http.MethodGet, "/orders?organization_id="+uuid.NewString(), key.Key, "").Code)
+
+	w = call(router, http.MethodGet, "/orders", key.Key, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var list models.OrderListResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	require.Len(t, list.Orders, 1)
+	assert.Equal(t, shared.ID, list.Orders[0].ID)
+
+	// Admins see and revoke the organization's keys; others don't see them
+	assert.Equal(t, http.StatusNotFound, call(router, http.MethodGet, "/api-keys?organization_id="+uuid.NewString(), owner, "").Code)
+	w = call(router, http.MethodGet, "/api-keys?organization_id="+org.ID.String(), owner, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), key.ID)
+	assert.Equal(t, http.StatusNotFound, call(router, http.MethodDelete, "/api-keys/"+key.ID, editor, "").Code)
+
+	// The key stops working when its creator leaves the organization
+	require.NoError(t, repo.RemoveOrganizationMember(org.ID, uuid.MustParse(adminID)))
+	assert.Equal(t, http.StatusUnauthorized, call(router, http.MethodGet, "/orders", key.Key, "").Code)
+	assert.Equal(t, http.StatusOK, call(router, http.MethodDelete, "/api-keys/"+key.ID, owner, "").Code)
+}
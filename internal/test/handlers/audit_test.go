@@ -0,0 +1,93 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"instant-hdr-backend/internal/autoenhance"
+	"instant-hdr-backend/internal/config"
+	"instant-hdr-backend/internal/handlers"
+	"instant-hdr-backend/internal/middleware"
+	"instant-hdr-backend/internal/models"
+	"instant-hdr-backend/internal/repository"
+)
+
+// auditRouter wires request IDs, auth, a mutating route and the audit log routes the way main does
+func auditRouter(repo repository.Repository) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	cfg := &config.Config{SupabaseJWTSecret: testJWTSecret}
+	tags := handlers.NewTagsHandler(autoenhance.NewClient("http://autoenhance.invalid", "test-key"), repo)
+	audit := handlers.NewAuditHandler(repo)
+
+	router := gin.New()
+	router.Use(middleware.RequestID())
+	api := router.Group("", middleware.AuthMiddleware(cfg, nil, middleware.NewAPIKeys(repo)))
+	api.POST("/orders/:order_id/tags", tags.AddOrderTags)
+	api.GET("/orders/:order_id/audit-log", audit.ListOrderAuditLog)
+	api.GET("/me/audit-log", audit.ListUserAuditLog)
+	return router
+}
+
+func auditLog(t *testing.T, router *gin.Engine, path, token string) models.AuditLogResponse {
+	w := call(router, http.MethodGet, path, token, "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var log models.AuditLogResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &log))
+	return log
+}
+
+func TestAudit_UserActions(t *testing.T) {
+	repo := repository.NewMemory()
+	router := auditRouter(repo)
+	aliceID, bobID := uuid.New(), uuid.New()
+	alice, bob := userToken(t, aliceID.String()), userToken(t, bobID.String())
+
+	order, err := repo.CreateOrder(uuid.New(), aliceID, nil)
+	require.NoError(t, err)
+
+	w := call(router, http.MethodPost, "/orders/"+order.ID.String()+"/tags", alice, `{"tags":["Beach"]}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	requestID := w.Header().Get(middleware.RequestIDHeader)
+	require.NotEmpty(t, requestID)
+
+	// Mutations are recorded with who, where from and which request
+	log := auditLog(t, router, "/orders/"+order.ID.String()+"/audit-log", alice)
+	require.Len(t, log.Entries, 1)
+	entry := log.Entries[0]
+	assert.Equal(t, models.AuditOrderTagsUpdate, entry.Action)
+	assert.Equal(t, aliceID.String(), entry.ActorID)
+	assert.Equal(t, aliceID.String(), entry.UserID)
+	assert.Equal(t, requestID, entry.RequestID)
+	assert.NotEmpty(t, entry.IP)
+	assert.JSONEq(t, `{"add":["beach"],"remove":null}`, string(entry.Details))
+
+	// System actions on the order concern its owner too
+	system := models.AuditEntry{
+		Action:  models.AuditOrderProcessingComplete,
+		OrderID: uuid.NullUUID{UUID: order.ID, Valid: true},
+		UserID:  uuid.NullUUID{UUID: aliceID, Valid: true},
+	}
+	require.NoError(t, repo.CreateAuditEntry(&system))
+
+	log = auditLog(t, router, "/me/audit-log", alice)
+	require.Len(t, log.Entries, 2)
+	assert.Equal(t, models.AuditOrderProcessingComplete, log.Entries[0].Action)
+	assert.Empty(t, log.Entries[0].ActorID)
+	assert.Empty(t, auditLog(t, router, "/me/audit-log", bob).Entries)
+
+	// Time filters and limits
+	future := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	assert.Empty(t, auditLog(t, router, "/me/audit-log?since="+future, alice).Entries)
+	assert.Len(t, auditLog(t, router, "/me/audit-log?until="+future+"&limit=1", alice).Entries, 1)
+	assert.Equal(t, http.StatusBadRequest, call(router, http.MethodGet, "/me/audit-log?limit=0", alice, "").Code)
+
+	// Other users don't see the order's log
+	assert.Equal(t, http.StatusNotFound, call(router, http.MethodGet, "/orders/"+order.ID.String()+"/audit-log", bob, "").Code)
+}
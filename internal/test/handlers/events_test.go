@@ -0,0 +1,141 @@
+package handlers_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"instant-hdr-backend/internal/config"
+	"instant-hdr-backend/internal/events"
+	"instant-hdr-backend/internal/handlers"
+	"instant-hdr-backend/internal/middleware"
+	"instant-hdr-backend/internal/models"
+	"instant-hdr-backend/internal/repository"
+	"instant-hdr-backend/internal/services"
+)
+
+// eventsRouter serves the event routes behind AuthMiddleware, as main does
+func eventsRouter(t *testing.T, repo repository.Repository, bus *events.Bus) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	cfg := &config.Config{SupabaseJWTSecret: testJWTSecret}
+	h := handlers.NewEventsHandler(repo, bus, 20*time.Millisecond)
+
+	router := gin.New()
+	api := router.Group("", middleware.AuthMiddleware(cfg, nil, middleware.NewAPIKeys(repo)))
+	api.GET("/orders/:order_id/events", h.StreamOrderEvents)
+	api.GET("/me/events", h.StreamUserEvents)
+	return router
+}
+
+// eventsServer serves eventsRouter over HTTP, so streams can be read while they are open
+func eventsServer(t *testing.T, repo repository.Repository, bus *events.Bus) *httptest.Server {
+	server := httptest.NewServer(eventsRouter(t, repo, bus))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// openStream connects and returns a reader over the stream's lines; the stream is closed with the test
+func openStream(t *testing.T, url, token, lastEventID string) (*http.Response, *bufio.Scanner) {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "text/event-stream")
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp, bufio.NewScanner(resp.Body)
+}
+
+// nextEvent reads lines up to the next event and returns its id, name and data, skipping comments
+func nextEvent(t *testing.T, lines *bufio.Scanner) (id, name, data string) {
+	for lines.Scan() {
+		line := lines.Text()
+		switch {
+		case line == "" && name != "":
+			return id, name, data
+		case strings.HasPrefix(line, "id: "):
+			id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "event: "):
+			name = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+	t.Fatal("stream ended before the next event")
+	return
+}
+
+func TestEvents_OrderStream(t *testing.T) {
+	repo := repository.NewMemory()
+	bus := events.NewBus(100)
+	dispatcher := services.NewEventDispatcher(repo, bus, nil, time.Hour)
+	server := eventsServer(t, repo, bus)
+	aliceID, bobID := uuid.New(), uuid.New()
+	alice := userToken(t, aliceID.String())
+
+	order, err := repo.CreateOrder(uuid.New(), aliceID, nil)
+	require.NoError(t, err)
+	url := server.URL + "/orders/" + order.ID.String() + "/events"
+
+	resp, err := http.Get(url)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	resp, _ = openStream(t, url, userToken(t, bobID.String()), "")
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	resp, lines := openStream(t, url, alice, "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	// Heartbeats keep the connection open until something happens
+	require.Eventually(t, func() bool {
+		return lines.Scan() && lines.Text() == ": heartbeat"
+	}, time.Second, time.Millisecond)
+
+	// Events are stored with the status change and reach the stream when dispatched, numbered per order
+	require.NoError(t, dispatcher.PublishStatus(order.ID, "uploading", 0, &events.UploadStarted{FileCount: 2}))
+	require.NoError(t, dispatcher.PublishStatus(order.ID, "uploaded", 0, &events.UploadCompleted{FileCount: 2}))
+	assert.Equal(t, 2, dispatcher.Dispatch())
+	id, name, data := nextEvent(t, lines)
+	assert.Equal(t, "1", id)
+	assert.Equal(t, "upload_started", name)
+	assert.Contains(t, data, `"file_count":2`)
+	assert.Contains(t, data, `"seq":1`)
+
+	// Reconnecting with Last-Event-ID replays what came after it from the outbox, dispatched or not
+	require.NoError(t, repo.CreateOrderEvent(&models.OrderEvent{OrderID: order.ID, Event: events.EventImageProcessed}))
+	_, resumed := openStream(t, url, alice, "1")
+	id, name, _ = nextEvent(t, resumed)
+	assert.Equal(t, "2", id)
+	assert.Equal(t, "upload_completed", name)
+	id, name, _ = nextEvent(t, resumed)
+	assert.Equal(t, "3", id)
+	assert.Equal(t, "webhook_image_processed", name)
+
+	// Without text/event-stream, the events after since come back as JSON
+	w := call(eventsRouter(t, repo, bus), http.MethodGet, "/orders/"+order.ID.String()+"/events?since=1&limit=1", alice, "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var page models.OrderEventsResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+	require.Len(t, page.Events, 1)
+	assert.Equal(t, int64(2), page.Events[0].Seq)
+	assert.Equal(t, "upload_completed", page.Events[0].Event)
+	assert.True(t, page.HasMore)
+}
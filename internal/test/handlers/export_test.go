@@ -0,0 +1,206 @@
+package handlers_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"image"
+	"image/jpeg"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"instant-hdr-backend/internal/autoenhance"
+	"instant-hdr-backend/internal/credits"
+	"instant-hdr-backend/internal/handlers"
+	"instant-hdr-backend/internal/middleware"
+	"instant-hdr-backend/internal/models"
+	"instant-hdr-backend/internal/repository"
+	"instant-hdr-backend/internal/services"
+	"instant-hdr-backend/internal/storage"
+)
+
+// exportAutoEnhance serves an order with two processed images of the same name, one still processing and one
+// AutoEnhance no longer has
+func exportAutoEnhance(t *testing.T) *httptest.Server {
+	var enhanced bytes.Buffer
+	require.NoError(t, jpeg.Encode(&enhanced, image.NewRGBA(image.Rect(0, 0, 64, 48)), nil))
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if orderID, ok := strings.CutPrefix(r.URL.Path, "/v3/orders/"); ok {
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"order_id": orderID, "status": "processed",
+				"images": []map[string]interface{}{
+					{"image_id": "img-1", "image_name": "IMG_1.jpg", "status": "completed", "enhance_type": "property"},
+					{"image_id": "img-2", "image_name": "IMG_1.jpeg", "status": "completed"},
+					{"image_id": "img-3", "image_name": "IMG_3.jpg", "status": "processing"},
+					{"image_id": "img-4", "image_name": "IMG_4.jpg", "status": "completed"},
+				},
+			})
+			return
+		}
+		path := strings.TrimPrefix(r.URL.Path, "/v3/images/")
+		switch {
+		case strings.HasPrefix(path, "img-4"):
+			http.NotFound(w, r)
+		case strings.HasSuffix(path, "/enhanced"):
+			w.Write(enhanced.Bytes())
+		default:
+			json.NewEncoder(w).Encode(map[string]interface{}{"image_id": path})
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// exportRouter serves export.zip with an in-memory repository and local storage. The X-User-ID header stands in for the JWT.
+func exportRouter(t *testing.T, repo repository.Repository, creditService *credits.Service) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	localStorage, err := storage.NewLocalStorage(t.TempDir(), "http://localhost/files")
+	require.NoError(t, err)
+
+	ae := autoenhance.NewClient(exportAutoEnhance(t).URL, "test-key")
+	imageService := services.NewImageService(ae, repo, localStorage, storage.NewURLResolver(localStorage, false, 0), nil, creditService)
+	h := handlers.NewExportHandler(ae, repo, localStorage, imageService)
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set(middleware.UserIDKey, c.GetHeader("X-User-ID"))
+		c.Next()
+	})
+	router.GET("/orders/:order_id/export.zip", h.ExportOrder)
+	return router
+}
+
+// readExport opens the archive and returns its entries (manifest.json aside) and manifest
+func readExport(t *testing.T, w *httptest.ResponseRecorder) (map[string][]byte, models.ExportManifest) {
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, "application/zip", w.Header().Get("Content-Type"))
+
+	zr, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+	require.NoError(t, err)
+
+	entries := make(map[string][]byte)
+	var manifest models.ExportManifest
+	for _, f := range zr.File {
+		r, err := f.Open()
+		require.NoError(t, err)
+		data, err := io.ReadAll(r)
+		r.Close()
+		require.NoError(t, err)
+
+		if f.Name == "manifest.json" {
+			require.NoError(t, json.Unmarshal(data, &manifest))
+			continue
+		}
+		entries[f.Name] = data
+	}
+	return entries, manifest
+}
+
+func TestExport_ManifestAndEntryNames(t *testing.T) {
+	repo := repository.NewMemory()
+	router := exportRouter(t, repo, credits.NewService(repo, false, 0))
+	owner := uuid.New()
+	order, err := repo.CreateOrder(uuid.New(), owner, nil)
+	require.NoError(t, err)
+
+	entries, manifest := readExport(t, do(router, http.MethodGet, "/orders/"+order.ID.String()+"/export.zip?quality=preview", owner.String(), ""))
+
+	// Both images are named IMG_1 once the extension is dropped
+	require.Len(t, entries, 2)
+	assert.Contains(t, entries, "IMG_1.jpg")
+	assert.Contains(t, entries, "IMG_1-2.jpg")
+
+	assert.Equal(t, order.ID.String(), manifest.OrderID)
+	assert.Equal(t, "preview", manifest.Quality)
+	assert.Equal(t, "jpeg", manifest.Format)
+	assert.True(t, manifest.Watermark)
+	require.Len(t, manifest.Images, 4)
+
+	first := manifest.Images[0]
+	assert.Equal(t, "IMG_1.jpg", first.Filename)
+	assert.Equal(t, int64(len(entries["IMG_1.jpg"])), first.FileSize)
+	assert.Equal(t, services.VariantSourceGenerated, first.Source)
+	assert.Equal(t, "property", first.ProcessingSettings["enhance_type"])
+	assert.Empty(t, first.Error)
+	assert.Equal(t, "IMG_1-2.jpg", manifest.Images[1].Filename)
+
+	// Failed images are reported, not archived
+	assert.Equal(t, "image is not processed", manifest.Images[2].Error)
+	assert.Empty(t, manifest.Images[2].Filename)
+	assert.Contains(t, manifest.Images[3].Error, services.ErrImageNotFound.Error())
+	assert.Empty(t, manifest.Images[3].Filename)
+
+	// A second export reuses the stored variants
+	_, manifest = readExport(t, do(router, http.MethodGet, "/orders/"+order.ID.String()+"/export.zip?quality=preview", owner.String(), ""))
+	assert.Equal(t, services.VariantSourceCache, manifest.Images[0].Source)
+}
+
+func TestExport_Unwatermarked(t *testing.T) {
+	repo := repository.NewMemory()
+	router := exportRouter(t, repo, credits.NewService(repo, true, 0))
+	owner, viewer := uuid.New(), uuid.New()
+
+	org := models.Organization{Name: "Studio", CreatedBy: owner}
+	require.NoError(t, repo.CreateOrganization(&org))
+	require.NoError(t, repo.SetOrganizationMember(&models.OrganizationMember{OrganizationID: org.ID, UserID: viewer, Role: models.RoleViewer}))
+	order, err := repo.CreateOrder(uuid.New(), owner, nil)
+	require.NoError(t, err)
+	require.NoError(t, repo.SetOrderOrganization(order.ID, uuid.NullUUID{UUID: org.ID, Valid: true}))
+	path := "/orders/" + order.ID.String() + "/export.zip"
+
+	// Viewers can export watermarked images only: unwatermarked ones spend credits
+	assert.Equal(t, http.StatusForbidden, do(router, http.MethodGet, path+"?watermark=false", viewer.String(), "").Code)
+	entries, _ := readExport(t, do(router, http.MethodGet, path, viewer.String(), ""))
+	assert.Len(t, entries, 2)
+
+	// Without credits every image fails on its own, and the archive still holds the manifest
+	entries, manifest := readExport(t, do(router, http.MethodGet, path+"?watermark=false", owner.String(), ""))
+	assert.Empty(t, entries)
+	require.Len(t, manifest.Images, 4)
+	assert.Contains(t, manifest.Images[0].Error, "not enough credits")
+	assert.False(t, manifest.Images[0].CreditUsed)
+
+	// With one credit, one image is exported and paid for
+	_, err = repo.GrantCredits(owner, 1, "test", uuid.NullUUID{})
+	require.NoError(t, err)
+	entries, manifest = readExport(t, do(router, http.MethodGet, path+"?watermark=false", owner.String(), ""))
+	assert.Len(t, entries, 1)
+	assert.True(t, manifest.Images[0].CreditUsed)
+	assert.Empty(t, manifest.Images[0].Error)
+	assert.Contains(t, manifest.Images[1].Error, "not enough credits")
+}
+
+func TestExport_ClientGone(t *testing.T) {
+	repo := repository.NewMemory()
+	creditService := credits.NewService(repo, true, 0)
+	router := exportRouter(t, repo, creditService)
+	owner := uuid.New()
+	order, err := repo.CreateOrder(uuid.New(), owner, nil)
+	require.NoError(t, err)
+	_, err = repo.GrantCredits(owner, 5, "test", uuid.NullUUID{})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req := httptest.NewRequest(http.MethodGet, "/orders/"+order.ID.String()+"/export.zip?watermark=false", nil).WithContext(ctx)
+	req.Header.Set("X-User-ID", owner.String())
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// Nothing is fetched or paid for, and the archive is left unfinished rather than passed off as complete
+	account, err := creditService.Account(owner)
+	require.NoError(t, err)
+	assert.Equal(t, 5, account.Balance)
+	_, err = zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+	assert.Error(t, err)
+}
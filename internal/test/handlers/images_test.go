@@ -0,0 +1,111 @@
+package handlers_test
+
+import (
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"instant-hdr-backend/internal/handlers"
+	"instant-hdr-backend/internal/middleware"
+	"instant-hdr-backend/internal/models"
+	"instant-hdr-backend/internal/repository"
+	"instant-hdr-backend/internal/storage"
+)
+
+// contentRouter serves image content for an order with a stored preview of img-1 holding "0123456789"
+func contentRouter(t *testing.T) (*gin.Engine, string, string) {
+	gin.SetMode(gin.TestMode)
+
+	repo := repository.NewMemory()
+	localStorage, err := storage.NewLocalStorage(t.TempDir(), "http://localhost/files")
+	require.NoError(t, err)
+
+	owner := uuid.New()
+	order, err := repo.CreateOrder(uuid.New(), owner, nil)
+	require.NoError(t, err)
+	storagePath, _, err := localStorage.UploadFile(owner, order.ID, "img-1_preview.jpg", []byte("0123456789"))
+	require.NoError(t, err)
+	require.NoError(t, repo.CreateOrderFile(&models.OrderFile{
+		OrderID:            order.ID,
+		UserID:             owner,
+		Filename:           "img-1_preview.jpg",
+		AutoEnhanceImageID: sql.NullString{String: "img-1", Valid: true},
+		StoragePath:        storagePath,
+		MimeType:           "image/jpeg",
+		IsFinal:            true,
+		Variant:            sql.NullString{String: "preview", Valid: true},
+		Format:             sql.NullString{String: "jpeg", Valid: true},
+		Watermark:          sql.NullBool{Bool: true, Valid: true},
+	}))
+
+	h := handlers.NewImagesHandler(nil, repo, localStorage, storage.NewURLResolver(localStorage, false, 0), nil)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set(middleware.UserIDKey, c.GetHeader("X-User-ID"))
+		c.Next()
+	})
+	router.GET("/orders/:order_id/images/:image_id/content", h.GetImageContent)
+	return router, "/orders/" + order.ID.String() + "/images/img-1/content", owner.String()
+}
+
+func getContent(router *gin.Engine, path, userID string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.Header.Set("X-User-ID", userID)
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestImageContent_Ranges(t *testing.T) {
+	router, path, userID := contentRouter(t)
+
+	w := getContent(router, path, userID, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, "0123456789", w.Body.String())
+	assert.Equal(t, "bytes", w.Header().Get("Accept-Ranges"))
+	etag := w.Header().Get("ETag")
+	require.NotEmpty(t, etag)
+
+	w = getContent(router, path, userID, map[string]string{"Range": "bytes=2-4"})
+	require.Equal(t, http.StatusPartialContent, w.Code)
+	assert.Equal(t, "234", w.Body.String())
+	assert.Equal(t, "bytes 2-4/10", w.Header().Get("Content-Range"))
+	assert.Equal(t, "3", w.Header().Get("Content-Length"))
+
+	// A resumed download whose copy is still current gets the rest
+	w = getContent(router, path, userID, map[string]string{"Range": "bytes=7-", "If-Range": etag})
+	require.Equal(t, http.StatusPartialContent, w.Code)
+	assert.Equal(t, "789", w.Body.String())
+
+	// The client's copy changed: the whole object, not a piece of the new one
+	w = getContent(router, path, userID, map[string]string{"Range": "bytes=7-", "If-Range": `"stale"`})
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "0123456789", w.Body.String())
+	assert.Empty(t, w.Header().Get("Content-Range"))
+
+	w = getContent(router, path, userID, map[string]string{"Range": "bytes=20-30"})
+	assert.Equal(t, http.StatusRequestedRangeNotSatisfiable, w.Code)
+	assert.Equal(t, "bytes */10", w.Header().Get("Content-Range"))
+}
+
+func TestImageContent_NotModified(t *testing.T) {
+	router, path, userID := contentRouter(t)
+
+	etag := getContent(router, path, userID, nil).Header().Get("ETag")
+	require.NotEmpty(t, etag)
+
+	w := getContent(router, path, userID, map[string]string{"If-None-Match": etag})
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Empty(t, w.Body.String())
+
+	w = getContent(router, path, userID, map[string]string{"If-None-Match": `"other"`})
+	assert.Equal(t, http.StatusOK, w.Code)
+}
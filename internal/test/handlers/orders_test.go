@@ -0,0 +1,243 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"instant-hdr-backend/internal/autoenhance"
+	"instant-hdr-backend/internal/handlers"
+	"instant-hdr-backend/internal/middleware"
+	"instant-hdr-backend/internal/models"
+	"instant-hdr-backend/internal/quota"
+	"instant-hdr-backend/internal/repository"
+	"instant-hdr-backend/internal/services"
+	"instant-hdr-backend/internal/storage"
+)
+
+// fakeAutoEnhance answers the order endpoints the orders handler calls
+func fakeAutoEnhance(t *testing.T) *httptest.Server {
+	var mu sync.Mutex
+	names := make(map[string]string)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		path := strings.TrimPrefix(r.URL.Path, "/v3/orders/")
+		switch {
+		case r.Method == http.MethodPost && path == "":
+			var in autoenhance.OrderIn
+			json.NewDecoder(r.Body).Decode(&in)
+			id := uuid.New().String()
+			names[id] = in.Name
+			json.NewEncoder(w).Encode(map[string]interface{}{"order_id": id, "name": in.Name, "status": "waiting"})
+		case r.Method == http.MethodGet && names[path] != "":
+			json.NewEncoder(w).Encode(map[string]interface{}{"order_id": path, "name": names[path], "status": "waiting"})
+		case r.Method == http.MethodDelete:
+			delete(names, path)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// ordersRouter serves the order routes with an in-memory repository. The X-User-ID header stands in for the JWT.
+func ordersRouter(t *testing.T, repo repository.Repository, quotaService *quota.Service) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	localStorage, err := storage.NewLocalStorage(t.TempDir(), "http://localhost/files")
+	require.NoError(t, err)
+
+	ae := autoenhance.NewClient(fakeAutoEnhance(t).URL, "test-key")
+	h := handlers.NewOrdersHandler(ae, repo, localStorage, quotaService, services.NewOrderPurger(ae, repo, localStorage))
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set(middleware.UserIDKey, c.GetHeader("X-User-ID"))
+		c.Next()
+	})
+	router.POST("/orders", h.CreateOrder)
+	router.GET("/orders", h.ListOrders)
+	router.GET("/orders/trash", h.ListTrash)
+	router.GET("/orders/:order_id", h.GetOrder)
+	router.DELETE("/orders/:order_id", h.DeleteOrder)
+	router.POST("/orders/:order_id/restore", h.RestoreOrder)
+	router.PUT("/orders/:order_id/property", h.SetOrderProperty)
+	router.PUT("/orders/:order_id/organization", h.SetOrderOrganization)
+	return router
+}
+
+func do(router *gin.Engine, method, path, userID, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("X-User-ID", userID)
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestOrdersHandler_Lifecycle(t *testing.T) {
+	router := ordersRouter(t, repository.NewMemory(), nil)
+	owner := uuid.New().String()
+	other := uuid.New().String()
+
+	w := do(router, http.MethodPost, "/orders", owner, `{"name":"Kitchen"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var created models.OrderResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	assert.Equal(t, "Kitchen", created.Name)
+
+	w = do(router, http.MethodGet, "/orders", owner, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var list models.OrderListResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	require.Len(t, list.Orders, 1)
+	assert.Equal(t, created.ID, list.Orders[0].ID)
+
+	// Orders are scoped to their owner
+	assert.Equal(t, http.StatusOK, do(router, http.MethodGet, "/orders/"+created.ID, owner, "").Code)
+	assert.Equal(t, http.StatusNotFound, do(router, http.MethodGet, "/orders/"+created.ID, other, "").Code)
+	assert.Equal(t, http.StatusNotFound, do(router, http.MethodDelete, "/orders/"+created.ID, other, "").Code)
+
+	assert.Equal(t, http.StatusOK, do(router, http.MethodDelete, "/orders/"+created.ID, owner, "").Code)
+	assert.Equal(t, http.StatusNotFound, do(router, http.MethodGet, "/orders/"+created.ID, owner, "").Code)
+}
+
+func TestOrdersHandler_ActiveOrderQuota(t *testing.T) {
+	repo := repository.NewMemory()
+	router := ordersRouter(t, repo, quota.NewService(repo, true, "free"))
+	userID := uuid.New().String()
+
+	for i := 0; i < quota.Plans["free"].MaxActiveOrders; i++ {
+		require.Equal(t, http.StatusOK, do(router, http.MethodPost, "/orders", userID, "").Code)
+	}
+
+	w := do(router, http.MethodPost, "/orders", userID, "")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	var resp models.QuotaExceededResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "quota_exceeded", resp.Error)
+	assert.Equal(t, quota.LimitActiveOrders, resp.Limit)
+
+	// Upgrading the plan lifts the limit
+	repo.SetUserPlan(uuid.MustParse(userID), "pro")
+	assert.Equal(t, http.StatusOK, do(router, http.MethodPost, "/orders", userID, "").Code)
+}
+
+func TestOrdersHandler_ListPagination(t *testing.T) {
+	router := ordersRouter(t, repository.NewMemory(), nil)
+	userID := uuid.New().String()
+
+	names := []string{"Kitchen", "Garden", "Attic", "kitchen annex", "Basement"}
+	for _, name := range names {
+		require.Equal(t, http.StatusOK, do(router, http.MethodPost, "/orders", userID, `{"name":"`+name+`"}`).Code)
+	}
+
+	list := func(query string) (int, models.OrderListResponse) {
+		w := do(router, http.MethodGet, "/orders?"+query, userID, "")
+		var resp models.OrderListResponse
+		if w.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		}
+		return w.Code, resp
+	}
+
+	// Walking the cursor visits every order exactly once
+	seen := make(map[string]bool)
+	query := "limit=2"
+	pages := 0
+	for {
+		code, page := list(query)
+		require.Equal(t, http.StatusOK, code)
+		pages++
+		for _, o := range page.Orders {
+			assert.False(t, seen[o.ID], "order %s returned twice", o.ID)
+			seen[o.ID] = true
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		query = "limit=2&cursor=" + page.NextCursor
+	}
+	assert.Len(t, seen, len(names))
+	assert.Equal(t, 3, pages)
+
+	_, byName := list("sort=name")
+	require.Len(t, byName.Orders, len(names))
+	assert.Equal(t, "Attic", byName.Orders[0].Name)
+	assert.Empty(t, byName.NextCursor)
+
+	_, filtered := list("name=KITCHEN&sort=name&direction=desc")
+	require.Len(t, filtered.Orders, 2)
+	assert.Equal(t, "kitchen annex", filtered.Orders[0].Name)
+
+	_, none := list("created_before=2000-01-01")
+	assert.Empty(t, none.Orders)
+
+	// A cursor only works with the sort it was issued for
+	_, first := list("limit=1")
+	code, _ := list("limit=1&sort=name&cursor=" + first.NextCursor)
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	for _, bad := range []string{"limit=0", "limit=500", "sort=size", "direction=up", "is_processing=maybe", "created_after=yesterday", "cursor=xyz"} {
+		code, _ := list(bad)
+		assert.Equal(t, http.StatusBadRequest, code, bad)
+	}
+}
+
+func TestOrdersHandler_TrashAndRestore(t *testing.T) {
+	repo := repository.NewMemory()
+	router := ordersRouter(t, repo, nil)
+	userID := uuid.New().String()
+
+	w := do(router, http.MethodPost, "/orders", userID, `{"name":"Wrong shoot"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	var created models.OrderResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+
+	listIDs := func(path string) []string {
+		w := do(router, http.MethodGet, path, userID, "")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var list models.OrderListResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+		ids := make([]string, 0, len(list.Orders))
+		for _, o := range list.Orders {
+			ids = append(ids, o.ID)
+		}
+		return ids
+	}
+
+	// Deleting moves the order to the trash
+	require.Equal(t, http.StatusOK, do(router, http.MethodDelete, "/orders/"+created.ID, userID, "").Code)
+	assert.Empty(t, listIDs("/orders"))
+	assert.Equal(t, []string{created.ID}, listIDs("/orders/trash"))
+	assert.Equal(t, http.StatusNotFound, do(router, http.MethodGet, "/orders/"+created.ID, userID, "").Code)
+	assert.Equal(t, http.StatusNotFound, do(router, http.MethodDelete, "/orders/"+created.ID, userID, "").Code)
+
+	// Restoring brings it back
+	assert.Equal(t, http.StatusNotFound, do(router, http.MethodPost, "/orders/"+created.ID+"/restore", uuid.New().String(), "").Code)
+	require.Equal(t, http.StatusOK, do(router, http.MethodPost, "/orders/"+created.ID+"/restore", userID, "").Code)
+	assert.Equal(t, []string{created.ID}, listIDs("/orders"))
+	assert.Empty(t, listIDs("/orders/trash"))
+	assert.Equal(t, http.StatusNotFound, do(router, http.MethodPost, "/orders/"+created.ID+"/restore", userID, "").Code)
+
+	// Permanent deletion also empties the trash
+	require.Equal(t, http.StatusOK, do(router, http.MethodDelete, "/orders/"+created.ID, userID, "").Code)
+	require.Equal(t, http.StatusOK, do(router, http.MethodDelete, "/orders/"+created.ID+"?permanent=true", userID, "").Code)
+	assert.Empty(t, listIDs("/orders/trash"))
+	_, err := repo.GetTrashedOrder(uuid.MustParse(created.ID), uuid.MustParse(userID))
+	assert.Error(t, err)
+}
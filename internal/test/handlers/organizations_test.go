@@ -0,0 +1,135 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"instant-hdr-backend/internal/handlers"
+	"instant-hdr-backend/internal/models"
+	"instant-hdr-backend/internal/repository"
+)
+
+func organizationsRouter(t *testing.T, repo repository.Repository) *gin.Engine {
+	router := ordersRouter(t, repo, nil)
+	h := handlers.NewOrganizationsHandler(repo)
+	router.POST("/organizations", h.CreateOrganization)
+	router.GET("/organizations", h.ListOrganizations)
+	router.GET("/organizations/:organization_id", h.GetOrganization)
+	router.DELETE("/organizations/:organization_id", h.DeleteOrganization)
+	router.GET("/organizations/:organization_id/members", h.ListMembers)
+	router.PUT("/organizations/:organization_id/members/:user_id", h.SetMember)
+	router.DELETE("/organizations/:organization_id/members/:user_id", h.RemoveMember)
+	return router
+}
+
+func TestOrganizations_SharedOrdersAndRoles(t *testing.T) {
+	repo := repository.NewMemory()
+	router := organizationsRouter(t, repo)
+	owner, admin, editor, viewer, outsider := uuid.NewString(), uuid.NewString(), uuid.NewString(), uuid.NewString(), uuid.NewString()
+
+	w := do(router, http.MethodPost, "/organizations", owner, `{"name":"Sunset Media"}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var org models.OrganizationResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &org))
+	assert.Equal(t, models.RoleOwner, org.Role)
+	orgPath := "/organizations/" + org.ID
+
+	for user, role := range map[string]string{admin: "admin", editor: "editor", viewer: "viewer"} {
+		require.Equal(t, http.StatusOK, do(router, http.MethodPut, orgPath+"/members/"+user, owner, `{"role":"`+role+`"}`).Code)
+	}
+	assert.Equal(t, http.StatusBadRequest, do(router, http.MethodPut, orgPath+"/members/"+outsider, owner, `{"role":"boss"}`).Code)
+
+	// Editors create organization orders; viewers can't
+	assert.Equal(t, http.StatusForbidden, do(router, http.MethodPost, "/orders", viewer, `{"organization_id":"`+org.ID+`"}`).Code)
+	assert.Equal(t, http.StatusNotFound, do(router, http.MethodPost, "/orders", outsider, `{"organization_id":"`+org.ID+`"}`).Code)
+	w = do(router, http.MethodPost, "/orders", editor, `{"name":"Shared shoot","organization_id":"`+org.ID+`"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var created models.OrderResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	assert.Equal(t, org.ID, created.OrganizationID)
+	assert.Equal(t, editor, created.CreatedBy)
+
+	// Every member sees the order with their own role; outsiders don't see it at all
+	w = do(router, http.MethodGet, "/orders/"+created.ID, viewer, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var seen models.OrderResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &seen))
+	assert.Equal(t, models.RoleViewer, seen.Role)
+	assert.Equal(t, http.StatusNotFound, do(router, http.MethodGet, "/orders/"+created.ID, outsider, "").Code)
+
+	w = do(router, http.MethodGet, "/orders?organization_id="+org.ID, owner, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var list models.OrderListResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	require.Len(t, list.Orders, 1)
+	assert.Equal(t, created.ID, list.Orders[0].ID)
+
+	// Trashing and restoring takes an admin
+	assert.Equal(t, http.StatusForbidden, do(router, http.MethodDelete, "/orders/"+created.ID, viewer, "").Code)
+	assert.Equal(t, http.StatusForbidden, do(router, http.MethodDelete, "/orders/"+created.ID, editor, "").Code)
+	require.Equal(t, http.StatusOK, do(router, http.MethodDelete, "/orders/"+created.ID, admin, "").Code)
+	assert.Equal(t, http.StatusForbidden, do(router, http.MethodPost, "/orders/"+created.ID+"/restore", editor, "").Code)
+	require.Equal(t, http.StatusOK, do(router, http.MethodPost, "/orders/"+created.ID+"/restore", admin, "").Code)
+
+	// Admins manage editors and viewers, but not admins and owners
+	assert.Equal(t, http.StatusForbidden, do(router, http.MethodPut, orgPath+"/members/"+viewer, admin, `{"role":"owner"}`).Code)
+	assert.Equal(t, http.StatusForbidden, do(router, http.MethodDelete, orgPath+"/members/"+owner, admin, "").Code)
+	assert.Equal(t, http.StatusOK, do(router, http.MethodPut, orgPath+"/members/"+viewer, admin, `{"role":"editor"}`).Code)
+	assert.Equal(t, http.StatusForbidden, do(router, http.MethodPut, orgPath+"/members/"+viewer, editor, `{"role":"viewer"}`).Code)
+
+	// The last owner can't leave or be demoted
+	assert.Equal(t, http.StatusConflict, do(router, http.MethodDelete, orgPath+"/members/"+owner, owner, "").Code)
+	assert.Equal(t, http.StatusConflict, do(router, http.MethodPut, orgPath+"/members/"+owner, owner, `{"role":"admin"}`).Code)
+
+	// Leaving the organization ends access to its orders
+	require.Equal(t, http.StatusOK, do(router, http.MethodDelete, orgPath+"/members/"+viewer, viewer, "").Code)
+	assert.Equal(t, http.StatusNotFound, do(router, http.MethodGet, "/orders/"+created.ID, viewer, "").Code)
+
+	// Deleting the organization hands the order back to its creator
+	assert.Equal(t, http.StatusForbidden, do(router, http.MethodDelete, orgPath, admin, "").Code)
+	require.Equal(t, http.StatusOK, do(router, http.MethodDelete, orgPath, owner, "").Code)
+	assert.Equal(t, http.StatusNotFound, do(router, http.MethodGet, "/orders/"+created.ID, admin, "").Code)
+	w = do(router, http.MethodGet, "/orders/"+created.ID, editor, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &seen))
+	assert.Equal(t, models.RoleOwner, seen.Role)
+}
+
+func TestOrdersHandler_SetOrderOrganization(t *testing.T) {
+	repo := repository.NewMemory()
+	router := organizationsRouter(t, repo)
+	owner, member := uuid.NewString(), uuid.NewString()
+
+	w := do(router, http.MethodPost, "/organizations", owner, `{"name":"Studio"}`)
+	require.Equal(t, http.StatusCreated, w.Code)
+	var org models.OrganizationResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &org))
+	require.Equal(t, http.StatusOK, do(router, http.MethodPut, "/organizations/"+org.ID+"/members/"+member, owner, `{"role":"viewer"}`).Code)
+
+	w = do(router, http.MethodPost, "/orders", owner, `{"name":"Personal"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	var created models.OrderResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	path := "/orders/" + created.ID + "/organization"
+
+	assert.Equal(t, http.StatusNotFound, do(router, http.MethodGet, "/orders/"+created.ID, member, "").Code)
+	assert.Equal(t, http.StatusNotFound, do(router, http.MethodPut, path, member, `{"organization_id":"`+org.ID+`"}`).Code)
+	assert.Equal(t, http.StatusNotFound, do(router, http.MethodPut, path, owner, `{"organization_id":"`+uuid.NewString()+`"}`).Code)
+
+	w = do(router, http.MethodPut, path, owner, `{"organization_id":"`+org.ID+`"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var summary models.OrderSummary
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &summary))
+	assert.Equal(t, org.ID, summary.OrganizationID)
+	assert.Equal(t, http.StatusOK, do(router, http.MethodGet, "/orders/"+created.ID, member, "").Code)
+
+	// Viewers can't take the order out again
+	assert.Equal(t, http.StatusForbidden, do(router, http.MethodPut, path, member, `{"organization_id":null}`).Code)
+	require.Equal(t, http.StatusOK, do(router, http.MethodPut, path, owner, `{"organization_id":null}`).Code)
+	assert.Equal(t, http.StatusNotFound, do(router, http.MethodGet, "/orders/"+created.ID, member, "").Code)
+}
@@ -0,0 +1,140 @@
+package handlers_test
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"instant-hdr-backend/internal/handlers"
+	"instant-hdr-backend/internal/models"
+	"instant-hdr-backend/internal/repository"
+)
+
+func TestPropertiesHandler_OrdersAndDelivery(t *testing.T) {
+	repo := repository.NewMemory()
+	router := ordersRouter(t, repo, nil)
+	h := handlers.NewPropertiesHandler(repo)
+	router.POST("/properties", h.CreateProperty)
+	router.GET("/properties", h.ListProperties)
+	router.GET("/properties/:property_id", h.GetProperty)
+	router.PATCH("/properties/:property_id", h.UpdateProperty)
+	router.DELETE("/properties/:property_id", h.DeleteProperty)
+	userID := uuid.New().String()
+	other := uuid.New().String()
+
+	assert.Equal(t, http.StatusBadRequest, do(router, http.MethodPost, "/properties", userID, `{"mls_number":"123"}`).Code)
+
+	w := do(router, http.MethodPost, "/properties", userID, `{"address":"123 Main St","mls_number":"MLS-42","client_name":"Acme Realty"}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var property models.PropertyResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &property))
+	assert.Equal(t, "123 Main St", property.Address)
+
+	// Properties are scoped to their owner
+	assert.Equal(t, http.StatusNotFound, do(router, http.MethodGet, "/properties/"+property.ID, other, "").Code)
+	assert.Equal(t, http.StatusNotFound, do(router, http.MethodPost, "/orders", other, `{"property_id":"`+property.ID+`"}`).Code)
+
+	detail := func() models.PropertyDetailResponse {
+		w := do(router, http.MethodGet, "/properties/"+property.ID, userID, "")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var resp models.PropertyDetailResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return resp
+	}
+	assert.Equal(t, models.DeliveryNoOrders, detail().Summary.DeliveryStatus)
+
+	var orderIDs []string
+	for _, name := range []string{"Living room", "Kitchen"} {
+		w := do(router, http.MethodPost, "/orders", userID, `{"name":"`+name+`","property_id":"`+property.ID+`"}`)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var created models.OrderResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+		assert.Equal(t, property.ID, created.PropertyID)
+		orderIDs = append(orderIDs, created.ID)
+	}
+	require.Equal(t, http.StatusOK, do(router, http.MethodPost, "/orders", userID, `{"name":"Unrelated"}`).Code)
+
+	w = do(router, http.MethodGet, "/orders?property_id="+property.ID, userID, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var list models.OrderListResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	assert.Len(t, list.Orders, 2)
+
+	// Two images, one delivered unwatermarked (in two sizes) and one only watermarked
+	orderID := uuid.MustParse(orderIDs[0])
+	require.NoError(t, repo.SyncAutoEnhanceOrderData(orderID, "Living room", "processed", false, false, false, 2, nil))
+	for _, file := range []struct {
+		imageID   string
+		variant   string
+		watermark bool
+	}{{"img-1", "high", false}, {"img-1", "preview", false}, {"img-2", "high", true}} {
+		require.NoError(t, repo.CreateOrderFile(&models.OrderFile{
+			OrderID:            orderID,
+			UserID:             uuid.MustParse(userID),
+			AutoEnhanceImageID: sql.NullString{String: file.imageID, Valid: true},
+			Variant:            sql.NullString{String: file.variant, Valid: true},
+			Watermark:          sql.NullBool{Bool: file.watermark, Valid: true},
+		}))
+	}
+
+	resp := detail()
+	assert.Len(t, resp.Orders, 2)
+	assert.Equal(t, 2, resp.Summary.OrderCount)
+	assert.Equal(t, 2, resp.Summary.TotalImages)
+	assert.Equal(t, 1, resp.Summary.DeliveredImages)
+	assert.Equal(t, models.DeliveryPartial, resp.Summary.DeliveryStatus)
+
+	w = do(router, http.MethodPatch, "/properties/"+property.ID, userID, `{"client_name":"","notes":"Lockbox 1234"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var updated models.PropertyResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &updated))
+	assert.Equal(t, "123 Main St", updated.Address)
+	assert.Empty(t, updated.ClientName)
+	assert.Equal(t, "Lockbox 1234", updated.Notes)
+
+	// Deleting the property keeps its orders
+	require.Equal(t, http.StatusOK, do(router, http.MethodDelete, "/properties/"+property.ID, userID, "").Code)
+	assert.Equal(t, http.StatusNotFound, do(router, http.MethodGet, "/properties/"+property.ID, userID, "").Code)
+	order, err := repo.GetOrder(orderID, uuid.MustParse(userID))
+	require.NoError(t, err)
+	assert.False(t, order.PropertyID.Valid)
+}
+
+func TestOrdersHandler_SetOrderProperty(t *testing.T) {
+	repo := repository.NewMemory()
+	router := ordersRouter(t, repo, nil)
+	userID := uuid.New().String()
+	other := uuid.New().String()
+
+	w := do(router, http.MethodPost, "/orders", userID, `{"name":"Front"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	var created models.OrderResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	assert.Empty(t, created.PropertyID)
+
+	property := &models.Property{UserID: uuid.MustParse(userID), Address: "9 Elm Rd"}
+	require.NoError(t, repo.CreateProperty(property))
+	foreign := &models.Property{UserID: uuid.MustParse(other), Address: "1 Other Ln"}
+	require.NoError(t, repo.CreateProperty(foreign))
+
+	path := "/orders/" + created.ID + "/property"
+	assert.Equal(t, http.StatusBadRequest, do(router, http.MethodPut, path, userID, `{"property_id":"nope"}`).Code)
+	assert.Equal(t, http.StatusNotFound, do(router, http.MethodPut, path, userID, `{"property_id":"`+foreign.ID.String()+`"}`).Code)
+	assert.Equal(t, http.StatusNotFound, do(router, http.MethodPut, path, other, `{"property_id":"`+foreign.ID.String()+`"}`).Code)
+
+	w = do(router, http.MethodPut, path, userID, `{"property_id":"`+property.ID.String()+`"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var summary models.OrderSummary
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &summary))
+	assert.Equal(t, property.ID.String(), summary.PropertyID)
+
+	w = do(router, http.MethodPut, path, userID, `{"property_id":null}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var unlinked models.OrderSummary
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &unlinked))
+	assert.Empty(t, unlinked.PropertyID)
+}
@@ -0,0 +1,162 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"instant-hdr-backend/internal/autoenhance"
+	"instant-hdr-backend/internal/handlers"
+	"instant-hdr-backend/internal/models"
+	"instant-hdr-backend/internal/repository"
+)
+
+// tagsRouter adds the tag and saved filter routes to ordersRouter. AutoEnhance orders have two images, img-1 and img-2.
+func tagsRouter(t *testing.T, repo repository.Repository) *gin.Engine {
+	router := ordersRouter(t, repo, nil)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		orderID := strings.TrimPrefix(r.URL.Path, "/v3/orders/")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"order_id": orderID,
+			"status":   "processed",
+			"images":   []map[string]interface{}{{"image_id": "img-1"}, {"image_id": "img-2"}},
+		})
+	}))
+	t.Cleanup(server.Close)
+
+	tags := handlers.NewTagsHandler(autoenhance.NewClient(server.URL, "test-key"), repo)
+	router.POST("/orders/tags", tags.BulkTagOrders)
+	router.POST("/orders/:order_id/tags", tags.AddOrderTags)
+	router.DELETE("/orders/:order_id/tags/:tag", tags.RemoveOrderTag)
+	router.POST("/orders/:order_id/images/:image_id/tags", tags.AddImageTags)
+	router.DELETE("/orders/:order_id/images/:image_id/tags/:tag", tags.RemoveImageTag)
+
+	filters := handlers.NewSavedFiltersHandler(repo)
+	router.POST("/saved-filters", filters.CreateSavedFilter)
+	router.GET("/saved-filters", filters.ListSavedFilters)
+	router.DELETE("/saved-filters/:filter_id", filters.DeleteSavedFilter)
+	return router
+}
+
+func TestTagsHandler_OrderTagsAndFilters(t *testing.T) {
+	router := tagsRouter(t, repository.NewMemory())
+	userID := uuid.NewString()
+
+	create := func(body string) string {
+		w := do(router, http.MethodPost, "/orders", userID, body)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var created models.OrderResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+		return created.ID
+	}
+	kitchen := create(`{"name":"Kitchen","tags":["Twilight"," rush "]}`)
+	garden := create(`{"name":"Garden"}`)
+	attic := create(`{"name":"Attic","tags":["twilight"]}`)
+	assert.Equal(t, http.StatusBadRequest, do(router, http.MethodPost, "/orders", userID, `{"tags":["a,b"]}`).Code)
+
+	tagsOf := func(w *httptest.ResponseRecorder) []string {
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var resp models.TagsResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return resp.Tags
+	}
+	assert.Equal(t, []string{"client-review", "twilight"}, tagsOf(do(router, http.MethodPost, "/orders/"+attic+"/tags", userID, `{"tags":["Client-Review","twilight"]}`)))
+	assert.Equal(t, []string{"client-review"}, tagsOf(do(router, http.MethodDelete, "/orders/"+attic+"/tags/TWILIGHT", userID, "")))
+	assert.Equal(t, http.StatusBadRequest, do(router, http.MethodPost, "/orders/"+attic+"/tags", userID, `{"tags":[""]}`).Code)
+	assert.Equal(t, http.StatusNotFound, do(router, http.MethodPost, "/orders/"+attic+"/tags", uuid.NewString(), `{"tags":["x"]}`).Code)
+
+	list := func(query string) []string {
+		w := do(router, http.MethodGet, "/orders?"+query, userID, "")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var resp models.OrderListResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		var names []string
+		for _, o := range resp.Orders {
+			names = append(names, o.Name)
+		}
+		return names
+	}
+	assert.Equal(t, []string{"Kitchen"}, list("tag=twilight"))
+	assert.Equal(t, []string{"Kitchen"}, list("tag=RUSH,twilight"))
+	assert.Empty(t, list("tag=rush,client-review"))
+
+	// Bulk tagging reports orders it could not tag and tags the rest
+	other := uuid.NewString()
+	w := do(router, http.MethodPost, "/orders/tags", userID, `{"order_ids":["`+kitchen+`","`+garden+`","`+other+`","nope"],"add":["delivered"],"remove":["rush"]}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var bulk models.BulkTagResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &bulk))
+	require.Len(t, bulk.Orders, 4)
+	assert.Equal(t, []string{"delivered", "twilight"}, bulk.Orders[0].Tags)
+	assert.Equal(t, []string{"delivered"}, bulk.Orders[1].Tags)
+	assert.Equal(t, "order not found", bulk.Orders[2].Error)
+	assert.Equal(t, "invalid order id", bulk.Orders[3].Error)
+	assert.Equal(t, []string{"Garden", "Kitchen"}, list("tag=delivered&sort=name"))
+	assert.Equal(t, http.StatusBadRequest, do(router, http.MethodPost, "/orders/tags", userID, `{"order_ids":["`+kitchen+`"]}`).Code)
+
+	// Saved filters apply their query; parameters in the request win
+	w = do(router, http.MethodPost, "/saved-filters", userID, `{"name":"Delivered","query":"tag=delivered&sort=name&direction=desc"}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var filter models.SavedFilterResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &filter))
+	assert.Equal(t, http.StatusConflict, do(router, http.MethodPost, "/saved-filters", userID, `{"name":"Delivered","query":"tag=rush"}`).Code)
+	for _, bad := range []string{"cursor=abc", "sort=size", "tag=rush,,twilight", "limit=0"} {
+		assert.Equal(t, http.StatusBadRequest, do(router, http.MethodPost, "/saved-filters", userID, `{"name":"Bad","query":"`+bad+`"}`).Code, bad)
+	}
+
+	assert.Equal(t, []string{"Kitchen", "Garden"}, list("saved_filter="+filter.ID))
+	assert.Equal(t, []string{"Garden", "Kitchen"}, list("saved_filter="+filter.ID+"&direction=asc"))
+	assert.Equal(t, http.StatusNotFound, do(router, http.MethodGet, "/orders?saved_filter="+filter.ID, other, "").Code)
+
+	w = do(router, http.MethodGet, "/saved-filters", userID, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var filters models.SavedFilterListResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &filters))
+	require.Len(t, filters.Filters, 1)
+	saved, err := url.ParseQuery(filters.Filters[0].Query)
+	require.NoError(t, err)
+	assert.Equal(t, "delivered", saved.Get("tag"))
+
+	assert.Equal(t, http.StatusNotFound, do(router, http.MethodDelete, "/saved-filters/"+filter.ID, other, "").Code)
+	require.Equal(t, http.StatusOK, do(router, http.MethodDelete, "/saved-filters/"+filter.ID, userID, "").Code)
+	assert.Equal(t, http.StatusNotFound, do(router, http.MethodGet, "/orders?saved_filter="+filter.ID, userID, "").Code)
+}
+
+func TestTagsHandler_ImageTags(t *testing.T) {
+	repo := repository.NewMemory()
+	router := tagsRouter(t, repo)
+	userID := uuid.New()
+	order, err := repo.CreateOrder(uuid.New(), userID, nil)
+	require.NoError(t, err)
+	path := "/orders/" + order.ID.String() + "/images/"
+
+	w := do(router, http.MethodPost, path+"img-1/tags", userID.String(), `{"tags":["Hero","exterior"]}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var resp models.TagsResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, []string{"exterior", "hero"}, resp.Tags)
+	assert.Equal(t, http.StatusNotFound, do(router, http.MethodPost, path+"img-9/tags", userID.String(), `{"tags":["hero"]}`).Code)
+
+	tags, err := repo.ListImageTags(order.ID)
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]string{"img-1": {"exterior", "hero"}}, tags)
+
+	w = do(router, http.MethodDelete, path+"img-1/tags/hero", userID.String(), "")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, []string{"exterior"}, resp.Tags)
+
+	// Deleting the order removes its image tags
+	require.NoError(t, repo.DeleteOrder(order.ID, userID))
+	tags, err = repo.ListImageTags(order.ID)
+	require.NoError(t, err)
+	assert.Empty(t, tags)
+}
@@ -0,0 +1,69 @@
+package imaging_test
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"instant-hdr-backend/internal/imaging"
+)
+
+func testImage(width, height int) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 128, A: 255})
+		}
+	}
+	return img
+}
+
+func TestResize_KeepsAspectRatio(t *testing.T) {
+	resized := imaging.Resize(testImage(3000, 2000), imaging.VariantPreview.MaxWidth)
+	assert.Equal(t, 800, resized.Bounds().Dx())
+	assert.Equal(t, 533, resized.Bounds().Dy())
+}
+
+func TestResize_NeverUpscales(t *testing.T) {
+	src := testImage(300, 200)
+	assert.Equal(t, src.Bounds(), imaging.Resize(src, imaging.VariantMedium.MaxWidth).Bounds())
+	assert.Equal(t, src.Bounds(), imaging.Resize(src, 0).Bounds())
+}
+
+func TestScale(t *testing.T) {
+	scaled := imaging.Scale(testImage(1000, 500), 0.5)
+	assert.Equal(t, 500, scaled.Bounds().Dx())
+	assert.Equal(t, 250, scaled.Bounds().Dy())
+}
+
+func TestEncodeDecode_RoundTrip(t *testing.T) {
+	src := testImage(64, 32)
+
+	for _, format := range []string{imaging.FormatJPEG, imaging.FormatPNG} {
+		data, err := imaging.Encode(src, format)
+		require.NoError(t, err, format)
+
+		decoded, err := imaging.Decode(data)
+		require.NoError(t, err, format)
+		assert.Equal(t, src.Bounds(), decoded.Bounds(), format)
+	}
+
+	_, err := imaging.Encode(src, imaging.FormatWebP)
+	assert.Error(t, err)
+	assert.False(t, imaging.CanEncode(imaging.FormatWebP))
+}
+
+func TestVariantByName(t *testing.T) {
+	v, ok := imaging.VariantByName("thumbnail")
+	require.True(t, ok)
+	assert.Equal(t, 400, v.MaxWidth)
+
+	v, ok = imaging.VariantByName("high")
+	require.True(t, ok)
+	assert.Equal(t, 0, v.MaxWidth)
+
+	_, ok = imaging.VariantByName("custom")
+	assert.False(t, ok)
+}
@@ -0,0 +1,62 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"instant-hdr-backend/internal/config"
+	"instant-hdr-backend/internal/middleware"
+)
+
+func adminRouter(cfg *config.Config, userID string) *gin.Engine {
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		if userID != "" {
+			c.Set(middleware.UserIDKey, userID)
+		}
+		c.Next()
+	})
+	router.Use(middleware.AdminMiddleware(cfg))
+	router.GET("/admin", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	})
+	return router
+}
+
+func TestAdminMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := &config.Config{
+		AdminUserIDs: []string{"8F5C1B2E-0000-4000-8000-000000000001"},
+	}
+
+	tests := []struct {
+		name   string
+		userID string
+		want   int
+	}{
+		{"admin (case-insensitive)", "8f5c1b2e-0000-4000-8000-000000000001", http.StatusOK},
+		{"other user", "8f5c1b2e-0000-4000-8000-000000000002", http.StatusForbidden},
+		{"no user", "", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/admin", nil)
+			w := httptest.NewRecorder()
+			adminRouter(cfg, tt.userID).ServeHTTP(w, req)
+			assert.Equal(t, tt.want, w.Code)
+		})
+	}
+}
+
+func TestAdminMiddleware_NoAdminsConfigured(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	req, _ := http.NewRequest("GET", "/admin", nil)
+	w := httptest.NewRecorder()
+	adminRouter(&config.Config{}, "8f5c1b2e-0000-4000-8000-000000000001").ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
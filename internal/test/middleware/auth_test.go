@@ -19,7 +19,7 @@ func TestAuthMiddleware_NoToken(t *testing.T) {
 	}
 
 	router := gin.New()
-	router.Use(middleware.AuthMiddleware(cfg))
+	router.Use(middleware.AuthMiddleware(cfg, nil, nil))
 	router.GET("/test", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{"status": "ok"})
 	})
@@ -38,7 +38,7 @@ func TestAuthMiddleware_InvalidToken(t *testing.T) {
 	}
 
 	router := gin.New()
-	router.Use(middleware.AuthMiddleware(cfg))
+	router.Use(middleware.AuthMiddleware(cfg, nil, nil))
 	router.GET("/test", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{"status": "ok"})
 	})
@@ -64,7 +64,7 @@ func TestAuthMiddleware_ValidToken(t *testing.T) {
 	tokenString, _ := token.SignedString([]byte(cfg.SupabaseJWTSecret))
 
 	router := gin.New()
-	router.Use(middleware.AuthMiddleware(cfg))
+	router.Use(middleware.AuthMiddleware(cfg, nil, nil))
 	router.GET("/test", func(c *gin.Context) {
 		userID, exists := c.Get(middleware.UserIDKey)
 		assert.True(t, exists)
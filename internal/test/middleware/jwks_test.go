@@ -0,0 +1,220 @@
+package middleware_test
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"instant-hdr-backend/internal/config"
+	"instant-hdr-backend/internal/middleware"
+)
+
+// jwksServer publishes a key set that tests can swap out, and counts fetches
+type jwksServer struct {
+	*httptest.Server
+	mu      sync.Mutex
+	keys    []map[string]string
+	fetches int
+	fail    bool
+}
+
+func newJWKSServer(t *testing.T, keys ...map[string]string) *jwksServer {
+	s := &jwksServer{keys: keys}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.fetches++
+		if s.fail {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": s.keys})
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *jwksServer) set(keys ...map[string]string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys = keys
+}
+
+func (s *jwksServer) fetchCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.fetches
+}
+
+func b64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func rsaJWK(kid string, key *rsa.PublicKey) map[string]string {
+	return map[string]string{"kid": kid, "kty": "RSA", "alg": "RS256", "use": "sig", "n": b64(key.N.Bytes()), "e": b64(big.NewInt(int64(key.E)).Bytes())}
+}
+
+func ecJWK(kid string, key *ecdsa.PublicKey) map[string]string {
+	point, _ := key.Bytes() // 0x04 || X || Y
+	return map[string]string{"kid": kid, "kty": "EC", "crv": "P-256", "x": b64(point[1:33]), "y": b64(point[33:])}
+}
+
+func edJWK(kid string, key ed25519.PublicKey) map[string]string {
+	return map[string]string{"kid": kid, "kty": "OKP", "crv": "Ed25519", "x": b64(key)}
+}
+
+func sign(t *testing.T, method jwt.SigningMethod, kid string, key crypto.PrivateKey, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	s, err := token.SignedString(key)
+	require.NoError(t, err)
+	return s
+}
+
+func authRouter(cfg *config.Config, jwks *middleware.JWKS) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middleware.AuthMiddleware(cfg, jwks, nil))
+	router.GET("/test", func(c *gin.Context) {
+		userID, _ := c.Get(middleware.UserIDKey)
+		c.JSON(http.StatusOK, gin.H{"user_id": userID})
+	})
+	return router
+}
+
+func authorize(router *gin.Engine, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/test", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestAuthMiddleware_JWKS(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	edPublic, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	server := newJWKSServer(t, rsaJWK("rsa-1", &rsaKey.PublicKey), ecJWK("ec-1", &ecKey.PublicKey), edJWK("ed-1", edPublic),
+		map[string]string{"kid": "enc-1", "kty": "RSA", "use": "enc"})
+	cfg := &config.Config{
+		SupabaseJWTSecret: "legacy-secret-key-for-jwt-signing-must-be-long-enough",
+		JWTIssuer:         "https://auth.example.com",
+		JWTAudience:       "authenticated",
+		JWTLeeway:         30 * time.Second,
+	}
+	router := authRouter(cfg, middleware.NewJWKS(server.URL, time.Hour))
+
+	now := time.Now()
+	claims := func(overrides jwt.MapClaims) jwt.MapClaims {
+		c := jwt.MapClaims{"sub": "user-123", "iss": cfg.JWTIssuer, "aud": cfg.JWTAudience, "exp": now.Add(time.Hour).Unix(), "iat": now.Unix()}
+		for k, v := range overrides {
+			if v == nil {
+				delete(c, k)
+			} else {
+				c[k] = v
+			}
+		}
+		return c
+	}
+
+	for name, token := range map[string]string{
+		"RS256": sign(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, claims(nil)),
+		"ES256": sign(t, jwt.SigningMethodES256, "ec-1", ecKey, claims(nil)),
+		"EdDSA": sign(t, jwt.SigningMethodEdDSA, "ed-1", edKey, claims(nil)),
+		"HS256": sign(t, jwt.SigningMethodHS256, "", []byte(cfg.SupabaseJWTSecret), claims(nil)),
+		"skew":  sign(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, claims(jwt.MapClaims{"exp": now.Add(-10 * time.Second).Unix()})),
+	} {
+		w := authorize(router, token)
+		require.Equal(t, http.StatusOK, w.Code, "%s: %s", name, w.Body.String())
+		assert.JSONEq(t, `{"user_id":"user-123"}`, w.Body.String(), name)
+	}
+
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	for name, token := range map[string]string{
+		"expired":        sign(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, claims(jwt.MapClaims{"exp": now.Add(-time.Minute).Unix()})),
+		"no exp":         sign(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, claims(jwt.MapClaims{"exp": nil})),
+		"issued later":   sign(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, claims(jwt.MapClaims{"iat": now.Add(time.Hour).Unix()})),
+		"wrong issuer":   sign(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, claims(jwt.MapClaims{"iss": "https://evil.example.com"})),
+		"wrong audience": sign(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, claims(jwt.MapClaims{"aud": "anon"})),
+		"wrong key":      sign(t, jwt.SigningMethodRS256, "rsa-1", otherKey, claims(nil)),
+		"unknown kid":    sign(t, jwt.SigningMethodRS256, "rsa-2", rsaKey, claims(nil)),
+		"key alg":        sign(t, jwt.SigningMethodRS384, "rsa-1", rsaKey, claims(nil)),
+		"key type":       sign(t, jwt.SigningMethodES256, "rsa-1", ecKey, claims(nil)),
+		"encryption key": sign(t, jwt.SigningMethodRS256, "enc-1", rsaKey, claims(nil)),
+		"none":           sign(t, jwt.SigningMethodNone, "", jwt.UnsafeAllowNoneSignatureType, claims(nil)),
+	} {
+		assert.Equal(t, http.StatusUnauthorized, authorize(router, token).Code, name)
+	}
+
+	// Legacy HS256 can be turned off
+	cfg.JWTRejectHS256 = true
+	w := authorize(router, sign(t, jwt.SigningMethodHS256, "", []byte(cfg.SupabaseJWTSecret), claims(nil)))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), "invalid token algorithm")
+}
+
+func TestJWKS_RotationAndCaching(t *testing.T) {
+	oldKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	newKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	server := newJWKSServer(t, rsaJWK("old", &oldKey.PublicKey))
+	jwks := middleware.NewJWKS(server.URL, time.Hour)
+	router := authRouter(&config.Config{JWTRejectHS256: true}, jwks)
+	claims := jwt.MapClaims{"sub": "user-123", "exp": time.Now().Add(time.Hour).Unix()}
+
+	// Keys are fetched on first use and then served from the cache
+	for i := 0; i < 3; i++ {
+		require.Equal(t, http.StatusOK, authorize(router, sign(t, jwt.SigningMethodRS256, "old", oldKey, claims)).Code)
+	}
+	assert.Equal(t, 1, server.fetchCount())
+
+	// A rotated-in kid is picked up by the next refresh; unknown kids don't trigger a fetch per request
+	server.set(rsaJWK("old", &oldKey.PublicKey), rsaJWK("new", &newKey.PublicKey))
+	rotated := sign(t, jwt.SigningMethodRS256, "new", newKey, claims)
+	assert.Equal(t, http.StatusUnauthorized, authorize(router, rotated).Code)
+	assert.Equal(t, http.StatusUnauthorized, authorize(router, rotated).Code)
+	assert.Equal(t, 1, server.fetchCount())
+
+	require.NoError(t, jwks.Refresh())
+	assert.Equal(t, http.StatusOK, authorize(router, rotated).Code)
+
+	// A failing endpoint keeps the cached keys
+	server.mu.Lock()
+	server.fail = true
+	server.mu.Unlock()
+	assert.Error(t, jwks.Refresh())
+	assert.Equal(t, http.StatusOK, authorize(router, rotated).Code)
+
+	// Tokens without kid are only accepted when the set has a single key
+	server.mu.Lock()
+	server.fail = false
+	server.mu.Unlock()
+	assert.Equal(t, http.StatusUnauthorized, authorize(router, sign(t, jwt.SigningMethodRS256, "", newKey, claims)).Code)
+	server.set(rsaJWK("new", &newKey.PublicKey))
+	require.NoError(t, jwks.Refresh())
+	assert.Equal(t, http.StatusOK, authorize(router, sign(t, jwt.SigningMethodRS256, "", newKey, claims)).Code)
+}
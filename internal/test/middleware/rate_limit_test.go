@@ -0,0 +1,75 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"instant-hdr-backend/internal/middleware"
+	"instant-hdr-backend/internal/ratelimit"
+)
+
+func TestRateLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	limiter := ratelimit.NewLimiter(ratelimit.NewMemory())
+	rule := ratelimit.Rule{Limit: 2, Window: time.Hour}
+
+	router := gin.New()
+	router.GET("/health", middleware.RateLimit(limiter, "public", rule), func(c *gin.Context) { c.Status(http.StatusOK) })
+	api := router.Group("/api", func(c *gin.Context) {
+		c.Set(middleware.UserIDKey, c.GetHeader("X-User-ID"))
+	}, middleware.RateLimit(limiter, "api", rule))
+	api.GET("/orders", func(c *gin.Context) { c.Status(http.StatusOK) })
+	api.GET("/status", middleware.RateLimit(limiter, "status", ratelimit.Rule{Limit: 1, Window: time.Hour}), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	get := func(path, userID, ip string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("X-User-ID", userID)
+		req.RemoteAddr = ip + ":1234"
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := get("/api/orders", "alice", "10.0.0.1")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "2", w.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "1", w.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "2;w=3600", w.Header().Get("RateLimit-Policy"))
+	reset, err := strconv.Atoi(w.Header().Get("RateLimit-Reset"))
+	require.NoError(t, err)
+	assert.True(t, reset > 0 && reset <= 3600)
+
+	// Users are counted by ID, not by address
+	assert.Equal(t, http.StatusOK, get("/api/orders", "alice", "10.0.0.2").Code)
+	w = get("/api/orders", "alice", "10.0.0.3")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "0", w.Header().Get("RateLimit-Remaining"))
+	assert.NotEmpty(t, w.Header().Get("Retry-After"))
+	assert.Equal(t, http.StatusOK, get("/api/orders", "bob", "10.0.0.1").Code)
+
+	// Route groups have their own counters on top of the group's
+	assert.Equal(t, http.StatusOK, get("/api/status", "bob", "10.0.0.1").Code)
+	assert.Equal(t, http.StatusTooManyRequests, get("/api/status", "bob", "10.0.0.1").Code)
+
+	// Public routes are counted per IP
+	assert.Equal(t, http.StatusOK, get("/health", "", "10.0.0.9").Code)
+	assert.Equal(t, http.StatusOK, get("/health", "", "10.0.0.9").Code)
+	assert.Equal(t, http.StatusTooManyRequests, get("/health", "", "10.0.0.9").Code)
+	assert.Equal(t, http.StatusOK, get("/health", "", "10.0.0.8").Code)
+
+	// Without a limiter nothing is limited
+	open := gin.New()
+	open.GET("/", middleware.RateLimit(nil, "api", rule), func(c *gin.Context) { c.Status(http.StatusOK) })
+	for i := 0; i < 5; i++ {
+		w := httptest.NewRecorder()
+		open.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Header().Get("RateLimit-Limit"))
+	}
+}
@@ -0,0 +1,52 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"instant-hdr-backend/internal/middleware"
+)
+
+func TestRequestID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.Use(middleware.RequestID())
+	router.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, c.GetString(middleware.RequestIDKey))
+	})
+
+	tests := []struct {
+		name     string
+		incoming string
+		keep     bool
+	}{
+		{"none", "", false},
+		{"from proxy", "req-7f3a9c", true},
+		{"with spaces", "req 7f3a9c", false},
+		{"too long", strings.Repeat("a", 129), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.incoming != "" {
+				req.Header.Set(middleware.RequestIDHeader, tt.incoming)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			id := w.Header().Get(middleware.RequestIDHeader)
+			assert.Equal(t, id, w.Body.String())
+			if tt.keep {
+				assert.Equal(t, tt.incoming, id)
+			} else {
+				assert.NoError(t, uuid.Validate(id))
+			}
+		})
+	}
+}
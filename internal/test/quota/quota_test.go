@@ -0,0 +1,121 @@
+package quota_test
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"instant-hdr-backend/internal/quota"
+)
+
+type fakeStore struct {
+	plan     string
+	usage    quota.Usage
+	brackets int
+	recorded int64
+}
+
+func (f *fakeStore) GetUserPlan(userID uuid.UUID) (string, error) {
+	return f.plan, nil
+}
+
+func (f *fakeStore) GetUsage(userID uuid.UUID, periodStart time.Time) (*quota.Usage, error) {
+	usage := f.usage
+	return &usage, nil
+}
+
+func (f *fakeStore) CountBrackets(orderID uuid.UUID) (int, error) {
+	return f.brackets, nil
+}
+
+func (f *fakeStore) RecordUsageEvent(userID, orderID uuid.UUID, kind string, bytes int64) error {
+	f.recorded += bytes
+	return nil
+}
+
+func TestCheckCreateOrder(t *testing.T) {
+	free := quota.Plans["free"]
+	store := &fakeStore{usage: quota.Usage{ActiveOrders: free.MaxActiveOrders - 1}}
+	service := quota.NewService(store, true, "free")
+
+	require.NoError(t, service.CheckCreateOrder(uuid.New()))
+
+	store.usage.ActiveOrders = free.MaxActiveOrders
+	err := service.CheckCreateOrder(uuid.New())
+
+	var exceeded *quota.ExceededError
+	require.True(t, errors.As(err, &exceeded))
+	assert.Equal(t, quota.LimitActiveOrders, exceeded.Limit)
+	assert.Equal(t, "free", exceeded.Plan)
+	assert.Equal(t, http.StatusForbidden, exceeded.StatusCode())
+}
+
+func TestCheckUpload(t *testing.T) {
+	free := quota.Plans["free"]
+	store := &fakeStore{brackets: free.MaxBracketsPerOrder - 3}
+	service := quota.NewService(store, true, "free")
+
+	require.NoError(t, service.CheckUpload(uuid.New(), uuid.New(), 3, 1024))
+
+	var exceeded *quota.ExceededError
+	err := service.CheckUpload(uuid.New(), uuid.New(), 4, 1024)
+	require.True(t, errors.As(err, &exceeded))
+	assert.Equal(t, quota.LimitBracketsPerOrder, exceeded.Limit)
+	assert.Equal(t, http.StatusForbidden, exceeded.StatusCode())
+
+	store.usage.UploadedBytesThisMonth = free.MaxUploadBytesPerMonth - 100
+	err = service.CheckUpload(uuid.New(), uuid.New(), 1, 101)
+	require.True(t, errors.As(err, &exceeded))
+	assert.Equal(t, quota.LimitUploadBytesMonthly, exceeded.Limit)
+	assert.Equal(t, int64(101), exceeded.Requested)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, exceeded.StatusCode())
+}
+
+func TestCheckStore(t *testing.T) {
+	free := quota.Plans["free"]
+	store := &fakeStore{usage: quota.Usage{StoredBytes: free.MaxStoredBytes - 10}}
+	service := quota.NewService(store, true, "free")
+
+	assert.NoError(t, service.CheckStore(uuid.New(), 10))
+	assert.NoError(t, service.CheckStore(uuid.New(), 0))
+	assert.Error(t, service.CheckStore(uuid.New(), 11))
+
+	// Unknown size is rejected once the user is at the limit
+	store.usage.StoredBytes = free.MaxStoredBytes
+	assert.Error(t, service.CheckStore(uuid.New(), 0))
+}
+
+func TestPlans(t *testing.T) {
+	store := &fakeStore{plan: "unlimited", usage: quota.Usage{ActiveOrders: 1 << 20, StoredBytes: 1 << 50}}
+	service := quota.NewService(store, true, "free")
+	assert.NoError(t, service.CheckCreateOrder(uuid.New()))
+	assert.NoError(t, service.CheckStore(uuid.New(), 1<<40))
+
+	// Users without an assigned plan (or an unknown one) get the default plan
+	store.plan = ""
+	plan, err := service.PlanFor(uuid.New())
+	require.NoError(t, err)
+	assert.Equal(t, "free", plan.Name)
+
+	pro := quota.NewService(store, true, "pro")
+	plan, err = pro.PlanFor(uuid.New())
+	require.NoError(t, err)
+	assert.Equal(t, "pro", plan.Name)
+}
+
+func TestDisabledServiceAllowsEverything(t *testing.T) {
+	store := &fakeStore{usage: quota.Usage{ActiveOrders: 1000, StoredBytes: 1 << 50, UploadedBytesThisMonth: 1 << 50}, brackets: 10000}
+	service := quota.NewService(store, false, "free")
+
+	assert.NoError(t, service.CheckCreateOrder(uuid.New()))
+	assert.NoError(t, service.CheckUpload(uuid.New(), uuid.New(), 10, 1<<40))
+	assert.NoError(t, service.CheckStore(uuid.New(), 1<<40))
+
+	// Usage is still recorded so it can be reported
+	require.NoError(t, service.RecordUpload(uuid.New(), uuid.New(), 512))
+	assert.Equal(t, int64(512), store.recorded)
+}
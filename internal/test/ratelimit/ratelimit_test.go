@@ -0,0 +1,56 @@
+package ratelimit_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"instant-hdr-backend/internal/ratelimit"
+)
+
+func TestParseRule(t *testing.T) {
+	rule, err := ratelimit.ParseRule("120/1m")
+	require.NoError(t, err)
+	assert.Equal(t, ratelimit.Rule{Limit: 120, Window: time.Minute}, rule)
+	assert.Equal(t, "120;w=60", rule.Policy())
+	assert.True(t, rule.Enabled())
+
+	for _, off := range []string{"off", "0"} {
+		rule, err := ratelimit.ParseRule(off)
+		require.NoError(t, err)
+		assert.False(t, rule.Enabled(), off)
+	}
+
+	for _, bad := range []string{"", "120", "-1/1m", "x/1m", "120/forever", "120/10ms"} {
+		_, err := ratelimit.ParseRule(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestMemory_FixedWindows(t *testing.T) {
+	store := ratelimit.NewMemory()
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	for want := 1; want <= 3; want++ {
+		count, err := store.IncrementRateLimit("api:user:1", start, time.Minute)
+		require.NoError(t, err)
+		assert.Equal(t, want, count)
+	}
+
+	// Keys count separately, and the next window starts over
+	count, _ := store.IncrementRateLimit("api:user:2", start, time.Minute)
+	assert.Equal(t, 1, count)
+	count, _ = store.IncrementRateLimit("api:user:1", start.Add(time.Minute), time.Minute)
+	assert.Equal(t, 1, count)
+
+	// A late request for the previous window counts toward the current one
+	count, _ = store.IncrementRateLimit("api:user:1", start, time.Minute)
+	assert.Equal(t, 2, count)
+
+	pruned, err := store.PruneRateLimits(start.Add(90 * time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), pruned) // user 2's window ended at 12:01
+	count, _ = store.IncrementRateLimit("api:user:1", start.Add(time.Minute), time.Minute)
+	assert.Equal(t, 3, count)
+}
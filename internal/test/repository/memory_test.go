@@ -0,0 +1,80 @@
+package repository_test
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"instant-hdr-backend/internal/models"
+	"instant-hdr-backend/internal/repository"
+)
+
+func TestMemory_OrderFileVariants(t *testing.T) {
+	repo := repository.NewMemory()
+	userID, orderID := uuid.New(), uuid.New()
+	_, err := repo.CreateOrder(orderID, userID, nil)
+	require.NoError(t, err)
+
+	_, err = repo.GetOrderFileVariant(orderID, "img", "preview", "jpeg", true)
+	assert.True(t, errors.Is(err, sql.ErrNoRows), "missing variants must wrap sql.ErrNoRows")
+
+	for i := 0; i < 2; i++ {
+		require.NoError(t, repo.CreateOrderFile(&models.OrderFile{
+			OrderID:            orderID,
+			UserID:             userID,
+			Filename:           "img_preview.jpg",
+			AutoEnhanceImageID: sql.NullString{String: "img", Valid: true},
+			Variant:            sql.NullString{String: "preview", Valid: true},
+			Format:             sql.NullString{String: "jpeg", Valid: true},
+			Watermark:          sql.NullBool{Bool: true, Valid: true},
+			FileSize:           sql.NullInt64{Int64: 100, Valid: true},
+		}))
+	}
+
+	files, err := repo.GetOrderFiles(orderID, userID)
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+
+	// The newest matching row wins
+	variant, err := repo.GetOrderFileVariant(orderID, "img", "preview", "jpeg", true)
+	require.NoError(t, err)
+	assert.Equal(t, files[0].ID, variant.ID)
+
+	// Files cannot reference unknown orders (foreign key)
+	assert.Error(t, repo.CreateOrderFile(&models.OrderFile{OrderID: uuid.New(), UserID: userID}))
+
+	usage, err := repo.GetUsage(userID, time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 1, usage.ActiveOrders)
+	assert.Equal(t, int64(200), usage.StoredBytes)
+}
+
+func TestMemory_DeleteOrderCascades(t *testing.T) {
+	repo := repository.NewMemory()
+	userID, orderID := uuid.New(), uuid.New()
+	_, err := repo.CreateOrder(orderID, userID, nil)
+	require.NoError(t, err)
+	require.NoError(t, repo.CreateOrderFile(&models.OrderFile{OrderID: orderID, UserID: userID, Filename: "a.jpg"}))
+	require.NoError(t, repo.CreateBracket(&models.Bracket{OrderID: orderID, BracketID: "b1", Filename: "a.jpg"}))
+	require.NoError(t, repo.CreateOrderEvent(&models.OrderEvent{OrderID: orderID, Event: "upload_started"}))
+
+	// Only the owner can delete
+	require.NoError(t, repo.DeleteOrder(orderID, uuid.New()))
+	_, err = repo.GetOrder(orderID, userID)
+	require.NoError(t, err)
+
+	require.NoError(t, repo.DeleteOrder(orderID, userID))
+	_, err = repo.GetOrder(orderID, userID)
+	assert.Error(t, err)
+
+	files, _ := repo.GetOrderFilesByOrderID(orderID)
+	assert.Empty(t, files)
+	count, _ := repo.CountBrackets(orderID)
+	assert.Zero(t, count)
+	orderEvents, _ := repo.ListOrderEvents(orderID, 0, 10)
+	assert.Empty(t, orderEvents)
+}
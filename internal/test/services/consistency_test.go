@@ -0,0 +1,217 @@
+package services_test
+
+import (
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"instant-hdr-backend/internal/models"
+	"instant-hdr-backend/internal/repository"
+	"instant-hdr-backend/internal/services"
+	"instant-hdr-backend/internal/storage"
+)
+
+// unreadableRepo fails to read the files of one order
+type unreadableRepo struct {
+	*repository.Memory
+	orderID uuid.UUID
+}
+
+func (r *unreadableRepo) GetOrderFilesByOrderID(orderID uuid.UUID) ([]models.OrderFile, error) {
+	if orderID == r.orderID {
+		return nil, errors.New("connection reset")
+	}
+	return r.Memory.GetOrderFilesByOrderID(orderID)
+}
+
+type consistencyFixture struct {
+	root    string
+	repo    *unreadableRepo
+	storage *storage.LocalStorage
+	checker *services.ConsistencyChecker
+}
+
+func newConsistencyChecker(t *testing.T) *consistencyFixture {
+	root := t.TempDir()
+	localStorage, err := storage.NewLocalStorage(root, "http://localhost/files")
+	require.NoError(t, err)
+
+	repo := &unreadableRepo{Memory: repository.NewMemory()}
+	return &consistencyFixture{
+		root:    root,
+		repo:    repo,
+		storage: localStorage,
+		checker: services.NewConsistencyChecker(repo, localStorage),
+	}
+}
+
+func (f *consistencyFixture) order(t *testing.T) models.Order {
+	order, err := f.repo.CreateOrder(uuid.New(), uuid.New(), nil)
+	require.NoError(t, err)
+	return *order
+}
+
+// upload stores an object for the order and dates it two hours back, past DefaultMinObjectAge
+func (f *consistencyFixture) upload(t *testing.T, userID, orderID uuid.UUID, name string) string {
+	storagePath, _, err := f.storage.UploadFile(userID, orderID, name, []byte("jpeg bytes of "+name))
+	require.NoError(t, err)
+	old := time.Now().Add(-2 * time.Hour)
+	require.NoError(t, os.Chtimes(filepath.Join(f.root, filepath.FromSlash(storagePath)), old, old))
+	return storagePath
+}
+
+func (f *consistencyFixture) row(t *testing.T, order models.Order, name, storagePath string, userID uuid.UUID) models.OrderFile {
+	file := models.OrderFile{
+		OrderID:     order.ID,
+		UserID:      userID,
+		Filename:    name,
+		StoragePath: storagePath,
+		FileSize:    sql.NullInt64{Int64: 20, Valid: true},
+		IsFinal:     true,
+	}
+	require.NoError(t, f.repo.CreateOrderFile(&file))
+	return file
+}
+
+func (f *consistencyFixture) exists(storagePath string) bool {
+	_, err := f.storage.Stat(storagePath)
+	return err == nil
+}
+
+// issues indexes the report by kind
+func issues(report *models.ConsistencyReport) map[string][]models.ConsistencyIssue {
+	byKind := make(map[string][]models.ConsistencyIssue)
+	for _, issue := range report.Issues {
+		byKind[issue.Kind] = append(byKind[issue.Kind], issue)
+	}
+	return byKind
+}
+
+func TestConsistency_Repair(t *testing.T) {
+	f := newConsistencyChecker(t)
+	order := f.order(t)
+
+	kept := f.upload(t, order.UserID, order.ID, "img-1_preview.jpg")
+	f.row(t, order, "img-1_preview.jpg", kept, order.UserID)
+	orphan := f.upload(t, order.UserID, order.ID, "img-2_preview.jpg")
+	dangling := f.row(t, order, "img-3_preview.jpg", storage.OrderPath(order.UserID, order.ID, "img-3_preview.jpg"), order.UserID)
+	relinkPath := f.upload(t, order.UserID, order.ID, "img-4_preview.jpg")
+	unlinked := f.row(t, order, "img-4_preview.jpg", "", uuid.Nil)
+	lost := f.row(t, order, "img-5_preview.jpg", "", uuid.Nil)
+	noOrder := f.upload(t, order.UserID, uuid.New(), "img-1_preview.jpg")
+
+	opts := services.ConsistencyOptions{MinObjectAge: services.DefaultMinObjectAge}
+
+	// A dry run reports every issue and changes nothing
+	report := f.checker.Check(opts)
+	assert.True(t, report.DryRun)
+	assert.Empty(t, report.Errors)
+	assert.Zero(t, report.Repaired)
+	assert.Equal(t, 1, report.OrdersChecked)
+	assert.Equal(t, 4, report.ObjectsChecked)
+	assert.Equal(t, 4, report.RowsChecked)
+	byKind := issues(report)
+	require.Len(t, byKind[services.IssueOrphanedObject], 2)
+	require.Len(t, byKind[services.IssueDanglingRow], 1)
+	require.Len(t, byKind[services.IssueMissingPath], 2)
+	for _, issue := range report.Issues {
+		assert.False(t, issue.Repaired)
+	}
+	assert.True(t, f.exists(orphan))
+	assert.True(t, f.exists(noOrder))
+	files, err := f.repo.GetOrderFilesByOrderID(order.ID)
+	require.NoError(t, err)
+	assert.Len(t, files, 4)
+
+	report = f.checker.Check(services.ConsistencyOptions{Repair: true, MinObjectAge: services.DefaultMinObjectAge})
+	assert.False(t, report.DryRun)
+	assert.Empty(t, report.Errors)
+	assert.Equal(t, 5, report.Repaired)
+	byKind = issues(report)
+
+	// Objects no row points to are deleted, whether or not their order exists
+	orphans := map[string]string{}
+	for _, issue := range byKind[services.IssueOrphanedObject] {
+		assert.Equal(t, services.ActionDeleteObject, issue.Action)
+		assert.True(t, issue.Repaired)
+		orphans[issue.StoragePath] = issue.Detail
+	}
+	assert.Equal(t, map[string]string{
+		orphan:  "no order_files row references this object",
+		noOrder: "order does not exist",
+	}, orphans)
+	assert.False(t, f.exists(orphan))
+	assert.False(t, f.exists(noOrder))
+	assert.True(t, f.exists(kept))
+
+	// Rows whose object is gone are deleted
+	require.Len(t, byKind[services.IssueDanglingRow], 1)
+	assert.Equal(t, dangling.ID.String(), byKind[services.IssueDanglingRow][0].FileID)
+	assert.Equal(t, services.ActionDeleteRow, byKind[services.IssueDanglingRow][0].Action)
+	_, err = f.repo.GetOrderFile(dangling.ID, order.UserID)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	// A row missing its path is relinked to the object at the expected path, or deleted when there is none
+	actions := map[string]string{}
+	for _, issue := range byKind[services.IssueMissingPath] {
+		assert.True(t, issue.Repaired)
+		actions[issue.FileID] = issue.Action
+	}
+	assert.Equal(t, map[string]string{
+		unlinked.ID.String(): services.ActionRelinkRow,
+		lost.ID.String():     services.ActionDeleteRow,
+	}, actions)
+	relinked, err := f.repo.GetOrderFile(unlinked.ID, order.UserID)
+	require.NoError(t, err)
+	assert.Equal(t, relinkPath, relinked.StoragePath)
+	assert.True(t, f.exists(relinkPath))
+	_, err = f.repo.GetOrderFile(lost.ID, order.UserID)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	// Nothing is left to repair
+	report = f.checker.Check(services.ConsistencyOptions{Repair: true, MinObjectAge: services.DefaultMinObjectAge})
+	assert.Empty(t, report.Issues)
+	assert.Empty(t, report.Errors)
+}
+
+func TestConsistency_KeepsFreshUploads(t *testing.T) {
+	f := newConsistencyChecker(t)
+	order := f.order(t)
+	old := f.upload(t, order.UserID, order.ID, "img-1_preview.jpg")
+
+	// Uploaded just now: its row may still be on its way
+	fresh, _, err := f.storage.UploadFile(order.UserID, order.ID, "img-2_preview.jpg", []byte("jpeg"))
+	require.NoError(t, err)
+
+	report := f.checker.Check(services.ConsistencyOptions{Repair: true, MinObjectAge: services.DefaultMinObjectAge})
+	assert.Empty(t, report.Errors)
+	require.Len(t, report.Issues, 1)
+	assert.Equal(t, old, report.Issues[0].StoragePath)
+	assert.False(t, f.exists(old))
+	assert.True(t, f.exists(fresh))
+}
+
+func TestConsistency_SkipsUnreadableOrders(t *testing.T) {
+	f := newConsistencyChecker(t)
+	unreadable, readable := f.order(t), f.order(t)
+	f.repo.orderID = unreadable.ID
+
+	// The rows of the first order can't be read, so its objects may well be referenced
+	stored := f.upload(t, unreadable.UserID, unreadable.ID, "img-1_preview.jpg")
+	f.row(t, unreadable, "img-1_preview.jpg", stored, unreadable.UserID)
+	orphan := f.upload(t, readable.UserID, readable.ID, "img-1_preview.jpg")
+
+	report := f.checker.Check(services.ConsistencyOptions{Repair: true, MinObjectAge: services.DefaultMinObjectAge})
+	require.Len(t, report.Errors, 1)
+	assert.Contains(t, report.Errors[0], unreadable.ID.String())
+	require.Len(t, report.Issues, 1)
+	assert.Equal(t, orphan, report.Issues[0].StoragePath)
+	assert.True(t, f.exists(stored))
+	assert.False(t, f.exists(orphan))
+}
@@ -0,0 +1,166 @@
+package services_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"instant-hdr-backend/internal/events"
+	"instant-hdr-backend/internal/repository"
+	"instant-hdr-backend/internal/services"
+)
+
+// flakySink fails the first fails publishes, like Supabase broadcast during an outage
+type flakySink struct {
+	mu        sync.Mutex
+	fails     int
+	channels  []string
+	published []map[string]interface{}
+}
+
+func (s *flakySink) PublishEvent(channel, event string, payload map[string]interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.fails > 0 {
+		s.fails--
+		return errors.New("broadcast unavailable")
+	}
+	s.channels = append(s.channels, channel)
+	s.published = append(s.published, payload)
+	return nil
+}
+
+// flakyTransport fails the first fails sends, like a dropped LISTEN/NOTIFY connection, and loops the others back
+type flakyTransport struct {
+	mu       sync.Mutex
+	fails    int
+	receiver events.Receiver
+}
+
+func (tr *flakyTransport) Start(receiver events.Receiver) error {
+	tr.receiver = receiver
+	return nil
+}
+
+func (tr *flakyTransport) Send(e events.Event) error {
+	tr.mu.Lock()
+	if tr.fails > 0 {
+		tr.fails--
+		tr.mu.Unlock()
+		return errors.New("connection refused")
+	}
+	tr.mu.Unlock()
+	tr.receiver.Receive(e)
+	return nil
+}
+
+func TestEventDispatcher_Retries(t *testing.T) {
+	repo := repository.NewMemory()
+	bus := events.NewBus(10)
+	sink := &flakySink{fails: 1}
+	dispatcher := services.NewEventDispatcher(repo, bus, sink, time.Hour)
+
+	userID := uuid.New()
+	order, err := repo.CreateOrder(uuid.New(), userID, nil)
+	require.NoError(t, err)
+	sub, _, _ := bus.Subscribe(events.OrderChannel(order.ID), 0)
+	defer sub.Close()
+	userSub, _, _ := bus.Subscribe(events.UserChannel(userID), 0)
+	defer userSub.Close()
+
+	require.NoError(t, dispatcher.PublishError(order.ID, "image processing failed", &events.ProcessingFailed{Error: "image processing failed"}))
+	stored, err := repo.GetOrder(order.ID, userID)
+	require.NoError(t, err)
+	assert.Equal(t, "failed", stored.Status)
+
+	// Subscribers of the order and of its owner get the event on the first attempt even though broadcast failed
+	assert.Zero(t, dispatcher.Dispatch())
+	e := <-sub.Events()
+	assert.Equal(t, uint64(1), e.ID)
+	assert.Equal(t, events.EventProcessingFailed, e.Name)
+	mirrored := <-userSub.Events()
+	assert.Equal(t, e.Data, mirrored.Data)
+	assert.Contains(t, string(mirrored.Data), `"status":"failed"`)
+
+	// The retry waits for its backoff
+	assert.Zero(t, dispatcher.Dispatch())
+	retry, err := repo.ClaimOrderEvents(time.Now().Add(time.Minute), time.Minute, 10)
+	require.NoError(t, err)
+	require.Len(t, retry, 1)
+	assert.Equal(t, 2, retry[0].Attempts)
+	assert.Equal(t, "broadcast unavailable", retry[0].LastError.String)
+
+	past := time.Now().Add(-time.Second)
+	require.NoError(t, repo.MarkOrderEventFailed(retry[0].ID, retry[0].LastError.String, &past))
+	assert.Equal(t, 1, dispatcher.Dispatch())
+	assert.Equal(t, []string{events.OrderChannel(order.ID), events.UserChannel(userID)}, sink.channels)
+	assert.Equal(t, float64(1), sink.published[0]["seq"])
+	assert.Equal(t, float64(events.SchemaVersion), sink.published[0]["version"])
+	select {
+	case e := <-sub.Events():
+		t.Fatalf("retry delivered %s to subscribers again", e.Name)
+	default:
+	}
+
+	// Invalid events are not stored, but the order still changes
+	assert.Error(t, dispatcher.PublishStatus(order.ID, "previews_ready", 100, &events.DownloadReady{}))
+	stored, err = repo.GetOrder(order.ID, userID)
+	require.NoError(t, err)
+	assert.Equal(t, "previews_ready", stored.Status)
+
+	// Dispatched events are done, but stay readable for catching up
+	assert.Zero(t, dispatcher.Dispatch())
+	listed, err := repo.ListOrderEvents(order.ID, 0, 10)
+	require.NoError(t, err)
+	require.Len(t, listed, 1)
+	assert.True(t, listed[0].DispatchedAt.Valid)
+}
+
+func TestEventDispatcher_RetriesDelivery(t *testing.T) {
+	repo := repository.NewMemory()
+	bus := events.NewBus(10)
+	require.NoError(t, bus.Connect(&flakyTransport{fails: 1}))
+	sink := &flakySink{}
+	dispatcher := services.NewEventDispatcher(repo, bus, sink, time.Hour)
+
+	userID := uuid.New()
+	order, err := repo.CreateOrder(uuid.New(), userID, nil)
+	require.NoError(t, err)
+	sub, _, _ := bus.Subscribe(events.OrderChannel(order.ID), 0)
+	defer sub.Close()
+
+	// The bus is down: broadcast still goes out, and delivery waits for a retry
+	require.NoError(t, dispatcher.PublishStatus(order.ID, "processing", 10, &events.ProcessingStarted{}))
+	assert.Zero(t, dispatcher.Dispatch())
+	assert.Len(t, sink.published, 2)
+	select {
+	case e := <-sub.Events():
+		t.Fatalf("delivered %s through a failed bus", e.Name)
+	default:
+	}
+
+	retry, err := repo.ClaimOrderEvents(time.Now().Add(time.Minute), time.Minute, 10)
+	require.NoError(t, err)
+	require.Len(t, retry, 1)
+	assert.Contains(t, retry[0].LastError.String, "connection refused")
+	assert.False(t, retry[0].DeliveredAt.Valid)
+	assert.True(t, retry[0].BroadcastAt.Valid)
+
+	// The retry delivers the event without broadcasting it again
+	past := time.Now().Add(-time.Second)
+	require.NoError(t, repo.MarkOrderEventFailed(retry[0].ID, retry[0].LastError.String, &past))
+	assert.Equal(t, 1, dispatcher.Dispatch())
+	e := <-sub.Events()
+	assert.Equal(t, events.EventProcessingStarted, e.Name)
+	assert.Len(t, sink.published, 2)
+
+	listed, err := repo.ListOrderEvents(order.ID, 0, 10)
+	require.NoError(t, err)
+	require.Len(t, listed, 1)
+	assert.True(t, listed[0].DeliveredAt.Valid)
+	assert.True(t, listed[0].DispatchedAt.Valid)
+}
@@ -0,0 +1,168 @@
+package services_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"image/jpeg"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"instant-hdr-backend/internal/autoenhance"
+	"instant-hdr-backend/internal/credits"
+	"instant-hdr-backend/internal/models"
+	"instant-hdr-backend/internal/repository"
+	"instant-hdr-backend/internal/services"
+	"instant-hdr-backend/internal/storage"
+)
+
+// enhanceServer serves a 640x480 JPEG for every image but img-corrupt, whose download is not an image, and counts downloads
+type enhanceServer struct {
+	downloads atomic.Int32
+	delay     time.Duration
+}
+
+func (s *enhanceServer) start(t *testing.T) *autoenhance.Client {
+	var enhanced bytes.Buffer
+	require.NoError(t, jpeg.Encode(&enhanced, image.NewRGBA(image.Rect(0, 0, 640, 480)), nil))
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := strings.TrimPrefix(r.URL.Path, "/v3/images/")
+		imageID, isDownload := strings.CutSuffix(path, "/enhanced")
+		if !isDownload {
+			json.NewEncoder(w).Encode(map[string]interface{}{"image_id": imageID})
+			return
+		}
+		s.downloads.Add(1)
+		time.Sleep(s.delay)
+		if imageID == "img-corrupt" {
+			w.Write([]byte("<html>gateway timeout</html>"))
+			return
+		}
+		w.Write(enhanced.Bytes())
+	}))
+	t.Cleanup(server.Close)
+	return autoenhance.NewClient(server.URL, "test-key")
+}
+
+type imageFixture struct {
+	ae      *enhanceServer
+	repo    *repository.Memory
+	credits *credits.Service
+	service *services.ImageService
+	order   *models.Order
+}
+
+func newImageService(t *testing.T, delay time.Duration) *imageFixture {
+	repo := repository.NewMemory()
+	localStorage, err := storage.NewLocalStorage(t.TempDir(), "http://localhost/files")
+	require.NoError(t, err)
+	order, err := repo.CreateOrder(uuid.New(), uuid.New(), nil)
+	require.NoError(t, err)
+
+	ae := &enhanceServer{delay: delay}
+	creditService := credits.NewService(repo, true, 0)
+	return &imageFixture{
+		ae:      ae,
+		repo:    repo,
+		credits: creditService,
+		service: services.NewImageService(ae.start(t), repo, localStorage, storage.NewURLResolver(localStorage, false, 0), nil, creditService),
+		order:   order,
+	}
+}
+
+func (f *imageFixture) request(imageID, quality, format string) services.VariantRequest {
+	return services.VariantRequest{Order: f.order, ImageID: imageID, Quality: quality, Format: format, Watermark: true}
+}
+
+func TestImageService_DownloadsOnce(t *testing.T) {
+	f := newImageService(t, 0)
+
+	// The first request downloads the high-res image and generates the standard sizes from it
+	result, err := f.service.EnsureVariant(f.request("img-1", "preview", "jpeg"))
+	require.NoError(t, err)
+	assert.Equal(t, services.VariantSourceGenerated, result.Source)
+	assert.False(t, result.CreditUsed)
+	assert.Equal(t, int32(1), f.ae.downloads.Load())
+	high, err := f.repo.GetOrderFileVariant(f.order.ID, "img-1", "high", "jpeg", true)
+	require.NoError(t, err)
+	assert.Equal(t, int64(640), high.Width.Int64)
+	assert.Equal(t, uuid.NullUUID{UUID: high.ID, Valid: true}, result.File.ParentFileID)
+
+	// Every other size and format is served or generated locally
+	width := 100
+	for _, req := range []services.VariantRequest{
+		f.request("img-1", "thumbnail", "jpeg"),
+		f.request("img-1", "preview", "png"),
+		{Order: f.order, ImageID: "img-1", Quality: "custom", MaxWidth: &width, Format: "jpeg", Watermark: true},
+	} {
+		result, err := f.service.EnsureVariant(req)
+		require.NoError(t, err, req.Quality)
+		assert.NotEqual(t, services.VariantSourceAutoEnhance, result.Source, req.Quality)
+		assert.Equal(t, high.ID, result.File.ParentFileID.UUID, req.Quality)
+	}
+	result, err = f.service.EnsureVariant(f.request("img-1", "high", "jpeg"))
+	require.NoError(t, err)
+	assert.Equal(t, services.VariantSourceCache, result.Source)
+	assert.Equal(t, int32(1), f.ae.downloads.Load())
+}
+
+func TestImageService_ConcurrentRequests(t *testing.T) {
+	f := newImageService(t, 50*time.Millisecond)
+
+	var wg sync.WaitGroup
+	for _, quality := range []string{"thumbnail", "preview", "medium", "high", "preview", "high"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := f.service.EnsureVariant(f.request("img-1", quality, "jpeg"))
+			assert.NoError(t, err, quality)
+		}()
+	}
+	wg.Wait()
+
+	// Requests for a new image wait for the one downloading it
+	assert.Equal(t, int32(1), f.ae.downloads.Load())
+	files, err := f.repo.GetOrderFilesByOrderID(f.order.ID)
+	require.NoError(t, err)
+	highs := 0
+	for _, file := range files {
+		if file.Variant.String == "high" {
+			highs++
+		}
+	}
+	assert.Equal(t, 1, highs)
+}
+
+func TestImageService_RefundsUndecodableDownload(t *testing.T) {
+	f := newImageService(t, 0)
+	_, err := f.repo.GrantCredits(f.order.UserID, 1, "test", uuid.NullUUID{})
+	require.NoError(t, err)
+
+	req := f.request("img-corrupt", "preview", "jpeg")
+	req.Watermark = false
+	_, err = f.service.EnsureVariant(req)
+	require.Error(t, err)
+	assert.Equal(t, int32(1), f.ae.downloads.Load())
+
+	// The credit is given back, so it still pays for a download that works
+	account, err := f.credits.Account(f.order.UserID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, account.Balance)
+
+	req.ImageID = "img-1"
+	result, err := f.service.EnsureVariant(req)
+	require.NoError(t, err)
+	assert.True(t, result.CreditUsed)
+	account, err = f.credits.Account(f.order.UserID)
+	require.NoError(t, err)
+	assert.Zero(t, account.Balance)
+}
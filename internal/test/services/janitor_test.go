@@ -0,0 +1,206 @@
+package services_test
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"instant-hdr-backend/internal/models"
+	"instant-hdr-backend/internal/repository"
+	"instant-hdr-backend/internal/services"
+	"instant-hdr-backend/internal/storage"
+)
+
+// failingRepo fails to delete order rows and file rows while fail is set, after storage was already purged
+type failingRepo struct {
+	*repository.Memory
+	fail bool
+}
+
+func (r *failingRepo) DeleteOrder(orderID, userID uuid.UUID) error {
+	if r.fail {
+		return errors.New("connection reset")
+	}
+	return r.Memory.DeleteOrder(orderID, userID)
+}
+
+func (r *failingRepo) DeleteOrderFilesByOrderID(orderID uuid.UUID) (int64, error) {
+	if r.fail {
+		return 0, errors.New("connection reset")
+	}
+	return r.Memory.DeleteOrderFilesByOrderID(orderID)
+}
+
+type janitorFixture struct {
+	repo    *failingRepo
+	storage *storage.LocalStorage
+	janitor *services.Janitor
+}
+
+// newJanitor keeps previews and trashed orders for 30 days. Records are created 40 days ago until the clock is reset.
+func newJanitor(t *testing.T) *janitorFixture {
+	repo := &failingRepo{Memory: repository.NewMemory()}
+	repo.SetClock(func() time.Time { return time.Now().AddDate(0, 0, -40) })
+
+	localStorage, err := storage.NewLocalStorage(t.TempDir(), "http://localhost/files")
+	require.NoError(t, err)
+
+	purger := services.NewOrderPurger(nil, repo, localStorage)
+	policy := services.RetentionPolicy{PreviewDays: 30, DeletedOrderDays: 30}
+	return &janitorFixture{
+		repo:    repo,
+		storage: localStorage,
+		janitor: services.NewJanitor(repo, localStorage, purger, policy, time.Hour),
+	}
+}
+
+func (f *janitorFixture) order(t *testing.T) models.Order {
+	order, err := f.repo.CreateOrder(uuid.New(), uuid.New(), nil)
+	require.NoError(t, err)
+	return *order
+}
+
+func (f *janitorFixture) storeFile(t *testing.T, order models.Order, name string, watermark bool) models.OrderFile {
+	data := []byte("jpeg bytes of " + name)
+	storagePath, _, err := f.storage.UploadFile(order.UserID, order.ID, name, data)
+	require.NoError(t, err)
+
+	file := models.OrderFile{
+		OrderID:     order.ID,
+		UserID:      order.UserID,
+		Filename:    name,
+		StoragePath: storagePath,
+		FileSize:    sql.NullInt64{Int64: int64(len(data)), Valid: true},
+		IsFinal:     true,
+		Watermark:   sql.NullBool{Bool: watermark, Valid: true},
+	}
+	require.NoError(t, f.repo.CreateOrderFile(&file))
+	return file
+}
+
+func (f *janitorFixture) objects(t *testing.T, order models.Order) int {
+	objects, err := f.storage.ListFiles(storage.OrderPrefix(order.UserID, order.ID))
+	require.NoError(t, err)
+	return len(objects)
+}
+
+func (f *janitorFixture) rows(t *testing.T, order models.Order) int {
+	files, err := f.repo.GetOrderFilesByOrderID(order.ID)
+	require.NoError(t, err)
+	return len(files)
+}
+
+func TestJanitor_ExpirePreviews(t *testing.T) {
+	f := newJanitor(t)
+	order := f.order(t)
+	preview := f.storeFile(t, order, "img-1_preview.jpg", true)
+	f.storeFile(t, order, "img-1_high_unwatermarked.jpg", false)
+	f.repo.SetClock(time.Now)
+	f.storeFile(t, order, "img-2_preview.jpg", true)
+
+	// Only the old watermarked preview is due; paid finals are kept however old
+	report := f.janitor.Run(true, uuid.NullUUID{})
+	assert.True(t, report.DryRun)
+	assert.Empty(t, report.Errors)
+	require.Len(t, report.ExpiredFiles, 1)
+	assert.Equal(t, preview.ID.String(), report.ExpiredFiles[0].FileID)
+	assert.Equal(t, 1, report.FilesDeleted)
+	assert.Equal(t, preview.FileSize.Int64, report.BytesFreed)
+	assert.Equal(t, 3, f.objects(t, order), "a dry run deletes nothing")
+	assert.Equal(t, 3, f.rows(t, order))
+
+	report = f.janitor.Run(false, uuid.NullUUID{})
+	assert.Empty(t, report.Errors)
+	assert.Equal(t, 1, report.FilesDeleted)
+	assert.Equal(t, 2, f.objects(t, order))
+	assert.Equal(t, 2, f.rows(t, order))
+	_, err := f.repo.GetOrderFile(preview.ID, order.UserID)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	assert.Zero(t, f.janitor.Run(false, uuid.NullUUID{}).FilesDeleted)
+}
+
+func TestJanitor_PurgeDeletedOrders(t *testing.T) {
+	f := newJanitor(t)
+	trashed, deleted, recent := f.order(t), f.order(t), f.order(t)
+	for _, order := range []models.Order{trashed, deleted, recent} {
+		f.storeFile(t, order, "img-1_high_unwatermarked.jpg", false)
+	}
+	require.NoError(t, f.repo.TrashOrder(trashed.ID, trashed.UserID))
+	require.NoError(t, f.repo.SyncAutoEnhanceOrderData(deleted.ID, "Deleted in AutoEnhance", "processed", false, false, true, 1, nil))
+	f.repo.SetClock(time.Now)
+	require.NoError(t, f.repo.TrashOrder(recent.ID, recent.UserID))
+
+	report := f.janitor.Run(true, uuid.NullUUID{})
+	assert.Empty(t, report.Errors)
+	require.Len(t, report.PurgedOrders, 2)
+	assert.Equal(t, 2, report.FilesDeleted)
+	purged := map[string]bool{}
+	for _, item := range report.PurgedOrders {
+		purged[item.OrderID] = item.Permanent
+	}
+	assert.Equal(t, map[string]bool{trashed.ID.String(): true, deleted.ID.String(): false}, purged)
+	assert.Equal(t, 1, f.objects(t, trashed), "a dry run deletes nothing")
+	assert.Equal(t, 1, f.objects(t, deleted))
+
+	report = f.janitor.Run(false, uuid.NullUUID{})
+	assert.Empty(t, report.Errors)
+	assert.Len(t, report.PurgedOrders, 2)
+
+	// The trashed order is gone entirely
+	_, err := f.repo.GetTrashedOrder(trashed.ID, trashed.UserID)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	assert.Zero(t, f.objects(t, trashed))
+
+	// The order deleted in AutoEnhance keeps its row, loses its files and is not listed again
+	_, err = f.repo.GetOrder(deleted.ID, deleted.UserID)
+	assert.NoError(t, err)
+	assert.Zero(t, f.objects(t, deleted))
+	assert.Zero(t, f.rows(t, deleted))
+	purgeable, err := f.repo.ListPurgeableOrders(time.Now(), uuid.NullUUID{})
+	require.NoError(t, err)
+	require.Len(t, purgeable, 1)
+	assert.Equal(t, recent.ID, purgeable[0].ID)
+
+	// The order trashed recently is kept
+	assert.Equal(t, 1, f.objects(t, recent))
+	assert.Empty(t, f.janitor.Run(false, uuid.NullUUID{}).PurgedOrders)
+}
+
+func TestJanitor_PartialFailure(t *testing.T) {
+	f := newJanitor(t)
+	trashed, deleted := f.order(t), f.order(t)
+	f.storeFile(t, trashed, "img-1_high_unwatermarked.jpg", false)
+	f.storeFile(t, deleted, "img-1_high_unwatermarked.jpg", false)
+	require.NoError(t, f.repo.TrashOrder(trashed.ID, trashed.UserID))
+	require.NoError(t, f.repo.SyncAutoEnhanceOrderData(deleted.ID, "Deleted in AutoEnhance", "processed", false, false, true, 1, nil))
+	f.repo.SetClock(time.Now)
+
+	// Storage is purged but the rows can't be deleted: both orders are reported and left for the next run
+	f.repo.fail = true
+	report := f.janitor.Run(false, uuid.NullUUID{})
+	require.Len(t, report.Errors, 2)
+	assert.Contains(t, report.Errors[0], "files deleted but row remains")
+	assert.Contains(t, report.Errors[1], "storage purged but rows remain")
+	assert.Zero(t, f.objects(t, trashed))
+	assert.Zero(t, f.objects(t, deleted))
+	_, err := f.repo.GetTrashedOrder(trashed.ID, trashed.UserID)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, f.rows(t, deleted))
+
+	f.repo.fail = false
+	report = f.janitor.Run(false, uuid.NullUUID{})
+	assert.Empty(t, report.Errors)
+	assert.Len(t, report.PurgedOrders, 2)
+	_, err = f.repo.GetTrashedOrder(trashed.ID, trashed.UserID)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	assert.Zero(t, f.rows(t, deleted))
+
+	purgeable, err := f.repo.ListPurgeableOrders(time.Now(), uuid.NullUUID{})
+	require.NoError(t, err)
+	assert.Empty(t, purgeable)
+}
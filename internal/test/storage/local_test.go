@@ -0,0 +1,133 @@
+package storage_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"instant-hdr-backend/internal/storage"
+)
+
+func TestLocalStorage_UploadDownloadDelete(t *testing.T) {
+	store, err := storage.NewLocalStorage(t.TempDir(), "http://localhost:8080/files")
+	require.NoError(t, err)
+
+	userID := uuid.New()
+	orderID := uuid.New()
+
+	storagePath, publicURL, err := store.UploadFile(userID, orderID, "img_preview.jpg", []byte("jpeg-data"))
+	require.NoError(t, err)
+	assert.Equal(t, "users/"+userID.String()+"/orders/"+orderID.String()+"/img_preview.jpg", storagePath)
+	assert.Equal(t, "http://localhost:8080/files/"+storagePath, publicURL)
+
+	body, obj, err := store.Download(storagePath)
+	require.NoError(t, err)
+	data, _ := io.ReadAll(body)
+	body.Close()
+	assert.Equal(t, "jpeg-data", string(data))
+	assert.Equal(t, int64(9), obj.Size)
+	assert.Equal(t, "image/jpeg", obj.ContentType)
+
+	objects, err := store.ListFiles(storage.OrderPrefix(userID, orderID))
+	require.NoError(t, err)
+	require.Len(t, objects, 1)
+	assert.Equal(t, storagePath, objects[0].Path)
+
+	require.NoError(t, store.DeleteOrderFiles(userID, orderID))
+	objects, err = store.ListFiles(storage.OrderPrefix(userID, orderID))
+	require.NoError(t, err)
+	assert.Empty(t, objects)
+}
+
+func TestLocalStorage_RejectsPathTraversal(t *testing.T) {
+	store, err := storage.NewLocalStorage(t.TempDir(), "http://localhost:8080/files")
+	require.NoError(t, err)
+
+	_, err = store.DownloadFile("users/../../etc/passwd")
+	assert.Error(t, err)
+}
+
+func TestLocalStorage_Handler(t *testing.T) {
+	store, err := storage.NewLocalStorage(t.TempDir(), "http://localhost:8080/files")
+	require.NoError(t, err)
+
+	storagePath, _, err := store.UploadFile(uuid.New(), uuid.New(), "a.png", []byte("png-data"))
+	require.NoError(t, err)
+
+	handler := http.StripPrefix("/files", store.Handler())
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/files/"+storagePath, nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "png-data", w.Body.String())
+
+	// Directory listings are not exposed
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/files/users/", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestLocalStorage_SignedURLs(t *testing.T) {
+	store, err := storage.NewLocalStorage(t.TempDir(), "http://localhost:8080/files")
+	require.NoError(t, err)
+	store.RequireSignedURLs([]byte("test-signing-key"))
+
+	storagePath, publicURL, err := store.UploadFile(uuid.New(), uuid.New(), "a.jpg", []byte("jpeg-data"))
+	require.NoError(t, err)
+
+	handler := http.StripPrefix("/files", store.Handler())
+
+	// Unsigned public URLs are rejected for private storage
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", strings.TrimPrefix(publicURL, "http://localhost:8080"), nil))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	signedURL, err := store.SignedURL(storagePath, time.Minute)
+	require.NoError(t, err)
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", strings.TrimPrefix(signedURL, "http://localhost:8080"), nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "jpeg-data", w.Body.String())
+
+	// Expired URLs are rejected
+	expiredURL, err := store.SignedURL(storagePath, -time.Minute)
+	require.NoError(t, err)
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", strings.TrimPrefix(expiredURL, "http://localhost:8080"), nil))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// A signature for one file does not unlock another
+	otherPath, _, err := store.UploadFile(uuid.New(), uuid.New(), "b.jpg", []byte("other"))
+	require.NoError(t, err)
+	tampered := strings.Replace(signedURL, storagePath, otherPath, 1)
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", strings.TrimPrefix(tampered, "http://localhost:8080"), nil))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestURLResolver(t *testing.T) {
+	store, err := storage.NewLocalStorage(t.TempDir(), "http://localhost:8080/files")
+	require.NoError(t, err)
+	store.RequireSignedURLs([]byte("test-signing-key"))
+
+	public := storage.NewURLResolver(store, false, time.Hour)
+	assert.Equal(t, "http://localhost:8080/files/x.jpg", public.StoredURL("http://localhost:8080/files/x.jpg"))
+	url, expiresAt, err := public.Resolve("x.jpg", "http://localhost:8080/files/x.jpg")
+	require.NoError(t, err)
+	assert.Equal(t, "http://localhost:8080/files/x.jpg", url)
+	assert.Nil(t, expiresAt)
+
+	private := storage.NewURLResolver(store, true, time.Hour)
+	assert.Empty(t, private.StoredURL("http://localhost:8080/files/x.jpg"))
+	url, expiresAt, err = private.Resolve("x.jpg", "")
+	require.NoError(t, err)
+	assert.Contains(t, url, "signature=")
+	require.NotNil(t, expiresAt)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), *expiresAt, time.Minute)
+}
@@ -0,0 +1,69 @@
+package storage_test
+
+import (
+	"io"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"instant-hdr-backend/internal/storage"
+)
+
+func TestParseRange(t *testing.T) {
+	tests := []struct {
+		header string
+		want   *storage.ByteRange
+		err    error
+	}{
+		{header: "", want: nil},
+		{header: "bytes=0-99", want: &storage.ByteRange{Offset: 0, Length: 100}},
+		{header: "bytes=100-", want: &storage.ByteRange{Offset: 100, Length: 900}},
+		{header: "bytes=-200", want: &storage.ByteRange{Offset: 800, Length: 200}},
+		{header: "bytes=-5000", want: &storage.ByteRange{Offset: 0, Length: 1000}},
+		{header: "bytes=900-5000", want: &storage.ByteRange{Offset: 900, Length: 100}},
+		{header: "bytes=0-1,5-6", want: nil}, // Multiple ranges: serve the whole object
+		{header: "items=0-1", want: nil},
+		{header: "bytes=1000-", err: storage.ErrRangeNotSatisfiable},
+		{header: "bytes=50-10", err: storage.ErrRangeNotSatisfiable},
+		{header: "bytes=abc", err: storage.ErrRangeNotSatisfiable},
+	}
+
+	for _, tt := range tests {
+		got, err := storage.ParseRange(tt.header, 1000)
+		if tt.err != nil {
+			assert.ErrorIs(t, err, tt.err, tt.header)
+			continue
+		}
+		require.NoError(t, err, tt.header)
+		assert.Equal(t, tt.want, got, tt.header)
+	}
+
+	assert.Equal(t, "bytes 100-199/1000", storage.ByteRange{Offset: 100, Length: 100}.ContentRange(1000))
+}
+
+func TestLocalStorage_DownloadRange(t *testing.T) {
+	store, err := storage.NewLocalStorage(t.TempDir(), "http://localhost:8080/files")
+	require.NoError(t, err)
+
+	storagePath, _, err := store.UploadFile(uuid.New(), uuid.New(), "a.jpg", []byte("0123456789"))
+	require.NoError(t, err)
+
+	body, obj, err := store.DownloadRange(storagePath, 2, 3)
+	require.NoError(t, err)
+	data, _ := io.ReadAll(body)
+	body.Close()
+	assert.Equal(t, "234", string(data))
+	assert.Equal(t, int64(10), obj.Size)
+
+	body, _, err = store.DownloadRange(storagePath, 7, -1)
+	require.NoError(t, err)
+	data, _ = io.ReadAll(body)
+	body.Close()
+	assert.Equal(t, "789", string(data))
+
+	stat, err := store.Stat(storagePath)
+	require.NoError(t, err)
+	assert.Equal(t, int64(10), stat.Size)
+	assert.NotEmpty(t, stat.ETag)
+}
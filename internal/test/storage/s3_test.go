@@ -0,0 +1,111 @@
+package storage_test
+
+import (
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"instant-hdr-backend/internal/storage"
+)
+
+// fakeS3 is a minimal path-style S3 server: PUT/GET/DELETE objects, ListObjectsV2 and multi-object delete
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=test-key/") {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	key := strings.TrimPrefix(r.URL.Path, "/bucket")
+	key = strings.TrimPrefix(key, "/")
+
+	switch {
+	case r.Method == http.MethodPut:
+		data, _ := io.ReadAll(r.Body)
+		f.objects[key] = data
+	case r.Method == http.MethodGet && key == "":
+		type content struct {
+			Key  string `xml:"Key"`
+			Size int64  `xml:"Size"`
+		}
+		result := struct {
+			XMLName  xml.Name  `xml:"ListBucketResult"`
+			Contents []content `xml:"Contents"`
+		}{}
+		for k, v := range f.objects {
+			if strings.HasPrefix(k, r.URL.Query().Get("prefix")) {
+				result.Contents = append(result.Contents, content{Key: k, Size: int64(len(v))})
+			}
+		}
+		xml.NewEncoder(w).Encode(result)
+	case r.Method == http.MethodGet:
+		data, ok := f.objects[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write(data)
+	case r.Method == http.MethodPost && r.URL.Query().Has("delete"):
+		var req struct {
+			Objects []struct {
+				Key string `xml:"Key"`
+			} `xml:"Object"`
+		}
+		xml.NewDecoder(r.Body).Decode(&req)
+		for _, o := range req.Objects {
+			delete(f.objects, o.Key)
+		}
+	case r.Method == http.MethodDelete:
+		delete(f.objects, key)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func TestS3Storage_RoundTrip(t *testing.T) {
+	fake := &fakeS3{objects: make(map[string][]byte)}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	store, err := storage.NewS3Storage(storage.S3Config{
+		Endpoint:        server.URL,
+		Bucket:          "bucket",
+		AccessKeyID:     "test-key",
+		SecretAccessKey: "test-secret",
+		ForcePathStyle:  true,
+	})
+	require.NoError(t, err)
+
+	userID := uuid.New()
+	orderID := uuid.New()
+
+	storagePath, publicURL, err := store.UploadFile(userID, orderID, "img_high.jpg", []byte("high-res"))
+	require.NoError(t, err)
+	assert.Equal(t, server.URL+"/bucket/"+storagePath, publicURL)
+
+	data, err := store.DownloadFile(storagePath)
+	require.NoError(t, err)
+	assert.Equal(t, "high-res", string(data))
+
+	objects, err := store.ListFiles(storage.OrderPrefix(userID, orderID))
+	require.NoError(t, err)
+	require.Len(t, objects, 1)
+	assert.Equal(t, storagePath, objects[0].Path)
+
+	require.NoError(t, store.DeleteOrderFiles(userID, orderID))
+	assert.Empty(t, fake.objects)
+}
@@ -0,0 +1,135 @@
+package supabase_test
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"instant-hdr-backend/internal/events"
+	"instant-hdr-backend/internal/repository"
+	"instant-hdr-backend/internal/supabase"
+)
+
+// notifyDriver is a database/sql driver that records the payload of every pg_notify instead of sending it
+type notifyDriver struct {
+	mu       sync.Mutex
+	payloads []string
+}
+
+var recorder = &notifyDriver{}
+
+func init() {
+	sql.Register("notify-recorder", recorder)
+}
+
+func (d *notifyDriver) Open(string) (driver.Conn, error) { return notifyConn{d}, nil }
+
+type notifyConn struct{ d *notifyDriver }
+
+func (c notifyConn) Prepare(query string) (driver.Stmt, error) { return notifyStmt(c), nil }
+func (c notifyConn) Close() error                              { return nil }
+func (c notifyConn) Begin() (driver.Tx, error)                 { return nil, errors.New("not supported") }
+
+type notifyStmt struct{ d *notifyDriver }
+
+func (s notifyStmt) Close() error  { return nil }
+func (s notifyStmt) NumInput() int { return -1 }
+
+func (s notifyStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	s.d.payloads = append(s.d.payloads, args[1].(string))
+	return driver.RowsAffected(0), nil
+}
+
+func (s notifyStmt) Query([]driver.Value) (driver.Rows, error) {
+	return nil, errors.New("not supported")
+}
+
+func (d *notifyDriver) last(t *testing.T) string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	require.NotEmpty(t, d.payloads)
+	return d.payloads[len(d.payloads)-1]
+}
+
+// recordingReceiver collects what the transport hands to the bus
+type recordingReceiver struct {
+	received []events.Event
+	lost     int
+}
+
+func (r *recordingReceiver) Receive(e events.Event) { r.received = append(r.received, e) }
+func (r *recordingReceiver) Lost()                  { r.lost++ }
+
+func TestNotifyTransport_LargeEvent(t *testing.T) {
+	db, err := sql.Open("notify-recorder", "")
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := repository.NewMemory()
+	transport := supabase.NewNotifyTransportWithDB(db, "", repo)
+	order, err := repo.CreateOrder(uuid.New(), uuid.New(), nil)
+	require.NoError(t, err)
+	stored, err := events.Encode(order.ID, "failed", &events.ProcessingFailed{Error: strings.Repeat("x", 10000)})
+	require.NoError(t, err)
+	require.NoError(t, repo.CreateOrderEvent(stored))
+	e := events.FromOrderEvent(*stored)
+
+	// Too large for a notification: only the outbox row it comes from is sent
+	require.NoError(t, transport.Send(e))
+	payload := recorder.last(t)
+	assert.Less(t, len(payload), 8000)
+	var message map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(payload), &message))
+	assert.Equal(t, stored.ID.String(), message["s"])
+	assert.NotContains(t, message, "d")
+
+	// Events without a row to load from can't be sent at all
+	assert.Error(t, transport.Send(events.Event{Channel: e.Channel, Name: e.Name, Data: e.Data}))
+
+	// Receivers load the data back, and are told when notifications may have been missed
+	notifications := make(chan *pq.Notification, 2)
+	notifications <- &pq.Notification{Extra: payload}
+	notifications <- nil
+	close(notifications)
+	receiver := &recordingReceiver{}
+	transport.Deliver(notifications, receiver)
+
+	require.Len(t, receiver.received, 1)
+	got := receiver.received[0]
+	assert.Equal(t, e.ID, got.ID)
+	assert.Equal(t, e.Channel, got.Channel)
+	assert.Equal(t, e.Source, got.Source)
+	assert.JSONEq(t, string(e.Data), string(got.Data))
+	assert.Equal(t, 1, receiver.lost)
+}
+
+func TestNotifyTransport_SmallEvent(t *testing.T) {
+	db, err := sql.Open("notify-recorder", "")
+	require.NoError(t, err)
+	defer db.Close()
+
+	transport := supabase.NewNotifyTransportWithDB(db, "", repository.NewMemory())
+	e := events.Event{Channel: events.UserChannel(uuid.New()), Name: "processing_started", Data: json.RawMessage(`{"seq":3}`), Source: uuid.New()}
+	require.NoError(t, transport.Send(e))
+
+	// The data travels with the notification; the outbox row is not read
+	notifications := make(chan *pq.Notification, 1)
+	notifications <- &pq.Notification{Extra: recorder.last(t)}
+	close(notifications)
+	receiver := &recordingReceiver{}
+	transport.Deliver(notifications, receiver)
+
+	require.Len(t, receiver.received, 1)
+	assert.Equal(t, e, receiver.received[0])
+	assert.Zero(t, receiver.lost)
+}
@@ -1,10 +1,15 @@
 package supabase_test
 
 import (
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"instant-hdr-backend/internal/supabase"
 )
 
 func TestStorageClient_GetPublicURL(t *testing.T) {
@@ -25,3 +30,33 @@ func TestStoragePathFormat(t *testing.T) {
 	assert.Contains(t, expectedPath, "projects/")
 	assert.Contains(t, expectedPath, filename)
 }
+
+func TestStorageClient_DownloadRangeIgnoredByServer(t *testing.T) {
+	// Answers every request with the whole object, as a proxy that drops Range would
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/jpeg")
+		w.Write([]byte("0123456789"))
+	}))
+	defer server.Close()
+
+	client, err := supabase.NewStorageClient(server.URL, "service-key", "images")
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		offset, length int64
+		want           string
+	}{
+		{2, 3, "234"},
+		{7, -1, "789"},
+		{0, 4, "0123"},
+		{0, -1, "0123456789"},
+	} {
+		body, obj, err := client.DownloadRange("a.jpg", tc.offset, tc.length)
+		require.NoError(t, err)
+		data, err := io.ReadAll(body)
+		body.Close()
+		require.NoError(t, err)
+		assert.Equal(t, tc.want, string(data))
+		assert.Equal(t, int64(10), obj.Size)
+	}
+}